@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/Abraxas-365/divi/pkg/ailedger/ailedgercontainer"
+	"github.com/Abraxas-365/divi/pkg/analytics/analyticscontainer"
+	"github.com/Abraxas-365/divi/pkg/audit/auditcontainer"
 	"github.com/Abraxas-365/divi/pkg/config"
 	"github.com/Abraxas-365/divi/pkg/fsx"
 	"github.com/Abraxas-365/divi/pkg/fsx/fsxlocal"
@@ -31,8 +34,11 @@ type Container struct {
 	S3Client   *s3.Client
 
 	// Bounded-context containers
+	Audit       *auditcontainer.Container
 	IAM         *iamcontainer.Container
+	AILedger    *ailedgercontainer.Container
 	DiveInspect *diveinspectcontainer.Container
+	Analytics   *analyticscontainer.Container
 	// manifesto:container-fields
 }
 
@@ -128,16 +134,32 @@ func (c *Container) initFileStorage() {
 
 func (c *Container) initModules() {
 	logx.Info("📦 Initializing modules...")
+	c.Audit = auditcontainer.New(auditcontainer.Deps{
+		DB: c.DB,
+	})
+
 	c.IAM = iamcontainer.New(iamcontainer.Deps{
 		DB:          c.DB,
 		Redis:       c.Redis,
 		Cfg:         c.Config,
 		OTPNotifier: NewConsoleNotifier(),
+		Audit:       c.Audit.Service,
+	})
+
+	c.AILedger = ailedgercontainer.New(ailedgercontainer.Deps{
+		DB: c.DB,
 	})
 
 	c.DiveInspect = diveinspectcontainer.New(diveinspectcontainer.Deps{
 		DB:         c.DB,
 		FileSystem: c.FileSystem,
+		Redis:      c.Redis,
+		Meter:      c.AILedger.Ledger,
+		Audit:      c.Audit.Service,
+	})
+
+	c.Analytics = analyticscontainer.New(analyticscontainer.Deps{
+		DB: c.DB,
 	})
 
 	// manifesto:module-init
@@ -150,6 +172,7 @@ func (c *Container) initModules() {
 func (c *Container) StartBackgroundServices(ctx context.Context) {
 	logx.Info("🔄 Starting background services...")
 	c.IAM.StartBackgroundServices(ctx)
+	c.DiveInspect.StartBackgroundServices(ctx)
 	// manifesto:background-start
 }
 
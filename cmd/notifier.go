@@ -0,0 +1,20 @@
+package main
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/divi/pkg/logx"
+)
+
+// ConsoleNotifier prints OTP codes to the log instead of delivering them.
+// Useful for local development where no email/SMS provider is configured.
+type ConsoleNotifier struct{}
+
+func NewConsoleNotifier() *ConsoleNotifier {
+	return &ConsoleNotifier{}
+}
+
+func (n *ConsoleNotifier) SendOTP(ctx context.Context, contact string, code string) error {
+	logx.Infof("📨 OTP for %s: %s", contact, code)
+	return nil
+}
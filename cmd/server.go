@@ -7,6 +7,7 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/Abraxas-365/divi/pkg/ai/llm/routerx"
 	"github.com/Abraxas-365/divi/pkg/config"
 	"github.com/Abraxas-365/divi/pkg/errx"
 	"github.com/Abraxas-365/divi/pkg/logx"
@@ -110,10 +111,10 @@ func setupMiddleware(app *fiber.App, cfg *config.Config) {
 
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     corsOrigins,
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-API-Key, X-Request-ID",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-API-Key, X-Request-ID, If-Match",
 		AllowMethods:     "GET, POST, PUT, DELETE, PATCH, HEAD, OPTIONS",
 		AllowCredentials: true,
-		ExposeHeaders:    "X-Request-ID",
+		ExposeHeaders:    "X-Request-ID, ETag",
 	}))
 
 	// Request logger
@@ -154,9 +155,21 @@ func registerRoutes(app *fiber.App, container *Container) {
 	container.IAM.InvitationHandlers.RegisterRoutes(protected, container.IAM.UnifiedAuthMiddleware)
 	logx.Info("  ✓ Invitation routes registered")
 
-	// ── DiveInspect Routes (open, no auth) ───────────────────────────────
+	// ── AI Ledger Routes ─────────────────────────────────────────────────
+	container.AILedger.Handlers.RegisterRoutes(protected, container.IAM.UnifiedAuthMiddleware)
+	logx.Info("  ✓ AI usage routes registered")
+
+	// ── Audit Routes ─────────────────────────────────────────────────────
+	container.Audit.Handlers.RegisterRoutes(protected, container.IAM.UnifiedAuthMiddleware)
+	logx.Info("  ✓ Audit log routes registered")
+
+	// ── Analytics Routes ─────────────────────────────────────────────────
+	container.Analytics.Handlers.RegisterRoutes(protected, container.IAM.UnifiedAuthMiddleware)
+	logx.Info("  ✓ Analytics routes registered")
+
+	// ── DiveInspect Routes (open, settings writes need a scope) ──────────
 	diveinspectAPI := app.Group("/api/v1")
-	container.DiveInspect.Handlers.RegisterRoutes(diveinspectAPI)
+	container.DiveInspect.Handlers.RegisterRoutes(diveinspectAPI, container.IAM.UnifiedAuthMiddleware)
 	logx.Info("  ✓ DiveInspect routes registered (open)")
 
 	// ── Module Routes (auto-injected by `manifesto add`) ─────────────────
@@ -197,6 +210,21 @@ func healthCheckHandler(container *Container) fiber.Handler {
 			health["redis"] = "healthy"
 		}
 
+		// AI providers: open breakers degrade AI features, not the service
+		if router := container.DiveInspect.AIRouter; router != nil {
+			health["ai"], health["ai_providers"] = aiRouterHealth(router)
+		}
+		if router := container.DiveInspect.AIVisionRouter; router != nil {
+			health["ai_vision"], health["ai_vision_providers"] = aiRouterHealth(router)
+		}
+
+		if cache := container.DiveInspect.LLMCache; cache != nil {
+			health["ai_cache"] = fiber.Map{
+				"llm":        cache.Stats(),
+				"embeddings": container.DiveInspect.EmbeddingCache.Stats(),
+			}
+		}
+
 		// Check storage (optional — can be slow)
 		if c.QueryBool("check_storage", false) {
 			if exists, err := container.FileSystem.Exists(c.Context(), ".health-check"); err != nil {
@@ -217,6 +245,26 @@ func healthCheckHandler(container *Container) fiber.Handler {
 	}
 }
 
+// aiRouterHealth summarizes a router's breakers: "healthy" when all are
+// closed, "unavailable" when all are open, "degraded" in between.
+func aiRouterHealth(router *routerx.Router) (string, []routerx.ProviderHealth) {
+	providers := router.Health()
+	open := 0
+	for _, p := range providers {
+		if p.State == routerx.BreakerOpen {
+			open++
+		}
+	}
+	switch open {
+	case 0:
+		return "healthy", providers
+	case len(providers):
+		return "unavailable", providers
+	default:
+		return "degraded", providers
+	}
+}
+
 func infoHandler(cfg *config.Config) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{
@@ -356,6 +404,9 @@ func printRouteSummary() {
 	logx.Info("   ├─ Passwordless: /auth/passwordless/*")
 	logx.Info("   ├─ API Keys: /api/v1/api-keys/*")
 	logx.Info("   ├─ Invitations: /api/v1/invitations/*")
+	logx.Info("   ├─ AI Usage: /api/v1/ai-usage/*")
+	logx.Info("   ├─ Audit Log: /api/v1/audit-log")
+	logx.Info("   ├─ Analytics: /api/v1/analytics/*")
 	logx.Info("   ├─ DiveInspect Vehicles: /api/v1/vehicles/*")
 	logx.Info("   ├─ DiveInspect Findings: /api/v1/findings/*")
 	logx.Info("   └─ API: /api/v1/*")
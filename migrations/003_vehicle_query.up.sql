@@ -0,0 +1,16 @@
+-- ============================================================================
+-- DiveInspect: indexes for structured vehicle filtering and sorting
+-- ============================================================================
+
+-- List filters match case-insensitively
+CREATE INDEX idx_vehicles_brand_lower ON vehicles(LOWER(brand));
+CREATE INDEX idx_vehicles_model_lower ON vehicles(LOWER(model));
+CREATE INDEX idx_vehicles_branch_lower ON vehicles(LOWER(branch));
+
+-- Range filters / sort keys (id is the keyset tiebreaker)
+CREATE INDEX idx_vehicles_mileage ON vehicles(mileage_km, id);
+CREATE INDEX idx_vehicles_price ON vehicles((COALESCE(price_usd, 0)), id);
+CREATE INDEX idx_vehicles_created_at_id ON vehicles(created_at DESC, id);
+
+CREATE INDEX idx_vehicle_specs_fuel_type_lower ON vehicle_specs(LOWER(fuel_type));
+CREATE INDEX idx_vehicle_specs_drivetrain_lower ON vehicle_specs(LOWER(drivetrain));
@@ -0,0 +1,42 @@
+-- ============================================================================
+-- DiveInspect: bulk vehicle import (CSV/XLSX)
+-- ============================================================================
+
+ALTER TABLE vehicles ADD COLUMN vin VARCHAR(17);
+
+CREATE INDEX idx_vehicles_vin_upper ON vehicles(UPPER(vin));
+CREATE INDEX idx_vehicles_plate_normalized ON vehicles(UPPER(REPLACE(REPLACE(plate, '-', ''), ' ', '')));
+
+-- ============================================================================
+-- VEHICLE IMPORT JOBS
+-- ============================================================================
+
+CREATE TABLE vehicle_import_jobs (
+    id VARCHAR(255) PRIMARY KEY DEFAULT uuid_generate_v4()::text,
+    file_name VARCHAR(500) NOT NULL,
+    file_path TEXT NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    status VARCHAR(50) NOT NULL DEFAULT 'awaiting_mapping',
+    headers TEXT[] NOT NULL DEFAULT '{}',
+    mapping JSONB,
+    enrich_created BOOLEAN NOT NULL DEFAULT FALSE,
+    total_rows INTEGER NOT NULL DEFAULT 0,
+    created_count INTEGER NOT NULL DEFAULT 0,
+    duplicate_count INTEGER NOT NULL DEFAULT 0,
+    failed_count INTEGER NOT NULL DEFAULT 0,
+    results JSONB NOT NULL DEFAULT '[]',
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+
+    CONSTRAINT chk_import_format CHECK (format IN ('csv', 'xlsx')),
+    CONSTRAINT chk_import_status CHECK (status IN ('awaiting_mapping', 'queued', 'running', 'completed', 'failed'))
+);
+
+CREATE INDEX idx_import_jobs_status_created ON vehicle_import_jobs(status, created_at);
+
+CREATE TRIGGER update_vehicle_import_jobs_updated_at BEFORE UPDATE ON vehicle_import_jobs
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE vehicle_import_jobs IS 'Bulk vehicle imports from branch spreadsheets, with per-row results';
@@ -0,0 +1,11 @@
+-- ============================================================================
+-- DiveInspect: photo-based equipment verification
+-- ============================================================================
+
+ALTER TABLE vehicle_equipment
+    ADD COLUMN verification_status VARCHAR(30) NOT NULL DEFAULT 'unverified',
+    ADD COLUMN verification_note TEXT,
+    ADD COLUMN verified_at TIMESTAMP,
+    ADD CONSTRAINT chk_equipment_verification CHECK (verification_status IN ('unverified', 'confirmed', 'possibly_missing'));
+
+CREATE INDEX idx_vehicle_equipment_confirmed ON vehicle_equipment(vehicle_id) WHERE is_confirmed;
@@ -0,0 +1,58 @@
+-- ============================================================================
+-- DiveInspect: tenant brand settings and listing variants
+-- ============================================================================
+
+-- Vehicles and imports remember the tenant that created them so background
+-- work (enrichment, listing generation) can apply that tenant's branding.
+ALTER TABLE vehicles ADD COLUMN tenant_id VARCHAR(255);
+CREATE INDEX idx_vehicles_tenant_id ON vehicles(tenant_id);
+
+ALTER TABLE vehicle_import_jobs ADD COLUMN tenant_id VARCHAR(255);
+
+ALTER TABLE generated_listings ADD COLUMN description_pt TEXT;
+
+-- ============================================================================
+-- BRAND SETTINGS
+-- ============================================================================
+
+CREATE TABLE brand_settings (
+    tenant_id VARCHAR(255) PRIMARY KEY,
+    dealer_name VARCHAR(255) NOT NULL,
+    warranty_text TEXT,
+    forbidden_claims TEXT[] NOT NULL DEFAULT '{}',
+    channel_styles JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_brand_settings_tenant FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE
+);
+
+CREATE TRIGGER update_brand_settings_updated_at BEFORE UPDATE ON brand_settings
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- ============================================================================
+-- LISTING VARIANTS (versioned per vehicle, channel and language)
+-- ============================================================================
+
+CREATE TABLE listing_variants (
+    id VARCHAR(255) PRIMARY KEY DEFAULT uuid_generate_v4()::text,
+    vehicle_id VARCHAR(255) NOT NULL,
+    channel VARCHAR(30) NOT NULL,
+    language VARCHAR(5) NOT NULL,
+    version INTEGER NOT NULL,
+    title VARCHAR(500),
+    body TEXT NOT NULL,
+    hashtags TEXT[] NOT NULL DEFAULT '{}',
+    is_active BOOLEAN NOT NULL DEFAULT FALSE,
+    generated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_listing_variants_vehicle FOREIGN KEY (vehicle_id) REFERENCES vehicles(id) ON DELETE CASCADE,
+    CONSTRAINT uq_listing_variant_version UNIQUE (vehicle_id, channel, language, version),
+    CONSTRAINT chk_listing_variant_channel CHECK (channel IN ('website', 'marketplace', 'social', 'whatsapp')),
+    CONSTRAINT chk_listing_variant_language CHECK (language IN ('es', 'en', 'pt'))
+);
+
+CREATE UNIQUE INDEX idx_listing_variants_active ON listing_variants(vehicle_id, channel, language) WHERE is_active;
+
+COMMENT ON TABLE brand_settings IS 'Per-tenant dealer branding used when generating listing copy';
+COMMENT ON TABLE listing_variants IS 'Channel- and language-specific listing copy with version history';
@@ -0,0 +1,13 @@
+-- ============================================================================
+-- DiveInspect: plausibility validation for enriched specs
+-- ============================================================================
+
+ALTER TABLE vehicle_specs
+    ADD COLUMN segment VARCHAR(30),
+    ADD COLUMN validation JSONB,
+    ADD COLUMN review_status VARCHAR(20) NOT NULL DEFAULT 'approved',
+    ADD CONSTRAINT chk_specs_review_status CHECK (review_status IN ('approved', 'needs_review'));
+
+CREATE INDEX idx_vehicle_specs_needs_review ON vehicle_specs(vehicle_id) WHERE review_status = 'needs_review';
+
+COMMENT ON COLUMN vehicle_specs.validation IS 'Guardrail report: confidence, sample agreement and fields nulled as implausible';
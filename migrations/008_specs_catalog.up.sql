@@ -0,0 +1,25 @@
+-- ============================================================================
+-- DiveInspect: curated specs catalog and per-field provenance
+-- ============================================================================
+
+CREATE TABLE specs_catalog (
+    id VARCHAR(255) PRIMARY KEY DEFAULT uuid_generate_v4()::text,
+    brand VARCHAR(100) NOT NULL,
+    model VARCHAR(100) NOT NULL,
+    version VARCHAR(255) NOT NULL DEFAULT '',
+    year INTEGER NOT NULL,
+    specs JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One entry per brand/model/version/year, matched case-insensitively
+CREATE UNIQUE INDEX idx_specs_catalog_key ON specs_catalog(LOWER(brand), LOWER(model), LOWER(version), year);
+
+CREATE TRIGGER update_specs_catalog_updated_at BEFORE UPDATE ON specs_catalog
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE vehicle_specs ADD COLUMN provenance JSONB;
+
+COMMENT ON TABLE specs_catalog IS 'Factory specs imported from the internal catalog; consulted before the LLM';
+COMMENT ON COLUMN vehicle_specs.provenance IS 'Source of each spec field, e.g. {"power_hp": "catalog", "cargo_liters": "llm"}';
@@ -0,0 +1,31 @@
+-- ============================================================================
+-- DiveInspect: per-step enrichment status
+-- ============================================================================
+
+CREATE TABLE vehicle_enrichment_steps (
+    vehicle_id VARCHAR(255) NOT NULL,
+    step VARCHAR(50) NOT NULL CHECK (step IN ('specs', 'equipment', 'listing', 'json-ld')),
+    status VARCHAR(50) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'succeeded', 'failed')),
+    error TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (vehicle_id, step),
+    CONSTRAINT fk_vehicle_enrichment_steps_vehicle FOREIGN KEY (vehicle_id) REFERENCES vehicles(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_vehicle_enrichment_steps_status ON vehicle_enrichment_steps(status) WHERE status <> 'succeeded';
+
+CREATE TRIGGER update_vehicle_enrichment_steps_updated_at BEFORE UPDATE ON vehicle_enrichment_steps
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Vehicles enriched before step tracking keep what they already have
+INSERT INTO vehicle_enrichment_steps (vehicle_id, step, status, completed_at)
+SELECT vehicle_id, 'specs', 'succeeded', enriched_at FROM vehicle_specs
+UNION ALL
+SELECT DISTINCT vehicle_id, 'equipment', 'succeeded', NULL::TIMESTAMP FROM vehicle_equipment
+UNION ALL
+SELECT vehicle_id, 'listing', 'succeeded', generated_at FROM generated_listings;
+
+COMMENT ON TABLE vehicle_enrichment_steps IS 'Last outcome of each enrichment step, so failed steps can be re-run on their own';
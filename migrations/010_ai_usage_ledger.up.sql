@@ -0,0 +1,47 @@
+-- ============================================================================
+-- AI usage ledger and per-tenant monthly budgets
+-- ============================================================================
+
+CREATE TABLE ai_usage_records (
+    id VARCHAR(255) PRIMARY KEY DEFAULT uuid_generate_v4()::text,
+    tenant_id VARCHAR(255),
+    feature VARCHAR(100) NOT NULL DEFAULT '',
+    subject_id VARCHAR(255),
+    kind VARCHAR(50) NOT NULL CHECK (kind IN ('llm', 'embedding', 'speech', 'ocr')),
+    model VARCHAR(255) NOT NULL DEFAULT '',
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    total_tokens INTEGER NOT NULL DEFAULT 0,
+    characters INTEGER NOT NULL DEFAULT 0,
+    audio_seconds NUMERIC(12, 3) NOT NULL DEFAULT 0,
+    pages INTEGER NOT NULL DEFAULT 0,
+    cost_usd NUMERIC(14, 6) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_ai_usage_records_tenant_created ON ai_usage_records(tenant_id, created_at);
+CREATE INDEX idx_ai_usage_records_created_at ON ai_usage_records(created_at);
+CREATE INDEX idx_ai_usage_records_subject_id ON ai_usage_records(subject_id) WHERE subject_id IS NOT NULL;
+
+COMMENT ON TABLE ai_usage_records IS 'One row per metered AI call, priced from the configured price table';
+COMMENT ON COLUMN ai_usage_records.subject_id IS 'Record the call was made for, e.g. a vehicle id';
+
+-- ============================================================================
+-- BUDGETS
+-- ============================================================================
+
+CREATE TABLE ai_budgets (
+    tenant_id VARCHAR(255) PRIMARY KEY,
+    monthly_limit_usd NUMERIC(12, 2) NOT NULL CHECK (monthly_limit_usd >= 0),
+    action VARCHAR(50) NOT NULL DEFAULT 'block' CHECK (action IN ('block', 'degrade')),
+    degrade_model VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_ai_budgets_tenant FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE
+);
+
+CREATE TRIGGER update_ai_budgets_updated_at BEFORE UPDATE ON ai_budgets
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE ai_budgets IS 'Monthly AI spend limit per tenant; over the limit calls are blocked or moved to a cheaper model';
@@ -0,0 +1,83 @@
+-- ============================================================================
+-- DiveInspect: domain event outbox and outbound webhooks
+-- ============================================================================
+
+-- ============================================================================
+-- DOMAIN EVENTS (transactional outbox)
+-- ============================================================================
+
+CREATE TABLE domain_events (
+    id VARCHAR(255) PRIMARY KEY DEFAULT uuid_generate_v4()::text,
+    tenant_id VARCHAR(255),
+    event_type VARCHAR(100) NOT NULL,
+    subject_id VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    dispatched_at TIMESTAMP
+);
+
+CREATE INDEX idx_domain_events_undispatched ON domain_events(created_at) WHERE dispatched_at IS NULL;
+
+COMMENT ON TABLE domain_events IS 'Outbox of state changes, written in the same transaction as the change';
+COMMENT ON COLUMN domain_events.dispatched_at IS 'When the event was fanned out to webhook deliveries';
+
+-- ============================================================================
+-- WEBHOOK ENDPOINTS
+-- ============================================================================
+
+CREATE TABLE webhook_endpoints (
+    id VARCHAR(255) PRIMARY KEY DEFAULT uuid_generate_v4()::text,
+    tenant_id VARCHAR(255) NOT NULL,
+    url TEXT NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    description TEXT,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_webhook_endpoints_tenant FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_endpoints_tenant_id ON webhook_endpoints(tenant_id) WHERE is_active;
+
+CREATE TRIGGER update_webhook_endpoints_updated_at BEFORE UPDATE ON webhook_endpoints
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON COLUMN webhook_endpoints.secret IS 'HMAC-SHA256 signing key for the X-DiveInspect-Signature header';
+COMMENT ON COLUMN webhook_endpoints.event_types IS 'Subscribed event types; empty subscribes to all';
+
+-- ============================================================================
+-- WEBHOOK DELIVERIES
+-- ============================================================================
+
+CREATE TABLE webhook_deliveries (
+    id VARCHAR(255) PRIMARY KEY DEFAULT uuid_generate_v4()::text,
+    tenant_id VARCHAR(255) NOT NULL,
+    endpoint_id VARCHAR(255) NOT NULL,
+    event_id VARCHAR(255) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_attempt_at TIMESTAMP,
+    response_status INTEGER,
+    response_body TEXT,
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_webhook_deliveries_endpoint FOREIGN KEY (endpoint_id) REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    CONSTRAINT fk_webhook_deliveries_event FOREIGN KEY (event_id) REFERENCES domain_events(id) ON DELETE CASCADE,
+    CONSTRAINT chk_webhook_delivery_status CHECK (status IN ('pending', 'succeeded', 'failed', 'dead'))
+);
+
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status IN ('pending', 'failed');
+CREATE INDEX idx_webhook_deliveries_endpoint ON webhook_deliveries(endpoint_id, created_at DESC);
+CREATE INDEX idx_webhook_deliveries_tenant ON webhook_deliveries(tenant_id, created_at DESC);
+
+CREATE TRIGGER update_webhook_deliveries_updated_at BEFORE UPDATE ON webhook_deliveries
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE webhook_deliveries IS 'Delivery log of domain events to webhook endpoints, with retry state';
+COMMENT ON COLUMN webhook_deliveries.status IS 'pending/failed are retried at next_attempt_at; dead has exhausted its retries';
@@ -0,0 +1,32 @@
+-- ============================================================================
+-- Audit trail
+-- ============================================================================
+
+-- ============================================================================
+-- AUDIT LOG (append-only)
+-- ============================================================================
+
+CREATE TABLE audit_log (
+    id VARCHAR(255) PRIMARY KEY DEFAULT uuid_generate_v4()::text,
+    tenant_id VARCHAR(255),
+    actor_type VARCHAR(20) NOT NULL,
+    actor_id VARCHAR(255),
+    actor_name VARCHAR(255),
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id VARCHAR(255) NOT NULL,
+    subject_id VARCHAR(255),
+    action VARCHAR(50) NOT NULL,
+    changes JSONB NOT NULL DEFAULT '[]',
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_audit_log_actor_type CHECK (actor_type IN ('user', 'api_key', 'system'))
+);
+
+CREATE INDEX idx_audit_log_tenant ON audit_log(tenant_id, created_at DESC);
+CREATE INDEX idx_audit_log_subject ON audit_log(subject_id, created_at DESC) WHERE subject_id IS NOT NULL;
+CREATE INDEX idx_audit_log_entity ON audit_log(entity_type, entity_id);
+
+COMMENT ON TABLE audit_log IS 'Append-only record of changes and auth events; rows are never updated or deleted';
+COMMENT ON COLUMN audit_log.changes IS 'Field-level diff: [{field, before, after}]';
+COMMENT ON COLUMN audit_log.subject_id IS 'Parent the entry belongs to, e.g. the vehicle of a finding';
@@ -0,0 +1,12 @@
+-- ============================================================================
+-- DiveInspect: revisions for optimistic concurrency on PATCH
+-- ============================================================================
+
+-- vehicles.version already holds the model version, so the counter is "revision"
+ALTER TABLE vehicles ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE vehicle_specs ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE inspection_findings ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN vehicles.revision IS 'Bumped on every update; exposed as the ETag and checked against If-Match';
+COMMENT ON COLUMN vehicle_specs.revision IS 'Bumped on every update; exposed as the ETag and checked against If-Match';
+COMMENT ON COLUMN inspection_findings.revision IS 'Bumped on every update; exposed as the ETag and checked against If-Match';
@@ -0,0 +1,8 @@
+-- ============================================================================
+-- DiveInspect: optimistic concurrency for inspections
+-- ============================================================================
+
+ALTER TABLE inspections ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN inspections.revision IS 'Bumped on every update; a write at a stale revision fails with a conflict';
+COMMENT ON COLUMN inspections.photos_count IS 'Maintained by atomic increments, never by full-row updates';
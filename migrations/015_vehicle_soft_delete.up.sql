@@ -0,0 +1,9 @@
+-- ============================================================================
+-- DiveInspect: soft delete and retention for vehicles
+-- ============================================================================
+
+ALTER TABLE vehicles ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX idx_vehicles_deleted_at ON vehicles(deleted_at) WHERE deleted_at IS NOT NULL;
+
+COMMENT ON COLUMN vehicles.deleted_at IS 'Set when the vehicle is deleted; the retention job hard-deletes it and its storage after the retention period';
@@ -0,0 +1,9 @@
+-- ============================================================================
+-- DiveInspect: leases for running vehicle imports
+-- ============================================================================
+
+ALTER TABLE vehicle_import_jobs ADD COLUMN locked_until TIMESTAMP;
+
+CREATE INDEX idx_import_jobs_running_lease ON vehicle_import_jobs(locked_until) WHERE status = 'running';
+
+COMMENT ON COLUMN vehicle_import_jobs.locked_until IS 'Lease of the worker running the job, extended while it runs; once it passes, another worker claims the job and resumes it';
@@ -0,0 +1,141 @@
+// Package aicache caches LLM responses and embeddings so identical calls are
+// answered without reaching the provider. CachedLLM and CachedEmbedder wrap
+// any llm.LLM or embedding.Embedder; entries live in a Store, in memory or in
+// Redis, and expire after a TTL.
+//
+// Keys are hashes of everything that shapes the answer: the messages and
+// chat options, or the text, model and dimensions. Calls made with
+// llm.WithoutCache or embedding.WithoutCache bypass the cache. Cache
+// failures are counted in the stats and never fail a call.
+package aicache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTTL is how long entries are kept unless configured otherwise
+const DefaultTTL = 24 * time.Hour
+
+// Store holds cache entries.
+type Store interface {
+	// Get returns the value under key and whether there was one
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Set stores value under key for ttl; zero keeps it until evicted
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// ============================================================================
+// Options
+// ============================================================================
+
+type cacheConfig struct {
+	ttl       time.Duration
+	namespace string
+}
+
+// CacheOption configures a cached LLM or embedder
+type CacheOption func(*cacheConfig)
+
+// WithTTL sets how long entries are kept
+func WithTTL(ttl time.Duration) CacheOption {
+	return func(c *cacheConfig) {
+		c.ttl = ttl
+	}
+}
+
+// WithNamespace prefixes every key, keeping apart the entries of caches
+// that share a store, e.g. those of different providers
+func WithNamespace(namespace string) CacheOption {
+	return func(c *cacheConfig) {
+		c.namespace = namespace
+	}
+}
+
+func newConfig(opts []CacheOption) cacheConfig {
+	cfg := cacheConfig{ttl: DefaultTTL}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// key hashes the parts into a key under kind and the namespace.
+func (c cacheConfig) key(kind string, parts ...any) (string, error) {
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	for _, part := range parts {
+		if err := enc.Encode(part); err != nil {
+			return "", err
+		}
+	}
+
+	key := kind + ":" + hex.EncodeToString(h.Sum(nil))
+	if c.namespace != "" {
+		key = c.namespace + ":" + key
+	}
+	return key, nil
+}
+
+// ============================================================================
+// Stats
+// ============================================================================
+
+// Stats counts cache lookups
+type Stats struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	Errors  int64   `json:"errors"`
+	HitRate float64 `json:"hit_rate"`
+}
+
+type counters struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+	errors atomic.Int64
+}
+
+func (c *counters) snapshot() Stats {
+	stats := Stats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+		Errors: c.errors.Load(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// lookup reads and decodes the entry under key into v, counting the
+// outcome. Unreadable entries count as errors and misses.
+func lookup(ctx context.Context, store Store, stats *counters, key string, v any) bool {
+	data, ok, err := store.Get(ctx, key)
+	if err == nil && ok {
+		if err = json.Unmarshal(data, v); err == nil {
+			stats.hits.Add(1)
+			return true
+		}
+	}
+	if err != nil {
+		stats.errors.Add(1)
+	}
+	stats.misses.Add(1)
+	return false
+}
+
+// save encodes and stores v under key.
+func save(ctx context.Context, store Store, stats *counters, key string, v any, ttl time.Duration) {
+	data, err := json.Marshal(v)
+	if err == nil {
+		err = store.Set(ctx, key, data, ttl)
+	}
+	if err != nil {
+		stats.errors.Add(1)
+	}
+}
@@ -0,0 +1,321 @@
+package aicache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/ai/embedding"
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+)
+
+// countingLLM answers with the number of calls it has served
+type countingLLM struct {
+	calls int
+	err   error
+}
+
+func (l *countingLLM) Chat(_ context.Context, _ []llm.Message, _ ...llm.Option) (llm.Response, error) {
+	l.calls++
+	if l.err != nil {
+		return llm.Response{}, l.err
+	}
+	return llm.Response{
+		Message: llm.NewAssistantMessage(strings.Repeat("x", l.calls)),
+		Usage:   llm.Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5},
+		Model:   "gpt-4o-mini",
+	}, nil
+}
+
+func (l *countingLLM) ChatStream(_ context.Context, _ []llm.Message, _ ...llm.Option) (llm.Stream, error) {
+	l.calls++
+	return &replayStream{chunks: []llm.Message{
+		{Role: llm.RoleAssistant, Content: "Hel"},
+		{Role: llm.RoleAssistant, Content: "lo", ToolCalls: []llm.ToolCall{{ID: "c1", Type: "function", Function: llm.FunctionCall{Name: "f", Arguments: "{}"}}}},
+	}}, nil
+}
+
+// countingEmbedder embeds a text as its length and records what it was sent
+type countingEmbedder struct {
+	batches [][]string
+}
+
+func (e *countingEmbedder) EmbedDocuments(_ context.Context, documents []string, _ ...embedding.Option) ([]embedding.Embedding, error) {
+	e.batches = append(e.batches, documents)
+	result := make([]embedding.Embedding, len(documents))
+	for i, doc := range documents {
+		result[i] = embedding.Embedding{Vector: []float32{float32(len(doc))}, Usage: embedding.Usage{PromptTokens: 7, TotalTokens: 7}}
+	}
+	return result, nil
+}
+
+func (e *countingEmbedder) EmbedQuery(ctx context.Context, text string, opts ...embedding.Option) (embedding.Embedding, error) {
+	result, err := e.EmbedDocuments(ctx, []string{text}, opts...)
+	if err != nil {
+		return embedding.Embedding{}, err
+	}
+	return result[0], nil
+}
+
+// failingStore fails every operation
+type failingStore struct{}
+
+func (failingStore) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errors.New("store down")
+}
+
+func (failingStore) Set(context.Context, string, []byte, time.Duration) error {
+	return errors.New("store down")
+}
+
+var question = []llm.Message{llm.NewSystemMessage("Be terse."), llm.NewUserMessage("Specs of a 2021 Corolla?")}
+
+func TestChatServesRepeatsFromCache(t *testing.T) {
+	inner := &countingLLM{}
+	c := NewCachedLLM(inner, NewMemoryStore(0))
+	ctx := context.Background()
+
+	first, err := c.Chat(ctx, question, llm.WithTemperature(0.2))
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	second, err := c.Chat(ctx, question, llm.WithTemperature(0.2))
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	if inner.calls != 1 {
+		t.Fatalf("provider calls = %d, want 1", inner.calls)
+	}
+	if first.Cached || !second.Cached || second.Message.Content != first.Message.Content || second.Model != "gpt-4o-mini" {
+		t.Fatalf("responses = %+v / %+v, want the second replayed", first, second)
+	}
+	if second.Usage != (llm.Usage{}) {
+		t.Fatalf("cached usage = %+v, want none", second.Usage)
+	}
+	if stats := c.Stats(); stats.Hits != 1 || stats.Misses != 1 || stats.HitRate != 0.5 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
+
+func TestChatKeysOnMessagesAndOptions(t *testing.T) {
+	inner := &countingLLM{}
+	c := NewCachedLLM(inner, NewMemoryStore(0))
+	ctx := context.Background()
+
+	c.Chat(ctx, question)
+	c.Chat(ctx, question, llm.WithTemperature(0.1))
+	c.Chat(ctx, question, llm.WithModel("gpt-4o"))
+	c.Chat(ctx, append(slices.Clone(question), llm.NewUserMessage("And 2022?")))
+	if inner.calls != 4 {
+		t.Fatalf("provider calls = %d, want every variant to miss", inner.calls)
+	}
+
+	// The end-user ID doesn't change the answer
+	c.Chat(ctx, question, llm.WithUser("u-42"))
+	if inner.calls != 4 {
+		t.Fatalf("provider calls = %d, want the user ignored", inner.calls)
+	}
+}
+
+func TestChatWithoutCacheBypasses(t *testing.T) {
+	inner := &countingLLM{}
+	store := NewMemoryStore(0)
+	c := NewCachedLLM(inner, store)
+	ctx := context.Background()
+
+	c.Chat(ctx, question, llm.WithoutCache())
+	c.Chat(ctx, question, llm.WithoutCache())
+	if inner.calls != 2 || store.Len() != 0 {
+		t.Fatalf("calls = %d, entries = %d, want no caching", inner.calls, store.Len())
+	}
+	if stats := c.Stats(); stats.Hits+stats.Misses != 0 {
+		t.Fatalf("stats = %+v, want bypassed calls uncounted", stats)
+	}
+}
+
+func TestChatDoesNotCacheErrors(t *testing.T) {
+	inner := &countingLLM{err: errors.New("boom")}
+	store := NewMemoryStore(0)
+	c := NewCachedLLM(inner, store)
+
+	if _, err := c.Chat(context.Background(), question); err == nil {
+		t.Fatal("Chat: want the provider error")
+	}
+	if store.Len() != 0 {
+		t.Fatal("stored a failed call")
+	}
+}
+
+func TestChatSurvivesStoreFailures(t *testing.T) {
+	inner := &countingLLM{}
+	c := NewCachedLLM(inner, failingStore{})
+
+	if _, err := c.Chat(context.Background(), question); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if stats := c.Stats(); stats.Errors != 2 || stats.Misses != 1 {
+		t.Fatalf("stats = %+v, want the failed get and set counted", stats)
+	}
+}
+
+func TestChatNamespacesKeepCachesApart(t *testing.T) {
+	store := NewMemoryStore(0)
+	openai, anthropic := &countingLLM{}, &countingLLM{}
+	NewCachedLLM(openai, store, WithNamespace("openai")).Chat(context.Background(), question)
+	NewCachedLLM(anthropic, store, WithNamespace("anthropic")).Chat(context.Background(), question)
+
+	if anthropic.calls != 1 {
+		t.Fatal("namespaced cache served another namespace's entry")
+	}
+}
+
+// ============================================================================
+// Streaming
+// ============================================================================
+
+func TestChatStreamReplaysFromCache(t *testing.T) {
+	inner := &countingLLM{}
+	c := NewCachedLLM(inner, NewMemoryStore(0))
+
+	first := readStream(t, c)
+	second := readStream(t, c)
+
+	if inner.calls != 1 {
+		t.Fatalf("provider calls = %d, want the second stream replayed", inner.calls)
+	}
+	if len(second) != 2 || second[0].Content != "Hel" || second[1].Content != "lo" ||
+		len(second[1].ToolCalls) != 1 || second[1].ToolCalls[0].ID != "c1" {
+		t.Fatalf("replayed chunks = %+v, want %+v", second, first)
+	}
+
+	// A stream doesn't answer a plain chat call
+	c.Chat(context.Background(), question)
+	if inner.calls != 2 {
+		t.Fatalf("provider calls = %d", inner.calls)
+	}
+}
+
+func TestChatStreamClosedEarlyIsNotStored(t *testing.T) {
+	inner := &countingLLM{}
+	store := NewMemoryStore(0)
+	c := NewCachedLLM(inner, store)
+
+	stream, err := c.ChatStream(context.Background(), question)
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	stream.Next()
+	stream.Close()
+
+	if store.Len() != 0 {
+		t.Fatal("stored a partial stream")
+	}
+}
+
+func readStream(t *testing.T, c *CachedLLM) []llm.Message {
+	t.Helper()
+	stream, err := c.ChatStream(context.Background(), question)
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	defer stream.Close()
+
+	var chunks []llm.Message
+	for {
+		msg, err := stream.Next()
+		if errors.Is(err, io.EOF) {
+			return chunks
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		chunks = append(chunks, msg)
+	}
+}
+
+// ============================================================================
+// Embeddings
+// ============================================================================
+
+func TestEmbedDocumentsOnlySendsMisses(t *testing.T) {
+	inner := &countingEmbedder{}
+	c := NewCachedEmbedder(inner, NewMemoryStore(0))
+	ctx := context.Background()
+
+	if _, err := c.EmbedDocuments(ctx, []string{"a", "bb"}); err != nil {
+		t.Fatalf("EmbedDocuments: %v", err)
+	}
+	result, err := c.EmbedDocuments(ctx, []string{"bb", "ccc", "a"})
+	if err != nil {
+		t.Fatalf("EmbedDocuments: %v", err)
+	}
+
+	if len(inner.batches) != 2 || !slices.Equal(inner.batches[1], []string{"ccc"}) {
+		t.Fatalf("batches = %v, want only the new text re-sent", inner.batches)
+	}
+	for i, want := range []float32{2, 3, 1} {
+		if !slices.Equal(result[i].Vector, []float32{want}) || result[i].Usage.TotalTokens != 7 {
+			t.Fatalf("result[%d] = %+v", i, result[i])
+		}
+	}
+
+	// All hits: nothing sent, no usage
+	result, _ = c.EmbedDocuments(ctx, []string{"a", "ccc"})
+	if len(inner.batches) != 2 || result[0].Usage.TotalTokens != 0 {
+		t.Fatalf("batches = %v, usage = %+v", inner.batches, result[0].Usage)
+	}
+	if stats := c.Stats(); stats.Hits != 4 || stats.Misses != 3 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
+
+func TestEmbedQueryKeysOnModel(t *testing.T) {
+	inner := &countingEmbedder{}
+	c := NewCachedEmbedder(inner, NewMemoryStore(0))
+	ctx := context.Background()
+
+	c.EmbedQuery(ctx, "sedan", embedding.WithModel("text-embedding-3-small"))
+	c.EmbedQuery(ctx, "sedan", embedding.WithModel("text-embedding-3-small"))
+	c.EmbedQuery(ctx, "sedan", embedding.WithModel("text-embedding-3-large"))
+	c.EmbedQuery(ctx, "sedan", embedding.WithModel("text-embedding-3-large"), embedding.WithoutCache())
+
+	if len(inner.batches) != 3 {
+		t.Fatalf("provider calls = %d, want 3", len(inner.batches))
+	}
+}
+
+// ============================================================================
+// Memory Store
+// ============================================================================
+
+func TestMemoryStoreExpiresAndEvicts(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore(2)
+	store.now = func() time.Time { return now }
+
+	store.Set(ctx, "a", []byte("1"), time.Minute)
+	store.Set(ctx, "b", []byte("2"), 0)
+	store.Get(ctx, "a") // a is now the most recently used
+	store.Set(ctx, "c", []byte("3"), 0)
+
+	if _, ok, _ := store.Get(ctx, "b"); ok {
+		t.Fatal("b survived eviction")
+	}
+	if v, ok, _ := store.Get(ctx, "a"); !ok || string(v) != "1" {
+		t.Fatalf("a = %q, %v", v, ok)
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok, _ := store.Get(ctx, "a"); ok {
+		t.Fatal("a outlived its TTL")
+	}
+	if _, ok, _ := store.Get(ctx, "c"); !ok {
+		t.Fatal("c without TTL expired")
+	}
+}
@@ -0,0 +1,109 @@
+package aicache
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/divi/pkg/ai/embedding"
+)
+
+var _ embedding.Embedder = (*CachedEmbedder)(nil)
+
+// CachedEmbedder serves embeddings of texts it has seen from a Store. Each
+// text is cached on its own, so a batch only sends the texts that missed.
+type CachedEmbedder struct {
+	embedder embedding.Embedder
+	store    Store
+	cfg      cacheConfig
+	stats    counters
+}
+
+// NewCachedEmbedder wraps embedder with a cache in store
+func NewCachedEmbedder(embedder embedding.Embedder, store Store, opts ...CacheOption) *CachedEmbedder {
+	return &CachedEmbedder{embedder: embedder, store: store, cfg: newConfig(opts)}
+}
+
+// Stats reports the cache's hits and misses, one lookup per text
+func (c *CachedEmbedder) Stats() Stats {
+	return c.stats.snapshot()
+}
+
+// EmbedDocuments implements the Embedder interface. Every returned
+// embedding carries the usage of the provider call for the misses, zero
+// when all texts hit, as providers report batch usage on each embedding.
+func (c *CachedEmbedder) EmbedDocuments(ctx context.Context, documents []string, opts ...embedding.Option) ([]embedding.Embedding, error) {
+	options := resolveEmbeddingOptions(opts)
+	if options.NoCache {
+		return c.embedder.EmbedDocuments(ctx, documents, opts...)
+	}
+
+	results := make([]embedding.Embedding, len(documents))
+	keys := make([]string, len(documents))
+	var missTexts []string
+	var missIndexes []int
+	for i, doc := range documents {
+		key, err := c.cfg.key("embedding", doc, options.Model, options.Dimensions)
+		if err == nil && lookup(ctx, c.store, &c.stats, key, &results[i].Vector) {
+			continue
+		}
+		keys[i] = key
+		missTexts = append(missTexts, doc)
+		missIndexes = append(missIndexes, i)
+	}
+
+	var usage embedding.Usage
+	if len(missTexts) > 0 {
+		embeddings, err := c.embedder.EmbedDocuments(ctx, missTexts, opts...)
+		if err != nil {
+			return nil, err
+		}
+		for j, emb := range embeddings {
+			if j >= len(missIndexes) {
+				break
+			}
+			i := missIndexes[j]
+			results[i].Vector = emb.Vector
+			usage = emb.Usage
+			if keys[i] != "" {
+				save(ctx, c.store, &c.stats, keys[i], emb.Vector, c.cfg.ttl)
+			}
+		}
+	}
+
+	for i := range results {
+		results[i].Usage = usage
+	}
+	return results, nil
+}
+
+// EmbedQuery implements the Embedder interface. Hits have no usage.
+func (c *CachedEmbedder) EmbedQuery(ctx context.Context, text string, opts ...embedding.Option) (embedding.Embedding, error) {
+	options := resolveEmbeddingOptions(opts)
+	if options.NoCache {
+		return c.embedder.EmbedQuery(ctx, text, opts...)
+	}
+
+	key, err := c.cfg.key("embedding", text, options.Model, options.Dimensions)
+	if err != nil {
+		return c.embedder.EmbedQuery(ctx, text, opts...)
+	}
+
+	var vector []float32
+	if lookup(ctx, c.store, &c.stats, key, &vector) {
+		return embedding.Embedding{Vector: vector}, nil
+	}
+
+	emb, err := c.embedder.EmbedQuery(ctx, text, opts...)
+	if err != nil {
+		return emb, err
+	}
+	save(ctx, c.store, &c.stats, key, emb.Vector, c.cfg.ttl)
+	return emb, nil
+}
+
+func resolveEmbeddingOptions(opts []embedding.Option) *embedding.EmbeddingOptions {
+	options := embedding.DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
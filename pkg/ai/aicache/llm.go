@@ -0,0 +1,143 @@
+package aicache
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+)
+
+var _ llm.LLM = (*CachedLLM)(nil)
+
+// CachedLLM answers repeated chat calls from a Store. Streams are recorded
+// as they are read and replayed chunk by chunk.
+type CachedLLM struct {
+	llm   llm.LLM
+	store Store
+	cfg   cacheConfig
+	stats counters
+}
+
+// NewCachedLLM wraps l with a response cache in store
+func NewCachedLLM(l llm.LLM, store Store, opts ...CacheOption) *CachedLLM {
+	return &CachedLLM{llm: l, store: store, cfg: newConfig(opts)}
+}
+
+// Stats reports the cache's hits and misses
+func (c *CachedLLM) Stats() Stats {
+	return c.stats.snapshot()
+}
+
+// Chat implements the LLM interface. Hits come back with Cached set and no
+// usage, since no tokens were spent.
+func (c *CachedLLM) Chat(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Response, error) {
+	key, ok := c.key("chat", messages, opts)
+	if !ok {
+		return c.llm.Chat(ctx, messages, opts...)
+	}
+
+	var cached llm.Response
+	if lookup(ctx, c.store, &c.stats, key, &cached) {
+		cached.Usage = llm.Usage{}
+		cached.Cached = true
+		return cached, nil
+	}
+
+	resp, err := c.llm.Chat(ctx, messages, opts...)
+	if err != nil {
+		return resp, err
+	}
+	save(ctx, c.store, &c.stats, key, resp, c.cfg.ttl)
+	return resp, nil
+}
+
+// ChatStream implements the LLM interface. A stream is stored once it has
+// been read to the end; streams closed early or failing are not.
+func (c *CachedLLM) ChatStream(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Stream, error) {
+	key, ok := c.key("stream", messages, opts)
+	if !ok {
+		return c.llm.ChatStream(ctx, messages, opts...)
+	}
+
+	var chunks []llm.Message
+	if lookup(ctx, c.store, &c.stats, key, &chunks) {
+		return &replayStream{chunks: chunks}, nil
+	}
+
+	stream, err := c.llm.ChatStream(ctx, messages, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingStream{Stream: stream, ctx: ctx, cache: c, key: key}, nil
+}
+
+// key hashes the call, reporting false when it must not be cached. The
+// stream flag and end-user ID don't change the answer and are left out.
+func (c *CachedLLM) key(kind string, messages []llm.Message, opts []llm.Option) (string, bool) {
+	options := llm.DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.NoCache {
+		return "", false
+	}
+
+	options.Stream = false
+	options.User = ""
+	key, err := c.cfg.key("llm:"+kind, messages, options)
+	if err != nil {
+		c.stats.errors.Add(1)
+		return "", false
+	}
+	return key, true
+}
+
+// recordingStream keeps the chunks it passes on and stores them at io.EOF
+type recordingStream struct {
+	llm.Stream
+	ctx    context.Context
+	cache  *CachedLLM
+	key    string
+	chunks []llm.Message
+	done   bool
+}
+
+func (s *recordingStream) Next() (llm.Message, error) {
+	msg, err := s.Stream.Next()
+	switch {
+	case err == nil:
+		s.chunks = append(s.chunks, msg)
+	case errors.Is(err, io.EOF) && !s.done:
+		s.done = true
+		save(s.ctx, s.cache.store, &s.cache.stats, s.key, s.chunks, s.cache.cfg.ttl)
+	}
+	return msg, err
+}
+
+func (s *recordingStream) Usage() (llm.Usage, bool) {
+	return llm.StreamUsage(s.Stream)
+}
+
+// replayStream yields stored chunks
+type replayStream struct {
+	chunks []llm.Message
+}
+
+func (s *replayStream) Next() (llm.Message, error) {
+	if len(s.chunks) == 0 {
+		return llm.Message{}, io.EOF
+	}
+	msg := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return msg, nil
+}
+
+func (s *replayStream) Close() error {
+	return nil
+}
+
+// Usage is zero: a replay costs nothing
+func (s *replayStream) Usage() (llm.Usage, bool) {
+	return llm.Usage{}, true
+}
@@ -0,0 +1,241 @@
+// Package ailimit keeps calls to AI providers under the provider's rate
+// limits instead of letting them fail with 429s. A Limiter enforces requests
+// per minute and estimated tokens per minute with token buckets, plus a cap
+// on calls in flight; LimitedLLM, LimitedEmbedder and LimitedRecognizer put
+// one in front of a provider.
+//
+// Calls over the limit wait their turn until the context deadline. A call
+// whose wait would outlast its deadline fails at once with ErrRateLimited.
+// Limiter state lives in a Store: in memory for one instance, or in Redis
+// for limits shared by every instance using the same provider account.
+package ailimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Limits are the budgets a Limiter enforces; zero disables a limit.
+type Limits struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+	MaxConcurrent     int
+}
+
+// Store holds limiter state.
+type Store interface {
+	// Take takes n tokens from the bucket under key, which holds a minute's
+	// worth and refills at perMinute. When it is short it takes nothing and
+	// returns how long until n tokens are available.
+	Take(ctx context.Context, key string, n, perMinute float64) (time.Duration, error)
+
+	// Spend takes n tokens without waiting, leaving the bucket in debt if
+	// need be; negative n returns tokens.
+	Spend(ctx context.Context, key string, n, perMinute float64) error
+
+	// Acquire takes one of limit slots under key as lease. Leases expire
+	// after ttl, so slots held by a crashed instance come back.
+	Acquire(ctx context.Context, key, lease string, limit int, ttl time.Duration) (bool, error)
+
+	// Release gives a slot back
+	Release(ctx context.Context, key, lease string) error
+}
+
+// Limiter admits calls to one provider within its limits. Store failures
+// let calls through: the provider's own limits still apply.
+type Limiter struct {
+	store  Store
+	name   string
+	limits Limits
+
+	pollInterval time.Duration
+	leaseTTL     time.Duration
+	sleep        func(ctx context.Context, d time.Duration) error
+	newLease     func() string
+}
+
+// LimiterOption configures a Limiter
+type LimiterOption func(*Limiter)
+
+// WithPollInterval sets how often a call waiting for a concurrency slot
+// checks for one
+func WithPollInterval(interval time.Duration) LimiterOption {
+	return func(l *Limiter) {
+		if interval > 0 {
+			l.pollInterval = interval
+		}
+	}
+}
+
+// WithLeaseTTL sets how long a concurrency slot is held at most, for calls
+// whose instance dies before releasing it
+func WithLeaseTTL(ttl time.Duration) LimiterOption {
+	return func(l *Limiter) {
+		if ttl > 0 {
+			l.leaseTTL = ttl
+		}
+	}
+}
+
+// NewLimiter creates a limiter. Limiters sharing a store and name share
+// their limits.
+func NewLimiter(store Store, name string, limits Limits, opts ...LimiterOption) *Limiter {
+	l := &Limiter{
+		store:        store,
+		name:         name,
+		limits:       limits,
+		pollInterval: 50 * time.Millisecond,
+		leaseTTL:     10 * time.Minute,
+		sleep:        sleep,
+		newLease:     uuid.NewString,
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// Limits returns the limits enforced
+func (l *Limiter) Limits() Limits {
+	return l.limits
+}
+
+// Wait blocks until a call estimated at tokens may go ahead. The permit
+// must be finished when the call is done.
+func (l *Limiter) Wait(ctx context.Context, tokens int) (*Permit, error) {
+	p := &Permit{limiter: l}
+
+	if rpm := l.limits.RequestsPerMinute; rpm > 0 {
+		if err := l.take(ctx, "rpm", 1, rpm); err != nil {
+			return nil, err
+		}
+		p.requests = 1
+	}
+
+	if tpm := l.limits.TokensPerMinute; tpm > 0 && tokens > 0 {
+		// A call larger than the whole budget waits for a full bucket
+		n := min(tokens, tpm)
+		if err := l.take(ctx, "tpm", n, tpm); err != nil {
+			p.refund()
+			return nil, err
+		}
+		p.tokens = n
+	}
+
+	if l.limits.MaxConcurrent > 0 {
+		lease, err := l.acquire(ctx)
+		if err != nil {
+			p.refund()
+			return nil, err
+		}
+		p.lease = lease
+	}
+
+	return p, nil
+}
+
+// take waits for n tokens from the named bucket.
+func (l *Limiter) take(ctx context.Context, bucket string, n, perMinute int) error {
+	key := l.name + ":" + bucket
+	for {
+		wait, err := l.store.Take(ctx, key, float64(n), float64(perMinute))
+		if err != nil || wait <= 0 {
+			return nil
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return errorRegistry.New(ErrRateLimited).
+				WithDetail("limiter", l.name).
+				WithDetail("limit", bucket).
+				WithDetail("wait", wait.String())
+		}
+		if err := l.sleep(ctx, wait); err != nil {
+			return errorRegistry.NewWithCause(ErrRateLimited, err).
+				WithDetail("limiter", l.name).
+				WithDetail("limit", bucket)
+		}
+	}
+}
+
+// acquire polls for a concurrency slot.
+func (l *Limiter) acquire(ctx context.Context) (string, error) {
+	key := l.name + ":concurrency"
+	lease := l.newLease()
+	for {
+		ok, err := l.store.Acquire(ctx, key, lease, l.limits.MaxConcurrent, l.leaseTTL)
+		if err != nil {
+			return "", nil
+		}
+		if ok {
+			return lease, nil
+		}
+		if err := l.sleep(ctx, l.pollInterval); err != nil {
+			return "", errorRegistry.NewWithCause(ErrRateLimited, err).
+				WithDetail("limiter", l.name).
+				WithDetail("limit", "concurrency")
+		}
+	}
+}
+
+// Permit is an admitted call
+type Permit struct {
+	limiter  *Limiter
+	requests int
+	tokens   int
+	lease    string
+	once     sync.Once
+}
+
+// Done frees the call's concurrency slot. When the provider reported the
+// tokens the call used, the token bucket is corrected by the difference
+// from the estimate; zero keeps the estimate.
+func (p *Permit) Done(ctx context.Context, usedTokens int) {
+	p.once.Do(func() {
+		l := p.limiter
+		ctx := context.WithoutCancel(ctx)
+		if tpm := l.limits.TokensPerMinute; tpm > 0 && usedTokens > 0 && usedTokens != p.tokens {
+			l.store.Spend(ctx, l.name+":tpm", float64(usedTokens-p.tokens), float64(tpm))
+		}
+		if p.lease != "" {
+			l.store.Release(ctx, l.name+":concurrency", p.lease)
+		}
+	})
+}
+
+// refund returns what a call that was never made took.
+func (p *Permit) refund() {
+	l := p.limiter
+	ctx := context.Background()
+	if p.requests > 0 {
+		l.store.Spend(ctx, l.name+":rpm", -float64(p.requests), float64(l.limits.RequestsPerMinute))
+	}
+	if p.tokens > 0 {
+		l.store.Spend(ctx, l.name+":tpm", -float64(p.tokens), float64(l.limits.TokensPerMinute))
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// ============================================================================
+// Token Estimates
+// ============================================================================
+
+// estimateTokens approximates the tokens in text at four characters each,
+// the usual ratio for English with OpenAI tokenizers.
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
@@ -0,0 +1,285 @@
+package ailimit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/ai/embedding"
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+	"github.com/Abraxas-365/divi/pkg/ai/ocr"
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+// fakeTime drives the memory store's clock; sleeping advances it
+type fakeTime struct {
+	mu    sync.Mutex
+	now   time.Time
+	slept []time.Duration
+}
+
+func (f *fakeTime) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeTime) Sleep(ctx context.Context, d time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.slept = append(f.slept, d)
+	f.now = f.now.Add(d)
+	return ctx.Err()
+}
+
+// newFakeLimiter returns a limiter on a memory store whose waits pass
+// instantly on a fake clock
+func newFakeLimiter(limits Limits) (*Limiter, *MemoryStore, *fakeTime) {
+	clock := &fakeTime{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.now = clock.Now
+	limiter := NewLimiter(store, "openai", limits)
+	limiter.sleep = clock.Sleep
+	return limiter, store, clock
+}
+
+// stubLLM reports a fixed usage and tracks calls in flight
+type stubLLM struct {
+	mu       sync.Mutex
+	calls    int
+	inFlight int
+	peak     int
+	usage    int
+	hold     time.Duration
+}
+
+func (s *stubLLM) Chat(_ context.Context, _ []llm.Message, _ ...llm.Option) (llm.Response, error) {
+	s.mu.Lock()
+	s.calls++
+	s.inFlight++
+	s.peak = max(s.peak, s.inFlight)
+	s.mu.Unlock()
+
+	time.Sleep(s.hold)
+
+	s.mu.Lock()
+	s.inFlight--
+	s.mu.Unlock()
+	return llm.Response{Usage: llm.Usage{TotalTokens: s.usage}}, nil
+}
+
+func (s *stubLLM) ChatStream(_ context.Context, _ []llm.Message, _ ...llm.Option) (llm.Stream, error) {
+	s.mu.Lock()
+	s.calls++
+	s.mu.Unlock()
+	return &emptyStream{}, nil
+}
+
+type emptyStream struct{}
+
+func (emptyStream) Next() (llm.Message, error) { return llm.Message{}, errors.New("done") }
+func (emptyStream) Close() error               { return nil }
+
+var hello = []llm.Message{llm.NewUserMessage("hello")}
+
+func TestRequestsPerMinuteQueue(t *testing.T) {
+	limiter, _, clock := newFakeLimiter(Limits{RequestsPerMinute: 2})
+	inner := &stubLLM{}
+	l := NewLimitedLLM(inner, limiter)
+
+	for range 3 {
+		if _, err := l.Chat(context.Background(), hello); err != nil {
+			t.Fatalf("Chat: %v", err)
+		}
+	}
+
+	if inner.calls != 3 {
+		t.Fatalf("calls = %d", inner.calls)
+	}
+	if len(clock.slept) != 1 || clock.slept[0] != 30*time.Second {
+		t.Fatalf("waits = %v, want the third call to wait for one request's refill", clock.slept)
+	}
+}
+
+func TestWaitFailsWhenDeadlineIsTooClose(t *testing.T) {
+	limiter, store, _ := newFakeLimiter(Limits{RequestsPerMinute: 10, TokensPerMinute: 100})
+	inner := &stubLLM{}
+	l := NewLimitedLLM(inner, limiter)
+
+	// Drain the token bucket
+	store.Spend(context.Background(), "openai:tpm", 100, 100)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := l.Chat(ctx, hello, llm.WithMaxTokens(50))
+
+	var e *errx.Error
+	if !errors.As(err, &e) || e.Code != ErrRateLimited.Code || e.Details["limit"] != "tpm" {
+		t.Fatalf("error = %v, want the token limit reported", err)
+	}
+	if inner.calls != 0 {
+		t.Fatal("the call went ahead")
+	}
+
+	// The request slot taken before the token check was given back
+	if wait, _ := store.Take(context.Background(), "openai:rpm", 10, 10); wait != 0 {
+		t.Fatalf("rpm bucket short by %v, want the request refunded", wait)
+	}
+}
+
+func TestTokensAreCorrectedByReportedUsage(t *testing.T) {
+	limiter, store, _ := newFakeLimiter(Limits{TokensPerMinute: 1000})
+	l := NewLimitedLLM(&stubLLM{usage: 600}, limiter)
+
+	if _, err := l.Chat(context.Background(), hello); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	// 600 used, so 400 are left
+	if wait, _ := store.Take(context.Background(), "openai:tpm", 400, 1000); wait != 0 {
+		t.Fatalf("tpm bucket short by %v", wait)
+	}
+	if wait, _ := store.Take(context.Background(), "openai:tpm", 1, 1000); wait == 0 {
+		t.Fatal("tpm bucket not charged for the reported usage")
+	}
+}
+
+func TestEstimateChatTokens(t *testing.T) {
+	messages := []llm.Message{
+		llm.NewUserMessage(strings.Repeat("a", 40)),
+		{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{{Function: llm.FunctionCall{Name: "look", Arguments: `{"q":"abc"}`}}}},
+		llm.NewUserImageMessage("", llm.Image{URL: "https://example.com/a.jpg"}, llm.Image{Data: []byte("jpeg")}),
+	}
+	got := estimateChatTokens(messages, []llm.Option{llm.WithMaxCompletionTokens(100)})
+	if want := (4 + 10) + (4 + 1 + 3) + (4 + 2*imageTokens) + 100; got != want {
+		t.Fatalf("estimate = %d, want %d", got, want)
+	}
+}
+
+func TestMaxConcurrentQueues(t *testing.T) {
+	store := NewMemoryStore()
+	limiter := NewLimiter(store, "openai", Limits{MaxConcurrent: 2}, WithPollInterval(time.Millisecond))
+	inner := &stubLLM{hold: 20 * time.Millisecond}
+	l := NewLimitedLLM(inner, limiter)
+
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := l.Chat(context.Background(), hello); err != nil {
+				t.Errorf("Chat: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if inner.calls != 6 || inner.peak > 2 {
+		t.Fatalf("calls = %d, peak in flight = %d, want at most 2", inner.calls, inner.peak)
+	}
+}
+
+func TestStreamHoldsSlotUntilClosed(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore(), "openai", Limits{MaxConcurrent: 1}, WithPollInterval(time.Millisecond))
+	l := NewLimitedLLM(&stubLLM{}, limiter)
+
+	stream, err := l.ChatStream(context.Background(), hello)
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Chat(ctx, hello); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want the call to wait out its deadline", err)
+	}
+
+	stream.Close()
+	if _, err := l.Chat(context.Background(), hello); err != nil {
+		t.Fatalf("Chat after close: %v", err)
+	}
+}
+
+// failingStore fails every operation
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, float64, float64) (time.Duration, error) {
+	return 0, errors.New("redis down")
+}
+func (failingStore) Spend(context.Context, string, float64, float64) error {
+	return errors.New("redis down")
+}
+func (failingStore) Acquire(context.Context, string, string, int, time.Duration) (bool, error) {
+	return false, errors.New("redis down")
+}
+func (failingStore) Release(context.Context, string, string) error { return errors.New("redis down") }
+
+func TestStoreFailuresLetCallsThrough(t *testing.T) {
+	limiter := NewLimiter(failingStore{}, "openai", Limits{RequestsPerMinute: 1, TokensPerMinute: 1, MaxConcurrent: 1})
+	inner := &stubLLM{}
+	if _, err := NewLimitedLLM(inner, limiter).Chat(context.Background(), hello); err != nil || inner.calls != 1 {
+		t.Fatalf("Chat = %v after %d calls, want it let through", err, inner.calls)
+	}
+}
+
+// ============================================================================
+// Embeddings and OCR
+// ============================================================================
+
+type stubEmbedder struct{ calls int }
+
+func (s *stubEmbedder) EmbedDocuments(_ context.Context, documents []string, _ ...embedding.Option) ([]embedding.Embedding, error) {
+	s.calls++
+	return make([]embedding.Embedding, len(documents)), nil
+}
+
+func (s *stubEmbedder) EmbedQuery(_ context.Context, _ string, _ ...embedding.Option) (embedding.Embedding, error) {
+	s.calls++
+	return embedding.Embedding{}, nil
+}
+
+func TestEmbedderWaitsForTokens(t *testing.T) {
+	limiter, _, clock := newFakeLimiter(Limits{TokensPerMinute: 60})
+	e := NewLimitedEmbedder(&stubEmbedder{}, limiter)
+
+	// 40 chars each: 10 estimated tokens per text
+	docs := []string{strings.Repeat("a", 40), strings.Repeat("b", 40), strings.Repeat("c", 40)}
+	if _, err := e.EmbedDocuments(context.Background(), docs); err != nil {
+		t.Fatalf("EmbedDocuments: %v", err)
+	}
+	if _, err := e.EmbedDocuments(context.Background(), docs); err != nil {
+		t.Fatalf("EmbedDocuments: %v", err)
+	}
+	if _, err := e.EmbedQuery(context.Background(), strings.Repeat("q", 40)); err != nil {
+		t.Fatalf("EmbedQuery: %v", err)
+	}
+
+	if len(clock.slept) != 1 || clock.slept[0] != 10*time.Second {
+		t.Fatalf("waits = %v, want the query to wait for 10 tokens", clock.slept)
+	}
+}
+
+type stubRecognizer struct{ calls int }
+
+func (s *stubRecognizer) RecognizeText(context.Context, ocr.Input, ...ocr.Option) (*ocr.Result, error) {
+	s.calls++
+	return &ocr.Result{}, nil
+}
+
+func TestRecognizerCountsRequests(t *testing.T) {
+	limiter, _, clock := newFakeLimiter(Limits{RequestsPerMinute: 1, TokensPerMinute: 1})
+	inner := &stubRecognizer{}
+	r := NewLimitedRecognizer(inner, limiter)
+
+	for range 2 {
+		if _, err := r.RecognizeText(context.Background(), ocr.Input{}); err != nil {
+			t.Fatalf("RecognizeText: %v", err)
+		}
+	}
+	if inner.calls != 2 || len(clock.slept) != 1 || clock.slept[0] != time.Minute {
+		t.Fatalf("calls = %d, waits = %v", inner.calls, clock.slept)
+	}
+}
@@ -0,0 +1,55 @@
+package ailimit
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/divi/pkg/ai/embedding"
+)
+
+var _ embedding.Embedder = (*LimitedEmbedder)(nil)
+
+// LimitedEmbedder admits embedding calls through a Limiter, estimating
+// tokens from the texts
+type LimitedEmbedder struct {
+	embedder embedding.Embedder
+	limiter  *Limiter
+}
+
+// NewLimitedEmbedder puts limiter in front of embedder
+func NewLimitedEmbedder(embedder embedding.Embedder, limiter *Limiter) *LimitedEmbedder {
+	return &LimitedEmbedder{embedder: embedder, limiter: limiter}
+}
+
+// EmbedDocuments implements the Embedder interface
+func (e *LimitedEmbedder) EmbedDocuments(ctx context.Context, documents []string, opts ...embedding.Option) ([]embedding.Embedding, error) {
+	tokens := 0
+	for _, doc := range documents {
+		tokens += estimateTokens(doc)
+	}
+
+	permit, err := e.limiter.Wait(ctx, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	embeddings, err := e.embedder.EmbedDocuments(ctx, documents, opts...)
+	used := 0
+	if len(embeddings) > 0 {
+		// Providers report the batch's usage on every embedding
+		used = embeddings[0].Usage.TotalTokens
+	}
+	permit.Done(ctx, used)
+	return embeddings, err
+}
+
+// EmbedQuery implements the Embedder interface
+func (e *LimitedEmbedder) EmbedQuery(ctx context.Context, text string, opts ...embedding.Option) (embedding.Embedding, error) {
+	permit, err := e.limiter.Wait(ctx, estimateTokens(text))
+	if err != nil {
+		return embedding.Embedding{}, err
+	}
+
+	emb, err := e.embedder.EmbedQuery(ctx, text, opts...)
+	permit.Done(ctx, emb.Usage.TotalTokens)
+	return emb, err
+}
@@ -0,0 +1,19 @@
+package ailimit
+
+import (
+	"net/http"
+
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+var (
+	// Error registry for the AI rate limiter
+	errorRegistry = errx.NewRegistry("AILIMIT")
+
+	ErrRateLimited = errorRegistry.Register(
+		"RATE_LIMITED",
+		errx.TypeExternal,
+		http.StatusTooManyRequests,
+		"AI provider limit reached before the request deadline",
+	)
+)
@@ -0,0 +1,110 @@
+package ailimit
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+)
+
+var _ llm.LLM = (*LimitedLLM)(nil)
+
+// LimitedLLM admits chat calls through a Limiter. A call's tokens are
+// estimated from its messages plus its completion limit, and corrected with
+// the usage the provider reports.
+type LimitedLLM struct {
+	llm     llm.LLM
+	limiter *Limiter
+}
+
+// NewLimitedLLM puts limiter in front of l
+func NewLimitedLLM(l llm.LLM, limiter *Limiter) *LimitedLLM {
+	return &LimitedLLM{llm: l, limiter: limiter}
+}
+
+// Chat implements the LLM interface
+func (l *LimitedLLM) Chat(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Response, error) {
+	permit, err := l.limiter.Wait(ctx, estimateChatTokens(messages, opts))
+	if err != nil {
+		return llm.Response{}, err
+	}
+
+	resp, err := l.llm.Chat(ctx, messages, opts...)
+	permit.Done(ctx, resp.Usage.TotalTokens)
+	return resp, err
+}
+
+// ChatStream implements the LLM interface. The concurrency slot is held
+// until the stream ends or is closed.
+func (l *LimitedLLM) ChatStream(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Stream, error) {
+	permit, err := l.limiter.Wait(ctx, estimateChatTokens(messages, opts))
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := l.llm.ChatStream(ctx, messages, opts...)
+	if err != nil {
+		permit.Done(ctx, 0)
+		return nil, err
+	}
+	return &limitedStream{Stream: stream, ctx: ctx, permit: permit}, nil
+}
+
+type limitedStream struct {
+	llm.Stream
+	ctx    context.Context
+	permit *Permit
+}
+
+func (s *limitedStream) Next() (llm.Message, error) {
+	msg, err := s.Stream.Next()
+	if err != nil {
+		s.done()
+	}
+	return msg, err
+}
+
+func (s *limitedStream) Close() error {
+	s.done()
+	return s.Stream.Close()
+}
+
+func (s *limitedStream) Usage() (llm.Usage, bool) {
+	return llm.StreamUsage(s.Stream)
+}
+
+// done frees the permit, correcting the estimate when the stream reports
+// its usage.
+func (s *limitedStream) done() {
+	usage, _ := llm.StreamUsage(s.Stream)
+	s.permit.Done(s.ctx, usage.TotalTokens)
+}
+
+// imageTokens is what an attached image is estimated at: a 1024x1024 image
+// at high detail on OpenAI. The reported usage corrects it.
+const imageTokens = 765
+
+// estimateChatTokens counts the prompt text, attached images and the
+// completion limit, as providers do when checking a request against the
+// token limit.
+func estimateChatTokens(messages []llm.Message, opts []llm.Option) int {
+	options := llm.DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	tokens := 0
+	for _, msg := range messages {
+		// Role and message framing
+		tokens += 4 + estimateTokens(msg.Content) + len(msg.Images)*imageTokens
+		for _, tc := range msg.ToolCalls {
+			tokens += estimateTokens(tc.Function.Name) + estimateTokens(tc.Function.Arguments)
+		}
+	}
+
+	if options.MaxCompletionTokens > 0 {
+		tokens += options.MaxCompletionTokens
+	} else {
+		tokens += options.MaxTokens
+	}
+	return tokens
+}
@@ -0,0 +1,105 @@
+package ailimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore keeps limiter state in process
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+	slots   map[string]map[string]time.Time // key -> lease -> expiry
+	now     func() time.Time
+}
+
+type memoryBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewMemoryStore creates an in-process store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*memoryBucket),
+		slots:   make(map[string]map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// Take implements Store
+func (s *MemoryStore) Take(_ context.Context, key string, n, perMinute float64) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := s.refill(key, perMinute)
+	if b.tokens >= n {
+		b.tokens -= n
+		return 0, nil
+	}
+	missing := n - b.tokens
+	return time.Duration(missing / perMinute * float64(time.Minute)), nil
+}
+
+// Spend implements Store
+func (s *MemoryStore) Spend(_ context.Context, key string, n, perMinute float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := s.refill(key, perMinute)
+	b.tokens = min(b.tokens-n, perMinute)
+	return nil
+}
+
+// refill brings the bucket under key up to date; new buckets start full.
+// Callers hold s.mu.
+func (s *MemoryStore) refill(key string, perMinute float64) *memoryBucket {
+	now := s.now()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{tokens: perMinute, updated: now}
+		s.buckets[key] = b
+		return b
+	}
+
+	elapsed := now.Sub(b.updated)
+	b.tokens = min(b.tokens+elapsed.Minutes()*perMinute, perMinute)
+	b.updated = now
+	return b
+}
+
+// Acquire implements Store
+func (s *MemoryStore) Acquire(_ context.Context, key, lease string, limit int, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	leases := s.slots[key]
+	if leases == nil {
+		leases = make(map[string]time.Time)
+		s.slots[key] = leases
+	}
+	for id, expiry := range leases {
+		if !now.Before(expiry) {
+			delete(leases, id)
+		}
+	}
+
+	if len(leases) >= limit {
+		return false, nil
+	}
+	leases[lease] = now.Add(ttl)
+	return true, nil
+}
+
+// Release implements Store
+func (s *MemoryStore) Release(_ context.Context, key, lease string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.slots[key], lease)
+	return nil
+}
@@ -0,0 +1,34 @@
+package ailimit
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/divi/pkg/ai/ocr"
+)
+
+var _ ocr.TextRecognizer = (*LimitedRecognizer)(nil)
+
+// LimitedRecognizer admits OCR calls through a Limiter. OCR is billed by
+// page, so only the request and concurrency limits apply. The wrapper only
+// exposes RecognizeText; build an ocr.Client on the provider itself for its
+// other capabilities.
+type LimitedRecognizer struct {
+	recognizer ocr.TextRecognizer
+	limiter    *Limiter
+}
+
+// NewLimitedRecognizer puts limiter in front of recognizer
+func NewLimitedRecognizer(recognizer ocr.TextRecognizer, limiter *Limiter) *LimitedRecognizer {
+	return &LimitedRecognizer{recognizer: recognizer, limiter: limiter}
+}
+
+// RecognizeText implements the TextRecognizer interface
+func (r *LimitedRecognizer) RecognizeText(ctx context.Context, input ocr.Input, opts ...ocr.Option) (*ocr.Result, error) {
+	permit, err := r.limiter.Wait(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer permit.Done(ctx, 0)
+
+	return r.recognizer.RecognizeText(ctx, input, opts...)
+}
@@ -0,0 +1,104 @@
+package ailimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ Store = (*RedisStore)(nil)
+
+// RedisStore keeps limiter state in Redis, shared by every instance. The
+// scripts read Redis' clock so instances with skewed clocks agree.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore creates a store on client. An empty prefix defaults to
+// "ailimit:".
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	if prefix == "" {
+		prefix = "ailimit:"
+	}
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// bucketScript refills the bucket in KEYS[1] and takes ARGV[1] tokens from
+// it, or all of them when ARGV[3] is 1 even into debt. It returns the
+// milliseconds to wait, 0 once taken. ARGV[2] is the refill rate per minute,
+// which is also the capacity.
+var bucketScript = redis.NewScript(`
+local n = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local force = ARGV[3] == "1"
+
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil then
+	tokens = rate
+else
+	tokens = math.min(rate, tokens + (now - ts) * rate / 60000)
+end
+
+local wait = 0
+if force or tokens >= n then
+	tokens = math.min(rate, tokens - n)
+else
+	wait = math.ceil((n - tokens) * 60000 / rate)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], 120000)
+return wait
+`)
+
+// acquireScript drops expired leases from the sorted set in KEYS[1] and adds
+// lease ARGV[1] if fewer than ARGV[2] remain; leases expire after ARGV[3]
+// milliseconds.
+var acquireScript = redis.NewScript(`
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local ttl = tonumber(ARGV[3])
+
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now)
+if redis.call("ZCARD", KEYS[1]) >= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call("ZADD", KEYS[1], now + ttl, ARGV[1])
+redis.call("PEXPIRE", KEYS[1], ttl)
+return 1
+`)
+
+// Take implements Store
+func (s *RedisStore) Take(ctx context.Context, key string, n, perMinute float64) (time.Duration, error) {
+	wait, err := bucketScript.Run(ctx, s.client, []string{s.prefix + key}, n, perMinute, "0").Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(wait) * time.Millisecond, nil
+}
+
+// Spend implements Store
+func (s *RedisStore) Spend(ctx context.Context, key string, n, perMinute float64) error {
+	return bucketScript.Run(ctx, s.client, []string{s.prefix + key}, n, perMinute, "1").Err()
+}
+
+// Acquire implements Store
+func (s *RedisStore) Acquire(ctx context.Context, key, lease string, limit int, ttl time.Duration) (bool, error) {
+	ok, err := acquireScript.Run(ctx, s.client, []string{s.prefix + key}, lease, limit, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return false, err
+	}
+	return ok == 1, nil
+}
+
+// Release implements Store
+func (s *RedisStore) Release(ctx context.Context, key, lease string) error {
+	return s.client.ZRem(ctx, s.prefix+key, lease).Err()
+}
@@ -0,0 +1,101 @@
+// Package aiusage meters calls to AI providers. Clients report what each call
+// consumed to a Meter, which prices it and can refuse or downgrade calls for
+// tenants over budget. Who a call is for comes from the context.
+package aiusage
+
+import (
+	"context"
+	"time"
+)
+
+// Kind is the type of AI call being metered.
+type Kind string
+
+const (
+	KindLLM       Kind = "llm"
+	KindEmbedding Kind = "embedding"
+	KindSpeech    Kind = "speech"
+	KindOCR       Kind = "ocr"
+)
+
+// Record is the usage of a single call.
+type Record struct {
+	Kind             Kind
+	Model            string
+	TenantID         string
+	Feature          string
+	SubjectID        string // e.g. the vehicle the call was made for
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	Characters       int     // TTS input
+	AudioSeconds     float64 // STT input
+	Pages            int     // OCR pages
+	CostUSD          float64
+	CreatedAt        time.Time
+}
+
+// Admission is a Meter's decision for a call about to be made.
+type Admission struct {
+	// Model replaces the requested model when the tenant is degraded to a
+	// cheaper one. Empty keeps the requested model.
+	Model string
+}
+
+// Meter receives the usage of every metered call.
+type Meter interface {
+	// Admit is called before each call. An error blocks the call.
+	Admit(ctx context.Context, kind Kind, model string) (Admission, error)
+
+	// Record stores the usage of a completed call. Failures are the meter's
+	// to log; they never fail the call.
+	Record(ctx context.Context, rec Record)
+}
+
+// ============================================================================
+// Context tags
+// ============================================================================
+
+type tagsKey struct{}
+
+// Tags say who a call is made for and why.
+type Tags struct {
+	TenantID  string
+	Feature   string
+	SubjectID string
+}
+
+// WithTenant tags calls made with ctx with the tenant paying for them.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	t := TagsFrom(ctx)
+	t.TenantID = tenantID
+	return context.WithValue(ctx, tagsKey{}, t)
+}
+
+// WithFeature tags calls made with ctx with the product feature using them,
+// e.g. "vision", "enrichment" or "listing".
+func WithFeature(ctx context.Context, feature string) context.Context {
+	t := TagsFrom(ctx)
+	t.Feature = feature
+	return context.WithValue(ctx, tagsKey{}, t)
+}
+
+// WithSubject tags calls made with ctx with the record they are about.
+func WithSubject(ctx context.Context, subjectID string) context.Context {
+	t := TagsFrom(ctx)
+	t.SubjectID = subjectID
+	return context.WithValue(ctx, tagsKey{}, t)
+}
+
+// TagsFrom returns the tags set on ctx.
+func TagsFrom(ctx context.Context) Tags {
+	t, _ := ctx.Value(tagsKey{}).(Tags)
+	return t
+}
+
+// Apply copies the tags on ctx onto rec.
+func (t Tags) Apply(rec *Record) {
+	rec.TenantID = t.TenantID
+	rec.Feature = t.Feature
+	rec.SubjectID = t.SubjectID
+}
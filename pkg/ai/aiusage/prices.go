@@ -0,0 +1,89 @@
+package aiusage
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Price is what a model charges, in USD. Only the units a model bills by
+// need to be set.
+type Price struct {
+	InputPerMTok  float64 `json:"input_per_mtok,omitempty"`
+	OutputPerMTok float64 `json:"output_per_mtok,omitempty"`
+	PerMChars     float64 `json:"per_mchars,omitempty"`
+	PerMinute     float64 `json:"per_minute,omitempty"`
+	PerPage       float64 `json:"per_page,omitempty"`
+}
+
+// PriceTable maps model names to prices. A model matches its own entry or,
+// failing that, the longest entry it starts with, so "gpt-4o-2024-08-06"
+// is billed as "gpt-4o".
+type PriceTable map[string]Price
+
+// DefaultPrices are list prices at the time of writing; override them with
+// ParsePriceTable when they change.
+var DefaultPrices = PriceTable{
+	"gpt-4o":                 {InputPerMTok: 2.50, OutputPerMTok: 10.00},
+	"gpt-4o-mini":            {InputPerMTok: 0.15, OutputPerMTok: 0.60},
+	"gpt-4.1":                {InputPerMTok: 2.00, OutputPerMTok: 8.00},
+	"gpt-4.1-mini":           {InputPerMTok: 0.40, OutputPerMTok: 1.60},
+	"text-embedding-3-small": {InputPerMTok: 0.02},
+	"text-embedding-3-large": {InputPerMTok: 0.13},
+	"tts-1":                  {PerMChars: 15.00},
+	"tts-1-hd":               {PerMChars: 30.00},
+	"whisper-1":              {PerMinute: 0.006},
+	"mistral-ocr-latest":     {PerPage: 0.001},
+	"mistral-large-latest":   {InputPerMTok: 2.00, OutputPerMTok: 6.00},
+	"mistral-small-latest":   {InputPerMTok: 0.20, OutputPerMTok: 0.60},
+}
+
+// ParsePriceTable reads a JSON object of model → Price and lays it over the
+// defaults.
+func ParsePriceTable(data []byte) (PriceTable, error) {
+	var overrides PriceTable
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, err
+	}
+	table := make(PriceTable, len(DefaultPrices)+len(overrides))
+	for model, p := range DefaultPrices {
+		table[model] = p
+	}
+	for model, p := range overrides {
+		table[model] = p
+	}
+	return table, nil
+}
+
+// Lookup returns the price for model.
+func (t PriceTable) Lookup(model string) (Price, bool) {
+	if p, ok := t[model]; ok {
+		return p, true
+	}
+	best := ""
+	for name := range t {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return t[best], true
+}
+
+// Cost prices rec. Unknown models cost 0.
+func (t PriceTable) Cost(rec Record) float64 {
+	p, ok := t.Lookup(rec.Model)
+	if !ok {
+		return 0
+	}
+	prompt := rec.PromptTokens
+	if prompt == 0 && rec.CompletionTokens == 0 {
+		prompt = rec.TotalTokens
+	}
+	return float64(prompt)*p.InputPerMTok/1e6 +
+		float64(rec.CompletionTokens)*p.OutputPerMTok/1e6 +
+		float64(rec.Characters)*p.PerMChars/1e6 +
+		rec.AudioSeconds/60*p.PerMinute +
+		float64(rec.Pages)*p.PerPage
+}
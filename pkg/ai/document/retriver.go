@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"strings"
+
+	"github.com/Abraxas-365/divi/pkg/ai/vstore"
 )
 
 // ============================================================================
@@ -16,6 +18,7 @@ type Retriever struct {
 	searchType      SearchType
 	topK            int
 	minScore        float32
+	filter          *vstore.Filter
 	mmrLambda       float32 // For MMR (Maximal Marginal Relevance)
 	reranker        Reranker
 	compressionFunc CompressionFunc
@@ -68,6 +71,12 @@ func (r *Retriever) WithMinScore(score float32) *Retriever {
 	return r
 }
 
+// WithFilter restricts retrieval to documents whose metadata matches the filter
+func (r *Retriever) WithFilter(filter *vstore.Filter) *Retriever {
+	r.filter = filter
+	return r
+}
+
 // WithReranker sets a reranker
 func (r *Retriever) WithReranker(reranker Reranker) *Retriever {
 	r.reranker = reranker
@@ -88,6 +97,7 @@ func (r *Retriever) Retrieve(ctx context.Context, query string) ([]*Document, er
 		Query:    query,
 		TopK:     r.topK * 2, // Fetch more for reranking/MMR
 		MinScore: r.minScore,
+		Filter:   r.filter,
 	}
 
 	result, err := r.store.Search(ctx, searchReq)
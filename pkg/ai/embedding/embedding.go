@@ -2,6 +2,9 @@ package embedding
 
 import (
 	"context"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/ai/aiusage"
 )
 
 // Embedder represents an interface for text embedding operations
@@ -31,6 +34,7 @@ type Usage struct {
 // Client represents a configured embedding client
 type Client struct {
 	embedder Embedder
+	meter    aiusage.Meter
 }
 
 // NewClient creates a new embedding client
@@ -38,12 +42,70 @@ func NewClient(embedder Embedder) *Client {
 	return &Client{embedder: embedder}
 }
 
+// WithMeter reports the usage of every call to meter and lets it block calls
+// before they are made.
+func (c *Client) WithMeter(meter aiusage.Meter) *Client {
+	c.meter = meter
+	return c
+}
+
 // EmbedDocuments converts a slice of documents into vector embeddings
 func (c *Client) EmbedDocuments(ctx context.Context, documents []string, opts ...Option) ([]Embedding, error) {
-	return c.embedder.EmbedDocuments(ctx, documents, opts...)
+	if c.meter == nil {
+		return c.embedder.EmbedDocuments(ctx, documents, opts...)
+	}
+
+	model := c.resolveModel(opts)
+	if _, err := c.meter.Admit(ctx, aiusage.KindEmbedding, model); err != nil {
+		return nil, err
+	}
+
+	embeddings, err := c.embedder.EmbedDocuments(ctx, documents, opts...)
+	if err != nil {
+		return nil, err
+	}
+	// Providers report the usage of the whole batch on every embedding
+	if len(embeddings) > 0 {
+		c.record(ctx, model, embeddings[0].Usage)
+	}
+	return embeddings, nil
 }
 
 // EmbedQuery converts a single query text into a vector embedding
 func (c *Client) EmbedQuery(ctx context.Context, text string, opts ...Option) (Embedding, error) {
-	return c.embedder.EmbedQuery(ctx, text, opts...)
+	if c.meter == nil {
+		return c.embedder.EmbedQuery(ctx, text, opts...)
+	}
+
+	model := c.resolveModel(opts)
+	if _, err := c.meter.Admit(ctx, aiusage.KindEmbedding, model); err != nil {
+		return Embedding{}, err
+	}
+
+	emb, err := c.embedder.EmbedQuery(ctx, text, opts...)
+	if err != nil {
+		return emb, err
+	}
+	c.record(ctx, model, emb.Usage)
+	return emb, nil
+}
+
+func (c *Client) resolveModel(opts []Option) string {
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options.Model
+}
+
+func (c *Client) record(ctx context.Context, model string, usage Usage) {
+	rec := aiusage.Record{
+		Kind:         aiusage.KindEmbedding,
+		Model:        model,
+		PromptTokens: usage.PromptTokens,
+		TotalTokens:  usage.TotalTokens,
+		CreatedAt:    time.Now(),
+	}
+	aiusage.TagsFrom(ctx).Apply(&rec)
+	c.meter.Record(ctx, rec)
 }
@@ -10,6 +10,9 @@ type EmbeddingOptions struct {
 
 	// User is an optional user identifier for tracking and rate limiting
 	User string
+
+	// NoCache bypasses embedding caches for this call
+	NoCache bool
 }
 
 // Option is a function type to modify EmbeddingOptions
@@ -36,6 +39,13 @@ func WithUser(user string) Option {
 	}
 }
 
+// WithoutCache makes the call skip embedding caches
+func WithoutCache() Option {
+	return func(o *EmbeddingOptions) {
+		o.NoCache = true
+	}
+}
+
 // DefaultOptions returns the default embedding options
 func DefaultOptions() *EmbeddingOptions {
 	return &EmbeddingOptions{
@@ -0,0 +1,26 @@
+package llm
+
+import (
+	"net/http"
+
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+var (
+	// Error registry for the LLM client
+	errorRegistry = errx.NewRegistry("LLM")
+
+	ErrUnsupportedSchema = errorRegistry.Register(
+		"UNSUPPORTED_SCHEMA",
+		errx.TypeInternal,
+		http.StatusInternalServerError,
+		"Go type cannot be described as a JSON schema",
+	)
+
+	ErrStructuredOutput = errorRegistry.Register(
+		"STRUCTURED_OUTPUT_INVALID",
+		errx.TypeExternal,
+		http.StatusBadGateway,
+		"Model response does not match the requested schema",
+	)
+)
@@ -2,6 +2,10 @@ package llm
 
 import (
 	"context"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/ai/aiusage"
 )
 
 // LLM represents a generic large language model interface
@@ -17,6 +21,15 @@ type LLM interface {
 type Response struct {
 	Message Message
 	Usage   Usage
+	Model   string // Model that served the request, when the provider reports it
+
+	// Provider names the backend that served the request when the call went
+	// through a router
+	Provider string
+
+	// Cached is set when the response was replayed from a cache rather than
+	// generated; its Usage is then zero
+	Cached bool
 }
 
 // Stream represents a streaming response
@@ -29,9 +42,25 @@ type Stream interface {
 	Close() error
 }
 
+// UsageReporter is implemented by streams that learn the usage of the call
+// from the provider. Usage reports false until it is known, and when the
+// provider doesn't report it. Streams wrapping another stream forward it.
+type UsageReporter interface {
+	Usage() (Usage, bool)
+}
+
+// StreamUsage returns the usage s reports, if any.
+func StreamUsage(s Stream) (Usage, bool) {
+	if r, ok := s.(UsageReporter); ok {
+		return r.Usage()
+	}
+	return Usage{}, false
+}
+
 // Client represents a configured LLM client
 type Client struct {
-	llm LLM
+	llm   LLM
+	meter aiusage.Meter
 }
 
 // NewClient creates a new LLM client
@@ -39,12 +68,159 @@ func NewClient(llm LLM) *Client {
 	return &Client{llm: llm}
 }
 
+// WithMeter reports the usage of every call to meter and lets it block or
+// downgrade calls before they are made.
+func (c *Client) WithMeter(meter aiusage.Meter) *Client {
+	c.meter = meter
+	return c
+}
+
 // Chat generates a response based on the conversation history
 func (c *Client) Chat(ctx context.Context, messages []Message, opts ...Option) (Response, error) {
-	return c.llm.Chat(ctx, messages, opts...)
+	if c.meter == nil {
+		return c.llm.Chat(ctx, messages, opts...)
+	}
+
+	opts, model, err := c.admit(ctx, opts)
+	if err != nil {
+		return Response{}, err
+	}
+
+	resp, err := c.llm.Chat(ctx, messages, opts...)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.Model != "" {
+		model = resp.Model
+	}
+	rec := aiusage.Record{
+		Kind:             aiusage.KindLLM,
+		Model:            model,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+		TotalTokens:      resp.Usage.TotalTokens,
+		CreatedAt:        time.Now(),
+	}
+	aiusage.TagsFrom(ctx).Apply(&rec)
+	c.meter.Record(ctx, rec)
+	return resp, nil
 }
 
-// ChatStream streams the response tokens
+// ChatStream streams the response tokens. A metered stream is recorded once
+// it ends or is closed, with the usage the provider reports or, failing that,
+// an estimate from the prompt and the text streamed so far.
 func (c *Client) ChatStream(ctx context.Context, messages []Message, opts ...Option) (Stream, error) {
-	return c.llm.ChatStream(ctx, messages, opts...)
+	if c.meter == nil {
+		return c.llm.ChatStream(ctx, messages, opts...)
+	}
+
+	opts, model, err := c.admit(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := c.llm.ChatStream(ctx, messages, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &meteredStream{Stream: stream, ctx: ctx, meter: c.meter, model: model, messages: messages}, nil
+}
+
+// meteredStream records the usage of a stream when it finishes.
+type meteredStream struct {
+	Stream
+	ctx      context.Context
+	meter    aiusage.Meter
+	model    string
+	messages []Message
+
+	completion strings.Builder
+	toolCalls  []ToolCall
+	recorded   bool
+}
+
+func (s *meteredStream) Next() (Message, error) {
+	msg, err := s.Stream.Next()
+	if err != nil {
+		s.record()
+		return msg, err
+	}
+	s.completion.WriteString(msg.Content)
+	if len(msg.ToolCalls) > 0 {
+		// Providers send the accumulated calls, not deltas
+		s.toolCalls = msg.ToolCalls
+	}
+	return msg, nil
+}
+
+func (s *meteredStream) Close() error {
+	s.record()
+	return s.Stream.Close()
+}
+
+func (s *meteredStream) Usage() (Usage, bool) {
+	return StreamUsage(s.Stream)
+}
+
+func (s *meteredStream) record() {
+	if s.recorded {
+		return
+	}
+	s.recorded = true
+
+	usage, ok := StreamUsage(s.Stream)
+	if !ok {
+		usage = s.estimateUsage()
+	}
+	rec := aiusage.Record{
+		Kind:             aiusage.KindLLM,
+		Model:            s.model,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+		CreatedAt:        time.Now(),
+	}
+	aiusage.TagsFrom(s.ctx).Apply(&rec)
+	s.meter.Record(s.ctx, rec)
+}
+
+// estimateUsage counts about four characters a token, plus the role framing
+// of each message.
+func (s *meteredStream) estimateUsage() Usage {
+	prompt := 0
+	for _, m := range s.messages {
+		prompt += 4 + estimateTokens(m.Content)
+		for _, tc := range m.ToolCalls {
+			prompt += estimateTokens(tc.Function.Name) + estimateTokens(tc.Function.Arguments)
+		}
+	}
+	completion := estimateTokens(s.completion.String())
+	for _, tc := range s.toolCalls {
+		completion += estimateTokens(tc.Function.Name) + estimateTokens(tc.Function.Arguments)
+	}
+	return Usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion}
+}
+
+func estimateTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+// admit asks the meter whether the call may go ahead, switching to the model
+// it degrades to if any. It returns the options to call with and the model
+// they request.
+func (c *Client) admit(ctx context.Context, opts []Option) ([]Option, string, error) {
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	admission, err := c.meter.Admit(ctx, aiusage.KindLLM, options.Model)
+	if err != nil {
+		return nil, "", err
+	}
+	if admission.Model != "" && admission.Model != options.Model {
+		opts = append(opts, WithModel(admission.Model))
+		return opts, admission.Model, nil
+	}
+	return opts, options.Model, nil
 }
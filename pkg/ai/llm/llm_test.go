@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/Abraxas-365/divi/pkg/ai/aiusage"
+)
+
+// chunkStream streams fixed chunks and, when usage is set, reports it.
+type chunkStream struct {
+	chunks []string
+	usage  *Usage
+}
+
+func (s *chunkStream) Next() (Message, error) {
+	if len(s.chunks) == 0 {
+		return Message{}, io.EOF
+	}
+	msg := Message{Role: RoleAssistant, Content: s.chunks[0]}
+	s.chunks = s.chunks[1:]
+	return msg, nil
+}
+
+func (s *chunkStream) Close() error { return nil }
+
+type reportingStream struct{ chunkStream }
+
+func (s *reportingStream) Usage() (Usage, bool) { return *s.usage, true }
+
+type streamingLLM struct{ stream Stream }
+
+func (l *streamingLLM) Chat(context.Context, []Message, ...Option) (Response, error) {
+	return Response{}, errors.New("not scripted")
+}
+
+func (l *streamingLLM) ChatStream(context.Context, []Message, ...Option) (Stream, error) {
+	return l.stream, nil
+}
+
+type recordingMeter struct{ records []aiusage.Record }
+
+func (m *recordingMeter) Admit(context.Context, aiusage.Kind, string) (aiusage.Admission, error) {
+	return aiusage.Admission{}, nil
+}
+
+func (m *recordingMeter) Record(_ context.Context, rec aiusage.Record) {
+	m.records = append(m.records, rec)
+}
+
+func TestClientRecordsStreamUsage(t *testing.T) {
+	reported := Usage{PromptTokens: 40, CompletionTokens: 12, TotalTokens: 52}
+	tests := []struct {
+		name   string
+		stream Stream
+		want   Usage
+	}{
+		{
+			name:   "reported by the provider",
+			stream: &reportingStream{chunkStream{chunks: []string{"Hello", " there"}, usage: &reported}},
+			want:   reported,
+		},
+		{
+			// 4 framing + 3 prompt tokens; "Hello there" is 3 tokens
+			name:   "estimated from the text",
+			stream: &chunkStream{chunks: []string{"Hello", " there"}},
+			want:   Usage{PromptTokens: 7, CompletionTokens: 3, TotalTokens: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meter := &recordingMeter{}
+			client := NewClient(&streamingLLM{stream: tt.stream}).WithMeter(meter)
+			ctx := aiusage.WithFeature(context.Background(), "chat")
+
+			stream, err := client.ChatStream(ctx, []Message{NewUserMessage("Say hello")}, WithModel("gpt-4o-mini"))
+			if err != nil {
+				t.Fatalf("ChatStream: %v", err)
+			}
+			for {
+				if _, err := stream.Next(); errors.Is(err, io.EOF) {
+					break
+				}
+			}
+			stream.Close()
+
+			if len(meter.records) != 1 {
+				t.Fatalf("records = %d, want one per stream", len(meter.records))
+			}
+			rec := meter.records[0]
+			got := Usage{PromptTokens: rec.PromptTokens, CompletionTokens: rec.CompletionTokens, TotalTokens: rec.TotalTokens}
+			if got != tt.want || rec.Model != "gpt-4o-mini" || rec.Feature != "chat" {
+				t.Fatalf("record = %+v, want usage %+v for gpt-4o-mini under chat", rec, tt.want)
+			}
+		})
+	}
+}
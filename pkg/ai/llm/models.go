@@ -17,9 +17,18 @@ type Message struct {
 	FunctionCall *FunctionCall  `json:"function_call,omitempty"`
 	ToolCalls    []ToolCall     `json:"tool_calls,omitempty"`
 	ToolCallID   string         `json:"tool_call_id,omitempty"`
+	Images       []Image        `json:"images,omitempty"`
 	Metadata     map[string]any `json:"metadata,omitempty"`
 }
 
+// Image is a picture attached to a user message, given either by URL or as
+// raw bytes with their media type
+type Image struct {
+	URL       string `json:"url,omitempty"`
+	Data      []byte `json:"data,omitempty"`
+	MediaType string `json:"media_type,omitempty"` // e.g. image/jpeg
+}
+
 // Usage represents token usage statistics
 type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
@@ -61,6 +70,15 @@ func NewUserMessage(content string) Message {
 	}
 }
 
+// NewUserImageMessage creates a new user message with images attached
+func NewUserImageMessage(content string, images ...Image) Message {
+	return Message{
+		Role:    RoleUser,
+		Content: content,
+		Images:  images,
+	}
+}
+
 // NewSystemMessage creates a new system message
 func NewSystemMessage(content string) Message {
 	return Message{
@@ -111,6 +129,8 @@ const (
 type ResponseFormat struct {
 	Type       ResponseFormatType `json:"type"`
 	JSONSchema any                `json:"schema,omitempty"` // Optional JSON schema for JSONSchema type
+	Name       string             `json:"name,omitempty"`   // Schema name, for providers that require one
+	Strict     bool               `json:"strict,omitempty"` // The model must follow the schema exactly
 }
 
 // WithResponseFormat specifies the output format
@@ -129,12 +149,17 @@ func WithJSONResponseFormat() Option {
 	}
 }
 
-// WithJSONSchemaResponseFormat sets the response format to conform to a specific JSON schema
+// WithJSONSchemaResponseFormat sets the response format to conform to a specific JSON schema.
+// A *Schema is sent under its title, in strict mode when it allows it.
 func WithJSONSchemaResponseFormat(schema any) Option {
 	return func(o *ChatOptions) {
 		o.ResponseFormat = &ResponseFormat{
 			Type:       JSONSchema,
 			JSONSchema: schema,
 		}
+		if s, ok := schema.(*Schema); ok {
+			o.ResponseFormat.Name = s.Title
+			o.ResponseFormat.Strict = s.strict()
+		}
 	}
 }
@@ -23,6 +23,9 @@ type ChatOptions struct {
 
 	ReasoningEffort string // Reasoning effort level: "low", "medium", "high"
 
+	NoCache bool // Bypass response caches for this call
+
+	StructuredRetries int // Corrections ChatStructured asks for after an invalid response
 }
 
 // Option is a function type to modify ChatOptions
@@ -151,11 +154,28 @@ func WithReasoningEffort(effort string) Option {
 	}
 }
 
+// WithoutCache makes the call skip response caches, neither reading nor
+// storing an answer
+func WithoutCache() Option {
+	return func(o *ChatOptions) {
+		o.NoCache = true
+	}
+}
+
+// WithStructuredRetries sets how many times ChatStructured feeds a response
+// that doesn't match the schema back to the model; 0 fails at once
+func WithStructuredRetries(retries int) Option {
+	return func(o *ChatOptions) {
+		o.StructuredRetries = max(retries, 0)
+	}
+}
+
 // DefaultOptions returns the default options
 func DefaultOptions() *ChatOptions {
 	return &ChatOptions{
-		Temperature: 0.7,
-		TopP:        1.0,
-		MaxTokens:   0, // No limit by default
+		Temperature:       0.7,
+		TopP:              1.0,
+		MaxTokens:         0, // No limit by default
+		StructuredRetries: 2,
 	}
 }
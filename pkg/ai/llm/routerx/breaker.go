@@ -0,0 +1,125 @@
+package routerx
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a provider's circuit breaker.
+type BreakerState string
+
+const (
+	// BreakerClosed lets every call through
+	BreakerClosed BreakerState = "closed"
+
+	// BreakerOpen skips the provider until the cooldown has passed
+	BreakerOpen BreakerState = "open"
+
+	// BreakerHalfOpen lets one probe call through to decide whether the
+	// provider has recovered
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// breaker opens after threshold consecutive failures and stays open for
+// cooldown, then admits a single probe.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker(threshold int, cooldown time.Duration, now func() time.Time) *breaker {
+	return &breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       now,
+		state:     BreakerClosed,
+	}
+}
+
+// allow reports whether a call may go to the provider. In the half-open
+// state only the first caller gets through.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// success closes the breaker.
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// failure counts a failed call, opening the breaker at the threshold or
+// when a probe fails.
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+		b.probing = false
+	}
+}
+
+// release gives up a call that ended without a verdict, such as one the
+// caller cancelled, so a half-open breaker can probe again.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// isOpen reports whether calls are currently being refused.
+func (b *breaker) isOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == BreakerOpen
+}
+
+// snapshot reports the state for health checks.
+func (b *breaker) snapshot() (BreakerState, int, time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.state
+	if state == BreakerOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		// The next call will probe
+		state = BreakerHalfOpen
+	}
+	var retryAt time.Time
+	if state == BreakerOpen {
+		retryAt = b.openedAt.Add(b.cooldown)
+	}
+	return state, b.failures, retryAt
+}
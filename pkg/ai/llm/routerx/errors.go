@@ -0,0 +1,77 @@
+package routerx
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+var (
+	// Error registry for the provider router
+	errorRegistry = errx.NewRegistry("ROUTER")
+
+	ErrNoProviderAvailable = errorRegistry.Register(
+		"NO_PROVIDER_AVAILABLE",
+		errx.TypeExternal,
+		http.StatusServiceUnavailable,
+		"No LLM provider is available",
+	)
+
+	ErrAllProvidersFailed = errorRegistry.Register(
+		"ALL_PROVIDERS_FAILED",
+		errx.TypeExternal,
+		http.StatusBadGateway,
+		"Every LLM provider failed to serve the request",
+	)
+
+	ErrTimeout = errorRegistry.Register(
+		"TIMEOUT",
+		errx.TypeExternal,
+		http.StatusGatewayTimeout,
+		"LLM provider did not answer in time",
+	)
+)
+
+// IsRetryable reports whether err is a transient provider failure worth
+// another attempt: rate limits, 5xx responses, failed connections and
+// timeouts.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var e *errx.Error
+	if errx.As(err, &e) {
+		if e.Type != errx.TypeExternal {
+			return false
+		}
+		return e.HTTPStatus == http.StatusTooManyRequests || e.HTTPStatus >= 500
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isProviderFailure reports whether err is the provider's fault rather than
+// the request's, so another provider may do better. Validation errors would
+// fail the same way everywhere.
+func isProviderFailure(err error) bool {
+	if IsRetryable(err) {
+		return true
+	}
+
+	var e *errx.Error
+	if errx.As(err, &e) {
+		return e.Type == errx.TypeExternal || e.Type == errx.TypeAuthorization
+	}
+
+	// Errors from outside errx carry no classification; give the next
+	// provider a chance
+	return true
+}
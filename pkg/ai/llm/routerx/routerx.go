@@ -0,0 +1,370 @@
+// Package routerx puts several LLM providers behind one llm.LLM. Calls go to
+// the first provider whose circuit breaker is closed; transient failures are
+// retried with jittered exponential backoff, and a provider that keeps
+// failing is skipped in favour of the next one until its cooldown passes.
+//
+//	router := routerx.New([]routerx.Backend{
+//		{Name: "openai", LLM: openaiProvider},
+//		{Name: "anthropic", LLM: anthropicProvider, Models: map[string]string{"*": "claude-sonnet-4-5"}},
+//	})
+//	client := llm.NewClient(router)
+package routerx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+)
+
+var _ llm.LLM = (*Router)(nil)
+
+// Backend is a provider the router can send calls to.
+type Backend struct {
+	// Name identifies the provider in responses and health reports
+	Name string
+	LLM  llm.LLM
+
+	// Models maps requested model names to this provider's. The key "*"
+	// catches every other named model and "" calls that name none; unmapped
+	// names are sent unchanged.
+	Models map[string]string
+
+	// Timeout bounds each attempt; zero uses the router's
+	Timeout time.Duration
+}
+
+// model returns the model to request from the provider.
+func (b Backend) model(requested string) string {
+	if model, ok := b.Models[requested]; ok {
+		return model
+	}
+	if model, ok := b.Models["*"]; ok && requested != "" {
+		return model
+	}
+	return requested
+}
+
+type backend struct {
+	Backend
+	breaker *breaker
+}
+
+// Router implements llm.LLM over an ordered list of providers
+type Router struct {
+	backends []*backend
+
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	timeout    time.Duration
+
+	breakerThreshold int
+	breakerCooldown  time.Duration
+
+	now func() time.Time
+}
+
+// RouterOption configures a Router
+type RouterOption func(*Router)
+
+// WithMaxRetries sets how many times a provider is retried after a
+// transient failure before the router moves on
+func WithMaxRetries(n int) RouterOption {
+	return func(r *Router) {
+		if n >= 0 {
+			r.maxRetries = n
+		}
+	}
+}
+
+// WithBackoff sets the delay before the first retry and the cap the
+// doubling delays grow to
+func WithBackoff(base, max time.Duration) RouterOption {
+	return func(r *Router) {
+		r.baseDelay = base
+		r.maxDelay = max
+	}
+}
+
+// WithTimeout bounds each attempt for providers without their own timeout.
+// Zero leaves attempts bounded only by the caller's context.
+func WithTimeout(timeout time.Duration) RouterOption {
+	return func(r *Router) {
+		r.timeout = timeout
+	}
+}
+
+// WithBreaker opens a provider's circuit after threshold consecutive
+// failures and keeps it open for cooldown
+func WithBreaker(threshold int, cooldown time.Duration) RouterOption {
+	return func(r *Router) {
+		if threshold > 0 {
+			r.breakerThreshold = threshold
+		}
+		r.breakerCooldown = cooldown
+	}
+}
+
+// New creates a router trying backends in order
+func New(backends []Backend, opts ...RouterOption) *Router {
+	r := &Router{
+		maxRetries:       2,
+		baseDelay:        250 * time.Millisecond,
+		maxDelay:         5 * time.Second,
+		timeout:          60 * time.Second,
+		breakerThreshold: 5,
+		breakerCooldown:  30 * time.Second,
+		now:              time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	for _, b := range backends {
+		r.backends = append(r.backends, &backend{
+			Backend: b,
+			breaker: newBreaker(r.breakerThreshold, r.breakerCooldown, r.now),
+		})
+	}
+
+	return r
+}
+
+// ============================================================================
+// LLM Implementation
+// ============================================================================
+
+// Chat implements the LLM interface. The response names the provider that
+// served it.
+func (r *Router) Chat(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Response, error) {
+	var resp llm.Response
+	cancel, err := r.route(ctx, opts, func(ctx context.Context, b *backend, opts []llm.Option) error {
+		var err error
+		resp, err = b.LLM.Chat(ctx, messages, opts...)
+		resp.Provider = b.Name
+		return err
+	})
+	if err != nil {
+		return llm.Response{}, err
+	}
+	cancel()
+	return resp, nil
+}
+
+// ChatStream implements the LLM interface. Only opening the stream falls
+// back; a stream failing midway reports the error. The stream has a
+// Provider method naming the provider serving it, and the attempt timeout
+// covers the whole stream.
+func (r *Router) ChatStream(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Stream, error) {
+	var stream llm.Stream
+	var served *backend
+	cancel, err := r.route(ctx, opts, func(ctx context.Context, b *backend, opts []llm.Option) error {
+		var err error
+		stream, err = b.LLM.ChatStream(ctx, messages, opts...)
+		served = b
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &routedStream{
+		Stream:   stream,
+		provider: served.Name,
+		breaker:  served.breaker,
+		cancel:   cancel,
+	}, nil
+}
+
+// routedStream reports mid-stream provider failures to the breaker
+type routedStream struct {
+	llm.Stream
+	provider string
+	breaker  *breaker
+	cancel   context.CancelFunc
+}
+
+// Provider names the provider serving the stream
+func (s *routedStream) Provider() string {
+	return s.provider
+}
+
+func (s *routedStream) Next() (llm.Message, error) {
+	msg, err := s.Stream.Next()
+	if err != nil && !errors.Is(err, io.EOF) && isProviderFailure(err) {
+		s.breaker.failure()
+	}
+	return msg, err
+}
+
+func (s *routedStream) Close() error {
+	err := s.Stream.Close()
+	s.cancel()
+	return err
+}
+
+func (s *routedStream) Usage() (llm.Usage, bool) {
+	return llm.StreamUsage(s.Stream)
+}
+
+// ============================================================================
+// Routing
+// ============================================================================
+
+type callFunc func(ctx context.Context, b *backend, opts []llm.Option) error
+
+// route runs call against the providers in order until one succeeds. It
+// returns the cancel func of the successful attempt's context, which the
+// caller releases once done with the result.
+func (r *Router) route(ctx context.Context, opts []llm.Option, call callFunc) (context.CancelFunc, error) {
+	options := llm.DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	var lastErr error
+	var tried []string
+	for _, b := range r.backends {
+		if !b.breaker.allow() {
+			continue
+		}
+		tried = append(tried, b.Name)
+
+		callOpts := opts
+		if model := b.model(options.Model); model != options.Model {
+			callOpts = append(slices.Clip(opts), llm.WithModel(model))
+		}
+
+		for attempt := 0; ; attempt++ {
+			cancel, err := r.attempt(ctx, b, callOpts, call)
+			if err == nil {
+				b.breaker.success()
+				return cancel, nil
+			}
+			lastErr = err
+
+			switch {
+			case ctx.Err() != nil:
+				b.breaker.release()
+				return nil, err
+			case !isProviderFailure(err):
+				// The provider answered; the request itself is at fault
+				b.breaker.success()
+				return nil, err
+			}
+
+			b.breaker.failure()
+			if !IsRetryable(err) || attempt >= r.maxRetries || b.breaker.isOpen() {
+				break
+			}
+			if err := sleep(ctx, r.backoff(attempt)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(tried) == 0 {
+		return nil, errorRegistry.New(ErrNoProviderAvailable).
+			WithDetail("providers", r.names())
+	}
+	return nil, errorRegistry.NewWithCause(ErrAllProvidersFailed, lastErr).
+		WithDetail("providers", tried)
+}
+
+// attempt makes one call under the provider's timeout.
+func (r *Router) attempt(ctx context.Context, b *backend, opts []llm.Option, call callFunc) (context.CancelFunc, error) {
+	timeout := b.Timeout
+	if timeout == 0 {
+		timeout = r.timeout
+	}
+
+	attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+	if timeout > 0 {
+		attemptCtx, cancel = context.WithTimeout(ctx, timeout)
+	}
+
+	err := call(attemptCtx, b, opts)
+	if err == nil {
+		return cancel, nil
+	}
+
+	timedOut := ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded)
+	cancel()
+	if timedOut {
+		return nil, errorRegistry.NewWithCause(ErrTimeout, err).
+			WithDetail("provider", b.Name).
+			WithDetail("timeout", timeout.String())
+	}
+	return nil, err
+}
+
+// backoff is the wait before retry n+1: the base delay doubled n times,
+// capped, with the upper half jittered so retries from concurrent calls
+// spread out.
+func (r *Router) backoff(n int) time.Duration {
+	delay := r.baseDelay
+	for i := 0; i < n && delay < r.maxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, r.maxDelay)
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (r *Router) names() []string {
+	names := make([]string, len(r.backends))
+	for i, b := range r.backends {
+		names[i] = b.Name
+	}
+	return names
+}
+
+// ============================================================================
+// Health
+// ============================================================================
+
+// ProviderHealth is a provider's circuit breaker state
+type ProviderHealth struct {
+	Name                string       `json:"name"`
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+
+	// RetryAt is when an open breaker lets the next probe through
+	RetryAt *time.Time `json:"retry_at,omitempty"`
+}
+
+// Health reports every provider's breaker, in routing order
+func (r *Router) Health() []ProviderHealth {
+	health := make([]ProviderHealth, len(r.backends))
+	for i, b := range r.backends {
+		state, failures, retryAt := b.breaker.snapshot()
+		health[i] = ProviderHealth{Name: b.Name, State: state, ConsecutiveFailures: failures}
+		if !retryAt.IsZero() {
+			health[i].RetryAt = &retryAt
+		}
+	}
+	return health
+}
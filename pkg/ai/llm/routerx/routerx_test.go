@@ -0,0 +1,412 @@
+package routerx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+var (
+	testRegistry = errx.NewRegistry("ROUTERTEST")
+
+	errRateLimit    = testRegistry.Register("RATE_LIMIT", errx.TypeExternal, http.StatusTooManyRequests, "rate limited")
+	errUnavailable  = testRegistry.Register("UNAVAILABLE", errx.TypeExternal, http.StatusServiceUnavailable, "unavailable")
+	errUnauthorized = testRegistry.Register("UNAUTHORIZED", errx.TypeAuthorization, http.StatusUnauthorized, "bad key")
+	errInvalid      = testRegistry.Register("INVALID", errx.TypeValidation, http.StatusBadRequest, "bad request")
+)
+
+// stubLLM answers from a script of errors, then succeeds. It records the
+// model each call asked for.
+type stubLLM struct {
+	mu     sync.Mutex
+	errs   []error
+	models []string
+	delay  time.Duration
+}
+
+func (s *stubLLM) next(ctx context.Context, opts []llm.Option) error {
+	options := llm.DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	s.mu.Lock()
+	s.models = append(s.models, options.Model)
+	var err error
+	if len(s.errs) > 0 {
+		err, s.errs = s.errs[0], s.errs[1:]
+	}
+	s.mu.Unlock()
+
+	if s.delay > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.delay):
+		}
+	}
+	return err
+}
+
+func (s *stubLLM) Chat(ctx context.Context, _ []llm.Message, opts ...llm.Option) (llm.Response, error) {
+	if err := s.next(ctx, opts); err != nil {
+		return llm.Response{}, err
+	}
+	return llm.Response{Message: llm.NewAssistantMessage("ok")}, nil
+}
+
+func (s *stubLLM) ChatStream(ctx context.Context, _ []llm.Message, opts ...llm.Option) (llm.Stream, error) {
+	if err := s.next(ctx, opts); err != nil {
+		return nil, err
+	}
+	return &stubStream{chunks: []string{"o", "k"}}, nil
+}
+
+func (s *stubLLM) calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.models)
+}
+
+type stubStream struct {
+	chunks []string
+	err    error
+}
+
+func (s *stubStream) Next() (llm.Message, error) {
+	if len(s.chunks) == 0 {
+		if s.err != nil {
+			return llm.Message{}, s.err
+		}
+		return llm.Message{}, io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return llm.Message{Role: llm.RoleAssistant, Content: chunk}, nil
+}
+
+func (s *stubStream) Close() error { return nil }
+
+// clock is a settable time source for breaker cooldowns
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func withClock(c *clock) RouterOption {
+	return func(r *Router) {
+		r.now = c.Now
+	}
+}
+
+func newTestRouter(backends []Backend, opts ...RouterOption) *Router {
+	return New(backends, append([]RouterOption{WithBackoff(time.Millisecond, 2*time.Millisecond)}, opts...)...)
+}
+
+func chat(r *Router) (llm.Response, error) {
+	return r.Chat(context.Background(), []llm.Message{llm.NewUserMessage("hi")})
+}
+
+func TestChatRetriesThenFallsBack(t *testing.T) {
+	primary := &stubLLM{errs: []error{
+		testRegistry.New(errRateLimit),
+		testRegistry.New(errUnavailable),
+		testRegistry.New(errUnavailable),
+	}}
+	backup := &stubLLM{}
+	r := newTestRouter([]Backend{{Name: "primary", LLM: primary}, {Name: "backup", LLM: backup}})
+
+	resp, err := chat(r)
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if resp.Provider != "backup" || resp.Message.Content != "ok" {
+		t.Fatalf("response = %+v, want it served by backup", resp)
+	}
+	if primary.calls() != 3 || backup.calls() != 1 {
+		t.Fatalf("calls = %d/%d, want the primary tried 3 times", primary.calls(), backup.calls())
+	}
+}
+
+func TestChatRecoversOnRetry(t *testing.T) {
+	primary := &stubLLM{errs: []error{testRegistry.New(errRateLimit)}}
+	r := newTestRouter([]Backend{{Name: "primary", LLM: primary}, {Name: "backup", LLM: &stubLLM{}}})
+
+	resp, err := chat(r)
+	if err != nil || resp.Provider != "primary" {
+		t.Fatalf("Chat = %+v, %v, want primary to succeed on retry", resp, err)
+	}
+}
+
+func TestChatFallsBackWithoutRetryingAuthErrors(t *testing.T) {
+	primary := &stubLLM{errs: []error{testRegistry.New(errUnauthorized)}}
+	r := newTestRouter([]Backend{{Name: "primary", LLM: primary}, {Name: "backup", LLM: &stubLLM{}}})
+
+	resp, err := chat(r)
+	if err != nil || resp.Provider != "backup" {
+		t.Fatalf("Chat = %+v, %v", resp, err)
+	}
+	if primary.calls() != 1 {
+		t.Fatalf("primary calls = %d, want no retry", primary.calls())
+	}
+}
+
+func TestChatReturnsRequestErrorsAsIs(t *testing.T) {
+	primary := &stubLLM{errs: []error{testRegistry.New(errInvalid)}}
+	backup := &stubLLM{}
+	r := newTestRouter([]Backend{{Name: "primary", LLM: primary}, {Name: "backup", LLM: backup}})
+
+	_, err := chat(r)
+	assertCode(t, err, errInvalid)
+	if primary.calls() != 1 || backup.calls() != 0 {
+		t.Fatalf("calls = %d/%d, want one call and no fallback", primary.calls(), backup.calls())
+	}
+	if h := r.Health()[0]; h.ConsecutiveFailures != 0 {
+		t.Fatalf("health = %+v, want request errors not counted", h)
+	}
+}
+
+func TestChatReportsWhenEveryProviderFails(t *testing.T) {
+	down := func() *stubLLM {
+		return &stubLLM{errs: []error{testRegistry.New(errUnavailable), testRegistry.New(errUnavailable)}}
+	}
+	r := newTestRouter([]Backend{{Name: "a", LLM: down()}, {Name: "b", LLM: down()}}, WithMaxRetries(1))
+
+	_, err := chat(r)
+	assertCode(t, err, ErrAllProvidersFailed)
+
+	var e *errx.Error
+	errors.As(err, &e)
+	if tried, _ := e.Details["providers"].([]string); !slices.Equal(tried, []string{"a", "b"}) {
+		t.Fatalf("providers = %v", e.Details["providers"])
+	}
+	if cause := errors.Unwrap(err); !errors.As(cause, &e) || e.Code != errUnavailable.Code {
+		t.Fatalf("cause = %v, want the last provider error", cause)
+	}
+}
+
+func TestChatTimesOutSlowAttempts(t *testing.T) {
+	slow := &stubLLM{delay: time.Second}
+	r := newTestRouter([]Backend{
+		{Name: "slow", LLM: slow, Timeout: 10 * time.Millisecond},
+		{Name: "fast", LLM: &stubLLM{}},
+	}, WithMaxRetries(1))
+
+	resp, err := chat(r)
+	if err != nil || resp.Provider != "fast" {
+		t.Fatalf("Chat = %+v, %v", resp, err)
+	}
+	if slow.calls() != 2 {
+		t.Fatalf("slow calls = %d, want timeouts retried", slow.calls())
+	}
+}
+
+func TestChatStopsWhenCallerCancels(t *testing.T) {
+	backup := &stubLLM{}
+	r := newTestRouter([]Backend{
+		{Name: "slow", LLM: &stubLLM{delay: time.Second}},
+		{Name: "backup", LLM: backup},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := r.Chat(ctx, []llm.Message{llm.NewUserMessage("hi")}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want the caller's deadline", err)
+	}
+	if backup.calls() != 0 {
+		t.Fatal("fell back after the caller gave up")
+	}
+}
+
+func TestChatMapsModelsPerProvider(t *testing.T) {
+	unauthorized := testRegistry.New(errUnauthorized)
+	primary := &stubLLM{errs: []error{unauthorized, unauthorized, unauthorized}}
+	backup := &stubLLM{}
+	r := newTestRouter([]Backend{
+		{Name: "openai", LLM: primary},
+		{Name: "anthropic", LLM: backup, Models: map[string]string{"gpt-4o": "claude-sonnet-4-5", "*": "claude-haiku-4-5"}},
+	})
+
+	for _, model := range []string{"gpt-4o", "gpt-4o-mini", ""} {
+		if _, err := r.Chat(context.Background(), []llm.Message{llm.NewUserMessage("hi")}, llm.WithModel(model)); err != nil {
+			t.Fatalf("Chat(%q): %v", model, err)
+		}
+	}
+
+	if !slices.Equal(primary.models, []string{"gpt-4o", "gpt-4o-mini", ""}) {
+		t.Fatalf("primary models = %v, want them unchanged", primary.models)
+	}
+	if !slices.Equal(backup.models, []string{"claude-sonnet-4-5", "claude-haiku-4-5", ""}) {
+		t.Fatalf("backup models = %v", backup.models)
+	}
+}
+
+// ============================================================================
+// Circuit Breaker
+// ============================================================================
+
+func TestBreakerOpensAndProbesAfterCooldown(t *testing.T) {
+	clk := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	primary := &stubLLM{errs: []error{
+		testRegistry.New(errUnavailable),
+		testRegistry.New(errUnavailable),
+		testRegistry.New(errUnavailable), // failed probe
+	}}
+	r := newTestRouter([]Backend{{Name: "primary", LLM: primary}, {Name: "backup", LLM: &stubLLM{}}},
+		WithMaxRetries(0), WithBreaker(2, time.Minute), withClock(clk))
+
+	for range 2 {
+		if resp, err := chat(r); err != nil || resp.Provider != "backup" {
+			t.Fatalf("Chat = %+v, %v", resp, err)
+		}
+	}
+	health := r.Health()[0]
+	if health.State != BreakerOpen || health.ConsecutiveFailures != 2 || health.RetryAt == nil ||
+		!health.RetryAt.Equal(clk.Now().Add(time.Minute)) {
+		t.Fatalf("health = %+v, want open until the cooldown ends", health)
+	}
+
+	// Open: the primary is skipped
+	chat(r)
+	if primary.calls() != 2 {
+		t.Fatalf("primary calls = %d, want it skipped while open", primary.calls())
+	}
+
+	// Cooldown over: one probe, which fails and reopens the breaker
+	clk.Advance(time.Minute)
+	if state := r.Health()[0].State; state != BreakerHalfOpen {
+		t.Fatalf("state = %s, want half_open after the cooldown", state)
+	}
+	chat(r)
+	if primary.calls() != 3 || r.Health()[0].State != BreakerOpen {
+		t.Fatalf("calls = %d, state = %s, want a failed probe to reopen", primary.calls(), r.Health()[0].State)
+	}
+
+	// A successful probe closes it
+	clk.Advance(time.Minute)
+	if resp, err := chat(r); err != nil || resp.Provider != "primary" {
+		t.Fatalf("Chat = %+v, %v", resp, err)
+	}
+	if health := r.Health()[0]; health.State != BreakerClosed || health.ConsecutiveFailures != 0 {
+		t.Fatalf("health = %+v, want closed", health)
+	}
+}
+
+func TestChatReportsNoProviderWhenAllBreakersOpen(t *testing.T) {
+	down := &stubLLM{errs: []error{testRegistry.New(errUnavailable)}}
+	r := newTestRouter([]Backend{{Name: "only", LLM: down}}, WithMaxRetries(0), WithBreaker(1, time.Hour))
+
+	_, err := chat(r)
+	assertCode(t, err, ErrAllProvidersFailed)
+	_, err = chat(r)
+	assertCode(t, err, ErrNoProviderAvailable)
+}
+
+// ============================================================================
+// Streaming
+// ============================================================================
+
+func TestChatStreamFallsBackOnOpen(t *testing.T) {
+	primary := &stubLLM{errs: []error{testRegistry.New(errUnauthorized)}}
+	r := newTestRouter([]Backend{{Name: "primary", LLM: primary}, {Name: "backup", LLM: &stubLLM{}}})
+
+	stream, err := r.ChatStream(context.Background(), []llm.Message{llm.NewUserMessage("hi")})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	defer stream.Close()
+
+	if p, ok := stream.(interface{ Provider() string }); !ok || p.Provider() != "backup" {
+		t.Fatalf("stream = %T, want it served by backup", stream)
+	}
+	var content string
+	for {
+		msg, err := stream.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		content += msg.Content
+	}
+	if content != "ok" {
+		t.Fatalf("content = %q", content)
+	}
+}
+
+func TestChatStreamCountsMidStreamFailures(t *testing.T) {
+	r := newTestRouter([]Backend{{Name: "only", LLM: &stubLLM{}}})
+	b := r.backends[0]
+
+	stream := &routedStream{
+		Stream:   &stubStream{err: testRegistry.New(errUnavailable)},
+		provider: b.Name,
+		breaker:  b.breaker,
+		cancel:   func() {},
+	}
+	if _, err := stream.Next(); err == nil {
+		t.Fatal("Next: want the provider error")
+	}
+	if h := r.Health()[0]; h.ConsecutiveFailures != 1 {
+		t.Fatalf("health = %+v, want the failure counted", h)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"rate limit", testRegistry.New(errRateLimit), true},
+		{"server error", testRegistry.New(errUnavailable), true},
+		{"router timeout", errorRegistry.New(ErrTimeout), true},
+		{"deadline", context.DeadlineExceeded, true},
+		{"unauthorized", testRegistry.New(errUnauthorized), false},
+		{"validation", testRegistry.New(errInvalid), false},
+		{"canceled", context.Canceled, false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Fatalf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// assertCode fails unless err carries the registered error code.
+func assertCode(t *testing.T, err error, code *errx.ErrorCode) {
+	t.Helper()
+	var e *errx.Error
+	if !errors.As(err, &e) || e.Code != code.Code {
+		t.Fatalf("error = %v, want code %s", err, code.Code)
+	}
+}
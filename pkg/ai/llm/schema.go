@@ -0,0 +1,508 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Schema is a JSON Schema describing a model's structured output or a
+// tool's parameters. SchemaFor derives one from a Go type.
+type Schema struct {
+	Type        string // object, array, string, integer, number or boolean
+	Nullable    bool   // null is accepted as well as Type
+	Title       string
+	Description string
+	Format      string
+	Enum        []any
+
+	// Properties of an object. Required lists the ones that must be present;
+	// a nullable property may be missing even when listed.
+	Properties map[string]*Schema
+	Required   []string
+
+	// Values describes the values of an object with free keys, such as a Go
+	// map. Objects without it accept only their Properties.
+	Values *Schema
+
+	// Items describes the elements of an array
+	Items *Schema
+
+	// order keeps the declaration order of Properties when derived from a
+	// struct, since models answer in the order they are shown
+	order []string
+}
+
+// SchemaFor derives a JSON Schema from T. See SchemaOf.
+func SchemaFor[T any]() (*Schema, error) {
+	return SchemaOf(reflect.TypeFor[T]())
+}
+
+// SchemaOf derives a JSON Schema from t, following encoding/json naming.
+// Struct fields may be described with tags:
+//
+//	description:"..."   what the field holds, shown to the model
+//	enum:"a,b,c"        allowed values; on a slice, allowed elements
+//	required:"false"    the field may be null; "true" forces a pointer or
+//	                    omitempty field to be given
+//
+// Fields are required unless they are pointers or omitempty. Every property
+// is listed as required and optional ones are nullable instead, as strict
+// structured output demands. time.Time is an RFC 3339 string; interfaces,
+// channels, functions and recursive types are not supported.
+func SchemaOf(t reflect.Type) (*Schema, error) {
+	b := &schemaBuilder{visiting: make(map[reflect.Type]bool)}
+	s, err := b.build(t)
+	if err != nil {
+		return nil, err
+	}
+	s.Title = schemaName(t)
+	return s, nil
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+type schemaBuilder struct {
+	visiting map[reflect.Type]bool
+}
+
+func (b *schemaBuilder) build(t reflect.Type) (*Schema, error) {
+	s := &Schema{}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		s.Nullable = true
+	}
+
+	if t == timeType {
+		s.Type, s.Format = "string", "date-time"
+		return s, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		s.Type = "string"
+	case reflect.Bool:
+		s.Type = "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s.Type = "integer"
+	case reflect.Float32, reflect.Float64:
+		s.Type = "number"
+
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json writes bytes as base64
+			s.Type = "string"
+			return s, nil
+		}
+		items, err := b.build(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		s.Type, s.Items = "array", items
+
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, unsupportedSchema(t, "map keys must be strings")
+		}
+		values, err := b.build(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		s.Type, s.Values = "object", values
+
+	case reflect.Struct:
+		if b.visiting[t] {
+			return nil, unsupportedSchema(t, "recursive types are not supported")
+		}
+		b.visiting[t] = true
+		defer delete(b.visiting, t)
+
+		s.Type = "object"
+		s.Properties = make(map[string]*Schema)
+		if err := b.fields(t, s); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, unsupportedSchema(t, "kind "+t.Kind().String()+" is not supported")
+	}
+	return s, nil
+}
+
+// fields adds the properties of struct t to s, flattening embedded structs
+// the way encoding/json does.
+func (b *schemaBuilder) fields(t reflect.Type, s *Schema) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := b.fields(ft, s); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop, err := b.build(f.Type)
+		if err != nil {
+			return err
+		}
+		prop.Description = f.Tag.Get("description")
+		if raw := f.Tag.Get("enum"); raw != "" {
+			target := prop
+			if prop.Type == "array" {
+				target = prop.Items
+			}
+			if target.Enum, err = parseEnum(target.Type, raw); err != nil {
+				return unsupportedSchema(t, fmt.Sprintf("field %s: %v", f.Name, err))
+			}
+		}
+
+		required := !prop.Nullable && !slices.Contains(strings.Split(opts, ","), "omitempty")
+		switch f.Tag.Get("required") {
+		case "true":
+			required = true
+		case "false":
+			required = false
+		}
+		prop.Nullable = !required
+
+		if _, seen := s.Properties[name]; !seen {
+			s.order = append(s.order, name)
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+	return nil
+}
+
+// parseEnum reads the comma-separated values of an enum tag as typ.
+func parseEnum(typ, raw string) ([]any, error) {
+	parts := strings.Split(raw, ",")
+	values := make([]any, 0, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		switch typ {
+		case "string":
+			values = append(values, p)
+		case "integer":
+			n, err := strconv.ParseInt(p, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("enum value %q is not an integer", p)
+			}
+			values = append(values, n)
+		case "number":
+			n, err := strconv.ParseFloat(p, 64)
+			if err != nil {
+				return nil, fmt.Errorf("enum value %q is not a number", p)
+			}
+			values = append(values, n)
+		default:
+			return nil, fmt.Errorf("enum is not supported on %s", typ)
+		}
+	}
+	return values, nil
+}
+
+// schemaName turns a Go type name into a schema name, e.g. ListingCopy
+// into listing_copy. Unnamed types get "response".
+func schemaName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	name, _, _ := strings.Cut(t.Name(), "[")
+	if name == "" {
+		return "response"
+	}
+
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		// A word starts at an upper case letter after a lower case one, or
+		// at the last capital of an acronym: HTTPStatus is http_status
+		if i > 0 && unicode.IsUpper(r) && (!unicode.IsUpper(runes[i-1]) ||
+			i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+func unsupportedSchema(t reflect.Type, reason string) error {
+	return errorRegistry.New(ErrUnsupportedSchema).
+		WithDetail("type", t.String()).
+		WithDetail("reason", reason)
+}
+
+// strict reports whether the schema fits strict structured output, which
+// rules out objects with free keys.
+func (s *Schema) strict() bool {
+	if s == nil {
+		return true
+	}
+	if s.Type == "object" && s.Values != nil {
+		return false
+	}
+	for _, p := range s.Properties {
+		if !p.strict() {
+			return false
+		}
+	}
+	return s.Items.strict()
+}
+
+// ============================================================================
+// Encoding
+// ============================================================================
+
+// MarshalJSON writes the schema as JSON Schema, keeping struct properties
+// in declaration order.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	write := func(key string, value any) error {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, "%q:", key)
+		buf.Write(data)
+		return nil
+	}
+
+	if s.Type != "" {
+		var typ any = s.Type
+		if s.Nullable {
+			typ = []string{s.Type, "null"}
+		}
+		if err := write("type", typ); err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range []struct{ key, value string }{
+		{"title", s.Title}, {"description", s.Description}, {"format", s.Format},
+	} {
+		if f.value != "" {
+			if err := write(f.key, f.value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(s.Enum) > 0 {
+		enum := s.Enum
+		if s.Nullable {
+			enum = append(slices.Clip(enum), nil)
+		}
+		if err := write("enum", enum); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.Type == "object" {
+		if err := write("properties", orderedProperties{s}); err != nil {
+			return nil, err
+		}
+		if err := write("required", append([]string{}, s.Required...)); err != nil {
+			return nil, err
+		}
+		var additional any = false
+		if s.Values != nil {
+			additional = s.Values
+		}
+		if err := write("additionalProperties", additional); err != nil {
+			return nil, err
+		}
+	}
+	if s.Items != nil {
+		if err := write("items", s.Items); err != nil {
+			return nil, err
+		}
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// orderedProperties encodes as a JSON object in declaration order, or in
+// key order for schemas built by hand.
+type orderedProperties struct{ s *Schema }
+
+func (p orderedProperties) MarshalJSON() ([]byte, error) {
+	s := p.s
+	names := s.order
+	if len(names) != len(s.Properties) {
+		names = make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range names {
+		data, err := json.Marshal(s.Properties[name])
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, "%q:", name)
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// ============================================================================
+// Validation
+// ============================================================================
+
+// SchemaError is a document that does not match a schema. Its message is
+// written to be fed back to the model.
+type SchemaError struct {
+	Path    string // e.g. $.items[2].category
+	Message string
+}
+
+func (e *SchemaError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// Validate checks a JSON document against the schema and returns the first
+// mismatch as a *SchemaError.
+func (s *Schema) Validate(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return &SchemaError{Path: "$", Message: "invalid JSON: " + err.Error()}
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return &SchemaError{Path: "$", Message: "unexpected data after the JSON value"}
+	}
+	return s.validate("$", v)
+}
+
+func (s *Schema) validate(path string, v any) error {
+	if v == nil {
+		if s.Nullable || s.Type == "" {
+			return nil
+		}
+		return &SchemaError{Path: path, Message: "must not be null"}
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return &SchemaError{Path: path, Message: "must be an object"}
+		}
+		for _, name := range s.Required {
+			if prop := s.Properties[name]; prop != nil && prop.Nullable {
+				continue
+			}
+			if _, ok := obj[name]; !ok {
+				return &SchemaError{Path: path, Message: fmt.Sprintf("missing required property %q", name)}
+			}
+		}
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			prop := s.Properties[key]
+			if prop == nil {
+				prop = s.Values
+			}
+			if prop == nil {
+				return &SchemaError{Path: path, Message: fmt.Sprintf("unknown property %q", key)}
+			}
+			if err := prop.validate(path+"."+key, obj[key]); err != nil {
+				return err
+			}
+		}
+
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			return &SchemaError{Path: path, Message: "must be an array"}
+		}
+		if s.Items != nil {
+			for i, item := range arr {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+					return err
+				}
+			}
+		}
+
+	case "string":
+		if _, ok := v.(string); !ok {
+			return &SchemaError{Path: path, Message: "must be a string"}
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, v.(string)); err != nil {
+				return &SchemaError{Path: path, Message: "must be an RFC 3339 date-time"}
+			}
+		}
+
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return &SchemaError{Path: path, Message: "must be an integer"}
+		}
+		if _, err := n.Int64(); err != nil {
+			return &SchemaError{Path: path, Message: "must be an integer"}
+		}
+
+	case "number":
+		if _, ok := v.(json.Number); !ok {
+			return &SchemaError{Path: path, Message: "must be a number"}
+		}
+
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return &SchemaError{Path: path, Message: "must be a boolean"}
+		}
+	}
+
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return fmt.Sprint(e) == fmt.Sprint(v) }) {
+		allowed := make([]string, len(s.Enum))
+		for i, e := range s.Enum {
+			data, _ := json.Marshal(e)
+			allowed[i] = string(data)
+		}
+		return &SchemaError{Path: path, Message: "must be one of " + strings.Join(allowed, ", ")}
+	}
+	return nil
+}
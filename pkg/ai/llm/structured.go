@@ -0,0 +1,67 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+)
+
+// ChatStructured asks the model for a T. The JSON Schema derived from T (see
+// SchemaOf) is sent as the response format and the response is validated
+// against it; a response that doesn't match is fed back to the model with
+// the mismatch, up to the configured StructuredRetries. The usage returned
+// covers every attempt.
+//
+// T must be a struct: providers take an object at the top level of
+// structured output.
+func ChatStructured[T any](ctx context.Context, client LLM, messages []Message, opts ...Option) (T, Usage, error) {
+	var result T
+	var usage Usage
+
+	schema, err := SchemaFor[T]()
+	if err != nil {
+		return result, usage, err
+	}
+	if schema.Type != "object" || schema.Nullable {
+		return result, usage, errorRegistry.New(ErrUnsupportedSchema).
+			WithDetail("type", schema.Title).
+			WithDetail("reason", "structured output must be a struct")
+	}
+
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	opts = append(slices.Clip(opts), WithJSONSchemaResponseFormat(schema))
+	messages = slices.Clip(messages)
+
+	for attempt := 0; ; attempt++ {
+		resp, err := client.Chat(ctx, messages, opts...)
+		if err != nil {
+			return result, usage, err
+		}
+		usage.PromptTokens += resp.Usage.PromptTokens
+		usage.CompletionTokens += resp.Usage.CompletionTokens
+		usage.TotalTokens += resp.Usage.TotalTokens
+
+		content := resp.Message.Content
+		invalid := schema.Validate([]byte(content))
+		if invalid == nil {
+			if invalid = json.Unmarshal([]byte(content), &result); invalid == nil {
+				return result, usage, nil
+			}
+			result = *new(T)
+		}
+
+		if attempt >= options.StructuredRetries {
+			return result, usage, errorRegistry.NewWithCause(ErrStructuredOutput, invalid).
+				WithDetail("schema", schema.Title).
+				WithDetail("attempts", attempt+1)
+		}
+		messages = append(messages,
+			NewAssistantMessage(content),
+			NewUserMessage("That response does not match the required JSON schema: "+invalid.Error()+
+				". Reply again with the complete corrected JSON object only."),
+		)
+	}
+}
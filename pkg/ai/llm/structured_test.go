@@ -0,0 +1,216 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+type inspectionReport struct {
+	Score    int      `json:"score" description:"1-10"`
+	Summary  *string  `json:"summary"`
+	Tags     []string `json:"tags,omitempty" enum:"urgent,cosmetic"`
+	Findings []struct {
+		Severity string  `json:"severity" enum:"minor,major"`
+		Weight   float64 `json:"weight"`
+	} `json:"findings"`
+	CheckedAt  time.Time `json:"checked_at"`
+	Internal   string    `json:"-"`
+	auditTrail string
+}
+
+func TestSchemaFor(t *testing.T) {
+	schema, err := SchemaFor[inspectionReport]()
+	if err != nil {
+		t.Fatalf("SchemaFor: %v", err)
+	}
+
+	data, _ := json.Marshal(schema)
+	want := `{"type":"object","title":"inspection_report","properties":{` +
+		`"score":{"type":"integer","description":"1-10"},` +
+		`"summary":{"type":["string","null"]},` +
+		`"tags":{"type":["array","null"],"items":{"type":"string","enum":["urgent","cosmetic"]}},` +
+		`"findings":{"type":"array","items":{"type":"object","properties":{` +
+		`"severity":{"type":"string","enum":["minor","major"]},"weight":{"type":"number"}},` +
+		`"required":["severity","weight"],"additionalProperties":false}},` +
+		`"checked_at":{"type":"string","format":"date-time"}},` +
+		`"required":["score","summary","tags","findings","checked_at"],"additionalProperties":false}`
+	if string(data) != want {
+		t.Fatalf("schema =\n%s\nwant\n%s", data, want)
+	}
+	if !schema.strict() {
+		t.Fatal("struct schema should be strict")
+	}
+}
+
+func TestSchemaForRejectsUnsupportedTypes(t *testing.T) {
+	type recursive struct {
+		Children []recursive `json:"children"`
+	}
+	type withAny struct {
+		Value any `json:"value"`
+	}
+
+	for name, derive := range map[string]func() (*Schema, error){
+		"recursive": SchemaFor[recursive],
+		"interface": SchemaFor[withAny],
+		"int keys":  SchemaFor[map[int]string],
+	} {
+		var e *errx.Error
+		if _, err := derive(); !errors.As(err, &e) || e.Code != ErrUnsupportedSchema.Code {
+			t.Errorf("%s: error = %v, want %s", name, err, ErrUnsupportedSchema.Code)
+		}
+	}
+}
+
+func TestSchemaName(t *testing.T) {
+	type HTTPStatusReport struct{}
+	schema, _ := SchemaFor[HTTPStatusReport]()
+	if schema.Title != "http_status_report" {
+		t.Fatalf("title = %q", schema.Title)
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	schema, _ := SchemaFor[inspectionReport]()
+	valid := `{"score": 7, "summary": null, "findings": [{"severity": "minor", "weight": 0.5}], "checked_at": "2024-05-01T10:00:00Z"}`
+	if err := schema.Validate([]byte(valid)); err != nil {
+		t.Fatalf("Validate(valid) = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"not json", `{"score": `, "$: invalid JSON"},
+		{"trailing data", `{} {}`, "$: unexpected data"},
+		{"nullable missing", `{"score": 7, "findings": [], "checked_at": "2024-05-01T10:00:00Z"}`, ""},
+		{"missing required", `{"findings": [], "checked_at": "2024-05-01T10:00:00Z"}`, `$: missing required property "score"`},
+		{"fraction", `{"score": 7.5, "findings": [], "checked_at": "2024-05-01T10:00:00Z"}`, "$.score: must be an integer"},
+		{"enum", `{"score": 7, "findings": [{"severity": "huge", "weight": 1}], "checked_at": "2024-05-01T10:00:00Z"}`, `$.findings[0].severity: must be one of "minor", "major"`},
+		{"null", `{"score": 7, "findings": null, "checked_at": "2024-05-01T10:00:00Z"}`, "$.findings: must not be null"},
+		{"unknown", `{"score": 7, "findings": [], "checked_at": "2024-05-01T10:00:00Z", "extra": 1}`, `$: unknown property "extra"`},
+		{"date", `{"score": 7, "findings": [], "checked_at": "yesterday"}`, "$.checked_at: must be an RFC 3339 date-time"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.Validate([]byte(tt.doc))
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Validate = %v, want a nullable property to be optional", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Fatalf("Validate = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// scriptedLLM answers with its replies in order
+type scriptedLLM struct {
+	replies []string
+	calls   [][]Message
+	opts    []*ChatOptions
+}
+
+func (l *scriptedLLM) Chat(_ context.Context, messages []Message, opts ...Option) (Response, error) {
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	l.calls = append(l.calls, messages)
+	l.opts = append(l.opts, options)
+	reply := l.replies[min(len(l.calls), len(l.replies))-1]
+	return Response{
+		Message: NewAssistantMessage(reply),
+		Usage:   Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+	}, nil
+}
+
+func (l *scriptedLLM) ChatStream(context.Context, []Message, ...Option) (Stream, error) {
+	return nil, errors.New("not streaming")
+}
+
+type listingCopy struct {
+	Title    string   `json:"title"`
+	Language string   `json:"language" enum:"es,en"`
+	Keywords []string `json:"keywords"`
+}
+
+func TestChatStructured(t *testing.T) {
+	model := &scriptedLLM{replies: []string{`{"title": "RAV4", "language": "es", "keywords": ["suv"]}`}}
+
+	got, usage, err := ChatStructured[listingCopy](context.Background(), model, []Message{NewUserMessage("write")})
+	if err != nil {
+		t.Fatalf("ChatStructured: %v", err)
+	}
+	if got.Title != "RAV4" || got.Language != "es" || len(got.Keywords) != 1 || usage.TotalTokens != 15 {
+		t.Fatalf("got %+v, usage %+v", got, usage)
+	}
+
+	format := model.opts[0].ResponseFormat
+	if format == nil || format.Type != JSONSchema || format.Name != "listing_copy" || !format.Strict {
+		t.Fatalf("response format = %+v", format)
+	}
+}
+
+func TestChatStructuredFeedsErrorsBack(t *testing.T) {
+	model := &scriptedLLM{replies: []string{
+		`{"title": "RAV4", "language": "fr", "keywords": []}`,
+		`{"title": "RAV4", "language": "en", "keywords": []}`,
+	}}
+	messages := []Message{NewUserMessage("write")}
+
+	got, usage, err := ChatStructured[listingCopy](context.Background(), model, messages)
+	if err != nil {
+		t.Fatalf("ChatStructured: %v", err)
+	}
+	if got.Language != "en" || usage.TotalTokens != 30 {
+		t.Fatalf("got %+v, usage %+v", got, usage)
+	}
+
+	retry := model.calls[1]
+	if len(retry) != 3 || retry[1].Role != RoleAssistant || !strings.Contains(retry[2].Content, `$.language: must be one of "es", "en"`) {
+		t.Fatalf("retry conversation = %+v", retry)
+	}
+	if len(messages) != 1 {
+		t.Fatal("the caller's messages were modified")
+	}
+}
+
+func TestChatStructuredGivesUp(t *testing.T) {
+	model := &scriptedLLM{replies: []string{`not json`}}
+
+	_, usage, err := ChatStructured[listingCopy](context.Background(), model, []Message{NewUserMessage("write")},
+		WithStructuredRetries(1))
+
+	var e *errx.Error
+	if !errors.As(err, &e) || e.Code != ErrStructuredOutput.Code {
+		t.Fatalf("error = %v, want %s", err, ErrStructuredOutput.Code)
+	}
+	var invalid *SchemaError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("error = %v, want the schema mismatch as cause", err)
+	}
+	if len(model.calls) != 2 || usage.TotalTokens != 30 {
+		t.Fatalf("calls = %d, usage %+v", len(model.calls), usage)
+	}
+}
+
+func TestChatStructuredRequiresStruct(t *testing.T) {
+	model := &scriptedLLM{replies: []string{`[]`}}
+	if _, _, err := ChatStructured[[]string](context.Background(), model, []Message{NewUserMessage("x")}); err == nil {
+		t.Fatal("expected an error for a non-struct type")
+	}
+	if len(model.calls) != 0 {
+		t.Fatal("the model was called")
+	}
+}
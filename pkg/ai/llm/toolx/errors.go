@@ -0,0 +1,40 @@
+package toolx
+
+import (
+	"net/http"
+
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+var (
+	// Error registry for tool calls; these reach the model as tool results
+	errorRegistry = errx.NewRegistry("TOOLX")
+
+	ErrUnknownTool = errorRegistry.Register(
+		"UNKNOWN_TOOL",
+		errx.TypeNotFound,
+		http.StatusNotFound,
+		"No tool with this name exists",
+	)
+
+	ErrInvalidArguments = errorRegistry.Register(
+		"INVALID_ARGUMENTS",
+		errx.TypeValidation,
+		http.StatusBadRequest,
+		"Arguments do not match the tool's parameters",
+	)
+
+	ErrToolFailed = errorRegistry.Register(
+		"TOOL_FAILED",
+		errx.TypeInternal,
+		http.StatusInternalServerError,
+		"The tool failed",
+	)
+
+	ErrInvalidResult = errorRegistry.Register(
+		"INVALID_RESULT",
+		errx.TypeInternal,
+		http.StatusInternalServerError,
+		"The tool result could not be encoded",
+	)
+)
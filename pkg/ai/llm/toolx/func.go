@@ -0,0 +1,95 @@
+package toolx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+)
+
+// Func is a tool backed by a typed Go function. Its parameters are derived
+// from In, arguments are checked against them before fn runs, and the
+// result is sent back as JSON.
+type Func[In, Out any] struct {
+	name        string
+	description string
+	schema      *llm.Schema
+	fn          func(ctx context.Context, in In) (Out, error)
+}
+
+var _ Toolx = (*Func[struct{}, any])(nil)
+
+// NewFunc creates a tool calling fn. In must be a struct; its fields are the
+// tool's parameters, described with the tags llm.SchemaOf reads. NewFunc
+// panics if In cannot be described as a JSON schema, since tools are
+// defined once at startup.
+func NewFunc[In, Out any](name, description string, fn func(ctx context.Context, in In) (Out, error)) *Func[In, Out] {
+	schema, err := llm.SchemaFor[In]()
+	if err != nil {
+		panic(fmt.Sprintf("toolx: parameters of tool %q: %v", name, err))
+	}
+	if schema.Type != "object" || schema.Nullable {
+		panic(fmt.Sprintf("toolx: parameters of tool %q must be a struct", name))
+	}
+	schema.Title = ""
+
+	return &Func[In, Out]{name: name, description: description, schema: schema, fn: fn}
+}
+
+// Name implements Toolx
+func (f *Func[In, Out]) Name() string {
+	return f.name
+}
+
+// GetTool implements Toolx
+func (f *Func[In, Out]) GetTool() llm.Tool {
+	return llm.Tool{
+		Type: "function",
+		Function: llm.Function{
+			Name:        f.name,
+			Description: f.description,
+			Parameters:  f.schema,
+		},
+	}
+}
+
+// Call implements Toolx. It returns the result as a JSON string; string
+// results are returned as is.
+func (f *Func[In, Out]) Call(ctx context.Context, inputs string) (any, error) {
+	if strings.TrimSpace(inputs) == "" {
+		inputs = "{}"
+	}
+
+	if err := f.schema.Validate([]byte(inputs)); err != nil {
+		e := errorRegistry.NewWithCause(ErrInvalidArguments, err)
+		var invalid *llm.SchemaError
+		if errors.As(err, &invalid) {
+			e.WithDetail("path", invalid.Path).WithDetail("problem", invalid.Message)
+		}
+		return nil, e
+	}
+
+	var in In
+	if err := json.Unmarshal([]byte(inputs), &in); err != nil {
+		return nil, errorRegistry.NewWithCause(ErrInvalidArguments, err).
+			WithDetail("problem", err.Error())
+	}
+
+	out, err := f.fn(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	if s, ok := any(out).(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		return nil, errorRegistry.NewWithCause(ErrInvalidResult, err).
+			WithDetail("reason", err.Error())
+	}
+	return string(data), nil
+}
@@ -3,10 +3,13 @@ package toolx
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 
 	"github.com/Abraxas-365/divi/pkg/ai/llm"
+	"github.com/Abraxas-365/divi/pkg/errx"
 )
 
 type Toolx interface {
@@ -35,15 +38,32 @@ func (t *ToolxClient) GetTools() []llm.Tool {
 	return tools
 }
 
+// Call runs the tool the model asked for and returns its result as a tool
+// message. Failures are reported to the model in the message as
+// {"error": {"code", "message", "details"}} so it can correct itself; only
+// a cancelled context is returned as an error.
 func (t *ToolxClient) Call(ctx context.Context, tc llm.ToolCall) (llm.Message, error) {
 	tool, ok := t.tools[tc.Function.Name]
 	if !ok {
-		return llm.NewToolMessage(tc.ID, "This tool dont exists"), nil // create custom errors for this
+		names := make([]string, 0, len(t.tools))
+		for name := range t.tools {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return errorMessage(tc, errorRegistry.New(ErrUnknownTool).
+			WithDetail("available_tools", names)), nil
 	}
 
 	result, err := tool.Call(ctx, tc.Function.Arguments)
 	if err != nil {
-		return llm.NewToolMessage(tc.ID, "Error calling tool: "+err.Error()), nil //create a custom error for this
+		if ctx.Err() != nil {
+			return llm.Message{}, ctx.Err()
+		}
+		var e *errx.Error
+		if !errors.As(err, &e) {
+			e = errorRegistry.NewWithCause(ErrToolFailed, err).WithDetail("reason", err.Error())
+		}
+		return errorMessage(tc, e), nil
 	}
 
 	var resultStr string
@@ -64,9 +84,36 @@ func (t *ToolxClient) Call(ctx context.Context, tc llm.ToolCall) (llm.Message, e
 		// Use JSON marshaling for complex types
 		jsonBytes, jsonErr := json.Marshal(result)
 		if jsonErr != nil {
-			return llm.NewToolMessage(tc.ID, "Error converting result to string: "+jsonErr.Error()), nil //create a custom error for this
+			return errorMessage(tc, errorRegistry.NewWithCause(ErrInvalidResult, jsonErr).
+				WithDetail("reason", jsonErr.Error())), nil
 		}
 		resultStr = string(jsonBytes)
 	}
 	return llm.NewToolMessage(tc.ID, resultStr), nil
 }
+
+// toolError is how a failed call is reported to the model
+type toolError struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// errorMessage reports e as the result of tool call tc.
+func errorMessage(tc llm.ToolCall, e *errx.Error) llm.Message {
+	details := map[string]any{"tool": tc.Function.Name}
+	for k, v := range e.Details {
+		details[k] = v
+	}
+
+	data, err := json.Marshal(map[string]toolError{
+		"error": {Code: e.Code, Message: e.Message, Details: details},
+	})
+	if err != nil {
+		// Details the tool attached could not be encoded
+		data, _ = json.Marshal(map[string]toolError{
+			"error": {Code: e.Code, Message: e.Message, Details: map[string]any{"tool": tc.Function.Name}},
+		})
+	}
+	return llm.NewToolMessage(tc.ID, string(data))
+}
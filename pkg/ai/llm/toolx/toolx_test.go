@@ -0,0 +1,182 @@
+package toolx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+type lookupInput struct {
+	VIN  string `json:"vin" description:"17-character vehicle identification number"`
+	Zone string `json:"zone,omitempty" enum:"front,rear"`
+}
+
+type lookupOutput struct {
+	Brand string `json:"brand"`
+	Year  int    `json:"year"`
+}
+
+func newLookupTool(calls *int) *Func[lookupInput, lookupOutput] {
+	return NewFunc("lookup_vehicle", "Looks up a vehicle by VIN",
+		func(ctx context.Context, in lookupInput) (lookupOutput, error) {
+			*calls++
+			switch in.VIN {
+			case "missing":
+				return lookupOutput{}, errx.NotFound("vehicle not found")
+			case "broken":
+				return lookupOutput{}, errors.New("database unavailable")
+			}
+			return lookupOutput{Brand: "Toyota", Year: 2022}, nil
+		})
+}
+
+func call(name, arguments string) llm.ToolCall {
+	return llm.ToolCall{ID: "call_1", Type: "function", Function: llm.FunctionCall{Name: name, Arguments: arguments}}
+}
+
+// toolErrorOf decodes a tool message reporting an error
+func toolErrorOf(t *testing.T, msg llm.Message) toolError {
+	t.Helper()
+	var body struct {
+		Error *toolError `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(msg.Content), &body); err != nil || body.Error == nil {
+		t.Fatalf("content = %s, want an error object", msg.Content)
+	}
+	return *body.Error
+}
+
+func TestFuncGetTool(t *testing.T) {
+	var calls int
+	tool := newLookupTool(&calls).GetTool()
+	if tool.Type != "function" || tool.Function.Name != "lookup_vehicle" || tool.Function.Description != "Looks up a vehicle by VIN" {
+		t.Fatalf("tool = %+v", tool)
+	}
+
+	data, _ := json.Marshal(tool.Function.Parameters)
+	want := `{"type":"object","properties":{` +
+		`"vin":{"type":"string","description":"17-character vehicle identification number"},` +
+		`"zone":{"type":["string","null"],"enum":["front","rear",null]}},` +
+		`"required":["vin","zone"],"additionalProperties":false}`
+	if string(data) != want {
+		t.Fatalf("parameters =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestFuncCall(t *testing.T) {
+	var calls int
+	client := FromToolx(newLookupTool(&calls))
+
+	msg, err := client.Call(context.Background(), call("lookup_vehicle", `{"vin": "JTMB", "zone": null}`))
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if msg.Role != llm.RoleTool || msg.ToolCallID != "call_1" || msg.Content != `{"brand":"Toyota","year":2022}` {
+		t.Fatalf("message = %+v", msg)
+	}
+}
+
+func TestFuncStringResultIsNotQuoted(t *testing.T) {
+	tool := NewFunc("echo", "Echoes", func(ctx context.Context, in struct {
+		Text string `json:"text"`
+	}) (string, error) {
+		return in.Text, nil
+	})
+
+	got, err := tool.Call(context.Background(), `{"text": "hola"}`)
+	if err != nil || got != "hola" {
+		t.Fatalf("Call = %v, %v", got, err)
+	}
+}
+
+func TestCallReportsErrorsToModel(t *testing.T) {
+	tests := []struct {
+		name        string
+		call        llm.ToolCall
+		wantCode    string
+		wantDetails map[string]any
+		wantCalls   int
+	}{
+		{
+			name:        "unknown tool",
+			call:        call("delete_vehicle", `{}`),
+			wantCode:    ErrUnknownTool.Code,
+			wantDetails: map[string]any{"tool": "delete_vehicle", "available_tools": []any{"lookup_vehicle"}},
+		},
+		{
+			name:        "invalid arguments",
+			call:        call("lookup_vehicle", `{"vin": "JTMB", "zone": "roof"}`),
+			wantCode:    ErrInvalidArguments.Code,
+			wantDetails: map[string]any{"tool": "lookup_vehicle", "path": "$.zone", "problem": `must be one of "front", "rear"`},
+		},
+		{
+			name:        "missing arguments",
+			call:        call("lookup_vehicle", ``),
+			wantCode:    ErrInvalidArguments.Code,
+			wantDetails: map[string]any{"tool": "lookup_vehicle", "path": "$", "problem": `missing required property "vin"`},
+		},
+		{
+			name:        "tool error",
+			call:        call("lookup_vehicle", `{"vin": "broken"}`),
+			wantCode:    ErrToolFailed.Code,
+			wantDetails: map[string]any{"tool": "lookup_vehicle", "reason": "database unavailable"},
+			wantCalls:   1,
+		},
+		{
+			name:        "tool errx error",
+			call:        call("lookup_vehicle", `{"vin": "missing"}`),
+			wantCode:    string(errx.TypeNotFound),
+			wantDetails: map[string]any{"tool": "lookup_vehicle"},
+			wantCalls:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			client := FromToolx(newLookupTool(&calls))
+
+			msg, err := client.Call(context.Background(), tt.call)
+			if err != nil {
+				t.Fatalf("Call: %v", err)
+			}
+			got := toolErrorOf(t, msg)
+			if got.Code != tt.wantCode || got.Message == "" {
+				t.Fatalf("error = %+v, want code %s", got, tt.wantCode)
+			}
+			gotDetails, _ := json.Marshal(got.Details)
+			wantDetails, _ := json.Marshal(tt.wantDetails)
+			if string(gotDetails) != string(wantDetails) {
+				t.Fatalf("details = %s, want %s", gotDetails, wantDetails)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("tool ran %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestCallReturnsCancellation(t *testing.T) {
+	tool := NewFunc("wait", "Waits", func(ctx context.Context, _ struct{}) (string, error) {
+		return "", ctx.Err()
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := FromToolx(tool).Call(ctx, call("wait", `{}`)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want the cancellation", err)
+	}
+}
+
+func TestNewFuncPanicsOnUnsupportedInput(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for non-struct parameters")
+		}
+	}()
+	NewFunc("bad", "Takes a string", func(ctx context.Context, in string) (string, error) { return in, nil })
+}
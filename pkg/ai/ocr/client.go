@@ -3,6 +3,9 @@ package ocr
 import (
 	"context"
 	"fmt"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/ai/aiusage"
 )
 
 // Client provides unified access to OCR capabilities
@@ -21,6 +24,8 @@ type Client struct {
 
 	annotator   Annotator
 	documentQnA DocumentQnA
+
+	meter aiusage.Meter
 }
 
 // NewClient creates a client from a provider
@@ -66,6 +71,42 @@ func NewClient(recognizer TextRecognizer) *Client {
 	return client
 }
 
+// WithMeter reports the usage of every call to meter and lets it block calls
+// before they are made.
+func (c *Client) WithMeter(meter aiusage.Meter) *Client {
+	c.meter = meter
+	return c
+}
+
+// admit asks the meter, if any, whether a call may go ahead.
+func (c *Client) admit(ctx context.Context, opts []Option) error {
+	if c.meter == nil {
+		return nil
+	}
+	_, err := c.meter.Admit(ctx, aiusage.KindOCR, ApplyOptions(opts...).Model)
+	return err
+}
+
+// record reports rec to the meter, if any, tagged from ctx.
+func (c *Client) record(ctx context.Context, opts []Option, rec aiusage.Record) {
+	if c.meter == nil {
+		return
+	}
+	rec.Kind = aiusage.KindOCR
+	rec.Model = ApplyOptions(opts...).Model
+	rec.CreatedAt = time.Now()
+	aiusage.TagsFrom(ctx).Apply(&rec)
+	c.meter.Record(ctx, rec)
+}
+
+func (c *Client) recordQnA(ctx context.Context, opts []Option, usage TokenUsage) {
+	c.record(ctx, opts, aiusage.Record{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+	})
+}
+
 // ============================================================================
 // Annotation Methods
 // ============================================================================
@@ -103,7 +144,14 @@ func (c *Client) Ask(ctx context.Context, input Input, question string, opts ...
 	if c.documentQnA == nil {
 		return QnAResponse{}, fmt.Errorf("document QnA not supported by this provider")
 	}
-	return c.documentQnA.AskQuestion(ctx, input, question, opts...)
+	if err := c.admit(ctx, opts); err != nil {
+		return QnAResponse{}, err
+	}
+	resp, err := c.documentQnA.AskQuestion(ctx, input, question, opts...)
+	if err == nil {
+		c.recordQnA(ctx, opts, resp.TokenUsage)
+	}
+	return resp, err
 }
 
 // AskMultiple asks multiple questions about a document
@@ -111,7 +159,14 @@ func (c *Client) AskMultiple(ctx context.Context, input Input, questions []strin
 	if c.documentQnA == nil {
 		return nil, fmt.Errorf("document QnA not supported by this provider")
 	}
-	return c.documentQnA.AskQuestions(ctx, input, questions, opts...)
+	if err := c.admit(ctx, opts); err != nil {
+		return nil, err
+	}
+	resps, err := c.documentQnA.AskQuestions(ctx, input, questions, opts...)
+	for _, resp := range resps {
+		c.recordQnA(ctx, opts, resp.TokenUsage)
+	}
+	return resps, err
 }
 
 // ChatWithDocument starts a conversation about a document
@@ -119,7 +174,14 @@ func (c *Client) ChatWithDocument(ctx context.Context, input Input, messages []C
 	if c.documentQnA == nil {
 		return QnAResponse{}, fmt.Errorf("document QnA not supported by this provider")
 	}
-	return c.documentQnA.Chat(ctx, input, messages, opts...)
+	if err := c.admit(ctx, opts); err != nil {
+		return QnAResponse{}, err
+	}
+	resp, err := c.documentQnA.Chat(ctx, input, messages, opts...)
+	if err == nil {
+		c.recordQnA(ctx, opts, resp.TokenUsage)
+	}
+	return resp, err
 }
 
 // ============================================================================
@@ -133,11 +195,16 @@ func (c *Client) SupportsDocumentQnA() bool { return c.documentQnA != nil }
 func (c *Client) Process(ctx context.Context, input Input, opts ...Option) (*Result, error) {
 	options := ApplyOptions(opts...)
 
+	if err := c.admit(ctx, opts); err != nil {
+		return nil, err
+	}
+
 	// Start with base text recognition
 	result, err := c.recognizer.RecognizeText(ctx, input, opts...)
 	if err != nil {
 		return nil, err
 	}
+	c.record(ctx, opts, aiusage.Record{Pages: result.Usage().PagesProcessed})
 
 	builder := NewResultBuilder().
 		WithText(result.Text()).
@@ -195,7 +262,16 @@ func (c *Client) Process(ctx context.Context, input Input, opts ...Option) (*Res
 // ProcessBatch processes multiple documents
 func (c *Client) ProcessBatch(ctx context.Context, inputs []Input, opts ...Option) ([]*Result, error) {
 	if c.batchProcessor != nil {
-		return c.batchProcessor.ProcessBatch(ctx, inputs, opts...)
+		if err := c.admit(ctx, opts); err != nil {
+			return nil, err
+		}
+		results, err := c.batchProcessor.ProcessBatch(ctx, inputs, opts...)
+		for _, result := range results {
+			if result != nil {
+				c.record(ctx, opts, aiusage.Record{Pages: result.Usage().PagesProcessed})
+			}
+		}
+		return results, err
 	}
 
 	// Fallback to sequential processing
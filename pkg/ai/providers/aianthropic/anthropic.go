@@ -0,0 +1,653 @@
+// Package aianthropic implements llm.LLM against the Anthropic Messages API,
+// so agentx.Agent and the DiveInspect services can run on Claude models.
+//
+// JSON mode and WithJSONSchemaResponseFormat are served through a forced
+// tool call whose input schema is the requested schema; the tool input comes
+// back as the message content, as it would from a provider with native
+// structured output.
+package aianthropic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+const (
+	DefaultBaseURL   = "https://api.anthropic.com"
+	DefaultModel     = "claude-sonnet-4-5"
+	DefaultMaxTokens = 4096
+	DefaultTimeout   = 5 * time.Minute
+	APIVersion       = "2023-06-01"
+
+	// structuredOutputTool is the tool forced for JSON output
+	structuredOutputTool = "structured_output"
+)
+
+var _ llm.LLM = (*AnthropicProvider)(nil)
+
+// AnthropicProvider implements the LLM interface for Anthropic
+type AnthropicProvider struct {
+	apiKey     string
+	baseURL    string
+	model      string
+	maxTokens  int
+	httpClient *http.Client
+}
+
+// NewAnthropicProvider creates a new Anthropic provider. An empty apiKey is
+// read from ANTHROPIC_API_KEY.
+func NewAnthropicProvider(apiKey string, opts ...ProviderOption) *AnthropicProvider {
+	if apiKey == "" {
+		apiKey = os.Getenv("ANTHROPIC_API_KEY")
+	}
+
+	p := &AnthropicProvider{
+		apiKey:    apiKey,
+		baseURL:   DefaultBaseURL,
+		model:     DefaultModel,
+		maxTokens: DefaultMaxTokens,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.httpClient == nil {
+		p.httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	p.baseURL = strings.TrimRight(p.baseURL, "/")
+
+	return p
+}
+
+// ============================================================================
+// API Types
+// ============================================================================
+
+type messagesRequest struct {
+	Model         string         `json:"model"`
+	MaxTokens     int            `json:"max_tokens"`
+	System        string         `json:"system,omitempty"`
+	Messages      []apiMessage   `json:"messages"`
+	Temperature   *float32       `json:"temperature,omitempty"`
+	TopP          *float32       `json:"top_p,omitempty"`
+	StopSequences []string       `json:"stop_sequences,omitempty"`
+	Tools         []apiTool      `json:"tools,omitempty"`
+	ToolChoice    *apiToolChoice `json:"tool_choice,omitempty"`
+	Metadata      *apiMetadata   `json:"metadata,omitempty"`
+	Stream        bool           `json:"stream,omitempty"`
+}
+
+type apiMessage struct {
+	Role    string         `json:"role"`
+	Content []contentBlock `json:"content"`
+}
+
+// contentBlock is any of the text, image, tool_use and tool_result blocks
+type contentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	Source    *imageSource    `json:"source,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+type imageSource struct {
+	Type      string `json:"type"` // "base64" or "url"
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+type apiTool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"input_schema"`
+}
+
+type apiToolChoice struct {
+	Type string `json:"type"` // auto, any, tool, none
+	Name string `json:"name,omitempty"`
+}
+
+type apiMetadata struct {
+	UserID string `json:"user_id"`
+}
+
+type apiUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+type messagesResponse struct {
+	ID         string         `json:"id"`
+	Model      string         `json:"model"`
+	Content    []contentBlock `json:"content"`
+	StopReason string         `json:"stop_reason"`
+	Usage      apiUsage       `json:"usage"`
+}
+
+// ============================================================================
+// Chat Implementation
+// ============================================================================
+
+// Chat implements the LLM interface
+func (p *AnthropicProvider) Chat(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Response, error) {
+	req, options, err := p.buildRequest(messages, opts, false)
+	if err != nil {
+		return llm.Response{}, err
+	}
+
+	resp, apiErr := p.post(ctx, req, options.Headers)
+	if apiErr != nil {
+		return llm.Response{}, apiErr.
+			WithDetail("model", req.Model).
+			WithDetail("num_messages", len(messages))
+	}
+	defer resp.Body.Close()
+
+	var result messagesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return llm.Response{}, WrapError(err, ErrAPIResponse).
+			WithDetail("error", "failed to parse response")
+	}
+
+	return llm.Response{
+		Message: convertFromContentBlocks(result.Content),
+		Usage:   convertUsage(result.Usage),
+		Model:   result.Model,
+	}, nil
+}
+
+// ============================================================================
+// Chat Stream Implementation
+// ============================================================================
+
+// ChatStream implements the LLM interface over server-sent events. Each
+// chunk yields its text delta and the tool calls so far.
+func (p *AnthropicProvider) ChatStream(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Stream, error) {
+	req, options, err := p.buildRequest(messages, opts, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, apiErr := p.post(ctx, req, options.Headers)
+	if apiErr != nil {
+		return nil, apiErr.
+			WithDetail("model", req.Model).
+			WithDetail("num_messages", len(messages))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	return &anthropicStream{
+		body:       resp.Body,
+		scanner:    scanner,
+		toolIndex:  map[int]int{},
+		structured: map[int]bool{},
+	}, nil
+}
+
+type streamEvent struct {
+	Type         string       `json:"type"`
+	Index        int          `json:"index"`
+	ContentBlock contentBlock `json:"content_block"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+	} `json:"delta"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type anthropicStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+
+	toolCalls []llm.ToolCall
+	// toolIndex maps a content block index to its position in toolCalls
+	toolIndex map[int]int
+	// structured marks the blocks carrying structured output
+	structured map[int]bool
+
+	lastError error
+}
+
+func (s *anthropicStream) Next() (llm.Message, error) {
+	for {
+		if s.lastError != nil {
+			return llm.Message{}, s.lastError
+		}
+
+		data, ok := s.nextEvent()
+		if !ok {
+			continue
+		}
+
+		var event streamEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			s.lastError = WrapError(err, ErrStreamFailed).
+				WithDetail("error", "failed to parse stream event")
+			continue
+		}
+
+		switch event.Type {
+		case "content_block_start":
+			block := event.ContentBlock
+			switch {
+			case block.Type == "tool_use" && block.Name == structuredOutputTool:
+				s.structured[event.Index] = true
+			case block.Type == "tool_use":
+				s.toolIndex[event.Index] = len(s.toolCalls)
+				s.toolCalls = append(s.toolCalls, llm.ToolCall{
+					ID:       block.ID,
+					Type:     "function",
+					Function: llm.FunctionCall{Name: block.Name},
+				})
+				return s.message(""), nil
+			case block.Type == "text" && block.Text != "":
+				return s.message(block.Text), nil
+			}
+
+		case "content_block_delta":
+			switch event.Delta.Type {
+			case "text_delta":
+				return s.message(event.Delta.Text), nil
+			case "input_json_delta":
+				if s.structured[event.Index] {
+					return s.message(event.Delta.PartialJSON), nil
+				}
+				if i, ok := s.toolIndex[event.Index]; ok {
+					s.toolCalls[i].Function.Arguments += event.Delta.PartialJSON
+					return s.message(""), nil
+				}
+			}
+
+		case "content_block_stop":
+			// Tools without parameters stream no input at all
+			if i, ok := s.toolIndex[event.Index]; ok && s.toolCalls[i].Function.Arguments == "" {
+				s.toolCalls[i].Function.Arguments = "{}"
+				return s.message(""), nil
+			}
+
+		case "message_stop":
+			s.lastError = io.EOF
+
+		case "error":
+			s.lastError = newAPIError(&AnthropicAPIError{
+				Type:    event.Error.Type,
+				Message: event.Error.Message,
+			})
+		}
+	}
+}
+
+// message returns a chunk with a text delta and the full tool call snapshot
+func (s *anthropicStream) message(content string) llm.Message {
+	return llm.Message{
+		Role:      llm.RoleAssistant,
+		Content:   content,
+		ToolCalls: slices.Clone(s.toolCalls),
+	}
+}
+
+// nextEvent reads the data of the next server-sent event. When the body
+// ends it records the outcome in lastError and returns false.
+func (s *anthropicStream) nextEvent() ([]byte, bool) {
+	var data []byte
+	for s.scanner.Scan() {
+		line := s.scanner.Bytes()
+		switch {
+		case len(line) == 0:
+			if len(data) > 0 {
+				return data, true
+			}
+		case bytes.HasPrefix(line, []byte("data:")):
+			data = append(data, bytes.TrimSpace(line[len("data:"):])...)
+		}
+	}
+
+	if err := s.scanner.Err(); err != nil {
+		s.lastError = WrapError(err, ErrStreamFailed)
+		return nil, false
+	}
+	if len(data) > 0 {
+		return data, true
+	}
+	// The body ended without message_stop
+	s.lastError = io.EOF
+	return nil, false
+}
+
+func (s *anthropicStream) Close() error {
+	return s.body.Close()
+}
+
+// ============================================================================
+// HTTP
+// ============================================================================
+
+// post sends the request and returns the response when it succeeded. The
+// caller closes the body.
+func (p *AnthropicProvider) post(ctx context.Context, payload messagesRequest, headers map[string]string) (*http.Response, *errx.Error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, WrapError(err, ErrJSONParsing).
+			WithDetail("error", "failed to marshal request payload")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, WrapError(err, ErrAPIRequest).
+			WithDetail("error", "failed to create HTTP request")
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", p.apiKey)
+	req.Header.Set("anthropic-version", APIVersion)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, WrapError(err, ErrAPIRequest).
+			WithDetail("error", "HTTP request failed")
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, ParseAPIErrorResponse(resp.StatusCode, respBody)
+	}
+
+	return resp, nil
+}
+
+// ============================================================================
+// Helper Functions
+// ============================================================================
+
+func (p *AnthropicProvider) buildRequest(messages []llm.Message, opts []llm.Option, stream bool) (messagesRequest, *llm.ChatOptions, *errx.Error) {
+	// Validate API key
+	if p.apiKey == "" {
+		return messagesRequest{}, nil, errorRegistry.New(ErrMissingAPIKey)
+	}
+
+	// Validate messages
+	if len(messages) == 0 {
+		return messagesRequest{}, nil, errorRegistry.New(ErrEmptyMessages)
+	}
+
+	options := llm.DefaultOptions()
+	options.Model = p.model
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	system, converted, err := convertToAnthropicMessages(messages)
+	if err != nil {
+		return messagesRequest{}, nil, err
+	}
+
+	req := messagesRequest{
+		Model:         options.Model,
+		MaxTokens:     p.maxTokens,
+		System:        system,
+		Messages:      converted,
+		StopSequences: options.Stop,
+		Stream:        stream,
+	}
+
+	if options.MaxCompletionTokens > 0 {
+		req.MaxTokens = options.MaxCompletionTokens
+	} else if options.MaxTokens > 0 {
+		req.MaxTokens = options.MaxTokens
+	}
+
+	// Recent models reject temperature and top_p together; the default
+	// top_p of 1 is the API's own default, so it is left out
+	if options.Temperature != 0 {
+		req.Temperature = &options.Temperature
+	}
+	if options.TopP != 0 && options.TopP != 1 {
+		req.TopP = &options.TopP
+	}
+	if options.User != "" {
+		req.Metadata = &apiMetadata{UserID: options.User}
+	}
+
+	req.Tools = convertToAnthropicTools(options.Tools, options.Functions)
+	req.ToolChoice = convertToAnthropicToolChoice(options.ToolChoice)
+
+	if schema, ok := structuredOutputSchema(options); ok {
+		req.Tools = append(req.Tools, apiTool{
+			Name:        structuredOutputTool,
+			Description: "Give the final answer as JSON matching the input schema.",
+			InputSchema: schema,
+		})
+		if len(req.Tools) == 1 {
+			req.ToolChoice = &apiToolChoice{Type: "tool", Name: structuredOutputTool}
+		}
+	}
+
+	return req, options, nil
+}
+
+// convertToAnthropicMessages splits off the system prompt and converts the
+// conversation. Tool results travel as user messages, and consecutive
+// messages of the same role are merged since the API expects them to
+// alternate.
+func convertToAnthropicMessages(messages []llm.Message) (string, []apiMessage, *errx.Error) {
+	var system []string
+	result := make([]apiMessage, 0, len(messages))
+
+	appendBlocks := func(role string, blocks ...contentBlock) {
+		if len(blocks) == 0 {
+			return
+		}
+		if n := len(result); n > 0 && result[n-1].Role == role {
+			result[n-1].Content = append(result[n-1].Content, blocks...)
+			return
+		}
+		result = append(result, apiMessage{Role: role, Content: blocks})
+	}
+
+	for i, msg := range messages {
+		switch msg.Role {
+		case llm.RoleSystem:
+			if msg.Content != "" {
+				system = append(system, msg.Content)
+			}
+
+		case llm.RoleUser:
+			var blocks []contentBlock
+			if msg.Content != "" {
+				blocks = append(blocks, contentBlock{Type: "text", Text: msg.Content})
+			}
+			for _, img := range msg.Images {
+				blocks = append(blocks, convertImage(img))
+			}
+			appendBlocks("user", blocks...)
+
+		case llm.RoleAssistant:
+			var blocks []contentBlock
+			if msg.Content != "" {
+				blocks = append(blocks, contentBlock{Type: "text", Text: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				input := json.RawMessage(tc.Function.Arguments)
+				if strings.TrimSpace(tc.Function.Arguments) == "" {
+					input = json.RawMessage("{}")
+				} else if !json.Valid(input) {
+					return "", nil, errorRegistry.New(ErrInvalidMessage).
+						WithDetail("message_index", i).
+						WithDetail("error", "tool call arguments are not valid JSON")
+				}
+				blocks = append(blocks, contentBlock{Type: "tool_use", ID: tc.ID, Name: tc.Function.Name, Input: input})
+			}
+			appendBlocks("assistant", blocks...)
+
+		case llm.RoleTool:
+			appendBlocks("user", contentBlock{Type: "tool_result", ToolUseID: msg.ToolCallID, Content: msg.Content})
+
+		case llm.RoleFunction:
+			appendBlocks("user", contentBlock{Type: "tool_result", ToolUseID: msg.Name, Content: msg.Content})
+
+		default:
+			return "", nil, errorRegistry.New(ErrUnsupportedRole).
+				WithDetail("message_index", i).
+				WithDetail("role", msg.Role)
+		}
+	}
+
+	if len(result) == 0 {
+		return "", nil, errorRegistry.New(ErrEmptyMessages).
+			WithDetail("error", "conversation has no user or assistant messages")
+	}
+
+	return strings.Join(system, "\n\n"), result, nil
+}
+
+func convertImage(img llm.Image) contentBlock {
+	if img.URL != "" {
+		return contentBlock{Type: "image", Source: &imageSource{Type: "url", URL: img.URL}}
+	}
+	mediaType := img.MediaType
+	if mediaType == "" {
+		mediaType = http.DetectContentType(img.Data)
+	}
+	return contentBlock{Type: "image", Source: &imageSource{
+		Type:      "base64",
+		MediaType: mediaType,
+		Data:      base64.StdEncoding.EncodeToString(img.Data),
+	}}
+}
+
+func convertToAnthropicTools(tools []llm.Tool, functions []llm.Function) []apiTool {
+	result := make([]apiTool, 0, len(tools)+len(functions))
+	for _, tool := range tools {
+		if tool.Type == "function" {
+			result = append(result, convertFunction(tool.Function))
+		}
+	}
+	for _, fn := range functions {
+		result = append(result, convertFunction(fn))
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// convertFunction converts a function definition. The API requires an
+// input schema even for tools without parameters.
+func convertFunction(fn llm.Function) apiTool {
+	schema := fn.Parameters
+	if schema == nil {
+		schema = map[string]any{"type": "object", "properties": map[string]any{}}
+	}
+	return apiTool{Name: fn.Name, Description: fn.Description, InputSchema: schema}
+}
+
+func convertToAnthropicToolChoice(toolChoice any) *apiToolChoice {
+	switch toolChoice {
+	case "auto":
+		return &apiToolChoice{Type: "auto"}
+	case "required":
+		return &apiToolChoice{Type: "any"}
+	case "none":
+		return &apiToolChoice{Type: "none"}
+	default:
+		return nil
+	}
+}
+
+// structuredOutputSchema returns the schema the answer must follow, if the
+// call asked for JSON output.
+func structuredOutputSchema(options *llm.ChatOptions) (any, bool) {
+	anyObject := map[string]any{"type": "object"}
+	if options.JSONMode {
+		return anyObject, true
+	}
+	if options.ResponseFormat == nil {
+		return nil, false
+	}
+
+	switch options.ResponseFormat.Type {
+	case llm.JSONObject:
+		return anyObject, true
+	case llm.JSONSchema:
+		if options.ResponseFormat.JSONSchema == nil {
+			return anyObject, true
+		}
+		return options.ResponseFormat.JSONSchema, true
+	default:
+		return nil, false
+	}
+}
+
+// convertFromContentBlocks joins the text blocks and converts tool uses.
+// Structured output arrives as the forced tool's input and becomes the
+// content.
+func convertFromContentBlocks(blocks []contentBlock) llm.Message {
+	message := llm.Message{Role: llm.RoleAssistant}
+
+	var text strings.Builder
+	for _, block := range blocks {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "tool_use":
+			arguments := string(block.Input)
+			if arguments == "" || arguments == "null" {
+				arguments = "{}"
+			}
+			if block.Name == structuredOutputTool {
+				message.Content = arguments
+				return message
+			}
+			message.ToolCalls = append(message.ToolCalls, llm.ToolCall{
+				ID:   block.ID,
+				Type: "function",
+				Function: llm.FunctionCall{
+					Name:      block.Name,
+					Arguments: arguments,
+				},
+			})
+		}
+	}
+
+	message.Content = text.String()
+	return message
+}
+
+// convertUsage counts cached prompt tokens as prompt tokens
+func convertUsage(usage apiUsage) llm.Usage {
+	prompt := usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
+	return llm.Usage{
+		PromptTokens:     prompt,
+		CompletionTokens: usage.OutputTokens,
+		TotalTokens:      prompt + usage.OutputTokens,
+	}
+}
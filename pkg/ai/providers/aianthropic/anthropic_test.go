@@ -0,0 +1,363 @@
+package aianthropic
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+	"github.com/Abraxas-365/divi/pkg/ai/providers/aimock"
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+func newTestProvider(t *testing.T, opts ...ProviderOption) (*AnthropicProvider, *aimock.Server) {
+	t.Helper()
+	srv := aimock.NewServer(t)
+	return NewAnthropicProvider("test-key", append([]ProviderOption{WithBaseURL(srv.URL)}, opts...)...), srv
+}
+
+type requestBody struct {
+	Model         string         `json:"model"`
+	MaxTokens     int            `json:"max_tokens"`
+	System        string         `json:"system"`
+	Messages      []apiMessage   `json:"messages"`
+	Temperature   *float32       `json:"temperature"`
+	TopP          *float32       `json:"top_p"`
+	StopSequences []string       `json:"stop_sequences"`
+	Tools         []apiTool      `json:"tools"`
+	ToolChoice    *apiToolChoice `json:"tool_choice"`
+	Stream        bool           `json:"stream"`
+}
+
+func lastRequest(t *testing.T, srv *aimock.Server) requestBody {
+	t.Helper()
+	var body requestBody
+	if err := srv.LastRequest(aimock.RouteAnthropicMessages).Decode(&body); err != nil {
+		t.Fatalf("decode request: %v", err)
+	}
+	return body
+}
+
+func TestChatSendsConversationAndOptions(t *testing.T) {
+	p, srv := newTestProvider(t, WithModel("claude-haiku-4-5"), WithDefaultMaxTokens(512))
+	srv.Enqueue(aimock.RouteAnthropicMessages, aimock.AnthropicMessage("claude-haiku-4-5", aimock.AnthropicText("Clear ", "skies.")))
+
+	weather := llm.Tool{Type: "function", Function: llm.Function{
+		Name:       "get_weather",
+		Parameters: map[string]any{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}},
+	}}
+	messages := []llm.Message{
+		llm.NewSystemMessage("You are terse."),
+		llm.NewSystemMessage("Answer in English."),
+		llm.NewUserMessage("Weather in Lima?"),
+		{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{
+			{ID: "toolu_0", Type: "function", Function: llm.FunctionCall{Name: "get_weather", Arguments: `{"city":"Lima"}`}},
+			{ID: "toolu_1", Type: "function", Function: llm.FunctionCall{Name: "get_time"}},
+		}},
+		llm.NewToolMessage("toolu_0", `{"sky":"clear"}`),
+		llm.NewToolMessage("toolu_1", "09:00"),
+		llm.NewUserMessage("Summarize."),
+	}
+
+	resp, err := p.Chat(context.Background(), messages,
+		llm.WithTemperature(0.2),
+		llm.WithStop([]string{"###"}),
+		llm.WithTools([]llm.Tool{weather}),
+		llm.WithToolChoice("required"),
+	)
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if resp.Message.Content != "Clear skies." || resp.Model != "claude-haiku-4-5" {
+		t.Fatalf("response = %q from %q", resp.Message.Content, resp.Model)
+	}
+	if resp.Usage != (llm.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}) {
+		t.Fatalf("usage = %+v", resp.Usage)
+	}
+
+	req := srv.LastRequest(aimock.RouteAnthropicMessages)
+	if req.Header.Get("x-api-key") != "test-key" || req.Header.Get("anthropic-version") != APIVersion {
+		t.Fatalf("headers = %v", req.Header)
+	}
+
+	body := lastRequest(t, srv)
+	if body.Model != "claude-haiku-4-5" || body.MaxTokens != 512 || body.Stream {
+		t.Fatalf("model/max_tokens/stream = %q/%d/%v", body.Model, body.MaxTokens, body.Stream)
+	}
+	if body.System != "You are terse.\n\nAnswer in English." {
+		t.Fatalf("system = %q", body.System)
+	}
+	if body.Temperature == nil || *body.Temperature != 0.2 || body.TopP != nil {
+		t.Fatalf("temperature/top_p = %v/%v, want 0.2 and no top_p", body.Temperature, body.TopP)
+	}
+	if !slices.Equal(body.StopSequences, []string{"###"}) {
+		t.Fatalf("stop_sequences = %v", body.StopSequences)
+	}
+	if len(body.Tools) != 1 || body.Tools[0].Name != "get_weather" || body.Tools[0].InputSchema == nil {
+		t.Fatalf("tools = %+v", body.Tools)
+	}
+	if body.ToolChoice == nil || body.ToolChoice.Type != "any" {
+		t.Fatalf("tool_choice = %+v, want any", body.ToolChoice)
+	}
+
+	roles := make([]string, len(body.Messages))
+	for i, m := range body.Messages {
+		roles[i] = m.Role
+	}
+	if !slices.Equal(roles, []string{"user", "assistant", "user"}) {
+		t.Fatalf("roles = %v, want alternating turns", roles)
+	}
+
+	assistant := body.Messages[1].Content
+	if len(assistant) != 2 || assistant[0].Type != "tool_use" || assistant[0].ID != "toolu_0" ||
+		string(assistant[0].Input) != `{"city":"Lima"}` || string(assistant[1].Input) != `{}` {
+		t.Fatalf("assistant blocks = %+v", assistant)
+	}
+
+	results := body.Messages[2].Content
+	if len(results) != 3 || results[0].Type != "tool_result" || results[0].ToolUseID != "toolu_0" ||
+		results[1].ToolUseID != "toolu_1" || results[2].Type != "text" || results[2].Text != "Summarize." {
+		t.Fatalf("tool results = %+v, want both results then the next user turn", results)
+	}
+}
+
+func TestChatSendsImages(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteAnthropicMessages, aimock.AnthropicMessage(DefaultModel, aimock.AnthropicText("A crack.")))
+
+	msg := llm.NewUserImageMessage("What is wrong here?",
+		llm.Image{Data: []byte("\x89PNG\r\n\x1a\n"), MediaType: "image/png"},
+		llm.Image{URL: "https://example.com/wall.jpg"},
+	)
+	if _, err := p.Chat(context.Background(), []llm.Message{msg}); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	blocks := lastRequest(t, srv).Messages[0].Content
+	if len(blocks) != 3 || blocks[0].Text != "What is wrong here?" {
+		t.Fatalf("blocks = %+v", blocks)
+	}
+	if src := blocks[1].Source; blocks[1].Type != "image" || src == nil || src.Type != "base64" ||
+		src.MediaType != "image/png" || src.Data != "iVBORw0KGgo=" {
+		t.Fatalf("inline image = %+v", blocks[1].Source)
+	}
+	if src := blocks[2].Source; src == nil || src.Type != "url" || src.URL != "https://example.com/wall.jpg" {
+		t.Fatalf("url image = %+v", blocks[2].Source)
+	}
+}
+
+func TestChatForcesToolForJSONSchema(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteAnthropicMessages, aimock.AnthropicMessage(DefaultModel,
+		aimock.AnthropicToolUse("toolu_0", structuredOutputTool, `{"year":2021}`)))
+
+	schema := map[string]any{"type": "object", "properties": map[string]any{"year": map[string]any{"type": "integer"}}}
+	resp, err := p.Chat(context.Background(), []llm.Message{llm.NewUserMessage("Year?")},
+		llm.WithJSONSchemaResponseFormat(schema))
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if resp.Message.Content != `{"year":2021}` || len(resp.Message.ToolCalls) != 0 {
+		t.Fatalf("message = %+v, want the tool input as content", resp.Message)
+	}
+
+	body := lastRequest(t, srv)
+	if len(body.Tools) != 1 || body.Tools[0].Name != structuredOutputTool {
+		t.Fatalf("tools = %+v", body.Tools)
+	}
+	if props, _ := body.Tools[0].InputSchema.(map[string]any)["properties"].(map[string]any); props["year"] == nil {
+		t.Fatalf("input_schema = %v, want the requested schema", body.Tools[0].InputSchema)
+	}
+	if body.ToolChoice == nil || body.ToolChoice.Type != "tool" || body.ToolChoice.Name != structuredOutputTool {
+		t.Fatalf("tool_choice = %+v", body.ToolChoice)
+	}
+}
+
+func TestChatParsesToolCalls(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteAnthropicMessages, aimock.AnthropicMessage(DefaultModel,
+		aimock.AnthropicText("Let me check."),
+		aimock.AnthropicToolUse("toolu_0", "get_weather", `{"city":"Lima"}`),
+		aimock.AnthropicToolUse("toolu_1", "get_time", `{}`),
+	))
+
+	resp, err := p.Chat(context.Background(), []llm.Message{llm.NewUserMessage("Weather and time?")})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	want := []llm.ToolCall{
+		{ID: "toolu_0", Type: "function", Function: llm.FunctionCall{Name: "get_weather", Arguments: `{"city":"Lima"}`}},
+		{ID: "toolu_1", Type: "function", Function: llm.FunctionCall{Name: "get_time", Arguments: `{}`}},
+	}
+	if resp.Message.Content != "Let me check." || !slices.Equal(resp.Message.ToolCalls, want) {
+		t.Fatalf("message = %+v, want tool calls %+v", resp.Message, want)
+	}
+}
+
+func TestChatValidatesBeforeCalling(t *testing.T) {
+	tests := []struct {
+		name     string
+		apiKey   string
+		messages []llm.Message
+		want     *errx.ErrorCode
+	}{
+		{"missing api key", "", []llm.Message{llm.NewUserMessage("hi")}, ErrMissingAPIKey},
+		{"empty messages", "key", nil, ErrEmptyMessages},
+		{"system prompt only", "key", []llm.Message{llm.NewSystemMessage("x")}, ErrEmptyMessages},
+		{"unsupported role", "key", []llm.Message{{Role: "narrator", Content: "x"}}, ErrUnsupportedRole},
+		{"malformed tool arguments", "key", []llm.Message{{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{{
+			ID: "c", Function: llm.FunctionCall{Name: "f", Arguments: "{not json"},
+		}}}}, ErrInvalidMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ANTHROPIC_API_KEY", "")
+			srv := aimock.NewServer(t)
+			p := NewAnthropicProvider(tt.apiKey, WithBaseURL(srv.URL))
+
+			_, err := p.Chat(context.Background(), tt.messages)
+			assertCode(t, err, tt.want)
+			if n := len(srv.Requests(aimock.RouteAnthropicMessages)); n != 0 {
+				t.Fatalf("requests = %d, want none", n)
+			}
+		})
+	}
+}
+
+func TestChatMapsAPIErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		resp aimock.Response
+		want *errx.ErrorCode
+	}{
+		{"bad key", aimock.AnthropicError(http.StatusUnauthorized, "authentication_error", "invalid x-api-key"), ErrAPIUnauthorized},
+		{"forbidden", aimock.AnthropicError(http.StatusForbidden, "permission_error", ""), ErrPermissionDenied},
+		{"rate limited", aimock.AnthropicError(http.StatusTooManyRequests, "rate_limit_error", ""), ErrAPIRateLimit},
+		{"overloaded", aimock.AnthropicError(529, "overloaded_error", "Overloaded"), ErrAPIOverloaded},
+		{"unknown model", aimock.AnthropicError(http.StatusNotFound, "not_found_error", "model: claude-x"), ErrModelNotFound},
+		{"prompt too long", aimock.AnthropicError(http.StatusBadRequest, "invalid_request_error", "prompt is too long: 210000 tokens > 200000 maximum"), ErrContextLengthExceeded},
+		{"bad request", aimock.AnthropicError(http.StatusBadRequest, "invalid_request_error", "max_tokens: Field required"), ErrInvalidRequest},
+		{"server error", aimock.AnthropicError(http.StatusInternalServerError, "api_error", ""), ErrAPIResponse},
+		{"non-JSON body", aimock.Response{Status: http.StatusBadGateway, Body: "<html>bad gateway</html>"}, ErrAPIResponse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, srv := newTestProvider(t)
+			srv.Enqueue(aimock.RouteAnthropicMessages, tt.resp)
+
+			_, err := p.Chat(context.Background(), []llm.Message{llm.NewUserMessage("hi")})
+			assertCode(t, err, tt.want)
+		})
+	}
+}
+
+// ============================================================================
+// Streaming
+// ============================================================================
+
+func TestChatStream(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteAnthropicMessages, aimock.AnthropicStream(DefaultModel,
+		aimock.AnthropicText("Checking ", "the weather"),
+		aimock.AnthropicToolUse("toolu_0", "get_weather", `{"city":"Lima"}`),
+		aimock.AnthropicToolUse("toolu_1", "get_time", ""),
+	))
+
+	stream, err := p.ChatStream(context.Background(), []llm.Message{llm.NewUserMessage("Weather in Lima?")})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	defer stream.Close()
+
+	content, last := drain(t, stream)
+	if content != "Checking the weather" {
+		t.Fatalf("content = %q", content)
+	}
+	want := []llm.ToolCall{
+		{ID: "toolu_0", Type: "function", Function: llm.FunctionCall{Name: "get_weather", Arguments: `{"city":"Lima"}`}},
+		{ID: "toolu_1", Type: "function", Function: llm.FunctionCall{Name: "get_time", Arguments: `{}`}},
+	}
+	if !slices.Equal(last.ToolCalls, want) {
+		t.Fatalf("tool calls = %+v, want %+v", last.ToolCalls, want)
+	}
+	if !lastRequest(t, srv).Stream {
+		t.Fatal("request did not ask to stream")
+	}
+}
+
+func TestChatStreamYieldsStructuredOutputAsContent(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteAnthropicMessages, aimock.AnthropicStream(DefaultModel,
+		aimock.AnthropicToolUse("toolu_0", structuredOutputTool, `{"year":2021}`)))
+
+	stream, err := p.ChatStream(context.Background(), []llm.Message{llm.NewUserMessage("Year?")}, llm.WithJSONMode())
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	defer stream.Close()
+
+	content, last := drain(t, stream)
+	if content != `{"year":2021}` || len(last.ToolCalls) != 0 {
+		t.Fatalf("content = %q, tool calls = %+v", content, last.ToolCalls)
+	}
+}
+
+func TestChatStreamSurfacesErrorEvents(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteAnthropicMessages, aimock.Response{Events: []any{
+		map[string]any{"type": "content_block_start", "index": 0, "content_block": map[string]any{"type": "text", "text": ""}},
+		map[string]any{"type": "content_block_delta", "index": 0, "delta": map[string]any{"type": "text_delta", "text": "Hel"}},
+		map[string]any{"type": "error", "error": map[string]any{"type": "overloaded_error", "message": "Overloaded"}},
+	}})
+
+	stream, err := p.ChatStream(context.Background(), []llm.Message{llm.NewUserMessage("hi")})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	defer stream.Close()
+
+	if msg, err := stream.Next(); err != nil || msg.Content != "Hel" {
+		t.Fatalf("first chunk = %q, %v", msg.Content, err)
+	}
+	_, err = stream.Next()
+	assertCode(t, err, ErrAPIOverloaded)
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// drain reads the stream to the end, returning the joined content and the
+// last chunk.
+func drain(t *testing.T, stream llm.Stream) (string, llm.Message) {
+	t.Helper()
+	var content strings.Builder
+	var last llm.Message
+	for {
+		msg, err := stream.Next()
+		if errors.Is(err, io.EOF) {
+			return content.String(), last
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		content.WriteString(msg.Content)
+		last = msg
+	}
+}
+
+// assertCode fails unless err carries the registered error code.
+func assertCode(t *testing.T, err error, code *errx.ErrorCode) {
+	t.Helper()
+	var e *errx.Error
+	if !errors.As(err, &e) || e.Code != code.Code {
+		t.Fatalf("error = %v, want code %s", err, code.Code)
+	}
+}
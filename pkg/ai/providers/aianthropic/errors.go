@@ -0,0 +1,237 @@
+package aianthropic
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+var (
+	// Error registry for Anthropic provider
+	errorRegistry = errx.NewRegistry("ANTHROPIC")
+
+	// API Errors
+	ErrAPIRequest = errorRegistry.Register(
+		"API_REQUEST_FAILED",
+		errx.TypeExternal,
+		http.StatusBadGateway,
+		"Failed to make request to Anthropic API",
+	)
+
+	ErrAPIResponse = errorRegistry.Register(
+		"API_RESPONSE_INVALID",
+		errx.TypeExternal,
+		http.StatusBadGateway,
+		"Invalid response from Anthropic API",
+	)
+
+	ErrAPIUnauthorized = errorRegistry.Register(
+		"API_UNAUTHORIZED",
+		errx.TypeAuthorization,
+		http.StatusUnauthorized,
+		"Invalid or missing Anthropic API key",
+	)
+
+	ErrPermissionDenied = errorRegistry.Register(
+		"PERMISSION_DENIED",
+		errx.TypeAuthorization,
+		http.StatusForbidden,
+		"API key lacks permission for this resource",
+	)
+
+	ErrAPIRateLimit = errorRegistry.Register(
+		"API_RATE_LIMIT",
+		errx.TypeExternal,
+		http.StatusTooManyRequests,
+		"Anthropic API rate limit exceeded",
+	)
+
+	ErrAPIOverloaded = errorRegistry.Register(
+		"API_OVERLOADED",
+		errx.TypeExternal,
+		http.StatusServiceUnavailable,
+		"Anthropic API is temporarily overloaded",
+	)
+
+	ErrModelNotFound = errorRegistry.Register(
+		"MODEL_NOT_FOUND",
+		errx.TypeValidation,
+		http.StatusNotFound,
+		"Requested model not found or not accessible",
+	)
+
+	ErrRequestTooLarge = errorRegistry.Register(
+		"REQUEST_TOO_LARGE",
+		errx.TypeValidation,
+		http.StatusRequestEntityTooLarge,
+		"Request exceeds the maximum allowed size",
+	)
+
+	ErrContextLengthExceeded = errorRegistry.Register(
+		"CONTEXT_LENGTH_EXCEEDED",
+		errx.TypeValidation,
+		http.StatusBadRequest,
+		"Input exceeds model's context length",
+	)
+
+	ErrInvalidRequest = errorRegistry.Register(
+		"INVALID_REQUEST",
+		errx.TypeValidation,
+		http.StatusBadRequest,
+		"Invalid request parameters",
+	)
+
+	// Input Validation Errors
+	ErrEmptyMessages = errorRegistry.Register(
+		"EMPTY_MESSAGES",
+		errx.TypeValidation,
+		http.StatusBadRequest,
+		"Messages array cannot be empty",
+	)
+
+	ErrInvalidMessage = errorRegistry.Register(
+		"INVALID_MESSAGE",
+		errx.TypeValidation,
+		http.StatusBadRequest,
+		"Invalid message format",
+	)
+
+	ErrUnsupportedRole = errorRegistry.Register(
+		"UNSUPPORTED_ROLE",
+		errx.TypeValidation,
+		http.StatusBadRequest,
+		"Unsupported message role",
+	)
+
+	// Response Errors
+	ErrStreamFailed = errorRegistry.Register(
+		"STREAM_FAILED",
+		errx.TypeExternal,
+		http.StatusBadGateway,
+		"Streaming response failed",
+	)
+
+	// Configuration Errors
+	ErrMissingAPIKey = errorRegistry.Register(
+		"MISSING_API_KEY",
+		errx.TypeValidation,
+		http.StatusBadRequest,
+		"Missing Anthropic API key",
+	)
+
+	// Processing Errors
+	ErrJSONParsing = errorRegistry.Register(
+		"JSON_PARSING_ERROR",
+		errx.TypeInternal,
+		http.StatusInternalServerError,
+		"Failed to parse JSON",
+	)
+
+	ErrConversionFailed = errorRegistry.Register(
+		"CONVERSION_FAILED",
+		errx.TypeInternal,
+		http.StatusInternalServerError,
+		"Failed to convert data format",
+	)
+)
+
+// AnthropicAPIError represents an error from the Anthropic API
+type AnthropicAPIError struct {
+	StatusCode int
+	Type       string
+	Message    string
+}
+
+// Error implements the error interface
+func (e *AnthropicAPIError) Error() string {
+	return fmt.Sprintf("Anthropic API error [%s]: %s", e.Type, e.Message)
+}
+
+// WrapError wraps a standard error with appropriate Anthropic error code
+func WrapError(err error, code *errx.ErrorCode) *errx.Error {
+	if err == nil {
+		return nil
+	}
+
+	// Check if it's already a custom error
+	var customErr *errx.Error
+	if errx.As(err, &customErr) {
+		return customErr
+	}
+
+	return errorRegistry.NewWithCause(code, err)
+}
+
+// ParseAPIErrorResponse parses error from API response body:
+// {"type": "error", "error": {"type": "...", "message": "..."}}
+func ParseAPIErrorResponse(statusCode int, body []byte) *errx.Error {
+	apiErr := &AnthropicAPIError{StatusCode: statusCode}
+
+	var errResp struct {
+		Error struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error.Message != "" {
+		apiErr.Type = errResp.Error.Type
+		apiErr.Message = errResp.Error.Message
+	} else {
+		// Fallback to raw body
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(statusCode)
+	}
+
+	return newAPIError(apiErr)
+}
+
+// newAPIError maps an API error to the matching error code, by error type
+// first and status code second.
+func newAPIError(apiErr *AnthropicAPIError) *errx.Error {
+	msgLower := strings.ToLower(apiErr.Message)
+
+	var baseErr *errx.ErrorCode
+	switch {
+	case apiErr.Type == "authentication_error" || apiErr.StatusCode == http.StatusUnauthorized:
+		baseErr = ErrAPIUnauthorized
+	case apiErr.Type == "permission_error" || apiErr.StatusCode == http.StatusForbidden:
+		baseErr = ErrPermissionDenied
+	case apiErr.Type == "rate_limit_error" || apiErr.StatusCode == http.StatusTooManyRequests:
+		baseErr = ErrAPIRateLimit
+	case apiErr.Type == "overloaded_error" || apiErr.StatusCode == 529:
+		baseErr = ErrAPIOverloaded
+	case apiErr.Type == "request_too_large" || apiErr.StatusCode == http.StatusRequestEntityTooLarge:
+		baseErr = ErrRequestTooLarge
+	case apiErr.Type == "not_found_error" || apiErr.StatusCode == http.StatusNotFound:
+		if strings.Contains(msgLower, "model") {
+			baseErr = ErrModelNotFound
+		} else {
+			baseErr = ErrAPIRequest
+		}
+	case apiErr.Type == "invalid_request_error" || apiErr.StatusCode == http.StatusBadRequest:
+		if strings.Contains(msgLower, "prompt is too long") || strings.Contains(msgLower, "context") {
+			baseErr = ErrContextLengthExceeded
+		} else {
+			baseErr = ErrInvalidRequest
+		}
+	case apiErr.StatusCode >= 500 || apiErr.Type == "api_error":
+		baseErr = ErrAPIResponse
+	default:
+		baseErr = ErrAPIRequest
+	}
+
+	customErr := errorRegistry.NewWithMessage(baseErr, apiErr.Message)
+	if apiErr.StatusCode != 0 {
+		customErr.WithDetail("status_code", apiErr.StatusCode)
+	}
+	if apiErr.Type != "" {
+		customErr.WithDetail("error_type", apiErr.Type)
+	}
+
+	return customErr
+}
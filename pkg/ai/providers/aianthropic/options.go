@@ -0,0 +1,50 @@
+package aianthropic
+
+import (
+	"net/http"
+	"time"
+)
+
+// ProviderOption configures the Anthropic provider
+type ProviderOption func(*AnthropicProvider)
+
+// WithBaseURL sets a custom base URL
+func WithBaseURL(url string) ProviderOption {
+	return func(p *AnthropicProvider) {
+		p.baseURL = url
+	}
+}
+
+// WithModel sets the model used when a call doesn't ask for one
+func WithModel(model string) ProviderOption {
+	return func(p *AnthropicProvider) {
+		p.model = model
+	}
+}
+
+// WithDefaultMaxTokens sets max_tokens for calls that don't set a limit.
+// The Messages API requires one on every request.
+func WithDefaultMaxTokens(n int) ProviderOption {
+	return func(p *AnthropicProvider) {
+		if n > 0 {
+			p.maxTokens = n
+		}
+	}
+}
+
+// WithHTTPClient sets a custom HTTP client
+func WithHTTPClient(client *http.Client) ProviderOption {
+	return func(p *AnthropicProvider) {
+		p.httpClient = client
+	}
+}
+
+// WithTimeout sets the request timeout
+func WithTimeout(timeout time.Duration) ProviderOption {
+	return func(p *AnthropicProvider) {
+		if p.httpClient == nil {
+			p.httpClient = &http.Client{}
+		}
+		p.httpClient.Timeout = timeout
+	}
+}
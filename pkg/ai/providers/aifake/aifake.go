@@ -0,0 +1,498 @@
+// Package aifake is an AI provider that answers from a script instead of a
+// model. It implements llm.LLM, embedding.Embedder, speech.Transcriber,
+// speech.Speaker and ocr.TextRecognizer, so tests and local development run
+// without API keys, network access or nondeterminism.
+//
+// Replies are matched against the conversation with regular expressions;
+// a rule with several replies plays them in order, which scripts tool-call
+// loops. Real sessions can be recorded to a golden file with NewRecorder and
+// replayed with LoadGoldenFile.
+package aifake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/ai/embedding"
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+	"github.com/Abraxas-365/divi/pkg/ai/ocr"
+	"github.com/Abraxas-365/divi/pkg/ai/speech"
+)
+
+var (
+	_ llm.LLM            = (*FakeProvider)(nil)
+	_ llm.LLM            = (*Recorder)(nil)
+	_ embedding.Embedder = (*FakeProvider)(nil)
+	_ speech.Transcriber = (*FakeProvider)(nil)
+	_ speech.Speaker     = (*FakeProvider)(nil)
+	_ ocr.TextRecognizer = (*FakeProvider)(nil)
+)
+
+// DefaultModel is reported as the serving model unless the call asks for one.
+const DefaultModel = "fake"
+
+// ============================================================================
+// Replies
+// ============================================================================
+
+// Reply is one scripted model turn.
+type Reply struct {
+	Content   string
+	ToolCalls []llm.ToolCall
+
+	// Usage overrides the token counts estimated from the text
+	Usage *llm.Usage
+
+	// Err fails the call instead of answering
+	Err error
+
+	// StreamErr is returned by Stream.Next once the content has been
+	// streamed; Chat returns it in place of the response
+	StreamErr error
+
+	// Latency is added to the provider latency before the reply is served
+	Latency time.Duration
+
+	// emptyForSchema swaps Content for the empty document of the schema
+	// the call asks for, if any
+	emptyForSchema bool
+}
+
+// Text replies with plain content.
+func Text(content string) Reply {
+	return Reply{Content: content}
+}
+
+// JSON replies with v encoded as JSON.
+func JSON(v any) Reply {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return Reply{Err: fmt.Errorf("aifake: encode reply: %w", err)}
+	}
+	return Reply{Content: string(data)}
+}
+
+// EmptyForSchema answers calls that ask for an *llm.Schema with the
+// smallest document it accepts: empty strings and arrays, zeros, the first
+// enum value and null where allowed. Other calls get content.
+func EmptyForSchema(content string) Reply {
+	return Reply{Content: content, emptyForSchema: true}
+}
+
+// ToolCalls replies with calls to the given tools. Calls without an ID get
+// call_1, call_2, ... in order.
+func ToolCalls(calls ...llm.ToolCall) Reply {
+	for i := range calls {
+		if calls[i].ID == "" {
+			calls[i].ID = fmt.Sprintf("call_%d", i+1)
+		}
+		if calls[i].Type == "" {
+			calls[i].Type = "function"
+		}
+	}
+	return Reply{ToolCalls: calls}
+}
+
+// Call builds a function tool call with JSON arguments.
+func Call(name, arguments string) llm.ToolCall {
+	return llm.ToolCall{Type: "function", Function: llm.FunctionCall{Name: name, Arguments: arguments}}
+}
+
+// Fail makes the call return err.
+func Fail(err error) Reply {
+	return Reply{Err: err}
+}
+
+// ============================================================================
+// Provider
+// ============================================================================
+
+// rule answers conversations matching pattern. Its replies are served in
+// order and the last one repeats.
+type rule struct {
+	pattern *regexp.Regexp
+	replies []Reply
+	served  int
+}
+
+func (r *rule) next() Reply {
+	reply := r.replies[min(r.served, len(r.replies)-1)]
+	r.served++
+	return reply
+}
+
+// Request is a chat request the provider received.
+type Request struct {
+	Messages []llm.Message
+	Options  llm.ChatOptions
+}
+
+// FakeProvider serves scripted replies. It is safe for concurrent use.
+type FakeProvider struct {
+	mu        sync.Mutex
+	rules     []*rule
+	fallback  *Reply
+	golden    map[string]Reply
+	requests  []Request
+	latency   time.Duration
+	chunkSize int
+	model     string
+
+	dimensions int
+	transcript string
+	ocrText    string
+}
+
+// NewFakeProvider creates a provider answering from the given script.
+func NewFakeProvider(opts ...ProviderOption) *FakeProvider {
+	p := &FakeProvider{
+		golden:     map[string]Reply{},
+		chunkSize:  4,
+		model:      DefaultModel,
+		dimensions: 1536,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// ProviderOption configures the fake provider
+type ProviderOption func(*FakeProvider)
+
+// WithReply answers conversations whose text matches pattern, a regular
+// expression, with replies in order; the last reply repeats. Rules are tried
+// in the order they were added.
+func WithReply(pattern string, replies ...Reply) ProviderOption {
+	return func(p *FakeProvider) {
+		if len(replies) == 0 {
+			replies = []Reply{Text("")}
+		}
+		p.rules = append(p.rules, &rule{pattern: regexp.MustCompile(pattern), replies: replies})
+	}
+}
+
+// WithFallback answers conversations no rule matches. Without it they fail
+// with ErrNoScriptedReply.
+func WithFallback(reply Reply) ProviderOption {
+	return func(p *FakeProvider) {
+		p.fallback = &reply
+	}
+}
+
+// WithLatency delays every reply by d.
+func WithLatency(d time.Duration) ProviderOption {
+	return func(p *FakeProvider) {
+		p.latency = d
+	}
+}
+
+// WithChunkSize sets how many characters each streamed chunk carries.
+func WithChunkSize(n int) ProviderOption {
+	return func(p *FakeProvider) {
+		if n > 0 {
+			p.chunkSize = n
+		}
+	}
+}
+
+// WithModel sets the model reported when a call doesn't ask for one.
+func WithModel(model string) ProviderOption {
+	return func(p *FakeProvider) {
+		p.model = model
+	}
+}
+
+// WithEmbeddingDimensions sets the length of the vectors returned when the
+// call doesn't ask for a size.
+func WithEmbeddingDimensions(n int) ProviderOption {
+	return func(p *FakeProvider) {
+		p.dimensions = n
+	}
+}
+
+// WithTranscript makes Transcribe return text whatever the audio.
+func WithTranscript(text string) ProviderOption {
+	return func(p *FakeProvider) {
+		p.transcript = text
+	}
+}
+
+// WithOCRText makes RecognizeText return text whatever the document.
+func WithOCRText(text string) ProviderOption {
+	return func(p *FakeProvider) {
+		p.ocrText = text
+	}
+}
+
+// Requests returns the chat requests received so far, oldest first.
+func (p *FakeProvider) Requests() []Request {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Request(nil), p.requests...)
+}
+
+// Reset forgets the recorded requests and restarts every reply sequence.
+func (p *FakeProvider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = nil
+	for _, r := range p.rules {
+		r.served = 0
+	}
+}
+
+// ============================================================================
+// Chat Implementation
+// ============================================================================
+
+// Chat implements the LLM interface
+func (p *FakeProvider) Chat(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Response, error) {
+	reply, options, err := p.serve(ctx, messages, opts)
+	if err != nil {
+		return llm.Response{}, err
+	}
+	if reply.StreamErr != nil {
+		return llm.Response{}, reply.StreamErr
+	}
+
+	return llm.Response{
+		Message: llm.Message{
+			Role:      llm.RoleAssistant,
+			Content:   reply.Content,
+			ToolCalls: reply.ToolCalls,
+		},
+		Usage: p.usage(messages, reply),
+		Model: p.modelFor(options),
+	}, nil
+}
+
+// ChatStream implements the LLM interface. The content is split into chunks
+// of the configured size; tool calls arrive in one final chunk, as the full
+// snapshot real providers accumulate.
+func (p *FakeProvider) ChatStream(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Stream, error) {
+	reply, _, err := p.serve(ctx, messages, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	size := p.chunkSize
+	p.mu.Unlock()
+
+	return &fakeStream{
+		ctx:       ctx,
+		chunks:    chunk(reply.Content, size),
+		toolCalls: reply.ToolCalls,
+		err:       reply.StreamErr,
+		usage:     p.usage(messages, reply),
+	}, nil
+}
+
+// serve records the call, picks its reply and waits out the latency. The
+// returned error is the reply's injected error or a scripting failure.
+func (p *FakeProvider) serve(ctx context.Context, messages []llm.Message, opts []llm.Option) (Reply, *llm.ChatOptions, error) {
+	if len(messages) == 0 {
+		return Reply{}, nil, errorRegistry.New(ErrEmptyMessages)
+	}
+
+	options := llm.DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	p.mu.Lock()
+	p.requests = append(p.requests, Request{Messages: append([]llm.Message(nil), messages...), Options: *options})
+	reply, err := p.match(messages)
+	latency := p.latency + reply.Latency
+	p.mu.Unlock()
+	if err != nil {
+		return Reply{}, nil, err
+	}
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return Reply{}, nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if reply.Err != nil {
+		return Reply{}, nil, reply.Err
+	}
+	if format := options.ResponseFormat; reply.emptyForSchema && format != nil {
+		if schema, ok := format.JSONSchema.(*llm.Schema); ok {
+			data, _ := json.Marshal(emptyDocument(schema))
+			reply.Content = string(data)
+		}
+	}
+	return reply, options, nil
+}
+
+// emptyDocument builds the smallest value schema accepts.
+func emptyDocument(schema *llm.Schema) any {
+	switch {
+	case schema.Nullable:
+		return nil
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	}
+
+	switch schema.Type {
+	case "object":
+		doc := make(map[string]any, len(schema.Properties))
+		for name, prop := range schema.Properties {
+			doc[name] = emptyDocument(prop)
+		}
+		return doc
+	case "array":
+		return []any{}
+	case "string":
+		if schema.Format == "date-time" {
+			return time.Time{}.Format(time.RFC3339)
+		}
+		return ""
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	default:
+		return nil
+	}
+}
+
+// match finds the reply for a conversation: a golden exchange first, then
+// the first matching rule, then the fallback. Callers hold p.mu.
+func (p *FakeProvider) match(messages []llm.Message) (Reply, error) {
+	if len(p.golden) > 0 {
+		if reply, ok := p.golden[conversationKey(messages)]; ok {
+			return reply, nil
+		}
+	}
+
+	text := conversationText(messages)
+	for _, r := range p.rules {
+		if r.pattern.MatchString(text) {
+			return r.next(), nil
+		}
+	}
+	if p.fallback != nil {
+		return *p.fallback, nil
+	}
+
+	if len(p.golden) > 0 {
+		return Reply{}, errorRegistry.New(ErrGoldenMiss).WithDetail("conversation", excerpt(text))
+	}
+	return Reply{}, errorRegistry.New(ErrNoScriptedReply).WithDetail("conversation", excerpt(text))
+}
+
+func (p *FakeProvider) modelFor(options *llm.ChatOptions) string {
+	if options != nil && options.Model != "" {
+		return options.Model
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.model
+}
+
+// usage returns the reply's usage, or estimates it at four characters a
+// token.
+func (p *FakeProvider) usage(messages []llm.Message, reply Reply) llm.Usage {
+	if reply.Usage != nil {
+		return *reply.Usage
+	}
+	completion := estimateTokens(reply.Content)
+	for _, tc := range reply.ToolCalls {
+		completion += estimateTokens(tc.Function.Name + tc.Function.Arguments)
+	}
+	prompt := estimateTokens(conversationText(messages))
+	return llm.Usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion}
+}
+
+// ============================================================================
+// Stream Implementation
+// ============================================================================
+
+type fakeStream struct {
+	ctx       context.Context
+	chunks    []string
+	toolCalls []llm.ToolCall
+	err       error
+	usage     llm.Usage
+	sent      int
+	done      bool
+}
+
+func (s *fakeStream) Next() (llm.Message, error) {
+	if err := s.ctx.Err(); err != nil {
+		return llm.Message{}, err
+	}
+	if s.sent < len(s.chunks) {
+		s.sent++
+		return llm.Message{Role: llm.RoleAssistant, Content: s.chunks[s.sent-1]}, nil
+	}
+	if !s.done && len(s.toolCalls) > 0 {
+		s.done = true
+		return llm.Message{Role: llm.RoleAssistant, ToolCalls: s.toolCalls}, nil
+	}
+	s.done = true
+	if s.err != nil {
+		return llm.Message{}, s.err
+	}
+	return llm.Message{}, io.EOF
+}
+
+func (s *fakeStream) Close() error {
+	return nil
+}
+
+// Usage is reported once the stream has ended, as real providers do
+func (s *fakeStream) Usage() (llm.Usage, bool) {
+	return s.usage, s.done
+}
+
+// ============================================================================
+// Helper Functions
+// ============================================================================
+
+// conversationText joins the message contents; rules match against it.
+func conversationText(messages []llm.Message) string {
+	var b strings.Builder
+	for i, m := range messages {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(m.Content)
+	}
+	return b.String()
+}
+
+// chunk splits s into pieces of at most size runes.
+func chunk(s string, size int) []string {
+	runes := []rune(s)
+	chunks := make([]string, 0, len(runes)/size+1)
+	for start := 0; start < len(runes); start += size {
+		chunks = append(chunks, string(runes[start:min(start+size, len(runes))]))
+	}
+	return chunks
+}
+
+func estimateTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+func excerpt(s string) string {
+	if len(s) > 200 {
+		return s[:200] + "..."
+	}
+	return s
+}
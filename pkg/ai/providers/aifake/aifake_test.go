@@ -0,0 +1,247 @@
+package aifake
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Abraxas-365/divi/pkg/ai/embedding"
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+func TestChatPlaysRepliesInOrder(t *testing.T) {
+	p := NewFakeProvider(
+		WithReply("weather", ToolCalls(Call("get_weather", `{"city":"Lima"}`)), Text("Sunny in Lima")),
+		WithReply(".*", Text("catch-all")),
+	)
+	ctx := context.Background()
+	ask := []llm.Message{llm.NewUserMessage("What's the weather in Lima?")}
+
+	first, err := p.Chat(ctx, ask)
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if len(first.Message.ToolCalls) != 1 || first.Message.ToolCalls[0].ID != "call_1" {
+		t.Fatalf("first reply = %+v, want one call_1 tool call", first.Message)
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := p.Chat(ctx, ask)
+		if err != nil || resp.Message.Content != "Sunny in Lima" {
+			t.Fatalf("reply %d = %q, %v; want the last reply repeated", i+2, resp.Message.Content, err)
+		}
+	}
+
+	resp, err := p.Chat(ctx, []llm.Message{llm.NewUserMessage("hello")}, llm.WithModel("gpt-4o"))
+	if err != nil || resp.Message.Content != "catch-all" || resp.Model != "gpt-4o" {
+		t.Fatalf("unmatched rule = %q from %q, %v", resp.Message.Content, resp.Model, err)
+	}
+	if n := len(p.Requests()); n != 4 {
+		t.Fatalf("requests = %d, want 4", n)
+	}
+}
+
+func TestChatFallback(t *testing.T) {
+	ctx := context.Background()
+	ask := []llm.Message{llm.NewUserMessage("anything")}
+
+	_, err := NewFakeProvider().Chat(ctx, ask)
+	assertCode(t, err, ErrNoScriptedReply)
+
+	resp, err := NewFakeProvider(WithFallback(Text("fallback"))).Chat(ctx, ask)
+	if err != nil || resp.Message.Content != "fallback" {
+		t.Fatalf("fallback reply = %q, %v", resp.Message.Content, err)
+	}
+
+	_, err = NewFakeProvider().Chat(ctx, nil)
+	assertCode(t, err, ErrEmptyMessages)
+}
+
+func TestChatScriptedErrors(t *testing.T) {
+	overloaded := errors.New("model overloaded")
+	cut := errors.New("connection reset")
+	p := NewFakeProvider(
+		WithReply("fail", Fail(overloaded)),
+		WithReply("stream", Reply{Content: "partial answer", StreamErr: cut}),
+	)
+	ctx := context.Background()
+
+	if _, err := p.Chat(ctx, []llm.Message{llm.NewUserMessage("please fail")}); !errors.Is(err, overloaded) {
+		t.Fatalf("Chat error = %v, want the scripted error", err)
+	}
+	if _, err := p.ChatStream(ctx, []llm.Message{llm.NewUserMessage("please fail")}); !errors.Is(err, overloaded) {
+		t.Fatalf("ChatStream error = %v, want the scripted error", err)
+	}
+	if _, err := p.Chat(ctx, []llm.Message{llm.NewUserMessage("stream")}); !errors.Is(err, cut) {
+		t.Fatalf("Chat error = %v, want the stream error", err)
+	}
+
+	stream, err := p.ChatStream(ctx, []llm.Message{llm.NewUserMessage("stream")})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	defer stream.Close()
+	var content string
+	for {
+		msg, err := stream.Next()
+		if err != nil {
+			if !errors.Is(err, cut) {
+				t.Fatalf("stream ended with %v, want the scripted error", err)
+			}
+			break
+		}
+		content += msg.Content
+	}
+	if content != "partial answer" {
+		t.Fatalf("streamed %q before the error, want the full content", content)
+	}
+}
+
+func TestEmptyForSchema(t *testing.T) {
+	type answer struct {
+		Name    string   `json:"name"`
+		Segment string   `json:"segment" enum:"suv,sedan"`
+		Tags    []string `json:"tags"`
+		Power   *float64 `json:"power"`
+	}
+	p := NewFakeProvider(WithFallback(EmptyForSchema("plain")))
+	ctx := context.Background()
+
+	got, _, err := llm.ChatStructured[answer](ctx, p, []llm.Message{llm.NewUserMessage("describe")})
+	if err != nil {
+		t.Fatalf("ChatStructured: %v", err)
+	}
+	if got.Name != "" || got.Segment != "suv" || len(got.Tags) != 0 || got.Power != nil {
+		t.Fatalf("answer = %+v, want the empty document", got)
+	}
+
+	resp, err := p.Chat(ctx, []llm.Message{llm.NewUserMessage("describe")})
+	if err != nil || resp.Message.Content != "plain" {
+		t.Fatalf("unstructured reply = %q, %v", resp.Message.Content, err)
+	}
+}
+
+func TestGoldenReplay(t *testing.T) {
+	ctx := context.Background()
+	conversation := []llm.Message{llm.NewSystemMessage("Be brief."), llm.NewUserMessage("Capital of Peru?")}
+	path := filepath.Join(t.TempDir(), "golden", "session.json")
+
+	live := NewFakeProvider(WithReply("Peru", Text("Lima")), WithReply("boom", Fail(errors.New("upstream 500"))))
+	recorder := NewRecorder(live, path)
+	if _, err := recorder.Chat(ctx, conversation); err != nil {
+		t.Fatalf("record Chat: %v", err)
+	}
+	if _, err := recorder.Chat(ctx, []llm.Message{llm.NewUserMessage("boom")}); err == nil {
+		t.Fatal("recorded failure should fail")
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	golden, err := LoadGoldenFile(path)
+	if err != nil {
+		t.Fatalf("LoadGoldenFile: %v", err)
+	}
+	replay := NewFakeProvider(golden)
+
+	resp, err := replay.Chat(ctx, conversation)
+	if err != nil || resp.Message.Content != "Lima" {
+		t.Fatalf("replayed reply = %q, %v", resp.Message.Content, err)
+	}
+	if _, err := replay.Chat(ctx, []llm.Message{llm.NewUserMessage("boom")}); err == nil || err.Error() != "upstream 500" {
+		t.Fatalf("replayed failure = %v, want the recorded error text", err)
+	}
+
+	// Only an identical conversation matches
+	_, err = replay.Chat(ctx, conversation[1:])
+	assertCode(t, err, ErrGoldenMiss)
+
+	// Rules still answer what the golden file lacks
+	mixed := NewFakeProvider(golden, WithReply("Chile", Text("Santiago")))
+	if resp, err := mixed.Chat(ctx, []llm.Message{llm.NewUserMessage("Capital of Chile?")}); err != nil || resp.Message.Content != "Santiago" {
+		t.Fatalf("rule reply = %q, %v", resp.Message.Content, err)
+	}
+
+	_, err = LoadGoldenFile(filepath.Join(t.TempDir(), "missing.json"))
+	assertCode(t, err, ErrGoldenFile)
+}
+
+func TestRecorderRecordsStreams(t *testing.T) {
+	ctx := context.Background()
+	recorder := NewRecorder(NewFakeProvider(WithFallback(Text("streamed reply")), WithChunkSize(3)), "")
+
+	stream, err := recorder.ChatStream(ctx, []llm.Message{llm.NewUserMessage("hi")})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	for {
+		if _, err := stream.Next(); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+	}
+	stream.Close()
+
+	exchanges := recorder.Exchanges()
+	if len(exchanges) != 1 || exchanges[0].Response.Content != "streamed reply" {
+		t.Fatalf("exchanges = %+v, want the stream recorded once", exchanges)
+	}
+}
+
+func TestEmbeddingDimensions(t *testing.T) {
+	ctx := context.Background()
+	p := NewFakeProvider()
+
+	doc, err := p.EmbedQuery(ctx, "red SUV")
+	if err != nil {
+		t.Fatalf("EmbedQuery: %v", err)
+	}
+	if len(doc.Vector) != 1536 {
+		t.Fatalf("default dimensions = %d, want 1536", len(doc.Vector))
+	}
+	var norm float64
+	for _, v := range doc.Vector {
+		norm += float64(v) * float64(v)
+	}
+	if math.Abs(norm-1) > 1e-4 {
+		t.Fatalf("vector norm² = %g, want a unit vector", norm)
+	}
+
+	small := NewFakeProvider(WithEmbeddingDimensions(8))
+	vecs, err := small.EmbedDocuments(ctx, []string{"red SUV", "red SUV", "blue sedan"})
+	if err != nil {
+		t.Fatalf("EmbedDocuments: %v", err)
+	}
+	if len(vecs[0].Vector) != 8 {
+		t.Fatalf("configured dimensions = %d, want 8", len(vecs[0].Vector))
+	}
+	if !slices.Equal(vecs[0].Vector, vecs[1].Vector) || slices.Equal(vecs[0].Vector, vecs[2].Vector) {
+		t.Fatal("embeddings should be deterministic per text and differ between texts")
+	}
+
+	sized, err := small.EmbedQuery(ctx, "red SUV", embedding.WithDimensions(4))
+	if err != nil || len(sized.Vector) != 4 {
+		t.Fatalf("requested dimensions = %d, %v; want 4", len(sized.Vector), err)
+	}
+
+	_, err = small.EmbedDocuments(ctx, nil)
+	assertCode(t, err, ErrEmptyMessages)
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// assertCode fails unless err carries the registered error code.
+func assertCode(t *testing.T, err error, code *errx.ErrorCode) {
+	t.Helper()
+	var e *errx.Error
+	if !errors.As(err, &e) || e.Code != code.Code {
+		t.Fatalf("error = %v, want code %s", err, code.Code)
+	}
+}
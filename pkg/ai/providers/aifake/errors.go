@@ -0,0 +1,40 @@
+package aifake
+
+import (
+	"net/http"
+
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+var (
+	// Error registry for the fake provider
+	errorRegistry = errx.NewRegistry("AIFAKE")
+
+	ErrNoScriptedReply = errorRegistry.Register(
+		"NO_SCRIPTED_REPLY",
+		errx.TypeInternal,
+		http.StatusNotImplemented,
+		"No scripted reply matches the conversation",
+	)
+
+	ErrGoldenMiss = errorRegistry.Register(
+		"GOLDEN_MISS",
+		errx.TypeInternal,
+		http.StatusNotImplemented,
+		"Conversation not found in the golden file",
+	)
+
+	ErrGoldenFile = errorRegistry.Register(
+		"GOLDEN_FILE",
+		errx.TypeInternal,
+		http.StatusInternalServerError,
+		"Failed to read or write the golden file",
+	)
+
+	ErrEmptyMessages = errorRegistry.Register(
+		"EMPTY_MESSAGES",
+		errx.TypeValidation,
+		http.StatusBadRequest,
+		"Messages array cannot be empty",
+	)
+)
@@ -0,0 +1,178 @@
+package aifake
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+)
+
+// Exchange is one recorded chat turn. A failed call keeps its error text;
+// replaying it fails with a plain error carrying that text.
+type Exchange struct {
+	Messages []llm.Message `json:"messages"`
+	Response llm.Message   `json:"response"`
+	Usage    llm.Usage     `json:"usage"`
+	Model    string        `json:"model,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// ============================================================================
+// Recording
+// ============================================================================
+
+// Recorder wraps a real LLM and records every exchange so it can be saved as
+// a golden file and replayed offline.
+type Recorder struct {
+	llm  llm.LLM
+	path string
+
+	mu        sync.Mutex
+	exchanges []Exchange
+}
+
+// NewRecorder records the exchanges of model; Save writes them to path.
+func NewRecorder(model llm.LLM, path string) *Recorder {
+	return &Recorder{llm: model, path: path}
+}
+
+// Chat implements the LLM interface
+func (r *Recorder) Chat(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Response, error) {
+	resp, err := r.llm.Chat(ctx, messages, opts...)
+	ex := Exchange{Messages: messages, Response: resp.Message, Usage: resp.Usage, Model: resp.Model}
+	if err != nil {
+		ex.Error = err.Error()
+	}
+	r.record(ex)
+	return resp, err
+}
+
+// ChatStream implements the LLM interface. The exchange is recorded when the
+// stream ends, with the content it carried.
+func (r *Recorder) ChatStream(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Stream, error) {
+	stream, err := r.llm.ChatStream(ctx, messages, opts...)
+	if err != nil {
+		r.record(Exchange{Messages: messages, Error: err.Error()})
+		return nil, err
+	}
+	return &recordingStream{stream: stream, recorder: r, exchange: Exchange{
+		Messages: messages,
+		Response: llm.Message{Role: llm.RoleAssistant},
+	}}, nil
+}
+
+// Exchanges returns what has been recorded so far.
+func (r *Recorder) Exchanges() []Exchange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Exchange(nil), r.exchanges...)
+}
+
+// Save writes the recorded exchanges to the golden file.
+func (r *Recorder) Save() error {
+	data, err := json.MarshalIndent(r.Exchanges(), "", "  ")
+	if err != nil {
+		return errorRegistry.NewWithCause(ErrGoldenFile, err).WithDetail("path", r.path)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return errorRegistry.NewWithCause(ErrGoldenFile, err).WithDetail("path", r.path)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return errorRegistry.NewWithCause(ErrGoldenFile, err).WithDetail("path", r.path)
+	}
+	return nil
+}
+
+func (r *Recorder) record(ex Exchange) {
+	ex.Messages = append([]llm.Message(nil), ex.Messages...)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exchanges = append(r.exchanges, ex)
+}
+
+type recordingStream struct {
+	stream   llm.Stream
+	recorder *Recorder
+	exchange Exchange
+	recorded bool
+}
+
+func (s *recordingStream) Next() (llm.Message, error) {
+	msg, err := s.stream.Next()
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			s.exchange.Error = err.Error()
+		}
+		s.finish()
+		return msg, err
+	}
+	s.exchange.Response.Content += msg.Content
+	if len(msg.ToolCalls) > 0 {
+		s.exchange.Response.ToolCalls = msg.ToolCalls
+	}
+	return msg, nil
+}
+
+func (s *recordingStream) Close() error {
+	s.finish()
+	return s.stream.Close()
+}
+
+func (s *recordingStream) finish() {
+	if s.recorded {
+		return
+	}
+	s.recorded = true
+	s.recorder.record(s.exchange)
+}
+
+// ============================================================================
+// Replay
+// ============================================================================
+
+// LoadGoldenFile reads exchanges saved by a Recorder. The provider answers a
+// conversation identical to a recorded one with its recorded response;
+// other conversations fall through to the scripted rules.
+func LoadGoldenFile(path string) (ProviderOption, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errorRegistry.NewWithCause(ErrGoldenFile, err).WithDetail("path", path)
+	}
+	var exchanges []Exchange
+	if err := json.Unmarshal(data, &exchanges); err != nil {
+		return nil, errorRegistry.NewWithCause(ErrGoldenFile, err).WithDetail("path", path)
+	}
+	return WithExchanges(exchanges...), nil
+}
+
+// WithExchanges replays recorded exchanges. A later exchange of the same
+// conversation replaces an earlier one.
+func WithExchanges(exchanges ...Exchange) ProviderOption {
+	return func(p *FakeProvider) {
+		for _, ex := range exchanges {
+			usage := ex.Usage
+			reply := Reply{Content: ex.Response.Content, ToolCalls: ex.Response.ToolCalls, Usage: &usage}
+			if ex.Error != "" {
+				reply = Fail(errors.New(ex.Error))
+			}
+			p.golden[conversationKey(ex.Messages)] = reply
+		}
+	}
+}
+
+// conversationKey identifies a conversation by its messages, ignoring their
+// metadata.
+func conversationKey(messages []llm.Message) string {
+	stripped := make([]llm.Message, len(messages))
+	for i, m := range messages {
+		m.Metadata = nil
+		stripped[i] = m
+	}
+	data, _ := json.Marshal(stripped)
+	return string(data)
+}
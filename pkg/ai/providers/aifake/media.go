@@ -0,0 +1,166 @@
+package aifake
+
+import (
+	"bytes"
+	"context"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/rand/v2"
+	"os"
+
+	"github.com/Abraxas-365/divi/pkg/ai/embedding"
+	"github.com/Abraxas-365/divi/pkg/ai/ocr"
+	"github.com/Abraxas-365/divi/pkg/ai/speech"
+)
+
+// ============================================================================
+// Embedding Implementation
+// ============================================================================
+
+// EmbedDocuments returns one deterministic unit vector per document: the
+// same text always embeds to the same vector, different texts to unrelated
+// ones.
+func (p *FakeProvider) EmbedDocuments(ctx context.Context, documents []string, opts ...embedding.Option) ([]embedding.Embedding, error) {
+	if len(documents) == 0 {
+		return nil, errorRegistry.New(ErrEmptyMessages)
+	}
+
+	options := embedding.DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	dims := options.Dimensions
+	if dims <= 0 {
+		p.mu.Lock()
+		dims = p.dimensions
+		p.mu.Unlock()
+	}
+
+	tokens := 0
+	for _, doc := range documents {
+		tokens += estimateTokens(doc)
+	}
+	usage := embedding.Usage{PromptTokens: tokens, TotalTokens: tokens}
+
+	embeddings := make([]embedding.Embedding, len(documents))
+	for i, doc := range documents {
+		embeddings[i] = embedding.Embedding{Vector: hashVector(doc, dims), Usage: usage}
+	}
+	return embeddings, nil
+}
+
+// EmbedQuery embeds a single text like EmbedDocuments.
+func (p *FakeProvider) EmbedQuery(ctx context.Context, text string, opts ...embedding.Option) (embedding.Embedding, error) {
+	embeddings, err := p.EmbedDocuments(ctx, []string{text}, opts...)
+	if err != nil {
+		return embedding.Embedding{}, err
+	}
+	return embeddings[0], nil
+}
+
+// hashVector draws a unit vector from a generator seeded with the text.
+func hashVector(text string, dims int) []float32 {
+	h := fnv.New64a()
+	h.Write([]byte(text))
+	rng := rand.New(rand.NewPCG(h.Sum64(), 0))
+
+	vec := make([]float32, dims)
+	var norm float64
+	for i := range vec {
+		v := rng.NormFloat64()
+		vec[i] = float32(v)
+		norm += v * v
+	}
+	norm = math.Sqrt(norm)
+	for i := range vec {
+		vec[i] = float32(float64(vec[i]) / norm)
+	}
+	return vec
+}
+
+// ============================================================================
+// Speech Implementation
+// ============================================================================
+
+// Synthesize returns the text itself as the audio content.
+func (p *FakeProvider) Synthesize(ctx context.Context, text string, opts ...speech.SynthesisOption) (speech.Audio, error) {
+	options := speech.SynthesisOptions{AudioFormat: speech.AudioFormatMP3, SampleRate: 24000}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return speech.Audio{
+		Content:    io.NopCloser(bytes.NewReader([]byte(text))),
+		Format:     options.AudioFormat,
+		SampleRate: options.SampleRate,
+		Usage:      speech.TTSUsage{InputCharacters: len(text)},
+	}, nil
+}
+
+// Transcribe returns the scripted transcript, or the audio bytes read as
+// text, so audio produced by Synthesize transcribes back to its input.
+func (p *FakeProvider) Transcribe(ctx context.Context, audio io.Reader, opts ...speech.TranscriptionOption) (speech.Transcript, error) {
+	var options speech.TranscriptionOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	data, err := io.ReadAll(audio)
+	if err != nil {
+		return speech.Transcript{}, err
+	}
+
+	p.mu.Lock()
+	text := p.transcript
+	p.mu.Unlock()
+	if text == "" {
+		text = string(data)
+	}
+
+	return speech.Transcript{
+		Text:         text,
+		LanguageCode: options.Language,
+		Confidence:   1,
+	}, nil
+}
+
+// ============================================================================
+// OCR Implementation
+// ============================================================================
+
+// RecognizeText returns the scripted OCR text, or the document bytes read as
+// text, as a single page.
+func (p *FakeProvider) RecognizeText(ctx context.Context, input ocr.Input, opts ...ocr.Option) (*ocr.Result, error) {
+	p.mu.Lock()
+	text := p.ocrText
+	p.mu.Unlock()
+
+	if text == "" {
+		data, err := inputBytes(input)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+
+	return ocr.NewResultBuilder().
+		WithText(text).
+		WithMarkdown(text).
+		WithPages([]ocr.Page{{Number: 1, Text: text, Confidence: 1, Markdown: text}}).
+		WithConfidence(1).
+		WithUsage(ocr.Usage{PagesProcessed: 1}).
+		Build(), nil
+}
+
+func inputBytes(input ocr.Input) ([]byte, error) {
+	switch {
+	case input.Reader != nil:
+		return io.ReadAll(input.Reader)
+	case input.Data != nil:
+		return input.Data, nil
+	case input.Path != "":
+		return os.ReadFile(input.Path)
+	default:
+		return []byte(input.URL), nil
+	}
+}
@@ -0,0 +1,134 @@
+package aifake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+)
+
+// OpenAITransport answers OpenAI chat completion requests from the script,
+// for code that calls the OpenAI SDK directly (e.g. multi-modal vision):
+//
+//	client := openai.NewClient(
+//		option.WithAPIKey("fake"),
+//		option.WithHTTPClient(&http.Client{Transport: provider.OpenAITransport()}),
+//	)
+//
+// Image parts are dropped; rules match the text parts. Streaming requests
+// and other endpoints get a 404.
+func (p *FakeProvider) OpenAITransport() http.RoundTripper {
+	return &openAITransport{provider: p}
+}
+
+type openAITransport struct {
+	provider *FakeProvider
+}
+
+type openAIRequest struct {
+	Model    string `json:"model"`
+	Stream   bool   `json:"stream"`
+	Messages []struct {
+		Role       string          `json:"role"`
+		Content    json.RawMessage `json:"content"`
+		ToolCallID string          `json:"tool_call_id"`
+		ToolCalls  []llm.ToolCall  `json:"tool_calls"`
+	} `json:"messages"`
+}
+
+func (t *openAITransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodPost || !strings.HasSuffix(req.URL.Path, "/chat/completions") {
+		return jsonResponse(req, http.StatusNotFound, openAIError("aifake only serves chat completions"))
+	}
+
+	var body openAIRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		return jsonResponse(req, http.StatusBadRequest, openAIError(err.Error()))
+	}
+	if body.Stream {
+		return jsonResponse(req, http.StatusNotFound, openAIError("aifake does not stream over HTTP"))
+	}
+
+	messages := make([]llm.Message, 0, len(body.Messages))
+	for _, m := range body.Messages {
+		messages = append(messages, llm.Message{
+			Role:       m.Role,
+			Content:    contentText(m.Content),
+			ToolCallID: m.ToolCallID,
+			ToolCalls:  m.ToolCalls,
+		})
+	}
+
+	var opts []llm.Option
+	if body.Model != "" {
+		opts = append(opts, llm.WithModel(body.Model))
+	}
+	resp, err := t.provider.Chat(req.Context(), messages, opts...)
+	if err != nil {
+		return jsonResponse(req, http.StatusInternalServerError, openAIError(err.Error()))
+	}
+
+	message := map[string]any{"role": llm.RoleAssistant, "content": resp.Message.Content}
+	finish := "stop"
+	if len(resp.Message.ToolCalls) > 0 {
+		message["tool_calls"] = resp.Message.ToolCalls
+		finish = "tool_calls"
+	}
+	return jsonResponse(req, http.StatusOK, map[string]any{
+		"id":      "chatcmpl-fake",
+		"object":  "chat.completion",
+		"created": 0,
+		"model":   resp.Model,
+		"choices": []map[string]any{{"index": 0, "finish_reason": finish, "message": message}},
+		"usage":   resp.Usage,
+	})
+}
+
+// contentText returns a message's text: the string content, or its text
+// parts joined by newlines.
+func contentText(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return ""
+	}
+	texts := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part.Type == "text" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+func openAIError(message string) map[string]any {
+	return map[string]any{"error": map[string]any{"message": message, "type": "aifake_error"}}
+}
+
+func jsonResponse(req *http.Request, status int, v any) (*http.Response, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("aifake: encode response: %w", err)
+	}
+	return &http.Response{
+		StatusCode:    status,
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+	}, nil
+}
@@ -25,6 +25,9 @@ type HTTPClient struct {
 	baseURL    string
 	httpClient *http.Client
 	maxRetries int
+
+	// retryDelay is the backoff unit: attempt n waits n*retryDelay
+	retryDelay time.Duration
 }
 
 // NewHTTPClient creates a new HTTP client for Mistral API
@@ -44,6 +47,7 @@ func NewHTTPClient(apiKey, baseURL string, httpClient *http.Client) *HTTPClient
 		baseURL:    baseURL,
 		httpClient: httpClient,
 		maxRetries: MaxRetries,
+		retryDelay: time.Second,
 	}
 }
 
@@ -60,7 +64,7 @@ func (c *HTTPClient) Post(ctx context.Context, endpoint string, payload any) ([]
 	for attempt := 0; attempt <= c.maxRetries; attempt++ {
 		if attempt > 0 {
 			// Exponential backoff
-			backoff := time.Duration(attempt) * time.Second
+			backoff := time.Duration(attempt) * c.retryDelay
 			select {
 			case <-time.After(backoff):
 			case <-ctx.Done():
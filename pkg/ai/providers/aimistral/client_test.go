@@ -0,0 +1,160 @@
+package aimistral
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/ai/ocr"
+	"github.com/Abraxas-365/divi/pkg/ai/providers/aimock"
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+// newTestProvider points a provider at a fresh mock server with a
+// millisecond retry backoff.
+func newTestProvider(t *testing.T) (*MistralProvider, *aimock.Server) {
+	t.Helper()
+	srv := aimock.NewServer(t)
+	p, err := NewMistralProvider("test-key", WithBaseURL(srv.BaseURL()))
+	if err != nil {
+		t.Fatalf("NewMistralProvider: %v", err)
+	}
+	p.client.retryDelay = time.Millisecond
+	return p, srv
+}
+
+func TestRecognizeText(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteOCR, aimock.OCR("mistral-ocr-latest", "# Invoice", "Total: 120"))
+
+	result, err := p.RecognizeText(context.Background(), ocr.FromURL("https://example.com/invoice.pdf"),
+		ocr.WithTables(ocr.TableFormat("markdown")),
+	)
+	if err != nil {
+		t.Fatalf("RecognizeText: %v", err)
+	}
+
+	if len(result.Pages()) != 2 || result.Pages()[1].Markdown != "Total: 120" {
+		t.Fatalf("pages = %+v", result.Pages())
+	}
+	if !strings.Contains(result.Text(), "# Invoice") || result.Usage().PagesProcessed != 2 {
+		t.Fatalf("text = %q, usage = %+v", result.Text(), result.Usage())
+	}
+
+	req := srv.LastRequest(aimock.RouteOCR)
+	if got := req.Header.Get("Authorization"); got != "Bearer test-key" {
+		t.Fatalf("Authorization = %q", got)
+	}
+	var body OCRRequest
+	if err := req.Decode(&body); err != nil {
+		t.Fatalf("decode request: %v", err)
+	}
+	if body.Model != DefaultModel || body.Document.Type != "document_url" ||
+		body.Document.DocumentURL != "https://example.com/invoice.pdf" || body.TableFormat != "markdown" {
+		t.Fatalf("request = %+v", body)
+	}
+}
+
+func TestRecognizeTextEncodesBase64AsDataURL(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteOCR, aimock.OCR("mistral-ocr-latest", "text"))
+
+	if _, err := p.RecognizeText(context.Background(), ocr.FromBase64([]byte("JVBERi0="), "application/pdf")); err != nil {
+		t.Fatalf("RecognizeText: %v", err)
+	}
+
+	var body OCRRequest
+	if err := srv.LastRequest(aimock.RouteOCR).Decode(&body); err != nil {
+		t.Fatalf("decode request: %v", err)
+	}
+	if body.Document.DocumentURL != "data:application/pdf;base64,JVBERi0=" {
+		t.Fatalf("document_url = %q", body.Document.DocumentURL)
+	}
+}
+
+func TestAskQuestion(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteChat, aimock.ChatCompletion(DefaultChatModel, "120 USD"))
+
+	answer, err := p.AskQuestion(context.Background(), ocr.FromURL("https://example.com/invoice.pdf"), "What is the total?")
+	if err != nil {
+		t.Fatalf("AskQuestion: %v", err)
+	}
+	if answer.Answer != "120 USD" || answer.TokenUsage.TotalTokens != 15 {
+		t.Fatalf("answer = %+v", answer)
+	}
+
+	var body ChatRequest
+	if err := srv.LastRequest(aimock.RouteChat).Decode(&body); err != nil {
+		t.Fatalf("decode request: %v", err)
+	}
+	if len(body.Messages) != 1 || len(body.Messages[0].Content) != 2 {
+		t.Fatalf("messages = %+v", body.Messages)
+	}
+	question, document := body.Messages[0].Content[0], body.Messages[0].Content[1]
+	if question.Text != "What is the total?" || document.Type != "document_url" {
+		t.Fatalf("content = %+v", body.Messages[0].Content)
+	}
+}
+
+func TestPostRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		status       int
+		wantRequests int
+		wantErr      *errx.ErrorCode
+	}{
+		{name: "rate limit then success", failures: 2, status: http.StatusTooManyRequests, wantRequests: 3},
+		{name: "server error then success", failures: 1, status: http.StatusBadGateway, wantRequests: 2},
+		{name: "rate limit exhausts retries", failures: MaxRetries + 1, status: http.StatusTooManyRequests, wantRequests: MaxRetries + 1, wantErr: ErrAPIRateLimit},
+		{name: "server error exhausts retries", failures: MaxRetries + 1, status: http.StatusInternalServerError, wantRequests: MaxRetries + 1, wantErr: ErrAPIRequest},
+		{name: "bad request is not retried", failures: 1, status: http.StatusBadRequest, wantRequests: 1, wantErr: ErrInvalidInput},
+		{name: "unauthorized is not retried", failures: 1, status: http.StatusUnauthorized, wantRequests: 1, wantErr: ErrAPIUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, srv := newTestProvider(t)
+			srv.Fail(aimock.RouteOCR, tt.status, tt.failures)
+			srv.On(aimock.RouteOCR, aimock.OCR("mistral-ocr-latest", "text"))
+
+			_, err := p.RecognizeText(context.Background(), ocr.FromURL("https://example.com/doc.pdf"))
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("RecognizeText: %v", err)
+				}
+			} else {
+				if err == nil || err.Code != tt.wantErr.Code {
+					t.Fatalf("error = %v, want code %s", err, tt.wantErr.Code)
+				}
+				if err.Details["status_code"] != tt.status {
+					t.Fatalf("status_code detail = %v, want %d", err.Details["status_code"], tt.status)
+				}
+			}
+
+			if n := len(srv.Requests(aimock.RouteOCR)); n != tt.wantRequests {
+				t.Fatalf("requests = %d, want %d", n, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestPostStopsRetryingWhenContextEnds(t *testing.T) {
+	p, srv := newTestProvider(t)
+	p.client.retryDelay = time.Hour
+	srv.Fail(aimock.RouteOCR, http.StatusServiceUnavailable, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := p.RecognizeText(ctx, ocr.FromURL("https://example.com/doc.pdf"))
+	if err == nil || err.Code != ErrAPIRequest.Code {
+		t.Fatalf("error = %v, want code %s", err, ErrAPIRequest.Code)
+	}
+	if n := len(srv.Requests(aimock.RouteOCR)); n != 1 {
+		t.Fatalf("requests = %d, want 1", n)
+	}
+}
@@ -0,0 +1,293 @@
+// Package aimock is an in-process HTTP server speaking the OpenAI, Mistral,
+// Ollama and Anthropic wire protocols: chat completions (plain, streamed
+// over SSE or as NDJSON), embeddings, audio and OCR. Provider tests point
+// their base URL at it, queue canned responses or injected failures per
+// route, and assert on the request payloads the provider actually sent.
+//
+//	srv := aimock.NewServer(t)
+//	srv.Enqueue(aimock.RouteChat, aimock.ChatCompletion("gpt-4o", "hello"))
+//	provider := aiopenai.NewOpenAIProvider("key", option.WithBaseURL(srv.BaseURL()))
+package aimock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Route is an API endpoint path the server answers.
+type Route string
+
+const (
+	RouteChat           Route = "/v1/chat/completions"
+	RouteEmbeddings     Route = "/v1/embeddings"
+	RouteSpeech         Route = "/v1/audio/speech"
+	RouteTranscriptions Route = "/v1/audio/transcriptions"
+	RouteOCR            Route = "/v1/ocr"
+
+	RouteOllamaChat  Route = "/api/chat"
+	RouteOllamaEmbed Route = "/api/embed"
+)
+
+// Response is a canned answer to one request.
+type Response struct {
+	// Status defaults to 200
+	Status int
+	Header http.Header
+
+	// Body is written as-is when it is a string or []byte, and JSON-encoded
+	// otherwise
+	Body any
+
+	// Events, when set, are streamed as server-sent events, each
+	// JSON-encoded on a data line, followed by [DONE]. Body is ignored.
+	Events []any
+
+	// Lines, when set, are streamed as newline-delimited JSON. Body is
+	// ignored.
+	Lines []any
+}
+
+// Request is a request the server received.
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// Decode unmarshals the JSON body into v.
+func (r Request) Decode(v any) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// JSON returns the body as a generic JSON object, or nil if it isn't one.
+func (r Request) JSON() map[string]any {
+	var m map[string]any
+	if err := json.Unmarshal(r.Body, &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+// Expectation checks a request; it reports failures through t.
+type Expectation func(t testing.TB, r Request)
+
+// Server is the mock API. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	t testing.TB
+
+	mu       sync.Mutex
+	queues   map[Route][]Response
+	defaults map[Route]Response
+	expects  map[Route][]Expectation
+	requests map[Route][]Request
+}
+
+// NewServer starts a mock server that is closed when the test ends.
+// Requests to a route with nothing queued and no default fail the test.
+func NewServer(t testing.TB) *Server {
+	s := &Server{
+		t:        t,
+		queues:   map[Route][]Response{},
+		defaults: map[Route]Response{},
+		expects:  map[Route][]Expectation{},
+		requests: map[Route][]Request{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// BaseURL is the OpenAI-style API root to configure providers with. Ollama
+// routes live under the server URL itself.
+func (s *Server) BaseURL() string {
+	return s.URL + "/v1"
+}
+
+// Enqueue queues responses for route, served once each in order.
+func (s *Server) Enqueue(route Route, responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queues[route] = append(s.queues[route], responses...)
+}
+
+// On answers route with resp whenever its queue is empty.
+func (s *Server) On(route Route, resp Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defaults[route] = resp
+}
+
+// Fail makes the next times requests to route fail with status, ahead of
+// anything already queued.
+func (s *Server) Fail(route Route, status, times int) {
+	failures := make([]Response, times)
+	for i := range failures {
+		failures[i] = Error(status, "")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queues[route] = append(failures, s.queues[route]...)
+}
+
+// Expect runs check on every later request to route.
+func (s *Server) Expect(route Route, check Expectation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expects[route] = append(s.expects[route], check)
+}
+
+// Requests returns the requests received on route, oldest first.
+func (s *Server) Requests(route Route) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests[route]...)
+}
+
+// LastRequest returns the latest request on route, failing the test if
+// there is none.
+func (s *Server) LastRequest(route Route) Request {
+	s.t.Helper()
+	requests := s.Requests(route)
+	if len(requests) == 0 {
+		s.t.Fatalf("aimock: no request received on %s", route)
+	}
+	return requests[len(requests)-1]
+}
+
+// ============================================================================
+// Handler
+// ============================================================================
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeResponse(w, Error(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	route := Route(r.URL.Path)
+	req := Request{Method: r.Method, Path: r.URL.Path, Header: r.Header.Clone(), Body: body}
+
+	s.mu.Lock()
+	s.requests[route] = append(s.requests[route], req)
+	expects := append([]Expectation(nil), s.expects[route]...)
+	resp, ok := s.next(route)
+	s.mu.Unlock()
+
+	for _, check := range expects {
+		check(s.t, req)
+	}
+
+	if !ok {
+		s.t.Errorf("aimock: unexpected %s %s", r.Method, r.URL.Path)
+		writeResponse(w, Error(http.StatusNotFound, "no response queued for "+r.URL.Path))
+		return
+	}
+	writeResponse(w, resp)
+}
+
+// next pops the route's queue or falls back to its default. Callers hold
+// s.mu.
+func (s *Server) next(route Route) (Response, bool) {
+	if queue := s.queues[route]; len(queue) > 0 {
+		s.queues[route] = queue[1:]
+		return queue[0], true
+	}
+	resp, ok := s.defaults[route]
+	return resp, ok
+}
+
+func writeResponse(w http.ResponseWriter, resp Response) {
+	for key, values := range resp.Header {
+		for _, v := range values {
+			w.Header().Add(key, v)
+		}
+	}
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	if resp.Events != nil {
+		writeEvents(w, status, resp.Events)
+		return
+	}
+	if resp.Lines != nil {
+		writeLines(w, status, resp.Lines)
+		return
+	}
+
+	var data []byte
+	switch body := resp.Body.(type) {
+	case nil:
+	case []byte:
+		data = body
+	case string:
+		data = []byte(body)
+	default:
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("aimock: encode body: %v", err), http.StatusInternalServerError)
+			return
+		}
+		data = encoded
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+	}
+
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func writeEvents(w http.ResponseWriter, status int, events []any) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(status)
+
+	flusher, _ := w.(http.Flusher)
+	var buf bytes.Buffer
+	for _, event := range events {
+		buf.Reset()
+		buf.WriteString("data: ")
+		if raw, ok := event.(string); ok {
+			buf.WriteString(raw)
+		} else if err := json.NewEncoder(&buf).Encode(event); err != nil {
+			return
+		}
+		w.Write([]byte(strings.TrimRight(buf.String(), "\n") + "\n\n"))
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	w.Write([]byte("data: [DONE]\n\n"))
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+func writeLines(w http.ResponseWriter, status int, lines []any) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(status)
+
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	for _, line := range lines {
+		if err := enc.Encode(line); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
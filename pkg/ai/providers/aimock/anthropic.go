@@ -0,0 +1,133 @@
+package aimock
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// ============================================================================
+// Anthropic
+// ============================================================================
+
+// RouteAnthropicMessages is the Anthropic Messages API; like the Ollama
+// routes it lives under the server URL itself.
+const RouteAnthropicMessages Route = "/v1/messages"
+
+// AnthropicBlock is an assistant content block: text, or a tool use with
+// its input as raw JSON.
+type AnthropicBlock struct {
+	// Text pieces are joined in a message and streamed one delta each
+	Text []string
+
+	ToolID   string
+	ToolName string
+	Input    string
+}
+
+// AnthropicText is a text block made of pieces.
+func AnthropicText(pieces ...string) AnthropicBlock {
+	return AnthropicBlock{Text: pieces}
+}
+
+// AnthropicToolUse is a tool_use block; input is a JSON object.
+func AnthropicToolUse(id, name, input string) AnthropicBlock {
+	return AnthropicBlock{ToolID: id, ToolName: name, Input: input}
+}
+
+// AnthropicError is an Anthropic API error in its
+// {"type": "error", "error": {...}} envelope.
+func AnthropicError(status int, errType, message string) Response {
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return Response{Status: status, Body: map[string]any{
+		"type":  "error",
+		"error": map[string]any{"type": errType, "message": message},
+	}}
+}
+
+// AnthropicMessage is a non-streamed Messages API answer.
+func AnthropicMessage(model string, blocks ...AnthropicBlock) Response {
+	content := make([]any, 0, len(blocks))
+	stopReason := "end_turn"
+	for _, block := range blocks {
+		if block.ToolName != "" {
+			stopReason = "tool_use"
+			content = append(content, map[string]any{
+				"type":  "tool_use",
+				"id":    block.ToolID,
+				"name":  block.ToolName,
+				"input": json.RawMessage(block.Input),
+			})
+			continue
+		}
+		content = append(content, map[string]any{"type": "text", "text": strings.Join(block.Text, "")})
+	}
+
+	return Response{Body: map[string]any{
+		"id":          "msg_mock",
+		"type":        "message",
+		"role":        "assistant",
+		"model":       model,
+		"content":     content,
+		"stop_reason": stopReason,
+		"usage":       map[string]any{"input_tokens": 10, "output_tokens": 5},
+	}}
+}
+
+// AnthropicStream streams blocks as Messages API events: text one delta per
+// piece, tool input split across two deltas.
+func AnthropicStream(model string, blocks ...AnthropicBlock) Response {
+	events := []any{map[string]any{
+		"type": "message_start",
+		"message": map[string]any{
+			"id": "msg_mock", "type": "message", "role": "assistant", "model": model,
+			"content": []any{},
+			"usage":   map[string]any{"input_tokens": 10, "output_tokens": 1},
+		},
+	}}
+
+	stopReason := "end_turn"
+	for i, block := range blocks {
+		if block.ToolName != "" {
+			stopReason = "tool_use"
+			events = append(events, map[string]any{
+				"type": "content_block_start", "index": i,
+				"content_block": map[string]any{"type": "tool_use", "id": block.ToolID, "name": block.ToolName, "input": map[string]any{}},
+			})
+			half := len(block.Input) / 2
+			for _, part := range []string{block.Input[:half], block.Input[half:]} {
+				if part == "" {
+					continue
+				}
+				events = append(events, map[string]any{
+					"type": "content_block_delta", "index": i,
+					"delta": map[string]any{"type": "input_json_delta", "partial_json": part},
+				})
+			}
+		} else {
+			events = append(events, map[string]any{
+				"type": "content_block_start", "index": i,
+				"content_block": map[string]any{"type": "text", "text": ""},
+			})
+			for _, piece := range block.Text {
+				events = append(events, map[string]any{
+					"type": "content_block_delta", "index": i,
+					"delta": map[string]any{"type": "text_delta", "text": piece},
+				})
+			}
+		}
+		events = append(events, map[string]any{"type": "content_block_stop", "index": i})
+	}
+
+	events = append(events,
+		map[string]any{
+			"type":  "message_delta",
+			"delta": map[string]any{"stop_reason": stopReason},
+			"usage": map[string]any{"output_tokens": 5},
+		},
+		map[string]any{"type": "message_stop"},
+	)
+	return Response{Events: events}
+}
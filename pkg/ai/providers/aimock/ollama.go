@@ -0,0 +1,64 @@
+package aimock
+
+import "net/http"
+
+// ============================================================================
+// Ollama
+// ============================================================================
+
+// OllamaError is an Ollama API error, a bare {"error": message}.
+func OllamaError(status int, message string) Response {
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return Response{Status: status, Body: map[string]any{"error": message}}
+}
+
+// OllamaToolCall is a tool call as Ollama sends it: arguments are a JSON
+// object and there is no call ID.
+func OllamaToolCall(name string, arguments map[string]any) map[string]any {
+	return map[string]any{"function": map[string]any{"name": name, "arguments": arguments}}
+}
+
+// OllamaChat is a non-streamed /api/chat answer.
+func OllamaChat(model, content string, toolCalls ...map[string]any) Response {
+	return Response{Body: ollamaChunk(model, content, toolCalls, true)}
+}
+
+// OllamaStream streams content pieces as NDJSON chunks, then a final done
+// chunk carrying the tool calls, if any, and the token counts.
+func OllamaStream(model string, pieces []string, toolCalls ...map[string]any) Response {
+	lines := make([]any, 0, len(pieces)+1)
+	for _, piece := range pieces {
+		lines = append(lines, ollamaChunk(model, piece, nil, false))
+	}
+	return Response{Lines: append(lines, ollamaChunk(model, "", toolCalls, true))}
+}
+
+func ollamaChunk(model, content string, toolCalls []map[string]any, done bool) map[string]any {
+	message := map[string]any{"role": "assistant", "content": content}
+	if len(toolCalls) > 0 {
+		message["tool_calls"] = toolCalls
+	}
+	chunk := map[string]any{
+		"model":      model,
+		"created_at": "2024-01-01T00:00:00Z",
+		"message":    message,
+		"done":       done,
+	}
+	if done {
+		chunk["done_reason"] = "stop"
+		chunk["prompt_eval_count"] = 10
+		chunk["eval_count"] = 5
+	}
+	return chunk
+}
+
+// OllamaEmbeddings is an /api/embed answer with one vector per input.
+func OllamaEmbeddings(model string, vectors ...[]float64) Response {
+	return Response{Body: map[string]any{
+		"model":             model,
+		"embeddings":        vectors,
+		"prompt_eval_count": 8,
+	}}
+}
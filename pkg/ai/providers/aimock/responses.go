@@ -0,0 +1,198 @@
+package aimock
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// ============================================================================
+// Errors
+// ============================================================================
+
+// Error is an API error in the OpenAI format, which Mistral shares. An empty
+// message gets the status text. Rate limits carry a Retry-After header and
+// the rate_limit_exceeded code.
+func Error(status int, message string) Response {
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	switch {
+	case status == http.StatusTooManyRequests:
+		return ErrorWithCode(status, message, "requests", "rate_limit_exceeded").RetryAfter(0)
+	case status == http.StatusUnauthorized:
+		return ErrorWithCode(status, message, "invalid_request_error", "invalid_api_key")
+	case status < 500:
+		return ErrorWithCode(status, message, "invalid_request_error", "")
+	default:
+		return ErrorWithCode(status, message, "api_error", "")
+	}
+}
+
+// ErrorWithCode is an API error with an explicit type and code, e.g.
+// insufficient_quota.
+func ErrorWithCode(status int, message, errType, code string) Response {
+	detail := map[string]any{"message": message, "type": errType, "param": nil, "code": nil}
+	if code != "" {
+		detail["code"] = code
+	}
+	return Response{Status: status, Body: map[string]any{"error": detail}}
+}
+
+// RetryAfter sets the Retry-After header, in seconds.
+func (r Response) RetryAfter(seconds int) Response {
+	header := r.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Retry-After", strconv.Itoa(seconds))
+	r.Header = header
+	return r
+}
+
+// ============================================================================
+// Chat Completions
+// ============================================================================
+
+// ToolCall is a function call the mock model makes.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments string
+}
+
+// Usage is the token usage reported with chat completions.
+var Usage = map[string]any{"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}
+
+// ChatCompletion is a non-streamed completion answering with content and
+// optional tool calls.
+func ChatCompletion(model, content string, toolCalls ...ToolCall) Response {
+	message := map[string]any{"role": "assistant", "content": content, "refusal": nil}
+	finish := "stop"
+	if len(toolCalls) > 0 {
+		calls := make([]map[string]any, len(toolCalls))
+		for i, tc := range toolCalls {
+			calls[i] = map[string]any{
+				"id":       tc.ID,
+				"type":     "function",
+				"function": map[string]any{"name": tc.Name, "arguments": tc.Arguments},
+			}
+		}
+		message["tool_calls"] = calls
+		finish = "tool_calls"
+	}
+
+	return Response{Body: map[string]any{
+		"id":      "chatcmpl-mock",
+		"object":  "chat.completion",
+		"created": 1700000000,
+		"model":   model,
+		"choices": []map[string]any{{"index": 0, "message": message, "finish_reason": finish, "logprobs": nil}},
+		"usage":   Usage,
+	}}
+}
+
+// Delta is the change one streamed chunk carries.
+type Delta map[string]any
+
+// ContentDelta streams a piece of the answer text.
+func ContentDelta(content string) Delta {
+	return Delta{"role": "assistant", "content": content}
+}
+
+// ToolCallDelta streams part of the tool call at index. As the real API
+// does, only the first delta of a call carries its ID and name.
+func ToolCallDelta(index int, id, name, arguments string) Delta {
+	call := map[string]any{"index": index, "function": map[string]any{"arguments": arguments}}
+	if id != "" {
+		call["id"] = id
+		call["type"] = "function"
+	}
+	if name != "" {
+		call["function"].(map[string]any)["name"] = name
+	}
+	return Delta{"tool_calls": []any{call}}
+}
+
+// ChatStream streams the deltas as chat.completion.chunk events. The last
+// chunk carries the finish reason and the usage.
+func ChatStream(model string, deltas ...Delta) Response {
+	events := make([]any, 0, len(deltas)+1)
+	chunk := func(delta Delta, finish any) map[string]any {
+		return map[string]any{
+			"id":      "chatcmpl-mock",
+			"object":  "chat.completion.chunk",
+			"created": 1700000000,
+			"model":   model,
+			"choices": []map[string]any{{"index": 0, "delta": delta, "finish_reason": finish}},
+		}
+	}
+
+	finish := "stop"
+	for _, d := range deltas {
+		if _, ok := d["tool_calls"]; ok {
+			finish = "tool_calls"
+		}
+		events = append(events, chunk(d, nil))
+	}
+	last := chunk(Delta{}, finish)
+	last["usage"] = Usage
+	return Response{Events: append(events, last)}
+}
+
+// ============================================================================
+// Embeddings and Audio
+// ============================================================================
+
+// Embeddings returns one embedding per vector, in order.
+func Embeddings(model string, vectors ...[]float64) Response {
+	data := make([]map[string]any, len(vectors))
+	for i, v := range vectors {
+		data[i] = map[string]any{"object": "embedding", "index": i, "embedding": v}
+	}
+	return Response{Body: map[string]any{
+		"object": "list",
+		"model":  model,
+		"data":   data,
+		"usage":  map[string]any{"prompt_tokens": 8, "total_tokens": 8},
+	}}
+}
+
+// Speech returns audio bytes as the speech endpoint does.
+func Speech(audio []byte, contentType string) Response {
+	return Response{
+		Header: http.Header{"Content-Type": []string{contentType}},
+		Body:   audio,
+	}
+}
+
+// Transcription returns a transcript.
+func Transcription(text string) Response {
+	return Response{Body: map[string]any{"text": text}}
+}
+
+// ============================================================================
+// Mistral OCR
+// ============================================================================
+
+// OCR returns one page per markdown string.
+func OCR(model string, pages ...string) Response {
+	data := make([]map[string]any, len(pages))
+	for i, markdown := range pages {
+		data[i] = map[string]any{
+			"index":      i,
+			"markdown":   markdown,
+			"images":     []any{},
+			"tables":     []any{},
+			"hyperlinks": []any{},
+			"dimensions": map[string]any{"dpi": 200, "height": 2200, "width": 1700},
+		}
+	}
+	return Response{Body: map[string]any{
+		"pages": data,
+		"model": model,
+		"usage_info": map[string]any{
+			"pages_processed": len(pages),
+			"doc_size_bytes":  1024 * len(pages),
+		},
+	}}
+}
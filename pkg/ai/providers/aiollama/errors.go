@@ -0,0 +1,195 @@
+package aiollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+var (
+	// Error registry for Ollama provider
+	errorRegistry = errx.NewRegistry("OLLAMA")
+
+	// API Errors
+	ErrAPIRequest = errorRegistry.Register(
+		"API_REQUEST_FAILED",
+		errx.TypeExternal,
+		http.StatusBadGateway,
+		"Failed to make request to Ollama API",
+	)
+
+	ErrAPIResponse = errorRegistry.Register(
+		"API_RESPONSE_INVALID",
+		errx.TypeExternal,
+		http.StatusBadGateway,
+		"Invalid response from Ollama API",
+	)
+
+	ErrServerUnavailable = errorRegistry.Register(
+		"SERVER_UNAVAILABLE",
+		errx.TypeExternal,
+		http.StatusServiceUnavailable,
+		"Ollama server is not reachable",
+	)
+
+	ErrModelNotFound = errorRegistry.Register(
+		"MODEL_NOT_FOUND",
+		errx.TypeValidation,
+		http.StatusNotFound,
+		"Requested model is not pulled on the Ollama server",
+	)
+
+	ErrContextLengthExceeded = errorRegistry.Register(
+		"CONTEXT_LENGTH_EXCEEDED",
+		errx.TypeValidation,
+		http.StatusBadRequest,
+		"Input exceeds model's context length",
+	)
+
+	ErrInvalidRequest = errorRegistry.Register(
+		"INVALID_REQUEST",
+		errx.TypeValidation,
+		http.StatusBadRequest,
+		"Invalid request parameters",
+	)
+
+	// Input Validation Errors
+	ErrEmptyMessages = errorRegistry.Register(
+		"EMPTY_MESSAGES",
+		errx.TypeValidation,
+		http.StatusBadRequest,
+		"Messages array cannot be empty",
+	)
+
+	ErrInvalidMessage = errorRegistry.Register(
+		"INVALID_MESSAGE",
+		errx.TypeValidation,
+		http.StatusBadRequest,
+		"Invalid message format",
+	)
+
+	ErrUnsupportedRole = errorRegistry.Register(
+		"UNSUPPORTED_ROLE",
+		errx.TypeValidation,
+		http.StatusBadRequest,
+		"Unsupported message role",
+	)
+
+	ErrEmptyEmbeddingInput = errorRegistry.Register(
+		"EMPTY_EMBEDDING_INPUT",
+		errx.TypeValidation,
+		http.StatusBadRequest,
+		"Embedding input cannot be empty",
+	)
+
+	// Response Errors
+	ErrNoEmbeddingReturned = errorRegistry.Register(
+		"NO_EMBEDDING_RETURNED",
+		errx.TypeExternal,
+		http.StatusBadGateway,
+		"No embedding returned from API",
+	)
+
+	ErrStreamFailed = errorRegistry.Register(
+		"STREAM_FAILED",
+		errx.TypeExternal,
+		http.StatusBadGateway,
+		"Streaming response failed",
+	)
+
+	// Processing Errors
+	ErrJSONParsing = errorRegistry.Register(
+		"JSON_PARSING_ERROR",
+		errx.TypeInternal,
+		http.StatusInternalServerError,
+		"Failed to parse JSON",
+	)
+
+	ErrConversionFailed = errorRegistry.Register(
+		"CONVERSION_FAILED",
+		errx.TypeInternal,
+		http.StatusInternalServerError,
+		"Failed to convert data format",
+	)
+)
+
+// ParseOllamaError maps a transport error to the matching error code
+func ParseOllamaError(err error) *errx.Error {
+	if err == nil {
+		return nil
+	}
+
+	// Check if it's already a custom error
+	var customErr *errx.Error
+	if errx.As(err, &customErr) {
+		return customErr
+	}
+
+	// A local server that isn't running refuses the connection
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return errorRegistry.NewWithCause(ErrServerUnavailable, err)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return errorRegistry.NewWithCause(ErrServerUnavailable, err)
+	}
+
+	return errorRegistry.NewWithCause(ErrAPIRequest, err)
+}
+
+// WrapError wraps a standard error with appropriate Ollama error code
+func WrapError(err error, code *errx.ErrorCode) *errx.Error {
+	if err == nil {
+		return nil
+	}
+
+	// Check if it's already a custom error
+	var customErr *errx.Error
+	if errx.As(err, &customErr) {
+		return customErr
+	}
+
+	return errorRegistry.NewWithCause(code, err)
+}
+
+// ParseAPIErrorResponse parses error from API response body. Ollama answers
+// failures with {"error": "message"}.
+func ParseAPIErrorResponse(statusCode int, body []byte) *errx.Error {
+	var errResp struct {
+		Error string `json:"error"`
+	}
+
+	message := strings.TrimSpace(string(body))
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != "" {
+		message = errResp.Error
+	}
+	if message == "" {
+		message = http.StatusText(statusCode)
+	}
+	msgLower := strings.ToLower(message)
+
+	// Map to appropriate error code
+	var baseErr *errx.ErrorCode
+	switch {
+	case statusCode == http.StatusNotFound && strings.Contains(msgLower, "model"):
+		baseErr = ErrModelNotFound
+	case strings.Contains(msgLower, "context length") || strings.Contains(msgLower, "context window"):
+		baseErr = ErrContextLengthExceeded
+	case statusCode == http.StatusBadRequest:
+		baseErr = ErrInvalidRequest
+	case statusCode == http.StatusServiceUnavailable:
+		baseErr = ErrServerUnavailable
+	case statusCode >= 500:
+		baseErr = ErrAPIResponse
+	default:
+		baseErr = ErrAPIRequest
+	}
+
+	return errorRegistry.NewWithMessage(baseErr, message).
+		WithDetail("status_code", statusCode)
+}
@@ -0,0 +1,553 @@
+// Package aiollama implements llm.LLM and embedding.Embedder against the
+// Ollama HTTP API, which llama.cpp-based servers also speak, so enrichment
+// and listings can run on a self-hosted model.
+package aiollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/ai/embedding"
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+const (
+	DefaultBaseURL        = "http://localhost:11434"
+	DefaultModel          = "llama3.1"
+	DefaultEmbeddingModel = "nomic-embed-text"
+	DefaultTimeout        = 5 * time.Minute // local models can be slow on CPU
+)
+
+var (
+	_ llm.LLM            = (*OllamaProvider)(nil)
+	_ embedding.Embedder = (*OllamaProvider)(nil)
+)
+
+// OllamaProvider implements the LLM and Embedder interfaces for Ollama
+type OllamaProvider struct {
+	baseURL        string
+	model          string
+	embeddingModel string
+	keepAlive      string
+	httpClient     *http.Client
+}
+
+// NewOllamaProvider creates a new Ollama provider. The base URL defaults to
+// OLLAMA_HOST, then to the local server.
+func NewOllamaProvider(opts ...ProviderOption) *OllamaProvider {
+	p := &OllamaProvider{
+		baseURL:        hostFromEnv(),
+		model:          DefaultModel,
+		embeddingModel: DefaultEmbeddingModel,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.httpClient == nil {
+		p.httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	p.baseURL = strings.TrimRight(p.baseURL, "/")
+
+	return p
+}
+
+// hostFromEnv reads OLLAMA_HOST the way the ollama CLI does: a bare
+// host:port means plain HTTP.
+func hostFromEnv() string {
+	host := os.Getenv("OLLAMA_HOST")
+	if host == "" {
+		return DefaultBaseURL
+	}
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+	return host
+}
+
+// ============================================================================
+// API Types
+// ============================================================================
+
+type chatRequest struct {
+	Model     string          `json:"model"`
+	Messages  []chatMessage   `json:"messages"`
+	Stream    bool            `json:"stream"`
+	Format    json.RawMessage `json:"format,omitempty"`
+	Options   map[string]any  `json:"options,omitempty"`
+	Tools     []llm.Tool      `json:"tools,omitempty"`
+	KeepAlive string          `json:"keep_alive,omitempty"`
+}
+
+type chatMessage struct {
+	Role      string         `json:"role"`
+	Content   string         `json:"content"`
+	ToolCalls []chatToolCall `json:"tool_calls,omitempty"`
+	ToolName  string         `json:"tool_name,omitempty"`
+}
+
+// chatToolCall carries its arguments as a JSON object, not a string
+type chatToolCall struct {
+	ID       string `json:"id,omitempty"`
+	Function struct {
+		Index     int             `json:"index,omitempty"`
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type chatResponse struct {
+	Model           string      `json:"model"`
+	Message         chatMessage `json:"message"`
+	Done            bool        `json:"done"`
+	DoneReason      string      `json:"done_reason"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+	EvalCount       int         `json:"eval_count"`
+	Error           string      `json:"error"`
+}
+
+type embedRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions,omitempty"`
+	KeepAlive  string   `json:"keep_alive,omitempty"`
+}
+
+type embedResponse struct {
+	Model           string      `json:"model"`
+	Embeddings      [][]float32 `json:"embeddings"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+}
+
+// ============================================================================
+// Chat Implementation
+// ============================================================================
+
+// Chat implements the LLM interface
+func (p *OllamaProvider) Chat(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Response, error) {
+	req, options, err := p.buildChatRequest(messages, opts, false)
+	if err != nil {
+		return llm.Response{}, err
+	}
+
+	resp, apiErr := p.post(ctx, "/api/chat", req)
+	if apiErr != nil {
+		return llm.Response{}, apiErr.
+			WithDetail("model", req.Model).
+			WithDetail("num_messages", len(messages))
+	}
+	defer resp.Body.Close()
+
+	var completion chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return llm.Response{}, WrapError(err, ErrAPIResponse).
+			WithDetail("error", "failed to parse response")
+	}
+	if completion.Error != "" {
+		return llm.Response{}, errorRegistry.NewWithMessage(ErrAPIResponse, completion.Error).
+			WithDetail("model", options.Model)
+	}
+
+	message := llm.Message{
+		Role:    llm.RoleAssistant,
+		Content: completion.Message.Content,
+	}
+	message.ToolCalls = appendToolCalls(nil, completion.Message.ToolCalls)
+
+	return llm.Response{
+		Message: message,
+		Usage: llm.Usage{
+			PromptTokens:     completion.PromptEvalCount,
+			CompletionTokens: completion.EvalCount,
+			TotalTokens:      completion.PromptEvalCount + completion.EvalCount,
+		},
+		Model: completion.Model,
+	}, nil
+}
+
+// ============================================================================
+// Chat Stream Implementation
+// ============================================================================
+
+// ChatStream implements the LLM interface. Ollama streams newline-delimited
+// JSON; each chunk yields its content delta and the tool calls so far.
+func (p *OllamaProvider) ChatStream(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Stream, error) {
+	req, _, err := p.buildChatRequest(messages, opts, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, apiErr := p.post(ctx, "/api/chat", req)
+	if apiErr != nil {
+		return nil, apiErr.
+			WithDetail("model", req.Model).
+			WithDetail("num_messages", len(messages))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	return &ollamaStream{body: resp.Body, scanner: scanner}, nil
+}
+
+type ollamaStream struct {
+	body      io.ReadCloser
+	scanner   *bufio.Scanner
+	toolCalls []llm.ToolCall
+	lastError error
+}
+
+func (s *ollamaStream) Next() (llm.Message, error) {
+	for {
+		if s.lastError != nil {
+			return llm.Message{}, s.lastError
+		}
+
+		if !s.scanner.Scan() {
+			if err := s.scanner.Err(); err != nil {
+				s.lastError = WrapError(err, ErrStreamFailed)
+			} else {
+				s.lastError = io.EOF
+			}
+			continue
+		}
+
+		line := bytes.TrimSpace(s.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var chunk chatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			s.lastError = WrapError(err, ErrStreamFailed).
+				WithDetail("error", "failed to parse stream chunk")
+			continue
+		}
+		if chunk.Error != "" {
+			s.lastError = errorRegistry.NewWithMessage(ErrStreamFailed, chunk.Error)
+			continue
+		}
+
+		s.toolCalls = appendToolCalls(s.toolCalls, chunk.Message.ToolCalls)
+		if chunk.Done {
+			s.lastError = io.EOF
+			if chunk.Message.Content == "" && len(chunk.Message.ToolCalls) == 0 {
+				continue
+			}
+		}
+
+		return llm.Message{
+			Role:      llm.RoleAssistant,
+			Content:   chunk.Message.Content, // delta only
+			ToolCalls: s.toolCalls,           // full accumulated snapshot
+		}, nil
+	}
+}
+
+func (s *ollamaStream) Close() error {
+	return s.body.Close()
+}
+
+// ============================================================================
+// Embedding Implementation
+// ============================================================================
+
+// EmbedDocuments implements the Embedder interface
+func (p *OllamaProvider) EmbedDocuments(ctx context.Context, documents []string, opts ...embedding.Option) ([]embedding.Embedding, error) {
+	if len(documents) == 0 {
+		return nil, errorRegistry.New(ErrEmptyEmbeddingInput)
+	}
+
+	options := embedding.DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	req := embedRequest{
+		Model:      options.Model,
+		Input:      documents,
+		Dimensions: options.Dimensions,
+		KeepAlive:  p.keepAlive,
+	}
+	if req.Model == "" {
+		req.Model = p.embeddingModel
+	}
+
+	resp, apiErr := p.post(ctx, "/api/embed", req)
+	if apiErr != nil {
+		return nil, apiErr.
+			WithDetail("model", req.Model).
+			WithDetail("num_documents", len(documents))
+	}
+	defer resp.Body.Close()
+
+	var result embedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, WrapError(err, ErrAPIResponse).
+			WithDetail("error", "failed to parse response")
+	}
+
+	if len(result.Embeddings) != len(documents) {
+		return nil, errorRegistry.New(ErrNoEmbeddingReturned).
+			WithDetail("num_documents", len(documents)).
+			WithDetail("num_embeddings", len(result.Embeddings))
+	}
+
+	usage := embedding.Usage{
+		PromptTokens: result.PromptEvalCount,
+		TotalTokens:  result.PromptEvalCount,
+	}
+	embeddings := make([]embedding.Embedding, len(result.Embeddings))
+	for i, vector := range result.Embeddings {
+		embeddings[i] = embedding.Embedding{Vector: vector, Usage: usage}
+	}
+
+	return embeddings, nil
+}
+
+// EmbedQuery implements the Embedder interface
+func (p *OllamaProvider) EmbedQuery(ctx context.Context, text string, opts ...embedding.Option) (embedding.Embedding, error) {
+	if text == "" {
+		return embedding.Embedding{}, errorRegistry.New(ErrEmptyEmbeddingInput)
+	}
+
+	embeddings, err := p.EmbedDocuments(ctx, []string{text}, opts...)
+	if err != nil {
+		return embedding.Embedding{}, err
+	}
+
+	return embeddings[0], nil
+}
+
+// ============================================================================
+// HTTP
+// ============================================================================
+
+// post sends payload as JSON and returns the response when it succeeded.
+// The caller closes the body.
+func (p *OllamaProvider) post(ctx context.Context, endpoint string, payload any) (*http.Response, *errx.Error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, WrapError(err, ErrJSONParsing).
+			WithDetail("error", "failed to marshal request payload")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, WrapError(err, ErrAPIRequest).
+			WithDetail("error", "failed to create HTTP request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, ParseOllamaError(err).
+			WithDetail("url", p.baseURL+endpoint)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, ParseAPIErrorResponse(resp.StatusCode, respBody)
+	}
+
+	return resp, nil
+}
+
+// ============================================================================
+// Helper Functions
+// ============================================================================
+
+func (p *OllamaProvider) buildChatRequest(messages []llm.Message, opts []llm.Option, stream bool) (chatRequest, *llm.ChatOptions, *errx.Error) {
+	if len(messages) == 0 {
+		return chatRequest{}, nil, errorRegistry.New(ErrEmptyMessages)
+	}
+
+	options := llm.DefaultOptions()
+	options.Model = p.model
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	converted, err := convertToOllamaMessages(messages)
+	if err != nil {
+		return chatRequest{}, nil, err
+	}
+
+	req := chatRequest{
+		Model:     options.Model,
+		Messages:  converted,
+		Stream:    stream,
+		Options:   convertToOllamaOptions(options),
+		KeepAlive: p.keepAlive,
+	}
+
+	// Ollama has no tool_choice; "none" is honoured by not offering tools
+	if choice, _ := options.ToolChoice.(string); choice != "none" {
+		req.Tools = convertToOllamaTools(options.Tools, options.Functions)
+	}
+
+	if req.Format, err = convertToOllamaFormat(options); err != nil {
+		return chatRequest{}, nil, err
+	}
+
+	return req, options, nil
+}
+
+// convertToOllamaMessages converts the conversation. Ollama identifies tool
+// results by tool name rather than call ID, so each result is named after
+// the call it answers.
+func convertToOllamaMessages(messages []llm.Message) ([]chatMessage, *errx.Error) {
+	toolNames := map[string]string{}
+	result := make([]chatMessage, 0, len(messages))
+
+	for i, msg := range messages {
+		switch msg.Role {
+		case llm.RoleSystem, llm.RoleUser:
+			result = append(result, chatMessage{Role: msg.Role, Content: msg.Content})
+
+		case llm.RoleAssistant:
+			out := chatMessage{Role: llm.RoleAssistant, Content: msg.Content}
+			for _, tc := range msg.ToolCalls {
+				toolNames[tc.ID] = tc.Function.Name
+
+				var call chatToolCall
+				call.ID = tc.ID
+				call.Function.Name = tc.Function.Name
+				call.Function.Arguments = json.RawMessage(tc.Function.Arguments)
+				if strings.TrimSpace(tc.Function.Arguments) == "" {
+					call.Function.Arguments = json.RawMessage("{}")
+				} else if !json.Valid(call.Function.Arguments) {
+					return nil, errorRegistry.New(ErrInvalidMessage).
+						WithDetail("message_index", i).
+						WithDetail("error", "tool call arguments are not valid JSON")
+				}
+				out.ToolCalls = append(out.ToolCalls, call)
+			}
+			result = append(result, out)
+
+		case llm.RoleTool:
+			result = append(result, chatMessage{
+				Role:     llm.RoleTool,
+				Content:  msg.Content,
+				ToolName: toolNames[msg.ToolCallID],
+			})
+
+		case llm.RoleFunction:
+			result = append(result, chatMessage{Role: llm.RoleTool, Content: msg.Content, ToolName: msg.Name})
+
+		default:
+			return nil, errorRegistry.New(ErrUnsupportedRole).
+				WithDetail("message_index", i).
+				WithDetail("role", msg.Role)
+		}
+	}
+
+	return result, nil
+}
+
+func convertToOllamaOptions(options *llm.ChatOptions) map[string]any {
+	result := map[string]any{}
+	if options.Temperature != 0 {
+		result["temperature"] = options.Temperature
+	}
+	if options.TopP != 0 {
+		result["top_p"] = options.TopP
+	}
+	if options.MaxCompletionTokens > 0 {
+		result["num_predict"] = options.MaxCompletionTokens
+	} else if options.MaxTokens > 0 {
+		result["num_predict"] = options.MaxTokens
+	}
+	if len(options.Stop) > 0 {
+		result["stop"] = options.Stop
+	}
+	if options.Seed != 0 {
+		result["seed"] = options.Seed
+	}
+	if options.PresencePenalty != 0 {
+		result["presence_penalty"] = options.PresencePenalty
+	}
+	if options.FrequencyPenalty != 0 {
+		result["frequency_penalty"] = options.FrequencyPenalty
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+func convertToOllamaTools(tools []llm.Tool, functions []llm.Function) []llm.Tool {
+	result := make([]llm.Tool, 0, len(tools)+len(functions))
+	for _, tool := range tools {
+		if tool.Type == "function" {
+			result = append(result, tool)
+		}
+	}
+	for _, fn := range functions {
+		result = append(result, llm.Tool{Type: "function", Function: fn})
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// convertToOllamaFormat returns "json" for JSON mode, or the schema itself
+// for structured output.
+func convertToOllamaFormat(options *llm.ChatOptions) (json.RawMessage, *errx.Error) {
+	if options.JSONMode {
+		return json.RawMessage(`"json"`), nil
+	}
+	if options.ResponseFormat == nil {
+		return nil, nil
+	}
+
+	switch options.ResponseFormat.Type {
+	case llm.JSONObject:
+		return json.RawMessage(`"json"`), nil
+	case llm.JSONSchema:
+		schema, err := json.Marshal(options.ResponseFormat.JSONSchema)
+		if err != nil {
+			return nil, WrapError(err, ErrConversionFailed).
+				WithDetail("error", "failed to convert response format")
+		}
+		return schema, nil
+	default:
+		return nil, nil
+	}
+}
+
+// appendToolCalls converts calls and appends them to acc. Calls without an
+// ID are numbered by their position so tool results can refer to them.
+func appendToolCalls(acc []llm.ToolCall, calls []chatToolCall) []llm.ToolCall {
+	for _, tc := range calls {
+		id := tc.ID
+		if id == "" {
+			id = fmt.Sprintf("call_%d", len(acc))
+		}
+		arguments := string(tc.Function.Arguments)
+		if arguments == "" || arguments == "null" {
+			arguments = "{}"
+		}
+		acc = append(acc, llm.ToolCall{
+			ID:   id,
+			Type: "function",
+			Function: llm.FunctionCall{
+				Name:      tc.Function.Name,
+				Arguments: arguments,
+			},
+		})
+	}
+	return acc
+}
@@ -0,0 +1,339 @@
+package aiollama
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Abraxas-365/divi/pkg/ai/embedding"
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+	"github.com/Abraxas-365/divi/pkg/ai/providers/aimock"
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+func newTestProvider(t *testing.T, opts ...ProviderOption) (*OllamaProvider, *aimock.Server) {
+	t.Helper()
+	srv := aimock.NewServer(t)
+	return NewOllamaProvider(append([]ProviderOption{WithBaseURL(srv.URL), WithModel("qwen2.5")}, opts...)...), srv
+}
+
+func TestChatSendsConversationAndOptions(t *testing.T) {
+	p, srv := newTestProvider(t, WithKeepAlive("10m"))
+	srv.Enqueue(aimock.RouteOllamaChat, aimock.OllamaChat("qwen2.5", `{"sky":"clear"}`))
+
+	weather := llm.Tool{Type: "function", Function: llm.Function{
+		Name:       "get_weather",
+		Parameters: map[string]any{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}},
+	}}
+	messages := []llm.Message{
+		llm.NewSystemMessage("You are terse."),
+		llm.NewUserMessage("Weather in Lima?"),
+		{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{{
+			ID: "call_0", Type: "function",
+			Function: llm.FunctionCall{Name: "get_weather", Arguments: `{"city":"Lima"}`},
+		}}},
+		llm.NewToolMessage("call_0", `{"sky":"clear"}`),
+	}
+
+	resp, err := p.Chat(context.Background(), messages,
+		llm.WithTemperature(0.2),
+		llm.WithMaxTokens(128),
+		llm.WithStop([]string{"###"}),
+		llm.WithTools([]llm.Tool{weather}),
+		llm.WithJSONMode(),
+	)
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if resp.Message.Content != `{"sky":"clear"}` || resp.Model != "qwen2.5" {
+		t.Fatalf("response = %q from %q", resp.Message.Content, resp.Model)
+	}
+	if resp.Usage != (llm.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}) {
+		t.Fatalf("usage = %+v", resp.Usage)
+	}
+
+	var body struct {
+		Model     string `json:"model"`
+		Stream    bool   `json:"stream"`
+		Format    string `json:"format"`
+		KeepAlive string `json:"keep_alive"`
+		Options   struct {
+			Temperature float64  `json:"temperature"`
+			NumPredict  int      `json:"num_predict"`
+			Stop        []string `json:"stop"`
+		} `json:"options"`
+		Messages []struct {
+			Role      string `json:"role"`
+			Content   string `json:"content"`
+			ToolName  string `json:"tool_name"`
+			ToolCalls []struct {
+				Function struct {
+					Name      string         `json:"name"`
+					Arguments map[string]any `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"messages"`
+		Tools []llm.Tool `json:"tools"`
+	}
+	if err := srv.LastRequest(aimock.RouteOllamaChat).Decode(&body); err != nil {
+		t.Fatalf("decode request: %v", err)
+	}
+
+	if body.Model != "qwen2.5" || body.Stream || body.Format != "json" || body.KeepAlive != "10m" {
+		t.Fatalf("model/stream/format/keep_alive = %q/%v/%q/%q", body.Model, body.Stream, body.Format, body.KeepAlive)
+	}
+	if body.Options.Temperature != 0.2 || body.Options.NumPredict != 128 || !slices.Equal(body.Options.Stop, []string{"###"}) {
+		t.Fatalf("options = %+v", body.Options)
+	}
+
+	assistant := body.Messages[2]
+	if len(assistant.ToolCalls) != 1 || assistant.ToolCalls[0].Function.Name != "get_weather" ||
+		assistant.ToolCalls[0].Function.Arguments["city"] != "Lima" {
+		t.Fatalf("assistant tool calls = %+v, want arguments as an object", assistant.ToolCalls)
+	}
+	if tool := body.Messages[3]; tool.Role != "tool" || tool.ToolName != "get_weather" {
+		t.Fatalf("tool message = %+v, want it named after the call it answers", tool)
+	}
+	if len(body.Tools) != 1 || body.Tools[0].Function.Name != "get_weather" {
+		t.Fatalf("tools = %+v", body.Tools)
+	}
+}
+
+func TestChatSendsSchemaAsFormat(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteOllamaChat, aimock.OllamaChat("qwen2.5", `{"year":2021}`))
+
+	schema := map[string]any{"type": "object", "properties": map[string]any{"year": map[string]any{"type": "integer"}}}
+	if _, err := p.Chat(context.Background(), []llm.Message{llm.NewUserMessage("Year?")},
+		llm.WithJSONSchemaResponseFormat(schema)); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	format, _ := srv.LastRequest(aimock.RouteOllamaChat).JSON()["format"].(map[string]any)
+	if format["type"] != "object" || format["properties"] == nil {
+		t.Fatalf("format = %v, want the schema", format)
+	}
+}
+
+func TestChatOmitsToolsWhenChoiceIsNone(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteOllamaChat, aimock.OllamaChat("qwen2.5", "ok"))
+
+	tool := llm.Tool{Type: "function", Function: llm.Function{Name: "lookup"}}
+	if _, err := p.Chat(context.Background(), []llm.Message{llm.NewUserMessage("hi")},
+		llm.WithTools([]llm.Tool{tool}), llm.WithToolChoice("none")); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	if tools, ok := srv.LastRequest(aimock.RouteOllamaChat).JSON()["tools"]; ok {
+		t.Fatalf("tools = %v, want none", tools)
+	}
+}
+
+func TestChatParsesToolCalls(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteOllamaChat, aimock.OllamaChat("qwen2.5", "",
+		aimock.OllamaToolCall("get_weather", map[string]any{"city": "Lima"}),
+		aimock.OllamaToolCall("get_time", nil),
+	))
+
+	resp, err := p.Chat(context.Background(), []llm.Message{llm.NewUserMessage("Weather and time?")})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	want := []llm.ToolCall{
+		{ID: "call_0", Type: "function", Function: llm.FunctionCall{Name: "get_weather", Arguments: `{"city":"Lima"}`}},
+		{ID: "call_1", Type: "function", Function: llm.FunctionCall{Name: "get_time", Arguments: `{}`}},
+	}
+	if !slices.Equal(resp.Message.ToolCalls, want) {
+		t.Fatalf("tool calls = %+v, want %+v", resp.Message.ToolCalls, want)
+	}
+}
+
+func TestChatValidatesBeforeCalling(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []llm.Message
+		want     *errx.ErrorCode
+	}{
+		{"empty messages", nil, ErrEmptyMessages},
+		{"unsupported role", []llm.Message{{Role: "narrator", Content: "x"}}, ErrUnsupportedRole},
+		{"malformed tool arguments", []llm.Message{{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{{
+			ID: "c", Function: llm.FunctionCall{Name: "f", Arguments: "{not json"},
+		}}}}, ErrInvalidMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, srv := newTestProvider(t)
+			_, err := p.Chat(context.Background(), tt.messages)
+			assertCode(t, err, tt.want)
+			if n := len(srv.Requests(aimock.RouteOllamaChat)); n != 0 {
+				t.Fatalf("requests = %d, want none", n)
+			}
+		})
+	}
+}
+
+func TestChatMapsAPIErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		resp aimock.Response
+		want *errx.ErrorCode
+	}{
+		{"model not pulled", aimock.OllamaError(http.StatusNotFound, `model "qwen2.5" not found, try pulling it first`), ErrModelNotFound},
+		{"bad request", aimock.OllamaError(http.StatusBadRequest, "invalid options"), ErrInvalidRequest},
+		{"context overflow", aimock.OllamaError(http.StatusBadRequest, "input exceeds the context length"), ErrContextLengthExceeded},
+		{"server busy", aimock.OllamaError(http.StatusServiceUnavailable, "server busy"), ErrServerUnavailable},
+		{"server error", aimock.OllamaError(http.StatusInternalServerError, "llama runner process has terminated"), ErrAPIResponse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, srv := newTestProvider(t)
+			srv.Enqueue(aimock.RouteOllamaChat, tt.resp)
+
+			_, err := p.Chat(context.Background(), []llm.Message{llm.NewUserMessage("hi")})
+			assertCode(t, err, tt.want)
+		})
+	}
+}
+
+func TestChatReportsUnreachableServer(t *testing.T) {
+	srv := aimock.NewServer(t)
+	url := srv.URL
+	srv.Close()
+
+	p := NewOllamaProvider(WithBaseURL(url))
+	_, err := p.Chat(context.Background(), []llm.Message{llm.NewUserMessage("hi")})
+	assertCode(t, err, ErrServerUnavailable)
+}
+
+// ============================================================================
+// Streaming
+// ============================================================================
+
+func TestChatStream(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteOllamaChat, aimock.OllamaStream("qwen2.5",
+		[]string{"Checking ", "the weather"},
+		aimock.OllamaToolCall("get_weather", map[string]any{"city": "Lima"}),
+	))
+
+	stream, err := p.ChatStream(context.Background(), []llm.Message{llm.NewUserMessage("Weather in Lima?")})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	var last llm.Message
+	for {
+		msg, err := stream.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		content.WriteString(msg.Content)
+		last = msg
+	}
+
+	if content.String() != "Checking the weather" {
+		t.Fatalf("content = %q", content.String())
+	}
+	want := []llm.ToolCall{{ID: "call_0", Type: "function", Function: llm.FunctionCall{Name: "get_weather", Arguments: `{"city":"Lima"}`}}}
+	if !slices.Equal(last.ToolCalls, want) {
+		t.Fatalf("tool calls = %+v, want %+v", last.ToolCalls, want)
+	}
+	if stream, _ := srv.LastRequest(aimock.RouteOllamaChat).JSON()["stream"].(bool); !stream {
+		t.Fatal("request did not ask to stream")
+	}
+}
+
+func TestChatStreamSurfacesMidStreamErrors(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteOllamaChat, aimock.Response{Lines: []any{
+		map[string]any{"model": "qwen2.5", "message": map[string]any{"role": "assistant", "content": "Hel"}},
+		map[string]any{"error": "out of memory"},
+	}})
+
+	stream, err := p.ChatStream(context.Background(), []llm.Message{llm.NewUserMessage("hi")})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	defer stream.Close()
+
+	if msg, err := stream.Next(); err != nil || msg.Content != "Hel" {
+		t.Fatalf("first chunk = %q, %v", msg.Content, err)
+	}
+	_, err = stream.Next()
+	assertCode(t, err, ErrStreamFailed)
+}
+
+// ============================================================================
+// Embeddings
+// ============================================================================
+
+func TestEmbedDocuments(t *testing.T) {
+	p, srv := newTestProvider(t, WithEmbeddingModel("mxbai-embed-large"))
+	srv.Enqueue(aimock.RouteOllamaEmbed, aimock.OllamaEmbeddings("mxbai-embed-large",
+		[]float64{0.1, 0.2},
+		[]float64{0.3, 0.4},
+	))
+
+	embeddings, err := p.EmbedDocuments(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("EmbedDocuments: %v", err)
+	}
+	if len(embeddings) != 2 || !slices.Equal(embeddings[1].Vector, []float32{0.3, 0.4}) || embeddings[0].Usage.PromptTokens != 8 {
+		t.Fatalf("embeddings = %+v", embeddings)
+	}
+
+	var body embedRequest
+	if err := srv.LastRequest(aimock.RouteOllamaEmbed).Decode(&body); err != nil {
+		t.Fatalf("decode request: %v", err)
+	}
+	if body.Model != "mxbai-embed-large" || !slices.Equal(body.Input, []string{"a", "b"}) {
+		t.Fatalf("request = %+v", body)
+	}
+}
+
+func TestEmbedQueryUsesCallModel(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteOllamaEmbed, aimock.OllamaEmbeddings("all-minilm", []float64{1, 0}))
+
+	if _, err := p.EmbedQuery(context.Background(), "query", embedding.WithModel("all-minilm")); err != nil {
+		t.Fatalf("EmbedQuery: %v", err)
+	}
+	if model := srv.LastRequest(aimock.RouteOllamaEmbed).JSON()["model"]; model != "all-minilm" {
+		t.Fatalf("model = %v", model)
+	}
+}
+
+func TestEmbedDocumentsRejectsShortResponse(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteOllamaEmbed, aimock.OllamaEmbeddings("nomic-embed-text", []float64{1, 0}))
+
+	_, err := p.EmbedDocuments(context.Background(), []string{"a", "b"})
+	assertCode(t, err, ErrNoEmbeddingReturned)
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// assertCode fails unless err carries the registered error code.
+func assertCode(t *testing.T, err error, code *errx.ErrorCode) {
+	t.Helper()
+	var e *errx.Error
+	if !errors.As(err, &e) || e.Code != code.Code {
+		t.Fatalf("error = %v, want code %s", err, code.Code)
+	}
+}
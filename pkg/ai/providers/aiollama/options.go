@@ -0,0 +1,56 @@
+package aiollama
+
+import (
+	"net/http"
+	"time"
+)
+
+// ProviderOption configures the Ollama provider
+type ProviderOption func(*OllamaProvider)
+
+// WithBaseURL sets the server address, e.g. http://gpu-box:11434
+func WithBaseURL(url string) ProviderOption {
+	return func(p *OllamaProvider) {
+		p.baseURL = url
+	}
+}
+
+// WithModel sets the chat model used when a call doesn't ask for one
+func WithModel(model string) ProviderOption {
+	return func(p *OllamaProvider) {
+		p.model = model
+	}
+}
+
+// WithEmbeddingModel sets the embedding model used when a call doesn't ask
+// for one
+func WithEmbeddingModel(model string) ProviderOption {
+	return func(p *OllamaProvider) {
+		p.embeddingModel = model
+	}
+}
+
+// WithKeepAlive sets how long the server keeps the model loaded after a
+// call, in Ollama duration syntax ("5m", "1h", "-1" for forever)
+func WithKeepAlive(keepAlive string) ProviderOption {
+	return func(p *OllamaProvider) {
+		p.keepAlive = keepAlive
+	}
+}
+
+// WithHTTPClient sets a custom HTTP client
+func WithHTTPClient(client *http.Client) ProviderOption {
+	return func(p *OllamaProvider) {
+		p.httpClient = client
+	}
+}
+
+// WithTimeout sets the request timeout. Streams are bounded by it too.
+func WithTimeout(timeout time.Duration) ProviderOption {
+	return func(p *OllamaProvider) {
+		if p.httpClient == nil {
+			p.httpClient = &http.Client{}
+		}
+		p.httpClient.Timeout = timeout
+	}
+}
@@ -2,11 +2,13 @@ package aiopenai
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/openai/openai-go/v3"
 )
 
 var (
@@ -189,6 +191,21 @@ func ParseOpenAIError(err error) *errx.Error {
 		return customErr
 	}
 
+	// Errors decoded from an API response carry the status code, which is
+	// more reliable than the message text
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		customErr := newAPIError(&OpenAIAPIError{
+			Message:    apiErr.Message,
+			Type:       apiErr.Type,
+			Param:      apiErr.Param,
+			Code:       apiErr.Code,
+			StatusCode: apiErr.StatusCode,
+		})
+		customErr.Err = err
+		return customErr
+	}
+
 	errMsg := err.Error()
 	errLower := strings.ToLower(errMsg)
 
@@ -260,6 +277,16 @@ func ParseAPIErrorResponse(statusCode int, body []byte) *errx.Error {
 		apiErr.Message = string(body)
 	}
 
+	return newAPIError(apiErr)
+}
+
+// newAPIError maps an API error to the matching error code by status.
+func newAPIError(apiErr *OpenAIAPIError) *errx.Error {
+	statusCode := apiErr.StatusCode
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(statusCode)
+	}
+
 	// Map to appropriate error code
 	var baseErr *errx.ErrorCode
 	switch statusCode {
@@ -272,7 +299,11 @@ func ParseAPIErrorResponse(statusCode int, body []byte) *errx.Error {
 			baseErr = ErrAPIUnauthorized
 		}
 	case http.StatusTooManyRequests:
-		baseErr = ErrAPIRateLimit
+		if strings.Contains(apiErr.Code, "quota") || strings.Contains(apiErr.Type, "quota") {
+			baseErr = ErrAPIQuotaExceeded
+		} else {
+			baseErr = ErrAPIRateLimit
+		}
 	case http.StatusNotFound:
 		if strings.Contains(strings.ToLower(apiErr.Message), "model") {
 			baseErr = ErrModelNotFound
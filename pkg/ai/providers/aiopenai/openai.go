@@ -2,6 +2,7 @@ package aiopenai
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -260,6 +261,11 @@ func (p *OpenAIProvider) ChatStream(ctx context.Context, messages []llm.Message,
 		params.ResponseFormat = format
 	}
 
+	// Ask for the usage chunk so metered streams record real token counts
+	params.StreamOptions = openai.ChatCompletionStreamOptionsParam{
+		IncludeUsage: openai.Bool(true),
+	}
+
 	// Create the stream
 	sseStream := p.client.Chat.Completions.NewStreaming(ctx, params)
 
@@ -539,6 +545,19 @@ func (s *openAIStream) Close() error {
 	return nil
 }
 
+// Usage reports the usage chunk OpenAI sends before the end of the stream
+func (s *openAIStream) Usage() (llm.Usage, bool) {
+	u := s.accumulator.Usage
+	if u.TotalTokens == 0 {
+		return llm.Usage{}, false
+	}
+	return llm.Usage{
+		PromptTokens:     int(u.PromptTokens),
+		CompletionTokens: int(u.CompletionTokens),
+		TotalTokens:      int(u.TotalTokens),
+	}, true
+}
+
 // ============================================================================
 // Helper Functions
 // ============================================================================
@@ -548,6 +567,20 @@ func convertToOpenAIMessage(msg llm.Message) (openai.ChatCompletionMessageParamU
 	case llm.RoleSystem:
 		return openai.SystemMessage(msg.Content), nil
 	case llm.RoleUser:
+		if len(msg.Images) > 0 {
+			parts := make([]openai.ChatCompletionContentPartUnionParam, 0, len(msg.Images)+1)
+			if msg.Content != "" {
+				parts = append(parts, openai.TextContentPart(msg.Content))
+			}
+			for _, img := range msg.Images {
+				url := img.URL
+				if url == "" {
+					url = fmt.Sprintf("data:%s;base64,%s", img.MediaType, base64.StdEncoding.EncodeToString(img.Data))
+				}
+				parts = append(parts, openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{URL: url}))
+			}
+			return openai.UserMessage(parts), nil
+		}
 		return openai.UserMessage(msg.Content), nil
 	case llm.RoleAssistant:
 		if len(msg.ToolCalls) > 0 {
@@ -710,13 +743,18 @@ func convertToResponseFormatParam(format *llm.ResponseFormat) (openai.ChatComple
 			schema = schemaMap
 		}
 
+		param := shared.ResponseFormatJSONSchemaJSONSchemaParam{
+			Name:   "schema",
+			Schema: schema,
+		}
+		if format.Name != "" {
+			param.Name = format.Name
+		}
+		if format.Strict {
+			param.Strict = openai.Bool(true)
+		}
 		return openai.ChatCompletionNewParamsResponseFormatUnion{
-			OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
-				JSONSchema: shared.ResponseFormatJSONSchemaJSONSchemaParam{
-					Name:   "schema",
-					Schema: schema,
-				},
-			},
+			OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{JSONSchema: param},
 		}, nil
 	default:
 		return openai.ChatCompletionNewParamsResponseFormatUnion{
@@ -761,6 +799,7 @@ func convertFromOpenAIResponse(completion *openai.ChatCompletion) (llm.Response,
 	return llm.Response{
 		Message: message,
 		Usage:   usage,
+		Model:   completion.Model,
 	}, nil
 }
 
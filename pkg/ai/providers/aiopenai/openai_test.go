@@ -0,0 +1,536 @@
+package aiopenai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Abraxas-365/divi/pkg/ai/embedding"
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+	"github.com/Abraxas-365/divi/pkg/ai/providers/aimock"
+	"github.com/Abraxas-365/divi/pkg/ai/speech"
+	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/openai/openai-go/v3/option"
+)
+
+// newTestProvider points a provider at a fresh mock server. SDK retries are
+// off so injected failures surface on the first attempt.
+func newTestProvider(t *testing.T) (*OpenAIProvider, *aimock.Server) {
+	t.Helper()
+	srv := aimock.NewServer(t)
+	p := NewOpenAIProvider("test-key", option.WithBaseURL(srv.BaseURL()), option.WithMaxRetries(0))
+	return p, srv
+}
+
+func TestChatSendsConversationAndOptions(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteChat, aimock.ChatCompletion("gpt-4o-mini", "It is sunny."))
+
+	weather := llm.Tool{Type: "function", Function: llm.Function{
+		Name:        "get_weather",
+		Description: "Current weather for a city",
+		Parameters: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"city": map[string]any{"type": "string"}},
+			"required":   []string{"city"},
+		},
+	}}
+	messages := []llm.Message{
+		llm.NewSystemMessage("You are terse."),
+		llm.NewUserMessage("Weather in Lima?"),
+		{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{{
+			ID: "call_1", Type: "function",
+			Function: llm.FunctionCall{Name: "get_weather", Arguments: `{"city":"Lima"}`},
+		}}},
+		llm.NewToolMessage("call_1", `{"sky":"clear"}`),
+	}
+
+	resp, err := p.Chat(context.Background(), messages,
+		llm.WithModel("gpt-4o-mini"),
+		llm.WithTemperature(0.5),
+		llm.WithMaxTokens(64),
+		llm.WithTools([]llm.Tool{weather}),
+		llm.WithToolChoice("required"),
+		llm.WithJSONMode(),
+	)
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	if resp.Message.Content != "It is sunny." || resp.Model != "gpt-4o-mini" {
+		t.Fatalf("response = %q from %q", resp.Message.Content, resp.Model)
+	}
+	if resp.Usage != (llm.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}) {
+		t.Fatalf("usage = %+v", resp.Usage)
+	}
+
+	req := srv.LastRequest(aimock.RouteChat)
+	if got := req.Header.Get("Authorization"); got != "Bearer test-key" {
+		t.Fatalf("Authorization = %q", got)
+	}
+
+	var body struct {
+		Model       string  `json:"model"`
+		Temperature float64 `json:"temperature"`
+		MaxTokens   int     `json:"max_tokens"`
+		ToolChoice  string  `json:"tool_choice"`
+		Messages    []struct {
+			Role       string `json:"role"`
+			Content    any    `json:"content"`
+			ToolCallID string `json:"tool_call_id"`
+			ToolCalls  []struct {
+				ID       string `json:"id"`
+				Type     string `json:"type"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"messages"`
+		Tools []struct {
+			Type     string `json:"type"`
+			Function struct {
+				Name       string         `json:"name"`
+				Parameters map[string]any `json:"parameters"`
+			} `json:"function"`
+		} `json:"tools"`
+		ResponseFormat struct {
+			Type string `json:"type"`
+		} `json:"response_format"`
+	}
+	if err := req.Decode(&body); err != nil {
+		t.Fatalf("decode request: %v", err)
+	}
+
+	if body.Model != "gpt-4o-mini" || body.Temperature != 0.5 || body.MaxTokens != 64 {
+		t.Fatalf("model/temperature/max_tokens = %q/%v/%d", body.Model, body.Temperature, body.MaxTokens)
+	}
+
+	var roles []string
+	for _, m := range body.Messages {
+		roles = append(roles, m.Role)
+	}
+	if want := []string{"system", "user", "assistant", "tool"}; !slices.Equal(roles, want) {
+		t.Fatalf("roles = %v, want %v", roles, want)
+	}
+	assistant := body.Messages[2]
+	if len(assistant.ToolCalls) != 1 || assistant.ToolCalls[0].ID != "call_1" ||
+		assistant.ToolCalls[0].Type != "function" ||
+		assistant.ToolCalls[0].Function.Name != "get_weather" ||
+		assistant.ToolCalls[0].Function.Arguments != `{"city":"Lima"}` {
+		t.Fatalf("assistant tool calls = %+v", assistant.ToolCalls)
+	}
+	if tool := body.Messages[3]; tool.ToolCallID != "call_1" || tool.Content != `{"sky":"clear"}` {
+		t.Fatalf("tool message = %+v", tool)
+	}
+
+	if len(body.Tools) != 1 || body.Tools[0].Type != "function" || body.Tools[0].Function.Name != "get_weather" {
+		t.Fatalf("tools = %+v", body.Tools)
+	}
+	if required, _ := body.Tools[0].Function.Parameters["required"].([]any); len(required) != 1 || required[0] != "city" {
+		t.Fatalf("tool parameters = %v", body.Tools[0].Function.Parameters)
+	}
+	if body.ToolChoice != "required" {
+		t.Fatalf("tool_choice = %q", body.ToolChoice)
+	}
+	if body.ResponseFormat.Type != "json_object" {
+		t.Fatalf("response_format = %q", body.ResponseFormat.Type)
+	}
+}
+
+func TestChatSendsLegacyFunctionsAsTools(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteChat, aimock.ChatCompletion("gpt-4o", "ok"))
+
+	_, err := p.Chat(context.Background(), []llm.Message{llm.NewUserMessage("hi")},
+		llm.WithFunctions([]llm.Function{{Name: "lookup", Parameters: map[string]any{"type": "object"}}}),
+	)
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	tools, _ := srv.LastRequest(aimock.RouteChat).JSON()["tools"].([]any)
+	if len(tools) != 1 {
+		t.Fatalf("tools = %v, want the function as one tool", tools)
+	}
+	fn := tools[0].(map[string]any)["function"].(map[string]any)
+	if fn["name"] != "lookup" {
+		t.Fatalf("tool function = %v", fn)
+	}
+}
+
+func TestChatSendsDerivedSchemaStrict(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteChat, aimock.ChatCompletion("gpt-4o", `{"score": 9}`))
+
+	type photoScore struct {
+		Score int `json:"score"`
+	}
+	schema, err := llm.SchemaFor[photoScore]()
+	if err != nil {
+		t.Fatalf("SchemaFor: %v", err)
+	}
+	if _, err := p.Chat(context.Background(), []llm.Message{llm.NewUserMessage("rate")},
+		llm.WithJSONSchemaResponseFormat(schema)); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	var body struct {
+		ResponseFormat struct {
+			Type       string `json:"type"`
+			JSONSchema struct {
+				Name   string         `json:"name"`
+				Strict bool           `json:"strict"`
+				Schema map[string]any `json:"schema"`
+			} `json:"json_schema"`
+		} `json:"response_format"`
+	}
+	if err := srv.LastRequest(aimock.RouteChat).Decode(&body); err != nil {
+		t.Fatalf("decode request: %v", err)
+	}
+	format := body.ResponseFormat
+	if format.Type != "json_schema" || format.JSONSchema.Name != "photo_score" || !format.JSONSchema.Strict {
+		t.Fatalf("response_format = %+v", format)
+	}
+	if format.JSONSchema.Schema["additionalProperties"] != false {
+		t.Fatalf("schema = %v", format.JSONSchema.Schema)
+	}
+}
+
+func TestChatSendsImagesAsContentParts(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteChat, aimock.ChatCompletion("gpt-4o", "A crack."))
+
+	msg := llm.NewUserImageMessage("What is wrong here?",
+		llm.Image{Data: []byte("\x89PNG\r\n\x1a\n"), MediaType: "image/png"},
+		llm.Image{URL: "https://example.com/wall.jpg"},
+	)
+	if _, err := p.Chat(context.Background(), []llm.Message{msg}); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	var body struct {
+		Messages []struct {
+			Content []struct {
+				Type     string `json:"type"`
+				Text     string `json:"text"`
+				ImageURL struct {
+					URL string `json:"url"`
+				} `json:"image_url"`
+			} `json:"content"`
+		} `json:"messages"`
+	}
+	if err := srv.LastRequest(aimock.RouteChat).Decode(&body); err != nil {
+		t.Fatalf("decode request: %v", err)
+	}
+
+	parts := body.Messages[0].Content
+	if len(parts) != 3 || parts[0].Type != "text" || parts[0].Text != "What is wrong here?" {
+		t.Fatalf("parts = %+v", parts)
+	}
+	if parts[1].ImageURL.URL != "data:image/png;base64,iVBORw0KGgo=" || parts[2].ImageURL.URL != "https://example.com/wall.jpg" {
+		t.Fatalf("image parts = %+v", parts[1:])
+	}
+}
+
+func TestChatParsesToolCalls(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteChat, aimock.ChatCompletion("gpt-4o", "",
+		aimock.ToolCall{ID: "call_a", Name: "get_weather", Arguments: `{"city":"Lima"}`},
+		aimock.ToolCall{ID: "call_b", Name: "get_time", Arguments: `{}`},
+	))
+
+	resp, err := p.Chat(context.Background(), []llm.Message{llm.NewUserMessage("Weather and time?")})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	calls := resp.Message.ToolCalls
+	if len(calls) != 2 {
+		t.Fatalf("tool calls = %+v, want 2", calls)
+	}
+	if calls[0].ID != "call_a" || calls[0].Type != "function" ||
+		calls[0].Function.Name != "get_weather" || calls[0].Function.Arguments != `{"city":"Lima"}` {
+		t.Fatalf("first call = %+v", calls[0])
+	}
+	if calls[1].ID != "call_b" || calls[1].Function.Name != "get_time" {
+		t.Fatalf("second call = %+v", calls[1])
+	}
+}
+
+func TestChatValidatesBeforeCalling(t *testing.T) {
+	srv := aimock.NewServer(t)
+
+	tests := []struct {
+		name     string
+		messages []llm.Message
+		want     *errx.ErrorCode
+	}{
+		{"empty messages", nil, ErrEmptyMessages},
+		{"unsupported role", []llm.Message{{Role: "narrator", Content: "x"}}, ErrUnsupportedRole},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewOpenAIProvider("test-key", option.WithBaseURL(srv.BaseURL()))
+			_, err := p.Chat(context.Background(), tt.messages)
+			assertCode(t, err, tt.want)
+		})
+	}
+
+	if n := len(srv.Requests(aimock.RouteChat)); n != 0 {
+		t.Fatalf("requests = %d, want none", n)
+	}
+}
+
+func TestChatMapsAPIErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		resp aimock.Response
+		want *errx.ErrorCode
+	}{
+		{"unauthorized", aimock.Error(http.StatusUnauthorized, "Incorrect API key provided"), ErrAPIUnauthorized},
+		{"rate limited", aimock.Error(http.StatusTooManyRequests, "Rate limit reached"), ErrAPIRateLimit},
+		{"quota exhausted", aimock.ErrorWithCode(http.StatusTooManyRequests, "You exceeded your current quota", "insufficient_quota", "insufficient_quota"), ErrAPIQuotaExceeded},
+		{"unknown model", aimock.ErrorWithCode(http.StatusNotFound, "The model `gpt-9` does not exist", "invalid_request_error", "model_not_found"), ErrModelNotFound},
+		{"context too long", aimock.ErrorWithCode(http.StatusBadRequest, "This model's maximum context length is 128000 tokens", "invalid_request_error", "context_length_exceeded"), ErrContextLengthExceeded},
+		{"bad request", aimock.Error(http.StatusBadRequest, "Invalid value for 'temperature'"), ErrInvalidRequest},
+		{"server error", aimock.Error(http.StatusInternalServerError, ""), ErrAPIResponse},
+		{"bad gateway", aimock.Error(http.StatusBadGateway, ""), ErrAPIResponse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, srv := newTestProvider(t)
+			srv.Enqueue(aimock.RouteChat, tt.resp)
+
+			_, err := p.Chat(context.Background(), []llm.Message{llm.NewUserMessage("hi")})
+			assertCode(t, err, tt.want)
+
+			var e *errx.Error
+			errors.As(err, &e)
+			if e.Details["status_code"] != tt.resp.Status {
+				t.Fatalf("status_code detail = %v, want %d", e.Details["status_code"], tt.resp.Status)
+			}
+		})
+	}
+}
+
+func TestChatRetriesTransientFailures(t *testing.T) {
+	srv := aimock.NewServer(t)
+	p := NewOpenAIProvider("test-key", option.WithBaseURL(srv.BaseURL()), option.WithMaxRetries(2))
+	srv.Fail(aimock.RouteChat, http.StatusServiceUnavailable, 1)
+	srv.Enqueue(aimock.RouteChat, aimock.ChatCompletion("gpt-4o", "recovered"))
+
+	resp, err := p.Chat(context.Background(), []llm.Message{llm.NewUserMessage("hi")})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if resp.Message.Content != "recovered" {
+		t.Fatalf("content = %q", resp.Message.Content)
+	}
+	if n := len(srv.Requests(aimock.RouteChat)); n != 2 {
+		t.Fatalf("requests = %d, want 2", n)
+	}
+}
+
+// ============================================================================
+// Streaming
+// ============================================================================
+
+func TestChatStreamYieldsContentDeltas(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteChat, aimock.ChatStream("gpt-4o",
+		aimock.ContentDelta("Hel"),
+		aimock.ContentDelta("lo, "),
+		aimock.ContentDelta("world"),
+	))
+
+	stream, err := p.ChatStream(context.Background(), []llm.Message{llm.NewUserMessage("hi")})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	for {
+		msg, err := stream.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		content.WriteString(msg.Content)
+	}
+	if content.String() != "Hello, world" {
+		t.Fatalf("content = %q", content.String())
+	}
+
+	if stream, _ := srv.LastRequest(aimock.RouteChat).JSON()["stream"].(bool); !stream {
+		t.Fatal("request did not ask to stream")
+	}
+}
+
+func TestChatStreamAccumulatesToolCallsByIndex(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteChat, aimock.ChatStream("gpt-4o",
+		aimock.ToolCallDelta(0, "call_a", "get_weather", ""),
+		aimock.ToolCallDelta(0, "", "", `{"city":`),
+		aimock.ToolCallDelta(1, "call_b", "get_time", `{}`),
+		aimock.ToolCallDelta(0, "", "", `"Lima"}`),
+	))
+
+	stream, err := p.ChatStream(context.Background(), []llm.Message{llm.NewUserMessage("hi")})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	defer stream.Close()
+
+	var last llm.Message
+	for {
+		msg, err := stream.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if len(msg.ToolCalls) > 0 {
+			last = msg
+		}
+	}
+
+	want := []llm.ToolCall{
+		{ID: "call_a", Type: "function", Function: llm.FunctionCall{Name: "get_weather", Arguments: `{"city":"Lima"}`}},
+		{ID: "call_b", Type: "function", Function: llm.FunctionCall{Name: "get_time", Arguments: `{}`}},
+	}
+	if !slices.Equal(last.ToolCalls, want) {
+		t.Fatalf("tool calls = %+v, want %+v", last.ToolCalls, want)
+	}
+}
+
+func TestChatStreamSurfacesAPIErrors(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteChat, aimock.Error(http.StatusTooManyRequests, "Rate limit reached"))
+
+	stream, err := p.ChatStream(context.Background(), []llm.Message{llm.NewUserMessage("hi")})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	defer stream.Close()
+
+	_, err = stream.Next()
+	assertCode(t, err, ErrAPIRateLimit)
+
+	// The error sticks
+	_, err = stream.Next()
+	assertCode(t, err, ErrAPIRateLimit)
+}
+
+// ============================================================================
+// Embeddings and Audio
+// ============================================================================
+
+func TestEmbedDocuments(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteEmbeddings, aimock.Embeddings("text-embedding-3-small",
+		[]float64{0.1, 0.2, 0.3},
+		[]float64{0.4, 0.5, 0.6},
+	))
+
+	embeddings, err := p.EmbedDocuments(context.Background(), []string{"a", "b"}, embedding.WithDimensions(3))
+	if err != nil {
+		t.Fatalf("EmbedDocuments: %v", err)
+	}
+	if len(embeddings) != 2 || !slices.Equal(embeddings[1].Vector, []float32{0.4, 0.5, 0.6}) {
+		t.Fatalf("embeddings = %+v", embeddings)
+	}
+	if embeddings[0].Usage.TotalTokens != 8 {
+		t.Fatalf("usage = %+v", embeddings[0].Usage)
+	}
+
+	var body struct {
+		Model      string   `json:"model"`
+		Input      []string `json:"input"`
+		Dimensions int      `json:"dimensions"`
+	}
+	if err := srv.LastRequest(aimock.RouteEmbeddings).Decode(&body); err != nil {
+		t.Fatalf("decode request: %v", err)
+	}
+	if body.Model != "text-embedding-3-small" || !slices.Equal(body.Input, []string{"a", "b"}) || body.Dimensions != 3 {
+		t.Fatalf("request = %+v", body)
+	}
+}
+
+func TestEmbedDocumentsRejectsEmptyResponse(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteEmbeddings, aimock.Embeddings("text-embedding-3-small"))
+
+	_, err := p.EmbedDocuments(context.Background(), []string{"a"})
+	assertCode(t, err, ErrNoEmbeddingReturned)
+}
+
+func TestSynthesize(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteSpeech, aimock.Speech([]byte("ID3-mp3-bytes"), "audio/mpeg"))
+
+	audio, err := p.Synthesize(context.Background(), "Hola", speech.WithVoice("echo"))
+	if err != nil {
+		t.Fatalf("Synthesize: %v", err)
+	}
+	defer audio.Content.Close()
+
+	data, err := io.ReadAll(audio.Content)
+	if err != nil {
+		t.Fatalf("read audio: %v", err)
+	}
+	if string(data) != "ID3-mp3-bytes" || audio.Format != speech.AudioFormatMP3 {
+		t.Fatalf("audio = %q as %s", data, audio.Format)
+	}
+
+	body := srv.LastRequest(aimock.RouteSpeech).JSON()
+	if body["input"] != "Hola" || body["voice"] != "echo" || body["response_format"] != "mp3" {
+		t.Fatalf("request = %v", body)
+	}
+}
+
+func TestTranscribe(t *testing.T) {
+	p, srv := newTestProvider(t)
+	srv.Enqueue(aimock.RouteTranscriptions, aimock.Transcription("buenos días"))
+
+	transcript, err := p.Transcribe(context.Background(), strings.NewReader("RIFF-audio"), speech.WithLanguage("es"))
+	if err != nil {
+		t.Fatalf("Transcribe: %v", err)
+	}
+	if transcript.Text != "buenos días" {
+		t.Fatalf("text = %q", transcript.Text)
+	}
+
+	req := srv.LastRequest(aimock.RouteTranscriptions)
+	if !strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/form-data") {
+		t.Fatalf("Content-Type = %q", req.Header.Get("Content-Type"))
+	}
+	for _, part := range []string{"whisper-1", "RIFF-audio", `name="language"`} {
+		if !strings.Contains(string(req.Body), part) {
+			t.Fatalf("multipart body lacks %q", part)
+		}
+	}
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// assertCode fails unless err carries the registered error code.
+func assertCode(t *testing.T, err error, code *errx.ErrorCode) {
+	t.Helper()
+	var e *errx.Error
+	if !errors.As(err, &e) || e.Code != code.Code {
+		t.Fatalf("error = %v, want code %s", err, code.Code)
+	}
+}
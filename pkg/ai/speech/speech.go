@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/ai/aiusage"
 
 	"github.com/Abraxas-365/divi/pkg/fsx"
 	"github.com/Abraxas-365/divi/pkg/fsx/fsxlocal"
@@ -102,6 +105,7 @@ const (
 // TTSClient represents a configured text-to-speech client
 type TTSClient struct {
 	speaker Speaker
+	meter   aiusage.Meter
 }
 
 // NewTTSClient creates a new text-to-speech client
@@ -109,15 +113,48 @@ func NewTTSClient(speaker Speaker) *TTSClient {
 	return &TTSClient{speaker: speaker}
 }
 
+// WithMeter reports the usage of every call to meter and lets it block calls
+// before they are made.
+func (c *TTSClient) WithMeter(meter aiusage.Meter) *TTSClient {
+	c.meter = meter
+	return c
+}
+
 // Synthesize converts text to speech audio
 func (c *TTSClient) Synthesize(ctx context.Context, text string, opts ...SynthesisOption) (Audio, error) {
-	return c.speaker.Synthesize(ctx, text, opts...)
+	if c.meter == nil {
+		return c.speaker.Synthesize(ctx, text, opts...)
+	}
+
+	var options SynthesisOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if _, err := c.meter.Admit(ctx, aiusage.KindSpeech, options.Model); err != nil {
+		return Audio{}, err
+	}
+
+	audio, err := c.speaker.Synthesize(ctx, text, opts...)
+	if err != nil {
+		return audio, err
+	}
+
+	rec := aiusage.Record{
+		Kind:       aiusage.KindSpeech,
+		Model:      options.Model,
+		Characters: audio.Usage.InputCharacters,
+		CreatedAt:  time.Now(),
+	}
+	aiusage.TagsFrom(ctx).Apply(&rec)
+	c.meter.Record(ctx, rec)
+	return audio, nil
 }
 
 // STTClient represents a configured speech-to-text client
 type STTClient struct {
 	transcriber Transcriber
 	fs          fsx.FileReader
+	meter       aiusage.Meter
 }
 
 // NewSTTClient creates a new speech-to-text client with default local filesystem
@@ -137,9 +174,41 @@ func (c *STTClient) WithFileSystem(fs fsx.FileReader) *STTClient {
 	return c // Return self for method chaining
 }
 
+// WithMeter reports the usage of every call to meter and lets it block calls
+// before they are made.
+func (c *STTClient) WithMeter(meter aiusage.Meter) *STTClient {
+	c.meter = meter
+	return c
+}
+
 // Transcribe converts speech audio to text
 func (c *STTClient) Transcribe(ctx context.Context, audio io.Reader, opts ...TranscriptionOption) (Transcript, error) {
-	return c.transcriber.Transcribe(ctx, audio, opts...)
+	if c.meter == nil {
+		return c.transcriber.Transcribe(ctx, audio, opts...)
+	}
+
+	var options TranscriptionOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if _, err := c.meter.Admit(ctx, aiusage.KindSpeech, options.Model); err != nil {
+		return Transcript{}, err
+	}
+
+	transcript, err := c.transcriber.Transcribe(ctx, audio, opts...)
+	if err != nil {
+		return transcript, err
+	}
+
+	rec := aiusage.Record{
+		Kind:         aiusage.KindSpeech,
+		Model:        options.Model,
+		AudioSeconds: float64(transcript.Usage.AudioDuration),
+		CreatedAt:    time.Now(),
+	}
+	aiusage.TagsFrom(ctx).Apply(&rec)
+	c.meter.Record(ctx, rec)
+	return transcript, nil
 }
 
 // TranscribeFile converts speech audio from a file path to text using the configured file system
@@ -151,5 +220,5 @@ func (c *STTClient) TranscribeFile(ctx context.Context, filePath string, opts ..
 	}
 	defer stream.Close()
 
-	return c.transcriber.Transcribe(ctx, stream, opts...)
+	return c.Transcribe(ctx, stream, opts...)
 }
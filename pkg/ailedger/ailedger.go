@@ -0,0 +1,116 @@
+package ailedger
+
+import (
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/ai/aiusage"
+)
+
+// ============================================================================
+// Usage Records
+// ============================================================================
+
+// UsageRecord is one metered AI call as stored in the ledger.
+type UsageRecord struct {
+	ID               string       `json:"id" db:"id"`
+	TenantID         *string      `json:"tenant_id,omitempty" db:"tenant_id"`
+	Feature          string       `json:"feature" db:"feature"`
+	SubjectID        *string      `json:"subject_id,omitempty" db:"subject_id"`
+	Kind             aiusage.Kind `json:"kind" db:"kind"`
+	Model            string       `json:"model" db:"model"`
+	PromptTokens     int          `json:"prompt_tokens" db:"prompt_tokens"`
+	CompletionTokens int          `json:"completion_tokens" db:"completion_tokens"`
+	TotalTokens      int          `json:"total_tokens" db:"total_tokens"`
+	Characters       int          `json:"characters" db:"characters"`
+	AudioSeconds     float64      `json:"audio_seconds" db:"audio_seconds"`
+	Pages            int          `json:"pages" db:"pages"`
+	CostUSD          float64      `json:"cost_usd" db:"cost_usd"`
+	CreatedAt        time.Time    `json:"created_at" db:"created_at"`
+}
+
+// ============================================================================
+// Reports
+// ============================================================================
+
+// ReportDimension is a column usage can be grouped by.
+type ReportDimension string
+
+const (
+	DimensionDay     ReportDimension = "day"
+	DimensionTenant  ReportDimension = "tenant"
+	DimensionFeature ReportDimension = "feature"
+	DimensionModel   ReportDimension = "model"
+	DimensionKind    ReportDimension = "kind"
+	DimensionSubject ReportDimension = "subject"
+)
+
+func (d ReportDimension) IsValid() bool {
+	switch d {
+	case DimensionDay, DimensionTenant, DimensionFeature, DimensionModel, DimensionKind, DimensionSubject:
+		return true
+	}
+	return false
+}
+
+type ReportQuery struct {
+	From     time.Time
+	To       time.Time // exclusive
+	TenantID *string   // nil = all tenants
+	Feature  string
+	GroupBy  []ReportDimension
+}
+
+// ReportRow is one group of a usage report. Only the dimensions grouped by
+// are set.
+type ReportRow struct {
+	Day              *time.Time `json:"day,omitempty" db:"day"`
+	TenantID         *string    `json:"tenant_id,omitempty" db:"tenant_id"`
+	Feature          *string    `json:"feature,omitempty" db:"feature"`
+	Model            *string    `json:"model,omitempty" db:"model"`
+	Kind             *string    `json:"kind,omitempty" db:"kind"`
+	SubjectID        *string    `json:"subject_id,omitempty" db:"subject_id"`
+	Calls            int        `json:"calls" db:"calls"`
+	PromptTokens     int        `json:"prompt_tokens" db:"prompt_tokens"`
+	CompletionTokens int        `json:"completion_tokens" db:"completion_tokens"`
+	TotalTokens      int        `json:"total_tokens" db:"total_tokens"`
+	CostUSD          float64    `json:"cost_usd" db:"cost_usd"`
+}
+
+type Report struct {
+	From         time.Time         `json:"from"`
+	To           time.Time         `json:"to"`
+	GroupBy      []ReportDimension `json:"group_by"`
+	Rows         []ReportRow       `json:"rows"`
+	TotalCalls   int               `json:"total_calls"`
+	TotalCostUSD float64           `json:"total_cost_usd"`
+}
+
+// ============================================================================
+// Budgets
+// ============================================================================
+
+type BudgetAction string
+
+const (
+	// BudgetBlock refuses AI calls once the limit is reached.
+	BudgetBlock BudgetAction = "block"
+	// BudgetDegrade moves LLM calls to DegradeModel once the limit is
+	// reached; other calls keep working.
+	BudgetDegrade BudgetAction = "degrade"
+)
+
+type Budget struct {
+	TenantID        string       `json:"tenant_id" db:"tenant_id"`
+	MonthlyLimitUSD float64      `json:"monthly_limit_usd" db:"monthly_limit_usd"`
+	Action          BudgetAction `json:"action" db:"action"`
+	DegradeModel    *string      `json:"degrade_model,omitempty" db:"degrade_model"`
+	CreatedAt       time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// BudgetStatus is a tenant's budget together with this month's spend.
+type BudgetStatus struct {
+	Budget        *Budget `json:"budget,omitempty"`
+	MonthSpendUSD float64 `json:"month_spend_usd"`
+	Exceeded      bool    `json:"exceeded"`
+}
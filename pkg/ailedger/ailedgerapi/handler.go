@@ -0,0 +1,154 @@
+package ailedgerapi
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/ailedger"
+	"github.com/Abraxas-365/divi/pkg/ailedger/ailedgersrv"
+	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/Abraxas-365/divi/pkg/iam"
+	"github.com/Abraxas-365/divi/pkg/iam/auth"
+	"github.com/Abraxas-365/divi/pkg/iam/scopes"
+	"github.com/Abraxas-365/divi/pkg/kernel"
+	"github.com/gofiber/fiber/v2"
+)
+
+// reportDateLayout is the layout of the from/to report parameters.
+const reportDateLayout = "2006-01-02"
+
+type Handlers struct {
+	ledger *ailedgersrv.LedgerService
+}
+
+func NewHandlers(ledger *ailedgersrv.LedgerService) *Handlers {
+	return &Handlers{ledger: ledger}
+}
+
+func (h *Handlers) RegisterRoutes(router fiber.Router, authMiddleware *auth.UnifiedAuthMiddleware) {
+	usage := router.Group("/ai-usage", authMiddleware.Authenticate())
+
+	usage.Get("/report", authMiddleware.RequireAdminOrScope(scopes.ScopeReportsView), h.GetReport)
+
+	usage.Get("/budget", authMiddleware.RequireAdminOrScope(scopes.ScopeReportsView), h.GetBudget)
+	usage.Put("/budget", authMiddleware.RequireAdminOrScope(scopes.ScopeTenantsConfig), h.SetBudget)
+	usage.Delete("/budget", authMiddleware.RequireAdminOrScope(scopes.ScopeTenantsConfig), h.DeleteBudget)
+}
+
+// GetReport aggregates usage between ?from and ?to (YYYY-MM-DD, to inclusive;
+// default the last 30 days), grouped by ?group_by=day,tenant,feature,model,
+// kind,subject. Admins may report on any ?tenant_id or across all tenants;
+// everyone else only sees their own tenant.
+func (h *Handlers) GetReport(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	to := time.Now().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	from := to.AddDate(0, 0, -30)
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(reportDateLayout, v)
+		if err != nil {
+			return errx.Validation("from must be a date (YYYY-MM-DD)")
+		}
+		from = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(reportDateLayout, v)
+		if err != nil {
+			return errx.Validation("to must be a date (YYYY-MM-DD)")
+		}
+		to = t.AddDate(0, 0, 1)
+	}
+
+	q := ailedger.ReportQuery{
+		From:    from,
+		To:      to,
+		Feature: c.Query("feature"),
+	}
+	groupBy := c.Query("group_by", "day")
+	for _, dim := range strings.Split(groupBy, ",") {
+		if dim = strings.TrimSpace(dim); dim != "" {
+			q.GroupBy = append(q.GroupBy, ailedger.ReportDimension(dim))
+		}
+	}
+
+	if isAdmin(authContext) {
+		if tenant := c.Query("tenant_id"); tenant != "" {
+			q.TenantID = &tenant
+		}
+	} else {
+		tenant := authContext.TenantID.String()
+		q.TenantID = &tenant
+	}
+
+	report, err := h.ledger.Report(c.Context(), q)
+	if err != nil {
+		return err
+	}
+	return c.JSON(report)
+}
+
+func (h *Handlers) GetBudget(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	status, err := h.ledger.BudgetStatus(c.Context(), authContext.TenantID.String())
+	if err != nil {
+		return err
+	}
+	return c.JSON(status)
+}
+
+type setBudgetRequest struct {
+	MonthlyLimitUSD float64               `json:"monthly_limit_usd"`
+	Action          ailedger.BudgetAction `json:"action"`
+	DegradeModel    *string               `json:"degrade_model"`
+}
+
+func (h *Handlers) SetBudget(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	var req setBudgetRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errx.Validation("Invalid request body")
+	}
+
+	budget := &ailedger.Budget{
+		TenantID:        authContext.TenantID.String(),
+		MonthlyLimitUSD: req.MonthlyLimitUSD,
+		Action:          req.Action,
+		DegradeModel:    req.DegradeModel,
+	}
+	if err := h.ledger.SetBudget(c.Context(), budget); err != nil {
+		return err
+	}
+
+	status, err := h.ledger.BudgetStatus(c.Context(), budget.TenantID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(status)
+}
+
+func (h *Handlers) DeleteBudget(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	if err := h.ledger.DeleteBudget(c.Context(), authContext.TenantID.String()); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func isAdmin(authContext *kernel.AuthContext) bool {
+	return authContext.HasAnyScope(scopes.ScopeAll, scopes.ScopeAdminAll)
+}
@@ -0,0 +1,65 @@
+package ailedgercontainer
+
+import (
+	"os"
+
+	"github.com/Abraxas-365/divi/pkg/ai/aiusage"
+	"github.com/Abraxas-365/divi/pkg/ailedger/ailedgerapi"
+	"github.com/Abraxas-365/divi/pkg/ailedger/ailedgerinfra"
+	"github.com/Abraxas-365/divi/pkg/ailedger/ailedgersrv"
+	"github.com/Abraxas-365/divi/pkg/logx"
+	"github.com/jmoiron/sqlx"
+)
+
+type Deps struct {
+	DB *sqlx.DB
+}
+
+type Container struct {
+	// Ledger is the aiusage.Meter other modules hand to their AI clients
+	Ledger   *ailedgersrv.LedgerService
+	Handlers *ailedgerapi.Handlers
+}
+
+// defaultModels are the models the configured providers use when a call
+// doesn't name one.
+var defaultModels = map[aiusage.Kind]string{
+	aiusage.KindLLM:       "gpt-4o",
+	aiusage.KindEmbedding: "text-embedding-3-small",
+	aiusage.KindSpeech:    "whisper-1",
+	aiusage.KindOCR:       "mistral-ocr-latest",
+}
+
+func New(deps Deps) *Container {
+	logx.Info("Initializing AI ledger container...")
+
+	c := &Container{}
+
+	// ── Repositories ─────────────────────────────────────────────────────
+	usageRepo := ailedgerinfra.NewPostgresUsageRepository(deps.DB)
+	budgetRepo := ailedgerinfra.NewPostgresBudgetRepository(deps.DB)
+
+	// ── Services ─────────────────────────────────────────────────────────
+	c.Ledger = ailedgersrv.NewLedgerService(usageRepo, budgetRepo, priceTable(), defaultModels)
+
+	// ── Handlers ─────────────────────────────────────────────────────────
+	c.Handlers = ailedgerapi.NewHandlers(c.Ledger)
+
+	logx.Info("AI ledger container initialized")
+	return c
+}
+
+// priceTable lays AI_PRICE_TABLE, a JSON object of model → price, over the
+// built-in list prices.
+func priceTable() aiusage.PriceTable {
+	raw := os.Getenv("AI_PRICE_TABLE")
+	if raw == "" {
+		return aiusage.DefaultPrices
+	}
+	table, err := aiusage.ParsePriceTable([]byte(raw))
+	if err != nil {
+		logx.Warnf("Ignoring invalid AI_PRICE_TABLE, using default prices: %v", err)
+		return aiusage.DefaultPrices
+	}
+	return table
+}
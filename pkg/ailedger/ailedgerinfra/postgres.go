@@ -0,0 +1,156 @@
+package ailedgerinfra
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/ailedger"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// ============================================================================
+// Usage Repository
+// ============================================================================
+
+type PostgresUsageRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresUsageRepository(db *sqlx.DB) *PostgresUsageRepository {
+	return &PostgresUsageRepository{db: db}
+}
+
+func (r *PostgresUsageRepository) Create(ctx context.Context, u *ailedger.UsageRecord) error {
+	if u.ID == "" {
+		u.ID = uuid.New().String()
+	}
+	query := `
+		INSERT INTO ai_usage_records (
+			id, tenant_id, feature, subject_id, kind, model,
+			prompt_tokens, completion_tokens, total_tokens, characters, audio_seconds, pages,
+			cost_usd, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
+	_, err := r.db.ExecContext(ctx, query,
+		u.ID, u.TenantID, u.Feature, u.SubjectID, u.Kind, u.Model,
+		u.PromptTokens, u.CompletionTokens, u.TotalTokens, u.Characters, u.AudioSeconds, u.Pages,
+		u.CostUSD, u.CreatedAt,
+	)
+	return err
+}
+
+// reportColumns maps each dimension to its SELECT expression.
+var reportColumns = map[ailedger.ReportDimension]string{
+	ailedger.DimensionDay:     "date_trunc('day', created_at) AS day",
+	ailedger.DimensionTenant:  "tenant_id",
+	ailedger.DimensionFeature: "feature",
+	ailedger.DimensionModel:   "model",
+	ailedger.DimensionKind:    "kind",
+	ailedger.DimensionSubject: "subject_id",
+}
+
+func (r *PostgresUsageRepository) Report(ctx context.Context, q ailedger.ReportQuery) ([]ailedger.ReportRow, error) {
+	var selects, groups []string
+	for i, dim := range q.GroupBy {
+		col, ok := reportColumns[dim]
+		if !ok {
+			return nil, fmt.Errorf("unknown report dimension %q", dim)
+		}
+		selects = append(selects, col)
+		groups = append(groups, fmt.Sprintf("%d", i+1))
+	}
+
+	where := []string{"created_at >= $1", "created_at < $2"}
+	args := []any{q.From, q.To}
+	if q.TenantID != nil {
+		args = append(args, *q.TenantID)
+		where = append(where, fmt.Sprintf("tenant_id = $%d", len(args)))
+	}
+	if q.Feature != "" {
+		args = append(args, q.Feature)
+		where = append(where, fmt.Sprintf("feature = $%d", len(args)))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+			COUNT(*) AS calls,
+			COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens,
+			COALESCE(SUM(completion_tokens), 0) AS completion_tokens,
+			COALESCE(SUM(total_tokens), 0) AS total_tokens,
+			COALESCE(SUM(cost_usd), 0) AS cost_usd
+		FROM ai_usage_records
+		WHERE %s`, selectPrefix(selects), strings.Join(where, " AND "))
+	if len(groups) > 0 {
+		query += fmt.Sprintf(`
+		GROUP BY %s
+		ORDER BY %s`, strings.Join(groups, ", "), strings.Join(groups, ", "))
+	}
+
+	var rows []ailedger.ReportRow
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func selectPrefix(cols []string) string {
+	if len(cols) == 0 {
+		return ""
+	}
+	return strings.Join(cols, ", ") + ","
+}
+
+func (r *PostgresUsageRepository) SpendSince(ctx context.Context, tenantID string, since time.Time) (float64, error) {
+	var spend float64
+	query := `SELECT COALESCE(SUM(cost_usd), 0) FROM ai_usage_records WHERE tenant_id = $1 AND created_at >= $2`
+	if err := r.db.GetContext(ctx, &spend, query, tenantID, since); err != nil {
+		return 0, err
+	}
+	return spend, nil
+}
+
+// ============================================================================
+// Budget Repository
+// ============================================================================
+
+type PostgresBudgetRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresBudgetRepository(db *sqlx.DB) *PostgresBudgetRepository {
+	return &PostgresBudgetRepository{db: db}
+}
+
+func (r *PostgresBudgetRepository) GetByTenantID(ctx context.Context, tenantID string) (*ailedger.Budget, error) {
+	var b ailedger.Budget
+	err := r.db.GetContext(ctx, &b, `SELECT * FROM ai_budgets WHERE tenant_id = $1`, tenantID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *PostgresBudgetRepository) Upsert(ctx context.Context, b *ailedger.Budget) error {
+	query := `
+		INSERT INTO ai_budgets (tenant_id, monthly_limit_usd, action, degrade_model)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			monthly_limit_usd = EXCLUDED.monthly_limit_usd,
+			action = EXCLUDED.action,
+			degrade_model = EXCLUDED.degrade_model
+		RETURNING created_at, updated_at`
+	return r.db.QueryRowContext(ctx, query,
+		b.TenantID, b.MonthlyLimitUSD, b.Action, b.DegradeModel,
+	).Scan(&b.CreatedAt, &b.UpdatedAt)
+}
+
+func (r *PostgresBudgetRepository) Delete(ctx context.Context, tenantID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM ai_budgets WHERE tenant_id = $1`, tenantID)
+	return err
+}
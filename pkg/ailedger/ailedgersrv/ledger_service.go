@@ -0,0 +1,272 @@
+package ailedgersrv
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/ai/aiusage"
+	"github.com/Abraxas-365/divi/pkg/ailedger"
+	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/Abraxas-365/divi/pkg/logx"
+)
+
+// budgetCacheTTL bounds how stale a tenant's cached month spend may get
+// before Admit reads it from the ledger again.
+const budgetCacheTTL = 30 * time.Second
+
+// LedgerService prices and stores AI usage and enforces monthly budgets. It
+// is the aiusage.Meter handed to the AI clients.
+type LedgerService struct {
+	usageRepo     ailedger.UsageRepository
+	budgetRepo    ailedger.BudgetRepository
+	prices        aiusage.PriceTable
+	defaultModels map[aiusage.Kind]string
+
+	mu    sync.Mutex
+	cache map[string]*budgetEntry
+}
+
+type budgetEntry struct {
+	budget     *ailedger.Budget
+	spend      float64
+	monthStart time.Time
+	loadedAt   time.Time
+}
+
+// NewLedgerService creates the ledger. defaultModels names the model a
+// provider uses when a call doesn't ask for one, so it can still be priced.
+func NewLedgerService(
+	usageRepo ailedger.UsageRepository,
+	budgetRepo ailedger.BudgetRepository,
+	prices aiusage.PriceTable,
+	defaultModels map[aiusage.Kind]string,
+) *LedgerService {
+	return &LedgerService{
+		usageRepo:     usageRepo,
+		budgetRepo:    budgetRepo,
+		prices:        prices,
+		defaultModels: defaultModels,
+		cache:         make(map[string]*budgetEntry),
+	}
+}
+
+var _ aiusage.Meter = (*LedgerService)(nil)
+
+// ============================================================================
+// Metering
+// ============================================================================
+
+// Admit checks the tenant's monthly budget. Calls without a tenant and
+// tenants without a budget are always admitted; so are calls when the budget
+// can't be read, rather than failing product features on a ledger outage.
+func (s *LedgerService) Admit(ctx context.Context, kind aiusage.Kind, model string) (aiusage.Admission, error) {
+	tenantID := aiusage.TagsFrom(ctx).TenantID
+	if tenantID == "" {
+		return aiusage.Admission{}, nil
+	}
+
+	entry, err := s.budgetEntry(ctx, tenantID)
+	if err != nil {
+		logx.Warnf("Failed to check AI budget for tenant %s, admitting call: %v", tenantID, err)
+		return aiusage.Admission{}, nil
+	}
+	if entry.budget == nil || entry.spend < entry.budget.MonthlyLimitUSD {
+		return aiusage.Admission{}, nil
+	}
+
+	b := entry.budget
+	if b.Action == ailedger.BudgetDegrade {
+		if kind == aiusage.KindLLM && b.DegradeModel != nil {
+			return aiusage.Admission{Model: *b.DegradeModel}, nil
+		}
+		return aiusage.Admission{}, nil
+	}
+
+	return aiusage.Admission{}, ailedger.ErrBudgetExceeded().
+		WithDetail("tenant_id", tenantID).
+		WithDetail("monthly_limit_usd", b.MonthlyLimitUSD).
+		WithDetail("month_spend_usd", entry.spend)
+}
+
+// Record prices rec and stores it. It outlives ctx being cancelled, since the
+// call it records has already been paid for.
+func (s *LedgerService) Record(ctx context.Context, rec aiusage.Record) {
+	if rec.Model == "" {
+		rec.Model = s.defaultModels[rec.Kind]
+	}
+	if rec.CreatedAt.IsZero() {
+		rec.CreatedAt = time.Now()
+	}
+	rec.CostUSD = s.prices.Cost(rec)
+	if _, ok := s.prices.Lookup(rec.Model); !ok && rec.Model != "" {
+		logx.Warnf("No AI price configured for model %s, recording it at no cost", rec.Model)
+	}
+
+	u := &ailedger.UsageRecord{
+		Feature:          rec.Feature,
+		Kind:             rec.Kind,
+		Model:            rec.Model,
+		PromptTokens:     rec.PromptTokens,
+		CompletionTokens: rec.CompletionTokens,
+		TotalTokens:      rec.TotalTokens,
+		Characters:       rec.Characters,
+		AudioSeconds:     rec.AudioSeconds,
+		Pages:            rec.Pages,
+		CostUSD:          rec.CostUSD,
+		CreatedAt:        rec.CreatedAt,
+	}
+	if rec.TenantID != "" {
+		u.TenantID = &rec.TenantID
+	}
+	if rec.SubjectID != "" {
+		u.SubjectID = &rec.SubjectID
+	}
+
+	if err := s.usageRepo.Create(context.WithoutCancel(ctx), u); err != nil {
+		logx.Errorf("Failed to record AI usage (%s %s, $%.6f): %v", rec.Kind, rec.Model, rec.CostUSD, err)
+		return
+	}
+
+	if rec.TenantID != "" {
+		s.mu.Lock()
+		if entry, ok := s.cache[rec.TenantID]; ok {
+			entry.spend += rec.CostUSD
+		}
+		s.mu.Unlock()
+	}
+}
+
+// budgetEntry returns a snapshot of the tenant's budget and month spend, from
+// the cache when fresh.
+func (s *LedgerService) budgetEntry(ctx context.Context, tenantID string) (budgetEntry, error) {
+	now := time.Now()
+	monthStart := startOfMonth(now)
+
+	s.mu.Lock()
+	if entry, ok := s.cache[tenantID]; ok && now.Sub(entry.loadedAt) < budgetCacheTTL && entry.monthStart.Equal(monthStart) {
+		snapshot := *entry
+		s.mu.Unlock()
+		return snapshot, nil
+	}
+	s.mu.Unlock()
+
+	budget, err := s.budgetRepo.GetByTenantID(ctx, tenantID)
+	if err != nil {
+		return budgetEntry{}, err
+	}
+	var spend float64
+	if budget != nil {
+		if spend, err = s.usageRepo.SpendSince(ctx, tenantID, monthStart); err != nil {
+			return budgetEntry{}, err
+		}
+	}
+
+	entry := budgetEntry{budget: budget, spend: spend, monthStart: monthStart, loadedAt: now}
+	s.mu.Lock()
+	s.cache[tenantID] = &entry
+	s.mu.Unlock()
+	return entry, nil
+}
+
+func (s *LedgerService) invalidate(tenantID string) {
+	s.mu.Lock()
+	delete(s.cache, tenantID)
+	s.mu.Unlock()
+}
+
+// ============================================================================
+// Reports
+// ============================================================================
+
+func (s *LedgerService) Report(ctx context.Context, q ailedger.ReportQuery) (*ailedger.Report, error) {
+	if !q.From.Before(q.To) {
+		return nil, ailedger.ErrInvalidReport().WithDetail("reason", "from must be before to")
+	}
+	seen := make(map[ailedger.ReportDimension]bool, len(q.GroupBy))
+	for _, dim := range q.GroupBy {
+		if !dim.IsValid() {
+			return nil, ailedger.ErrInvalidReport().WithDetail("group_by", string(dim))
+		}
+		if seen[dim] {
+			return nil, ailedger.ErrInvalidReport().WithDetail("reason", "duplicate group_by "+string(dim))
+		}
+		seen[dim] = true
+	}
+
+	rows, err := s.usageRepo.Report(ctx, q)
+	if err != nil {
+		return nil, errx.Wrap(err, "Failed to build AI usage report", errx.TypeInternal)
+	}
+
+	report := &ailedger.Report{
+		From:    q.From,
+		To:      q.To,
+		GroupBy: q.GroupBy,
+		Rows:    rows,
+	}
+	if report.Rows == nil {
+		report.Rows = []ailedger.ReportRow{}
+	}
+	for _, row := range rows {
+		report.TotalCalls += row.Calls
+		report.TotalCostUSD += row.CostUSD
+	}
+	return report, nil
+}
+
+// ============================================================================
+// Budgets
+// ============================================================================
+
+func (s *LedgerService) BudgetStatus(ctx context.Context, tenantID string) (*ailedger.BudgetStatus, error) {
+	budget, err := s.budgetRepo.GetByTenantID(ctx, tenantID)
+	if err != nil {
+		return nil, errx.Wrap(err, "Failed to get AI budget", errx.TypeInternal)
+	}
+	spend, err := s.usageRepo.SpendSince(ctx, tenantID, startOfMonth(time.Now()))
+	if err != nil {
+		return nil, errx.Wrap(err, "Failed to get AI spend", errx.TypeInternal)
+	}
+	return &ailedger.BudgetStatus{
+		Budget:        budget,
+		MonthSpendUSD: spend,
+		Exceeded:      budget != nil && spend >= budget.MonthlyLimitUSD,
+	}, nil
+}
+
+func (s *LedgerService) SetBudget(ctx context.Context, b *ailedger.Budget) error {
+	if b.MonthlyLimitUSD < 0 {
+		return ailedger.ErrInvalidBudget().WithDetail("monthly_limit_usd", b.MonthlyLimitUSD)
+	}
+	if b.Action == "" {
+		b.Action = ailedger.BudgetBlock
+	}
+	switch b.Action {
+	case ailedger.BudgetBlock:
+	case ailedger.BudgetDegrade:
+		if b.DegradeModel == nil || *b.DegradeModel == "" {
+			return ailedger.ErrInvalidBudget().WithDetail("reason", "degrade budgets need a degrade_model")
+		}
+	default:
+		return ailedger.ErrInvalidBudget().WithDetail("action", string(b.Action))
+	}
+
+	if err := s.budgetRepo.Upsert(ctx, b); err != nil {
+		return errx.Wrap(err, "Failed to save AI budget", errx.TypeInternal)
+	}
+	s.invalidate(b.TenantID)
+	return nil
+}
+
+func (s *LedgerService) DeleteBudget(ctx context.Context, tenantID string) error {
+	if err := s.budgetRepo.Delete(ctx, tenantID); err != nil {
+		return errx.Wrap(err, "Failed to delete AI budget", errx.TypeInternal)
+	}
+	s.invalidate(tenantID)
+	return nil
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
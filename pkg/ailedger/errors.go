@@ -0,0 +1,27 @@
+package ailedger
+
+import (
+	"net/http"
+
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+var ErrRegistry = errx.NewRegistry("AILEDGER")
+
+var (
+	CodeBudgetExceeded = ErrRegistry.Register("BUDGET_EXCEEDED", errx.TypeBusiness, http.StatusPaymentRequired, "Monthly AI budget exceeded")
+	CodeInvalidBudget  = ErrRegistry.Register("INVALID_BUDGET", errx.TypeValidation, http.StatusBadRequest, "Invalid AI budget")
+	CodeInvalidReport  = ErrRegistry.Register("INVALID_REPORT", errx.TypeValidation, http.StatusBadRequest, "Invalid usage report query")
+)
+
+func ErrBudgetExceeded() *errx.Error {
+	return ErrRegistry.New(CodeBudgetExceeded)
+}
+
+func ErrInvalidBudget() *errx.Error {
+	return ErrRegistry.New(CodeInvalidBudget)
+}
+
+func ErrInvalidReport() *errx.Error {
+	return ErrRegistry.New(CodeInvalidReport)
+}
@@ -0,0 +1,20 @@
+package ailedger
+
+import (
+	"context"
+	"time"
+)
+
+type UsageRepository interface {
+	Create(ctx context.Context, r *UsageRecord) error
+	Report(ctx context.Context, q ReportQuery) ([]ReportRow, error)
+	// SpendSince sums a tenant's cost from since until now.
+	SpendSince(ctx context.Context, tenantID string, since time.Time) (float64, error)
+}
+
+type BudgetRepository interface {
+	// GetByTenantID returns nil, nil when the tenant has no budget.
+	GetByTenantID(ctx context.Context, tenantID string) (*Budget, error)
+	Upsert(ctx context.Context, b *Budget) error
+	Delete(ctx context.Context, tenantID string) error
+}
@@ -0,0 +1,159 @@
+package analytics
+
+import (
+	"strconv"
+	"time"
+)
+
+// ============================================================================
+// Queries
+// ============================================================================
+
+// Bucket is the period every report row is aggregated into.
+type Bucket string
+
+const (
+	BucketDay   Bucket = "day"
+	BucketWeek  Bucket = "week"
+	BucketMonth Bucket = "month"
+)
+
+func (b Bucket) IsValid() bool {
+	switch b {
+	case BucketDay, BucketWeek, BucketMonth:
+		return true
+	}
+	return false
+}
+
+// Query scopes a report. Each report filters on the date of the event it
+// measures: vehicle creation, publication, inspection or enrichment.
+type Query struct {
+	From     time.Time
+	To       time.Time // exclusive
+	TenantID *string   // nil = all tenants
+	Branches []string  // empty = all branches
+	Bucket   Bucket
+}
+
+// Report is one analytics report over Query's range.
+type Report[T any] struct {
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Bucket Bucket    `json:"bucket"`
+	Rows   []T       `json:"rows"`
+}
+
+// Row is a report row that can be exported as CSV.
+type Row interface {
+	CSVHeader() []string
+	CSVRecord() []string
+}
+
+// ============================================================================
+// Report Rows
+// ============================================================================
+
+// StatusCount counts vehicles created in the period by branch and their
+// current status.
+type StatusCount struct {
+	Period   time.Time `json:"period" db:"period"`
+	Branch   *string   `json:"branch" db:"branch"`
+	Status   string    `json:"status" db:"status"`
+	Vehicles int       `json:"vehicles" db:"vehicles"`
+}
+
+func (StatusCount) CSVHeader() []string {
+	return []string{"period", "branch", "status", "vehicles"}
+}
+
+func (r StatusCount) CSVRecord() []string {
+	return []string{formatPeriod(r.Period), deref(r.Branch), r.Status, strconv.Itoa(r.Vehicles)}
+}
+
+// PublishLeadTime is how long vehicles published in the period took from
+// creation as drafts to their first publication.
+type PublishLeadTime struct {
+	Period   time.Time `json:"period" db:"period"`
+	Branch   *string   `json:"branch" db:"branch"`
+	Vehicles int       `json:"vehicles" db:"vehicles"`
+	AvgDays  float64   `json:"avg_days" db:"avg_days"`
+	MaxDays  float64   `json:"max_days" db:"max_days"`
+}
+
+func (PublishLeadTime) CSVHeader() []string {
+	return []string{"period", "branch", "vehicles", "avg_days", "max_days"}
+}
+
+func (r PublishLeadTime) CSVRecord() []string {
+	return []string{formatPeriod(r.Period), deref(r.Branch), strconv.Itoa(r.Vehicles), formatFloat(r.AvgDays), formatFloat(r.MaxDays)}
+}
+
+// InspectionScore averages the overall score of the scored inspections
+// created in the period, by vehicle brand and year.
+type InspectionScore struct {
+	Period      time.Time `json:"period" db:"period"`
+	Brand       string    `json:"brand" db:"brand"`
+	Year        int       `json:"year" db:"year"`
+	Inspections int       `json:"inspections" db:"inspections"`
+	AvgScore    float64   `json:"avg_score" db:"avg_score"`
+}
+
+func (InspectionScore) CSVHeader() []string {
+	return []string{"period", "brand", "year", "inspections", "avg_score"}
+}
+
+func (r InspectionScore) CSVRecord() []string {
+	return []string{formatPeriod(r.Period), r.Brand, strconv.Itoa(r.Year), strconv.Itoa(r.Inspections), formatFloat(r.AvgScore)}
+}
+
+// FindingCount counts the findings of inspections created in the period by
+// zone, type and severity.
+type FindingCount struct {
+	Period   time.Time `json:"period" db:"period"`
+	Zone     string    `json:"zone" db:"zone"`
+	Type     string    `json:"type" db:"finding_type"`
+	Severity string    `json:"severity" db:"severity"`
+	Findings int       `json:"findings" db:"findings"`
+}
+
+func (FindingCount) CSVHeader() []string {
+	return []string{"period", "zone", "type", "severity", "findings"}
+}
+
+func (r FindingCount) CSVRecord() []string {
+	return []string{formatPeriod(r.Period), r.Zone, r.Type, r.Severity, strconv.Itoa(r.Findings)}
+}
+
+// EnrichmentFailureRate is the share of enrichment steps finished in the
+// period whose last run failed.
+type EnrichmentFailureRate struct {
+	Period      time.Time `json:"period" db:"period"`
+	Step        string    `json:"step" db:"step"`
+	Finished    int       `json:"finished" db:"finished"`
+	Failed      int       `json:"failed" db:"failed"`
+	FailureRate float64   `json:"failure_rate" db:"failure_rate"`
+}
+
+func (EnrichmentFailureRate) CSVHeader() []string {
+	return []string{"period", "step", "finished", "failed", "failure_rate"}
+}
+
+func (r EnrichmentFailureRate) CSVRecord() []string {
+	return []string{formatPeriod(r.Period), r.Step, strconv.Itoa(r.Finished), strconv.Itoa(r.Failed), formatFloat(r.FailureRate)}
+}
+
+func formatPeriod(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,184 @@
+package analyticsapi
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/analytics"
+	"github.com/Abraxas-365/divi/pkg/analytics/analyticssrv"
+	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/Abraxas-365/divi/pkg/iam"
+	"github.com/Abraxas-365/divi/pkg/iam/auth"
+	"github.com/Abraxas-365/divi/pkg/iam/scopes"
+	"github.com/gofiber/fiber/v2"
+)
+
+// dateLayout is the layout of the from/to query parameters.
+const dateLayout = "2006-01-02"
+
+type Handlers struct {
+	analytics *analyticssrv.AnalyticsService
+}
+
+func NewHandlers(analytics *analyticssrv.AnalyticsService) *Handlers {
+	return &Handlers{analytics: analytics}
+}
+
+func (h *Handlers) RegisterRoutes(router fiber.Router, authMiddleware *auth.UnifiedAuthMiddleware) {
+	dashboard := router.Group("/analytics",
+		authMiddleware.Authenticate(),
+		authMiddleware.RequireAdminOrScope(scopes.ScopeAnalyticsDashboard),
+	)
+
+	dashboard.Get("/vehicles-by-status", h.GetVehiclesByStatus)
+	dashboard.Get("/time-to-publish", h.GetTimeToPublish)
+	dashboard.Get("/inspection-scores", h.GetInspectionScores)
+	dashboard.Get("/findings", h.GetFindingFrequency)
+	dashboard.Get("/enrichment-failures", h.GetEnrichmentFailures)
+}
+
+func (h *Handlers) GetVehiclesByStatus(c *fiber.Ctx) error {
+	q, err := parseQuery(c)
+	if err != nil {
+		return err
+	}
+	report, err := h.analytics.VehiclesByStatus(c.Context(), q)
+	if err != nil {
+		return err
+	}
+	return send(c, "vehicles-by-status", report)
+}
+
+func (h *Handlers) GetTimeToPublish(c *fiber.Ctx) error {
+	q, err := parseQuery(c)
+	if err != nil {
+		return err
+	}
+	report, err := h.analytics.TimeToPublish(c.Context(), q)
+	if err != nil {
+		return err
+	}
+	return send(c, "time-to-publish", report)
+}
+
+func (h *Handlers) GetInspectionScores(c *fiber.Ctx) error {
+	q, err := parseQuery(c)
+	if err != nil {
+		return err
+	}
+	report, err := h.analytics.InspectionScores(c.Context(), q)
+	if err != nil {
+		return err
+	}
+	return send(c, "inspection-scores", report)
+}
+
+func (h *Handlers) GetFindingFrequency(c *fiber.Ctx) error {
+	q, err := parseQuery(c)
+	if err != nil {
+		return err
+	}
+	report, err := h.analytics.FindingFrequency(c.Context(), q)
+	if err != nil {
+		return err
+	}
+	return send(c, "findings", report)
+}
+
+func (h *Handlers) GetEnrichmentFailures(c *fiber.Ctx) error {
+	q, err := parseQuery(c)
+	if err != nil {
+		return err
+	}
+	report, err := h.analytics.EnrichmentFailures(c.Context(), q)
+	if err != nil {
+		return err
+	}
+	return send(c, "enrichment-failures", report)
+}
+
+// parseQuery reads ?from and ?to (YYYY-MM-DD, to inclusive; default the last
+// 90 days), ?bucket=day|week|month (default month) and ?branch, repeated or
+// comma-separated. Reports cover the caller's tenant; admins may pass
+// ?tenant_id or omit it to report across all tenants.
+func parseQuery(c *fiber.Ctx) (analytics.Query, error) {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return analytics.Query{}, iam.ErrUnauthorized()
+	}
+
+	to := time.Now().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	q := analytics.Query{
+		From:   to.AddDate(0, 0, -90),
+		To:     to,
+		Bucket: analytics.Bucket(c.Query("bucket", string(analytics.BucketMonth))),
+	}
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(dateLayout, v)
+		if err != nil {
+			return q, errx.Validation("from must be a date (YYYY-MM-DD)")
+		}
+		q.From = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(dateLayout, v)
+		if err != nil {
+			return q, errx.Validation("to must be a date (YYYY-MM-DD)")
+		}
+		q.To = t.AddDate(0, 0, 1)
+	}
+
+	for _, raw := range c.Context().QueryArgs().PeekMulti("branch") {
+		for _, branch := range strings.Split(string(raw), ",") {
+			if branch = strings.TrimSpace(branch); branch != "" {
+				q.Branches = append(q.Branches, branch)
+			}
+		}
+	}
+
+	if authContext.HasAnyScope(scopes.ScopeAll, scopes.ScopeAdminAll) {
+		if tenant := c.Query("tenant_id"); tenant != "" {
+			q.TenantID = &tenant
+		}
+	} else {
+		tenant := authContext.TenantID.String()
+		q.TenantID = &tenant
+	}
+
+	return q, nil
+}
+
+// send writes the report as JSON, or as a CSV download with ?format=csv.
+func send[T analytics.Row](c *fiber.Ctx, name string, report *analytics.Report[T]) error {
+	switch c.Query("format", "json") {
+	case "json":
+		return c.JSON(report)
+	case "csv":
+	default:
+		return errx.Validation("format must be json or csv")
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	var header T
+	if err := w.Write(header.CSVHeader()); err != nil {
+		return errx.Wrap(err, "Failed to write CSV", errx.TypeInternal)
+	}
+	for _, row := range report.Rows {
+		if err := w.Write(row.CSVRecord()); err != nil {
+			return errx.Wrap(err, "Failed to write CSV", errx.TypeInternal)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return errx.Wrap(err, "Failed to write CSV", errx.TypeInternal)
+	}
+
+	filename := fmt.Sprintf("%s_%s_%s.csv", name, report.From.Format(dateLayout), report.To.AddDate(0, 0, -1).Format(dateLayout))
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	return c.Send(buf.Bytes())
+}
@@ -0,0 +1,36 @@
+package analyticscontainer
+
+import (
+	"github.com/Abraxas-365/divi/pkg/analytics/analyticsapi"
+	"github.com/Abraxas-365/divi/pkg/analytics/analyticsinfra"
+	"github.com/Abraxas-365/divi/pkg/analytics/analyticssrv"
+	"github.com/Abraxas-365/divi/pkg/logx"
+	"github.com/jmoiron/sqlx"
+)
+
+type Deps struct {
+	DB *sqlx.DB
+}
+
+type Container struct {
+	Service  *analyticssrv.AnalyticsService
+	Handlers *analyticsapi.Handlers
+}
+
+func New(deps Deps) *Container {
+	logx.Info("Initializing analytics container...")
+
+	c := &Container{}
+
+	// ── Repositories ─────────────────────────────────────────────────────
+	repo := analyticsinfra.NewPostgresRepository(deps.DB)
+
+	// ── Services ─────────────────────────────────────────────────────────
+	c.Service = analyticssrv.NewAnalyticsService(repo)
+
+	// ── Handlers ─────────────────────────────────────────────────────────
+	c.Handlers = analyticsapi.NewHandlers(c.Service)
+
+	logx.Info("Analytics container initialized")
+	return c
+}
@@ -0,0 +1,181 @@
+package analyticsinfra
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Abraxas-365/divi/pkg/analytics"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// PostgresRepository aggregates straight over the DiveInspect tables.
+// Soft-deleted vehicles are left out of every report.
+type PostgresRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// bucketUnits whitelists the date_trunc units a bucket maps to.
+var bucketUnits = map[analytics.Bucket]string{
+	analytics.BucketDay:   "day",
+	analytics.BucketWeek:  "week",
+	analytics.BucketMonth: "month",
+}
+
+// scope builds the WHERE clause shared by every report: dateColumn within
+// the range, plus the tenant and branch filters on the vehicle aliased v.
+// It returns the period expression to select and group by.
+func scope(q analytics.Query, dateColumn string) (period, where string, args []any, err error) {
+	unit, ok := bucketUnits[q.Bucket]
+	if !ok {
+		return "", "", nil, fmt.Errorf("unknown analytics bucket %q", q.Bucket)
+	}
+
+	conds := []string{
+		"v.deleted_at IS NULL",
+		dateColumn + " >= $1",
+		dateColumn + " < $2",
+	}
+	args = []any{q.From, q.To}
+	if q.TenantID != nil {
+		args = append(args, *q.TenantID)
+		conds = append(conds, fmt.Sprintf("v.tenant_id = $%d", len(args)))
+	}
+	if len(q.Branches) > 0 {
+		branches := make([]string, len(q.Branches))
+		for i, b := range q.Branches {
+			branches[i] = strings.ToLower(b)
+		}
+		args = append(args, pq.StringArray(branches))
+		conds = append(conds, fmt.Sprintf("LOWER(v.branch) = ANY($%d)", len(args)))
+	}
+
+	period = fmt.Sprintf("date_trunc('%s', %s)", unit, dateColumn)
+	return period, strings.Join(conds, " AND "), args, nil
+}
+
+func (r *PostgresRepository) VehiclesByStatus(ctx context.Context, q analytics.Query) ([]analytics.StatusCount, error) {
+	period, where, args, err := scope(q, "v.created_at")
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(`
+		SELECT %s AS period, v.branch, v.status, COUNT(*) AS vehicles
+		FROM vehicles v
+		WHERE %s
+		GROUP BY 1, 2, 3
+		ORDER BY 1, 2, 3`, period, where)
+
+	var rows []analytics.StatusCount
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// TimeToPublish measures from vehicle creation to its first vehicle.published
+// event in the outbox, so vehicles published before the outbox existed are
+// not counted.
+func (r *PostgresRepository) TimeToPublish(ctx context.Context, q analytics.Query) ([]analytics.PublishLeadTime, error) {
+	period, where, args, err := scope(q, "p.published_at")
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(`
+		WITH published AS (
+			SELECT subject_id AS vehicle_id, MIN(created_at) AS published_at
+			FROM domain_events
+			WHERE event_type = 'vehicle.published'
+			GROUP BY subject_id
+		)
+		SELECT %s AS period, v.branch,
+			COUNT(*) AS vehicles,
+			AVG(EXTRACT(EPOCH FROM p.published_at - v.created_at) / 86400) AS avg_days,
+			MAX(EXTRACT(EPOCH FROM p.published_at - v.created_at) / 86400) AS max_days
+		FROM vehicles v
+		JOIN published p ON p.vehicle_id = v.id
+		WHERE %s
+		GROUP BY 1, 2
+		ORDER BY 1, 2`, period, where)
+
+	var rows []analytics.PublishLeadTime
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (r *PostgresRepository) InspectionScores(ctx context.Context, q analytics.Query) ([]analytics.InspectionScore, error) {
+	period, where, args, err := scope(q, "i.created_at")
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(`
+		SELECT %s AS period, v.brand, v.year,
+			COUNT(*) AS inspections,
+			AVG(i.score_overall) AS avg_score
+		FROM inspections i
+		JOIN vehicles v ON v.id = i.vehicle_id
+		WHERE %s AND i.score_overall IS NOT NULL
+		GROUP BY 1, 2, 3
+		ORDER BY 1, 2, 3`, period, where)
+
+	var rows []analytics.InspectionScore
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// FindingFrequency dates findings by their inspection; findings carry no
+// timestamp of their own.
+func (r *PostgresRepository) FindingFrequency(ctx context.Context, q analytics.Query) ([]analytics.FindingCount, error) {
+	period, where, args, err := scope(q, "i.created_at")
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(`
+		SELECT %s AS period, f.zone, f.finding_type, f.severity, COUNT(*) AS findings
+		FROM inspection_findings f
+		JOIN inspections i ON i.id = f.inspection_id
+		JOIN vehicles v ON v.id = i.vehicle_id
+		WHERE %s
+		GROUP BY 1, 2, 3, 4
+		ORDER BY 1, findings DESC, 2, 3, 4`, period, where)
+
+	var rows []analytics.FindingCount
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// EnrichmentFailures counts each step's last outcome; pending and running
+// steps have not finished and are left out.
+func (r *PostgresRepository) EnrichmentFailures(ctx context.Context, q analytics.Query) ([]analytics.EnrichmentFailureRate, error) {
+	period, where, args, err := scope(q, "COALESCE(es.completed_at, es.updated_at)")
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(`
+		SELECT %s AS period, es.step,
+			COUNT(*) AS finished,
+			COUNT(*) FILTER (WHERE es.status = 'failed') AS failed,
+			COUNT(*) FILTER (WHERE es.status = 'failed')::float / COUNT(*) AS failure_rate
+		FROM vehicle_enrichment_steps es
+		JOIN vehicles v ON v.id = es.vehicle_id
+		WHERE %s AND es.status IN ('succeeded', 'failed')
+		GROUP BY 1, 2
+		ORDER BY 1, 2`, period, where)
+
+	var rows []analytics.EnrichmentFailureRate
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
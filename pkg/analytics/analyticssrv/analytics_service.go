@@ -0,0 +1,85 @@
+package analyticssrv
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/divi/pkg/analytics"
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+// maxDayBuckets caps day-bucketed ranges so a report stays a dashboard
+// series rather than a table dump.
+const maxDayBuckets = 366
+
+type AnalyticsService struct {
+	repo analytics.Repository
+}
+
+func NewAnalyticsService(repo analytics.Repository) *AnalyticsService {
+	return &AnalyticsService{repo: repo}
+}
+
+// VehiclesByStatus counts vehicles per branch and current status, bucketed
+// by when they were created.
+func (s *AnalyticsService) VehiclesByStatus(ctx context.Context, q analytics.Query) (*analytics.Report[analytics.StatusCount], error) {
+	return run(ctx, q, s.repo.VehiclesByStatus)
+}
+
+// TimeToPublish averages the days from draft to first publication per
+// branch, bucketed by publication date.
+func (s *AnalyticsService) TimeToPublish(ctx context.Context, q analytics.Query) (*analytics.Report[analytics.PublishLeadTime], error) {
+	return run(ctx, q, s.repo.TimeToPublish)
+}
+
+// InspectionScores averages overall inspection scores per vehicle brand
+// and year, bucketed by inspection date.
+func (s *AnalyticsService) InspectionScores(ctx context.Context, q analytics.Query) (*analytics.Report[analytics.InspectionScore], error) {
+	return run(ctx, q, s.repo.InspectionScores)
+}
+
+// FindingFrequency counts findings per zone, type and severity, bucketed by
+// inspection date.
+func (s *AnalyticsService) FindingFrequency(ctx context.Context, q analytics.Query) (*analytics.Report[analytics.FindingCount], error) {
+	return run(ctx, q, s.repo.FindingFrequency)
+}
+
+// EnrichmentFailures reports the failure rate of each enrichment step,
+// bucketed by when the step last finished.
+func (s *AnalyticsService) EnrichmentFailures(ctx context.Context, q analytics.Query) (*analytics.Report[analytics.EnrichmentFailureRate], error) {
+	return run(ctx, q, s.repo.EnrichmentFailures)
+}
+
+// run validates q, fetches the rows and wraps them in a report.
+func run[T any](ctx context.Context, q analytics.Query, fetch func(context.Context, analytics.Query) ([]T, error)) (*analytics.Report[T], error) {
+	if err := validate(q); err != nil {
+		return nil, err
+	}
+
+	rows, err := fetch(ctx, q)
+	if err != nil {
+		return nil, errx.Wrap(err, "Failed to build analytics report", errx.TypeInternal)
+	}
+	if rows == nil {
+		rows = []T{}
+	}
+
+	return &analytics.Report[T]{
+		From:   q.From,
+		To:     q.To,
+		Bucket: q.Bucket,
+		Rows:   rows,
+	}, nil
+}
+
+func validate(q analytics.Query) error {
+	if !q.Bucket.IsValid() {
+		return analytics.ErrInvalidQuery().WithDetail("bucket", string(q.Bucket))
+	}
+	if !q.From.Before(q.To) {
+		return analytics.ErrInvalidQuery().WithDetail("reason", "from must be before to")
+	}
+	if q.Bucket == analytics.BucketDay && q.To.Sub(q.From).Hours() > maxDayBuckets*24 {
+		return analytics.ErrInvalidQuery().WithDetail("reason", "day buckets span at most one year")
+	}
+	return nil
+}
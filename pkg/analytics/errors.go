@@ -0,0 +1,17 @@
+package analytics
+
+import (
+	"net/http"
+
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+var ErrRegistry = errx.NewRegistry("ANALYTICS")
+
+var (
+	CodeInvalidQuery = ErrRegistry.Register("INVALID_QUERY", errx.TypeValidation, http.StatusBadRequest, "Invalid analytics query")
+)
+
+func ErrInvalidQuery() *errx.Error {
+	return ErrRegistry.New(CodeInvalidQuery)
+}
@@ -0,0 +1,11 @@
+package analytics
+
+import "context"
+
+type Repository interface {
+	VehiclesByStatus(ctx context.Context, q Query) ([]StatusCount, error)
+	TimeToPublish(ctx context.Context, q Query) ([]PublishLeadTime, error)
+	InspectionScores(ctx context.Context, q Query) ([]InspectionScore, error)
+	FindingFrequency(ctx context.Context, q Query) ([]FindingCount, error)
+	EnrichmentFailures(ctx context.Context, q Query) ([]EnrichmentFailureRate, error)
+}
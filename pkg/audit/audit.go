@@ -0,0 +1,230 @@
+package audit
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/kernel"
+)
+
+// ============================================================================
+// Entries
+// ============================================================================
+
+type Action string
+
+const (
+	ActionCreate         Action = "create"
+	ActionUpdate         Action = "update"
+	ActionDelete         Action = "delete"
+	ActionRestore        Action = "restore"
+	ActionLogin          Action = "login"
+	ActionLoginFailed    Action = "login_failed"
+	ActionPasswordChange Action = "password_change"
+	ActionTokenRefresh   Action = "token_refresh"
+)
+
+// ActorType is who made a change.
+type ActorType string
+
+const (
+	ActorUser   ActorType = "user"
+	ActorAPIKey ActorType = "api_key"
+	// ActorSystem covers background jobs and anything else without a caller.
+	ActorSystem ActorType = "system"
+)
+
+// Entry is one append-only audit log row.
+type Entry struct {
+	ID         string       `json:"id" db:"id"`
+	TenantID   *string      `json:"tenant_id,omitempty" db:"tenant_id"`
+	ActorType  ActorType    `json:"actor_type" db:"actor_type"`
+	ActorID    *string      `json:"actor_id,omitempty" db:"actor_id"`
+	ActorName  *string      `json:"actor_name,omitempty" db:"actor_name"`
+	EntityType string       `json:"entity_type" db:"entity_type"`
+	EntityID   string       `json:"entity_id" db:"entity_id"`
+	SubjectID  *string      `json:"subject_id,omitempty" db:"subject_id"`
+	Action     Action       `json:"action" db:"action"`
+	Changes    FieldChanges `json:"changes" db:"changes"`
+	Metadata   Metadata     `json:"metadata,omitempty" db:"metadata"`
+	CreatedAt  time.Time    `json:"created_at" db:"created_at"`
+}
+
+// FieldChange is the before and after JSON value of one field. Before is
+// null on create and After is null on delete.
+type FieldChange struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+type FieldChanges []FieldChange
+
+func (f FieldChanges) Value() (driver.Value, error) {
+	if f == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(f)
+	return string(b), err
+}
+
+func (f *FieldChanges) Scan(value any) error {
+	return scanJSON(value, f)
+}
+
+type Metadata map[string]any
+
+func (m Metadata) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(m)
+	return string(b), err
+}
+
+func (m *Metadata) Scan(value any) error {
+	return scanJSON(value, m)
+}
+
+func scanJSON(value any, dest any) error {
+	if value == nil {
+		return nil
+	}
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into JSON", value)
+	}
+	return json.Unmarshal(b, dest)
+}
+
+// Query filters the audit log. Every filter is optional.
+type Query struct {
+	TenantID   *string // nil = all tenants
+	EntityType string
+	EntityID   string
+	SubjectID  string
+	ActorID    string
+	Action     Action
+	From       *time.Time
+	To         *time.Time // exclusive
+	Page       int
+	PageSize   int
+}
+
+// ============================================================================
+// Recording
+// ============================================================================
+
+// Change describes a write to an audited entity. Before is nil on create and
+// After is nil on delete; both are diffed field by field through their JSON
+// encoding.
+type Change struct {
+	TenantID   *string
+	EntityType string
+	EntityID   string
+	// SubjectID groups entries under a parent, e.g. the vehicle a finding
+	// belongs to, so its history can be read as one timeline.
+	SubjectID *string
+	Action    Action
+	Before    any
+	After     any
+}
+
+// Recorder appends changes to the audit log. The actor is taken from the
+// kernel.AuthContext in ctx; call it with the context of the transaction
+// that makes the change so both commit together.
+type Recorder interface {
+	RecordChange(ctx context.Context, change Change) error
+}
+
+// Log is a Recorder that can also be read back.
+type Log interface {
+	Recorder
+	List(ctx context.Context, q Query) (*kernel.Paginated[Entry], error)
+}
+
+// ignoredFields change on every write and would only add noise to diffs.
+var ignoredFields = map[string]bool{
+	"updated_at": true,
+	"revision":   true,
+}
+
+// Diff compares the JSON encodings of before and after and returns the
+// top-level fields that differ, sorted by name. Either side may be nil.
+func Diff(before, after any) (FieldChanges, error) {
+	b, err := fieldsOf(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := fieldsOf(after)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool, len(a)+len(b))
+	for k := range b {
+		keys[k] = true
+	}
+	for k := range a {
+		keys[k] = true
+	}
+
+	changes := FieldChanges{}
+	for k := range keys {
+		if ignoredFields[k] {
+			continue
+		}
+		bv, av := b[k], a[k]
+		if jsonEqual(bv, av) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: k, Before: nullable(bv), After: nullable(av)})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+func fieldsOf(v any) (map[string]json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if string(raw) == "null" {
+		return nil, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("audit: %T is not a JSON object: %w", v, err)
+	}
+	return fields, nil
+}
+
+// jsonEqual compares two JSON values ignoring formatting and key order.
+func jsonEqual(a, b json.RawMessage) bool {
+	a, b = nullable(a), nullable(b)
+	var av, bv any
+	if json.Unmarshal(a, &av) != nil || json.Unmarshal(b, &bv) != nil {
+		return string(a) == string(b)
+	}
+	return reflect.DeepEqual(av, bv)
+}
+
+func nullable(v json.RawMessage) json.RawMessage {
+	if len(v) == 0 {
+		return json.RawMessage("null")
+	}
+	return v
+}
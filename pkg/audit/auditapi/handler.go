@@ -0,0 +1,78 @@
+package auditapi
+
+import (
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/audit"
+	"github.com/Abraxas-365/divi/pkg/audit/auditsrv"
+	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/Abraxas-365/divi/pkg/iam"
+	"github.com/Abraxas-365/divi/pkg/iam/auth"
+	"github.com/Abraxas-365/divi/pkg/iam/scopes"
+	"github.com/gofiber/fiber/v2"
+)
+
+// dateLayout is the layout of the from/to query parameters.
+const dateLayout = "2006-01-02"
+
+type Handlers struct {
+	audit *auditsrv.AuditService
+}
+
+func NewHandlers(audit *auditsrv.AuditService) *Handlers {
+	return &Handlers{audit: audit}
+}
+
+func (h *Handlers) RegisterRoutes(router fiber.Router, authMiddleware *auth.UnifiedAuthMiddleware) {
+	router.Get("/audit-log",
+		authMiddleware.Authenticate(),
+		authMiddleware.RequireAdminOrScope(scopes.ScopeAuditRead),
+		h.ListEntries,
+	)
+}
+
+// ListEntries returns the caller's tenant audit log, newest first, filtered
+// by ?entity_type, ?entity_id, ?subject_id, ?actor_id, ?action and ?from/?to
+// (YYYY-MM-DD, to inclusive). Admins may read another ?tenant_id.
+func (h *Handlers) ListEntries(c *fiber.Ctx) error {
+	authContext, ok := auth.GetAuthContext(c)
+	if !ok {
+		return iam.ErrUnauthorized()
+	}
+
+	tenant := authContext.TenantID.String()
+	q := audit.Query{
+		TenantID:   &tenant,
+		EntityType: c.Query("entity_type"),
+		EntityID:   c.Query("entity_id"),
+		SubjectID:  c.Query("subject_id"),
+		ActorID:    c.Query("actor_id"),
+		Action:     audit.Action(c.Query("action")),
+		Page:       c.QueryInt("page", 1),
+		PageSize:   c.QueryInt("page_size", 50),
+	}
+	if other := c.Query("tenant_id"); other != "" && authContext.HasAnyScope(scopes.ScopeAll, scopes.ScopeAdminAll) {
+		q.TenantID = &other
+	}
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(dateLayout, v)
+		if err != nil {
+			return errx.Validation("from must be a date (YYYY-MM-DD)")
+		}
+		q.From = &t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(dateLayout, v)
+		if err != nil {
+			return errx.Validation("to must be a date (YYYY-MM-DD)")
+		}
+		t = t.AddDate(0, 0, 1)
+		q.To = &t
+	}
+
+	page, err := h.audit.List(c.Context(), q)
+	if err != nil {
+		return err
+	}
+	return c.JSON(page)
+}
@@ -0,0 +1,38 @@
+package auditcontainer
+
+import (
+	"github.com/Abraxas-365/divi/pkg/audit/auditapi"
+	"github.com/Abraxas-365/divi/pkg/audit/auditinfra"
+	"github.com/Abraxas-365/divi/pkg/audit/auditsrv"
+	"github.com/Abraxas-365/divi/pkg/logx"
+	"github.com/jmoiron/sqlx"
+)
+
+type Deps struct {
+	DB *sqlx.DB
+}
+
+type Container struct {
+	// Service is both the audit.Log domain modules record changes to and the
+	// auth.AuditService IAM logs authentication events to.
+	Service  *auditsrv.AuditService
+	Handlers *auditapi.Handlers
+}
+
+func New(deps Deps) *Container {
+	logx.Info("Initializing audit container...")
+
+	c := &Container{}
+
+	// ── Repositories ─────────────────────────────────────────────────────
+	repo := auditinfra.NewPostgresAuditRepository(deps.DB)
+
+	// ── Services ─────────────────────────────────────────────────────────
+	c.Service = auditsrv.NewAuditService(repo)
+
+	// ── Handlers ─────────────────────────────────────────────────────────
+	c.Handlers = auditapi.NewHandlers(c.Service)
+
+	logx.Info("Audit container initialized")
+	return c
+}
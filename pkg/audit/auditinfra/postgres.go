@@ -0,0 +1,97 @@
+package auditinfra
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Abraxas-365/divi/pkg/audit"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type PostgresAuditRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresAuditRepository(db *sqlx.DB) *PostgresAuditRepository {
+	return &PostgresAuditRepository{db: db}
+}
+
+// getExecutor returns the transaction carried by ctx under "db_tx", if any,
+// so entries commit together with the change they record.
+func (r *PostgresAuditRepository) getExecutor(ctx context.Context) sqlx.ExtContext {
+	if tx, ok := ctx.Value("db_tx").(*sqlx.Tx); ok {
+		return tx
+	}
+	return r.db
+}
+
+func (r *PostgresAuditRepository) Create(ctx context.Context, e *audit.Entry) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	query := `
+		INSERT INTO audit_log (
+			id, tenant_id, actor_type, actor_id, actor_name,
+			entity_type, entity_id, subject_id, action, changes, metadata
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING created_at`
+	return r.getExecutor(ctx).QueryRowxContext(ctx, query,
+		e.ID, e.TenantID, e.ActorType, e.ActorID, e.ActorName,
+		e.EntityType, e.EntityID, e.SubjectID, e.Action, e.Changes, e.Metadata,
+	).Scan(&e.CreatedAt)
+}
+
+func (r *PostgresAuditRepository) List(ctx context.Context, q audit.Query) ([]audit.Entry, int, error) {
+	conds := []string{"TRUE"}
+	var args []any
+	add := func(cond string, v any) {
+		args = append(args, v)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if q.TenantID != nil {
+		add("tenant_id = $%d", *q.TenantID)
+	}
+	if q.EntityType != "" {
+		add("entity_type = $%d", q.EntityType)
+	}
+	if q.EntityID != "" {
+		add("entity_id = $%d", q.EntityID)
+	}
+	if q.SubjectID != "" {
+		add("subject_id = $%d", q.SubjectID)
+	}
+	if q.ActorID != "" {
+		add("actor_id = $%d", q.ActorID)
+	}
+	if q.Action != "" {
+		add("action = $%d", q.Action)
+	}
+	if q.From != nil {
+		add("created_at >= $%d", *q.From)
+	}
+	if q.To != nil {
+		add("created_at < $%d", *q.To)
+	}
+	where := strings.Join(conds, " AND ")
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM audit_log WHERE `+where, args...); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (q.Page - 1) * q.PageSize
+	args = append(args, q.PageSize, offset)
+	query := fmt.Sprintf(`
+		SELECT * FROM audit_log
+		WHERE %s
+		ORDER BY created_at DESC, id
+		LIMIT $%d OFFSET $%d`, where, len(args)-1, len(args))
+
+	var entries []audit.Entry
+	if err := r.db.SelectContext(ctx, &entries, query, args...); err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
@@ -0,0 +1,173 @@
+package auditsrv
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/divi/pkg/audit"
+	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/Abraxas-365/divi/pkg/iam/auth"
+	"github.com/Abraxas-365/divi/pkg/kernel"
+)
+
+// AuditService writes the audit log for domain changes (audit.Log) and for
+// authentication events (auth.AuditService).
+type AuditService struct {
+	repo audit.Repository
+}
+
+func NewAuditService(repo audit.Repository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+var (
+	_ audit.Log         = (*AuditService)(nil)
+	_ auth.AuditService = (*AuditService)(nil)
+)
+
+// ============================================================================
+// Domain Changes
+// ============================================================================
+
+// RecordChange appends the field-level diff of change. Updates that change
+// nothing are not recorded.
+func (s *AuditService) RecordChange(ctx context.Context, change audit.Change) error {
+	changes, err := audit.Diff(change.Before, change.After)
+	if err != nil {
+		return errx.Wrap(err, "Failed to diff audited entity", errx.TypeInternal).
+			WithDetail("entity_type", change.EntityType)
+	}
+	if change.Action == audit.ActionUpdate && len(changes) == 0 {
+		return nil
+	}
+
+	entry := &audit.Entry{
+		TenantID:   change.TenantID,
+		EntityType: change.EntityType,
+		EntityID:   change.EntityID,
+		SubjectID:  change.SubjectID,
+		Action:     change.Action,
+		Changes:    changes,
+	}
+	if entry.TenantID == nil {
+		entry.TenantID = tenantFrom(ctx)
+	}
+	setActor(ctx, entry)
+
+	if err := s.repo.Create(ctx, entry); err != nil {
+		return errx.Wrap(err, "Failed to write audit log", errx.TypeInternal).
+			WithDetail("entity_type", change.EntityType).
+			WithDetail("entity_id", change.EntityID)
+	}
+	return nil
+}
+
+// List returns the audit log entries matching q, newest first.
+func (s *AuditService) List(ctx context.Context, q audit.Query) (*kernel.Paginated[audit.Entry], error) {
+	if q.From != nil && q.To != nil && !q.From.Before(*q.To) {
+		return nil, audit.ErrInvalidQuery().WithDetail("reason", "from must be before to")
+	}
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 || q.PageSize > 100 {
+		q.PageSize = 50
+	}
+
+	entries, total, err := s.repo.List(ctx, q)
+	if err != nil {
+		return nil, errx.Wrap(err, "Failed to read audit log", errx.TypeInternal)
+	}
+	page := kernel.NewPaginated(entries, q.Page, q.PageSize, total)
+	return &page, nil
+}
+
+// ============================================================================
+// Authentication Events
+// ============================================================================
+
+func (s *AuditService) LogLoginAttempt(ctx context.Context, userID kernel.UserID, success bool, ipAddress string) error {
+	action := audit.ActionLogin
+	if !success {
+		action = audit.ActionLoginFailed
+	}
+	return s.logAuthEvent(ctx, userID, action, ipAddress)
+}
+
+func (s *AuditService) LogPasswordChange(ctx context.Context, userID kernel.UserID, ipAddress string) error {
+	return s.logAuthEvent(ctx, userID, audit.ActionPasswordChange, ipAddress)
+}
+
+func (s *AuditService) LogTokenRefresh(ctx context.Context, userID kernel.UserID, ipAddress string) error {
+	return s.logAuthEvent(ctx, userID, audit.ActionTokenRefresh, ipAddress)
+}
+
+// logAuthEvent records an event the user performed on their own account.
+// There is usually no AuthContext yet, so the tenant comes from
+// kernel.TenantContextKey.
+func (s *AuditService) logAuthEvent(ctx context.Context, userID kernel.UserID, action audit.Action, ipAddress string) error {
+	id := userID.String()
+	entry := &audit.Entry{
+		TenantID:   tenantFrom(ctx),
+		ActorType:  audit.ActorUser,
+		ActorID:    &id,
+		EntityType: "user",
+		EntityID:   id,
+		Action:     action,
+		Changes:    audit.FieldChanges{},
+		Metadata:   audit.Metadata{"ip_address": ipAddress},
+	}
+	if err := s.repo.Create(ctx, entry); err != nil {
+		return errx.Wrap(err, "Failed to write audit log", errx.TypeInternal).
+			WithDetail("action", action)
+	}
+	return nil
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// setActor fills the actor from the caller in ctx; without one the change
+// was made by the system.
+func setActor(ctx context.Context, entry *audit.Entry) {
+	ac, ok := kernel.AuthContextFrom(ctx)
+	switch {
+	case !ok:
+		entry.ActorType = audit.ActorSystem
+	case ac.IsAPIKey:
+		entry.ActorType = audit.ActorAPIKey
+		if ac.APIKeyID != "" {
+			entry.ActorID = &ac.APIKeyID
+		}
+	default:
+		entry.ActorType = audit.ActorUser
+		if ac.UserID != nil {
+			id := ac.UserID.String()
+			entry.ActorID = &id
+		}
+		if ac.Email != "" {
+			entry.ActorName = &ac.Email
+		} else if ac.Name != "" {
+			entry.ActorName = &ac.Name
+		}
+	}
+}
+
+func tenantFrom(ctx context.Context) *string {
+	if ac, ok := kernel.AuthContextFrom(ctx); ok && !ac.TenantID.IsEmpty() {
+		tenant := ac.TenantID.String()
+		return &tenant
+	}
+	switch v := ctx.Value(kernel.TenantContextKey).(type) {
+	case kernel.TenantID:
+		if !v.IsEmpty() {
+			tenant := v.String()
+			return &tenant
+		}
+	case string:
+		if v != "" {
+			return &v
+		}
+	}
+	return nil
+}
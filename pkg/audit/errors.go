@@ -0,0 +1,17 @@
+package audit
+
+import (
+	"net/http"
+
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+var ErrRegistry = errx.NewRegistry("AUDIT")
+
+var (
+	CodeInvalidQuery = ErrRegistry.Register("INVALID_QUERY", errx.TypeValidation, http.StatusBadRequest, "Invalid audit log query")
+)
+
+func ErrInvalidQuery() *errx.Error {
+	return ErrRegistry.New(CodeInvalidQuery)
+}
@@ -0,0 +1,9 @@
+package audit
+
+import "context"
+
+type Repository interface {
+	// Create appends an entry, joining the transaction in ctx if there is one.
+	Create(ctx context.Context, e *Entry) error
+	List(ctx context.Context, q Query) ([]Entry, int, error)
+}
@@ -1,11 +1,15 @@
 package diveinspectapi
 
 import (
+	"io"
 	"strconv"
+	"strings"
 
 	"github.com/Abraxas-365/divi/pkg/diveinspect"
 	"github.com/Abraxas-365/divi/pkg/diveinspect/diveinspectsrv"
 	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/Abraxas-365/divi/pkg/iam/auth"
+	"github.com/Abraxas-365/divi/pkg/iam/scopes"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -14,6 +18,12 @@ type Handlers struct {
 	enrichmentSvc *diveinspectsrv.EnrichmentService
 	inspectionSvc *diveinspectsrv.InspectionService
 	reportSvc     *diveinspectsrv.ReportService
+	searchSvc     *diveinspectsrv.InventorySearchService
+	importSvc     *diveinspectsrv.ImportService
+	verifySvc     *diveinspectsrv.EquipmentVerificationService
+	listingSvc    *diveinspectsrv.ListingService
+	catalogSvc    *diveinspectsrv.SpecsCatalogService
+	webhookSvc    *diveinspectsrv.WebhookService
 }
 
 func NewHandlers(
@@ -21,28 +31,53 @@ func NewHandlers(
 	enrichmentSvc *diveinspectsrv.EnrichmentService,
 	inspectionSvc *diveinspectsrv.InspectionService,
 	reportSvc *diveinspectsrv.ReportService,
+	searchSvc *diveinspectsrv.InventorySearchService,
+	importSvc *diveinspectsrv.ImportService,
+	verifySvc *diveinspectsrv.EquipmentVerificationService,
+	listingSvc *diveinspectsrv.ListingService,
+	catalogSvc *diveinspectsrv.SpecsCatalogService,
+	webhookSvc *diveinspectsrv.WebhookService,
 ) *Handlers {
 	return &Handlers{
 		vehicleSvc:    vehicleSvc,
 		enrichmentSvc: enrichmentSvc,
 		inspectionSvc: inspectionSvc,
 		reportSvc:     reportSvc,
+		searchSvc:     searchSvc,
+		importSvc:     importSvc,
+		verifySvc:     verifySvc,
+		listingSvc:    listingSvc,
+		catalogSvc:    catalogSvc,
+		webhookSvc:    webhookSvc,
 	}
 }
 
 // RegisterRoutes registers all DiveInspect routes on the given router group
-func (h *Handlers) RegisterRoutes(router fiber.Router) {
+func (h *Handlers) RegisterRoutes(router fiber.Router, authMiddleware *auth.UnifiedAuthMiddleware) {
 	vehicles := router.Group("/vehicles")
 
+	// Inventory search (registered before /:id so "search" is not taken as an ID)
+	vehicles.Get("/search", h.SearchInventory)
+	vehicles.Get("/facets", h.GetVehicleFacets)
+
+	// Bulk import
+	vehicles.Post("/imports", h.UploadImport)
+	vehicles.Get("/imports/:jobId", h.GetImport)
+	vehicles.Post("/imports/:jobId/start", h.StartImport)
+
 	// Vehicle CRUD
 	vehicles.Post("/", h.CreateVehicle)
 	vehicles.Get("/", h.ListVehicles)
 	vehicles.Get("/:id", h.GetVehicle)
 	vehicles.Patch("/:id", h.UpdateVehicle)
 	vehicles.Delete("/:id", h.DeleteVehicle)
+	vehicles.Post("/:id/restore", h.RestoreVehicle)
+	vehicles.Get("/:id/history", h.GetVehicleHistory)
 
 	// Enrichment
 	vehicles.Post("/:id/enrich", h.EnrichVehicle)
+	vehicles.Get("/:id/enrich", h.GetEnrichmentStatus)
+	vehicles.Post("/:id/equipment/verify", h.VerifyEquipment)
 
 	// Preview & Publish
 	vehicles.Get("/:id/preview", h.GetVehiclePreview)
@@ -50,6 +85,7 @@ func (h *Handlers) RegisterRoutes(router fiber.Router) {
 
 	// Specs
 	vehicles.Patch("/:id/specs", h.UpdateSpecs)
+	vehicles.Post("/:id/specs/approve", h.ApproveSpecs)
 
 	// Photos & Inspection
 	vehicles.Post("/:id/photos", h.UploadPhoto)
@@ -61,9 +97,41 @@ func (h *Handlers) RegisterRoutes(router fiber.Router) {
 	// Listing JSON
 	vehicles.Get("/:id/listing.json", h.GetListingJSON)
 
+	// Listing variants (per channel and language, versioned)
+	vehicles.Get("/:id/listing/variants", h.ListListingVariants)
+	vehicles.Post("/:id/listing/variants", h.GenerateListingVariants)
+	vehicles.Get("/:id/listing/variants/:channel/:language/history", h.GetListingHistory)
+	vehicles.Post("/:id/listing/variants/:channel/:language/rollback", h.RollbackListing)
+
+	// Changing tenant configuration needs settings:write: brand settings, and
+	// webhook endpoints, which receive every event and so export the data
+	authenticate := authMiddleware.Authenticate()
+	settingsWrite := authMiddleware.RequireAdminOrScope(scopes.ScopeSettingsWrite)
+
+	// Tenant brand settings
+	router.Get("/brand-settings", h.GetBrandSettings)
+	router.Put("/brand-settings", authenticate, settingsWrite, h.UpdateBrandSettings)
+
+	// Specs catalog
+	router.Post("/specs-catalog/import", h.ImportSpecsCatalog)
+	router.Get("/specs-catalog/lookup", h.LookupSpecsCatalog)
+
 	// Inspection findings
 	findings := router.Group("/findings")
 	findings.Patch("/:fid", h.UpdateFinding)
+
+	// Outbound webhooks (delivery routes before /:id so "deliveries" is not taken as an ID)
+	webhooks := router.Group("/webhooks")
+	webhooks.Post("/", authenticate, settingsWrite, h.CreateWebhookEndpoint)
+	webhooks.Get("/", h.ListWebhookEndpoints)
+	webhooks.Get("/deliveries", h.ListWebhookDeliveries)
+	webhooks.Get("/deliveries/:deliveryId", h.GetWebhookDelivery)
+	webhooks.Post("/deliveries/:deliveryId/redeliver", authenticate, settingsWrite, h.RedeliverWebhook)
+	webhooks.Get("/:id", h.GetWebhookEndpoint)
+	webhooks.Patch("/:id", authenticate, settingsWrite, h.UpdateWebhookEndpoint)
+	webhooks.Delete("/:id", authenticate, settingsWrite, h.DeleteWebhookEndpoint)
+	webhooks.Post("/:id/rotate-secret", authenticate, settingsWrite, h.RotateWebhookSecret)
+	webhooks.Get("/:id/deliveries", h.ListWebhookDeliveries)
 }
 
 // ============================================================================
@@ -72,6 +140,7 @@ func (h *Handlers) RegisterRoutes(router fiber.Router) {
 
 type createVehicleRequest struct {
 	Plate         *string  `json:"plate"`
+	VIN           *string  `json:"vin"`
 	Brand         string   `json:"brand"`
 	Model         string   `json:"model"`
 	Version       *string  `json:"version"`
@@ -93,6 +162,7 @@ func (h *Handlers) CreateVehicle(c *fiber.Ctx) error {
 
 	vehicle := &diveinspect.Vehicle{
 		Plate:         req.Plate,
+		VIN:           req.VIN,
 		Brand:         req.Brand,
 		Model:         req.Model,
 		Version:       req.Version,
@@ -104,6 +174,7 @@ func (h *Handlers) CreateVehicle(c *fiber.Ctx) error {
 		PriceUSD:      req.PriceUSD,
 		Branch:        req.Branch,
 		Origin:        req.Origin,
+		TenantID:      tenantID(c),
 	}
 
 	if err := h.vehicleSvc.Create(c.Context(), vehicle); err != nil {
@@ -119,111 +190,117 @@ func (h *Handlers) GetVehicle(c *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
+	setETag(c, vehicle.Revision)
 	return c.JSON(vehicle)
 }
 
 func (h *Handlers) ListVehicles(c *fiber.Ctx) error {
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	pageSize, _ := strconv.Atoi(c.Query("page_size", "20"))
+	q, err := parseVehicleQuery(c)
+	if err != nil {
+		return err
+	}
 
-	vehicles, total, err := h.vehicleSvc.List(c.Context(), page, pageSize)
+	result, err := h.vehicleSvc.Query(c.Context(), q)
 	if err != nil {
 		return err
 	}
 
-	return c.JSON(fiber.Map{
-		"items": vehicles,
-		"pagination": fiber.Map{
-			"page":      page,
-			"page_size": pageSize,
-			"total":     total,
-		},
-	})
+	return c.JSON(result)
 }
 
-func (h *Handlers) UpdateVehicle(c *fiber.Ctx) error {
-	id := c.Params("id")
-	vehicle, err := h.vehicleSvc.GetByID(c.Context(), id)
+func (h *Handlers) GetVehicleFacets(c *fiber.Ctx) error {
+	q, err := parseVehicleQuery(c)
 	if err != nil {
 		return err
 	}
 
-	// Parse partial update
-	var updates map[string]interface{}
-	if err := c.BodyParser(&updates); err != nil {
-		return errx.Validation("Invalid request body")
+	facets, err := h.vehicleSvc.Facets(c.Context(), q)
+	if err != nil {
+		return err
 	}
 
-	if v, ok := updates["plate"]; ok {
-		if s, ok := v.(string); ok {
-			vehicle.Plate = &s
-		}
-	}
-	if v, ok := updates["brand"]; ok {
-		if s, ok := v.(string); ok {
-			vehicle.Brand = s
-		}
-	}
-	if v, ok := updates["model"]; ok {
-		if s, ok := v.(string); ok {
-			vehicle.Model = s
-		}
+	return c.JSON(facets)
+}
+
+// parseVehicleQuery reads list filters from the query string. List params
+// accept repeated keys or comma-separated values; sort is a comma-separated
+// list of fields, each optionally prefixed with "-" for descending.
+func parseVehicleQuery(c *fiber.Ctx) (diveinspect.VehicleQuery, error) {
+	q := diveinspect.VehicleQuery{
+		Brands:      queryList(c, "brand"),
+		Models:      queryList(c, "model"),
+		Branches:    queryList(c, "branch"),
+		FuelTypes:   queryList(c, "fuel_type"),
+		Drivetrains: queryList(c, "drivetrain"),
+		Cursor:      c.Query("cursor"),
+		Page:        c.QueryInt("page", 1),
+		PageSize:    c.QueryInt("page_size", 20),
+
+		IncludeDeleted: c.QueryBool("include_deleted", false),
 	}
-	if v, ok := updates["version"]; ok {
-		if s, ok := v.(string); ok {
-			vehicle.Version = &s
-		}
+
+	for _, status := range queryList(c, "status") {
+		q.Statuses = append(q.Statuses, diveinspect.VehicleStatus(status))
 	}
-	if v, ok := updates["trim"]; ok {
-		if s, ok := v.(string); ok {
-			vehicle.Trim = &s
-		}
+
+	var err error
+	if q.YearMin, err = queryInt(c, "year_min"); err != nil {
+		return q, err
 	}
-	if v, ok := updates["year"]; ok {
-		if f, ok := v.(float64); ok {
-			vehicle.Year = int(f)
-		}
+	if q.YearMax, err = queryInt(c, "year_max"); err != nil {
+		return q, err
 	}
-	if v, ok := updates["mileage_km"]; ok {
-		if f, ok := v.(float64); ok {
-			vehicle.MileageKM = int(f)
-		}
+	if q.MileageMin, err = queryInt(c, "mileage_min"); err != nil {
+		return q, err
 	}
-	if v, ok := updates["color_exterior"]; ok {
-		if s, ok := v.(string); ok {
-			vehicle.ColorExterior = &s
-		}
+	if q.MileageMax, err = queryInt(c, "mileage_max"); err != nil {
+		return q, err
 	}
-	if v, ok := updates["color_interior"]; ok {
-		if s, ok := v.(string); ok {
-			vehicle.ColorInterior = &s
-		}
+	if q.PriceMin, err = queryFloat(c, "price_min"); err != nil {
+		return q, err
 	}
-	if v, ok := updates["price_usd"]; ok {
-		if f, ok := v.(float64); ok {
-			vehicle.PriceUSD = &f
-		}
+	if q.PriceMax, err = queryFloat(c, "price_max"); err != nil {
+		return q, err
 	}
-	if v, ok := updates["branch"]; ok {
-		if s, ok := v.(string); ok {
-			vehicle.Branch = &s
+
+	for _, field := range queryList(c, "sort") {
+		sort := diveinspect.VehicleSort{Field: diveinspect.VehicleSortField(field)}
+		if strings.HasPrefix(field, "-") {
+			sort.Field = diveinspect.VehicleSortField(field[1:])
+			sort.Desc = true
 		}
+		q.Sort = append(q.Sort, sort)
 	}
-	if v, ok := updates["origin"]; ok {
-		if s, ok := v.(string); ok {
-			vehicle.Origin = &s
+
+	return q, nil
+}
+
+func queryList(c *fiber.Ctx, key string) []string {
+	var values []string
+	for _, raw := range c.Context().QueryArgs().PeekMulti(key) {
+		for _, part := range strings.Split(string(raw), ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
 		}
 	}
-	if v, ok := updates["status"]; ok {
-		if s, ok := v.(string); ok {
-			vehicle.Status = diveinspect.VehicleStatus(s)
-		}
+	return values
+}
+
+// UpdateVehicle applies a JSON Merge Patch (RFC 7396) to the vehicle:
+// members set to null are cleared and omitted members are left as they are.
+func (h *Handlers) UpdateVehicle(c *fiber.Ctx) error {
+	ifMatch, err := parseIfMatch(c)
+	if err != nil {
+		return err
 	}
 
-	if err := h.vehicleSvc.Update(c.Context(), vehicle); err != nil {
+	vehicle, err := h.vehicleSvc.Patch(c.Context(), c.Params("id"), c.Body(), ifMatch)
+	if err != nil {
 		return err
 	}
 
+	setETag(c, vehicle.Revision)
 	return c.JSON(vehicle)
 }
 
@@ -235,10 +312,163 @@ func (h *Handlers) DeleteVehicle(c *fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
+// RestoreVehicle undoes a delete that the retention job has not purged yet.
+func (h *Handlers) RestoreVehicle(c *fiber.Ctx) error {
+	vehicle, err := h.vehicleSvc.Restore(c.Context(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+	setETag(c, vehicle.Revision)
+	return c.JSON(vehicle)
+}
+
+// GetVehicleHistory returns the audit trail of the vehicle, its specs, its
+// inspections and their findings, newest first.
+func (h *Handlers) GetVehicleHistory(c *fiber.Ctx) error {
+	history, err := h.vehicleSvc.History(c.Context(), c.Params("id"), c.QueryInt("page", 1), c.QueryInt("page_size", 50))
+	if err != nil {
+		return err
+	}
+	return c.JSON(history)
+}
+
+// ============================================================================
+// Inventory Search
+// ============================================================================
+
+func (h *Handlers) SearchInventory(c *fiber.Ctx) error {
+	tenant := tenantID(c)
+	if tenant == nil {
+		return errx.Unauthorized("Inventory search requires a tenant")
+	}
+
+	q := diveinspect.InventorySearchQuery{
+		TenantID: *tenant,
+		Query:    c.Query("q"),
+		Limit:    c.QueryInt("limit", 0),
+	}
+
+	var err error
+	if q.MinPriceUSD, err = queryFloat(c, "min_price"); err != nil {
+		return err
+	}
+	if q.MaxPriceUSD, err = queryFloat(c, "max_price"); err != nil {
+		return err
+	}
+	if q.MinYear, err = queryInt(c, "min_year"); err != nil {
+		return err
+	}
+	if q.MaxYear, err = queryInt(c, "max_year"); err != nil {
+		return err
+	}
+	if q.MinMileageKM, err = queryInt(c, "min_mileage"); err != nil {
+		return err
+	}
+	if q.MaxMileageKM, err = queryInt(c, "max_mileage"); err != nil {
+		return err
+	}
+	if fuel := c.Query("fuel_type"); fuel != "" {
+		q.FuelType = &fuel
+	}
+
+	hits, err := h.searchSvc.Search(c.Context(), q)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"query": q.Query,
+		"items": hits,
+	})
+}
+
+func queryInt(c *fiber.Ctx, key string) (*int, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, errx.Validation("Invalid query parameter").WithDetail("param", key)
+	}
+	return &v, nil
+}
+
+func queryFloat(c *fiber.Ctx, key string) (*float64, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, errx.Validation("Invalid query parameter").WithDetail("param", key)
+	}
+	return &v, nil
+}
+
+// ============================================================================
+// Bulk Import
+// ============================================================================
+
+func (h *Handlers) UploadImport(c *fiber.Ctx) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return errx.Validation("File is required")
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return errx.Wrap(err, "Failed to read uploaded file", errx.TypeInternal)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return errx.Wrap(err, "Failed to read uploaded file", errx.TypeInternal)
+	}
+
+	preview, err := h.importSvc.Upload(c.Context(), tenantID(c), file.Filename, data)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(preview)
+}
+
+type startImportRequest struct {
+	Mapping diveinspect.ColumnMapping `json:"mapping"`
+	Enrich  bool                      `json:"enrich"`
+}
+
+func (h *Handlers) StartImport(c *fiber.Ctx) error {
+	var req startImportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errx.Validation("Invalid request body")
+	}
+
+	job, err := h.importSvc.Queue(c.Context(), c.Params("jobId"), req.Mapping, req.Enrich)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(job)
+}
+
+func (h *Handlers) GetImport(c *fiber.Ctx) error {
+	job, err := h.importSvc.GetByID(c.Context(), c.Params("jobId"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(job)
+}
+
 // ============================================================================
 // Enrichment Handlers
 // ============================================================================
 
+// EnrichVehicle runs the steps listed in ?steps=specs,equipment,listing,json-ld,
+// or resumes the steps that have not succeeded yet when none are given. The
+// preview includes the status of every step.
 func (h *Handlers) EnrichVehicle(c *fiber.Ctx) error {
 	id := c.Params("id")
 	vehicle, err := h.vehicleSvc.GetByID(c.Context(), id)
@@ -246,7 +476,14 @@ func (h *Handlers) EnrichVehicle(c *fiber.Ctx) error {
 		return err
 	}
 
-	if err := h.enrichmentSvc.EnrichVehicle(c.Context(), vehicle); err != nil {
+	var steps []diveinspect.EnrichmentStep
+	for _, step := range strings.Split(c.Query("steps"), ",") {
+		if step = strings.TrimSpace(step); step != "" {
+			steps = append(steps, diveinspect.EnrichmentStep(step))
+		}
+	}
+
+	if _, err := h.enrichmentSvc.EnrichVehicle(c.Context(), vehicle, steps...); err != nil {
 		return err
 	}
 
@@ -259,6 +496,29 @@ func (h *Handlers) EnrichVehicle(c *fiber.Ctx) error {
 	return c.JSON(preview)
 }
 
+func (h *Handlers) GetEnrichmentStatus(c *fiber.Ctx) error {
+	steps, err := h.enrichmentSvc.Status(c.Context(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"steps": steps})
+}
+
+// VerifyEquipment checks the equipment list against the vehicle's interior
+// photos and regenerates the listing from the confirmed items.
+func (h *Handlers) VerifyEquipment(c *fiber.Ctx) error {
+	vehicle, err := h.vehicleSvc.GetByID(c.Context(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	result, err := h.verifySvc.VerifyEquipment(c.Context(), vehicle)
+	if err != nil {
+		return err
+	}
+	return c.JSON(result)
+}
+
 // ============================================================================
 // Preview & Publish
 // ============================================================================
@@ -285,24 +545,28 @@ func (h *Handlers) PublishVehicle(c *fiber.Ctx) error {
 // Specs
 // ============================================================================
 
+// UpdateSpecs applies a JSON Merge Patch (RFC 7396) to the vehicle's specs.
 func (h *Handlers) UpdateSpecs(c *fiber.Ctx) error {
-	id := c.Params("id")
-
-	// Verify vehicle exists
-	if _, err := h.vehicleSvc.GetByID(c.Context(), id); err != nil {
+	ifMatch, err := parseIfMatch(c)
+	if err != nil {
 		return err
 	}
 
-	var specs diveinspect.VehicleSpecs
-	if err := c.BodyParser(&specs); err != nil {
-		return errx.Validation("Invalid request body")
+	specs, err := h.vehicleSvc.PatchSpecs(c.Context(), c.Params("id"), c.Body(), ifMatch)
+	if err != nil {
+		return err
 	}
-	specs.VehicleID = id
 
-	if err := h.vehicleSvc.UpdateSpecs(c.Context(), &specs); err != nil {
+	setETag(c, specs.Revision)
+	return c.JSON(specs)
+}
+
+// ApproveSpecs confirms specs flagged by the guardrails as reviewed.
+func (h *Handlers) ApproveSpecs(c *fiber.Ctx) error {
+	specs, err := h.vehicleSvc.ApproveSpecs(c.Context(), c.Params("id"))
+	if err != nil {
 		return err
 	}
-
 	return c.JSON(specs)
 }
 
@@ -416,47 +680,408 @@ func (h *Handlers) GetListingJSON(c *fiber.Ctx) error {
 }
 
 // ============================================================================
-// Finding Update
+// Listing Variants
+// ============================================================================
+
+func (h *Handlers) ListListingVariants(c *fiber.Ctx) error {
+	variants, err := h.listingSvc.ListVariants(c.Context(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"variants": variants})
+}
+
+type generateVariantsRequest struct {
+	Channels  []diveinspect.ListingChannel  `json:"channels"`
+	Languages []diveinspect.ListingLanguage `json:"languages"`
+}
+
+// GenerateListingVariants writes a new version of the copy for the requested
+// channels and languages (all of them when omitted).
+func (h *Handlers) GenerateListingVariants(c *fiber.Ctx) error {
+	var req generateVariantsRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return errx.Validation("Invalid request body")
+		}
+	}
+
+	vehicle, err := h.vehicleSvc.GetByID(c.Context(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	variants, err := h.listingSvc.GenerateVariants(c.Context(), vehicle, req.Channels, req.Languages)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"variants": variants})
+}
+
+func (h *Handlers) GetListingHistory(c *fiber.Ctx) error {
+	history, err := h.listingSvc.History(c.Context(),
+		c.Params("id"),
+		diveinspect.ListingChannel(c.Params("channel")),
+		diveinspect.ListingLanguage(c.Params("language")),
+	)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"versions": history})
+}
+
+type rollbackListingRequest struct {
+	Version int `json:"version"`
+}
+
+func (h *Handlers) RollbackListing(c *fiber.Ctx) error {
+	var req rollbackListingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errx.Validation("Invalid request body")
+	}
+	if req.Version < 1 {
+		return errx.Validation("version is required")
+	}
+
+	variant, err := h.listingSvc.Rollback(c.Context(),
+		c.Params("id"),
+		diveinspect.ListingChannel(c.Params("channel")),
+		diveinspect.ListingLanguage(c.Params("language")),
+		req.Version,
+	)
+	if err != nil {
+		return err
+	}
+	return c.JSON(variant)
+}
+
+// ============================================================================
+// Specs Catalog
 // ============================================================================
 
-type updateFindingRequest struct {
-	Zone            *string `json:"zone"`
-	FindingType     *string `json:"finding_type"`
-	Severity        *string `json:"severity"`
-	Description     *string `json:"description"`
-	ConfirmedByHuman *bool  `json:"confirmed_by_human"`
+func (h *Handlers) ImportSpecsCatalog(c *fiber.Ctx) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return errx.Validation("File is required")
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return errx.Wrap(err, "Failed to read uploaded file", errx.TypeInternal)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return errx.Wrap(err, "Failed to read uploaded file", errx.TypeInternal)
+	}
+
+	result, err := h.catalogSvc.Import(c.Context(), file.Filename, data)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(result)
 }
 
+func (h *Handlers) LookupSpecsCatalog(c *fiber.Ctx) error {
+	brand, model := c.Query("brand"), c.Query("model")
+	year := c.QueryInt("year", 0)
+	if brand == "" || model == "" || year == 0 {
+		return errx.Validation("brand, model and year are required")
+	}
+
+	var version *string
+	if v := c.Query("version"); v != "" {
+		version = &v
+	}
+
+	entry, err := h.catalogSvc.Lookup(c.Context(), brand, model, version, year)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(entry)
+}
+
+// ============================================================================
+// Brand Settings
+// ============================================================================
+
+func (h *Handlers) GetBrandSettings(c *fiber.Ctx) error {
+	tenant := tenantID(c)
+	if tenant == nil {
+		return errx.Unauthorized("Brand settings require a tenant")
+	}
+	return c.JSON(h.listingSvc.BrandSettings(c.Context(), tenant))
+}
+
+type brandSettingsRequest struct {
+	DealerName      string                    `json:"dealer_name"`
+	WarrantyText    *string                   `json:"warranty_text"`
+	ForbiddenClaims []string                  `json:"forbidden_claims"`
+	ChannelStyles   diveinspect.ChannelStyles `json:"channel_styles"`
+}
+
+func (h *Handlers) UpdateBrandSettings(c *fiber.Ctx) error {
+	tenant := tenantID(c)
+	if tenant == nil {
+		return errx.Unauthorized("Brand settings require a tenant")
+	}
+
+	var req brandSettingsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errx.Validation("Invalid request body")
+	}
+
+	settings := &diveinspect.BrandSettings{
+		TenantID:        *tenant,
+		DealerName:      req.DealerName,
+		WarrantyText:    req.WarrantyText,
+		ForbiddenClaims: req.ForbiddenClaims,
+		ChannelStyles:   req.ChannelStyles,
+	}
+	if err := h.listingSvc.UpdateBrandSettings(c.Context(), settings); err != nil {
+		return err
+	}
+	return c.JSON(settings)
+}
+
+// ============================================================================
+// Finding Update
+// ============================================================================
+
+// UpdateFinding applies a JSON Merge Patch (RFC 7396) to a finding of one of
+// the caller's vehicles.
 func (h *Handlers) UpdateFinding(c *fiber.Ctx) error {
-	fid := c.Params("fid")
+	ifMatch, err := parseIfMatch(c)
+	if err != nil {
+		return err
+	}
+
+	finding, err := h.inspectionSvc.PatchFinding(c.Context(), tenantID(c), c.Params("fid"), c.Body(), ifMatch)
+	if err != nil {
+		return err
+	}
+
+	setETag(c, finding.Revision)
+	return c.JSON(finding)
+}
 
-	var req updateFindingRequest
+// ============================================================================
+// Webhooks
+// ============================================================================
+
+// webhookEndpointWithSecret is returned only when a secret is created or
+// rotated; the secret is never shown again.
+type webhookEndpointWithSecret struct {
+	*diveinspect.WebhookEndpoint
+	Secret string `json:"secret"`
+}
+
+type createWebhookRequest struct {
+	URL         string   `json:"url"`
+	EventTypes  []string `json:"event_types"`
+	Description *string  `json:"description"`
+}
+
+func (h *Handlers) CreateWebhookEndpoint(c *fiber.Ctx) error {
+	tenant := tenantID(c)
+	if tenant == nil {
+		return errx.Unauthorized("Webhooks require a tenant")
+	}
+
+	var req createWebhookRequest
 	if err := c.BodyParser(&req); err != nil {
 		return errx.Validation("Invalid request body")
 	}
 
-	finding := &diveinspect.InspectionFinding{ID: fid}
+	endpoint := &diveinspect.WebhookEndpoint{
+		TenantID:    *tenant,
+		URL:         req.URL,
+		EventTypes:  req.EventTypes,
+		Description: req.Description,
+		IsActive:    true,
+	}
+	if err := h.webhookSvc.CreateEndpoint(c.Context(), endpoint); err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(webhookEndpointWithSecret{endpoint, endpoint.Secret})
+}
+
+func (h *Handlers) ListWebhookEndpoints(c *fiber.Ctx) error {
+	tenant := tenantID(c)
+	if tenant == nil {
+		return errx.Unauthorized("Webhooks require a tenant")
+	}
 
-	if req.Zone != nil {
-		finding.Zone = diveinspect.FindingZone(*req.Zone)
+	endpoints, err := h.webhookSvc.ListEndpoints(c.Context(), *tenant)
+	if err != nil {
+		return err
 	}
-	if req.FindingType != nil {
-		finding.FindingType = diveinspect.FindingType(*req.FindingType)
+	return c.JSON(fiber.Map{"endpoints": endpoints})
+}
+
+func (h *Handlers) GetWebhookEndpoint(c *fiber.Ctx) error {
+	tenant := tenantID(c)
+	if tenant == nil {
+		return errx.Unauthorized("Webhooks require a tenant")
 	}
-	if req.Severity != nil {
-		finding.Severity = diveinspect.FindingSeverity(*req.Severity)
+
+	endpoint, err := h.webhookSvc.GetEndpoint(c.Context(), *tenant, c.Params("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(endpoint)
+}
+
+type updateWebhookRequest struct {
+	URL         *string   `json:"url"`
+	EventTypes  *[]string `json:"event_types"`
+	Description *string   `json:"description"`
+	IsActive    *bool     `json:"is_active"`
+}
+
+func (h *Handlers) UpdateWebhookEndpoint(c *fiber.Ctx) error {
+	tenant := tenantID(c)
+	if tenant == nil {
+		return errx.Unauthorized("Webhooks require a tenant")
+	}
+
+	var req updateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errx.Validation("Invalid request body")
+	}
+
+	endpoint, err := h.webhookSvc.GetEndpoint(c.Context(), *tenant, c.Params("id"))
+	if err != nil {
+		return err
+	}
+	if req.URL != nil {
+		endpoint.URL = *req.URL
+	}
+	if req.EventTypes != nil {
+		endpoint.EventTypes = *req.EventTypes
 	}
 	if req.Description != nil {
-		finding.Description = req.Description
+		endpoint.Description = req.Description
 	}
-	if req.ConfirmedByHuman != nil {
-		finding.ConfirmedByHuman = *req.ConfirmedByHuman
+	if req.IsActive != nil {
+		endpoint.IsActive = *req.IsActive
 	}
 
-	if err := h.inspectionSvc.UpdateFinding(c.Context(), finding); err != nil {
+	if err := h.webhookSvc.UpdateEndpoint(c.Context(), endpoint); err != nil {
 		return err
 	}
+	return c.JSON(endpoint)
+}
+
+func (h *Handlers) DeleteWebhookEndpoint(c *fiber.Ctx) error {
+	tenant := tenantID(c)
+	if tenant == nil {
+		return errx.Unauthorized("Webhooks require a tenant")
+	}
 
-	return c.JSON(finding)
+	if err := h.webhookSvc.DeleteEndpoint(c.Context(), *tenant, c.Params("id")); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handlers) RotateWebhookSecret(c *fiber.Ctx) error {
+	tenant := tenantID(c)
+	if tenant == nil {
+		return errx.Unauthorized("Webhooks require a tenant")
+	}
+
+	endpoint, err := h.webhookSvc.RotateSecret(c.Context(), *tenant, c.Params("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(webhookEndpointWithSecret{endpoint, endpoint.Secret})
+}
+
+// ListWebhookDeliveries serves the delivery log, for all of the tenant's
+// endpoints or, under /webhooks/:id/deliveries, for one.
+func (h *Handlers) ListWebhookDeliveries(c *fiber.Ctx) error {
+	tenant := tenantID(c)
+	if tenant == nil {
+		return errx.Unauthorized("Webhooks require a tenant")
+	}
+
+	q := diveinspect.WebhookDeliveryQuery{
+		TenantID:   *tenant,
+		EndpointID: c.Params("id"),
+		Status:     diveinspect.DeliveryStatus(c.Query("status")),
+		Page:       c.QueryInt("page", 1),
+		PageSize:   c.QueryInt("page_size", 20),
+	}
+	if q.EndpointID != "" {
+		if _, err := h.webhookSvc.GetEndpoint(c.Context(), *tenant, q.EndpointID); err != nil {
+			return err
+		}
+	}
+
+	result, err := h.webhookSvc.ListDeliveries(c.Context(), q)
+	if err != nil {
+		return err
+	}
+	return c.JSON(result)
+}
+
+func (h *Handlers) GetWebhookDelivery(c *fiber.Ctx) error {
+	tenant := tenantID(c)
+	if tenant == nil {
+		return errx.Unauthorized("Webhooks require a tenant")
+	}
+
+	delivery, err := h.webhookSvc.GetDelivery(c.Context(), *tenant, c.Params("deliveryId"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(delivery)
 }
 
+func (h *Handlers) RedeliverWebhook(c *fiber.Ctx) error {
+	tenant := tenantID(c)
+	if tenant == nil {
+		return errx.Unauthorized("Webhooks require a tenant")
+	}
+
+	delivery, err := h.webhookSvc.Redeliver(c.Context(), *tenant, c.Params("deliveryId"))
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusAccepted).JSON(delivery)
+}
+
+// tenantID returns the caller's tenant, or nil for requests without one.
+func tenantID(c *fiber.Ctx) *string {
+	authCtx, ok := auth.GetAuthContext(c)
+	if !ok || authCtx.TenantID.IsEmpty() {
+		return nil
+	}
+	id := authCtx.TenantID.String()
+	return &id
+}
+
+// setETag exposes a revision as the response's entity tag.
+func setETag(c *fiber.Ctx, revision int) {
+	c.Set(fiber.HeaderETag, `"`+strconv.Itoa(revision)+`"`)
+}
+
+// parseIfMatch reads the revision from an If-Match header set to an ETag
+// from setETag. It returns nil when the header is absent or "*".
+func parseIfMatch(c *fiber.Ctx) (*int, error) {
+	v := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if v == "" || v == "*" {
+		return nil, nil
+	}
+	v = strings.Trim(strings.TrimPrefix(v, "W/"), `"`)
+	revision, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, errx.Validation("If-Match must be an ETag returned by this API").
+			WithDetail("if_match", c.Get(fiber.HeaderIfMatch))
+	}
+	return &revision, nil
+}
@@ -1,29 +1,90 @@
 package diveinspectcontainer
 
 import (
+	"context"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Abraxas-365/divi/pkg/ai/aicache"
+	"github.com/Abraxas-365/divi/pkg/ai/ailimit"
+	"github.com/Abraxas-365/divi/pkg/ai/aiusage"
+	"github.com/Abraxas-365/divi/pkg/ai/document"
+	"github.com/Abraxas-365/divi/pkg/ai/embedding"
 	"github.com/Abraxas-365/divi/pkg/ai/llm"
+	"github.com/Abraxas-365/divi/pkg/ai/llm/routerx"
+	"github.com/Abraxas-365/divi/pkg/ai/providers/aianthropic"
+	"github.com/Abraxas-365/divi/pkg/ai/providers/aifake"
+	"github.com/Abraxas-365/divi/pkg/ai/providers/aiollama"
 	"github.com/Abraxas-365/divi/pkg/ai/providers/aiopenai"
+	"github.com/Abraxas-365/divi/pkg/ai/vstore"
+	"github.com/Abraxas-365/divi/pkg/ai/vstore/providers/vstmemory"
+	"github.com/Abraxas-365/divi/pkg/audit"
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
 	"github.com/Abraxas-365/divi/pkg/diveinspect/diveinspectapi"
 	"github.com/Abraxas-365/divi/pkg/diveinspect/diveinspectinfra"
 	"github.com/Abraxas-365/divi/pkg/diveinspect/diveinspectsrv"
 	"github.com/Abraxas-365/divi/pkg/fsx"
 	"github.com/Abraxas-365/divi/pkg/logx"
 	"github.com/jmoiron/sqlx"
-	"github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/option"
+	"github.com/redis/go-redis/v9"
 )
 
 type Deps struct {
 	DB         *sqlx.DB
 	FileSystem fsx.FileSystem
+
+	// Redis backs the AI response cache when AI_CACHE=redis, and shares AI
+	// rate limits across instances
+	Redis *redis.Client
+
+	// Meter prices and budgets every AI call; nil disables metering
+	Meter aiusage.Meter
+
+	// Audit receives the trail of changes to vehicles, inspections and
+	// findings, and serves vehicle history
+	Audit audit.Log
 }
 
 type Container struct {
 	Handlers *diveinspectapi.Handlers
+
+	// InventorySearch owns the semantic index of published vehicles
+	InventorySearch *diveinspectsrv.InventorySearchService
+
+	// Background workers
+	ImportService   *diveinspectsrv.ImportService
+	EnrichmentQueue *diveinspectsrv.EnrichmentQueue
+	Webhooks        *diveinspectsrv.WebhookService
+	Retention       *diveinspectsrv.RetentionService
+
+	// AIRouter spreads chat calls over the fallback providers; nil when
+	// AI_FALLBACK_PROVIDERS is unset
+	AIRouter *routerx.Router
+
+	// AIVisionRouter does the same for calls with photos, over the fallback
+	// providers that read images; nil when there are none
+	AIVisionRouter *routerx.Router
+
+	// LLMCache and EmbeddingCache answer repeated AI calls; nil when
+	// AI_CACHE is unset
+	LLMCache       *aicache.CachedLLM
+	EmbeddingCache *aicache.CachedEmbedder
 }
 
+// inventoryEmbeddingDims matches text-embedding-3-small
+const inventoryEmbeddingDims = 1536
+
+// ollamaEmbeddingDims matches nomic-embed-text, the default local embedding
+// model
+const ollamaEmbeddingDims = 768
+
+// aiCacheMemoryEntries bounds the in-process AI cache
+const aiCacheMemoryEntries = 10_000
+
 func New(deps Deps) *Container {
 	logx.Info("Initializing DiveInspect container...")
 
@@ -37,31 +98,151 @@ func New(deps Deps) *Container {
 	findingRepo := diveinspectinfra.NewPostgresInspectionFindingRepository(deps.DB)
 	photoRepo := diveinspectinfra.NewPostgresInspectionPhotoRepository(deps.DB)
 	listingRepo := diveinspectinfra.NewPostgresGeneratedListingRepository(deps.DB)
+	importJobRepo := diveinspectinfra.NewPostgresVehicleImportJobRepository(deps.DB)
+	brandRepo := diveinspectinfra.NewPostgresBrandSettingsRepository(deps.DB)
+	variantRepo := diveinspectinfra.NewPostgresListingVariantRepository(deps.DB)
+	catalogRepo := diveinspectinfra.NewPostgresSpecsCatalogRepository(deps.DB)
+	stepRepo := diveinspectinfra.NewPostgresEnrichmentStepRepository(deps.DB)
+	eventRepo := diveinspectinfra.NewPostgresDomainEventRepository(deps.DB)
+	webhookEndpointRepo := diveinspectinfra.NewPostgresWebhookEndpointRepository(deps.DB)
+	webhookDeliveryRepo := diveinspectinfra.NewPostgresWebhookDeliveryRepository(deps.DB)
+	txManager := diveinspectinfra.NewPostgresTxManager(deps.DB)
 
 	// ── AI Providers ─────────────────────────────────────────────────────
-	openaiAPIKey := os.Getenv("OPENAI_API_KEY")
+	ai := newAIProviders()
+
+	// Rate limiting, fallback routing, then response caching, wrap the
+	// providers. Limits apply to the primary providers' accounts only; vision
+	// shares the chat limiter when both run on the same account.
+	limiter := newAILimiter(ai.name, deps.Redis)
+	if limiter != nil {
+		ai.llm = ailimit.NewLimitedLLM(ai.llm, limiter)
+		ai.embedder = ailimit.NewLimitedEmbedder(ai.embedder, limiter)
+	}
+	visionLimiter := limiter
+	if ai.visionName != ai.name {
+		visionLimiter = newAILimiter(ai.visionName, deps.Redis)
+	}
+	if visionLimiter != nil {
+		ai.vision = ailimit.NewLimitedLLM(ai.vision, visionLimiter)
+	}
+	var chatLLM llm.LLM = ai.llm
+	if c.AIRouter = newAIRouter(ai); c.AIRouter != nil {
+		chatLLM = c.AIRouter
+	}
+	var embedder embedding.Embedder = ai.embedder
+	if store := newAICacheStore(deps.Redis); store != nil {
+		ttl := aicache.DefaultTTL
+		if raw := os.Getenv("AI_CACHE_TTL"); raw != "" {
+			if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+				ttl = d
+			} else {
+				logx.Warnf("Invalid AI_CACHE_TTL %q, using %s", raw, aicache.DefaultTTL)
+			}
+		}
+		opts := []aicache.CacheOption{aicache.WithTTL(ttl), aicache.WithNamespace(ai.name)}
+		c.LLMCache = aicache.NewCachedLLM(chatLLM, store, opts...)
+		c.EmbeddingCache = aicache.NewCachedEmbedder(embedder, store, opts...)
+		chatLLM, embedder = c.LLMCache, c.EmbeddingCache
+	}
 
 	// LLM client for enrichment & listing generation
-	openaiProvider := aiopenai.NewOpenAIProvider(openaiAPIKey)
-	llmClient := llm.NewClient(openaiProvider)
+	llmClient := llm.NewClient(chatLLM).WithMeter(deps.Meter)
+
+	// LLM client for inspection photos and equipment verification. Photos
+	// are not cached: every upload is new.
+	var visionLLM llm.LLM = ai.vision
+	if c.AIVisionRouter = newVisionRouter(ai); c.AIVisionRouter != nil {
+		visionLLM = c.AIVisionRouter
+	}
+	visionClient := llm.NewClient(visionLLM).WithMeter(deps.Meter)
 
-	// Direct OpenAI client for vision (multi-modal)
-	openaiClient := openai.NewClient(option.WithAPIKey(openaiAPIKey))
+	// Embeddings + vector store for semantic inventory search. The index is
+	// in-memory and rebuilt from published vehicles on startup.
+	inventoryEmbedder := document.NewEmbedder(
+		embedding.NewClient(embedder).WithMeter(deps.Meter),
+		ai.embeddingDims,
+		embedding.WithModel(ai.embeddingModel),
+	)
+	inventoryVectors := vstore.NewClient(vstmemory.NewMemoryVectorStore(ai.embeddingDims, vstore.MetricCosine))
+	inventoryStore := document.NewDocumentStore(inventoryVectors, inventoryEmbedder).WithNamespace("inventory")
 
 	// ── Services ─────────────────────────────────────────────────────────
+	// State changes write their events to the outbox in the same transaction;
+	// the webhook dispatcher delivers them
+	events := diveinspectsrv.NewEventPublisher(eventRepo)
+	c.Webhooks = diveinspectsrv.NewWebhookService(
+		webhookEndpointRepo,
+		webhookDeliveryRepo,
+		eventRepo,
+		txManager,
+		5*time.Second,
+	)
+
+	c.InventorySearch = diveinspectsrv.NewInventorySearchService(
+		vehicleRepo,
+		specsRepo,
+		equipmentRepo,
+		listingRepo,
+		variantRepo,
+		inventoryStore,
+	)
+
+	// Tenants without brand settings fall back to the deployment defaults
+	listingSvc := diveinspectsrv.NewListingService(
+		llmClient,
+		specsRepo,
+		equipmentRepo,
+		variantRepo,
+		brandRepo,
+		defaultBrandSettings(),
+		vehicleRepo,
+		c.InventorySearch,
+		txManager,
+		events,
+	)
+
+	// Curated catalog first; the LLM only fills the fields it lacks
+	specsChain := diveinspectsrv.NewSpecsChain(
+		diveinspectsrv.NewCatalogSpecsSource(catalogRepo),
+		diveinspectsrv.NewLLMSpecsSource(llmClient),
+	)
+	catalogSvc := diveinspectsrv.NewSpecsCatalogService(catalogRepo)
+
 	enrichmentSvc := diveinspectsrv.NewEnrichmentService(
 		llmClient,
 		specsRepo,
 		equipmentRepo,
 		listingRepo,
+		listingSvc,
+		vehicleRepo,
+		specsChain,
+		stepRepo,
+		c.InventorySearch,
+		txManager,
+		events,
+		deps.Audit,
 	)
 
 	visionSvc := diveinspectsrv.NewVisionService(
-		openaiClient,
+		visionClient,
+		listingSvc,
 		deps.FileSystem,
 		inspectionRepo,
 		findingRepo,
 		photoRepo,
+		txManager,
+		events,
+		deps.Audit,
+	)
+
+	verifySvc := diveinspectsrv.NewEquipmentVerificationService(
+		visionClient,
+		deps.FileSystem,
+		inspectionRepo,
+		photoRepo,
+		equipmentRepo,
+		enrichmentSvc,
 	)
 
 	inspectionSvc := diveinspectsrv.NewInspectionService(
@@ -71,6 +252,9 @@ func New(deps Deps) *Container {
 		vehicleRepo,
 		deps.FileSystem,
 		visionSvc,
+		txManager,
+		events,
+		deps.Audit,
 	)
 
 	vehicleSvc := diveinspectsrv.NewVehicleService(
@@ -81,6 +265,37 @@ func New(deps Deps) *Container {
 		inspectionRepo,
 		findingRepo,
 		photoRepo,
+		c.InventorySearch,
+		stepRepo,
+		txManager,
+		events,
+		deps.Audit,
+	)
+
+	c.EnrichmentQueue = diveinspectsrv.NewEnrichmentQueue(
+		vehicleRepo,
+		enrichmentSvc,
+		500,
+	)
+
+	c.ImportService = diveinspectsrv.NewImportService(
+		importJobRepo,
+		vehicleRepo,
+		vehicleSvc,
+		c.EnrichmentQueue,
+		deps.FileSystem,
+		5*time.Second,
+	)
+
+	// Deleted vehicles stay restorable for the retention period, then their
+	// rows and storage are purged
+	c.Retention = diveinspectsrv.NewRetentionService(
+		vehicleRepo,
+		inspectionRepo,
+		deps.FileSystem,
+		txManager,
+		vehicleRetention(),
+		time.Hour,
 	)
 
 	reportSvc := diveinspectsrv.NewReportService(
@@ -98,8 +313,376 @@ func New(deps Deps) *Container {
 		enrichmentSvc,
 		inspectionSvc,
 		reportSvc,
+		c.InventorySearch,
+		c.ImportService,
+		verifySvc,
+		listingSvc,
+		catalogSvc,
+		c.Webhooks,
 	)
 
 	logx.Info("DiveInspect container initialized")
 	return c
 }
+
+// StartBackgroundServices starts DiveInspect background workers.
+func (c *Container) StartBackgroundServices(ctx context.Context) {
+	go func() {
+		if err := c.InventorySearch.ReindexPublished(ctx); err != nil {
+			logx.Errorf("Failed to build inventory search index: %v", err)
+		}
+	}()
+	logx.Info("  ✅ DiveInspect inventory indexing started")
+
+	go c.ImportService.Start(ctx)
+	go c.EnrichmentQueue.Start(ctx)
+	logx.Info("  ✅ DiveInspect import worker and enrichment queue started")
+
+	go c.Webhooks.Start(ctx)
+	logx.Info("  ✅ DiveInspect webhook dispatcher started")
+
+	go c.Retention.Start(ctx)
+	logx.Info("  ✅ DiveInspect vehicle retention job started")
+}
+
+// aiProviders are the model backends the services call.
+type aiProviders struct {
+	name           string
+	llm            llm.LLM
+	embedder       embedding.Embedder
+	embeddingModel string
+	embeddingDims  int
+
+	// vision serves calls with photos, on the account named visionName
+	vision     llm.LLM
+	visionName string
+}
+
+// newAIProviders picks the backends from AI_PROVIDER:
+//
+//   - "fake" answers every call from a script so the server runs offline
+//     without keys; replies can be replayed from the golden file in
+//     AI_FAKE_GOLDEN.
+//   - "ollama" runs chat and embeddings on the self-hosted server at
+//     OLLAMA_HOST with OLLAMA_MODEL and OLLAMA_EMBEDDING_MODEL. Vision stays
+//     on OpenAI.
+//   - "anthropic" runs chat on Claude with ANTHROPIC_API_KEY and
+//     ANTHROPIC_MODEL. Embeddings and vision stay on OpenAI.
+//   - anything else uses OpenAI.
+//
+// AI_FALLBACK_PROVIDERS adds providers chat and vision fall back to; see
+// newAIRouter and newVisionRouter.
+func newAIProviders() aiProviders {
+	apiKey := os.Getenv("OPENAI_API_KEY")
+
+	switch provider := os.Getenv("AI_PROVIDER"); provider {
+	case "fake":
+		return newFakeAIProviders()
+
+	case "ollama":
+		embeddingModel := getEnv("OLLAMA_EMBEDDING_MODEL", aiollama.DefaultEmbeddingModel)
+		dims := ollamaEmbeddingDims
+		if raw := os.Getenv("OLLAMA_EMBEDDING_DIMS"); raw != "" {
+			if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+				dims = n
+			} else {
+				logx.Warnf("Invalid OLLAMA_EMBEDDING_DIMS %q, using %d", raw, ollamaEmbeddingDims)
+			}
+		}
+
+		ollama := aiollama.NewOllamaProvider(
+			aiollama.WithModel(ollamaModel()),
+			aiollama.WithEmbeddingModel(embeddingModel),
+		)
+		logx.Info("AI_PROVIDER=ollama: chat and embeddings run on the local model server")
+		return aiProviders{
+			name:           "ollama",
+			llm:            ollama,
+			embedder:       ollama,
+			embeddingModel: embeddingModel,
+			embeddingDims:  dims,
+			vision:         newOpenAIChatProvider(apiKey, hasFallback("openai", acceptsImages)),
+			visionName:     "openai",
+		}
+
+	case "anthropic":
+		openaiProvider := aiopenai.NewOpenAIProvider(apiKey)
+		anthropic := aianthropic.NewAnthropicProvider(
+			os.Getenv("ANTHROPIC_API_KEY"),
+			aianthropic.WithModel(anthropicModel()),
+		)
+		logx.Info("AI_PROVIDER=anthropic: chat runs on Claude, embeddings and vision on OpenAI")
+		return aiProviders{
+			name:           "anthropic",
+			llm:            anthropic,
+			embedder:       openaiProvider,
+			embeddingModel: "text-embedding-3-small",
+			embeddingDims:  inventoryEmbeddingDims,
+			vision:         newOpenAIChatProvider(apiKey, hasFallback("openai", acceptsImages)),
+			visionName:     "openai",
+		}
+
+	default:
+		if provider != "" && provider != "openai" {
+			logx.Warnf("Unknown AI_PROVIDER %q, using OpenAI", provider)
+		}
+		return aiProviders{
+			name:           "openai",
+			llm:            newOpenAIChatProvider(apiKey, hasFallback("openai", anyProvider)),
+			embedder:       aiopenai.NewOpenAIProvider(apiKey),
+			embeddingModel: "text-embedding-3-small",
+			embeddingDims:  inventoryEmbeddingDims,
+			vision:         newOpenAIChatProvider(apiKey, hasFallback("openai", acceptsImages)),
+			visionName:     "openai",
+		}
+	}
+}
+
+// newAIRouter puts the chat provider first in a router that falls back to
+// the providers listed in AI_FALLBACK_PROVIDERS, e.g. "anthropic,ollama".
+// It returns nil when there are none, and for the fake provider.
+func newAIRouter(ai aiProviders) *routerx.Router {
+	return newFallbackRouter("AI chat", ai.name, ai.llm, anyProvider)
+}
+
+// newVisionRouter does the same for calls with photos, falling back only to
+// the listed providers whose models read images.
+func newVisionRouter(ai aiProviders) *routerx.Router {
+	return newFallbackRouter("AI vision", ai.visionName, ai.vision, acceptsImages)
+}
+
+// newFallbackRouter routes over primary and the providers in
+// AI_FALLBACK_PROVIDERS that accept allows.
+func newFallbackRouter(label, name string, primary llm.LLM, allow func(name string) bool) *routerx.Router {
+	raw := os.Getenv("AI_FALLBACK_PROVIDERS")
+	if raw == "" || name == "fake" {
+		return nil
+	}
+
+	backends := []routerx.Backend{{Name: name, LLM: primary, Models: chatModels(name)}}
+	for _, fallback := range strings.Split(raw, ",") {
+		fallback = strings.TrimSpace(fallback)
+		if fallback == "" || fallback == name || !allow(fallback) {
+			continue
+		}
+		backend, ok := newFallbackBackend(fallback)
+		if !ok {
+			logx.Warnf("Unknown provider %q in AI_FALLBACK_PROVIDERS, skipping", fallback)
+			continue
+		}
+		backends = append(backends, backend)
+	}
+	if len(backends) == 1 {
+		return nil
+	}
+
+	names := make([]string, len(backends))
+	for i, b := range backends {
+		names[i] = b.Name
+	}
+	logx.Infof("%s routed over %s", label, strings.Join(names, " → "))
+	return routerx.New(backends)
+}
+
+// hasFallback reports whether AI_FALLBACK_PROVIDERS gives name's calls a
+// provider allow accepts to fall back to, i.e. whether they are routed.
+func hasFallback(name string, allow func(name string) bool) bool {
+	for _, fallback := range strings.Split(os.Getenv("AI_FALLBACK_PROVIDERS"), ",") {
+		fallback = strings.TrimSpace(fallback)
+		if fallback != name && slices.Contains(fallbackProviders, fallback) && allow(fallback) {
+			return true
+		}
+	}
+	return false
+}
+
+// newOpenAIChatProvider builds the OpenAI provider for chat or vision calls.
+// When they are routed the router does the retrying, so the client doesn't:
+// its own retries would hold a call on a failing provider instead of
+// falling back.
+func newOpenAIChatProvider(apiKey string, routed bool) *aiopenai.OpenAIProvider {
+	if routed {
+		return aiopenai.NewOpenAIProvider(apiKey, option.WithMaxRetries(0))
+	}
+	return aiopenai.NewOpenAIProvider(apiKey)
+}
+
+func anyProvider(string) bool { return true }
+
+// acceptsImages reports whether the provider's configured model reads
+// images. Ollama's default model doesn't.
+func acceptsImages(name string) bool {
+	return name == "openai" || name == "anthropic"
+}
+
+// fallbackProviders are the names newFallbackBackend knows
+var fallbackProviders = []string{"openai", "anthropic", "ollama"}
+
+// newFallbackBackend builds a chat-only provider for the router. The
+// router does the retrying, so the OpenAI client doesn't.
+func newFallbackBackend(name string) (routerx.Backend, bool) {
+	backend := routerx.Backend{Name: name, Models: chatModels(name)}
+	switch name {
+	case "openai":
+		backend.LLM = aiopenai.NewOpenAIProvider(os.Getenv("OPENAI_API_KEY"), option.WithMaxRetries(0))
+	case "anthropic":
+		backend.LLM = aianthropic.NewAnthropicProvider(os.Getenv("ANTHROPIC_API_KEY"), aianthropic.WithModel(anthropicModel()))
+	case "ollama":
+		backend.LLM = aiollama.NewOllamaProvider(aiollama.WithModel(ollamaModel()))
+	default:
+		return routerx.Backend{}, false
+	}
+	return backend, true
+}
+
+// chatModels sends the configured model to providers that can't serve the
+// OpenAI model names callers may ask for.
+func chatModels(name string) map[string]string {
+	switch name {
+	case "anthropic":
+		return map[string]string{"*": anthropicModel()}
+	case "ollama":
+		return map[string]string{"*": ollamaModel()}
+	default:
+		return nil
+	}
+}
+
+func anthropicModel() string {
+	return getEnv("ANTHROPIC_MODEL", aianthropic.DefaultModel)
+}
+
+func ollamaModel() string {
+	return getEnv("OLLAMA_MODEL", aiollama.DefaultModel)
+}
+
+// newAICacheStore picks the AI response cache from AI_CACHE: "redis" shares
+// it across instances, "memory" keeps it in process. It returns nil when
+// caching is off.
+func newAICacheStore(client *redis.Client) aicache.Store {
+	switch backend := os.Getenv("AI_CACHE"); backend {
+	case "":
+		return nil
+	case "redis":
+		if client == nil {
+			logx.Warn("AI_CACHE=redis without a Redis client, AI responses are not cached")
+			return nil
+		}
+		logx.Info("AI responses cached in Redis")
+		return aicache.NewRedisStore(client, "")
+	case "memory":
+		logx.Info("AI responses cached in memory")
+		return aicache.NewMemoryStore(aiCacheMemoryEntries)
+	default:
+		logx.Warnf("Unknown AI_CACHE %q, AI responses are not cached", backend)
+		return nil
+	}
+}
+
+// newAILimiter keeps calls to the named provider under AI_RATE_LIMIT_RPM
+// requests and AI_RATE_LIMIT_TPM estimated tokens per minute, with at most
+// AI_MAX_CONCURRENT in flight; each provider account gets its own limiter. Limits are shared through Redis when there is
+// a client. It returns nil when no limit is set.
+func newAILimiter(name string, client *redis.Client) *ailimit.Limiter {
+	limits := ailimit.Limits{
+		RequestsPerMinute: envLimit("AI_RATE_LIMIT_RPM"),
+		TokensPerMinute:   envLimit("AI_RATE_LIMIT_TPM"),
+		MaxConcurrent:     envLimit("AI_MAX_CONCURRENT"),
+	}
+	if limits == (ailimit.Limits{}) {
+		return nil
+	}
+
+	var store ailimit.Store = ailimit.NewMemoryStore()
+	if client != nil {
+		store = ailimit.NewRedisStore(client, "")
+	}
+	logx.Infof("AI calls to %s limited to %d rpm, %d tpm, %d concurrent (0 is unlimited)",
+		name, limits.RequestsPerMinute, limits.TokensPerMinute, limits.MaxConcurrent)
+	return ailimit.NewLimiter(store, name, limits)
+}
+
+// envLimit reads a non-negative limit; unset or invalid is 0, no limit.
+func envLimit(key string) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		logx.Warnf("Invalid %s %q, not limiting", key, raw)
+		return 0
+	}
+	return n
+}
+
+// newFakeAIProviders answers every AI call from the scripted fake provider.
+func newFakeAIProviders() aiProviders {
+	// Unscripted prompts get an empty JSON object, or the empty document of
+	// the schema they ask for, which every service parses as "nothing found"
+	opts := []aifake.ProviderOption{
+		aifake.WithFallback(aifake.EmptyForSchema("{}")),
+		aifake.WithEmbeddingDimensions(inventoryEmbeddingDims),
+	}
+	if path := os.Getenv("AI_FAKE_GOLDEN"); path != "" {
+		golden, err := aifake.LoadGoldenFile(path)
+		if err != nil {
+			logx.Fatalf("Failed to load AI golden file: %v", err)
+		}
+		opts = append(opts, golden)
+	}
+
+	fake := aifake.NewFakeProvider(opts...)
+	logx.Warn("AI_PROVIDER=fake: AI calls are answered by the scripted fake provider")
+	return aiProviders{
+		name:           "fake",
+		llm:            fake,
+		embedder:       fake,
+		embeddingModel: "text-embedding-3-small",
+		embeddingDims:  inventoryEmbeddingDims,
+		vision:         fake,
+		visionName:     "fake",
+	}
+}
+
+// getEnv returns the environment variable or fallback when it is unset.
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// defaultRetentionDays is how long a deleted vehicle can be restored when
+// DIVEINSPECT_RETENTION_DAYS is unset.
+const defaultRetentionDays = 30
+
+// vehicleRetention reads how long deleted vehicles are kept before purging.
+func vehicleRetention() time.Duration {
+	days := defaultRetentionDays
+	if raw := os.Getenv("DIVEINSPECT_RETENTION_DAYS"); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n >= 0 {
+			days = n
+		} else {
+			logx.Warnf("Invalid DIVEINSPECT_RETENTION_DAYS %q, using %d", raw, defaultRetentionDays)
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// defaultBrandSettings reads the deployment-wide branding from the environment.
+func defaultBrandSettings() diveinspect.BrandSettings {
+	b := diveinspect.BrandSettings{
+		DealerName:    os.Getenv("DIVEINSPECT_DEALER_NAME"),
+		ChannelStyles: diveinspect.ChannelStyles{},
+	}
+	if warranty := os.Getenv("DIVEINSPECT_WARRANTY_TEXT"); warranty != "" {
+		b.WarrantyText = &warranty
+	}
+	for _, claim := range strings.Split(os.Getenv("DIVEINSPECT_FORBIDDEN_CLAIMS"), ";") {
+		if claim = strings.TrimSpace(claim); claim != "" {
+			b.ForbiddenClaims = append(b.ForbiddenClaims, claim)
+		}
+	}
+	return b
+}
@@ -0,0 +1,41 @@
+package diveinspectinfra
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/jmoiron/sqlx"
+)
+
+type PostgresBrandSettingsRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresBrandSettingsRepository(db *sqlx.DB) *PostgresBrandSettingsRepository {
+	return &PostgresBrandSettingsRepository{db: db}
+}
+
+func (r *PostgresBrandSettingsRepository) GetByTenantID(ctx context.Context, tenantID string) (*diveinspect.BrandSettings, error) {
+	var b diveinspect.BrandSettings
+	query := `SELECT * FROM brand_settings WHERE tenant_id = $1`
+	if err := r.db.GetContext(ctx, &b, query, tenantID); err != nil {
+		return nil, errx.NotFound("Brand settings not found").WithDetail("tenant_id", tenantID)
+	}
+	return &b, nil
+}
+
+func (r *PostgresBrandSettingsRepository) Upsert(ctx context.Context, b *diveinspect.BrandSettings) error {
+	query := `
+		INSERT INTO brand_settings (tenant_id, dealer_name, warranty_text, forbidden_claims, channel_styles)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			dealer_name = EXCLUDED.dealer_name,
+			warranty_text = EXCLUDED.warranty_text,
+			forbidden_claims = EXCLUDED.forbidden_claims,
+			channel_styles = EXCLUDED.channel_styles
+		RETURNING created_at, updated_at`
+	return r.db.QueryRowContext(ctx, query,
+		b.TenantID, b.DealerName, b.WarrantyText, b.ForbiddenClaims, b.ChannelStyles,
+	).Scan(&b.CreatedAt, &b.UpdatedAt)
+}
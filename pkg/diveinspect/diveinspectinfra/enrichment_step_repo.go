@@ -0,0 +1,44 @@
+package diveinspectinfra
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/jmoiron/sqlx"
+)
+
+type PostgresEnrichmentStepRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresEnrichmentStepRepository(db *sqlx.DB) *PostgresEnrichmentStepRepository {
+	return &PostgresEnrichmentStepRepository{db: db}
+}
+
+func (r *PostgresEnrichmentStepRepository) Upsert(ctx context.Context, s *diveinspect.VehicleEnrichmentStep) error {
+	query := `
+		INSERT INTO vehicle_enrichment_steps (vehicle_id, step, status, error, attempts, started_at, completed_at)
+		VALUES ($1, $2, $3, $4, CASE WHEN $3 = 'running' THEN 1 ELSE 0 END, $5, $6)
+		ON CONFLICT (vehicle_id, step) DO UPDATE SET
+			status = EXCLUDED.status,
+			error = EXCLUDED.error,
+			attempts = vehicle_enrichment_steps.attempts + CASE WHEN EXCLUDED.status = 'running' THEN 1 ELSE 0 END,
+			started_at = COALESCE(EXCLUDED.started_at, vehicle_enrichment_steps.started_at),
+			completed_at = EXCLUDED.completed_at
+		RETURNING attempts, started_at, updated_at`
+	return getExecutor(ctx, r.db).QueryRowxContext(ctx, query,
+		s.VehicleID, s.Step, s.Status, s.Error, s.StartedAt, s.CompletedAt,
+	).Scan(&s.Attempts, &s.StartedAt, &s.UpdatedAt)
+}
+
+func (r *PostgresEnrichmentStepRepository) ListByVehicleID(ctx context.Context, vehicleID string) ([]diveinspect.VehicleEnrichmentStep, error) {
+	var steps []diveinspect.VehicleEnrichmentStep
+	query := `
+		SELECT * FROM vehicle_enrichment_steps
+		WHERE vehicle_id = $1
+		ORDER BY array_position(ARRAY['specs', 'equipment', 'listing', 'json-ld'], step::text)`
+	if err := r.db.SelectContext(ctx, &steps, query, vehicleID); err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
@@ -0,0 +1,97 @@
+package diveinspectinfra
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type PostgresVehicleImportJobRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresVehicleImportJobRepository(db *sqlx.DB) *PostgresVehicleImportJobRepository {
+	return &PostgresVehicleImportJobRepository{db: db}
+}
+
+func (r *PostgresVehicleImportJobRepository) Create(ctx context.Context, j *diveinspect.VehicleImportJob) error {
+	if j.ID == "" {
+		j.ID = uuid.New().String()
+	}
+	query := `
+		INSERT INTO vehicle_import_jobs (id, tenant_id, file_name, file_path, format, status, headers, mapping, enrich_created, total_rows, results)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING created_at, updated_at`
+	return r.db.QueryRowContext(ctx, query,
+		j.ID, j.TenantID, j.FileName, j.FilePath, j.Format, j.Status, j.Headers, j.Mapping,
+		j.EnrichCreated, j.TotalRows, j.Results,
+	).Scan(&j.CreatedAt, &j.UpdatedAt)
+}
+
+func (r *PostgresVehicleImportJobRepository) GetByID(ctx context.Context, id string) (*diveinspect.VehicleImportJob, error) {
+	var j diveinspect.VehicleImportJob
+	query := `SELECT * FROM vehicle_import_jobs WHERE id = $1`
+	if err := r.db.GetContext(ctx, &j, query, id); err != nil {
+		return nil, errx.NotFound("Import job not found").WithDetail("id", id)
+	}
+	return &j, nil
+}
+
+func (r *PostgresVehicleImportJobRepository) Update(ctx context.Context, j *diveinspect.VehicleImportJob) error {
+	query := `
+		UPDATE vehicle_import_jobs SET
+			status = $2, mapping = $3, enrich_created = $4, total_rows = $5,
+			created_count = $6, duplicate_count = $7, failed_count = $8,
+			results = $9, error = $10, completed_at = $11
+		WHERE id = $1
+		RETURNING updated_at`
+	return r.db.QueryRowContext(ctx, query,
+		j.ID, j.Status, j.Mapping, j.EnrichCreated, j.TotalRows,
+		j.CreatedCount, j.DuplicateCount, j.FailedCount,
+		j.Results, j.Error, j.CompletedAt,
+	).Scan(&j.UpdatedAt)
+}
+
+func (r *PostgresVehicleImportJobRepository) ClaimNext(ctx context.Context, lease time.Duration) (*diveinspect.VehicleImportJob, error) {
+	var j diveinspect.VehicleImportJob
+	query := `
+		UPDATE vehicle_import_jobs SET
+			status = $1, locked_until = CURRENT_TIMESTAMP + make_interval(secs => $3)
+		WHERE id = (
+			SELECT id FROM vehicle_import_jobs
+			WHERE status = $2
+			   OR (status = $1 AND (locked_until IS NULL OR locked_until < CURRENT_TIMESTAMP))
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`
+	err := r.db.GetContext(ctx, &j, query, diveinspect.ImportRunning, diveinspect.ImportQueued, lease.Seconds())
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+func (r *PostgresVehicleImportJobRepository) ExtendLease(ctx context.Context, id string, lease time.Duration) error {
+	query := `
+		UPDATE vehicle_import_jobs SET locked_until = CURRENT_TIMESTAMP + make_interval(secs => $3)
+		WHERE id = $1 AND status = $2`
+	result, err := r.db.ExecContext(ctx, query, id, diveinspect.ImportRunning, lease.Seconds())
+	if err != nil {
+		return err
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return errx.NotFound("Running import job not found").WithDetail("id", id)
+	}
+	return nil
+}
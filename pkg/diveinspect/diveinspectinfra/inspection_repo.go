@@ -2,9 +2,9 @@ package diveinspectinfra
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/Abraxas-365/divi/pkg/diveinspect"
-	"github.com/Abraxas-365/divi/pkg/errx"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
@@ -29,7 +29,7 @@ func (r *PostgresInspectionRepository) Create(ctx context.Context, i *diveinspec
 		INSERT INTO inspections (id, vehicle_id, inspector_name, inspector_branch, score_overall, score_exterior, score_interior, score_mechanical, score_tires, photos_count, findings_count, status, pdf_url, inspected_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		RETURNING created_at, updated_at`
-	return r.db.QueryRowContext(ctx, query,
+	return getExecutor(ctx, r.db).QueryRowxContext(ctx, query,
 		i.ID, i.VehicleID, i.InspectorName, i.InspectorBranch,
 		i.ScoreOverall, i.ScoreExterior, i.ScoreInterior, i.ScoreMechanical, i.ScoreTires,
 		i.PhotosCount, i.FindingsCount, i.Status, i.PDFURL, i.InspectedAt,
@@ -40,7 +40,7 @@ func (r *PostgresInspectionRepository) GetByID(ctx context.Context, id string) (
 	var i diveinspect.Inspection
 	query := `SELECT * FROM inspections WHERE id = $1`
 	if err := r.db.GetContext(ctx, &i, query, id); err != nil {
-		return nil, errx.NotFound("Inspection not found").WithDetail("id", id)
+		return nil, diveinspect.NewError(diveinspect.ErrInspectionNotFound).WithDetail("id", id)
 	}
 	return &i, nil
 }
@@ -49,26 +49,62 @@ func (r *PostgresInspectionRepository) GetByVehicleID(ctx context.Context, vehic
 	var i diveinspect.Inspection
 	query := `SELECT * FROM inspections WHERE vehicle_id = $1 ORDER BY created_at DESC LIMIT 1`
 	if err := r.db.GetContext(ctx, &i, query, vehicleID); err != nil {
-		return nil, errx.NotFound("Inspection not found for vehicle").WithDetail("vehicle_id", vehicleID)
+		return nil, diveinspect.NewError(diveinspect.ErrInspectionNotFound).WithDetail("vehicle_id", vehicleID)
 	}
 	return &i, nil
 }
 
+func (r *PostgresInspectionRepository) ListByVehicleID(ctx context.Context, vehicleID string) ([]diveinspect.Inspection, error) {
+	var inspections []diveinspect.Inspection
+	query := `SELECT * FROM inspections WHERE vehicle_id = $1 ORDER BY created_at`
+	if err := r.db.SelectContext(ctx, &inspections, query, vehicleID); err != nil {
+		return nil, err
+	}
+	return inspections, nil
+}
+
 func (r *PostgresInspectionRepository) Update(ctx context.Context, i *diveinspect.Inspection) error {
 	query := `
 		UPDATE inspections SET
 			inspector_name = $2, inspector_branch = $3,
 			score_overall = $4, score_exterior = $5, score_interior = $6,
 			score_mechanical = $7, score_tires = $8,
-			photos_count = $9, findings_count = $10, status = $11,
-			pdf_url = $12, inspected_at = $13
-		WHERE id = $1
-		RETURNING updated_at`
-	return r.db.QueryRowContext(ctx, query,
+			findings_count = $9, status = $10, inspected_at = $11,
+			revision = revision + 1
+		WHERE id = $1 AND ($12 = 0 OR revision = $12)
+		RETURNING updated_at, revision`
+	exec := getExecutor(ctx, r.db)
+	err := exec.QueryRowxContext(ctx, query,
 		i.ID, i.InspectorName, i.InspectorBranch,
 		i.ScoreOverall, i.ScoreExterior, i.ScoreInterior, i.ScoreMechanical, i.ScoreTires,
-		i.PhotosCount, i.FindingsCount, i.Status, i.PDFURL, i.InspectedAt,
-	).Scan(&i.UpdatedAt)
+		i.FindingsCount, i.Status, i.InspectedAt, i.Revision,
+	).Scan(&i.UpdatedAt, &i.Revision)
+	if err == sql.ErrNoRows {
+		return revisionError(ctx, exec, "inspections", i.ID, diveinspect.NewError(diveinspect.ErrInspectionNotFound).WithDetail("id", i.ID))
+	}
+	return err
+}
+
+func (r *PostgresInspectionRepository) IncrementPhotosCount(ctx context.Context, id string) (int, error) {
+	var count int
+	query := `UPDATE inspections SET photos_count = photos_count + 1 WHERE id = $1 RETURNING photos_count`
+	err := getExecutor(ctx, r.db).QueryRowxContext(ctx, query, id).Scan(&count)
+	if err == sql.ErrNoRows {
+		return 0, diveinspect.NewError(diveinspect.ErrInspectionNotFound).WithDetail("id", id)
+	}
+	return count, err
+}
+
+func (r *PostgresInspectionRepository) SetPDFURL(ctx context.Context, id, pdfURL string) error {
+	result, err := getExecutor(ctx, r.db).ExecContext(ctx, `UPDATE inspections SET pdf_url = $2 WHERE id = $1`, id, pdfURL)
+	if err != nil {
+		return err
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return diveinspect.NewError(diveinspect.ErrInspectionNotFound).WithDetail("id", id)
+	}
+	return nil
 }
 
 func (r *PostgresInspectionRepository) Delete(ctx context.Context, id string) error {
@@ -78,7 +114,7 @@ func (r *PostgresInspectionRepository) Delete(ctx context.Context, id string) er
 	}
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return errx.NotFound("Inspection not found").WithDetail("id", id)
+		return diveinspect.NewError(diveinspect.ErrInspectionNotFound).WithDetail("id", id)
 	}
 	return nil
 }
@@ -102,26 +138,31 @@ func (r *PostgresInspectionFindingRepository) CreateBatch(ctx context.Context, f
 	query := `
 		INSERT INTO inspection_findings (id, inspection_id, photo_url, annotated_photo_url, zone, finding_type, severity, description, ai_confidence, confirmed_by_human)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
-	tx, err := r.db.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	for i := range findings {
-		if findings[i].ID == "" {
-			findings[i].ID = uuid.New().String()
-		}
-		_, err := tx.ExecContext(ctx, query,
-			findings[i].ID, findings[i].InspectionID, findings[i].PhotoURL, findings[i].AnnotatedPhotoURL,
-			findings[i].Zone, findings[i].FindingType, findings[i].Severity,
-			findings[i].Description, findings[i].AIConfidence, findings[i].ConfirmedByHuman,
-		)
-		if err != nil {
-			return err
+	return withTx(ctx, r.db, func(exec sqlx.ExtContext) error {
+		for i := range findings {
+			if findings[i].ID == "" {
+				findings[i].ID = uuid.New().String()
+			}
+			_, err := exec.ExecContext(ctx, query,
+				findings[i].ID, findings[i].InspectionID, findings[i].PhotoURL, findings[i].AnnotatedPhotoURL,
+				findings[i].Zone, findings[i].FindingType, findings[i].Severity,
+				findings[i].Description, findings[i].AIConfidence, findings[i].ConfirmedByHuman,
+			)
+			if err != nil {
+				return err
+			}
 		}
+		return nil
+	})
+}
+
+func (r *PostgresInspectionFindingRepository) GetByID(ctx context.Context, id string) (*diveinspect.InspectionFinding, error) {
+	var f diveinspect.InspectionFinding
+	query := `SELECT * FROM inspection_findings WHERE id = $1`
+	if err := sqlx.GetContext(ctx, getExecutor(ctx, r.db), &f, query, id); err != nil {
+		return nil, diveinspect.NewError(diveinspect.ErrFindingNotFound).WithDetail("id", id)
 	}
-	return tx.Commit()
+	return &f, nil
 }
 
 func (r *PostgresInspectionFindingRepository) GetByInspectionID(ctx context.Context, inspectionID string) ([]diveinspect.InspectionFinding, error) {
@@ -133,23 +174,23 @@ func (r *PostgresInspectionFindingRepository) GetByInspectionID(ctx context.Cont
 	return findings, nil
 }
 
+// Update saves f and bumps its revision. When f.Revision is set the row must
+// still be at that revision, otherwise the update fails with a conflict.
 func (r *PostgresInspectionFindingRepository) Update(ctx context.Context, f *diveinspect.InspectionFinding) error {
 	query := `
 		UPDATE inspection_findings SET
 			zone = $2, finding_type = $3, severity = $4, description = $5,
-			confirmed_by_human = $6
-		WHERE id = $1`
-	result, err := r.db.ExecContext(ctx, query,
-		f.ID, f.Zone, f.FindingType, f.Severity, f.Description, f.ConfirmedByHuman,
-	)
-	if err != nil {
-		return err
-	}
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
-		return errx.NotFound("Finding not found").WithDetail("id", f.ID)
+			confirmed_by_human = $6, revision = revision + 1
+		WHERE id = $1 AND ($7 = 0 OR revision = $7)
+		RETURNING inspection_id, revision`
+	exec := getExecutor(ctx, r.db)
+	err := exec.QueryRowxContext(ctx, query,
+		f.ID, f.Zone, f.FindingType, f.Severity, f.Description, f.ConfirmedByHuman, f.Revision,
+	).Scan(&f.InspectionID, &f.Revision)
+	if err == sql.ErrNoRows {
+		return revisionError(ctx, exec, "inspection_findings", f.ID, diveinspect.NewError(diveinspect.ErrFindingNotFound).WithDetail("id", f.ID))
 	}
-	return nil
+	return err
 }
 
 func (r *PostgresInspectionFindingRepository) Delete(ctx context.Context, id string) error {
@@ -177,7 +218,7 @@ func (r *PostgresInspectionPhotoRepository) Create(ctx context.Context, p *divei
 		INSERT INTO inspection_photos (id, inspection_id, photo_url, zone, sort_order)
 		VALUES ($1, $2, $3, $4, $5)
 		RETURNING uploaded_at`
-	return r.db.QueryRowContext(ctx, query,
+	return getExecutor(ctx, r.db).QueryRowxContext(ctx, query,
 		p.ID, p.InspectionID, p.PhotoURL, p.Zone, p.SortOrder,
 	).Scan(&p.UploadedAt)
 }
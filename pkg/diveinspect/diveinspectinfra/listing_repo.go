@@ -4,7 +4,6 @@ import (
 	"context"
 
 	"github.com/Abraxas-365/divi/pkg/diveinspect"
-	"github.com/Abraxas-365/divi/pkg/errx"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
@@ -22,17 +21,18 @@ func (r *PostgresGeneratedListingRepository) Upsert(ctx context.Context, l *dive
 		l.ID = uuid.New().String()
 	}
 	query := `
-		INSERT INTO generated_listings (id, vehicle_id, title, description_es, description_en, seo_keywords, schema_json_ld, generated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO generated_listings (id, vehicle_id, title, description_es, description_en, description_pt, seo_keywords, schema_json_ld, generated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		ON CONFLICT (vehicle_id) DO UPDATE SET
 			title = EXCLUDED.title,
 			description_es = EXCLUDED.description_es,
 			description_en = EXCLUDED.description_en,
+			description_pt = EXCLUDED.description_pt,
 			seo_keywords = EXCLUDED.seo_keywords,
 			schema_json_ld = EXCLUDED.schema_json_ld,
 			generated_at = EXCLUDED.generated_at`
-	_, err := r.db.ExecContext(ctx, query,
-		l.ID, l.VehicleID, l.Title, l.DescriptionES, l.DescriptionEN,
+	_, err := getExecutor(ctx, r.db).ExecContext(ctx, query,
+		l.ID, l.VehicleID, l.Title, l.DescriptionES, l.DescriptionEN, l.DescriptionPT,
 		l.SEOKeywords, l.SchemaJSONLD, l.GeneratedAt,
 	)
 	return err
@@ -42,7 +42,7 @@ func (r *PostgresGeneratedListingRepository) GetByVehicleID(ctx context.Context,
 	var l diveinspect.GeneratedListing
 	query := `SELECT * FROM generated_listings WHERE vehicle_id = $1`
 	if err := r.db.GetContext(ctx, &l, query, vehicleID); err != nil {
-		return nil, errx.NotFound("Generated listing not found").WithDetail("vehicle_id", vehicleID)
+		return nil, diveinspect.NewError(diveinspect.ErrListingNotFound).WithDetail("vehicle_id", vehicleID)
 	}
 	return &l, nil
 }
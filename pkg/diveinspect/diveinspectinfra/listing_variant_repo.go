@@ -0,0 +1,146 @@
+package diveinspectinfra
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type PostgresListingVariantRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresListingVariantRepository(db *sqlx.DB) *PostgresListingVariantRepository {
+	return &PostgresListingVariantRepository{db: db}
+}
+
+func (r *PostgresListingVariantRepository) CreateVersions(ctx context.Context, variants []diveinspect.ListingVariant) error {
+	if len(variants) == 0 {
+		return nil
+	}
+	return withTx(ctx, r.db, func(exec sqlx.ExtContext) error {
+		for i := range variants {
+			v := &variants[i]
+			if v.ID == "" {
+				v.ID = uuid.New().String()
+			}
+
+			// A concurrent generation can claim the same version or the active
+			// slot first; the unique constraints reject the loser, which retries
+			// against the committed rows
+			for attempt := 1; ; attempt++ {
+				err := insertVersion(ctx, exec, v)
+				if err == nil {
+					break
+				}
+				if !isVersionConflict(err) || attempt == maxVersionAttempts {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// maxVersionAttempts bounds the retries of a version lost to a concurrent
+// generation of the same channel and language.
+const maxVersionAttempts = 3
+
+// insertVersion deactivates the current copy and inserts v as the next
+// version, inside a savepoint so a lost race leaves the transaction usable.
+func insertVersion(ctx context.Context, exec sqlx.ExtContext, v *diveinspect.ListingVariant) error {
+	if _, err := exec.ExecContext(ctx, `SAVEPOINT listing_variant_version`); err != nil {
+		return err
+	}
+	err := func() error {
+		if _, err := exec.ExecContext(ctx, `
+			UPDATE listing_variants SET is_active = FALSE
+			WHERE vehicle_id = $1 AND channel = $2 AND language = $3 AND is_active`,
+			v.VehicleID, v.Channel, v.Language); err != nil {
+			return err
+		}
+
+		v.IsActive = true
+		return sqlx.GetContext(ctx, exec, &v.Version, `
+			INSERT INTO listing_variants (id, vehicle_id, channel, language, version, title, body, hashtags, is_active, generated_at)
+			SELECT $1, $2, $3, $4, COALESCE(MAX(version), 0) + 1, $5, $6, $7, $8, $9
+			FROM listing_variants
+			WHERE vehicle_id = $2 AND channel = $3 AND language = $4
+			RETURNING version`,
+			v.ID, v.VehicleID, v.Channel, v.Language, v.Title, v.Body, v.Hashtags, v.IsActive, v.GeneratedAt,
+		)
+	}()
+	if err != nil {
+		if _, rbErr := exec.ExecContext(ctx, `ROLLBACK TO SAVEPOINT listing_variant_version`); rbErr != nil {
+			return rbErr
+		}
+		return err
+	}
+	_, err = exec.ExecContext(ctx, `RELEASE SAVEPOINT listing_variant_version`)
+	return err
+}
+
+// isVersionConflict reports whether err is a unique violation on the version
+// number or the active copy of a channel and language.
+func isVersionConflict(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != "23505" { // unique_violation
+		return false
+	}
+	return pqErr.Constraint == "uq_listing_variant_version" || pqErr.Constraint == "idx_listing_variants_active"
+}
+
+func (r *PostgresListingVariantRepository) ListActive(ctx context.Context, vehicleID string) ([]diveinspect.ListingVariant, error) {
+	var variants []diveinspect.ListingVariant
+	query := `SELECT * FROM listing_variants WHERE vehicle_id = $1 AND is_active ORDER BY channel, language`
+	if err := r.db.SelectContext(ctx, &variants, query, vehicleID); err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+func (r *PostgresListingVariantRepository) ListHistory(ctx context.Context, vehicleID string, channel diveinspect.ListingChannel, language diveinspect.ListingLanguage) ([]diveinspect.ListingVariant, error) {
+	var variants []diveinspect.ListingVariant
+	query := `
+		SELECT * FROM listing_variants
+		WHERE vehicle_id = $1 AND channel = $2 AND language = $3
+		ORDER BY version DESC`
+	if err := r.db.SelectContext(ctx, &variants, query, vehicleID, channel, language); err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+func (r *PostgresListingVariantRepository) Activate(ctx context.Context, vehicleID string, channel diveinspect.ListingChannel, language diveinspect.ListingLanguage, version int) (*diveinspect.ListingVariant, error) {
+	var v diveinspect.ListingVariant
+	err := withTx(ctx, r.db, func(exec sqlx.ExtContext) error {
+		if _, err := exec.ExecContext(ctx, `
+			UPDATE listing_variants SET is_active = FALSE
+			WHERE vehicle_id = $1 AND channel = $2 AND language = $3 AND is_active`,
+			vehicleID, channel, language); err != nil {
+			return err
+		}
+
+		err := sqlx.GetContext(ctx, exec, &v, `
+			UPDATE listing_variants SET is_active = TRUE
+			WHERE vehicle_id = $1 AND channel = $2 AND language = $3 AND version = $4
+			RETURNING *`, vehicleID, channel, language, version)
+		if err == sql.ErrNoRows {
+			return errx.NotFound("Listing version not found").
+				WithDetail("channel", channel).
+				WithDetail("language", language).
+				WithDetail("version", version)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
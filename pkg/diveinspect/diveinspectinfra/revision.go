@@ -0,0 +1,22 @@
+package diveinspectinfra
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/jmoiron/sqlx"
+)
+
+// revisionError explains why a revision-checked UPDATE matched no row: the
+// row is gone, or another write bumped its revision first.
+func revisionError(ctx context.Context, exec sqlx.QueryerContext, table, id string, notFound error) error {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM ` + table + ` WHERE id = $1)`
+	if err := sqlx.GetContext(ctx, exec, &exists, query, id); err != nil {
+		return err
+	}
+	if !exists {
+		return notFound
+	}
+	return diveinspect.NewError(diveinspect.ErrRevisionConflict).WithDetail("id", id)
+}
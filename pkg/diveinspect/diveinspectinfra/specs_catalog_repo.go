@@ -0,0 +1,68 @@
+package diveinspectinfra
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type PostgresSpecsCatalogRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresSpecsCatalogRepository(db *sqlx.DB) *PostgresSpecsCatalogRepository {
+	return &PostgresSpecsCatalogRepository{db: db}
+}
+
+func (r *PostgresSpecsCatalogRepository) UpsertBatch(ctx context.Context, entries []diveinspect.SpecsCatalogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	query := `
+		INSERT INTO specs_catalog (id, brand, model, version, year, specs)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT ((LOWER(brand)), (LOWER(model)), (LOWER(version)), year) DO UPDATE SET
+			specs = EXCLUDED.specs`
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := range entries {
+		if entries[i].ID == "" {
+			entries[i].ID = uuid.New().String()
+		}
+		e := entries[i]
+		if _, err := tx.ExecContext(ctx, query, e.ID, e.Brand, e.Model, e.Version, e.Year, e.Specs); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresSpecsCatalogRepository) FindBest(ctx context.Context, brand, model string, version *string, year int) (*diveinspect.SpecsCatalogEntry, error) {
+	v := ""
+	if version != nil {
+		v = *version
+	}
+	// The exact version sorts before the version-less fallback
+	query := `
+		SELECT * FROM specs_catalog
+		WHERE LOWER(brand) = LOWER($1) AND LOWER(model) = LOWER($2) AND year = $4
+		  AND (LOWER(version) = LOWER($3) OR version = '')
+		ORDER BY version = '' ASC
+		LIMIT 1`
+	var e diveinspect.SpecsCatalogEntry
+	err := r.db.GetContext(ctx, &e, query, brand, model, v, year)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
@@ -0,0 +1,67 @@
+package diveinspectinfra
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// txKey is the context key the transaction travels under, shared with the
+// other Postgres repositories in this codebase.
+const txKey = "db_tx"
+
+// PostgresTxManager runs a unit of work in a single transaction. Repositories
+// called with the returned context join it through getExecutor.
+type PostgresTxManager struct {
+	db *sqlx.DB
+}
+
+func NewPostgresTxManager(db *sqlx.DB) *PostgresTxManager {
+	return &PostgresTxManager{db: db}
+}
+
+// WithinTx commits when fn returns nil and rolls back otherwise. Nested calls
+// reuse the outer transaction.
+func (m *PostgresTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, txKey, tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// getExecutor returns the transaction carried by ctx, if any, otherwise db.
+func getExecutor(ctx context.Context, db *sqlx.DB) sqlx.ExtContext {
+	if tx, ok := ctx.Value(txKey).(*sqlx.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// withTx runs fn on the transaction carried by ctx, or on a new one that is
+// committed when fn succeeds.
+func withTx(ctx context.Context, db *sqlx.DB, fn func(exec sqlx.ExtContext) error) error {
+	if tx, ok := ctx.Value(txKey).(*sqlx.Tx); ok {
+		return fn(tx)
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
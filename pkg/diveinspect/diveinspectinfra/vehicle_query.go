@@ -0,0 +1,344 @@
+package diveinspectinfra
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/Abraxas-365/divi/pkg/kernel"
+	"github.com/lib/pq"
+)
+
+// sortColumns whitelists sortable fields. Only these expressions are ever
+// interpolated into ORDER BY / keyset clauses; all values go through args.
+// Vehicles without a price sort as 0.
+var sortColumns = map[diveinspect.VehicleSortField]string{
+	diveinspect.SortByCreatedAt: "v.created_at",
+	diveinspect.SortByUpdatedAt: "v.updated_at",
+	diveinspect.SortByPrice:     "COALESCE(v.price_usd, 0)",
+	diveinspect.SortByYear:      "v.year",
+	diveinspect.SortByMileage:   "v.mileage_km",
+	diveinspect.SortByBrand:     "v.brand",
+	diveinspect.SortByModel:     "v.model",
+}
+
+var defaultVehicleSort = []diveinspect.VehicleSort{{Field: diveinspect.SortByCreatedAt, Desc: true}}
+
+const vehicleQueryFrom = `FROM vehicles v LEFT JOIN vehicle_specs s ON s.vehicle_id = v.id`
+
+// facetDimension identifies a filter dimension so facet counts can skip it.
+type facetDimension int
+
+const (
+	facetNone facetDimension = iota
+	facetBrand
+	facetModel
+	facetYear
+	facetBranch
+	facetStatus
+)
+
+// ============================================================================
+// Query
+// ============================================================================
+
+func (r *PostgresVehicleRepository) Query(ctx context.Context, q diveinspect.VehicleQuery) (*diveinspect.VehicleQueryPage, error) {
+	sorts := q.Sort
+	if len(sorts) == 0 {
+		sorts = defaultVehicleSort
+	}
+	for _, s := range sorts {
+		if _, ok := sortColumns[s.Field]; !ok {
+			return nil, errx.Validation("Invalid sort field").WithDetail("field", s.Field)
+		}
+	}
+
+	b := &sqlBuilder{}
+	r.applyFilters(b, q, facetNone)
+
+	// Only offset pages report a total; a cursor page skips the count
+	var total int
+	if q.Cursor == "" {
+		countQuery := "SELECT COUNT(*) " + vehicleQueryFrom + b.where()
+		if err := r.db.GetContext(ctx, &total, countQuery, b.args...); err != nil {
+			return nil, errx.Wrap(err, "Failed to count vehicles", errx.TypeInternal)
+		}
+	} else {
+		values, err := decodeCursor(q.Cursor, sorts)
+		if err != nil {
+			return nil, err
+		}
+		b.and(keysetClause(b, sorts, values))
+	}
+
+	query := "SELECT v.* " + vehicleQueryFrom + b.where() + orderByClause(sorts)
+	// Fetch one extra row to know whether a next page exists.
+	query += " LIMIT " + b.arg(q.PageSize+1)
+	if q.Cursor == "" {
+		query += " OFFSET " + b.arg((q.Page-1)*q.PageSize)
+	}
+
+	var vehicles []diveinspect.Vehicle
+	if err := r.db.SelectContext(ctx, &vehicles, query, b.args...); err != nil {
+		return nil, errx.Wrap(err, "Failed to query vehicles", errx.TypeInternal)
+	}
+
+	page := &diveinspect.VehicleQueryPage{}
+	if len(vehicles) > q.PageSize {
+		vehicles = vehicles[:q.PageSize]
+		page.NextCursor = encodeCursor(sorts, vehicles[len(vehicles)-1])
+		page.HasMore = true
+	}
+	page.Paginated = kernel.NewPaginated(vehicles, q.Page, q.PageSize, total)
+	return page, nil
+}
+
+// ============================================================================
+// Facets
+// ============================================================================
+
+func (r *PostgresVehicleRepository) Facets(ctx context.Context, q diveinspect.VehicleQuery) (*diveinspect.VehicleFacets, error) {
+	facets := &diveinspect.VehicleFacets{}
+
+	dims := []struct {
+		dim    facetDimension
+		column string
+		out    *[]diveinspect.FacetCount
+	}{
+		{facetBrand, "v.brand", &facets.Brands},
+		{facetModel, "v.model", &facets.Models},
+		{facetYear, "v.year::text", &facets.Years},
+		{facetBranch, "v.branch", &facets.Branches},
+		{facetStatus, "v.status", &facets.Statuses},
+	}
+
+	for _, d := range dims {
+		b := &sqlBuilder{}
+		r.applyFilters(b, q, d.dim)
+		b.and(d.column + " IS NOT NULL")
+
+		query := fmt.Sprintf(
+			`SELECT %s AS value, COUNT(*) AS count %s%s GROUP BY 1 ORDER BY count DESC, value`,
+			d.column, vehicleQueryFrom, b.where(),
+		)
+
+		counts := []diveinspect.FacetCount{}
+		if err := r.db.SelectContext(ctx, &counts, query, b.args...); err != nil {
+			return nil, errx.Wrap(err, "Failed to compute vehicle facets", errx.TypeInternal)
+		}
+		*d.out = counts
+	}
+
+	return facets, nil
+}
+
+// ============================================================================
+// Filter building
+// ============================================================================
+
+// applyFilters adds every filter in q to b, except the one for skip.
+func (r *PostgresVehicleRepository) applyFilters(b *sqlBuilder, q diveinspect.VehicleQuery, skip facetDimension) {
+	if !q.IncludeDeleted {
+		b.and("v.deleted_at IS NULL")
+	}
+	if len(q.Brands) > 0 && skip != facetBrand {
+		b.and("LOWER(v.brand) = ANY(" + b.arg(lowerAll(q.Brands)) + ")")
+	}
+	if len(q.Models) > 0 && skip != facetModel {
+		b.and("LOWER(v.model) = ANY(" + b.arg(lowerAll(q.Models)) + ")")
+	}
+	if len(q.Branches) > 0 && skip != facetBranch {
+		b.and("LOWER(v.branch) = ANY(" + b.arg(lowerAll(q.Branches)) + ")")
+	}
+	if len(q.Statuses) > 0 && skip != facetStatus {
+		statuses := make([]string, len(q.Statuses))
+		for i, s := range q.Statuses {
+			statuses[i] = string(s)
+		}
+		b.and("v.status = ANY(" + b.arg(pq.StringArray(statuses)) + ")")
+	}
+	if len(q.FuelTypes) > 0 {
+		b.and("LOWER(s.fuel_type) = ANY(" + b.arg(lowerAll(q.FuelTypes)) + ")")
+	}
+	if len(q.Drivetrains) > 0 {
+		b.and("LOWER(s.drivetrain) = ANY(" + b.arg(lowerAll(q.Drivetrains)) + ")")
+	}
+
+	if skip != facetYear {
+		if q.YearMin != nil {
+			b.and("v.year >= " + b.arg(*q.YearMin))
+		}
+		if q.YearMax != nil {
+			b.and("v.year <= " + b.arg(*q.YearMax))
+		}
+	}
+	if q.MileageMin != nil {
+		b.and("v.mileage_km >= " + b.arg(*q.MileageMin))
+	}
+	if q.MileageMax != nil {
+		b.and("v.mileage_km <= " + b.arg(*q.MileageMax))
+	}
+	if q.PriceMin != nil {
+		b.and("v.price_usd >= " + b.arg(*q.PriceMin))
+	}
+	if q.PriceMax != nil {
+		b.and("v.price_usd <= " + b.arg(*q.PriceMax))
+	}
+}
+
+func orderByClause(sorts []diveinspect.VehicleSort) string {
+	parts := make([]string, 0, len(sorts)+1)
+	for _, s := range sorts {
+		dir := "ASC"
+		if s.Desc {
+			dir = "DESC"
+		}
+		parts = append(parts, sortColumns[s.Field]+" "+dir)
+	}
+	// id is the final tiebreaker so keyset pagination is stable
+	parts = append(parts, "v.id ASC")
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
+
+// keysetClause builds "(c1 > $a) OR (c1 = $a AND c2 < $b) OR ..." so the
+// next page starts strictly after the cursor row in the requested order.
+func keysetClause(b *sqlBuilder, sorts []diveinspect.VehicleSort, values []string) string {
+	columns := make([]string, 0, len(sorts)+1)
+	ops := make([]string, 0, len(sorts)+1)
+	for _, s := range sorts {
+		columns = append(columns, sortColumns[s.Field])
+		if s.Desc {
+			ops = append(ops, "<")
+		} else {
+			ops = append(ops, ">")
+		}
+	}
+	columns = append(columns, "v.id")
+	ops = append(ops, ">")
+
+	branches := make([]string, 0, len(columns))
+	for i := range columns {
+		terms := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			terms = append(terms, columns[j]+" = "+b.arg(values[j]))
+		}
+		terms = append(terms, columns[i]+" "+ops[i]+" "+b.arg(values[i]))
+		branches = append(branches, "("+strings.Join(terms, " AND ")+")")
+	}
+	return "(" + strings.Join(branches, " OR ") + ")"
+}
+
+// ============================================================================
+// Cursor encoding
+// ============================================================================
+
+// vehicleCursor carries the sort key it was issued for, so a cursor can't be
+// replayed against a different ordering.
+type vehicleCursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+}
+
+func sortSignature(sorts []diveinspect.VehicleSort) string {
+	parts := make([]string, len(sorts))
+	for i, s := range sorts {
+		if s.Desc {
+			parts[i] = "-" + string(s.Field)
+		} else {
+			parts[i] = string(s.Field)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func encodeCursor(sorts []diveinspect.VehicleSort, last diveinspect.Vehicle) string {
+	c := vehicleCursor{Sort: sortSignature(sorts)}
+	for _, s := range sorts {
+		c.Values = append(c.Values, sortValue(last, s.Field))
+	}
+	c.Values = append(c.Values, last.ID)
+
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(cursor string, sorts []diveinspect.VehicleSort) ([]string, error) {
+	invalid := errx.Validation("Invalid or stale pagination cursor")
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+	var c vehicleCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, invalid
+	}
+	if c.Sort != sortSignature(sorts) || len(c.Values) != len(sorts)+1 {
+		return nil, invalid
+	}
+	return c.Values, nil
+}
+
+func sortValue(v diveinspect.Vehicle, field diveinspect.VehicleSortField) string {
+	switch field {
+	case diveinspect.SortByCreatedAt:
+		return v.CreatedAt.Format(time.RFC3339Nano)
+	case diveinspect.SortByUpdatedAt:
+		return v.UpdatedAt.Format(time.RFC3339Nano)
+	case diveinspect.SortByPrice:
+		if v.PriceUSD == nil {
+			return "0"
+		}
+		return strconv.FormatFloat(*v.PriceUSD, 'f', -1, 64)
+	case diveinspect.SortByYear:
+		return strconv.Itoa(v.Year)
+	case diveinspect.SortByMileage:
+		return strconv.Itoa(v.MileageKM)
+	case diveinspect.SortByBrand:
+		return v.Brand
+	case diveinspect.SortByModel:
+		return v.Model
+	}
+	return ""
+}
+
+// ============================================================================
+// SQL builder
+// ============================================================================
+
+// sqlBuilder accumulates WHERE conditions and positional args.
+type sqlBuilder struct {
+	conditions []string
+	args       []any
+}
+
+// arg registers a value and returns its placeholder.
+func (b *sqlBuilder) arg(v any) string {
+	b.args = append(b.args, v)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+func (b *sqlBuilder) and(condition string) {
+	b.conditions = append(b.conditions, condition)
+}
+
+func (b *sqlBuilder) where() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+func lowerAll(values []string) pq.StringArray {
+	out := make(pq.StringArray, len(values))
+	for i, v := range values {
+		out[i] = strings.ToLower(strings.TrimSpace(v))
+	}
+	return out
+}
@@ -2,6 +2,8 @@ package diveinspectinfra
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/Abraxas-365/divi/pkg/diveinspect"
 	"github.com/Abraxas-365/divi/pkg/errx"
@@ -22,59 +24,107 @@ func (r *PostgresVehicleRepository) Create(ctx context.Context, v *diveinspect.V
 		v.ID = uuid.New().String()
 	}
 	query := `
-		INSERT INTO vehicles (id, plate, brand, model, version, trim, year, mileage_km, color_exterior, color_interior, price_usd, branch, origin, status)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		INSERT INTO vehicles (id, plate, brand, model, version, trim, year, mileage_km, color_exterior, color_interior, price_usd, branch, origin, status, vin, tenant_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 		RETURNING created_at, updated_at`
-	return r.db.QueryRowContext(ctx, query,
+	return getExecutor(ctx, r.db).QueryRowxContext(ctx, query,
 		v.ID, v.Plate, v.Brand, v.Model, v.Version, v.Trim, v.Year, v.MileageKM,
-		v.ColorExterior, v.ColorInterior, v.PriceUSD, v.Branch, v.Origin, v.Status,
+		v.ColorExterior, v.ColorInterior, v.PriceUSD, v.Branch, v.Origin, v.Status, v.VIN, v.TenantID,
 	).Scan(&v.CreatedAt, &v.UpdatedAt)
 }
 
 func (r *PostgresVehicleRepository) GetByID(ctx context.Context, id string) (*diveinspect.Vehicle, error) {
 	var v diveinspect.Vehicle
-	query := `SELECT * FROM vehicles WHERE id = $1`
+	query := `SELECT * FROM vehicles WHERE id = $1 AND deleted_at IS NULL`
 	if err := r.db.GetContext(ctx, &v, query, id); err != nil {
-		return nil, errx.NotFound("Vehicle not found").WithDetail("id", id)
+		return nil, diveinspect.NewError(diveinspect.ErrVehicleNotFound).WithDetail("id", id)
 	}
 	return &v, nil
 }
 
+// Update saves v and bumps its revision. When v.Revision is set the row must
+// still be at that revision, otherwise the update fails with a conflict.
 func (r *PostgresVehicleRepository) Update(ctx context.Context, v *diveinspect.Vehicle) error {
 	query := `
 		UPDATE vehicles SET
 			plate = $2, brand = $3, model = $4, version = $5, trim = $6, year = $7,
 			mileage_km = $8, color_exterior = $9, color_interior = $10, price_usd = $11,
-			branch = $12, origin = $13, status = $14
-		WHERE id = $1
-		RETURNING updated_at`
-	return r.db.QueryRowContext(ctx, query,
+			branch = $12, origin = $13, status = $14, vin = $15, revision = revision + 1
+		WHERE id = $1 AND ($16 = 0 OR revision = $16)
+		RETURNING updated_at, revision`
+	exec := getExecutor(ctx, r.db)
+	err := exec.QueryRowxContext(ctx, query,
 		v.ID, v.Plate, v.Brand, v.Model, v.Version, v.Trim, v.Year, v.MileageKM,
-		v.ColorExterior, v.ColorInterior, v.PriceUSD, v.Branch, v.Origin, v.Status,
-	).Scan(&v.UpdatedAt)
+		v.ColorExterior, v.ColorInterior, v.PriceUSD, v.Branch, v.Origin, v.Status, v.VIN, v.Revision,
+	).Scan(&v.UpdatedAt, &v.Revision)
+	if err == sql.ErrNoRows {
+		return revisionError(ctx, exec, "vehicles", v.ID, diveinspect.NewError(diveinspect.ErrVehicleNotFound).WithDetail("id", v.ID))
+	}
+	return err
 }
 
+// Delete soft-deletes the vehicle. Its inspections, listings and files stay
+// until HardDelete, so Restore can bring it back.
 func (r *PostgresVehicleRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM vehicles WHERE id = $1`
-	result, err := r.db.ExecContext(ctx, query, id)
+	query := `UPDATE vehicles SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL`
+	result, err := getExecutor(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return diveinspect.NewError(diveinspect.ErrVehicleNotFound).WithDetail("id", id)
+	}
+	return nil
+}
+
+// Restore clears deleted_at on a soft-deleted vehicle and returns it.
+func (r *PostgresVehicleRepository) Restore(ctx context.Context, id string) (*diveinspect.Vehicle, error) {
+	var v diveinspect.Vehicle
+	query := `UPDATE vehicles SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL RETURNING *`
+	if err := sqlx.GetContext(ctx, getExecutor(ctx, r.db), &v, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, diveinspect.NewError(diveinspect.ErrVehicleNotFound).WithDetail("id", id)
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+// HardDelete removes a vehicle soft-deleted before cutoff; its inspections,
+// findings, photos, specs and listings go with it through ON DELETE CASCADE.
+func (r *PostgresVehicleRepository) HardDelete(ctx context.Context, id string, cutoff time.Time) error {
+	query := `DELETE FROM vehicles WHERE id = $1 AND deleted_at < $2`
+	result, err := getExecutor(ctx, r.db).ExecContext(ctx, query, id, cutoff)
 	if err != nil {
 		return err
 	}
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return errx.NotFound("Vehicle not found").WithDetail("id", id)
+		return diveinspect.NewError(diveinspect.ErrVehicleNotFound).WithDetail("id", id)
 	}
 	return nil
 }
 
+// ListDeletedBefore returns up to limit vehicles soft-deleted before cutoff,
+// oldest first.
+func (r *PostgresVehicleRepository) ListDeletedBefore(ctx context.Context, cutoff time.Time, limit int) ([]diveinspect.Vehicle, error) {
+	var vehicles []diveinspect.Vehicle
+	query := `SELECT * FROM vehicles WHERE deleted_at < $1 ORDER BY deleted_at LIMIT $2`
+	if err := r.db.SelectContext(ctx, &vehicles, query, cutoff, limit); err != nil {
+		return nil, err
+	}
+	return vehicles, nil
+}
+
 func (r *PostgresVehicleRepository) List(ctx context.Context, page, pageSize int) ([]diveinspect.Vehicle, int, error) {
 	var total int
-	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM vehicles`); err != nil {
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM vehicles WHERE deleted_at IS NULL`); err != nil {
 		return nil, 0, err
 	}
 
 	var vehicles []diveinspect.Vehicle
-	query := `SELECT * FROM vehicles ORDER BY created_at DESC LIMIT $1 OFFSET $2`
+	query := `SELECT * FROM vehicles WHERE deleted_at IS NULL ORDER BY created_at DESC LIMIT $1 OFFSET $2`
 	offset := (page - 1) * pageSize
 	if err := r.db.SelectContext(ctx, &vehicles, query, pageSize, offset); err != nil {
 		return nil, 0, err
@@ -84,12 +134,12 @@ func (r *PostgresVehicleRepository) List(ctx context.Context, page, pageSize int
 
 func (r *PostgresVehicleRepository) ListByStatus(ctx context.Context, status diveinspect.VehicleStatus, page, pageSize int) ([]diveinspect.Vehicle, int, error) {
 	var total int
-	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM vehicles WHERE status = $1`, status); err != nil {
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM vehicles WHERE status = $1 AND deleted_at IS NULL`, status); err != nil {
 		return nil, 0, err
 	}
 
 	var vehicles []diveinspect.Vehicle
-	query := `SELECT * FROM vehicles WHERE status = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3`
+	query := `SELECT * FROM vehicles WHERE status = $1 AND deleted_at IS NULL ORDER BY created_at DESC LIMIT $2 OFFSET $3`
 	offset := (page - 1) * pageSize
 	if err := r.db.SelectContext(ctx, &vehicles, query, status, pageSize, offset); err != nil {
 		return nil, 0, err
@@ -97,6 +147,28 @@ func (r *PostgresVehicleRepository) ListByStatus(ctx context.Context, status div
 	return vehicles, total, nil
 }
 
+// FindByPlateOrVIN returns a vehicle whose normalized plate or VIN matches.
+// Plates are compared ignoring case, spaces and dashes.
+func (r *PostgresVehicleRepository) FindByPlateOrVIN(ctx context.Context, plate, vin *string) (*diveinspect.Vehicle, error) {
+	if plate == nil && vin == nil {
+		return nil, diveinspect.NewError(diveinspect.ErrVehicleNotFound)
+	}
+	var v diveinspect.Vehicle
+	query := `
+		SELECT * FROM vehicles
+		WHERE deleted_at IS NULL
+		  AND (($1::text IS NOT NULL AND UPPER(REPLACE(REPLACE(plate, '-', ''), ' ', '')) = UPPER(REPLACE(REPLACE($1, '-', ''), ' ', '')))
+		    OR ($2::text IS NOT NULL AND UPPER(vin) = UPPER($2)))
+		LIMIT 1`
+	if err := r.db.GetContext(ctx, &v, query, plate, vin); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, diveinspect.NewError(diveinspect.ErrVehicleNotFound)
+		}
+		return nil, errx.Wrap(err, "Failed to look up vehicle", errx.TypeInternal)
+	}
+	return &v, nil
+}
+
 // ============================================================================
 // Vehicle Specs Repository
 // ============================================================================
@@ -113,16 +185,20 @@ func (r *PostgresVehicleSpecsRepository) Create(ctx context.Context, s *diveinsp
 	if s.ID == "" {
 		s.ID = uuid.New().String()
 	}
+	if s.ReviewStatus == "" {
+		s.ReviewStatus = diveinspect.SpecsApproved
+	}
 	query := `
 		INSERT INTO vehicle_specs (
 			id, vehicle_id, engine_type, engine_cc, engine_cylinders, power_hp, power_kw,
 			torque_nm, torque_rpm_range, fuel_type, fuel_system, transmission_type, transmission_gears,
 			drivetrain, accel_0_100, top_speed_kmh, fuel_city_kml, fuel_highway_kml, fuel_combined_kml,
 			fuel_tank_liters, length_mm, width_mm, height_mm, wheelbase_mm, cargo_liters,
-			cargo_max_liters, curb_weight_kg, tire_size, spare_tire, specs_source, specs_confidence, enriched_at
+			cargo_max_liters, curb_weight_kg, tire_size, spare_tire, specs_source, specs_confidence, enriched_at,
+			segment, validation, review_status, provenance
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19,
-			$20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32
+			$20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34, $35, $36
 		)`
 	_, err := r.db.ExecContext(ctx, query,
 		s.ID, s.VehicleID, s.EngineType, s.EngineCC, s.EngineCylinders, s.PowerHP, s.PowerKW,
@@ -130,6 +206,7 @@ func (r *PostgresVehicleSpecsRepository) Create(ctx context.Context, s *diveinsp
 		s.Drivetrain, s.Accel0100, s.TopSpeedKMH, s.FuelCityKML, s.FuelHighwayKML, s.FuelCombinedKML,
 		s.FuelTankLiters, s.LengthMM, s.WidthMM, s.HeightMM, s.WheelbaseMM, s.CargoLiters,
 		s.CargoMaxLiters, s.CurbWeightKG, s.TireSize, s.SpareTire, s.SpecsSource, s.SpecsConfidence, s.EnrichedAt,
+		s.Segment, s.Validation, s.ReviewStatus, s.Provenance,
 	)
 	return err
 }
@@ -138,25 +215,31 @@ func (r *PostgresVehicleSpecsRepository) GetByVehicleID(ctx context.Context, veh
 	var s diveinspect.VehicleSpecs
 	query := `SELECT * FROM vehicle_specs WHERE vehicle_id = $1`
 	if err := r.db.GetContext(ctx, &s, query, vehicleID); err != nil {
-		return nil, errx.NotFound("Vehicle specs not found").WithDetail("vehicle_id", vehicleID)
+		return nil, diveinspect.NewError(diveinspect.ErrSpecsNotFound).WithDetail("vehicle_id", vehicleID)
 	}
 	return &s, nil
 }
 
+// Upsert inserts or replaces the vehicle's specs and bumps their revision.
+// When s.Revision is set the stored specs must still be at that revision.
 func (r *PostgresVehicleSpecsRepository) Upsert(ctx context.Context, s *diveinspect.VehicleSpecs) error {
 	if s.ID == "" {
 		s.ID = uuid.New().String()
 	}
+	if s.ReviewStatus == "" {
+		s.ReviewStatus = diveinspect.SpecsApproved
+	}
 	query := `
 		INSERT INTO vehicle_specs (
 			id, vehicle_id, engine_type, engine_cc, engine_cylinders, power_hp, power_kw,
 			torque_nm, torque_rpm_range, fuel_type, fuel_system, transmission_type, transmission_gears,
 			drivetrain, accel_0_100, top_speed_kmh, fuel_city_kml, fuel_highway_kml, fuel_combined_kml,
 			fuel_tank_liters, length_mm, width_mm, height_mm, wheelbase_mm, cargo_liters,
-			cargo_max_liters, curb_weight_kg, tire_size, spare_tire, specs_source, specs_confidence, enriched_at
+			cargo_max_liters, curb_weight_kg, tire_size, spare_tire, specs_source, specs_confidence, enriched_at,
+			segment, validation, review_status, provenance
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19,
-			$20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32
+			$20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34, $35, $36
 		)
 		ON CONFLICT (vehicle_id) DO UPDATE SET
 			engine_type = EXCLUDED.engine_type, engine_cc = EXCLUDED.engine_cc,
@@ -173,14 +256,23 @@ func (r *PostgresVehicleSpecsRepository) Upsert(ctx context.Context, s *diveinsp
 			cargo_liters = EXCLUDED.cargo_liters, cargo_max_liters = EXCLUDED.cargo_max_liters,
 			curb_weight_kg = EXCLUDED.curb_weight_kg, tire_size = EXCLUDED.tire_size,
 			spare_tire = EXCLUDED.spare_tire, specs_source = EXCLUDED.specs_source,
-			specs_confidence = EXCLUDED.specs_confidence, enriched_at = EXCLUDED.enriched_at`
-	_, err := r.db.ExecContext(ctx, query,
+			specs_confidence = EXCLUDED.specs_confidence, enriched_at = EXCLUDED.enriched_at,
+			segment = EXCLUDED.segment, validation = EXCLUDED.validation,
+			review_status = EXCLUDED.review_status, provenance = EXCLUDED.provenance,
+			revision = vehicle_specs.revision + 1
+		WHERE $37 = 0 OR vehicle_specs.revision = $37
+		RETURNING id, revision`
+	err := getExecutor(ctx, r.db).QueryRowxContext(ctx, query,
 		s.ID, s.VehicleID, s.EngineType, s.EngineCC, s.EngineCylinders, s.PowerHP, s.PowerKW,
 		s.TorqueNM, s.TorqueRPMRange, s.FuelType, s.FuelSystem, s.TransmissionType, s.TransmissionGears,
 		s.Drivetrain, s.Accel0100, s.TopSpeedKMH, s.FuelCityKML, s.FuelHighwayKML, s.FuelCombinedKML,
 		s.FuelTankLiters, s.LengthMM, s.WidthMM, s.HeightMM, s.WheelbaseMM, s.CargoLiters,
 		s.CargoMaxLiters, s.CurbWeightKG, s.TireSize, s.SpareTire, s.SpecsSource, s.SpecsConfidence, s.EnrichedAt,
-	)
+		s.Segment, s.Validation, s.ReviewStatus, s.Provenance, s.Revision,
+	).Scan(&s.ID, &s.Revision)
+	if err == sql.ErrNoRows {
+		return diveinspect.NewError(diveinspect.ErrRevisionConflict).WithDetail("vehicle_id", s.VehicleID)
+	}
 	return err
 }
 
@@ -206,28 +298,28 @@ func (r *PostgresVehicleEquipmentRepository) CreateBatch(ctx context.Context, eq
 		return nil
 	}
 	query := `
-		INSERT INTO vehicle_equipment (id, vehicle_id, category, feature_name, feature_description, is_standard, is_confirmed, source)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
-	tx, err := r.db.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	for i := range equipment {
-		if equipment[i].ID == "" {
-			equipment[i].ID = uuid.New().String()
+		INSERT INTO vehicle_equipment (id, vehicle_id, category, feature_name, feature_description, is_standard, is_confirmed, source, verification_status, verification_note, verified_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+	return withTx(ctx, r.db, func(exec sqlx.ExtContext) error {
+		for i := range equipment {
+			if equipment[i].ID == "" {
+				equipment[i].ID = uuid.New().String()
+			}
+			if equipment[i].VerificationStatus == "" {
+				equipment[i].VerificationStatus = diveinspect.VerificationUnverified
+			}
+			_, err := exec.ExecContext(ctx, query,
+				equipment[i].ID, equipment[i].VehicleID, equipment[i].Category,
+				equipment[i].FeatureName, equipment[i].FeatureDescription,
+				equipment[i].IsStandard, equipment[i].IsConfirmed, equipment[i].Source,
+				equipment[i].VerificationStatus, equipment[i].VerificationNote, equipment[i].VerifiedAt,
+			)
+			if err != nil {
+				return err
+			}
 		}
-		_, err := tx.ExecContext(ctx, query,
-			equipment[i].ID, equipment[i].VehicleID, equipment[i].Category,
-			equipment[i].FeatureName, equipment[i].FeatureDescription,
-			equipment[i].IsStandard, equipment[i].IsConfirmed, equipment[i].Source,
-		)
-		if err != nil {
-			return err
-		}
-	}
-	return tx.Commit()
+		return nil
+	})
 }
 
 func (r *PostgresVehicleEquipmentRepository) GetByVehicleID(ctx context.Context, vehicleID string) ([]diveinspect.VehicleEquipment, error) {
@@ -249,6 +341,31 @@ func (r *PostgresVehicleEquipmentRepository) GetByVehicleIDAndCategory(ctx conte
 }
 
 func (r *PostgresVehicleEquipmentRepository) DeleteByVehicleID(ctx context.Context, vehicleID string) error {
-	_, err := r.db.ExecContext(ctx, `DELETE FROM vehicle_equipment WHERE vehicle_id = $1`, vehicleID)
+	_, err := getExecutor(ctx, r.db).ExecContext(ctx, `DELETE FROM vehicle_equipment WHERE vehicle_id = $1`, vehicleID)
 	return err
 }
+
+func (r *PostgresVehicleEquipmentRepository) UpdateVerificationBatch(ctx context.Context, equipment []diveinspect.VehicleEquipment) error {
+	if len(equipment) == 0 {
+		return nil
+	}
+	query := `
+		UPDATE vehicle_equipment SET
+			is_confirmed = $2, verification_status = $3, verification_note = $4, verified_at = $5
+		WHERE id = $1`
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, eq := range equipment {
+		_, err := tx.ExecContext(ctx, query,
+			eq.ID, eq.IsConfirmed, eq.VerificationStatus, eq.VerificationNote, eq.VerifiedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
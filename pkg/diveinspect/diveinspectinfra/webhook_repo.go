@@ -0,0 +1,258 @@
+package diveinspectinfra
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ============================================================================
+// Domain Event Repository
+// ============================================================================
+
+type PostgresDomainEventRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresDomainEventRepository(db *sqlx.DB) *PostgresDomainEventRepository {
+	return &PostgresDomainEventRepository{db: db}
+}
+
+func (r *PostgresDomainEventRepository) Create(ctx context.Context, e *diveinspect.DomainEvent) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	query := `
+		INSERT INTO domain_events (id, tenant_id, event_type, subject_id, payload)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`
+	return getExecutor(ctx, r.db).QueryRowxContext(ctx, query,
+		e.ID, e.TenantID, e.Type, e.SubjectID, e.Payload,
+	).Scan(&e.CreatedAt)
+}
+
+func (r *PostgresDomainEventRepository) GetByID(ctx context.Context, id string) (*diveinspect.DomainEvent, error) {
+	var e diveinspect.DomainEvent
+	query := `SELECT * FROM domain_events WHERE id = $1`
+	if err := r.db.GetContext(ctx, &e, query, id); err != nil {
+		return nil, errx.NotFound("Event not found").WithDetail("id", id)
+	}
+	return &e, nil
+}
+
+func (r *PostgresDomainEventRepository) ClaimUndispatched(ctx context.Context, limit int) ([]diveinspect.DomainEvent, error) {
+	var events []diveinspect.DomainEvent
+	query := `
+		SELECT * FROM domain_events
+		WHERE dispatched_at IS NULL
+		ORDER BY created_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`
+	if err := sqlx.SelectContext(ctx, getExecutor(ctx, r.db), &events, query, limit); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *PostgresDomainEventRepository) MarkDispatched(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := getExecutor(ctx, r.db).ExecContext(ctx,
+		`UPDATE domain_events SET dispatched_at = CURRENT_TIMESTAMP WHERE id = ANY($1)`, pq.Array(ids))
+	return err
+}
+
+// ============================================================================
+// Webhook Endpoint Repository
+// ============================================================================
+
+type PostgresWebhookEndpointRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresWebhookEndpointRepository(db *sqlx.DB) *PostgresWebhookEndpointRepository {
+	return &PostgresWebhookEndpointRepository{db: db}
+}
+
+func (r *PostgresWebhookEndpointRepository) Create(ctx context.Context, e *diveinspect.WebhookEndpoint) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	if e.EventTypes == nil {
+		e.EventTypes = pq.StringArray{}
+	}
+	query := `
+		INSERT INTO webhook_endpoints (id, tenant_id, url, secret, event_types, description, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at, updated_at`
+	return r.db.QueryRowContext(ctx, query,
+		e.ID, e.TenantID, e.URL, e.Secret, e.EventTypes, e.Description, e.IsActive,
+	).Scan(&e.CreatedAt, &e.UpdatedAt)
+}
+
+func (r *PostgresWebhookEndpointRepository) GetByID(ctx context.Context, id string) (*diveinspect.WebhookEndpoint, error) {
+	var e diveinspect.WebhookEndpoint
+	query := `SELECT * FROM webhook_endpoints WHERE id = $1`
+	if err := r.db.GetContext(ctx, &e, query, id); err != nil {
+		return nil, errx.NotFound("Webhook endpoint not found").WithDetail("id", id)
+	}
+	return &e, nil
+}
+
+func (r *PostgresWebhookEndpointRepository) ListByTenantID(ctx context.Context, tenantID string) ([]diveinspect.WebhookEndpoint, error) {
+	var endpoints []diveinspect.WebhookEndpoint
+	query := `SELECT * FROM webhook_endpoints WHERE tenant_id = $1 ORDER BY created_at`
+	if err := sqlx.SelectContext(ctx, getExecutor(ctx, r.db), &endpoints, query, tenantID); err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+func (r *PostgresWebhookEndpointRepository) Update(ctx context.Context, e *diveinspect.WebhookEndpoint) error {
+	if e.EventTypes == nil {
+		e.EventTypes = pq.StringArray{}
+	}
+	query := `
+		UPDATE webhook_endpoints SET
+			url = $2, secret = $3, event_types = $4, description = $5, is_active = $6
+		WHERE id = $1
+		RETURNING updated_at`
+	return r.db.QueryRowContext(ctx, query,
+		e.ID, e.URL, e.Secret, e.EventTypes, e.Description, e.IsActive,
+	).Scan(&e.UpdatedAt)
+}
+
+func (r *PostgresWebhookEndpointRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhook_endpoints WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return errx.NotFound("Webhook endpoint not found").WithDetail("id", id)
+	}
+	return nil
+}
+
+// ============================================================================
+// Webhook Delivery Repository
+// ============================================================================
+
+type PostgresWebhookDeliveryRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresWebhookDeliveryRepository(db *sqlx.DB) *PostgresWebhookDeliveryRepository {
+	return &PostgresWebhookDeliveryRepository{db: db}
+}
+
+func (r *PostgresWebhookDeliveryRepository) CreateBatch(ctx context.Context, deliveries []diveinspect.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	query := `
+		INSERT INTO webhook_deliveries (id, tenant_id, endpoint_id, event_id, event_type, status, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6, COALESCE($7, CURRENT_TIMESTAMP))
+		RETURNING next_attempt_at, created_at, updated_at`
+	return withTx(ctx, r.db, func(exec sqlx.ExtContext) error {
+		for i := range deliveries {
+			d := &deliveries[i]
+			if d.ID == "" {
+				d.ID = uuid.New().String()
+			}
+			if d.Status == "" {
+				d.Status = diveinspect.DeliveryPending
+			}
+			err := exec.QueryRowxContext(ctx, query,
+				d.ID, d.TenantID, d.EndpointID, d.EventID, d.EventType, d.Status, d.NextAttemptAt,
+			).Scan(&d.NextAttemptAt, &d.CreatedAt, &d.UpdatedAt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *PostgresWebhookDeliveryRepository) GetByID(ctx context.Context, id string) (*diveinspect.WebhookDelivery, error) {
+	var d diveinspect.WebhookDelivery
+	query := `SELECT * FROM webhook_deliveries WHERE id = $1`
+	if err := r.db.GetContext(ctx, &d, query, id); err != nil {
+		return nil, errx.NotFound("Webhook delivery not found").WithDetail("id", id)
+	}
+	return &d, nil
+}
+
+func (r *PostgresWebhookDeliveryRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]diveinspect.WebhookDelivery, error) {
+	var deliveries []diveinspect.WebhookDelivery
+	query := `
+		UPDATE webhook_deliveries SET next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $3)
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status IN ($1, $2) AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY next_attempt_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`
+	err := r.db.SelectContext(ctx, &deliveries, query,
+		diveinspect.DeliveryPending, diveinspect.DeliveryFailed, lease.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *PostgresWebhookDeliveryRepository) Update(ctx context.Context, d *diveinspect.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries SET
+			status = $2, attempts = $3, next_attempt_at = $4, last_attempt_at = $5,
+			response_status = $6, response_body = $7, error = $8
+		WHERE id = $1
+		RETURNING updated_at`
+	return r.db.QueryRowContext(ctx, query,
+		d.ID, d.Status, d.Attempts, d.NextAttemptAt, d.LastAttemptAt,
+		d.ResponseStatus, d.ResponseBody, d.Error,
+	).Scan(&d.UpdatedAt)
+}
+
+func (r *PostgresWebhookDeliveryRepository) List(ctx context.Context, q diveinspect.WebhookDeliveryQuery) ([]diveinspect.WebhookDelivery, int, error) {
+	conds := []string{"tenant_id = $1"}
+	args := []any{q.TenantID}
+	if q.EndpointID != "" {
+		args = append(args, q.EndpointID)
+		conds = append(conds, fmt.Sprintf("endpoint_id = $%d", len(args)))
+	}
+	if q.Status != "" {
+		args = append(args, q.Status)
+		conds = append(conds, fmt.Sprintf("status = $%d", len(args)))
+	}
+	where := strings.Join(conds, " AND ")
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM webhook_deliveries WHERE `+where, args...); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (q.Page - 1) * q.PageSize
+	args = append(args, q.PageSize, offset)
+	query := fmt.Sprintf(`
+		SELECT * FROM webhook_deliveries
+		WHERE %s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d`, where, len(args)-1, len(args))
+
+	var deliveries []diveinspect.WebhookDelivery
+	if err := r.db.SelectContext(ctx, &deliveries, query, args...); err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
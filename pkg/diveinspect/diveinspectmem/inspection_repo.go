@@ -0,0 +1,244 @@
+package diveinspectmem
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/google/uuid"
+)
+
+// ============================================================================
+// Inspection Repository
+// ============================================================================
+
+type MemoryInspectionRepository struct {
+	store *Store
+}
+
+func NewMemoryInspectionRepository(store *Store) *MemoryInspectionRepository {
+	return &MemoryInspectionRepository{store: store}
+}
+
+func (r *MemoryInspectionRepository) Create(ctx context.Context, i *diveinspect.Inspection) error {
+	defer r.store.lock()()
+	if i.ID == "" {
+		i.ID = uuid.New().String()
+	}
+	now := time.Now()
+	i.CreatedAt, i.UpdatedAt = now, now
+
+	row := *i
+	row.Revision = 1
+	r.store.inspections = append(r.store.inspections, row)
+	return nil
+}
+
+func (r *MemoryInspectionRepository) GetByID(ctx context.Context, id string) (*diveinspect.Inspection, error) {
+	defer r.store.lock()()
+	i := r.index(id)
+	if i < 0 {
+		return nil, diveinspect.NewError(diveinspect.ErrInspectionNotFound).WithDetail("id", id)
+	}
+	inspection := r.store.inspections[i]
+	return &inspection, nil
+}
+
+// GetByVehicleID returns the vehicle's latest inspection.
+func (r *MemoryInspectionRepository) GetByVehicleID(ctx context.Context, vehicleID string) (*diveinspect.Inspection, error) {
+	defer r.store.lock()()
+	var latest *diveinspect.Inspection
+	for i := range r.store.inspections {
+		insp := &r.store.inspections[i]
+		if insp.VehicleID == vehicleID && (latest == nil || !insp.CreatedAt.Before(latest.CreatedAt)) {
+			latest = insp
+		}
+	}
+	if latest == nil {
+		return nil, diveinspect.NewError(diveinspect.ErrInspectionNotFound).WithDetail("vehicle_id", vehicleID)
+	}
+	inspection := *latest
+	return &inspection, nil
+}
+
+func (r *MemoryInspectionRepository) ListByVehicleID(ctx context.Context, vehicleID string) ([]diveinspect.Inspection, error) {
+	defer r.store.lock()()
+	inspections := filter(r.store.inspections, func(i *diveinspect.Inspection) bool { return i.VehicleID == vehicleID })
+	slices.SortStableFunc(inspections, func(a, b diveinspect.Inspection) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return inspections, nil
+}
+
+func (r *MemoryInspectionRepository) Update(ctx context.Context, i *diveinspect.Inspection) error {
+	defer r.store.lock()()
+	idx := r.index(i.ID)
+	if idx < 0 {
+		return diveinspect.NewError(diveinspect.ErrInspectionNotFound).WithDetail("id", i.ID)
+	}
+	current := &r.store.inspections[idx]
+	if i.Revision != 0 && current.Revision != i.Revision {
+		return diveinspect.NewError(diveinspect.ErrRevisionConflict).WithDetail("id", i.ID)
+	}
+
+	row := *i
+	row.VehicleID = current.VehicleID
+	row.PhotosCount = current.PhotosCount
+	row.PDFURL = current.PDFURL
+	row.CreatedAt = current.CreatedAt
+	row.Revision = current.Revision + 1
+	row.UpdatedAt = time.Now()
+	*current = row
+
+	i.Revision, i.UpdatedAt = row.Revision, row.UpdatedAt
+	return nil
+}
+
+func (r *MemoryInspectionRepository) IncrementPhotosCount(ctx context.Context, id string) (int, error) {
+	defer r.store.lock()()
+	i := r.index(id)
+	if i < 0 {
+		return 0, diveinspect.NewError(diveinspect.ErrInspectionNotFound).WithDetail("id", id)
+	}
+	r.store.inspections[i].PhotosCount++
+	return r.store.inspections[i].PhotosCount, nil
+}
+
+func (r *MemoryInspectionRepository) SetPDFURL(ctx context.Context, id, pdfURL string) error {
+	defer r.store.lock()()
+	i := r.index(id)
+	if i < 0 {
+		return diveinspect.NewError(diveinspect.ErrInspectionNotFound).WithDetail("id", id)
+	}
+	r.store.inspections[i].PDFURL = &pdfURL
+	return nil
+}
+
+// Delete removes the inspection with its findings and photos.
+func (r *MemoryInspectionRepository) Delete(ctx context.Context, id string) error {
+	defer r.store.lock()()
+	i := r.index(id)
+	if i < 0 {
+		return diveinspect.NewError(diveinspect.ErrInspectionNotFound).WithDetail("id", id)
+	}
+	t := &r.store.tables
+	t.inspections = slices.Delete(t.inspections, i, i+1)
+	t.findings = slices.DeleteFunc(t.findings, func(f diveinspect.InspectionFinding) bool { return f.InspectionID == id })
+	t.photos = slices.DeleteFunc(t.photos, func(p diveinspect.InspectionPhoto) bool { return p.InspectionID == id })
+	return nil
+}
+
+func (r *MemoryInspectionRepository) index(id string) int {
+	return indexOf(r.store.inspections, func(i *diveinspect.Inspection) bool { return i.ID == id })
+}
+
+// ============================================================================
+// Inspection Finding Repository
+// ============================================================================
+
+type MemoryInspectionFindingRepository struct {
+	store *Store
+}
+
+func NewMemoryInspectionFindingRepository(store *Store) *MemoryInspectionFindingRepository {
+	return &MemoryInspectionFindingRepository{store: store}
+}
+
+func (r *MemoryInspectionFindingRepository) CreateBatch(ctx context.Context, findings []diveinspect.InspectionFinding) error {
+	defer r.store.lock()()
+	for i := range findings {
+		if findings[i].ID == "" {
+			findings[i].ID = uuid.New().String()
+		}
+		row := findings[i]
+		row.Revision = 1
+		r.store.findings = append(r.store.findings, row)
+	}
+	return nil
+}
+
+func (r *MemoryInspectionFindingRepository) GetByID(ctx context.Context, id string) (*diveinspect.InspectionFinding, error) {
+	defer r.store.lock()()
+	i := indexOf(r.store.findings, func(f *diveinspect.InspectionFinding) bool { return f.ID == id })
+	if i < 0 {
+		return nil, diveinspect.NewError(diveinspect.ErrFindingNotFound).WithDetail("id", id)
+	}
+	f := r.store.findings[i]
+	return &f, nil
+}
+
+// GetByInspectionID orders findings like the Postgres repository: severity
+// descending as text, then zone.
+func (r *MemoryInspectionFindingRepository) GetByInspectionID(ctx context.Context, inspectionID string) ([]diveinspect.InspectionFinding, error) {
+	defer r.store.lock()()
+	findings := filter(r.store.findings, func(f *diveinspect.InspectionFinding) bool { return f.InspectionID == inspectionID })
+	slices.SortStableFunc(findings, func(a, b diveinspect.InspectionFinding) int {
+		return cmp.Or(cmp.Compare(b.Severity, a.Severity), cmp.Compare(a.Zone, b.Zone))
+	})
+	return findings, nil
+}
+
+// Update saves f and bumps its revision. When f.Revision is set the row must
+// still be at that revision, otherwise the update fails with a conflict.
+func (r *MemoryInspectionFindingRepository) Update(ctx context.Context, f *diveinspect.InspectionFinding) error {
+	defer r.store.lock()()
+	i := indexOf(r.store.findings, func(row *diveinspect.InspectionFinding) bool { return row.ID == f.ID })
+	if i < 0 {
+		return diveinspect.NewError(diveinspect.ErrFindingNotFound).WithDetail("id", f.ID)
+	}
+	row := &r.store.findings[i]
+	if f.Revision != 0 && row.Revision != f.Revision {
+		return diveinspect.NewError(diveinspect.ErrRevisionConflict).WithDetail("id", f.ID)
+	}
+
+	row.Zone = f.Zone
+	row.FindingType = f.FindingType
+	row.Severity = f.Severity
+	row.Description = f.Description
+	row.ConfirmedByHuman = f.ConfirmedByHuman
+	row.Revision++
+
+	f.InspectionID, f.Revision = row.InspectionID, row.Revision
+	return nil
+}
+
+func (r *MemoryInspectionFindingRepository) Delete(ctx context.Context, id string) error {
+	defer r.store.lock()()
+	r.store.findings = slices.DeleteFunc(r.store.findings, func(f diveinspect.InspectionFinding) bool { return f.ID == id })
+	return nil
+}
+
+// ============================================================================
+// Inspection Photo Repository
+// ============================================================================
+
+type MemoryInspectionPhotoRepository struct {
+	store *Store
+}
+
+func NewMemoryInspectionPhotoRepository(store *Store) *MemoryInspectionPhotoRepository {
+	return &MemoryInspectionPhotoRepository{store: store}
+}
+
+func (r *MemoryInspectionPhotoRepository) Create(ctx context.Context, p *diveinspect.InspectionPhoto) error {
+	defer r.store.lock()()
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	p.UploadedAt = time.Now()
+	r.store.photos = append(r.store.photos, *p)
+	return nil
+}
+
+func (r *MemoryInspectionPhotoRepository) GetByInspectionID(ctx context.Context, inspectionID string) ([]diveinspect.InspectionPhoto, error) {
+	defer r.store.lock()()
+	photos := filter(r.store.photos, func(p *diveinspect.InspectionPhoto) bool { return p.InspectionID == inspectionID })
+	slices.SortStableFunc(photos, func(a, b diveinspect.InspectionPhoto) int { return cmp.Compare(a.SortOrder, b.SortOrder) })
+	return photos, nil
+}
+
+func (r *MemoryInspectionPhotoRepository) Delete(ctx context.Context, id string) error {
+	defer r.store.lock()()
+	r.store.photos = slices.DeleteFunc(r.store.photos, func(p diveinspect.InspectionPhoto) bool { return p.ID == id })
+	return nil
+}
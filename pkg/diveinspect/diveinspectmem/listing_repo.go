@@ -0,0 +1,280 @@
+package diveinspectmem
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/google/uuid"
+)
+
+// ============================================================================
+// Generated Listing Repository
+// ============================================================================
+
+type MemoryGeneratedListingRepository struct {
+	store *Store
+}
+
+func NewMemoryGeneratedListingRepository(store *Store) *MemoryGeneratedListingRepository {
+	return &MemoryGeneratedListingRepository{store: store}
+}
+
+func (r *MemoryGeneratedListingRepository) Upsert(ctx context.Context, l *diveinspect.GeneratedListing) error {
+	defer r.store.lock()()
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+	i := indexOf(r.store.listings, func(row *diveinspect.GeneratedListing) bool { return row.VehicleID == l.VehicleID })
+	if i < 0 {
+		r.store.listings = append(r.store.listings, *l)
+		return nil
+	}
+	// Like ON CONFLICT, the stored row keeps its id
+	row := *l
+	row.ID = r.store.listings[i].ID
+	r.store.listings[i] = row
+	return nil
+}
+
+func (r *MemoryGeneratedListingRepository) GetByVehicleID(ctx context.Context, vehicleID string) (*diveinspect.GeneratedListing, error) {
+	defer r.store.lock()()
+	i := indexOf(r.store.listings, func(l *diveinspect.GeneratedListing) bool { return l.VehicleID == vehicleID })
+	if i < 0 {
+		return nil, diveinspect.NewError(diveinspect.ErrListingNotFound).WithDetail("vehicle_id", vehicleID)
+	}
+	l := r.store.listings[i]
+	return &l, nil
+}
+
+func (r *MemoryGeneratedListingRepository) Delete(ctx context.Context, vehicleID string) error {
+	defer r.store.lock()()
+	r.store.listings = slices.DeleteFunc(r.store.listings, func(l diveinspect.GeneratedListing) bool { return l.VehicleID == vehicleID })
+	return nil
+}
+
+// ============================================================================
+// Listing Variant Repository
+// ============================================================================
+
+type MemoryListingVariantRepository struct {
+	store *Store
+}
+
+func NewMemoryListingVariantRepository(store *Store) *MemoryListingVariantRepository {
+	return &MemoryListingVariantRepository{store: store}
+}
+
+func (r *MemoryListingVariantRepository) CreateVersions(ctx context.Context, variants []diveinspect.ListingVariant) error {
+	defer r.store.lock()()
+	for i := range variants {
+		v := &variants[i]
+		if v.ID == "" {
+			v.ID = uuid.New().String()
+		}
+		v.Version = 1
+		for j := range r.store.variants {
+			row := &r.store.variants[j]
+			if !sameSlot(row, v.VehicleID, v.Channel, v.Language) {
+				continue
+			}
+			if row.Version >= v.Version {
+				v.Version = row.Version + 1
+			}
+			row.IsActive = false
+		}
+		v.IsActive = true
+		r.store.variants = append(r.store.variants, *v)
+	}
+	return nil
+}
+
+func (r *MemoryListingVariantRepository) ListActive(ctx context.Context, vehicleID string) ([]diveinspect.ListingVariant, error) {
+	defer r.store.lock()()
+	variants := filter(r.store.variants, func(v *diveinspect.ListingVariant) bool { return v.VehicleID == vehicleID && v.IsActive })
+	slices.SortStableFunc(variants, func(a, b diveinspect.ListingVariant) int {
+		return cmp.Or(cmp.Compare(a.Channel, b.Channel), cmp.Compare(a.Language, b.Language))
+	})
+	return variants, nil
+}
+
+func (r *MemoryListingVariantRepository) ListHistory(ctx context.Context, vehicleID string, channel diveinspect.ListingChannel, language diveinspect.ListingLanguage) ([]diveinspect.ListingVariant, error) {
+	defer r.store.lock()()
+	variants := filter(r.store.variants, func(v *diveinspect.ListingVariant) bool { return sameSlot(v, vehicleID, channel, language) })
+	slices.SortStableFunc(variants, func(a, b diveinspect.ListingVariant) int { return cmp.Compare(b.Version, a.Version) })
+	return variants, nil
+}
+
+func (r *MemoryListingVariantRepository) Activate(ctx context.Context, vehicleID string, channel diveinspect.ListingChannel, language diveinspect.ListingLanguage, version int) (*diveinspect.ListingVariant, error) {
+	defer r.store.lock()()
+	target := indexOf(r.store.variants, func(v *diveinspect.ListingVariant) bool {
+		return sameSlot(v, vehicleID, channel, language) && v.Version == version
+	})
+	if target < 0 {
+		return nil, errx.NotFound("Listing version not found").
+			WithDetail("channel", channel).
+			WithDetail("language", language).
+			WithDetail("version", version)
+	}
+	for i := range r.store.variants {
+		if sameSlot(&r.store.variants[i], vehicleID, channel, language) {
+			r.store.variants[i].IsActive = i == target
+		}
+	}
+	v := r.store.variants[target]
+	return &v, nil
+}
+
+func sameSlot(v *diveinspect.ListingVariant, vehicleID string, channel diveinspect.ListingChannel, language diveinspect.ListingLanguage) bool {
+	return v.VehicleID == vehicleID && v.Channel == channel && v.Language == language
+}
+
+// ============================================================================
+// Brand Settings Repository
+// ============================================================================
+
+type MemoryBrandSettingsRepository struct {
+	store *Store
+}
+
+func NewMemoryBrandSettingsRepository(store *Store) *MemoryBrandSettingsRepository {
+	return &MemoryBrandSettingsRepository{store: store}
+}
+
+func (r *MemoryBrandSettingsRepository) GetByTenantID(ctx context.Context, tenantID string) (*diveinspect.BrandSettings, error) {
+	defer r.store.lock()()
+	i := indexOf(r.store.brands, func(b *diveinspect.BrandSettings) bool { return b.TenantID == tenantID })
+	if i < 0 {
+		return nil, errx.NotFound("Brand settings not found").WithDetail("tenant_id", tenantID)
+	}
+	b := r.store.brands[i]
+	return &b, nil
+}
+
+func (r *MemoryBrandSettingsRepository) Upsert(ctx context.Context, b *diveinspect.BrandSettings) error {
+	defer r.store.lock()()
+	now := time.Now()
+	b.UpdatedAt = now
+	i := indexOf(r.store.brands, func(row *diveinspect.BrandSettings) bool { return row.TenantID == b.TenantID })
+	if i < 0 {
+		b.CreatedAt = now
+		r.store.brands = append(r.store.brands, *b)
+		return nil
+	}
+	b.CreatedAt = r.store.brands[i].CreatedAt
+	r.store.brands[i] = *b
+	return nil
+}
+
+// ============================================================================
+// Enrichment Step Repository
+// ============================================================================
+
+type MemoryEnrichmentStepRepository struct {
+	store *Store
+}
+
+func NewMemoryEnrichmentStepRepository(store *Store) *MemoryEnrichmentStepRepository {
+	return &MemoryEnrichmentStepRepository{store: store}
+}
+
+func (r *MemoryEnrichmentStepRepository) Upsert(ctx context.Context, s *diveinspect.VehicleEnrichmentStep) error {
+	defer r.store.lock()()
+	s.UpdatedAt = time.Now()
+	started := 0
+	if s.Status == diveinspect.StepRunning {
+		started = 1
+	}
+
+	i := indexOf(r.store.steps, func(row *diveinspect.VehicleEnrichmentStep) bool {
+		return row.VehicleID == s.VehicleID && row.Step == s.Step
+	})
+	if i < 0 {
+		s.Attempts = started
+		r.store.steps = append(r.store.steps, *s)
+		return nil
+	}
+
+	current := r.store.steps[i]
+	s.Attempts = current.Attempts + started
+	if s.StartedAt == nil {
+		s.StartedAt = current.StartedAt
+	}
+	r.store.steps[i] = *s
+	return nil
+}
+
+// ListByVehicleID returns the steps in pipeline order.
+func (r *MemoryEnrichmentStepRepository) ListByVehicleID(ctx context.Context, vehicleID string) ([]diveinspect.VehicleEnrichmentStep, error) {
+	defer r.store.lock()()
+	steps := filter(r.store.steps, func(s *diveinspect.VehicleEnrichmentStep) bool { return s.VehicleID == vehicleID })
+	slices.SortStableFunc(steps, func(a, b diveinspect.VehicleEnrichmentStep) int {
+		return cmp.Compare(slices.Index(diveinspect.EnrichmentSteps, a.Step), slices.Index(diveinspect.EnrichmentSteps, b.Step))
+	})
+	return steps, nil
+}
+
+// ============================================================================
+// Specs Catalog Repository
+// ============================================================================
+
+type MemorySpecsCatalogRepository struct {
+	store *Store
+}
+
+func NewMemorySpecsCatalogRepository(store *Store) *MemorySpecsCatalogRepository {
+	return &MemorySpecsCatalogRepository{store: store}
+}
+
+// UpsertBatch replaces the specs of entries already in the catalog, matching
+// brand, model and version without case.
+func (r *MemorySpecsCatalogRepository) UpsertBatch(ctx context.Context, entries []diveinspect.SpecsCatalogEntry) error {
+	defer r.store.lock()()
+	now := time.Now()
+	for i := range entries {
+		if entries[i].ID == "" {
+			entries[i].ID = uuid.New().String()
+		}
+		e := entries[i]
+		j := indexOf(r.store.catalog, func(row *diveinspect.SpecsCatalogEntry) bool {
+			return strings.EqualFold(row.Brand, e.Brand) && strings.EqualFold(row.Model, e.Model) &&
+				strings.EqualFold(row.Version, e.Version) && row.Year == e.Year
+		})
+		if j >= 0 {
+			r.store.catalog[j].Specs = e.Specs
+			r.store.catalog[j].UpdatedAt = now
+			continue
+		}
+		e.CreatedAt, e.UpdatedAt = now, now
+		r.store.catalog = append(r.store.catalog, e)
+	}
+	return nil
+}
+
+func (r *MemorySpecsCatalogRepository) FindBest(ctx context.Context, brand, model string, version *string, year int) (*diveinspect.SpecsCatalogEntry, error) {
+	defer r.store.lock()()
+	v := deref(version)
+	var fallback *diveinspect.SpecsCatalogEntry
+	for i := range r.store.catalog {
+		e := &r.store.catalog[i]
+		if !strings.EqualFold(e.Brand, brand) || !strings.EqualFold(e.Model, model) || e.Year != year {
+			continue
+		}
+		if e.Version != "" && strings.EqualFold(e.Version, v) {
+			found := *e
+			return &found, nil
+		}
+		if e.Version == "" && fallback == nil {
+			fallback = e
+		}
+	}
+	if fallback == nil {
+		return nil, nil
+	}
+	found := *fallback
+	return &found, nil
+}
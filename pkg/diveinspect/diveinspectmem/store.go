@@ -0,0 +1,169 @@
+// Package diveinspectmem implements the DiveInspect ports in memory, for tests
+// and for running the services without a database. The repositories mirror the
+// Postgres ones: same ordering, same revision checks and the same not-found
+// errors.
+package diveinspectmem
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+)
+
+// Store holds every table the repositories share. Rows are kept by value in
+// insertion order; reads return copies.
+type Store struct {
+	mu   sync.Mutex
+	txMu sync.Mutex
+	tables
+}
+
+type tables struct {
+	vehicles    []diveinspect.Vehicle
+	specs       []diveinspect.VehicleSpecs
+	equipment   []diveinspect.VehicleEquipment
+	inspections []diveinspect.Inspection
+	findings    []diveinspect.InspectionFinding
+	photos      []diveinspect.InspectionPhoto
+	listings    []diveinspect.GeneratedListing
+	variants    []diveinspect.ListingVariant
+	steps       []diveinspect.VehicleEnrichmentStep
+	brands      []diveinspect.BrandSettings
+	catalog     []diveinspect.SpecsCatalogEntry
+	importJobs  []diveinspect.VehicleImportJob
+	events      []diveinspect.DomainEvent
+	endpoints   []diveinspect.WebhookEndpoint
+	deliveries  []diveinspect.WebhookDelivery
+}
+
+func NewStore() *Store {
+	return &Store{}
+}
+
+var (
+	_ diveinspect.VehicleRepository           = (*MemoryVehicleRepository)(nil)
+	_ diveinspect.VehicleSpecsRepository      = (*MemoryVehicleSpecsRepository)(nil)
+	_ diveinspect.VehicleEquipmentRepository  = (*MemoryVehicleEquipmentRepository)(nil)
+	_ diveinspect.SpecsCatalogRepository      = (*MemorySpecsCatalogRepository)(nil)
+	_ diveinspect.BrandSettingsRepository     = (*MemoryBrandSettingsRepository)(nil)
+	_ diveinspect.ListingVariantRepository    = (*MemoryListingVariantRepository)(nil)
+	_ diveinspect.InspectionRepository        = (*MemoryInspectionRepository)(nil)
+	_ diveinspect.InspectionFindingRepository = (*MemoryInspectionFindingRepository)(nil)
+	_ diveinspect.InspectionPhotoRepository   = (*MemoryInspectionPhotoRepository)(nil)
+	_ diveinspect.GeneratedListingRepository  = (*MemoryGeneratedListingRepository)(nil)
+	_ diveinspect.EnrichmentStepRepository    = (*MemoryEnrichmentStepRepository)(nil)
+	_ diveinspect.TxManager                   = (*MemoryTxManager)(nil)
+	_ diveinspect.DomainEventRepository       = (*MemoryDomainEventRepository)(nil)
+	_ diveinspect.WebhookEndpointRepository   = (*MemoryWebhookEndpointRepository)(nil)
+	_ diveinspect.WebhookDeliveryRepository   = (*MemoryWebhookDeliveryRepository)(nil)
+	_ diveinspect.VehicleImportJobRepository  = (*MemoryVehicleImportJobRepository)(nil)
+	_ diveinspect.VehicleIndexer              = (*MemoryVehicleIndexer)(nil)
+)
+
+func (t *tables) clone() tables {
+	return tables{
+		vehicles:    slices.Clone(t.vehicles),
+		specs:       slices.Clone(t.specs),
+		equipment:   slices.Clone(t.equipment),
+		inspections: slices.Clone(t.inspections),
+		findings:    slices.Clone(t.findings),
+		photos:      slices.Clone(t.photos),
+		listings:    slices.Clone(t.listings),
+		variants:    slices.Clone(t.variants),
+		steps:       slices.Clone(t.steps),
+		brands:      slices.Clone(t.brands),
+		catalog:     slices.Clone(t.catalog),
+		importJobs:  slices.Clone(t.importJobs),
+		events:      slices.Clone(t.events),
+		endpoints:   slices.Clone(t.endpoints),
+		deliveries:  slices.Clone(t.deliveries),
+	}
+}
+
+// lock takes the store for one repository call.
+func (s *Store) lock() func() {
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+// ============================================================================
+// Transactions
+// ============================================================================
+
+type txKey struct{}
+
+// MemoryTxManager runs one transaction at a time against a Store. It
+// snapshots the tables when the transaction starts and puts the snapshot back
+// if fn fails. Writes made outside a transaction while one is open are lost
+// on its rollback, so code under test should write through the context it
+// was given.
+type MemoryTxManager struct {
+	store *Store
+}
+
+func NewMemoryTxManager(store *Store) *MemoryTxManager {
+	return &MemoryTxManager{store: store}
+}
+
+// WithinTx commits when fn returns nil and rolls back otherwise. Nested calls
+// reuse the outer transaction.
+func (m *MemoryTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(bool); ok {
+		return fn(ctx)
+	}
+
+	m.store.txMu.Lock()
+	defer m.store.txMu.Unlock()
+
+	unlock := m.store.lock()
+	snapshot := m.store.tables.clone()
+	unlock()
+
+	if err := fn(context.WithValue(ctx, txKey{}, true)); err != nil {
+		unlock := m.store.lock()
+		m.store.tables = snapshot
+		unlock()
+		return err
+	}
+	return nil
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// indexOf returns the index of the first row matching, or -1.
+func indexOf[T any](rows []T, match func(*T) bool) int {
+	for i := range rows {
+		if match(&rows[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// filter returns copies of the rows matching, in table order.
+func filter[T any](rows []T, match func(*T) bool) []T {
+	var out []T
+	for i := range rows {
+		if match(&rows[i]) {
+			out = append(out, rows[i])
+		}
+	}
+	return out
+}
+
+// page returns the rows of a 1-based offset page.
+func page[T any](rows []T, page, pageSize int) []T {
+	start := (page - 1) * pageSize
+	if start < 0 {
+		start = 0
+	}
+	if start >= len(rows) {
+		return nil
+	}
+	end := min(start+pageSize, len(rows))
+	return slices.Clone(rows[start:end])
+}
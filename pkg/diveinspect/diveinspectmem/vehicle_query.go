@@ -0,0 +1,241 @@
+package diveinspectmem
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/Abraxas-365/divi/pkg/kernel"
+)
+
+var defaultVehicleSort = []diveinspect.VehicleSort{{Field: diveinspect.SortByCreatedAt, Desc: true}}
+
+// facetDimension identifies a filter dimension so facet counts can skip it.
+type facetDimension int
+
+const (
+	facetNone facetDimension = iota
+	facetBrand
+	facetModel
+	facetYear
+	facetBranch
+	facetStatus
+)
+
+// ============================================================================
+// Query
+// ============================================================================
+
+// Query filters and sorts like the Postgres repository. The cursor is the id
+// of the last vehicle returned rather than its sort key, which is enough for
+// a single process.
+func (r *MemoryVehicleRepository) Query(ctx context.Context, q diveinspect.VehicleQuery) (*diveinspect.VehicleQueryPage, error) {
+	sorts := q.Sort
+	if len(sorts) == 0 {
+		sorts = defaultVehicleSort
+	}
+	for _, s := range sorts {
+		if !sortable(s.Field) {
+			return nil, errx.Validation("Invalid sort field").WithDetail("field", s.Field)
+		}
+	}
+
+	defer r.store.lock()()
+	vehicles := r.match(q, facetNone)
+	slices.SortStableFunc(vehicles, func(a, b diveinspect.Vehicle) int {
+		for _, s := range sorts {
+			c := compareBy(a, b, s.Field)
+			if s.Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	// Only offset pages report a total, like the Postgres repository
+	total := 0
+	start := (q.Page - 1) * q.PageSize
+	if q.Cursor == "" {
+		total = len(vehicles)
+	} else {
+		raw, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+		i := slices.IndexFunc(vehicles, func(v diveinspect.Vehicle) bool { return v.ID == string(raw) })
+		if err != nil || i < 0 {
+			return nil, errx.Validation("Invalid or stale pagination cursor")
+		}
+		start = i + 1
+	}
+	start = max(start, 0)
+	if start > len(vehicles) {
+		start = len(vehicles)
+	}
+	end := min(start+q.PageSize, len(vehicles))
+	items := slices.Clone(vehicles[start:end])
+
+	result := &diveinspect.VehicleQueryPage{}
+	if end < len(vehicles) && len(items) > 0 {
+		result.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(items[len(items)-1].ID))
+		result.HasMore = true
+	}
+	result.Paginated = kernel.NewPaginated(items, q.Page, q.PageSize, total)
+	return result, nil
+}
+
+func sortable(field diveinspect.VehicleSortField) bool {
+	switch field {
+	case diveinspect.SortByCreatedAt, diveinspect.SortByUpdatedAt, diveinspect.SortByPrice,
+		diveinspect.SortByYear, diveinspect.SortByMileage, diveinspect.SortByBrand, diveinspect.SortByModel:
+		return true
+	}
+	return false
+}
+
+// compareBy orders two vehicles by one field. Vehicles without a price sort
+// as 0.
+func compareBy(a, b diveinspect.Vehicle, field diveinspect.VehicleSortField) int {
+	switch field {
+	case diveinspect.SortByCreatedAt:
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case diveinspect.SortByUpdatedAt:
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	case diveinspect.SortByPrice:
+		return cmp.Compare(deref(a.PriceUSD), deref(b.PriceUSD))
+	case diveinspect.SortByYear:
+		return cmp.Compare(a.Year, b.Year)
+	case diveinspect.SortByMileage:
+		return cmp.Compare(a.MileageKM, b.MileageKM)
+	case diveinspect.SortByBrand:
+		return cmp.Compare(a.Brand, b.Brand)
+	case diveinspect.SortByModel:
+		return cmp.Compare(a.Model, b.Model)
+	}
+	return 0
+}
+
+// ============================================================================
+// Facets
+// ============================================================================
+
+func (r *MemoryVehicleRepository) Facets(ctx context.Context, q diveinspect.VehicleQuery) (*diveinspect.VehicleFacets, error) {
+	facets := &diveinspect.VehicleFacets{}
+
+	dims := []struct {
+		dim   facetDimension
+		value func(v diveinspect.Vehicle) *string
+		out   *[]diveinspect.FacetCount
+	}{
+		{facetBrand, func(v diveinspect.Vehicle) *string { return &v.Brand }, &facets.Brands},
+		{facetModel, func(v diveinspect.Vehicle) *string { return &v.Model }, &facets.Models},
+		{facetYear, func(v diveinspect.Vehicle) *string { y := strconv.Itoa(v.Year); return &y }, &facets.Years},
+		{facetBranch, func(v diveinspect.Vehicle) *string { return v.Branch }, &facets.Branches},
+		{facetStatus, func(v diveinspect.Vehicle) *string { s := string(v.Status); return &s }, &facets.Statuses},
+	}
+
+	defer r.store.lock()()
+	for _, d := range dims {
+		counts := map[string]int{}
+		for _, v := range r.match(q, d.dim) {
+			if value := d.value(v); value != nil {
+				counts[*value]++
+			}
+		}
+
+		out := []diveinspect.FacetCount{}
+		for value, count := range counts {
+			out = append(out, diveinspect.FacetCount{Value: value, Count: count})
+		}
+		slices.SortFunc(out, func(a, b diveinspect.FacetCount) int {
+			return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Value, b.Value))
+		})
+		*d.out = out
+	}
+
+	return facets, nil
+}
+
+// ============================================================================
+// Filtering
+// ============================================================================
+
+// match returns the vehicles passing every filter in q except the one for
+// skip. The caller holds the store lock.
+func (r *MemoryVehicleRepository) match(q diveinspect.VehicleQuery, skip facetDimension) []diveinspect.Vehicle {
+	specs := make(map[string]*diveinspect.VehicleSpecs, len(r.store.specs))
+	for i := range r.store.specs {
+		specs[r.store.specs[i].VehicleID] = &r.store.specs[i]
+	}
+
+	return filter(r.store.vehicles, func(v *diveinspect.Vehicle) bool {
+		if v.DeletedAt != nil && !q.IncludeDeleted {
+			return false
+		}
+		if skip != facetBrand && !anyFold(q.Brands, &v.Brand) {
+			return false
+		}
+		if skip != facetModel && !anyFold(q.Models, &v.Model) {
+			return false
+		}
+		if skip != facetBranch && !anyFold(q.Branches, v.Branch) {
+			return false
+		}
+		if skip != facetStatus && len(q.Statuses) > 0 && !slices.Contains(q.Statuses, v.Status) {
+			return false
+		}
+
+		s := specs[v.ID]
+		if s == nil {
+			s = &diveinspect.VehicleSpecs{}
+		}
+		if !anyFold(q.FuelTypes, s.FuelType) || !anyFold(q.Drivetrains, s.Drivetrain) {
+			return false
+		}
+
+		if skip != facetYear && !within(v.Year, q.YearMin, q.YearMax) {
+			return false
+		}
+		if !within(v.MileageKM, q.MileageMin, q.MileageMax) {
+			return false
+		}
+		if (q.PriceMin != nil || q.PriceMax != nil) && (v.PriceUSD == nil || !within(*v.PriceUSD, q.PriceMin, q.PriceMax)) {
+			return false
+		}
+		return true
+	})
+}
+
+// anyFold reports whether value equals one of values ignoring case and
+// surrounding space. An empty values list matches everything.
+func anyFold(values []string, value *string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	if value == nil {
+		return false
+	}
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), *value) {
+			return true
+		}
+	}
+	return false
+}
+
+func within[T cmp.Ordered](v T, lo, hi *T) bool {
+	return (lo == nil || v >= *lo) && (hi == nil || v <= *hi)
+}
+
+func deref[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
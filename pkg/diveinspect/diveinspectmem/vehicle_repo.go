@@ -0,0 +1,363 @@
+package diveinspectmem
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/google/uuid"
+)
+
+// ============================================================================
+// Vehicle Repository
+// ============================================================================
+
+type MemoryVehicleRepository struct {
+	store *Store
+}
+
+func NewMemoryVehicleRepository(store *Store) *MemoryVehicleRepository {
+	return &MemoryVehicleRepository{store: store}
+}
+
+func (r *MemoryVehicleRepository) Create(ctx context.Context, v *diveinspect.Vehicle) error {
+	defer r.store.lock()()
+	if v.ID == "" {
+		v.ID = uuid.New().String()
+	}
+	now := time.Now()
+	v.CreatedAt, v.UpdatedAt = now, now
+
+	row := *v
+	row.Revision = 1
+	row.DeletedAt = nil
+	r.store.vehicles = append(r.store.vehicles, row)
+	return nil
+}
+
+func (r *MemoryVehicleRepository) GetByID(ctx context.Context, id string) (*diveinspect.Vehicle, error) {
+	defer r.store.lock()()
+	i := indexOf(r.store.vehicles, func(v *diveinspect.Vehicle) bool { return v.ID == id && v.DeletedAt == nil })
+	if i < 0 {
+		return nil, diveinspect.NewError(diveinspect.ErrVehicleNotFound).WithDetail("id", id)
+	}
+	v := r.store.vehicles[i]
+	return &v, nil
+}
+
+// Update saves v and bumps its revision. When v.Revision is set the row must
+// still be at that revision, otherwise the update fails with a conflict.
+func (r *MemoryVehicleRepository) Update(ctx context.Context, v *diveinspect.Vehicle) error {
+	defer r.store.lock()()
+	i := indexOf(r.store.vehicles, func(row *diveinspect.Vehicle) bool { return row.ID == v.ID })
+	if i < 0 {
+		return diveinspect.NewError(diveinspect.ErrVehicleNotFound).WithDetail("id", v.ID)
+	}
+	current := &r.store.vehicles[i]
+	if v.Revision != 0 && current.Revision != v.Revision {
+		return diveinspect.NewError(diveinspect.ErrRevisionConflict).WithDetail("id", v.ID)
+	}
+
+	row := *v
+	row.TenantID = current.TenantID
+	row.CreatedAt = current.CreatedAt
+	row.DeletedAt = current.DeletedAt
+	row.Revision = current.Revision + 1
+	row.UpdatedAt = time.Now()
+	*current = row
+
+	v.Revision, v.UpdatedAt = row.Revision, row.UpdatedAt
+	return nil
+}
+
+// Delete soft-deletes the vehicle. Its inspections, listings and files stay
+// until HardDelete, so Restore can bring it back.
+func (r *MemoryVehicleRepository) Delete(ctx context.Context, id string) error {
+	defer r.store.lock()()
+	i := indexOf(r.store.vehicles, func(v *diveinspect.Vehicle) bool { return v.ID == id && v.DeletedAt == nil })
+	if i < 0 {
+		return diveinspect.NewError(diveinspect.ErrVehicleNotFound).WithDetail("id", id)
+	}
+	now := time.Now()
+	r.store.vehicles[i].DeletedAt = &now
+	return nil
+}
+
+// Restore clears deleted_at on a soft-deleted vehicle and returns it.
+func (r *MemoryVehicleRepository) Restore(ctx context.Context, id string) (*diveinspect.Vehicle, error) {
+	defer r.store.lock()()
+	i := indexOf(r.store.vehicles, func(v *diveinspect.Vehicle) bool { return v.ID == id && v.DeletedAt != nil })
+	if i < 0 {
+		return nil, diveinspect.NewError(diveinspect.ErrVehicleNotFound).WithDetail("id", id)
+	}
+	r.store.vehicles[i].DeletedAt = nil
+	v := r.store.vehicles[i]
+	return &v, nil
+}
+
+// HardDelete removes a vehicle soft-deleted before cutoff together with the
+// rows the Postgres schema would cascade to.
+func (r *MemoryVehicleRepository) HardDelete(ctx context.Context, id string, cutoff time.Time) error {
+	defer r.store.lock()()
+	i := indexOf(r.store.vehicles, func(v *diveinspect.Vehicle) bool {
+		return v.ID == id && v.DeletedAt != nil && v.DeletedAt.Before(cutoff)
+	})
+	if i < 0 {
+		return diveinspect.NewError(diveinspect.ErrVehicleNotFound).WithDetail("id", id)
+	}
+
+	t := &r.store.tables
+	inspections := map[string]bool{}
+	for _, insp := range t.inspections {
+		if insp.VehicleID == id {
+			inspections[insp.ID] = true
+		}
+	}
+	t.vehicles = slices.Delete(t.vehicles, i, i+1)
+	t.specs = slices.DeleteFunc(t.specs, func(s diveinspect.VehicleSpecs) bool { return s.VehicleID == id })
+	t.equipment = slices.DeleteFunc(t.equipment, func(e diveinspect.VehicleEquipment) bool { return e.VehicleID == id })
+	t.listings = slices.DeleteFunc(t.listings, func(l diveinspect.GeneratedListing) bool { return l.VehicleID == id })
+	t.variants = slices.DeleteFunc(t.variants, func(v diveinspect.ListingVariant) bool { return v.VehicleID == id })
+	t.steps = slices.DeleteFunc(t.steps, func(s diveinspect.VehicleEnrichmentStep) bool { return s.VehicleID == id })
+	t.inspections = slices.DeleteFunc(t.inspections, func(insp diveinspect.Inspection) bool { return insp.VehicleID == id })
+	t.findings = slices.DeleteFunc(t.findings, func(f diveinspect.InspectionFinding) bool { return inspections[f.InspectionID] })
+	t.photos = slices.DeleteFunc(t.photos, func(p diveinspect.InspectionPhoto) bool { return inspections[p.InspectionID] })
+	return nil
+}
+
+// ListDeletedBefore returns up to limit vehicles soft-deleted before cutoff,
+// oldest first.
+func (r *MemoryVehicleRepository) ListDeletedBefore(ctx context.Context, cutoff time.Time, limit int) ([]diveinspect.Vehicle, error) {
+	defer r.store.lock()()
+	vehicles := filter(r.store.vehicles, func(v *diveinspect.Vehicle) bool {
+		return v.DeletedAt != nil && v.DeletedAt.Before(cutoff)
+	})
+	slices.SortStableFunc(vehicles, func(a, b diveinspect.Vehicle) int { return a.DeletedAt.Compare(*b.DeletedAt) })
+	if len(vehicles) > limit {
+		vehicles = vehicles[:limit]
+	}
+	return vehicles, nil
+}
+
+func (r *MemoryVehicleRepository) List(ctx context.Context, page, pageSize int) ([]diveinspect.Vehicle, int, error) {
+	return r.list(func(v *diveinspect.Vehicle) bool { return true }, page, pageSize)
+}
+
+func (r *MemoryVehicleRepository) ListByStatus(ctx context.Context, status diveinspect.VehicleStatus, page, pageSize int) ([]diveinspect.Vehicle, int, error) {
+	return r.list(func(v *diveinspect.Vehicle) bool { return v.Status == status }, page, pageSize)
+}
+
+// list returns a page of the live vehicles matching, newest first.
+func (r *MemoryVehicleRepository) list(match func(*diveinspect.Vehicle) bool, pageNum, pageSize int) ([]diveinspect.Vehicle, int, error) {
+	defer r.store.lock()()
+	vehicles := filter(r.store.vehicles, func(v *diveinspect.Vehicle) bool { return v.DeletedAt == nil && match(v) })
+	slices.SortStableFunc(vehicles, func(a, b diveinspect.Vehicle) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return page(vehicles, pageNum, pageSize), len(vehicles), nil
+}
+
+// FindByPlateOrVIN returns a vehicle whose normalized plate or VIN matches.
+// Plates are compared ignoring case, spaces and dashes.
+func (r *MemoryVehicleRepository) FindByPlateOrVIN(ctx context.Context, plate, vin *string) (*diveinspect.Vehicle, error) {
+	if plate == nil && vin == nil {
+		return nil, diveinspect.NewError(diveinspect.ErrVehicleNotFound)
+	}
+	defer r.store.lock()()
+	i := indexOf(r.store.vehicles, func(v *diveinspect.Vehicle) bool {
+		if v.DeletedAt != nil {
+			return false
+		}
+		if plate != nil && v.Plate != nil && normalizePlate(*v.Plate) == normalizePlate(*plate) {
+			return true
+		}
+		return vin != nil && v.VIN != nil && strings.EqualFold(*v.VIN, *vin)
+	})
+	if i < 0 {
+		return nil, diveinspect.NewError(diveinspect.ErrVehicleNotFound)
+	}
+	v := r.store.vehicles[i]
+	return &v, nil
+}
+
+func normalizePlate(plate string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(plate))
+}
+
+// ============================================================================
+// Vehicle Specs Repository
+// ============================================================================
+
+type MemoryVehicleSpecsRepository struct {
+	store *Store
+}
+
+func NewMemoryVehicleSpecsRepository(store *Store) *MemoryVehicleSpecsRepository {
+	return &MemoryVehicleSpecsRepository{store: store}
+}
+
+func (r *MemoryVehicleSpecsRepository) Create(ctx context.Context, s *diveinspect.VehicleSpecs) error {
+	defer r.store.lock()()
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	if s.ReviewStatus == "" {
+		s.ReviewStatus = diveinspect.SpecsApproved
+	}
+	row := *s
+	row.Revision = 1
+	r.store.specs = append(r.store.specs, row)
+	return nil
+}
+
+func (r *MemoryVehicleSpecsRepository) GetByVehicleID(ctx context.Context, vehicleID string) (*diveinspect.VehicleSpecs, error) {
+	defer r.store.lock()()
+	i := indexOf(r.store.specs, func(s *diveinspect.VehicleSpecs) bool { return s.VehicleID == vehicleID })
+	if i < 0 {
+		return nil, diveinspect.NewError(diveinspect.ErrSpecsNotFound).WithDetail("vehicle_id", vehicleID)
+	}
+	s := r.store.specs[i]
+	return &s, nil
+}
+
+// Upsert inserts or replaces the vehicle's specs and bumps their revision.
+// When s.Revision is set the stored specs must still be at that revision.
+func (r *MemoryVehicleSpecsRepository) Upsert(ctx context.Context, s *diveinspect.VehicleSpecs) error {
+	defer r.store.lock()()
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	if s.ReviewStatus == "" {
+		s.ReviewStatus = diveinspect.SpecsApproved
+	}
+
+	i := indexOf(r.store.specs, func(row *diveinspect.VehicleSpecs) bool { return row.VehicleID == s.VehicleID })
+	if i < 0 {
+		s.Revision = 1
+		r.store.specs = append(r.store.specs, *s)
+		return nil
+	}
+
+	current := &r.store.specs[i]
+	if s.Revision != 0 && current.Revision != s.Revision {
+		return diveinspect.NewError(diveinspect.ErrRevisionConflict).WithDetail("vehicle_id", s.VehicleID)
+	}
+	s.ID = current.ID
+	s.Revision = current.Revision + 1
+	*current = *s
+	return nil
+}
+
+func (r *MemoryVehicleSpecsRepository) Delete(ctx context.Context, vehicleID string) error {
+	defer r.store.lock()()
+	r.store.specs = slices.DeleteFunc(r.store.specs, func(s diveinspect.VehicleSpecs) bool { return s.VehicleID == vehicleID })
+	return nil
+}
+
+// ============================================================================
+// Vehicle Equipment Repository
+// ============================================================================
+
+type MemoryVehicleEquipmentRepository struct {
+	store *Store
+}
+
+func NewMemoryVehicleEquipmentRepository(store *Store) *MemoryVehicleEquipmentRepository {
+	return &MemoryVehicleEquipmentRepository{store: store}
+}
+
+func (r *MemoryVehicleEquipmentRepository) CreateBatch(ctx context.Context, equipment []diveinspect.VehicleEquipment) error {
+	defer r.store.lock()()
+	for i := range equipment {
+		if equipment[i].ID == "" {
+			equipment[i].ID = uuid.New().String()
+		}
+		if equipment[i].VerificationStatus == "" {
+			equipment[i].VerificationStatus = diveinspect.VerificationUnverified
+		}
+	}
+	r.store.equipment = append(r.store.equipment, equipment...)
+	return nil
+}
+
+func (r *MemoryVehicleEquipmentRepository) GetByVehicleID(ctx context.Context, vehicleID string) ([]diveinspect.VehicleEquipment, error) {
+	defer r.store.lock()()
+	equipment := filter(r.store.equipment, func(e *diveinspect.VehicleEquipment) bool { return e.VehicleID == vehicleID })
+	slices.SortStableFunc(equipment, func(a, b diveinspect.VehicleEquipment) int {
+		return cmp.Or(cmp.Compare(a.Category, b.Category), cmp.Compare(a.FeatureName, b.FeatureName))
+	})
+	return equipment, nil
+}
+
+func (r *MemoryVehicleEquipmentRepository) GetByVehicleIDAndCategory(ctx context.Context, vehicleID string, category diveinspect.EquipmentCategory) ([]diveinspect.VehicleEquipment, error) {
+	defer r.store.lock()()
+	equipment := filter(r.store.equipment, func(e *diveinspect.VehicleEquipment) bool {
+		return e.VehicleID == vehicleID && e.Category == category
+	})
+	slices.SortStableFunc(equipment, func(a, b diveinspect.VehicleEquipment) int { return cmp.Compare(a.FeatureName, b.FeatureName) })
+	return equipment, nil
+}
+
+func (r *MemoryVehicleEquipmentRepository) DeleteByVehicleID(ctx context.Context, vehicleID string) error {
+	defer r.store.lock()()
+	r.store.equipment = slices.DeleteFunc(r.store.equipment, func(e diveinspect.VehicleEquipment) bool { return e.VehicleID == vehicleID })
+	return nil
+}
+
+func (r *MemoryVehicleEquipmentRepository) UpdateVerificationBatch(ctx context.Context, equipment []diveinspect.VehicleEquipment) error {
+	defer r.store.lock()()
+	for _, eq := range equipment {
+		i := indexOf(r.store.equipment, func(row *diveinspect.VehicleEquipment) bool { return row.ID == eq.ID })
+		if i < 0 {
+			continue
+		}
+		row := &r.store.equipment[i]
+		row.IsConfirmed = eq.IsConfirmed
+		row.VerificationStatus = eq.VerificationStatus
+		row.VerificationNote = eq.VerificationNote
+		row.VerifiedAt = eq.VerifiedAt
+	}
+	return nil
+}
+
+// ============================================================================
+// Vehicle Indexer
+// ============================================================================
+
+// MemoryVehicleIndexer records which vehicles are in the search index and
+// how often each was indexed.
+type MemoryVehicleIndexer struct {
+	store   *Store
+	indexed map[string]bool
+	counts  map[string]int
+}
+
+func NewMemoryVehicleIndexer(store *Store) *MemoryVehicleIndexer {
+	return &MemoryVehicleIndexer{store: store, indexed: map[string]bool{}, counts: map[string]int{}}
+}
+
+func (x *MemoryVehicleIndexer) IndexVehicle(ctx context.Context, vehicleID string) error {
+	defer x.store.lock()()
+	x.indexed[vehicleID] = true
+	x.counts[vehicleID]++
+	return nil
+}
+
+func (x *MemoryVehicleIndexer) RemoveVehicle(ctx context.Context, vehicleID string) error {
+	defer x.store.lock()()
+	delete(x.indexed, vehicleID)
+	return nil
+}
+
+// Indexed reports whether the vehicle is currently in the index.
+func (x *MemoryVehicleIndexer) Indexed(vehicleID string) bool {
+	defer x.store.lock()()
+	return x.indexed[vehicleID]
+}
+
+// IndexCount reports how many times the vehicle was indexed.
+func (x *MemoryVehicleIndexer) IndexCount(vehicleID string) int {
+	defer x.store.lock()()
+	return x.counts[vehicleID]
+}
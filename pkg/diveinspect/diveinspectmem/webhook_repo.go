@@ -0,0 +1,340 @@
+package diveinspectmem
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ============================================================================
+// Domain Event Repository
+// ============================================================================
+
+type MemoryDomainEventRepository struct {
+	store *Store
+}
+
+func NewMemoryDomainEventRepository(store *Store) *MemoryDomainEventRepository {
+	return &MemoryDomainEventRepository{store: store}
+}
+
+func (r *MemoryDomainEventRepository) Create(ctx context.Context, e *diveinspect.DomainEvent) error {
+	defer r.store.lock()()
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	e.CreatedAt = time.Now()
+	r.store.events = append(r.store.events, *e)
+	return nil
+}
+
+func (r *MemoryDomainEventRepository) GetByID(ctx context.Context, id string) (*diveinspect.DomainEvent, error) {
+	defer r.store.lock()()
+	i := indexOf(r.store.events, func(e *diveinspect.DomainEvent) bool { return e.ID == id })
+	if i < 0 {
+		return nil, errx.NotFound("Event not found").WithDetail("id", id)
+	}
+	e := r.store.events[i]
+	return &e, nil
+}
+
+// ClaimUndispatched returns up to limit undispatched events, oldest first.
+// There is a single process, so nothing else can hold them.
+func (r *MemoryDomainEventRepository) ClaimUndispatched(ctx context.Context, limit int) ([]diveinspect.DomainEvent, error) {
+	defer r.store.lock()()
+	events := filter(r.store.events, func(e *diveinspect.DomainEvent) bool { return e.DispatchedAt == nil })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (r *MemoryDomainEventRepository) MarkDispatched(ctx context.Context, ids []string) error {
+	defer r.store.lock()()
+	now := time.Now()
+	for i := range r.store.events {
+		if slices.Contains(ids, r.store.events[i].ID) {
+			r.store.events[i].DispatchedAt = &now
+		}
+	}
+	return nil
+}
+
+// Events returns every recorded event in the order it was published.
+func (r *MemoryDomainEventRepository) Events() []diveinspect.DomainEvent {
+	defer r.store.lock()()
+	return slices.Clone(r.store.events)
+}
+
+// ============================================================================
+// Webhook Endpoint Repository
+// ============================================================================
+
+type MemoryWebhookEndpointRepository struct {
+	store *Store
+}
+
+func NewMemoryWebhookEndpointRepository(store *Store) *MemoryWebhookEndpointRepository {
+	return &MemoryWebhookEndpointRepository{store: store}
+}
+
+func (r *MemoryWebhookEndpointRepository) Create(ctx context.Context, e *diveinspect.WebhookEndpoint) error {
+	defer r.store.lock()()
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	if e.EventTypes == nil {
+		e.EventTypes = pq.StringArray{}
+	}
+	now := time.Now()
+	e.CreatedAt, e.UpdatedAt = now, now
+	r.store.endpoints = append(r.store.endpoints, *e)
+	return nil
+}
+
+func (r *MemoryWebhookEndpointRepository) GetByID(ctx context.Context, id string) (*diveinspect.WebhookEndpoint, error) {
+	defer r.store.lock()()
+	i := r.index(id)
+	if i < 0 {
+		return nil, errx.NotFound("Webhook endpoint not found").WithDetail("id", id)
+	}
+	e := r.store.endpoints[i]
+	return &e, nil
+}
+
+func (r *MemoryWebhookEndpointRepository) ListByTenantID(ctx context.Context, tenantID string) ([]diveinspect.WebhookEndpoint, error) {
+	defer r.store.lock()()
+	return filter(r.store.endpoints, func(e *diveinspect.WebhookEndpoint) bool { return e.TenantID == tenantID }), nil
+}
+
+func (r *MemoryWebhookEndpointRepository) Update(ctx context.Context, e *diveinspect.WebhookEndpoint) error {
+	defer r.store.lock()()
+	i := r.index(e.ID)
+	if i < 0 {
+		return errx.NotFound("Webhook endpoint not found").WithDetail("id", e.ID)
+	}
+	if e.EventTypes == nil {
+		e.EventTypes = pq.StringArray{}
+	}
+	row := &r.store.endpoints[i]
+	row.URL = e.URL
+	row.Secret = e.Secret
+	row.EventTypes = e.EventTypes
+	row.Description = e.Description
+	row.IsActive = e.IsActive
+	row.UpdatedAt = time.Now()
+	e.UpdatedAt = row.UpdatedAt
+	return nil
+}
+
+func (r *MemoryWebhookEndpointRepository) Delete(ctx context.Context, id string) error {
+	defer r.store.lock()()
+	i := r.index(id)
+	if i < 0 {
+		return errx.NotFound("Webhook endpoint not found").WithDetail("id", id)
+	}
+	r.store.endpoints = slices.Delete(r.store.endpoints, i, i+1)
+	r.store.deliveries = slices.DeleteFunc(r.store.deliveries, func(d diveinspect.WebhookDelivery) bool { return d.EndpointID == id })
+	return nil
+}
+
+func (r *MemoryWebhookEndpointRepository) index(id string) int {
+	return indexOf(r.store.endpoints, func(e *diveinspect.WebhookEndpoint) bool { return e.ID == id })
+}
+
+// ============================================================================
+// Webhook Delivery Repository
+// ============================================================================
+
+type MemoryWebhookDeliveryRepository struct {
+	store *Store
+}
+
+func NewMemoryWebhookDeliveryRepository(store *Store) *MemoryWebhookDeliveryRepository {
+	return &MemoryWebhookDeliveryRepository{store: store}
+}
+
+func (r *MemoryWebhookDeliveryRepository) CreateBatch(ctx context.Context, deliveries []diveinspect.WebhookDelivery) error {
+	defer r.store.lock()()
+	now := time.Now()
+	for i := range deliveries {
+		d := &deliveries[i]
+		if d.ID == "" {
+			d.ID = uuid.New().String()
+		}
+		if d.Status == "" {
+			d.Status = diveinspect.DeliveryPending
+		}
+		if d.NextAttemptAt == nil {
+			next := now
+			d.NextAttemptAt = &next
+		}
+		d.CreatedAt, d.UpdatedAt = now, now
+		r.store.deliveries = append(r.store.deliveries, *d)
+	}
+	return nil
+}
+
+func (r *MemoryWebhookDeliveryRepository) GetByID(ctx context.Context, id string) (*diveinspect.WebhookDelivery, error) {
+	defer r.store.lock()()
+	i := indexOf(r.store.deliveries, func(d *diveinspect.WebhookDelivery) bool { return d.ID == id })
+	if i < 0 {
+		return nil, errx.NotFound("Webhook delivery not found").WithDetail("id", id)
+	}
+	d := r.store.deliveries[i]
+	return &d, nil
+}
+
+// ClaimDue leases up to limit due pending or failed deliveries, earliest
+// first, by moving their next attempt forward by lease.
+func (r *MemoryWebhookDeliveryRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]diveinspect.WebhookDelivery, error) {
+	defer r.store.lock()()
+	now := time.Now()
+	var due []int
+	for i, d := range r.store.deliveries {
+		if (d.Status == diveinspect.DeliveryPending || d.Status == diveinspect.DeliveryFailed) &&
+			d.NextAttemptAt != nil && !d.NextAttemptAt.After(now) {
+			due = append(due, i)
+		}
+	}
+	slices.SortStableFunc(due, func(a, b int) int {
+		return r.store.deliveries[a].NextAttemptAt.Compare(*r.store.deliveries[b].NextAttemptAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	leased := now.Add(lease)
+	claimed := make([]diveinspect.WebhookDelivery, 0, len(due))
+	for _, i := range due {
+		next := leased
+		r.store.deliveries[i].NextAttemptAt = &next
+		claimed = append(claimed, r.store.deliveries[i])
+	}
+	return claimed, nil
+}
+
+func (r *MemoryWebhookDeliveryRepository) Update(ctx context.Context, d *diveinspect.WebhookDelivery) error {
+	defer r.store.lock()()
+	i := indexOf(r.store.deliveries, func(row *diveinspect.WebhookDelivery) bool { return row.ID == d.ID })
+	if i < 0 {
+		return errx.NotFound("Webhook delivery not found").WithDetail("id", d.ID)
+	}
+	row := &r.store.deliveries[i]
+	row.Status = d.Status
+	row.Attempts = d.Attempts
+	row.NextAttemptAt = d.NextAttemptAt
+	row.LastAttemptAt = d.LastAttemptAt
+	row.ResponseStatus = d.ResponseStatus
+	row.ResponseBody = d.ResponseBody
+	row.Error = d.Error
+	row.UpdatedAt = time.Now()
+	d.UpdatedAt = row.UpdatedAt
+	return nil
+}
+
+func (r *MemoryWebhookDeliveryRepository) List(ctx context.Context, q diveinspect.WebhookDeliveryQuery) ([]diveinspect.WebhookDelivery, int, error) {
+	defer r.store.lock()()
+	deliveries := filter(r.store.deliveries, func(d *diveinspect.WebhookDelivery) bool {
+		return d.TenantID == q.TenantID &&
+			(q.EndpointID == "" || d.EndpointID == q.EndpointID) &&
+			(q.Status == "" || d.Status == q.Status)
+	})
+	slices.SortStableFunc(deliveries, func(a, b diveinspect.WebhookDelivery) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return page(deliveries, q.Page, q.PageSize), len(deliveries), nil
+}
+
+// ============================================================================
+// Vehicle Import Job Repository
+// ============================================================================
+
+type MemoryVehicleImportJobRepository struct {
+	store *Store
+}
+
+func NewMemoryVehicleImportJobRepository(store *Store) *MemoryVehicleImportJobRepository {
+	return &MemoryVehicleImportJobRepository{store: store}
+}
+
+func (r *MemoryVehicleImportJobRepository) Create(ctx context.Context, j *diveinspect.VehicleImportJob) error {
+	defer r.store.lock()()
+	if j.ID == "" {
+		j.ID = uuid.New().String()
+	}
+	now := time.Now()
+	j.CreatedAt, j.UpdatedAt = now, now
+	r.store.importJobs = append(r.store.importJobs, *j)
+	return nil
+}
+
+func (r *MemoryVehicleImportJobRepository) GetByID(ctx context.Context, id string) (*diveinspect.VehicleImportJob, error) {
+	defer r.store.lock()()
+	i := r.index(id)
+	if i < 0 {
+		return nil, errx.NotFound("Import job not found").WithDetail("id", id)
+	}
+	j := r.store.importJobs[i]
+	return &j, nil
+}
+
+func (r *MemoryVehicleImportJobRepository) Update(ctx context.Context, j *diveinspect.VehicleImportJob) error {
+	defer r.store.lock()()
+	i := r.index(j.ID)
+	if i < 0 {
+		return errx.NotFound("Import job not found").WithDetail("id", j.ID)
+	}
+	row := &r.store.importJobs[i]
+	row.Status = j.Status
+	row.Mapping = j.Mapping
+	row.EnrichCreated = j.EnrichCreated
+	row.TotalRows = j.TotalRows
+	row.CreatedCount = j.CreatedCount
+	row.DuplicateCount = j.DuplicateCount
+	row.FailedCount = j.FailedCount
+	row.Results = j.Results
+	row.Error = j.Error
+	row.CompletedAt = j.CompletedAt
+	row.UpdatedAt = time.Now()
+	j.UpdatedAt = row.UpdatedAt
+	return nil
+}
+
+// ClaimNext moves the oldest queued job, or a running job whose lease has
+// expired, to running and leases it for lease. Returns nil, nil when no job
+// is waiting.
+func (r *MemoryVehicleImportJobRepository) ClaimNext(ctx context.Context, lease time.Duration) (*diveinspect.VehicleImportJob, error) {
+	defer r.store.lock()()
+	now := time.Now()
+	i := indexOf(r.store.importJobs, func(j *diveinspect.VehicleImportJob) bool {
+		return j.Status == diveinspect.ImportQueued ||
+			(j.Status == diveinspect.ImportRunning && (j.LockedUntil == nil || j.LockedUntil.Before(now)))
+	})
+	if i < 0 {
+		return nil, nil
+	}
+	lockedUntil := now.Add(lease)
+	r.store.importJobs[i].Status = diveinspect.ImportRunning
+	r.store.importJobs[i].LockedUntil = &lockedUntil
+	j := r.store.importJobs[i]
+	return &j, nil
+}
+
+func (r *MemoryVehicleImportJobRepository) ExtendLease(ctx context.Context, id string, lease time.Duration) error {
+	defer r.store.lock()()
+	i := r.index(id)
+	if i < 0 || r.store.importJobs[i].Status != diveinspect.ImportRunning {
+		return errx.NotFound("Running import job not found").WithDetail("id", id)
+	}
+	lockedUntil := time.Now().Add(lease)
+	r.store.importJobs[i].LockedUntil = &lockedUntil
+	return nil
+}
+
+func (r *MemoryVehicleImportJobRepository) index(id string) int {
+	return indexOf(r.store.importJobs, func(j *diveinspect.VehicleImportJob) bool { return j.ID == id })
+}
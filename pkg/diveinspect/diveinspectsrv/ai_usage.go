@@ -0,0 +1,34 @@
+package diveinspectsrv
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/divi/pkg/ai/aiusage"
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+)
+
+// Features the AI usage ledger reports spend under
+const (
+	featureEnrichment = "enrichment"
+	featureListing    = "listing"
+	featureVision     = "vision"
+	featureSearch     = "search"
+)
+
+// visionModel is asked for by the multi-modal calls; fallback providers map
+// it to their own model
+const visionModel = "gpt-4o"
+
+// usageContext tags AI calls made with the returned context so the ledger
+// bills them to the vehicle's tenant under the given feature.
+func usageContext(ctx context.Context, vehicle *diveinspect.Vehicle, feature string) context.Context {
+	ctx = aiusage.WithFeature(ctx, feature)
+	if vehicle == nil {
+		return ctx
+	}
+	ctx = aiusage.WithSubject(ctx, vehicle.ID)
+	if vehicle.TenantID != nil {
+		ctx = aiusage.WithTenant(ctx, *vehicle.TenantID)
+	}
+	return ctx
+}
@@ -0,0 +1,38 @@
+package diveinspectsrv
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/divi/pkg/audit"
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/kernel"
+)
+
+// Entity types DiveInspect writes to the audit log
+const (
+	auditVehicle    = "vehicle"
+	auditSpecs      = "vehicle_specs"
+	auditInspection = "inspection"
+	auditFinding    = "finding"
+)
+
+// vehicleChange describes a change to the vehicle or one of the entities it
+// owns. The vehicle is the subject, so the entry shows in its history.
+func vehicleChange(vehicle *diveinspect.Vehicle, entityType, entityID string, action audit.Action, before, after any) audit.Change {
+	return audit.Change{
+		TenantID:   vehicle.TenantID,
+		EntityType: entityType,
+		EntityID:   entityID,
+		SubjectID:  &vehicle.ID,
+		Action:     action,
+		Before:     before,
+		After:      after,
+	}
+}
+
+// systemContext drops the caller from ctx so the audit log credits the system
+// with writes the enrichment pipeline decides on, even when a request started
+// the run.
+func systemContext(ctx context.Context) context.Context {
+	return kernel.WithAuthContext(ctx, nil)
+}
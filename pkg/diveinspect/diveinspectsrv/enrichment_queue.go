@@ -0,0 +1,70 @@
+package diveinspectsrv
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/logx"
+)
+
+// EnrichmentQueue runs vehicle enrichment in the background, one vehicle at
+// a time, so bulk operations don't hold a request open for LLM calls.
+type EnrichmentQueue struct {
+	vehicleRepo   diveinspect.VehicleRepository
+	enrichmentSvc *EnrichmentService
+	pending       chan string
+}
+
+func NewEnrichmentQueue(
+	vehicleRepo diveinspect.VehicleRepository,
+	enrichmentSvc *EnrichmentService,
+	capacity int,
+) *EnrichmentQueue {
+	return &EnrichmentQueue{
+		vehicleRepo:   vehicleRepo,
+		enrichmentSvc: enrichmentSvc,
+		pending:       make(chan string, capacity),
+	}
+}
+
+// Enqueue schedules a vehicle for enrichment, waiting for queue space until
+// ctx is done.
+func (q *EnrichmentQueue) Enqueue(ctx context.Context, vehicleID string) error {
+	select {
+	case q.pending <- vehicleID:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Start drains the queue until ctx is cancelled.
+func (q *EnrichmentQueue) Start(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			logx.Info("Enrichment queue stopped")
+			return
+		case vehicleID := <-q.pending:
+			q.process(ctx, vehicleID)
+		}
+	}
+}
+
+func (q *EnrichmentQueue) process(ctx context.Context, vehicleID string) {
+	vehicle, err := q.vehicleRepo.GetByID(ctx, vehicleID)
+	if err != nil {
+		logx.Warnf("Skipping queued enrichment for vehicle %s: %v", vehicleID, err)
+		return
+	}
+	steps, err := q.enrichmentSvc.EnrichVehicle(ctx, vehicle)
+	if err != nil {
+		logx.Errorf("Queued enrichment failed for vehicle %s: %v", vehicleID, err)
+		return
+	}
+	for _, step := range steps {
+		if step.Status == diveinspect.StepFailed {
+			logx.Warnf("Queued enrichment for vehicle %s left step %s failed", vehicleID, step.Step)
+		}
+	}
+}
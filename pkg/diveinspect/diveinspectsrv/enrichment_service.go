@@ -2,11 +2,14 @@ package diveinspectsrv
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"strings"
 	"time"
 
 	"github.com/Abraxas-365/divi/pkg/ai/llm"
+	"github.com/Abraxas-365/divi/pkg/audit"
 	"github.com/Abraxas-365/divi/pkg/diveinspect"
 	"github.com/Abraxas-365/divi/pkg/errx"
 	"github.com/Abraxas-365/divi/pkg/logx"
@@ -17,6 +20,14 @@ type EnrichmentService struct {
 	specsRepo     diveinspect.VehicleSpecsRepository
 	equipmentRepo diveinspect.VehicleEquipmentRepository
 	listingRepo   diveinspect.GeneratedListingRepository
+	listingSvc    *ListingService
+	vehicleRepo   diveinspect.VehicleRepository
+	specsSource   diveinspect.SpecsSource
+	stepRepo      diveinspect.EnrichmentStepRepository
+	indexer       diveinspect.VehicleIndexer
+	txManager     diveinspect.TxManager
+	events        *EventPublisher
+	auditLog      audit.Recorder
 }
 
 func NewEnrichmentService(
@@ -24,186 +35,367 @@ func NewEnrichmentService(
 	specsRepo diveinspect.VehicleSpecsRepository,
 	equipmentRepo diveinspect.VehicleEquipmentRepository,
 	listingRepo diveinspect.GeneratedListingRepository,
+	listingSvc *ListingService,
+	vehicleRepo diveinspect.VehicleRepository,
+	specsSource diveinspect.SpecsSource,
+	stepRepo diveinspect.EnrichmentStepRepository,
+	indexer diveinspect.VehicleIndexer,
+	txManager diveinspect.TxManager,
+	events *EventPublisher,
+	auditLog audit.Recorder,
 ) *EnrichmentService {
 	return &EnrichmentService{
 		llmClient:     llmClient,
 		specsRepo:     specsRepo,
 		equipmentRepo: equipmentRepo,
 		listingRepo:   listingRepo,
+		listingSvc:    listingSvc,
+		vehicleRepo:   vehicleRepo,
+		specsSource:   specsSource,
+		stepRepo:      stepRepo,
+		indexer:       indexer,
+		txManager:     txManager,
+		events:        events,
+		auditLog:      auditLog,
 	}
 }
 
-// EnrichVehicle runs the full enrichment pipeline: specs + equipment + listing description
-func (s *EnrichmentService) EnrichVehicle(ctx context.Context, vehicle *diveinspect.Vehicle) error {
-	logx.Infof("Starting enrichment for vehicle %s: %s %s %d", vehicle.ID, vehicle.Brand, vehicle.Model, vehicle.Year)
+// EnrichVehicle runs the given enrichment steps in pipeline order. With no
+// steps it resumes: only steps that have not succeeded yet are run, so a retry
+// doesn't re-bill the LLM calls that already went through. Every step commits
+// its writes and its status in one transaction; a failed step is recorded and
+// the remaining steps still run. The returned error covers only failures to
+// read or record step state; step failures are reported in the returned steps.
+func (s *EnrichmentService) EnrichVehicle(ctx context.Context, vehicle *diveinspect.Vehicle, steps ...diveinspect.EnrichmentStep) ([]diveinspect.VehicleEnrichmentStep, error) {
+	for _, step := range steps {
+		if !step.IsValid() {
+			return nil, errx.Validation("Unknown enrichment step").WithDetail("step", string(step))
+		}
+	}
+
+	if len(steps) == 0 {
+		state, err := s.stepRepo.ListByVehicleID(ctx, vehicle.ID)
+		if err != nil {
+			return nil, errx.Wrap(err, "Failed to get enrichment status", errx.TypeInternal)
+		}
+		succeeded := make(map[diveinspect.EnrichmentStep]bool, len(state))
+		for _, st := range state {
+			succeeded[st.Step] = st.Status == diveinspect.StepSucceeded
+		}
+		for _, step := range diveinspect.EnrichmentSteps {
+			if !succeeded[step] {
+				steps = append(steps, step)
+			}
+		}
+	}
+
+	requested := make(map[diveinspect.EnrichmentStep]bool, len(steps))
+	for _, step := range steps {
+		requested[step] = true
+	}
+
+	logx.Infof("Starting enrichment for vehicle %s: %s %s %d, steps=%v", vehicle.ID, vehicle.Brand, vehicle.Model, vehicle.Year, steps)
 
-	// Step 1: Enrich specs
-	specs, err := s.enrichSpecs(ctx, vehicle)
+	for _, step := range diveinspect.EnrichmentSteps {
+		if !requested[step] {
+			continue
+		}
+		if err := s.runStep(ctx, vehicle, step); err != nil {
+			logx.Errorf("Enrichment step %s failed for vehicle %s: %v", step, vehicle.ID, err)
+		}
+	}
+	syncIndex(ctx, s.indexer, vehicle.ID)
+
+	state, err := s.stepRepo.ListByVehicleID(ctx, vehicle.ID)
+	if err != nil {
+		return nil, errx.Wrap(err, "Failed to get enrichment status", errx.TypeInternal)
+	}
+	return state, nil
+}
+
+// Status returns the last recorded outcome of each enrichment step.
+func (s *EnrichmentService) Status(ctx context.Context, vehicleID string) ([]diveinspect.VehicleEnrichmentStep, error) {
+	state, err := s.stepRepo.ListByVehicleID(ctx, vehicleID)
+	if err != nil {
+		return nil, errx.Wrap(err, "Failed to get enrichment status", errx.TypeInternal)
+	}
+	return state, nil
+}
+
+// RegenerateListing rewrites the listing from the vehicle's current specs and
+// confirmed equipment, e.g. after a photo verification pass, and refreshes its
+// JSON-LD.
+func (s *EnrichmentService) RegenerateListing(ctx context.Context, vehicle *diveinspect.Vehicle) (*diveinspect.GeneratedListing, error) {
+	if err := s.runStep(ctx, vehicle, diveinspect.StepListing); err != nil {
+		return nil, err
+	}
+	syncIndex(ctx, s.indexer, vehicle.ID)
+	if err := s.runStep(ctx, vehicle, diveinspect.StepJSONLD); err != nil {
+		logx.Warnf("Failed to refresh JSON-LD for vehicle %s: %v", vehicle.ID, err)
+	}
+	logx.Infof("Listing regenerated for vehicle %s", vehicle.ID)
+	return s.listingRepo.GetByVehicleID(ctx, vehicle.ID)
+}
+
+// runStep records the step as running, runs it and records a failure. The
+// step itself records success inside the transaction that holds its writes.
+func (s *EnrichmentService) runStep(ctx context.Context, vehicle *diveinspect.Vehicle, step diveinspect.EnrichmentStep) error {
+	started := time.Now()
+	if err := s.stepRepo.Upsert(ctx, &diveinspect.VehicleEnrichmentStep{
+		VehicleID: vehicle.ID,
+		Step:      step,
+		Status:    diveinspect.StepRunning,
+		StartedAt: &started,
+	}); err != nil {
+		return errx.Wrap(err, "Failed to record enrichment step", errx.TypeInternal)
+	}
+
+	feature := featureEnrichment
+	if step == diveinspect.StepListing || step == diveinspect.StepJSONLD {
+		feature = featureListing
+	}
+	ctx = usageContext(ctx, vehicle, feature)
+
+	var err error
+	switch step {
+	case diveinspect.StepSpecs:
+		err = s.runSpecsStep(ctx, vehicle)
+	case diveinspect.StepEquipment:
+		err = s.runEquipmentStep(ctx, vehicle)
+	case diveinspect.StepListing:
+		err = s.runListingStep(ctx, vehicle)
+	case diveinspect.StepJSONLD:
+		err = s.runJSONLDStep(ctx, vehicle)
+	}
+	if err == nil {
+		return nil
+	}
+
+	now := time.Now()
+	msg := err.Error()
+	if recErr := s.stepRepo.Upsert(ctx, &diveinspect.VehicleEnrichmentStep{
+		VehicleID:   vehicle.ID,
+		Step:        step,
+		Status:      diveinspect.StepFailed,
+		Error:       &msg,
+		CompletedAt: &now,
+	}); recErr != nil {
+		logx.Errorf("Failed to record failed enrichment step %s for vehicle %s: %v", step, vehicle.ID, recErr)
+	}
+	return err
+}
+
+// markStep records a step outcome without touching its attempt count.
+func (s *EnrichmentService) markStep(ctx context.Context, vehicleID string, step diveinspect.EnrichmentStep, status diveinspect.EnrichmentStepStatus) error {
+	var completed *time.Time
+	if status == diveinspect.StepSucceeded {
+		now := time.Now()
+		completed = &now
+	}
+	return s.stepRepo.Upsert(ctx, &diveinspect.VehicleEnrichmentStep{
+		VehicleID:   vehicleID,
+		Step:        step,
+		Status:      status,
+		CompletedAt: completed,
+	})
+}
+
+// fetchSpecs asks the specs source for specs that keep the fields a person
+// corrected in current. A chain is seeded with them; any other source's
+// answer gets them copied back.
+func (s *EnrichmentService) fetchSpecs(ctx context.Context, vehicle *diveinspect.Vehicle, current *diveinspect.VehicleSpecs) (*diveinspect.VehicleSpecs, error) {
+	if chain, ok := s.specsSource.(*SpecsChain); ok {
+		return chain.FetchSpecsOver(ctx, vehicle, current)
+	}
+	specs, err := s.specsSource.FetchSpecs(ctx, vehicle)
+	if err != nil || specs == nil {
+		return specs, err
+	}
+	if manual := keepManualSpecs(specs, current); len(manual) > 0 {
+		provenance := diveinspect.SpecsProvenance{}
+		maps.Copy(provenance, specs.Provenance)
+		for _, field := range manual {
+			provenance[field] = specsManualSource
+		}
+		specs.Provenance = provenance
+	}
+	return specs, nil
+}
+
+func (s *EnrichmentService) runSpecsStep(ctx context.Context, vehicle *diveinspect.Vehicle) error {
+	current, err := s.specsRepo.GetByVehicleID(ctx, vehicle.ID)
+	if err != nil {
+		if !isNotFound(err) {
+			return errx.Wrap(err, "Failed to load vehicle specs", errx.TypeInternal)
+		}
+		current = nil
+	}
+
+	specs, err := s.fetchSpecs(ctx, vehicle, current)
+	if err == nil && specs == nil {
+		err = fmt.Errorf("no specs source returned data")
+	}
 	if err != nil {
-		logx.Errorf("Failed to enrich specs for vehicle %s: %v", vehicle.ID, err)
 		return errx.Wrap(err, "Failed to enrich vehicle specs", errx.TypeExternal)
 	}
 
-	if err := s.specsRepo.Upsert(ctx, specs); err != nil {
-		return errx.Wrap(err, "Failed to save enriched specs", errx.TypeInternal)
+	if current != nil {
+		// Save over the specs that were read, so a correction made while
+		// the sources were asked conflicts instead of being lost
+		specs.Revision = current.Revision
+		// Approved specs stay approved unless a source changed a value
+		if current.ReviewStatus == diveinspect.SpecsApproved && len(changedSpecFields(current, specs)) == 0 {
+			specs.ReviewStatus = diveinspect.SpecsApproved
+		}
+	}
+
+	// Low-confidence specs hold the vehicle in review until a person checks them
+	toReview := specs.ReviewStatus == diveinspect.SpecsNeedsReview && vehicle.Status == diveinspect.VehicleStatusDraft
+
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		action, before := audit.ActionCreate, any(nil)
+		if current != nil {
+			action, before = audit.ActionUpdate, current
+		}
+		if err := s.specsRepo.Upsert(ctx, specs); err != nil {
+			var conflict *errx.Error
+			if errors.As(err, &conflict) && conflict.Code == diveinspect.ErrRevisionConflict.Code {
+				return err
+			}
+			return errx.Wrap(err, "Failed to save enriched specs", errx.TypeInternal)
+		}
+		if err := s.auditLog.RecordChange(systemContext(ctx), vehicleChange(vehicle, auditSpecs, vehicle.ID, action, before, specs)); err != nil {
+			return err
+		}
+		if toReview {
+			// Re-read so an edit made while the specs were fetched isn't
+			// lost; the revision check catches one made since.
+			current, err := s.vehicleRepo.GetByID(ctx, vehicle.ID)
+			if err != nil {
+				return err
+			}
+			if current.Status == diveinspect.VehicleStatusDraft {
+				before := *current
+				current.Status = diveinspect.VehicleStatusReview
+				if err := s.vehicleRepo.Update(ctx, current); err != nil {
+					return err
+				}
+				if err := s.auditLog.RecordChange(systemContext(ctx), vehicleChange(current, auditVehicle, current.ID, audit.ActionUpdate, &before, current)); err != nil {
+					return err
+				}
+			}
+		}
+		return s.markStep(ctx, vehicle.ID, diveinspect.StepSpecs, diveinspect.StepSucceeded)
+	})
+	if err != nil {
+		return err
+	}
+
+	if toReview {
+		vehicle.Status = diveinspect.VehicleStatusReview
+		logx.Warnf("Vehicle %s routed to manual review: specs confidence %.2f", vehicle.ID, *specs.SpecsConfidence)
 	}
 	logx.Infof("Specs enriched for vehicle %s", vehicle.ID)
+	return nil
+}
 
-	// Step 2: Enrich equipment
+func (s *EnrichmentService) runEquipmentStep(ctx context.Context, vehicle *diveinspect.Vehicle) error {
 	equipment, err := s.enrichEquipment(ctx, vehicle)
 	if err != nil {
-		logx.Errorf("Failed to enrich equipment for vehicle %s: %v", vehicle.ID, err)
 		return errx.Wrap(err, "Failed to enrich vehicle equipment", errx.TypeExternal)
 	}
 
-	// Replace existing equipment
-	_ = s.equipmentRepo.DeleteByVehicleID(ctx, vehicle.ID)
-	if err := s.equipmentRepo.CreateBatch(ctx, equipment); err != nil {
-		return errx.Wrap(err, "Failed to save enriched equipment", errx.TypeInternal)
-	}
-	logx.Infof("Equipment enriched for vehicle %s: %d features", vehicle.ID, len(equipment))
-
-	// Step 3: Generate listing description
-	listing, err := s.generateListing(ctx, vehicle, specs, equipment)
+	// Replace existing equipment, keeping what photo verification already established
+	existing, err := s.equipmentRepo.GetByVehicleID(ctx, vehicle.ID)
 	if err != nil {
-		logx.Errorf("Failed to generate listing for vehicle %s: %v", vehicle.ID, err)
-		return errx.Wrap(err, "Failed to generate listing", errx.TypeExternal)
+		return errx.Wrap(err, "Failed to get vehicle equipment", errx.TypeInternal)
 	}
+	equipment = mergeVerifiedEquipment(equipment, existing)
 
-	if err := s.listingRepo.Upsert(ctx, listing); err != nil {
-		return errx.Wrap(err, "Failed to save generated listing", errx.TypeInternal)
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.equipmentRepo.DeleteByVehicleID(ctx, vehicle.ID); err != nil {
+			return errx.Wrap(err, "Failed to replace vehicle equipment", errx.TypeInternal)
+		}
+		if err := s.equipmentRepo.CreateBatch(ctx, equipment); err != nil {
+			return errx.Wrap(err, "Failed to save enriched equipment", errx.TypeInternal)
+		}
+		return s.markStep(ctx, vehicle.ID, diveinspect.StepEquipment, diveinspect.StepSucceeded)
+	})
+	if err != nil {
+		return err
 	}
-	logx.Infof("Listing generated for vehicle %s", vehicle.ID)
 
+	logx.Infof("Equipment enriched for vehicle %s: %d features", vehicle.ID, len(equipment))
 	return nil
 }
 
-func (s *EnrichmentService) enrichSpecs(ctx context.Context, vehicle *diveinspect.Vehicle) (*diveinspect.VehicleSpecs, error) {
-	version := ""
-	if vehicle.Version != nil {
-		version = *vehicle.Version
-	}
-	trim := ""
-	if vehicle.Trim != nil {
-		trim = *vehicle.Trim
+func (s *EnrichmentService) runListingStep(ctx context.Context, vehicle *diveinspect.Vehicle) error {
+	specs, _ := s.specsRepo.GetByVehicleID(ctx, vehicle.ID)
+	equipment, err := s.equipmentRepo.GetByVehicleID(ctx, vehicle.ID)
+	if err != nil {
+		return errx.Wrap(err, "Failed to get vehicle equipment", errx.TypeInternal)
 	}
 
-	prompt := fmt.Sprintf(`You are a vehicle specifications expert. Given the following vehicle identification, provide the complete factory specifications.
+	listing, err := s.generateListing(ctx, vehicle, specs, confirmedEquipment(equipment))
+	if err != nil {
+		return errx.Wrap(err, "Failed to generate listing", errx.TypeExternal)
+	}
 
-Vehicle: %s %s %s %s %d
+	// The new listing has no JSON-LD yet, so that step has to run again
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.listingRepo.Upsert(ctx, listing); err != nil {
+			return errx.Wrap(err, "Failed to save generated listing", errx.TypeInternal)
+		}
+		if err := s.markStep(ctx, vehicle.ID, diveinspect.StepJSONLD, diveinspect.StepPending); err != nil {
+			return err
+		}
+		if err := s.markStep(ctx, vehicle.ID, diveinspect.StepListing, diveinspect.StepSucceeded); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, vehicle.TenantID, diveinspect.EventListingGenerated, vehicle.ID, listing)
+	})
+	if err != nil {
+		return err
+	}
 
-Return a JSON object with these fields (use null for unknown values):
-{
-  "engine_type": "string (e.g. 'Inline-4 Turbo')",
-  "engine_cc": number,
-  "engine_cylinders": number,
-  "power_hp": number,
-  "power_kw": number,
-  "torque_nm": number,
-  "torque_rpm_range": "string (e.g. '1620-2600')",
-  "fuel_type": "string",
-  "fuel_system": "string",
-  "transmission_type": "string (e.g. '7G-DCT Doble Embrague')",
-  "transmission_gears": number,
-  "drivetrain": "string (FWD/RWD/AWD/4WD)",
-  "accel_0_100": number,
-  "top_speed_kmh": number,
-  "fuel_city_kml": number,
-  "fuel_highway_kml": number,
-  "fuel_combined_kml": number,
-  "fuel_tank_liters": number,
-  "length_mm": number,
-  "width_mm": number,
-  "height_mm": number,
-  "wheelbase_mm": number,
-  "cargo_liters": number,
-  "cargo_max_liters": number,
-  "curb_weight_kg": number,
-  "tire_size": "string",
-  "spare_tire": "string"
+	logx.Infof("Listing generated for vehicle %s", vehicle.ID)
+	return nil
 }
 
-Only return the JSON object, no additional text.`, vehicle.Brand, vehicle.Model, version, trim, vehicle.Year)
+func (s *EnrichmentService) runJSONLDStep(ctx context.Context, vehicle *diveinspect.Vehicle) error {
+	listing, err := s.listingRepo.GetByVehicleID(ctx, vehicle.ID)
+	if err != nil {
+		return errx.Validation("Listing has not been generated yet, run the listing step first")
+	}
+	specs, _ := s.specsRepo.GetByVehicleID(ctx, vehicle.ID)
 
-	resp, err := s.llmClient.Chat(ctx, []llm.Message{
-		llm.NewSystemMessage("You are a precise vehicle specifications database. Return only valid JSON with factory specs for the given vehicle. Be accurate and use real data from manufacturer catalogs."),
-		llm.NewUserMessage(prompt),
-	}, llm.WithJSONMode(), llm.WithTemperature(0.1))
+	jsonLD, err := buildListingJSONLD(vehicle, specs, listing)
 	if err != nil {
-		return nil, err
+		return errx.Wrap(err, "Failed to build listing JSON-LD", errx.TypeInternal)
 	}
+	listing.SchemaJSONLD = &jsonLD
 
-	var specsData struct {
-		EngineType        *string  `json:"engine_type"`
-		EngineCC          *int     `json:"engine_cc"`
-		EngineCylinders   *int     `json:"engine_cylinders"`
-		PowerHP           *float64 `json:"power_hp"`
-		PowerKW           *float64 `json:"power_kw"`
-		TorqueNM          *int     `json:"torque_nm"`
-		TorqueRPMRange    *string  `json:"torque_rpm_range"`
-		FuelType          *string  `json:"fuel_type"`
-		FuelSystem        *string  `json:"fuel_system"`
-		TransmissionType  *string  `json:"transmission_type"`
-		TransmissionGears *int     `json:"transmission_gears"`
-		Drivetrain        *string  `json:"drivetrain"`
-		Accel0100         *float64 `json:"accel_0_100"`
-		TopSpeedKMH       *int     `json:"top_speed_kmh"`
-		FuelCityKML       *float64 `json:"fuel_city_kml"`
-		FuelHighwayKML    *float64 `json:"fuel_highway_kml"`
-		FuelCombinedKML   *float64 `json:"fuel_combined_kml"`
-		FuelTankLiters    *int     `json:"fuel_tank_liters"`
-		LengthMM          *int     `json:"length_mm"`
-		WidthMM           *int     `json:"width_mm"`
-		HeightMM          *int     `json:"height_mm"`
-		WheelbaseMM       *int     `json:"wheelbase_mm"`
-		CargoLiters       *int     `json:"cargo_liters"`
-		CargoMaxLiters    *int     `json:"cargo_max_liters"`
-		CurbWeightKG      *int     `json:"curb_weight_kg"`
-		TireSize          *string  `json:"tire_size"`
-		SpareTire         *string  `json:"spare_tire"`
-	}
-
-	if err := json.Unmarshal([]byte(resp.Message.Content), &specsData); err != nil {
-		return nil, fmt.Errorf("failed to parse specs response: %w", err)
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.listingRepo.Upsert(ctx, listing); err != nil {
+			return errx.Wrap(err, "Failed to save listing JSON-LD", errx.TypeInternal)
+		}
+		return s.markStep(ctx, vehicle.ID, diveinspect.StepJSONLD, diveinspect.StepSucceeded)
+	})
+	if err != nil {
+		return err
 	}
 
-	now := time.Now()
-	source := "llm-enrichment"
-	confidence := 0.85
-
-	return &diveinspect.VehicleSpecs{
-		VehicleID:         vehicle.ID,
-		EngineType:        specsData.EngineType,
-		EngineCC:          specsData.EngineCC,
-		EngineCylinders:   specsData.EngineCylinders,
-		PowerHP:           specsData.PowerHP,
-		PowerKW:           specsData.PowerKW,
-		TorqueNM:          specsData.TorqueNM,
-		TorqueRPMRange:    specsData.TorqueRPMRange,
-		FuelType:          specsData.FuelType,
-		FuelSystem:        specsData.FuelSystem,
-		TransmissionType:  specsData.TransmissionType,
-		TransmissionGears: specsData.TransmissionGears,
-		Drivetrain:        specsData.Drivetrain,
-		Accel0100:         specsData.Accel0100,
-		TopSpeedKMH:       specsData.TopSpeedKMH,
-		FuelCityKML:       specsData.FuelCityKML,
-		FuelHighwayKML:    specsData.FuelHighwayKML,
-		FuelCombinedKML:   specsData.FuelCombinedKML,
-		FuelTankLiters:    specsData.FuelTankLiters,
-		LengthMM:          specsData.LengthMM,
-		WidthMM:           specsData.WidthMM,
-		HeightMM:          specsData.HeightMM,
-		WheelbaseMM:       specsData.WheelbaseMM,
-		CargoLiters:       specsData.CargoLiters,
-		CargoMaxLiters:    specsData.CargoMaxLiters,
-		CurbWeightKG:      specsData.CurbWeightKG,
-		TireSize:          specsData.TireSize,
-		SpareTire:         specsData.SpareTire,
-		SpecsSource:       &source,
-		SpecsConfidence:   &confidence,
-		EnrichedAt:        &now,
-	}, nil
+	logx.Infof("JSON-LD generated for vehicle %s", vehicle.ID)
+	return nil
+}
+
+// equipmentAnswer is the model's list of a trim's standard equipment
+type equipmentAnswer struct {
+	Items []struct {
+		Category    diveinspect.EquipmentCategory `json:"category" enum:"safety,comfort,infotainment,exterior,interior"`
+		FeatureName string                        `json:"feature_name"`
+		Description string                        `json:"feature_description" description:"Brief description in Spanish"`
+	} `json:"items"`
 }
 
 func (s *EnrichmentService) enrichEquipment(ctx context.Context, vehicle *diveinspect.Vehicle) ([]diveinspect.VehicleEquipment, error) {
@@ -220,40 +412,23 @@ func (s *EnrichmentService) enrichEquipment(ctx context.Context, vehicle *divein
 
 Vehicle: %s %s %s %s %d
 
-Return a JSON array of equipment items grouped by category. Each item:
-{
-  "category": "safety|comfort|infotainment|exterior|interior",
-  "feature_name": "string",
-  "feature_description": "string (brief description in Spanish)"
-}
+Group the equipment items by category.
+Include ALL standard features for this specific trim level. Be comprehensive.`, vehicle.Brand, vehicle.Model, version, trim, vehicle.Year)
 
-Include ALL standard features for this specific trim level. Be comprehensive.
-Only return the JSON array, no additional text.`, vehicle.Brand, vehicle.Model, version, trim, vehicle.Year)
-
-	resp, err := s.llmClient.Chat(ctx, []llm.Message{
-		llm.NewSystemMessage("You are a comprehensive vehicle equipment database. Return only valid JSON arrays with all standard equipment features for the given vehicle trim. Write descriptions in Spanish. Be thorough and accurate."),
+	answer, _, err := llm.ChatStructured[equipmentAnswer](ctx, s.llmClient, []llm.Message{
+		llm.NewSystemMessage("You are a comprehensive vehicle equipment database. List all standard equipment features for the given vehicle trim. Write descriptions in Spanish. Be thorough and accurate."),
 		llm.NewUserMessage(prompt),
-	}, llm.WithJSONMode(), llm.WithTemperature(0.1))
+	}, llm.WithTemperature(0.1))
 	if err != nil {
 		return nil, err
 	}
 
-	var items []struct {
-		Category    string `json:"category"`
-		FeatureName string `json:"feature_name"`
-		Description string `json:"feature_description"`
-	}
-
-	if err := json.Unmarshal([]byte(resp.Message.Content), &items); err != nil {
-		return nil, fmt.Errorf("failed to parse equipment response: %w", err)
-	}
-
-	equipment := make([]diveinspect.VehicleEquipment, 0, len(items))
-	for _, item := range items {
+	equipment := make([]diveinspect.VehicleEquipment, 0, len(answer.Items))
+	for _, item := range answer.Items {
 		desc := item.Description
 		eq := diveinspect.VehicleEquipment{
 			VehicleID:          vehicle.ID,
-			Category:           diveinspect.EquipmentCategory(item.Category),
+			Category:           item.Category,
 			FeatureName:        item.FeatureName,
 			FeatureDescription: &desc,
 			IsStandard:         true,
@@ -266,7 +441,56 @@ Only return the JSON array, no additional text.`, vehicle.Brand, vehicle.Model,
 	return equipment, nil
 }
 
+// listingAnswer is the model's listing copy
+type listingAnswer struct {
+	Title         string   `json:"title" description:"SEO-optimized title including brand, model, year and a key differentiator"`
+	DescriptionES string   `json:"description_es" description:"Professional sales description in Spanish"`
+	DescriptionEN string   `json:"description_en" description:"The same description in English"`
+	DescriptionPT string   `json:"description_pt" description:"The same description in Brazilian Portuguese"`
+	SEOKeywords   []string `json:"seo_keywords"`
+}
+
 func (s *EnrichmentService) generateListing(ctx context.Context, vehicle *diveinspect.Vehicle, specs *diveinspect.VehicleSpecs, equipment []diveinspect.VehicleEquipment) (*diveinspect.GeneratedListing, error) {
+	brand := s.listingSvc.BrandSettings(ctx, vehicle.TenantID)
+	style := brand.StyleFor(diveinspect.ChannelWebsite)
+
+	warranty := ""
+	if brand.WarrantyText != nil && *brand.WarrantyText != "" {
+		warranty = ", mention the warranty"
+	}
+
+	prompt := fmt.Sprintf(`Generate a professional vehicle sales listing for:
+
+%s
+Rules:
+- Title: at most %d characters
+- Spanish description: 150-250 words, at most %d characters. Highlight key features, low mileage if applicable%s
+- Tone: %s`, listingFacts(vehicle, specs, equipment),
+		style.MaxTitleChars, style.MaxBodyChars, warranty, style.Tone)
+
+	listingData, _, err := llm.ChatStructured[listingAnswer](ctx, s.llmClient, []llm.Message{
+		llm.NewSystemMessage(brandSystemPrompt(brand)),
+		llm.NewUserMessage(prompt),
+	}, llm.WithTemperature(0.5))
+	if err != nil {
+		return nil, err
+	}
+
+	title := truncateText(listingData.Title, style.MaxTitleChars)
+	return &diveinspect.GeneratedListing{
+		VehicleID:     vehicle.ID,
+		Title:         &title,
+		DescriptionES: &listingData.DescriptionES,
+		DescriptionEN: &listingData.DescriptionEN,
+		DescriptionPT: &listingData.DescriptionPT,
+		SEOKeywords:   listingData.SEOKeywords,
+		GeneratedAt:   time.Now(),
+	}, nil
+}
+
+// listingFacts renders what listing copy may say about a vehicle. equipment
+// should already be limited to confirmed items.
+func listingFacts(vehicle *diveinspect.Vehicle, specs *diveinspect.VehicleSpecs, equipment []diveinspect.VehicleEquipment) string {
 	version := ""
 	if vehicle.Version != nil {
 		version = *vehicle.Version
@@ -308,9 +532,7 @@ func (s *EnrichmentService) generateListing(ctx context.Context, vehicle *divein
 		}
 	}
 
-	prompt := fmt.Sprintf(`Generate a professional vehicle sales listing in Spanish for:
-
-Vehicle: %s %s %s %d
+	return fmt.Sprintf(`Vehicle: %s %s %s %d
 Kilometraje: %d km
 Color: %s
 Origen: %s
@@ -320,44 +542,56 @@ Precio: %s
 Specs: %s
 
 Equipment highlights:
-%s
-
-Generate a JSON response:
-{
-  "title": "SEO-optimized title (max 100 chars, include brand, model, year, key differentiator)",
-  "description_es": "Professional sales description in Spanish (150-250 words). Highlight key features, low mileage if applicable, mention Divemotor warranty. Professional but attractive tone. SEO-friendly.",
-  "description_en": "Same description in English",
-  "seo_keywords": ["keyword1", "keyword2", ...]
+%s`, vehicle.Brand, vehicle.Model, version, vehicle.Year,
+		vehicle.MileageKM, color, origin, branch, price, specsSummary, equipSummary)
 }
 
-Only return the JSON object.`, vehicle.Brand, vehicle.Model, version, vehicle.Year,
-		vehicle.MileageKM, color, origin, branch, price, specsSummary, equipSummary)
+// confirmedEquipment returns only the items verified on the actual vehicle.
+// Listings never advertise equipment that hasn't been confirmed.
+func confirmedEquipment(equipment []diveinspect.VehicleEquipment) []diveinspect.VehicleEquipment {
+	confirmed := make([]diveinspect.VehicleEquipment, 0, len(equipment))
+	for _, eq := range equipment {
+		if eq.IsConfirmed {
+			confirmed = append(confirmed, eq)
+		}
+	}
+	return confirmed
+}
 
-	resp, err := s.llmClient.Chat(ctx, []llm.Message{
-		llm.NewSystemMessage("You are an expert automotive copywriter for Divemotor, the leading Mercedes-Benz dealer in Peru. Write compelling, SEO-optimized vehicle listings. Always mention Garantía Divemotor. Return only valid JSON."),
-		llm.NewUserMessage(prompt),
-	}, llm.WithJSONMode(), llm.WithTemperature(0.5))
-	if err != nil {
-		return nil, err
+// mergeVerifiedEquipment carries verification results from a previous
+// equipment list over to a freshly enriched one, matching by feature name,
+// and keeps items that didn't come from the factory spec (visual detections,
+// manual input).
+func mergeVerifiedEquipment(fresh, existing []diveinspect.VehicleEquipment) []diveinspect.VehicleEquipment {
+	previous := make(map[string]diveinspect.VehicleEquipment, len(existing))
+	for _, eq := range existing {
+		if eq.Source == diveinspect.SourceFactorySpec {
+			previous[equipmentKey(eq.FeatureName)] = eq
+		}
 	}
 
-	var listingData struct {
-		Title         string   `json:"title"`
-		DescriptionES string   `json:"description_es"`
-		DescriptionEN string   `json:"description_en"`
-		SEOKeywords   []string `json:"seo_keywords"`
+	seen := make(map[string]bool, len(fresh))
+	for i := range fresh {
+		key := equipmentKey(fresh[i].FeatureName)
+		seen[key] = true
+		if prev, ok := previous[key]; ok {
+			fresh[i].IsConfirmed = prev.IsConfirmed
+			fresh[i].VerificationStatus = prev.VerificationStatus
+			fresh[i].VerificationNote = prev.VerificationNote
+			fresh[i].VerifiedAt = prev.VerifiedAt
+		}
 	}
 
-	if err := json.Unmarshal([]byte(resp.Message.Content), &listingData); err != nil {
-		return nil, fmt.Errorf("failed to parse listing response: %w", err)
+	for _, eq := range existing {
+		if eq.Source == diveinspect.SourceFactorySpec || seen[equipmentKey(eq.FeatureName)] {
+			continue
+		}
+		eq.ID = ""
+		fresh = append(fresh, eq)
 	}
+	return fresh
+}
 
-	return &diveinspect.GeneratedListing{
-		VehicleID:     vehicle.ID,
-		Title:         &listingData.Title,
-		DescriptionES: &listingData.DescriptionES,
-		DescriptionEN: &listingData.DescriptionEN,
-		SEOKeywords:   listingData.SEOKeywords,
-		GeneratedAt:   time.Now(),
-	}, nil
+func equipmentKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
 }
@@ -0,0 +1,332 @@
+package diveinspectsrv
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/Abraxas-365/divi/pkg/kernel"
+)
+
+const (
+	equipmentPrompt = "vehicle equipment expert"
+	listingPrompt   = "Generate a professional vehicle sales listing"
+
+	equipmentReply = `{"items": [
+		{"category": "safety", "feature_name": "ABS", "feature_description": "Frenos antibloqueo"},
+		{"category": "comfort", "feature_name": "Climatizador", "feature_description": "Aire acondicionado automático"}
+	]}`
+	listingReply = `{
+		"title": "Toyota RAV4 2022",
+		"description_es": "SUV en excelente estado.",
+		"description_en": "SUV in great condition.",
+		"description_pt": "SUV em ótimo estado.",
+		"seo_keywords": ["toyota", "rav4"]
+	}`
+)
+
+// stubSpecsSource returns the same specs for every vehicle, or err. onFetch
+// runs while the specs are being fetched.
+type stubSpecsSource struct {
+	specs   diveinspect.VehicleSpecs
+	err     error
+	calls   int
+	onFetch func()
+}
+
+func (s *stubSpecsSource) Name() string { return "stub" }
+
+func (s *stubSpecsSource) FetchSpecs(ctx context.Context, v *diveinspect.Vehicle) (*diveinspect.VehicleSpecs, error) {
+	s.calls++
+	if s.onFetch != nil {
+		s.onFetch()
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	specs := s.specs
+	specs.VehicleID = v.ID
+	return &specs, nil
+}
+
+func (e *testEnv) enrichmentService(model llm.LLM, source diveinspect.SpecsSource) *EnrichmentService {
+	client := llm.NewClient(model)
+	return NewEnrichmentService(
+		client, e.specs, e.equipment, e.listings, e.listingService(client), e.vehicles, source, e.steps, e.indexer, e.tx, e.publisher, e.audit,
+	)
+}
+
+func stepStatuses(steps []diveinspect.VehicleEnrichmentStep) map[diveinspect.EnrichmentStep]diveinspect.EnrichmentStepStatus {
+	statuses := make(map[diveinspect.EnrichmentStep]diveinspect.EnrichmentStepStatus, len(steps))
+	for _, s := range steps {
+		statuses[s.Step] = s.Status
+	}
+	return statuses
+}
+
+func TestEnrichmentServiceEnrichVehicle(t *testing.T) {
+	approved := diveinspect.VehicleSpecs{ReviewStatus: diveinspect.SpecsApproved, SpecsConfidence: ptr(0.95)}
+	succeeded := diveinspect.StepSucceeded
+
+	tests := []struct {
+		name       string
+		specs      *stubSpecsSource
+		replies    []scriptedReply
+		want       map[diveinspect.EnrichmentStep]diveinspect.EnrichmentStepStatus
+		wantStatus diveinspect.VehicleStatus
+	}{
+		{
+			name:    "every step succeeds",
+			specs:   &stubSpecsSource{specs: approved},
+			replies: []scriptedReply{{match: equipmentPrompt, content: equipmentReply}, {match: listingPrompt, content: listingReply}},
+			want: map[diveinspect.EnrichmentStep]diveinspect.EnrichmentStepStatus{
+				diveinspect.StepSpecs: succeeded, diveinspect.StepEquipment: succeeded,
+				diveinspect.StepListing: succeeded, diveinspect.StepJSONLD: succeeded,
+			},
+			wantStatus: diveinspect.VehicleStatusDraft,
+		},
+		{
+			name:  "equipment model fails",
+			specs: &stubSpecsSource{specs: approved},
+			replies: []scriptedReply{
+				{match: equipmentPrompt, err: errors.New("model overloaded")},
+				{match: listingPrompt, content: listingReply},
+			},
+			want: map[diveinspect.EnrichmentStep]diveinspect.EnrichmentStepStatus{
+				diveinspect.StepSpecs: succeeded, diveinspect.StepEquipment: diveinspect.StepFailed,
+				diveinspect.StepListing: succeeded, diveinspect.StepJSONLD: succeeded,
+			},
+			wantStatus: diveinspect.VehicleStatusDraft,
+		},
+		{
+			name:    "specs source fails",
+			specs:   &stubSpecsSource{err: errors.New("catalog offline")},
+			replies: []scriptedReply{{match: equipmentPrompt, content: equipmentReply}, {match: listingPrompt, content: listingReply}},
+			want: map[diveinspect.EnrichmentStep]diveinspect.EnrichmentStepStatus{
+				diveinspect.StepSpecs: diveinspect.StepFailed, diveinspect.StepEquipment: succeeded,
+				diveinspect.StepListing: succeeded, diveinspect.StepJSONLD: succeeded,
+			},
+			wantStatus: diveinspect.VehicleStatusDraft,
+		},
+		{
+			name: "low-confidence specs hold the vehicle in review",
+			specs: &stubSpecsSource{specs: diveinspect.VehicleSpecs{
+				ReviewStatus: diveinspect.SpecsNeedsReview, SpecsConfidence: ptr(0.4),
+			}},
+			replies: []scriptedReply{{match: equipmentPrompt, content: equipmentReply}, {match: listingPrompt, content: listingReply}},
+			want: map[diveinspect.EnrichmentStep]diveinspect.EnrichmentStepStatus{
+				diveinspect.StepSpecs: succeeded, diveinspect.StepEquipment: succeeded,
+				diveinspect.StepListing: succeeded, diveinspect.StepJSONLD: succeeded,
+			},
+			wantStatus: diveinspect.VehicleStatusReview,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newTestEnv(t)
+			v := env.addVehicle(t, diveinspect.Vehicle{})
+
+			steps, err := env.enrichmentService(newScriptedLLM(tt.replies...), tt.specs).EnrichVehicle(ctx, v)
+			if err != nil {
+				t.Fatalf("EnrichVehicle: %v", err)
+			}
+			got := stepStatuses(steps)
+			for step, want := range tt.want {
+				if got[step] != want {
+					t.Errorf("step %s = %s, want %s", step, got[step], want)
+				}
+			}
+
+			stored, err := env.vehicles.GetByID(ctx, v.ID)
+			if err != nil {
+				t.Fatalf("GetByID: %v", err)
+			}
+			if stored.Status != tt.wantStatus {
+				t.Fatalf("vehicle status = %s, want %s", stored.Status, tt.wantStatus)
+			}
+
+			if tt.want[diveinspect.StepListing] == succeeded {
+				listing, err := env.listings.GetByVehicleID(ctx, v.ID)
+				if err != nil {
+					t.Fatalf("GetByVehicleID: %v", err)
+				}
+				if listing.SchemaJSONLD == nil {
+					t.Fatal("listing has no JSON-LD")
+				}
+			}
+		})
+	}
+}
+
+func TestEnrichmentServiceResumeRunsOnlyFailedSteps(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	v := env.addVehicle(t, diveinspect.Vehicle{})
+	source := &stubSpecsSource{specs: diveinspect.VehicleSpecs{ReviewStatus: diveinspect.SpecsApproved}}
+
+	failing := newScriptedLLM(
+		scriptedReply{match: equipmentPrompt, err: errors.New("model overloaded")},
+		scriptedReply{match: listingPrompt, content: listingReply},
+	)
+	if _, err := env.enrichmentService(failing, source).EnrichVehicle(ctx, v); err != nil {
+		t.Fatalf("EnrichVehicle: %v", err)
+	}
+
+	healthy := newScriptedLLM(
+		scriptedReply{match: equipmentPrompt, content: equipmentReply},
+		scriptedReply{match: listingPrompt, content: listingReply},
+	)
+	steps, err := env.enrichmentService(healthy, source).EnrichVehicle(ctx, v)
+	if err != nil {
+		t.Fatalf("resume EnrichVehicle: %v", err)
+	}
+
+	for step, status := range stepStatuses(steps) {
+		if status != diveinspect.StepSucceeded {
+			t.Errorf("step %s = %s after resume, want succeeded", step, status)
+		}
+	}
+	if source.calls != 1 {
+		t.Errorf("specs fetched %d times, want 1", source.calls)
+	}
+	if n := healthy.callCount(equipmentPrompt); n != 1 {
+		t.Errorf("equipment calls on resume = %d, want 1", n)
+	}
+	if n := healthy.callCount(listingPrompt); n != 0 {
+		t.Errorf("listing calls on resume = %d, want 0", n)
+	}
+
+	equipment, err := env.equipment.GetByVehicleID(ctx, v.ID)
+	if err != nil {
+		t.Fatalf("GetByVehicleID: %v", err)
+	}
+	if len(equipment) != 2 {
+		t.Fatalf("equipment = %d items, want 2", len(equipment))
+	}
+}
+
+func TestEnrichmentServiceRejectsUnknownStep(t *testing.T) {
+	env := newTestEnv(t)
+	v := env.addVehicle(t, diveinspect.Vehicle{})
+
+	_, err := env.enrichmentService(newScriptedLLM(), &stubSpecsSource{}).EnrichVehicle(context.Background(), v, "paint")
+	assertType(t, err, errx.TypeValidation)
+}
+
+func TestEnrichmentServiceReindexesVehicle(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	v := env.addVehicle(t, diveinspect.Vehicle{Status: diveinspect.VehicleStatusPublished})
+	source := &stubSpecsSource{specs: diveinspect.VehicleSpecs{ReviewStatus: diveinspect.SpecsApproved}}
+	svc := env.enrichmentService(newScriptedLLM(
+		scriptedReply{match: equipmentPrompt, content: equipmentReply},
+		scriptedReply{match: listingPrompt, content: listingReply},
+	), source)
+
+	if _, err := svc.EnrichVehicle(ctx, v); err != nil {
+		t.Fatalf("EnrichVehicle: %v", err)
+	}
+	if n := env.indexer.IndexCount(v.ID); n != 1 {
+		t.Fatalf("indexed %d times after enrichment, want 1", n)
+	}
+	if _, err := svc.RegenerateListing(ctx, v); err != nil {
+		t.Fatalf("RegenerateListing: %v", err)
+	}
+	if n := env.indexer.IndexCount(v.ID); n != 2 {
+		t.Fatalf("indexed %d times after regenerating the listing, want 2", n)
+	}
+}
+
+func TestEnrichmentServiceAuditsAsSystem(t *testing.T) {
+	env := newTestEnv(t)
+	v := env.addVehicle(t, diveinspect.Vehicle{})
+	source := &stubSpecsSource{specs: diveinspect.VehicleSpecs{ReviewStatus: diveinspect.SpecsNeedsReview, SpecsConfidence: ptr(0.4)}}
+	svc := env.enrichmentService(newScriptedLLM(), source)
+
+	// A user's request starts the run, but the pipeline makes the changes
+	userID := kernel.UserID("user-1")
+	ctx := kernel.WithAuthContext(context.Background(), &kernel.AuthContext{UserID: &userID, TenantID: "tenant-1"})
+	if _, err := svc.EnrichVehicle(ctx, v, diveinspect.StepSpecs); err != nil {
+		t.Fatalf("EnrichVehicle: %v", err)
+	}
+
+	var entities []string
+	for i, c := range env.audit.changes {
+		entities = append(entities, c.EntityType+":"+string(c.Action))
+		if env.audit.byCaller[i] {
+			t.Errorf("%s change recorded for the caller, want the system", c.EntityType)
+		}
+	}
+	want := []string{"vehicle_specs:create", "vehicle:update"}
+	if !slices.Equal(entities, want) {
+		t.Fatalf("audited %v, want %v", entities, want)
+	}
+}
+
+func TestEnrichmentServiceKeepsManualSpecs(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	v := env.addVehicle(t, diveinspect.Vehicle{})
+	source := &stubSpecsSource{specs: diveinspect.VehicleSpecs{PowerHP: ptr(150.0), EngineCC: ptr(1998), SpecsConfidence: ptr(0.95)}}
+	svc := env.enrichmentService(newScriptedLLM(), NewSpecsChain(source))
+	enrich := func() diveinspect.EnrichmentStepStatus {
+		t.Helper()
+		steps, err := svc.EnrichVehicle(ctx, v, diveinspect.StepSpecs)
+		if err != nil {
+			t.Fatalf("EnrichVehicle: %v", err)
+		}
+		return stepStatuses(steps)[diveinspect.StepSpecs]
+	}
+	stored := func() *diveinspect.VehicleSpecs {
+		t.Helper()
+		specs, err := env.specs.GetByVehicleID(ctx, v.ID)
+		if err != nil {
+			t.Fatalf("GetByVehicleID: %v", err)
+		}
+		return specs
+	}
+
+	enrich()
+	if _, err := env.vehicleService().PatchSpecs(ctx, v.ID, []byte(`{"power_hp": 180}`), nil); err != nil {
+		t.Fatalf("PatchSpecs: %v", err)
+	}
+
+	// Unchanged values keep the approval even from an unsure source
+	source.specs.SpecsConfidence = ptr(0.2)
+	enrich()
+	specs := stored()
+	if *specs.PowerHP != 180 || specs.Provenance["power_hp"] != "manual" {
+		t.Fatalf("power_hp = %v from %q, want the correction kept", *specs.PowerHP, specs.Provenance["power_hp"])
+	}
+	if specs.ReviewStatus != diveinspect.SpecsApproved {
+		t.Fatalf("review status = %s, want approved while no value changed", specs.ReviewStatus)
+	}
+
+	// A changed value the reviewer didn't correct needs a new review
+	source.specs.EngineCC = ptr(2000)
+	enrich()
+	specs = stored()
+	if *specs.EngineCC != 2000 || *specs.PowerHP != 180 || specs.ReviewStatus != diveinspect.SpecsNeedsReview {
+		t.Fatalf("specs = engine %d, power %v, %s; want the new engine held for review",
+			*specs.EngineCC, *specs.PowerHP, specs.ReviewStatus)
+	}
+
+	// A correction made while the sources are asked wins
+	source.onFetch = func() {
+		if _, err := env.vehicleService().PatchSpecs(ctx, v.ID, []byte(`{"engine_cc": 1995}`), nil); err != nil {
+			t.Fatalf("PatchSpecs: %v", err)
+		}
+	}
+	if status := enrich(); status != diveinspect.StepFailed {
+		t.Fatalf("racing step = %s, want failed", status)
+	}
+	if specs := stored(); *specs.EngineCC != 1995 {
+		t.Fatalf("engine_cc = %d, want the racing correction", *specs.EngineCC)
+	}
+}
@@ -0,0 +1,262 @@
+package diveinspectsrv
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/Abraxas-365/divi/pkg/fsx"
+	"github.com/Abraxas-365/divi/pkg/logx"
+)
+
+// maxVerificationPhotos bounds how many images go into a single vision call.
+const maxVerificationPhotos = 8
+
+// EquipmentVerificationService checks listed equipment against cabin photos
+// with a multi-modal model. The client must reach a provider that accepts
+// images.
+type EquipmentVerificationService struct {
+	visionClient   *llm.Client
+	fs             fsx.FileSystem
+	inspectionRepo diveinspect.InspectionRepository
+	photoRepo      diveinspect.InspectionPhotoRepository
+	equipmentRepo  diveinspect.VehicleEquipmentRepository
+	enrichmentSvc  *EnrichmentService
+}
+
+func NewEquipmentVerificationService(
+	visionClient *llm.Client,
+	fs fsx.FileSystem,
+	inspectionRepo diveinspect.InspectionRepository,
+	photoRepo diveinspect.InspectionPhotoRepository,
+	equipmentRepo diveinspect.VehicleEquipmentRepository,
+	enrichmentSvc *EnrichmentService,
+) *EquipmentVerificationService {
+	return &EquipmentVerificationService{
+		visionClient:   visionClient,
+		fs:             fs,
+		inspectionRepo: inspectionRepo,
+		photoRepo:      photoRepo,
+		equipmentRepo:  equipmentRepo,
+		enrichmentSvc:  enrichmentSvc,
+	}
+}
+
+type equipmentVerificationResponse struct {
+	Confirmed []struct {
+		ID       string `json:"id" description:"Equipment id exactly as given"`
+		Evidence string `json:"evidence" description:"What in the photos shows it, in Spanish"`
+	} `json:"confirmed"`
+	Missing []struct {
+		ID     string `json:"id" description:"Equipment id exactly as given"`
+		Reason string `json:"reason" description:"Why it should be visible but is not, in Spanish"`
+	} `json:"missing"`
+	Extras []struct {
+		Category    string `json:"category" enum:"safety,comfort,infotainment,exterior,interior"`
+		FeatureName string `json:"feature_name"`
+		Description string `json:"feature_description" description:"Brief description in Spanish"`
+	} `json:"extras"`
+}
+
+// VerifyEquipment checks the vehicle's listed equipment against its interior,
+// dashboard and infotainment photos. Visible items are confirmed, factory items
+// that should be visible but aren't are flagged as possibly missing, and extras
+// spotted in the photos are added as visual detections. The listing is then
+// regenerated so it only advertises confirmed equipment.
+func (s *EquipmentVerificationService) VerifyEquipment(ctx context.Context, vehicle *diveinspect.Vehicle) (*diveinspect.EquipmentVerificationResult, error) {
+	equipment, err := s.equipmentRepo.GetByVehicleID(ctx, vehicle.ID)
+	if err != nil {
+		return nil, errx.Wrap(err, "Failed to get vehicle equipment", errx.TypeInternal)
+	}
+	if len(equipment) == 0 {
+		return nil, errx.Validation("Vehicle has no equipment to verify, run enrichment first")
+	}
+
+	inspection, err := s.inspectionRepo.GetByVehicleID(ctx, vehicle.ID)
+	if err != nil {
+		return nil, err
+	}
+	photos, err := s.photoRepo.GetByInspectionID(ctx, inspection.ID)
+	if err != nil {
+		return nil, errx.Wrap(err, "Failed to get inspection photos", errx.TypeInternal)
+	}
+
+	var cabinPhotos []diveinspect.InspectionPhoto
+	for _, photo := range photos {
+		if isInteriorZone(photo.Zone) && len(cabinPhotos) < maxVerificationPhotos {
+			cabinPhotos = append(cabinPhotos, photo)
+		}
+	}
+	if len(cabinPhotos) == 0 {
+		return nil, errx.Validation("No interior, dashboard or infotainment photos uploaded for this vehicle")
+	}
+
+	logx.Infof("Verifying %d equipment items for vehicle %s against %d photos", len(equipment), vehicle.ID, len(cabinPhotos))
+
+	resp, err := s.analyzeEquipment(usageContext(ctx, vehicle, featureVision), vehicle, equipment, cabinPhotos)
+	if err != nil {
+		return nil, errx.Wrap(err, "Failed to verify equipment", errx.TypeExternal)
+	}
+
+	result := &diveinspect.EquipmentVerificationResult{
+		VehicleID:      vehicle.ID,
+		PhotosAnalyzed: len(cabinPhotos),
+	}
+	now := time.Now()
+
+	byID := make(map[string]*diveinspect.VehicleEquipment, len(equipment))
+	names := make(map[string]bool, len(equipment))
+	for i := range equipment {
+		byID[equipment[i].ID] = &equipment[i]
+		names[equipmentKey(equipment[i].FeatureName)] = true
+	}
+
+	updated := make(map[string]bool)
+	for _, c := range resp.Confirmed {
+		eq, ok := byID[c.ID]
+		if !ok {
+			continue
+		}
+		evidence := c.Evidence
+		eq.IsConfirmed = true
+		eq.VerificationStatus = diveinspect.VerificationConfirmed
+		eq.VerificationNote = &evidence
+		eq.VerifiedAt = &now
+		updated[eq.ID] = true
+	}
+	for _, m := range resp.Missing {
+		eq, ok := byID[m.ID]
+		// Only factory claims can be "missing"; a confirmed item wins over a contradictory flag
+		if !ok || eq.Source != diveinspect.SourceFactorySpec || updated[eq.ID] {
+			continue
+		}
+		reason := m.Reason
+		eq.IsConfirmed = false
+		eq.VerificationStatus = diveinspect.VerificationPossiblyMissing
+		eq.VerificationNote = &reason
+		eq.VerifiedAt = &now
+		updated[eq.ID] = true
+	}
+
+	changes := make([]diveinspect.VehicleEquipment, 0, len(updated))
+	for _, eq := range equipment {
+		if updated[eq.ID] {
+			changes = append(changes, eq)
+		}
+	}
+	if err := s.equipmentRepo.UpdateVerificationBatch(ctx, changes); err != nil {
+		return nil, errx.Wrap(err, "Failed to save equipment verification", errx.TypeInternal)
+	}
+
+	var extras []diveinspect.VehicleEquipment
+	for _, x := range resp.Extras {
+		key := equipmentKey(x.FeatureName)
+		if key == "" || names[key] {
+			continue
+		}
+		names[key] = true
+		desc := x.Description
+		extras = append(extras, diveinspect.VehicleEquipment{
+			VehicleID:          vehicle.ID,
+			Category:           normalizeEquipmentCategory(x.Category),
+			FeatureName:        strings.TrimSpace(x.FeatureName),
+			FeatureDescription: &desc,
+			IsStandard:         false,
+			IsConfirmed:        true,
+			Source:             diveinspect.SourceVisualDetection,
+			VerificationStatus: diveinspect.VerificationConfirmed,
+			VerifiedAt:         &now,
+		})
+	}
+	if len(extras) > 0 {
+		if err := s.equipmentRepo.CreateBatch(ctx, extras); err != nil {
+			return nil, errx.Wrap(err, "Failed to save detected equipment", errx.TypeInternal)
+		}
+	}
+
+	for _, eq := range append(equipment, extras...) {
+		switch eq.VerificationStatus {
+		case diveinspect.VerificationConfirmed:
+			result.Confirmed++
+		case diveinspect.VerificationPossiblyMissing:
+			result.PossiblyMissing++
+		}
+	}
+	result.ExtrasDetected = len(extras)
+	result.Equipment = append(equipment, extras...)
+
+	// A regenerated listing re-indexes the vehicle; without one the
+	// verified equipment still has to reach the index
+	if _, err := s.enrichmentSvc.RegenerateListing(ctx, vehicle); err != nil {
+		logx.Warnf("Failed to regenerate listing after equipment verification for vehicle %s: %v", vehicle.ID, err)
+		syncIndex(ctx, s.enrichmentSvc.indexer, vehicle.ID)
+	}
+
+	logx.Infof("Equipment verified for vehicle %s: confirmed=%d, possibly_missing=%d, extras=%d",
+		vehicle.ID, result.Confirmed, result.PossiblyMissing, result.ExtrasDetected)
+	return result, nil
+}
+
+func (s *EquipmentVerificationService) analyzeEquipment(ctx context.Context, vehicle *diveinspect.Vehicle, equipment []diveinspect.VehicleEquipment, photos []diveinspect.InspectionPhoto) (*equipmentVerificationResponse, error) {
+	version := ""
+	if vehicle.Version != nil {
+		version = *vehicle.Version
+	}
+
+	var list strings.Builder
+	for _, eq := range equipment {
+		fmt.Fprintf(&list, "- id=%s [%s] %s\n", eq.ID, eq.Category, eq.FeatureName)
+	}
+
+	brand := s.enrichmentSvc.listingSvc.BrandSettings(ctx, vehicle.TenantID)
+	systemPrompt := fmt.Sprintf(`%s
+
+You are given photos of the interior, dashboard and infotainment system of a %s %s %s %d, and the equipment list claimed for this vehicle:
+
+%s
+Rules:
+- Only confirm a feature you can clearly see (a button, screen, control, badge or component)
+- Only mark as missing a feature whose location is clearly shown in the photos and is absent there
+- Features that cannot be judged from these photos go in neither list
+- Extras are clearly visible features that are not in the list above
+- Use the ids exactly as given`, inspectorIdentity(brand), vehicle.Brand, vehicle.Model, version, vehicle.Year, list.String())
+
+	images := make([]llm.Image, 0, len(photos))
+	for _, photo := range photos {
+		photoData, err := s.fs.ReadFile(ctx, photo.PhotoURL)
+		if err != nil {
+			logx.Warnf("Skipping photo %s in equipment verification: %v", photo.ID, err)
+			continue
+		}
+		images = append(images, llm.Image{Data: photoData, MediaType: "image/jpeg"})
+	}
+	if len(images) == 0 {
+		return nil, fmt.Errorf("none of the photos could be read")
+	}
+
+	resp, _, err := llm.ChatStructured[equipmentVerificationResponse](ctx, s.visionClient, []llm.Message{
+		llm.NewSystemMessage(systemPrompt),
+		llm.NewUserImageMessage("Verify the listed equipment against these photos.", images...),
+	},
+		llm.WithModel(visionModel),
+		llm.WithMaxTokens(4096),
+		llm.WithTemperature(0.1),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("vision API call failed: %w", err)
+	}
+	return &resp, nil
+}
+
+func normalizeEquipmentCategory(category string) diveinspect.EquipmentCategory {
+	switch c := diveinspect.EquipmentCategory(strings.ToLower(strings.TrimSpace(category))); c {
+	case diveinspect.EquipmentSafety, diveinspect.EquipmentComfort, diveinspect.EquipmentInfotainment,
+		diveinspect.EquipmentExterior, diveinspect.EquipmentInterior:
+		return c
+	}
+	return diveinspect.EquipmentInterior
+}
@@ -0,0 +1,41 @@
+package diveinspectsrv
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+// EventPublisher writes domain events to the outbox. Call Publish with the
+// context of the TxManager transaction that makes the change, so the event
+// commits or rolls back with it; the WebhookService delivers it afterwards.
+type EventPublisher struct {
+	eventRepo diveinspect.DomainEventRepository
+}
+
+func NewEventPublisher(eventRepo diveinspect.DomainEventRepository) *EventPublisher {
+	return &EventPublisher{eventRepo: eventRepo}
+}
+
+// Publish records an event about subjectID with data as its JSON payload.
+func (p *EventPublisher) Publish(ctx context.Context, tenantID *string, eventType diveinspect.EventType, subjectID string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return errx.Wrap(err, "Failed to encode domain event", errx.TypeInternal).
+			WithDetail("type", eventType)
+	}
+
+	event := &diveinspect.DomainEvent{
+		TenantID:  tenantID,
+		Type:      eventType,
+		SubjectID: subjectID,
+		Payload:   payload,
+	}
+	if err := p.eventRepo.Create(ctx, event); err != nil {
+		return errx.Wrap(err, "Failed to record domain event", errx.TypeInternal).
+			WithDetail("type", eventType)
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+package diveinspectsrv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+	"github.com/Abraxas-365/divi/pkg/audit"
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/diveinspect/diveinspectmem"
+	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/Abraxas-365/divi/pkg/fsx/fsxlocal"
+	"github.com/Abraxas-365/divi/pkg/kernel"
+)
+
+// ============================================================================
+// Environment
+// ============================================================================
+
+// testEnv wires the in-memory repositories the services under test share.
+type testEnv struct {
+	store       *diveinspectmem.Store
+	vehicles    *diveinspectmem.MemoryVehicleRepository
+	specs       *diveinspectmem.MemoryVehicleSpecsRepository
+	equipment   *diveinspectmem.MemoryVehicleEquipmentRepository
+	inspections *diveinspectmem.MemoryInspectionRepository
+	findings    *diveinspectmem.MemoryInspectionFindingRepository
+	photos      *diveinspectmem.MemoryInspectionPhotoRepository
+	listings    *diveinspectmem.MemoryGeneratedListingRepository
+	variants    *diveinspectmem.MemoryListingVariantRepository
+	brands      *diveinspectmem.MemoryBrandSettingsRepository
+	steps       *diveinspectmem.MemoryEnrichmentStepRepository
+	events      *diveinspectmem.MemoryDomainEventRepository
+	indexer     *diveinspectmem.MemoryVehicleIndexer
+	tx          *diveinspectmem.MemoryTxManager
+	fs          *fsxlocal.LocalFileSystem
+	audit       *recordingAudit
+	publisher   *EventPublisher
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	fs, err := fsxlocal.NewLocalFileSystem(t.TempDir())
+	if err != nil {
+		t.Fatalf("local file system: %v", err)
+	}
+
+	store := diveinspectmem.NewStore()
+	events := diveinspectmem.NewMemoryDomainEventRepository(store)
+	return &testEnv{
+		store:       store,
+		vehicles:    diveinspectmem.NewMemoryVehicleRepository(store),
+		specs:       diveinspectmem.NewMemoryVehicleSpecsRepository(store),
+		equipment:   diveinspectmem.NewMemoryVehicleEquipmentRepository(store),
+		inspections: diveinspectmem.NewMemoryInspectionRepository(store),
+		findings:    diveinspectmem.NewMemoryInspectionFindingRepository(store),
+		photos:      diveinspectmem.NewMemoryInspectionPhotoRepository(store),
+		listings:    diveinspectmem.NewMemoryGeneratedListingRepository(store),
+		variants:    diveinspectmem.NewMemoryListingVariantRepository(store),
+		brands:      diveinspectmem.NewMemoryBrandSettingsRepository(store),
+		steps:       diveinspectmem.NewMemoryEnrichmentStepRepository(store),
+		events:      events,
+		indexer:     diveinspectmem.NewMemoryVehicleIndexer(store),
+		tx:          diveinspectmem.NewMemoryTxManager(store),
+		fs:          fs,
+		audit:       &recordingAudit{},
+		publisher:   NewEventPublisher(events),
+	}
+}
+
+func (e *testEnv) vehicleService() *VehicleService {
+	return NewVehicleService(
+		e.vehicles, e.specs, e.equipment, e.listings, e.inspections, e.findings, e.photos,
+		e.indexer, e.steps, e.tx, e.publisher, e.audit,
+	)
+}
+
+// testDealerName is the dealer of the deployment's default brand settings.
+const testDealerName = "Divi Motors"
+
+func (e *testEnv) listingService(client *llm.Client) *ListingService {
+	return NewListingService(
+		client, e.specs, e.equipment, e.variants, e.brands,
+		diveinspect.BrandSettings{DealerName: testDealerName}, e.vehicles, e.indexer, e.tx, e.publisher,
+	)
+}
+
+func (e *testEnv) inspectionService(vision *VisionService) *InspectionService {
+	return NewInspectionService(
+		e.inspections, e.findings, e.photos, e.vehicles, e.fs, vision, e.tx, e.publisher, e.audit,
+	)
+}
+
+func (e *testEnv) reportService() *ReportService {
+	return NewReportService(e.vehicles, e.specs, e.equipment, e.inspections, e.findings, e.fs)
+}
+
+// addVehicle stores a draft vehicle straight through the repository.
+func (e *testEnv) addVehicle(t *testing.T, v diveinspect.Vehicle) *diveinspect.Vehicle {
+	t.Helper()
+	if v.Brand == "" {
+		v.Brand, v.Model, v.Year = "Toyota", "RAV4", 2022
+	}
+	if v.Status == "" {
+		v.Status = diveinspect.VehicleStatusDraft
+	}
+	if err := e.vehicles.Create(context.Background(), &v); err != nil {
+		t.Fatalf("create vehicle: %v", err)
+	}
+	stored, err := e.vehicles.GetByID(context.Background(), v.ID)
+	if err != nil {
+		t.Fatalf("get vehicle: %v", err)
+	}
+	return stored
+}
+
+// addInspection stores a pending inspection of the vehicle with the given
+// findings.
+func (e *testEnv) addInspection(t *testing.T, vehicleID string, findings ...diveinspect.InspectionFinding) *diveinspect.Inspection {
+	t.Helper()
+	ctx := context.Background()
+	inspection := &diveinspect.Inspection{VehicleID: vehicleID, Status: diveinspect.InspectionPending}
+	if err := e.inspections.Create(ctx, inspection); err != nil {
+		t.Fatalf("create inspection: %v", err)
+	}
+	for i := range findings {
+		findings[i].InspectionID = inspection.ID
+	}
+	if err := e.findings.CreateBatch(ctx, findings); err != nil {
+		t.Fatalf("create findings: %v", err)
+	}
+	return inspection
+}
+
+// eventTypes lists the published events in order.
+func (e *testEnv) eventTypes() []diveinspect.EventType {
+	var types []diveinspect.EventType
+	for _, ev := range e.events.Events() {
+		types = append(types, ev.Type)
+	}
+	return types
+}
+
+// ============================================================================
+// Audit
+// ============================================================================
+
+// recordingAudit keeps changes in memory, with whether a caller made each one,
+// and fails every write while failWith is set.
+type recordingAudit struct {
+	mu       sync.Mutex
+	changes  []audit.Change
+	byCaller []bool
+	failWith error
+}
+
+func (a *recordingAudit) RecordChange(ctx context.Context, change audit.Change) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.failWith != nil {
+		return a.failWith
+	}
+	_, byCaller := kernel.AuthContextFrom(ctx)
+	a.changes = append(a.changes, change)
+	a.byCaller = append(a.byCaller, byCaller)
+	return nil
+}
+
+func (a *recordingAudit) List(ctx context.Context, q audit.Query) (*kernel.Paginated[audit.Entry], error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var entries []audit.Entry
+	for i := len(a.changes) - 1; i >= 0; i-- {
+		c := a.changes[i]
+		if c.SubjectID == nil || *c.SubjectID != q.SubjectID {
+			continue
+		}
+		entries = append(entries, audit.Entry{
+			TenantID:   c.TenantID,
+			EntityType: c.EntityType,
+			EntityID:   c.EntityID,
+			SubjectID:  c.SubjectID,
+			Action:     c.Action,
+		})
+	}
+	page := kernel.NewPaginated(entries, q.Page, q.PageSize, len(entries))
+	return &page, nil
+}
+
+func (a *recordingAudit) actions() []audit.Action {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var actions []audit.Action
+	for _, c := range a.changes {
+		actions = append(actions, c.Action)
+	}
+	return actions
+}
+
+// ============================================================================
+// Scripted LLM
+// ============================================================================
+
+// scriptedReply answers every prompt containing match.
+type scriptedReply struct {
+	match   string
+	content string
+	err     error
+}
+
+// scriptedLLM is an llm.LLM that answers from a script instead of a model.
+// A prompt no reply matches fails the call.
+type scriptedLLM struct {
+	mu      sync.Mutex
+	replies []scriptedReply
+	calls   []string
+	images  int // images attached to the calls received
+}
+
+func newScriptedLLM(replies ...scriptedReply) *scriptedLLM {
+	return &scriptedLLM{replies: replies}
+}
+
+func (l *scriptedLLM) Chat(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Response, error) {
+	var prompt strings.Builder
+	images := 0
+	for _, m := range messages {
+		prompt.WriteString(m.Content)
+		prompt.WriteString("\n")
+		images += len(m.Images)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.images += images
+	for _, r := range l.replies {
+		if !strings.Contains(prompt.String(), r.match) {
+			continue
+		}
+		l.calls = append(l.calls, r.match)
+		if r.err != nil {
+			return llm.Response{}, r.err
+		}
+		return llm.Response{Message: llm.NewAssistantMessage(r.content)}, nil
+	}
+	return llm.Response{}, fmt.Errorf("no scripted reply for prompt: %.80q", prompt.String())
+}
+
+func (l *scriptedLLM) ChatStream(ctx context.Context, messages []llm.Message, opts ...llm.Option) (llm.Stream, error) {
+	return nil, errors.New("scripted LLM does not stream")
+}
+
+// callCount returns how many calls matched the given script entry.
+func (l *scriptedLLM) callCount(match string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := 0
+	for _, c := range l.calls {
+		if c == match {
+			n++
+		}
+	}
+	return n
+}
+
+// ============================================================================
+// Assertions
+// ============================================================================
+
+// assertCode fails unless err carries the registered error code.
+func assertCode(t *testing.T, err error, code *errx.ErrorCode) {
+	t.Helper()
+	var e *errx.Error
+	if !errors.As(err, &e) || e.Code != code.Code {
+		t.Fatalf("error = %v, want code %s", err, code.Code)
+	}
+}
+
+// assertType fails unless err is an errx error of type want.
+func assertType(t *testing.T, err error, want errx.Type) {
+	t.Helper()
+	var e *errx.Error
+	if !errors.As(err, &e) || e.Type != want {
+		t.Fatalf("error = %v, want type %s", err, want)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,571 @@
+package diveinspectsrv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/Abraxas-365/divi/pkg/fsx"
+	"github.com/Abraxas-365/divi/pkg/logx"
+	"github.com/google/uuid"
+)
+
+const (
+	importSampleRows    = 5
+	importProgressEvery = 100
+	// importLease is how long a worker's claim on a running job lasts. It is
+	// renewed every importLease/3 while the job runs, so a job is only
+	// claimed again once its worker has stopped.
+	importLease = 2 * time.Minute
+)
+
+// importField describes a vehicle field that can be mapped from a column.
+// Synonyms are matched against normalized headers to suggest a mapping.
+type importField struct {
+	Name     string
+	Required bool
+	Synonyms []string
+}
+
+var importFields = []importField{
+	{"plate", false, []string{"plate", "placa", "patente", "matricula"}},
+	{"vin", false, []string{"vin", "chasis", "nro chasis", "numero de chasis", "serie", "numero de serie"}},
+	{"brand", true, []string{"brand", "make", "marca"}},
+	{"model", true, []string{"model", "modelo"}},
+	{"version", false, []string{"version"}},
+	{"trim", false, []string{"trim", "acabado"}},
+	{"year", true, []string{"year", "ano", "anio", "ano modelo", "ano fabricacion"}},
+	{"mileage_km", false, []string{"mileage", "mileage km", "km", "kms", "kilometraje", "kilometros"}},
+	{"color_exterior", false, []string{"color", "color exterior", "exterior color"}},
+	{"color_interior", false, []string{"color interior", "interior color", "tapiz", "tapiceria"}},
+	{"price_usd", false, []string{"price", "price usd", "precio", "precio usd", "precio venta"}},
+	{"branch", false, []string{"branch", "sucursal", "tienda", "local"}},
+	{"origin", false, []string{"origin", "origen", "procedencia"}},
+}
+
+type ImportService struct {
+	jobRepo     diveinspect.VehicleImportJobRepository
+	vehicleRepo diveinspect.VehicleRepository
+	vehicleSvc  *VehicleService
+	enrichQueue *EnrichmentQueue
+	fs          fsx.FileSystem
+	interval    time.Duration
+}
+
+func NewImportService(
+	jobRepo diveinspect.VehicleImportJobRepository,
+	vehicleRepo diveinspect.VehicleRepository,
+	vehicleSvc *VehicleService,
+	enrichQueue *EnrichmentQueue,
+	fs fsx.FileSystem,
+	interval time.Duration,
+) *ImportService {
+	return &ImportService{
+		jobRepo:     jobRepo,
+		vehicleRepo: vehicleRepo,
+		vehicleSvc:  vehicleSvc,
+		enrichQueue: enrichQueue,
+		fs:          fs,
+		interval:    interval,
+	}
+}
+
+// ============================================================================
+// Upload & Mapping
+// ============================================================================
+
+// Upload stores the file, parses its header and returns a preview with a
+// suggested column mapping. The job waits for Queue to confirm the mapping.
+// Vehicles created by the import belong to tenantID.
+func (s *ImportService) Upload(ctx context.Context, tenantID *string, fileName string, data []byte) (*diveinspect.ImportPreview, error) {
+	var format diveinspect.ImportFormat
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv", ".txt":
+		format = diveinspect.ImportFormatCSV
+	case ".xlsx":
+		format = diveinspect.ImportFormatXLSX
+	default:
+		return nil, errx.Validation("Unsupported file type, expected .csv or .xlsx").WithDetail("file", fileName)
+	}
+
+	table, err := parseImportFile(format, data)
+	if err != nil {
+		return nil, errx.Validation("Could not parse import file").WithDetail("reason", err.Error())
+	}
+
+	jobID := uuid.New().String()
+	storagePath := fmt.Sprintf("imports/%s/%s", jobID, filepath.Base(fileName))
+	if err := s.fs.WriteFile(ctx, storagePath, data); err != nil {
+		return nil, errx.Wrap(err, "Failed to store import file", errx.TypeInternal)
+	}
+
+	job := &diveinspect.VehicleImportJob{
+		ID:        jobID,
+		TenantID:  tenantID,
+		FileName:  filepath.Base(fileName),
+		FilePath:  storagePath,
+		Format:    format,
+		Status:    diveinspect.ImportAwaitingMapping,
+		Headers:   table.Headers,
+		TotalRows: len(table.Rows),
+		Results:   diveinspect.ImportRowResults{},
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, errx.Wrap(err, "Failed to create import job", errx.TypeInternal)
+	}
+
+	sample := table.Rows
+	if len(sample) > importSampleRows {
+		sample = sample[:importSampleRows]
+	}
+
+	return &diveinspect.ImportPreview{
+		Job:              *job,
+		SampleRows:       sample,
+		SuggestedMapping: suggestMapping(table.Headers),
+	}, nil
+}
+
+// Queue confirms the column mapping and hands the job to the background worker.
+func (s *ImportService) Queue(ctx context.Context, jobID string, mapping diveinspect.ColumnMapping, enrich bool) (*diveinspect.VehicleImportJob, error) {
+	job, err := s.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != diveinspect.ImportAwaitingMapping {
+		return nil, errx.Validation("Import job has already been queued").WithDetail("status", job.Status)
+	}
+	if err := validateMapping(mapping, job.Headers); err != nil {
+		return nil, err
+	}
+
+	job.Mapping = mapping
+	job.EnrichCreated = enrich
+	job.Status = diveinspect.ImportQueued
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		return nil, errx.Wrap(err, "Failed to queue import job", errx.TypeInternal)
+	}
+	return job, nil
+}
+
+func (s *ImportService) GetByID(ctx context.Context, jobID string) (*diveinspect.VehicleImportJob, error) {
+	return s.jobRepo.GetByID(ctx, jobID)
+}
+
+func validateMapping(mapping diveinspect.ColumnMapping, headers []string) error {
+	known := make(map[string]bool, len(importFields))
+	for _, f := range importFields {
+		known[f.Name] = true
+		if f.Required && mapping[f.Name] == "" {
+			return errx.Validation("Required field is not mapped").WithDetail("field", f.Name)
+		}
+	}
+
+	present := make(map[string]bool, len(headers))
+	for _, h := range headers {
+		present[h] = true
+	}
+	for field, column := range mapping {
+		if !known[field] {
+			return errx.Validation("Unknown target field in mapping").WithDetail("field", field)
+		}
+		if column != "" && !present[column] {
+			return errx.Validation("Mapped column not found in file").WithDetail("column", column)
+		}
+	}
+	return nil
+}
+
+func suggestMapping(headers []string) diveinspect.ColumnMapping {
+	mapping := diveinspect.ColumnMapping{}
+	for _, f := range importFields {
+		for _, h := range headers {
+			normalized := normalizeHeader(h)
+			for _, syn := range f.Synonyms {
+				if normalized == syn {
+					mapping[f.Name] = h
+					break
+				}
+			}
+			if _, ok := mapping[f.Name]; ok {
+				break
+			}
+		}
+	}
+	return mapping
+}
+
+var nonAlnum = regexp.MustCompile(`[^a-z0-9]+`)
+
+func normalizeHeader(h string) string {
+	h = strings.ToLower(strings.TrimSpace(h))
+	h = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ñ", "n", "ü", "u").Replace(h)
+	return strings.TrimSpace(nonAlnum.ReplaceAllString(h, " "))
+}
+
+// ============================================================================
+// Background Worker
+// ============================================================================
+
+// Start polls for queued import jobs until ctx is cancelled.
+func (s *ImportService) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logx.Info("Vehicle import worker stopped")
+			return
+		case <-ticker.C:
+			s.runQueued(ctx)
+		}
+	}
+}
+
+// runQueued processes queued jobs, and running jobs whose worker died, until
+// none are left.
+func (s *ImportService) runQueued(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := s.jobRepo.ClaimNext(ctx, importLease)
+		if err != nil {
+			logx.Errorf("Failed to claim import job: %v", err)
+			return
+		}
+		if job == nil {
+			return
+		}
+		s.process(ctx, job)
+	}
+}
+
+// process imports the job's rows. A job claimed again after its worker died
+// resumes after the last saved progress; rows imported since then are found
+// again as already registered and reported as duplicates.
+func (s *ImportService) process(ctx context.Context, job *diveinspect.VehicleImportJob) {
+	logx.Infof("Processing vehicle import %s (%s)", job.ID, job.FileName)
+
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	go s.keepLease(ctx, stop, job.ID)
+
+	data, err := s.fs.ReadFile(ctx, job.FilePath)
+	if err != nil {
+		s.fail(ctx, job, fmt.Sprintf("could not read import file: %v", err))
+		return
+	}
+	table, err := parseImportFile(job.Format, data)
+	if err != nil {
+		s.fail(ctx, job, err.Error())
+		return
+	}
+
+	columns := make(map[string]int, len(job.Mapping))
+	for i, h := range table.Headers {
+		for field, column := range job.Mapping {
+			if column == h {
+				columns[field] = i
+			}
+		}
+	}
+
+	job.TotalRows = len(table.Rows)
+	done := min(len(job.Results), len(table.Rows))
+	if done > 0 {
+		logx.Infof("Resuming vehicle import %s at row %d", job.ID, done+2)
+	}
+	job.Results = slices.Grow(job.Results[:done:done], len(table.Rows)-done)
+	seen := make(map[string]int)
+	for i, result := range job.Results {
+		if result.Status == diveinspect.ImportRowCreated {
+			vehicle, _ := vehicleFromRow(table.Rows[i], columns)
+			for _, key := range duplicateKeys(vehicle) {
+				seen[key] = result.Row
+			}
+		}
+	}
+
+	for i, row := range table.Rows[done:] {
+		i += done
+		if ctx.Err() != nil {
+			return
+		}
+		// Row numbers are 1-based and count the header, matching the spreadsheet
+		result := s.importRow(ctx, job, i+2, row, columns, seen)
+		job.Results = append(job.Results, result)
+
+		switch result.Status {
+		case diveinspect.ImportRowCreated:
+			job.CreatedCount++
+		case diveinspect.ImportRowDuplicate:
+			job.DuplicateCount++
+		case diveinspect.ImportRowFailed:
+			job.FailedCount++
+		}
+
+		if (i+1)%importProgressEvery == 0 {
+			if err := s.jobRepo.Update(ctx, job); err != nil {
+				logx.Warnf("Failed to save progress for import %s: %v", job.ID, err)
+			}
+		}
+	}
+
+	now := time.Now()
+	job.Status = diveinspect.ImportCompleted
+	job.CompletedAt = &now
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		logx.Errorf("Failed to complete import %s: %v", job.ID, err)
+		return
+	}
+	logx.Infof("Vehicle import %s done: %d created, %d duplicate, %d failed",
+		job.ID, job.CreatedCount, job.DuplicateCount, job.FailedCount)
+}
+
+// keepLease renews the worker's lease on the job until ctx is done. If the
+// lease can't be renewed the job may already be running elsewhere, so stop
+// is called to abandon it.
+func (s *ImportService) keepLease(ctx context.Context, stop context.CancelFunc, jobID string) {
+	ticker := time.NewTicker(importLease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.jobRepo.ExtendLease(ctx, jobID, importLease); err != nil && ctx.Err() == nil {
+				logx.Errorf("Lost lease on vehicle import %s, abandoning it: %v", jobID, err)
+				stop()
+				return
+			}
+		}
+	}
+}
+
+func (s *ImportService) fail(ctx context.Context, job *diveinspect.VehicleImportJob, reason string) {
+	logx.Errorf("Vehicle import %s failed: %s", job.ID, reason)
+	now := time.Now()
+	job.Status = diveinspect.ImportFailed
+	job.Error = &reason
+	job.CompletedAt = &now
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		logx.Errorf("Failed to mark import %s as failed: %v", job.ID, err)
+	}
+}
+
+func (s *ImportService) importRow(
+	ctx context.Context,
+	job *diveinspect.VehicleImportJob,
+	rowNum int,
+	row []string,
+	columns map[string]int,
+	seen map[string]int,
+) diveinspect.ImportRowResult {
+	result := diveinspect.ImportRowResult{Row: rowNum}
+
+	vehicle, reasons := vehicleFromRow(row, columns)
+	vehicle.TenantID = job.TenantID
+	if err := validateVehicle(vehicle); err != nil {
+		reasons = append(reasons, errorMessage(err))
+	}
+	if len(reasons) > 0 {
+		result.Status = diveinspect.ImportRowFailed
+		result.Reasons = reasons
+		return result
+	}
+
+	// Duplicates within the same file
+	for _, key := range duplicateKeys(vehicle) {
+		if prev, ok := seen[key]; ok {
+			result.Status = diveinspect.ImportRowDuplicate
+			result.Reasons = []string{fmt.Sprintf("same %s as row %d", strings.SplitN(key, ":", 2)[0], prev)}
+			return result
+		}
+	}
+
+	// Duplicates already registered
+	existing, err := s.vehicleRepo.FindByPlateOrVIN(ctx, vehicle.Plate, vehicle.VIN)
+	if err == nil {
+		result.Status = diveinspect.ImportRowDuplicate
+		result.VehicleID = &existing.ID
+		result.Reasons = []string{"plate or VIN already registered"}
+		return result
+	}
+	if !isNotFound(err) {
+		result.Status = diveinspect.ImportRowFailed
+		result.Reasons = []string{errorMessage(err)}
+		return result
+	}
+
+	if err := s.vehicleSvc.Create(ctx, vehicle); err != nil {
+		result.Status = diveinspect.ImportRowFailed
+		result.Reasons = []string{errorMessage(err)}
+		return result
+	}
+
+	for _, key := range duplicateKeys(vehicle) {
+		seen[key] = rowNum
+	}
+	result.Status = diveinspect.ImportRowCreated
+	result.VehicleID = &vehicle.ID
+
+	if job.EnrichCreated {
+		if err := s.enrichQueue.Enqueue(ctx, vehicle.ID); err != nil {
+			result.Reasons = append(result.Reasons, "enrichment not queued: "+err.Error())
+		}
+	}
+	return result
+}
+
+// vehicleFromRow builds a vehicle from mapped cells, collecting a reason for
+// every cell that can't be parsed.
+func vehicleFromRow(row []string, columns map[string]int) (*diveinspect.Vehicle, []string) {
+	var reasons []string
+	cell := func(field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+	optional := func(field string) *string {
+		if v := cell(field); v != "" {
+			return &v
+		}
+		return nil
+	}
+
+	v := &diveinspect.Vehicle{
+		Plate:         optional("plate"),
+		VIN:           optional("vin"),
+		Brand:         cell("brand"),
+		Model:         cell("model"),
+		Version:       optional("version"),
+		Trim:          optional("trim"),
+		ColorExterior: optional("color_exterior"),
+		ColorInterior: optional("color_interior"),
+		Branch:        optional("branch"),
+		Origin:        optional("origin"),
+	}
+	if v.VIN != nil {
+		vin := strings.ToUpper(*v.VIN)
+		if len(vin) != 17 {
+			reasons = append(reasons, "VIN must be 17 characters")
+		}
+		v.VIN = &vin
+	}
+
+	if raw := cell("year"); raw != "" {
+		year, err := parseImportInt(raw)
+		if err != nil {
+			reasons = append(reasons, fmt.Sprintf("invalid year %q", raw))
+		}
+		v.Year = year
+	}
+	if raw := cell("mileage_km"); raw != "" {
+		mileage, err := parseImportInt(raw)
+		if err != nil || mileage < 0 {
+			reasons = append(reasons, fmt.Sprintf("invalid mileage %q", raw))
+		}
+		v.MileageKM = mileage
+	}
+	if raw := cell("price_usd"); raw != "" {
+		price, err := parseImportPrice(raw)
+		if err != nil || price < 0 {
+			reasons = append(reasons, fmt.Sprintf("invalid price %q", raw))
+		} else {
+			v.PriceUSD = &price
+		}
+	}
+
+	return v, reasons
+}
+
+func duplicateKeys(v *diveinspect.Vehicle) []string {
+	var keys []string
+	if v.Plate != nil {
+		keys = append(keys, "plate:"+normalizePlate(*v.Plate))
+	}
+	if v.VIN != nil {
+		keys = append(keys, "vin:"+strings.ToUpper(*v.VIN))
+	}
+	return keys
+}
+
+func normalizePlate(p string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(p))
+}
+
+// parseImportInt accepts plain integers, spreadsheet floats ("45000.0") and
+// thousands-separated text ("45.000 km", "45,000"). Separators follow
+// parseImportPrice, so "45.000" is 45000 rather than 45; a decimal part is
+// dropped.
+func parseImportInt(raw string) (int, error) {
+	f, err := parseImportPrice(raw)
+	if err != nil {
+		return 0, fmt.Errorf("no number in %q", raw)
+	}
+	if strings.HasPrefix(strings.TrimSpace(raw), "-") {
+		f = -f
+	}
+	return int(f), nil
+}
+
+// parseImportPrice accepts "15500", "$ 15,500.00", "15.500,00" and "15.500".
+// When both separators appear the last one is the decimal point; a single
+// separator is a thousands separator only when exactly three digits follow.
+func parseImportPrice(raw string) (float64, error) {
+	if strings.ContainsAny(raw, "eE") {
+		return strconv.ParseFloat(raw, 64)
+	}
+	clean := strings.Map(func(r rune) rune {
+		if (r >= '0' && r <= '9') || r == '.' || r == ',' {
+			return r
+		}
+		return -1
+	}, raw)
+
+	lastDot := strings.LastIndex(clean, ".")
+	lastComma := strings.LastIndex(clean, ",")
+	decimalAt := -1
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		decimalAt = max(lastDot, lastComma)
+	case lastDot >= 0 && strings.Count(clean, ".") == 1 && len(clean)-lastDot-1 != 3:
+		decimalAt = lastDot
+	case lastComma >= 0 && strings.Count(clean, ",") == 1 && len(clean)-lastComma-1 != 3:
+		decimalAt = lastComma
+	}
+
+	var b strings.Builder
+	for i, r := range clean {
+		switch {
+		case i == decimalAt:
+			b.WriteRune('.')
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		}
+	}
+	return strconv.ParseFloat(b.String(), 64)
+}
+
+func isNotFound(err error) bool {
+	var e *errx.Error
+	return errors.As(err, &e) && e.Type == errx.TypeNotFound
+}
+
+func errorMessage(err error) string {
+	var e *errx.Error
+	if errors.As(err, &e) {
+		return e.Message
+	}
+	return err.Error()
+}
@@ -0,0 +1,116 @@
+package diveinspectsrv
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/diveinspect/diveinspectmem"
+)
+
+func TestParseImportInt(t *testing.T) {
+	tests := map[string]int{
+		"45000":     45000,
+		"45000.0":   45000,
+		"45.000":    45000,
+		"45,000":    45000,
+		"45.000 km": 45000,
+		"1,234,567": 1234567,
+		"45.5":      45,
+		"2020":      2020,
+		"-5":        -5,
+		"1e5":       100000,
+	}
+	for raw, want := range tests {
+		got, err := parseImportInt(raw)
+		if err != nil || got != want {
+			t.Errorf("parseImportInt(%q) = %d, %v; want %d", raw, got, err, want)
+		}
+	}
+
+	if _, err := parseImportInt("n/a"); err == nil {
+		t.Error(`parseImportInt("n/a") should fail`)
+	}
+}
+
+func TestParseImportPrice(t *testing.T) {
+	tests := map[string]float64{
+		"15500":       15500,
+		"$ 15,500.00": 15500,
+		"15.500,00":   15500,
+		"15.500":      15500,
+		"15,5":        15.5,
+		"15500.75":    15500.75,
+	}
+	for raw, want := range tests {
+		got, err := parseImportPrice(raw)
+		if err != nil || got != want {
+			t.Errorf("parseImportPrice(%q) = %v, %v; want %v", raw, got, err, want)
+		}
+	}
+}
+
+func TestImportResumesJobWithExpiredLease(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	jobs := diveinspectmem.NewMemoryVehicleImportJobRepository(env.store)
+	svc := NewImportService(jobs, env.vehicles, env.vehicleService(), nil, env.fs, time.Second)
+
+	csv := "Marca,Modelo,Año,Placa\nToyota,RAV4,2022,ABC-123\nToyota,RAV4,2021,ABC-123\nKia,Rio,2020,XYZ-987\n"
+	if err := env.fs.WriteFile(ctx, "imports/stock.csv", []byte(csv)); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	// A worker imported the first row, saved its progress and died
+	first := env.addVehicle(t, diveinspect.Vehicle{Brand: "Toyota", Model: "RAV4", Year: 2022, Plate: ptr("ABC-123")})
+	expired := time.Now().Add(-time.Minute)
+	job := &diveinspect.VehicleImportJob{
+		FileName: "stock.csv",
+		FilePath: "imports/stock.csv",
+		Format:   diveinspect.ImportFormatCSV,
+		Status:   diveinspect.ImportRunning,
+		Mapping:  diveinspect.ColumnMapping{"brand": "Marca", "model": "Modelo", "year": "Año", "plate": "Placa"},
+		Results: diveinspect.ImportRowResults{
+			{Row: 2, Status: diveinspect.ImportRowCreated, VehicleID: &first.ID},
+		},
+		CreatedCount: 1,
+		LockedUntil:  &expired,
+	}
+	if err := jobs.Create(ctx, job); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	svc.runQueued(ctx)
+
+	got, err := jobs.GetByID(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.Status != diveinspect.ImportCompleted || len(got.Results) != 3 {
+		t.Fatalf("job = %s with %d results, want completed with 3", got.Status, len(got.Results))
+	}
+	if got.CreatedCount != 2 || got.DuplicateCount != 1 {
+		t.Fatalf("counts = %d created, %d duplicate; want 2 and 1", got.CreatedCount, got.DuplicateCount)
+	}
+	if dup := got.Results[1]; dup.Status != diveinspect.ImportRowDuplicate || len(dup.Reasons) != 1 || dup.Reasons[0] != "same plate as row 2" {
+		t.Fatalf("row 3 = %+v, want a duplicate of row 2", dup)
+	}
+}
+
+func TestImportSkipsJobWithLiveLease(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	jobs := diveinspectmem.NewMemoryVehicleImportJobRepository(env.store)
+
+	lockedUntil := time.Now().Add(time.Minute)
+	job := &diveinspect.VehicleImportJob{Status: diveinspect.ImportRunning, LockedUntil: &lockedUntil}
+	if err := jobs.Create(ctx, job); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	claimed, err := jobs.ClaimNext(ctx, importLease)
+	if err != nil || claimed != nil {
+		t.Fatalf("ClaimNext = %v, %v; want the running job left alone", claimed, err)
+	}
+}
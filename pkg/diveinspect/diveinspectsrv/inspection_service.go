@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/Abraxas-365/divi/pkg/audit"
 	"github.com/Abraxas-365/divi/pkg/diveinspect"
 	"github.com/Abraxas-365/divi/pkg/errx"
 	"github.com/Abraxas-365/divi/pkg/fsx"
@@ -18,6 +19,9 @@ type InspectionService struct {
 	vehicleRepo    diveinspect.VehicleRepository
 	fs             fsx.FileSystem
 	visionService  *VisionService
+	txManager      diveinspect.TxManager
+	events         *EventPublisher
+	auditLog       audit.Recorder
 }
 
 func NewInspectionService(
@@ -27,6 +31,9 @@ func NewInspectionService(
 	vehicleRepo diveinspect.VehicleRepository,
 	fs fsx.FileSystem,
 	visionService *VisionService,
+	txManager diveinspect.TxManager,
+	events *EventPublisher,
+	auditLog audit.Recorder,
 ) *InspectionService {
 	return &InspectionService{
 		inspectionRepo: inspectionRepo,
@@ -35,12 +42,16 @@ func NewInspectionService(
 		vehicleRepo:    vehicleRepo,
 		fs:             fs,
 		visionService:  visionService,
+		txManager:      txManager,
+		events:         events,
+		auditLog:       auditLog,
 	}
 }
 
 func (s *InspectionService) CreateInspection(ctx context.Context, vehicleID string, inspectorName, inspectorBranch *string) (*diveinspect.Inspection, error) {
 	// Verify vehicle exists
-	if _, err := s.vehicleRepo.GetByID(ctx, vehicleID); err != nil {
+	vehicle, err := s.vehicleRepo.GetByID(ctx, vehicleID)
+	if err != nil {
 		return nil, err
 	}
 
@@ -51,8 +62,17 @@ func (s *InspectionService) CreateInspection(ctx context.Context, vehicleID stri
 		Status:          diveinspect.InspectionPending,
 	}
 
-	if err := s.inspectionRepo.Create(ctx, inspection); err != nil {
-		return nil, errx.Wrap(err, "Failed to create inspection", errx.TypeInternal)
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.inspectionRepo.Create(ctx, inspection); err != nil {
+			return errx.Wrap(err, "Failed to create inspection", errx.TypeInternal)
+		}
+		if err := s.auditLog.RecordChange(ctx, vehicleChange(vehicle, auditInspection, inspection.ID, audit.ActionCreate, nil, inspection)); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, vehicle.TenantID, diveinspect.EventInspectionCreated, inspection.ID, inspection)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return inspection, nil
@@ -89,25 +109,29 @@ func (s *InspectionService) UploadPhoto(ctx context.Context, inspectionID string
 		return nil, errx.Wrap(err, "Failed to upload photo", errx.TypeInternal)
 	}
 
-	// Get current photo count for sort order
-	photos, _ := s.photoRepo.GetByInspectionID(ctx, inspectionID)
-	sortOrder := len(photos)
-
 	photo := &diveinspect.InspectionPhoto{
-		InspectionID: inspectionID,
+		InspectionID: inspection.ID,
 		PhotoURL:     storagePath,
 		Zone:         zone,
-		SortOrder:    sortOrder,
 	}
 
-	if err := s.photoRepo.Create(ctx, photo); err != nil {
-		return nil, errx.Wrap(err, "Failed to save photo record", errx.TypeInternal)
+	// The counter increment locks the inspection row, so concurrent uploads
+	// get distinct sort orders
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		count, err := s.inspectionRepo.IncrementPhotosCount(ctx, inspection.ID)
+		if err != nil {
+			return err
+		}
+		photo.SortOrder = count - 1
+		if err := s.photoRepo.Create(ctx, photo); err != nil {
+			return errx.Wrap(err, "Failed to save photo record", errx.TypeInternal)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Update photos count
-	inspection.PhotosCount = sortOrder + 1
-	_ = s.inspectionRepo.Update(ctx, inspection)
-
 	return photo, nil
 }
 
@@ -125,8 +149,81 @@ func (s *InspectionService) RunInspection(ctx context.Context, inspectionID stri
 	return s.visionService.RunInspection(ctx, vehicle, inspectionID)
 }
 
-func (s *InspectionService) UpdateFinding(ctx context.Context, finding *diveinspect.InspectionFinding) error {
-	return s.findingRepo.Update(ctx, finding)
+// findingReadOnly are the finding members a merge patch may not touch.
+var findingReadOnly = []string{
+	"id", "inspection_id", "photo_url", "annotated_photo_url", "ai_confidence", "revision",
+}
+
+// PatchFinding applies a JSON Merge Patch to a finding of one of the
+// tenant's vehicles; findings of other tenants are reported as not found.
+// ifMatch is the revision the client last read, if it sent one.
+func (s *InspectionService) PatchFinding(ctx context.Context, tenantID *string, findingID string, patch []byte, ifMatch *int) (*diveinspect.InspectionFinding, error) {
+	var patched *diveinspect.InspectionFinding
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		current, err := s.findingRepo.GetByID(ctx, findingID)
+		if err != nil {
+			return err
+		}
+		inspection, err := s.inspectionRepo.GetByID(ctx, current.InspectionID)
+		if err != nil {
+			return err
+		}
+		vehicle, err := s.vehicleRepo.GetByID(ctx, inspection.VehicleID)
+		if err != nil {
+			return err
+		}
+		if !sameTenant(vehicle.TenantID, tenantID) {
+			return diveinspect.NewError(diveinspect.ErrFindingNotFound).WithDetail("id", findingID)
+		}
+		if err := checkRevision(ifMatch, current.Revision); err != nil {
+			return err
+		}
+
+		patched, err = applyMergePatch(current, patch, findingReadOnly...)
+		if err != nil {
+			return err
+		}
+		if err := validateFindingPatch(current, patched); err != nil {
+			return err
+		}
+
+		if err := s.findingRepo.Update(ctx, patched); err != nil {
+			return err
+		}
+		if err := s.auditLog.RecordChange(ctx, vehicleChange(vehicle, auditFinding, patched.ID, audit.ActionUpdate, current, patched)); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, vehicle.TenantID, diveinspect.EventFindingUpdated, patched.ID, patched)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return patched, nil
+}
+
+// validateFindingPatch checks the enum members a patch changed. Values the
+// vision model wrote are left alone, so patching one field never fails on
+// another.
+func validateFindingPatch(before, f *diveinspect.InspectionFinding) error {
+	if f.Zone != before.Zone && !f.Zone.IsValid() {
+		return errx.Validation("Invalid finding zone").WithDetail("zone", f.Zone)
+	}
+	if f.FindingType != before.FindingType && !f.FindingType.IsValid() {
+		return errx.Validation("Invalid finding type").WithDetail("finding_type", f.FindingType)
+	}
+	if f.Severity != before.Severity && !f.Severity.IsValid() {
+		return errx.Validation("Invalid finding severity").WithDetail("severity", f.Severity)
+	}
+	return nil
+}
+
+// sameTenant reports whether a record owned by owner is visible to a caller
+// of tenant; untenanted records are only visible to untenanted callers.
+func sameTenant(owner, tenant *string) bool {
+	if owner == nil || tenant == nil {
+		return owner == nil && tenant == nil
+	}
+	return *owner == *tenant
 }
 
 func (s *InspectionService) GetByVehicleID(ctx context.Context, vehicleID string) (*diveinspect.InspectionFullView, error) {
@@ -0,0 +1,146 @@
+package diveinspectsrv
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+func TestInspectionServiceCreateInspection(t *testing.T) {
+	tests := []struct {
+		name      string
+		vehicleID string
+		wantErr   *errx.ErrorCode
+	}{
+		{name: "existing vehicle"},
+		{name: "unknown vehicle", vehicleID: "missing", wantErr: diveinspect.ErrVehicleNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newTestEnv(t)
+			v := env.addVehicle(t, diveinspect.Vehicle{})
+			vehicleID := v.ID
+			if tt.vehicleID != "" {
+				vehicleID = tt.vehicleID
+			}
+
+			inspection, err := env.inspectionService(nil).CreateInspection(ctx, vehicleID, ptr("Ana"), nil)
+			if tt.wantErr != nil {
+				assertCode(t, err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateInspection: %v", err)
+			}
+			if inspection.Status != diveinspect.InspectionPending {
+				t.Fatalf("status = %s, want pending", inspection.Status)
+			}
+			stored, err := env.inspections.GetByVehicleID(ctx, v.ID)
+			if err != nil || stored.ID != inspection.ID {
+				t.Fatalf("GetByVehicleID = %v, %v; want the new inspection", stored, err)
+			}
+			if got := env.eventTypes(); !slices.Equal(got, []diveinspect.EventType{diveinspect.EventInspectionCreated}) {
+				t.Fatalf("events = %v", got)
+			}
+		})
+	}
+}
+
+func TestInspectionServiceUploadPhoto(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	svc := env.inspectionService(nil)
+	v := env.addVehicle(t, diveinspect.Vehicle{})
+	inspection := env.addInspection(t, v.ID)
+
+	zones := []diveinspect.PhotoZone{diveinspect.PhotoZoneFront, diveinspect.PhotoZoneRear, diveinspect.PhotoZoneEngine}
+	for i, zone := range zones {
+		photo, err := svc.UploadPhoto(ctx, inspection.ID, zone, strings.NewReader("jpeg"), "photo.jpg")
+		if err != nil {
+			t.Fatalf("UploadPhoto: %v", err)
+		}
+		if photo.SortOrder != i {
+			t.Fatalf("sort order = %d, want %d", photo.SortOrder, i)
+		}
+		if ok, err := env.fs.Exists(ctx, photo.PhotoURL); err != nil || !ok {
+			t.Fatalf("photo %s was not stored", photo.PhotoURL)
+		}
+	}
+
+	view, err := svc.GetByID(ctx, inspection.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if view.Inspection.PhotosCount != len(zones) || len(view.Photos) != len(zones) {
+		t.Fatalf("photos = %d counted, %d stored; want %d", view.Inspection.PhotosCount, len(view.Photos), len(zones))
+	}
+
+	_, err = svc.UploadPhoto(ctx, "missing", diveinspect.PhotoZoneFront, strings.NewReader("jpeg"), "photo.jpg")
+	assertCode(t, err, diveinspect.ErrInspectionNotFound)
+}
+
+func TestInspectionServicePatchFinding(t *testing.T) {
+	tests := []struct {
+		name     string
+		tenantID *string
+		patch    string
+		ifMatch  *int
+		wantErr  *errx.ErrorCode
+		wantTyp  errx.Type
+	}{
+		{name: "confirms finding", tenantID: ptr("acme"), patch: `{"severity": "major", "confirmed_by_human": true}`, ifMatch: ptr(1)},
+		{name: "other tenant", tenantID: ptr("globex"), patch: `{"severity": "major"}`, wantErr: diveinspect.ErrFindingNotFound},
+		{name: "untenanted caller", patch: `{"severity": "major"}`, wantErr: diveinspect.ErrFindingNotFound},
+		{name: "stale If-Match", tenantID: ptr("acme"), patch: `{"severity": "major"}`, ifMatch: ptr(3), wantErr: diveinspect.ErrPreconditionFailed},
+		{name: "invalid severity", tenantID: ptr("acme"), patch: `{"severity": "catastrophic"}`, wantTyp: errx.TypeValidation},
+		{name: "read-only field", tenantID: ptr("acme"), patch: `{"ai_confidence": 1}`, wantErr: diveinspect.ErrInvalidPatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newTestEnv(t)
+			v := env.addVehicle(t, diveinspect.Vehicle{TenantID: ptr("acme")})
+			findings := []diveinspect.InspectionFinding{{
+				Zone:        diveinspect.ZoneFront,
+				FindingType: diveinspect.FindingScratch,
+				Severity:    diveinspect.SeverityMinor,
+			}}
+			env.addInspection(t, v.ID, findings...)
+			findingID := findings[0].ID
+
+			patched, err := env.inspectionService(nil).PatchFinding(ctx, tt.tenantID, findingID, []byte(tt.patch), tt.ifMatch)
+			switch {
+			case tt.wantErr != nil:
+				assertCode(t, err, tt.wantErr)
+			case tt.wantTyp != "":
+				assertType(t, err, tt.wantTyp)
+			case err != nil:
+				t.Fatalf("PatchFinding: %v", err)
+			}
+
+			current, err := env.findings.GetByID(ctx, findingID)
+			if err != nil {
+				t.Fatalf("GetByID: %v", err)
+			}
+			if tt.wantErr != nil || tt.wantTyp != "" {
+				if current.Severity != diveinspect.SeverityMinor || current.Revision != 1 {
+					t.Fatalf("finding = %s rev %d, want it untouched", current.Severity, current.Revision)
+				}
+				return
+			}
+			if patched.Severity != diveinspect.SeverityMajor || !current.ConfirmedByHuman || current.Revision != 2 {
+				t.Fatalf("finding = %s confirmed=%v rev %d", current.Severity, current.ConfirmedByHuman, current.Revision)
+			}
+			if got := env.eventTypes(); !slices.Equal(got, []diveinspect.EventType{diveinspect.EventFindingUpdated}) {
+				t.Fatalf("events = %v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,316 @@
+package diveinspectsrv
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Abraxas-365/divi/pkg/ai/aiusage"
+	"github.com/Abraxas-365/divi/pkg/ai/document"
+	"github.com/Abraxas-365/divi/pkg/ai/vstore"
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/Abraxas-365/divi/pkg/logx"
+)
+
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+	reindexPageSize    = 100
+)
+
+// InventorySearchService indexes published vehicles into a document store and
+// answers natural-language inventory queries combined with structured filters.
+type InventorySearchService struct {
+	vehicleRepo   diveinspect.VehicleRepository
+	specsRepo     diveinspect.VehicleSpecsRepository
+	equipmentRepo diveinspect.VehicleEquipmentRepository
+	listingRepo   diveinspect.GeneratedListingRepository
+	variantRepo   diveinspect.ListingVariantRepository
+	store         *document.DocumentStore
+}
+
+func NewInventorySearchService(
+	vehicleRepo diveinspect.VehicleRepository,
+	specsRepo diveinspect.VehicleSpecsRepository,
+	equipmentRepo diveinspect.VehicleEquipmentRepository,
+	listingRepo diveinspect.GeneratedListingRepository,
+	variantRepo diveinspect.ListingVariantRepository,
+	store *document.DocumentStore,
+) *InventorySearchService {
+	return &InventorySearchService{
+		vehicleRepo:   vehicleRepo,
+		specsRepo:     specsRepo,
+		equipmentRepo: equipmentRepo,
+		listingRepo:   listingRepo,
+		variantRepo:   variantRepo,
+		store:         store,
+	}
+}
+
+// IndexVehicle (re)indexes a vehicle if it is published, or removes it from
+// the index otherwise.
+func (s *InventorySearchService) IndexVehicle(ctx context.Context, vehicleID string) error {
+	vehicle, err := s.vehicleRepo.GetByID(ctx, vehicleID)
+	if err != nil {
+		return err
+	}
+	if vehicle.Status != diveinspect.VehicleStatusPublished {
+		return s.RemoveVehicle(ctx, vehicleID)
+	}
+	return s.ingest(usageContext(ctx, vehicle, featureSearch), []diveinspect.Vehicle{*vehicle})
+}
+
+// RemoveVehicle drops a vehicle from the index. Removing an unindexed vehicle
+// is not an error.
+func (s *InventorySearchService) RemoveVehicle(ctx context.Context, vehicleID string) error {
+	if err := s.store.DeleteDocuments(ctx, []string{vehicleID}); err != nil {
+		return errx.Wrap(err, "Failed to remove vehicle from search index", errx.TypeInternal)
+	}
+	return nil
+}
+
+// ReindexPublished rebuilds the index from every published vehicle.
+func (s *InventorySearchService) ReindexPublished(ctx context.Context) error {
+	for page := 1; ; page++ {
+		vehicles, total, err := s.vehicleRepo.ListByStatus(ctx, diveinspect.VehicleStatusPublished, page, reindexPageSize)
+		if err != nil {
+			return errx.Wrap(err, "Failed to list published vehicles", errx.TypeInternal)
+		}
+		if err := s.ingest(aiusage.WithFeature(ctx, featureSearch), vehicles); err != nil {
+			return err
+		}
+		if page*reindexPageSize >= total || len(vehicles) == 0 {
+			logx.Infof("Inventory search index rebuilt with %d vehicles", total)
+			return nil
+		}
+	}
+}
+
+// Search ranks the tenant's published vehicles by semantic similarity to the
+// query text, restricted to those matching the structured filters.
+func (s *InventorySearchService) Search(ctx context.Context, q diveinspect.InventorySearchQuery) ([]diveinspect.InventorySearchHit, error) {
+	if q.TenantID == "" {
+		return nil, errx.Validation("Inventory search requires a tenant")
+	}
+	q.Query = strings.TrimSpace(q.Query)
+	if q.Query == "" {
+		return nil, errx.Validation("Search query is required")
+	}
+	if q.Limit < 1 {
+		q.Limit = defaultSearchLimit
+	}
+	if q.Limit > maxSearchLimit {
+		q.Limit = maxSearchLimit
+	}
+
+	docs, err := document.NewRetriever(s.store).
+		WithTopK(q.Limit).
+		WithFilter(buildSearchFilter(q)).
+		Retrieve(aiusage.WithFeature(ctx, featureSearch), q.Query)
+	if err != nil {
+		return nil, errx.Wrap(err, "Inventory search failed", errx.TypeExternal)
+	}
+
+	hits := make([]diveinspect.InventorySearchHit, 0, len(docs))
+	for _, doc := range docs {
+		vehicle, err := s.vehicleRepo.GetByID(ctx, doc.ID)
+		if err != nil {
+			// The index may briefly lag behind deletes; skip stale entries.
+			continue
+		}
+		if vehicle.Status != diveinspect.VehicleStatusPublished {
+			continue
+		}
+
+		hit := diveinspect.InventorySearchHit{
+			Rank:    len(hits) + 1,
+			Vehicle: *vehicle,
+		}
+		if listing, err := s.listingRepo.GetByVehicleID(ctx, vehicle.ID); err == nil {
+			hit.Title = listing.Title
+		}
+		hits = append(hits, hit)
+	}
+
+	return hits, nil
+}
+
+func (s *InventorySearchService) ingest(ctx context.Context, vehicles []diveinspect.Vehicle) error {
+	if len(vehicles) == 0 {
+		return nil
+	}
+
+	loader := &vehicleLoader{svc: s, vehicles: vehicles}
+	result, err := document.NewIngestionPipeline(loader, s.store).Run(ctx)
+	if err != nil {
+		return errx.Wrap(err, "Failed to index vehicles", errx.TypeInternal)
+	}
+	if result.FailedCount > 0 || len(result.Errors) > 0 {
+		var cause error = fmt.Errorf("%d documents failed", result.FailedCount)
+		if len(result.Errors) > 0 {
+			cause = result.Errors[0]
+		}
+		return errx.Wrap(cause, "Failed to index vehicles", errx.TypeExternal).
+			WithDetail("failed", result.FailedCount)
+	}
+	return nil
+}
+
+// buildDocument renders a vehicle, its specs, equipment, listing and website
+// copy as a single searchable document. Filterable attributes go into metadata.
+func (s *InventorySearchService) buildDocument(ctx context.Context, v diveinspect.Vehicle) *document.Document {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %d", v.Brand, v.Model, v.Year)
+	if v.Version != nil {
+		fmt.Fprintf(&b, " %s", *v.Version)
+	}
+	if v.Trim != nil {
+		fmt.Fprintf(&b, " %s", *v.Trim)
+	}
+	fmt.Fprintf(&b, "\nMileage: %d km\n", v.MileageKM)
+	if v.ColorExterior != nil {
+		fmt.Fprintf(&b, "Exterior color: %s\n", *v.ColorExterior)
+	}
+	if v.ColorInterior != nil {
+		fmt.Fprintf(&b, "Interior color: %s\n", *v.ColorInterior)
+	}
+
+	doc := document.NewDocument("").
+		WithID(v.ID).
+		WithMetadata("vehicle_id", v.ID).
+		WithMetadata("brand", v.Brand).
+		WithMetadata("model", v.Model).
+		WithMetadata("year", v.Year).
+		WithMetadata("mileage_km", v.MileageKM).
+		WithMetadata("status", string(v.Status))
+	if v.TenantID != nil {
+		doc.WithMetadata("tenant_id", *v.TenantID)
+	}
+	if v.PriceUSD != nil {
+		doc.WithMetadata("price_usd", *v.PriceUSD)
+	}
+
+	if listing, err := s.listingRepo.GetByVehicleID(ctx, v.ID); err == nil {
+		if listing.Title != nil {
+			fmt.Fprintf(&b, "\n%s\n", *listing.Title)
+		}
+		if listing.DescriptionES != nil {
+			fmt.Fprintf(&b, "%s\n", *listing.DescriptionES)
+		}
+		if listing.DescriptionEN != nil {
+			fmt.Fprintf(&b, "%s\n", *listing.DescriptionEN)
+		}
+		if len(listing.SEOKeywords) > 0 {
+			fmt.Fprintf(&b, "Keywords: %s\n", strings.Join(listing.SEOKeywords, ", "))
+		}
+	}
+
+	// The website copy is what buyers read; other channels repeat it
+	variants, _ := s.variantRepo.ListActive(ctx, v.ID)
+	for _, variant := range variants {
+		if variant.Channel != diveinspect.ChannelWebsite {
+			continue
+		}
+		if variant.Title != nil {
+			fmt.Fprintf(&b, "\n%s\n", *variant.Title)
+		}
+		fmt.Fprintf(&b, "%s\n", variant.Body)
+	}
+
+	if specs, err := s.specsRepo.GetByVehicleID(ctx, v.ID); err == nil {
+		b.WriteString("\nSpecifications:\n")
+		writeSpec(&b, "Engine", specs.EngineType)
+		writeSpec(&b, "Fuel", specs.FuelType)
+		writeSpec(&b, "Transmission", specs.TransmissionType)
+		writeSpec(&b, "Drivetrain", specs.Drivetrain)
+		if specs.PowerHP != nil {
+			fmt.Fprintf(&b, "Power: %.0f HP\n", *specs.PowerHP)
+		}
+		if specs.TorqueNM != nil {
+			fmt.Fprintf(&b, "Torque: %d Nm\n", *specs.TorqueNM)
+		}
+		if specs.FuelType != nil {
+			doc.WithMetadata("fuel_type", strings.ToLower(*specs.FuelType))
+		}
+		if specs.Drivetrain != nil {
+			doc.WithMetadata("drivetrain", strings.ToLower(*specs.Drivetrain))
+		}
+	}
+
+	// Only confirmed equipment is searchable, same as the listing
+	equipment, _ := s.equipmentRepo.GetByVehicleID(ctx, v.ID)
+	if confirmed := confirmedEquipment(equipment); len(confirmed) > 0 {
+		b.WriteString("\nEquipment:\n")
+		for _, eq := range confirmed {
+			fmt.Fprintf(&b, "- %s\n", eq.FeatureName)
+		}
+	}
+
+	doc.Content = b.String()
+	return doc
+}
+
+func writeSpec(b *strings.Builder, label string, value *string) {
+	if value != nil && *value != "" {
+		fmt.Fprintf(b, "%s: %s\n", label, *value)
+	}
+}
+
+func buildSearchFilter(q diveinspect.InventorySearchQuery) *vstore.Filter {
+	f := vstore.NewFilter().
+		AddMust("tenant_id", vstore.OpEqual, q.TenantID).
+		AddMust("status", vstore.OpEqual, string(diveinspect.VehicleStatusPublished))
+	if q.MinPriceUSD != nil {
+		f.AddMust("price_usd", vstore.OpGreaterThanOrEqual, *q.MinPriceUSD)
+	}
+	if q.MaxPriceUSD != nil {
+		f.AddMust("price_usd", vstore.OpLessThanOrEqual, *q.MaxPriceUSD)
+	}
+	if q.MinYear != nil {
+		f.AddMust("year", vstore.OpGreaterThanOrEqual, *q.MinYear)
+	}
+	if q.MaxYear != nil {
+		f.AddMust("year", vstore.OpLessThanOrEqual, *q.MaxYear)
+	}
+	if q.MinMileageKM != nil {
+		f.AddMust("mileage_km", vstore.OpGreaterThanOrEqual, *q.MinMileageKM)
+	}
+	if q.MaxMileageKM != nil {
+		f.AddMust("mileage_km", vstore.OpLessThanOrEqual, *q.MaxMileageKM)
+	}
+	if q.FuelType != nil && *q.FuelType != "" {
+		f.AddMust("fuel_type", vstore.OpEqual, strings.ToLower(*q.FuelType))
+	}
+	return f
+}
+
+// ============================================================================
+// Vehicle Loader - feeds vehicles into the ingestion pipeline
+// ============================================================================
+
+type vehicleLoader struct {
+	svc      *InventorySearchService
+	vehicles []diveinspect.Vehicle
+}
+
+func (l *vehicleLoader) Load(ctx context.Context) ([]*document.Document, error) {
+	docs := make([]*document.Document, 0, len(l.vehicles))
+	for _, v := range l.vehicles {
+		docs = append(docs, l.svc.buildDocument(ctx, v))
+	}
+	return docs, nil
+}
+
+func (l *vehicleLoader) LoadStream(ctx context.Context) (document.DocumentStream, error) {
+	index := 0
+	return document.DocumentStreamFunc(func() (*document.Document, error) {
+		if index >= len(l.vehicles) {
+			return nil, io.EOF
+		}
+		v := l.vehicles[index]
+		index++
+		return l.svc.buildDocument(ctx, v), nil
+	}), nil
+}
@@ -0,0 +1,62 @@
+package diveinspectsrv
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Abraxas-365/divi/pkg/ai/document"
+	"github.com/Abraxas-365/divi/pkg/ai/embedding"
+	"github.com/Abraxas-365/divi/pkg/ai/vstore"
+	"github.com/Abraxas-365/divi/pkg/ai/vstore/providers/vstmemory"
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+// uniformEmbedder embeds every text as the same vector, so every indexed
+// vehicle matches every query and only the filters decide the hits.
+type uniformEmbedder struct{}
+
+func (uniformEmbedder) EmbedDocuments(ctx context.Context, documents []string, opts ...embedding.Option) ([]embedding.Embedding, error) {
+	embeddings := make([]embedding.Embedding, len(documents))
+	for i := range documents {
+		embeddings[i] = embedding.Embedding{Vector: []float32{1, 0}}
+	}
+	return embeddings, nil
+}
+
+func (uniformEmbedder) EmbedQuery(ctx context.Context, text string, opts ...embedding.Option) (embedding.Embedding, error) {
+	return embedding.Embedding{Vector: []float32{1, 0}}, nil
+}
+
+func (e *testEnv) searchService() *InventorySearchService {
+	vectors := vstore.NewClient(vstmemory.NewMemoryVectorStore(2, vstore.MetricCosine))
+	store := document.NewDocumentStore(vectors, document.NewEmbedder(uniformEmbedder{}, 2))
+	return NewInventorySearchService(e.vehicles, e.specs, e.equipment, e.listings, e.variants, store)
+}
+
+func TestInventorySearchServiceScopesToTenant(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	svc := env.searchService()
+	published := diveinspect.VehicleStatusPublished
+	ours := env.addVehicle(t, diveinspect.Vehicle{TenantID: ptr("tenant-a"), Status: published})
+	theirs := env.addVehicle(t, diveinspect.Vehicle{TenantID: ptr("tenant-b"), Status: published})
+	for _, v := range []*diveinspect.Vehicle{ours, theirs} {
+		if err := svc.IndexVehicle(ctx, v.ID); err != nil {
+			t.Fatalf("IndexVehicle: %v", err)
+		}
+	}
+
+	for _, tenant := range []string{"tenant-a", "tenant-b"} {
+		hits, err := svc.Search(ctx, diveinspect.InventorySearchQuery{TenantID: tenant, Query: "Toyota RAV4"})
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		if len(hits) != 1 || *hits[0].Vehicle.TenantID != tenant {
+			t.Fatalf("%s hits = %+v, want only its own vehicle", tenant, hits)
+		}
+	}
+
+	_, err := svc.Search(ctx, diveinspect.InventorySearchQuery{Query: "Toyota RAV4"})
+	assertType(t, err, errx.TypeValidation)
+}
@@ -0,0 +1,93 @@
+package diveinspectsrv
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+)
+
+// buildListingJSONLD renders the schema.org Car markup embedded in the
+// public vehicle page. Only known values are included.
+func buildListingJSONLD(vehicle *diveinspect.Vehicle, specs *diveinspect.VehicleSpecs, listing *diveinspect.GeneratedListing) (string, error) {
+	doc := map[string]any{
+		"@context":         "https://schema.org",
+		"@type":            "Car",
+		"brand":            map[string]any{"@type": "Brand", "name": vehicle.Brand},
+		"model":            vehicle.Model,
+		"vehicleModelDate": vehicle.Year,
+		"mileageFromOdometer": map[string]any{
+			"@type":    "QuantitativeValue",
+			"value":    vehicle.MileageKM,
+			"unitCode": "KMT",
+		},
+	}
+
+	if listing.Title != nil {
+		doc["name"] = *listing.Title
+	} else {
+		doc["name"] = strings.TrimSpace(vehicle.Brand + " " + vehicle.Model)
+	}
+	if listing.DescriptionES != nil {
+		doc["description"] = *listing.DescriptionES
+	}
+	if len(listing.SEOKeywords) > 0 {
+		doc["keywords"] = strings.Join(listing.SEOKeywords, ", ")
+	}
+	if vehicle.Version != nil {
+		doc["vehicleConfiguration"] = *vehicle.Version
+	}
+	if vehicle.VIN != nil {
+		doc["vehicleIdentificationNumber"] = *vehicle.VIN
+	}
+	if vehicle.ColorExterior != nil {
+		doc["color"] = *vehicle.ColorExterior
+	}
+	if vehicle.ColorInterior != nil {
+		doc["vehicleInteriorColor"] = *vehicle.ColorInterior
+	}
+	if vehicle.PriceUSD != nil {
+		doc["offers"] = map[string]any{
+			"@type":         "Offer",
+			"price":         *vehicle.PriceUSD,
+			"priceCurrency": "USD",
+			"itemCondition": "https://schema.org/UsedCondition",
+			"availability":  "https://schema.org/InStock",
+		}
+	}
+
+	if specs != nil {
+		if specs.FuelType != nil {
+			doc["fuelType"] = *specs.FuelType
+		}
+		if specs.TransmissionType != nil {
+			doc["vehicleTransmission"] = *specs.TransmissionType
+		}
+		if specs.Drivetrain != nil {
+			doc["driveWheelConfiguration"] = *specs.Drivetrain
+		}
+		engine := map[string]any{}
+		if specs.EngineType != nil {
+			engine["name"] = *specs.EngineType
+		}
+		if specs.PowerHP != nil {
+			engine["enginePower"] = map[string]any{"@type": "QuantitativeValue", "value": *specs.PowerHP, "unitCode": "BHP"}
+		}
+		if specs.TorqueNM != nil {
+			engine["torque"] = map[string]any{"@type": "QuantitativeValue", "value": *specs.TorqueNM, "unitCode": "NU"}
+		}
+		if specs.EngineCC != nil {
+			engine["engineDisplacement"] = map[string]any{"@type": "QuantitativeValue", "value": *specs.EngineCC, "unitCode": "CMQ"}
+		}
+		if len(engine) > 0 {
+			engine["@type"] = "EngineSpecification"
+			doc["vehicleEngine"] = engine
+		}
+	}
+
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,389 @@
+package diveinspectsrv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/Abraxas-365/divi/pkg/logx"
+)
+
+var languageNames = map[diveinspect.ListingLanguage]string{
+	diveinspect.LanguageES: "Spanish",
+	diveinspect.LanguageEN: "English",
+	diveinspect.LanguagePT: "Brazilian Portuguese",
+}
+
+var channelDescriptions = map[diveinspect.ListingChannel]string{
+	diveinspect.ChannelWebsite:     "the dealer's own website vehicle page",
+	diveinspect.ChannelMarketplace: "a third-party classifieds marketplace listing",
+	diveinspect.ChannelSocial:      "a social media post (Instagram/Facebook)",
+	diveinspect.ChannelWhatsApp:    "a WhatsApp message sent to an interested customer",
+}
+
+// ListingService owns tenant brand settings and the per-channel, per-language
+// listing variants with their version history.
+type ListingService struct {
+	llmClient     *llm.Client
+	specsRepo     diveinspect.VehicleSpecsRepository
+	equipmentRepo diveinspect.VehicleEquipmentRepository
+	variantRepo   diveinspect.ListingVariantRepository
+	brandRepo     diveinspect.BrandSettingsRepository
+	defaultBrand  diveinspect.BrandSettings
+	vehicleRepo   diveinspect.VehicleRepository
+	indexer       diveinspect.VehicleIndexer
+	txManager     diveinspect.TxManager
+	events        *EventPublisher
+}
+
+func NewListingService(
+	llmClient *llm.Client,
+	specsRepo diveinspect.VehicleSpecsRepository,
+	equipmentRepo diveinspect.VehicleEquipmentRepository,
+	variantRepo diveinspect.ListingVariantRepository,
+	brandRepo diveinspect.BrandSettingsRepository,
+	defaultBrand diveinspect.BrandSettings,
+	vehicleRepo diveinspect.VehicleRepository,
+	indexer diveinspect.VehicleIndexer,
+	txManager diveinspect.TxManager,
+	events *EventPublisher,
+) *ListingService {
+	return &ListingService{
+		llmClient:     llmClient,
+		specsRepo:     specsRepo,
+		equipmentRepo: equipmentRepo,
+		variantRepo:   variantRepo,
+		brandRepo:     brandRepo,
+		defaultBrand:  defaultBrand,
+		vehicleRepo:   vehicleRepo,
+		indexer:       indexer,
+		txManager:     txManager,
+		events:        events,
+	}
+}
+
+// ============================================================================
+// Brand Settings
+// ============================================================================
+
+// BrandSettings returns the tenant's brand settings, or the deployment
+// defaults when the tenant is unknown or hasn't configured any.
+func (s *ListingService) BrandSettings(ctx context.Context, tenantID *string) *diveinspect.BrandSettings {
+	if tenantID != nil && *tenantID != "" {
+		if b, err := s.brandRepo.GetByTenantID(ctx, *tenantID); err == nil {
+			return b
+		}
+	}
+	b := s.defaultBrand
+	if tenantID != nil {
+		b.TenantID = *tenantID
+	}
+	return &b
+}
+
+func (s *ListingService) UpdateBrandSettings(ctx context.Context, b *diveinspect.BrandSettings) error {
+	b.DealerName = strings.TrimSpace(b.DealerName)
+	if b.DealerName == "" {
+		return errx.Validation("dealer_name is required")
+	}
+	for channel, style := range b.ChannelStyles {
+		if _, ok := diveinspect.DefaultChannelStyles[channel]; !ok {
+			return errx.Validation("Invalid channel in channel_styles").WithDetail("channel", channel)
+		}
+		if style.MaxTitleChars < 0 || style.MaxBodyChars < 0 {
+			return errx.Validation("Channel length limits must not be negative").WithDetail("channel", channel)
+		}
+	}
+	claims := b.ForbiddenClaims[:0]
+	for _, c := range b.ForbiddenClaims {
+		if c = strings.TrimSpace(c); c != "" {
+			claims = append(claims, c)
+		}
+	}
+	b.ForbiddenClaims = claims
+
+	if err := s.brandRepo.Upsert(ctx, b); err != nil {
+		return errx.Wrap(err, "Failed to save brand settings", errx.TypeInternal)
+	}
+	return nil
+}
+
+// ============================================================================
+// Variants
+// ============================================================================
+
+type variantCopy struct {
+	Title    string   `json:"title"`
+	Body     string   `json:"body"`
+	Hashtags []string `json:"hashtags"`
+}
+
+// variantAnswer is one channel's copy keyed by language. Languages that
+// were not asked for are null.
+type variantAnswer struct {
+	ES *variantCopy `json:"es" description:"Spanish copy"`
+	EN *variantCopy `json:"en" description:"English copy"`
+	PT *variantCopy `json:"pt" description:"Brazilian Portuguese copy"`
+}
+
+func (a *variantAnswer) copies() map[diveinspect.ListingLanguage]variantCopy {
+	copies := make(map[diveinspect.ListingLanguage]variantCopy, len(diveinspect.ListingLanguages))
+	for lang, c := range map[diveinspect.ListingLanguage]*variantCopy{
+		diveinspect.LanguageES: a.ES,
+		diveinspect.LanguageEN: a.EN,
+		diveinspect.LanguagePT: a.PT,
+	} {
+		if c != nil {
+			copies[lang] = *c
+		}
+	}
+	return copies
+}
+
+// GenerateVariants writes new copy for each channel and language and stores it
+// as the next active version. Empty channels/languages mean all of them.
+func (s *ListingService) GenerateVariants(ctx context.Context, vehicle *diveinspect.Vehicle, channels []diveinspect.ListingChannel, languages []diveinspect.ListingLanguage) ([]diveinspect.ListingVariant, error) {
+	ctx = usageContext(ctx, vehicle, featureListing)
+	if len(channels) == 0 {
+		channels = diveinspect.ListingChannels
+	}
+	if len(languages) == 0 {
+		languages = diveinspect.ListingLanguages
+	}
+	for _, ch := range channels {
+		if _, ok := diveinspect.DefaultChannelStyles[ch]; !ok {
+			return nil, errx.Validation("Invalid listing channel").WithDetail("channel", ch)
+		}
+	}
+	for _, lang := range languages {
+		if _, ok := languageNames[lang]; !ok {
+			return nil, errx.Validation("Invalid listing language").WithDetail("language", lang)
+		}
+	}
+
+	brand := s.BrandSettings(ctx, vehicle.TenantID)
+	specs, _ := s.specsRepo.GetByVehicleID(ctx, vehicle.ID)
+	equipment, _ := s.equipmentRepo.GetByVehicleID(ctx, vehicle.ID)
+	facts := listingFacts(vehicle, specs, confirmedEquipment(equipment))
+
+	now := time.Now()
+	var variants []diveinspect.ListingVariant
+	for _, ch := range channels {
+		copies, err := s.generateChannel(ctx, brand, ch, languages, facts)
+		if err != nil {
+			logx.Errorf("Failed to generate %s listing for vehicle %s: %v", ch, vehicle.ID, err)
+			return nil, errx.Wrap(err, "Failed to generate listing variants", errx.TypeExternal).WithDetail("channel", ch)
+		}
+		for _, lang := range languages {
+			c := copies[lang]
+			v := diveinspect.ListingVariant{
+				VehicleID:   vehicle.ID,
+				Channel:     ch,
+				Language:    lang,
+				Body:        c.Body,
+				Hashtags:    c.Hashtags,
+				GeneratedAt: now,
+			}
+			if c.Title != "" {
+				title := c.Title
+				v.Title = &title
+			}
+			variants = append(variants, v)
+		}
+	}
+
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.variantRepo.CreateVersions(ctx, variants); err != nil {
+			return errx.Wrap(err, "Failed to save listing variants", errx.TypeInternal)
+		}
+		return s.events.Publish(ctx, vehicle.TenantID, diveinspect.EventListingVariantsGenerated, vehicle.ID, map[string]any{
+			"vehicle_id": vehicle.ID,
+			"variants":   variants,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	syncIndex(ctx, s.indexer, vehicle.ID)
+	logx.Infof("Generated %d listing variants for vehicle %s", len(variants), vehicle.ID)
+	return variants, nil
+}
+
+// generateChannel asks for all languages of one channel in a single call,
+// retrying once with feedback if the copy breaks a brand rule.
+func (s *ListingService) generateChannel(ctx context.Context, brand *diveinspect.BrandSettings, channel diveinspect.ListingChannel, languages []diveinspect.ListingLanguage, facts string) (map[diveinspect.ListingLanguage]variantCopy, error) {
+	style := brand.StyleFor(channel)
+
+	langList := make([]string, len(languages))
+	for i, lang := range languages {
+		langList[i] = fmt.Sprintf("%s (%s)", lang, languageNames[lang])
+	}
+
+	titleRule := fmt.Sprintf("- Title: at most %d characters", style.MaxTitleChars)
+	if style.MaxTitleChars == 0 {
+		titleRule = "- No title for this channel: leave the title empty"
+	}
+	hashtagRule := "- No hashtags: leave the hashtags empty"
+	if style.Hashtags {
+		hashtagRule = "- 3 to 8 relevant hashtags, without the # sign"
+	}
+
+	prompt := fmt.Sprintf(`Write vehicle sales copy for %s.
+
+%s
+Tone: %s
+Rules:
+%s
+- Body: at most %d characters
+%s
+- Only mention equipment from the list above
+- Write each language natively, not as a literal translation
+
+Languages: %s
+Write copy keyed by language code for these languages only; leave the others null.`, channelDescriptions[channel], facts, style.Tone,
+		titleRule, style.MaxBodyChars, hashtagRule, strings.Join(langList, ", "))
+
+	messages := []llm.Message{
+		llm.NewSystemMessage(brandSystemPrompt(brand)),
+		llm.NewUserMessage(prompt),
+	}
+
+	var lastViolation string
+	for attempt := 0; attempt < 2; attempt++ {
+		answer, _, err := llm.ChatStructured[variantAnswer](ctx, s.llmClient, messages, llm.WithTemperature(0.6))
+		if err != nil {
+			return nil, err
+		}
+		copies := answer.copies()
+
+		lastViolation = ""
+		for _, lang := range languages {
+			c, ok := copies[lang]
+			if !ok || strings.TrimSpace(c.Body) == "" {
+				lastViolation = fmt.Sprintf("missing copy for language %q", lang)
+				break
+			}
+			if claim := findForbiddenClaim(brand, c.Title+" "+c.Body); claim != "" {
+				lastViolation = fmt.Sprintf("the %s copy contains the forbidden claim %q", lang, claim)
+				break
+			}
+			c.Title = truncateText(c.Title, style.MaxTitleChars)
+			c.Body = truncateText(c.Body, style.MaxBodyChars)
+			if !style.Hashtags {
+				c.Hashtags = nil
+			}
+			copies[lang] = c
+		}
+		if lastViolation == "" {
+			return copies, nil
+		}
+
+		content, err := json.Marshal(answer)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages,
+			llm.NewAssistantMessage(string(content)),
+			llm.NewUserMessage("That response is not acceptable: "+lastViolation+". Rewrite all languages following every rule."),
+		)
+	}
+	return nil, fmt.Errorf("generated copy rejected: %s", lastViolation)
+}
+
+func (s *ListingService) ListVariants(ctx context.Context, vehicleID string) ([]diveinspect.ListingVariant, error) {
+	variants, err := s.variantRepo.ListActive(ctx, vehicleID)
+	if err != nil {
+		return nil, errx.Wrap(err, "Failed to list listing variants", errx.TypeInternal)
+	}
+	return variants, nil
+}
+
+func (s *ListingService) History(ctx context.Context, vehicleID string, channel diveinspect.ListingChannel, language diveinspect.ListingLanguage) ([]diveinspect.ListingVariant, error) {
+	variants, err := s.variantRepo.ListHistory(ctx, vehicleID, channel, language)
+	if err != nil {
+		return nil, errx.Wrap(err, "Failed to list listing history", errx.TypeInternal)
+	}
+	return variants, nil
+}
+
+// Rollback makes an earlier version the active copy again. Newer versions
+// stay in the history.
+func (s *ListingService) Rollback(ctx context.Context, vehicleID string, channel diveinspect.ListingChannel, language diveinspect.ListingLanguage, version int) (*diveinspect.ListingVariant, error) {
+	vehicle, err := s.vehicleRepo.GetByID(ctx, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	var v *diveinspect.ListingVariant
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		v, err = s.variantRepo.Activate(ctx, vehicleID, channel, language, version)
+		if err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, vehicle.TenantID, diveinspect.EventListingVariantActivated, v.ID, v)
+	})
+	if err != nil {
+		if _, ok := err.(*errx.Error); ok {
+			return nil, err
+		}
+		return nil, errx.Wrap(err, "Failed to roll back listing", errx.TypeInternal)
+	}
+	syncIndex(ctx, s.indexer, vehicleID)
+	logx.Infof("Listing %s/%s for vehicle %s rolled back to version %d", channel, language, vehicleID, version)
+	return v, nil
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// brandSystemPrompt renders the dealer identity and brand rules shared by all
+// listing copy.
+func brandSystemPrompt(brand *diveinspect.BrandSettings) string {
+	var b strings.Builder
+	if brand.DealerName != "" {
+		fmt.Fprintf(&b, "You are an expert automotive copywriter for %s.", brand.DealerName)
+	} else {
+		b.WriteString("You are an expert automotive copywriter for a vehicle dealer.")
+	}
+	b.WriteString(" Write compelling, accurate vehicle listings.")
+	if brand.WarrantyText != nil && *brand.WarrantyText != "" {
+		fmt.Fprintf(&b, " Mention the warranty: %q.", *brand.WarrantyText)
+	}
+	if len(brand.ForbiddenClaims) > 0 {
+		fmt.Fprintf(&b, " Never make these claims or use these phrases, in any language: %s.", strings.Join(brand.ForbiddenClaims, "; "))
+	}
+	b.WriteString(" Return only valid JSON.")
+	return b.String()
+}
+
+func findForbiddenClaim(brand *diveinspect.BrandSettings, text string) string {
+	lower := strings.ToLower(text)
+	for _, claim := range brand.ForbiddenClaims {
+		if claim != "" && strings.Contains(lower, strings.ToLower(claim)) {
+			return claim
+		}
+	}
+	return ""
+}
+
+// truncateText cuts text to at most max characters, preferring a word
+// boundary. A max of 0 means no text at all.
+func truncateText(text string, max int) string {
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	runes := []rune(text)
+	cut := string(runes[:max])
+	if i := strings.LastIndexAny(cut, " \n"); i > max/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:-\n")
+}
@@ -0,0 +1,81 @@
+package diveinspectsrv
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+// applyMergePatch applies a JSON Merge Patch (RFC 7396) to current and
+// decodes the result into a new T; current is left untouched. Members listed
+// in readOnly can't be patched, and members T doesn't have are rejected.
+func applyMergePatch[T any](current *T, patch []byte, readOnly ...string) (*T, error) {
+	var ops map[string]any
+	if err := json.Unmarshal(patch, &ops); err != nil || ops == nil {
+		return nil, diveinspect.NewError(diveinspect.ErrInvalidPatch).
+			WithDetail("reason", "patch must be a JSON object")
+	}
+	for _, field := range readOnly {
+		if _, ok := ops[field]; ok {
+			return nil, diveinspect.NewError(diveinspect.ErrInvalidPatch).
+				WithDetail("reason", "field is read-only").
+				WithDetail("field", field)
+		}
+	}
+
+	raw, err := json.Marshal(current)
+	if err != nil {
+		return nil, errx.Wrap(err, "Failed to encode entity for patching", errx.TypeInternal)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, errx.Wrap(err, "Failed to encode entity for patching", errx.TypeInternal)
+	}
+
+	merged, err := json.Marshal(mergeValue(doc, ops))
+	if err != nil {
+		return nil, errx.Wrap(err, "Failed to apply patch", errx.TypeInternal)
+	}
+
+	var out T
+	dec := json.NewDecoder(bytes.NewReader(merged))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&out); err != nil {
+		return nil, diveinspect.NewError(diveinspect.ErrInvalidPatch).
+			WithDetail("reason", err.Error())
+	}
+	return &out, nil
+}
+
+// mergeValue is the MergePatch algorithm of RFC 7396: objects merge member
+// by member, null removes a member, and anything else replaces the target.
+func mergeValue(target, patch any) any {
+	ops, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	doc, ok := target.(map[string]any)
+	if !ok {
+		doc = map[string]any{}
+	}
+	for k, v := range ops {
+		if v == nil {
+			delete(doc, k)
+			continue
+		}
+		doc[k] = mergeValue(doc[k], v)
+	}
+	return doc
+}
+
+// checkRevision enforces an If-Match precondition. ifMatch is nil when the
+// client sent none.
+func checkRevision(ifMatch *int, current int) error {
+	if ifMatch != nil && *ifMatch != current {
+		return diveinspect.NewError(diveinspect.ErrPreconditionFailed).
+			WithDetail("revision", current)
+	}
+	return nil
+}
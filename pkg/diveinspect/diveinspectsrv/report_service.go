@@ -65,7 +65,7 @@ func (s *ReportService) GenerateReport(ctx context.Context, vehicleID string) (s
 
 	// Update inspection with PDF URL
 	inspection.PDFURL = &storagePath
-	_ = s.inspectionRepo.Update(ctx, inspection)
+	_ = s.inspectionRepo.SetPDFURL(ctx, inspection.ID, storagePath)
 
 	return storagePath, pdfBytes, nil
 }
@@ -0,0 +1,76 @@
+package diveinspectsrv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+func TestReportServiceGenerateReport(t *testing.T) {
+	tests := []struct {
+		name           string
+		vehicleID      string
+		withInspection bool
+		wantErr        *errx.ErrorCode
+		wantTyp        errx.Type
+	}{
+		{name: "inspected vehicle", withInspection: true},
+		{name: "vehicle without inspection", wantTyp: errx.TypeNotFound},
+		{name: "unknown vehicle", vehicleID: "missing", wantErr: diveinspect.ErrVehicleNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newTestEnv(t)
+			v := env.addVehicle(t, diveinspect.Vehicle{})
+			var inspection *diveinspect.Inspection
+			if tt.withInspection {
+				inspection = env.addInspection(t, v.ID, diveinspect.InspectionFinding{
+					Zone:        diveinspect.ZoneRear,
+					FindingType: diveinspect.FindingDent,
+					Severity:    diveinspect.SeverityModerate,
+					Description: ptr("Abolladura en la puerta trasera"),
+				})
+			}
+			vehicleID := v.ID
+			if tt.vehicleID != "" {
+				vehicleID = tt.vehicleID
+			}
+
+			path, pdf, err := env.reportService().GenerateReport(ctx, vehicleID)
+			switch {
+			case tt.wantErr != nil:
+				assertCode(t, err, tt.wantErr)
+				return
+			case tt.wantTyp != "":
+				assertType(t, err, tt.wantTyp)
+				return
+			case err != nil:
+				t.Fatalf("GenerateReport: %v", err)
+			}
+
+			if want := fmt.Sprintf("reports/%s/inspection_report.pdf", v.ID); path != want {
+				t.Fatalf("path = %s, want %s", path, want)
+			}
+			if !bytes.HasPrefix(pdf, []byte("%PDF")) {
+				t.Fatalf("report is not a PDF: %.20q", pdf)
+			}
+			stored, err := env.fs.ReadFile(ctx, path)
+			if err != nil || !bytes.Equal(stored, pdf) {
+				t.Fatalf("stored report differs from the returned one (err %v)", err)
+			}
+			current, err := env.inspections.GetByID(ctx, inspection.ID)
+			if err != nil {
+				t.Fatalf("GetByID: %v", err)
+			}
+			if current.PDFURL == nil || *current.PDFURL != path {
+				t.Fatalf("pdf url = %v, want %s", current.PDFURL, path)
+			}
+		})
+	}
+}
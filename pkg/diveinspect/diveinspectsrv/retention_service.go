@@ -0,0 +1,128 @@
+package diveinspectsrv
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/fsx"
+	"github.com/Abraxas-365/divi/pkg/logx"
+)
+
+const retentionBatchSize = 50
+
+// RetentionService purges vehicles that have been soft-deleted for longer
+// than the retention period: first their rows, then their inspection photos
+// and reports in storage. The row goes first so a vehicle restored mid-purge
+// keeps its files; files that cannot be removed afterwards are logged.
+type RetentionService struct {
+	vehicleRepo    diveinspect.VehicleRepository
+	inspectionRepo diveinspect.InspectionRepository
+	fileSystem     fsx.FileSystem
+	txManager      diveinspect.TxManager
+	retention      time.Duration
+	interval       time.Duration
+}
+
+func NewRetentionService(
+	vehicleRepo diveinspect.VehicleRepository,
+	inspectionRepo diveinspect.InspectionRepository,
+	fileSystem fsx.FileSystem,
+	txManager diveinspect.TxManager,
+	retention time.Duration,
+	interval time.Duration,
+) *RetentionService {
+	return &RetentionService{
+		vehicleRepo:    vehicleRepo,
+		inspectionRepo: inspectionRepo,
+		fileSystem:     fileSystem,
+		txManager:      txManager,
+		retention:      retention,
+		interval:       interval,
+	}
+}
+
+// Start runs a purge pass every interval until ctx is cancelled.
+func (s *RetentionService) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logx.Info("Vehicle retention job stopped")
+			return
+		case <-ticker.C:
+			s.Purge(ctx)
+		}
+	}
+}
+
+// Purge hard-deletes every vehicle deleted before the retention cutoff and
+// returns how many it removed.
+func (s *RetentionService) Purge(ctx context.Context) int {
+	cutoff := time.Now().Add(-s.retention)
+	purged := 0
+
+	for ctx.Err() == nil {
+		vehicles, err := s.vehicleRepo.ListDeletedBefore(ctx, cutoff, retentionBatchSize)
+		if err != nil {
+			logx.Errorf("Failed to list expired vehicles: %v", err)
+			break
+		}
+
+		batchPurged := 0
+		for _, v := range vehicles {
+			if err := s.purgeVehicle(ctx, v.ID, cutoff); err != nil {
+				if isNotFound(err) {
+					logx.Infof("Vehicle %s was restored or deleted again before it could be purged", v.ID)
+				} else {
+					logx.Errorf("Failed to purge vehicle %s: %v", v.ID, err)
+				}
+				continue
+			}
+			batchPurged++
+		}
+		purged += batchPurged
+
+		// Vehicles that failed stay in the next batch; stop rather than
+		// retry them in a tight loop.
+		if len(vehicles) < retentionBatchSize || batchPurged < len(vehicles) {
+			break
+		}
+	}
+
+	if purged > 0 {
+		logx.Infof("Purged %d deleted vehicles", purged)
+	}
+	return purged
+}
+
+// purgeVehicle deletes the vehicle's row if it is still deleted before
+// cutoff, then its storage. The inspections are listed in the same
+// transaction, before the cascade removes them.
+func (s *RetentionService) purgeVehicle(ctx context.Context, vehicleID string, cutoff time.Time) error {
+	var dirs []string
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		inspections, err := s.inspectionRepo.ListByVehicleID(ctx, vehicleID)
+		if err != nil {
+			return err
+		}
+		for _, inspection := range inspections {
+			dirs = append(dirs, fmt.Sprintf("inspections/%s/", inspection.ID))
+		}
+		dirs = append(dirs, fmt.Sprintf("reports/%s/", vehicleID))
+		return s.vehicleRepo.HardDelete(ctx, vehicleID, cutoff)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range dirs {
+		if err := s.fileSystem.DeleteDir(ctx, dir, true); err != nil {
+			logx.Errorf("Purged vehicle %s left files behind in %s: %v", vehicleID, dir, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package diveinspectsrv
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+)
+
+func TestRetentionServicePurge(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	svc := NewRetentionService(env.vehicles, env.inspections, env.fs, env.tx, 0, time.Hour)
+	exists := func(path string) bool {
+		t.Helper()
+		ok, err := env.fs.Exists(ctx, path)
+		if err != nil {
+			t.Fatalf("Exists: %v", err)
+		}
+		return ok
+	}
+
+	expired := env.addVehicle(t, diveinspect.Vehicle{})
+	photo := "inspections/" + env.addInspection(t, expired.ID).ID + "/front.jpg"
+	beforeRecent := time.Now()
+	recent := env.addVehicle(t, diveinspect.Vehicle{})
+	report := "reports/" + recent.ID + "/report.pdf"
+	for _, path := range []string{photo, report} {
+		if err := env.fs.WriteFile(ctx, path, []byte("data")); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	for _, v := range []*diveinspect.Vehicle{expired, recent} {
+		if err := env.vehicles.Delete(ctx, v.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+	}
+
+	// Deleted after the cutoff, e.g. restored and deleted again mid-purge
+	err := svc.purgeVehicle(ctx, recent.ID, beforeRecent)
+	assertCode(t, err, diveinspect.ErrVehicleNotFound)
+	if !exists(report) {
+		t.Fatal("a vehicle deleted after the cutoff lost its report")
+	}
+
+	if n := svc.Purge(ctx); n != 2 {
+		t.Fatalf("purged %d vehicles, want 2", n)
+	}
+	if exists(photo) || exists(report) {
+		t.Fatal("purged vehicles left their files behind")
+	}
+	_, err = env.vehicles.Restore(ctx, expired.ID)
+	assertCode(t, err, diveinspect.ErrVehicleNotFound)
+}
@@ -0,0 +1,283 @@
+package diveinspectsrv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/Abraxas-365/divi/pkg/logx"
+)
+
+// SpecsCatalogService loads curated factory specs into the catalog that
+// enrichment consults before asking the LLM.
+type SpecsCatalogService struct {
+	catalogRepo diveinspect.SpecsCatalogRepository
+}
+
+func NewSpecsCatalogService(catalogRepo diveinspect.SpecsCatalogRepository) *SpecsCatalogService {
+	return &SpecsCatalogService{catalogRepo: catalogRepo}
+}
+
+// catalogJSONEntry is one object of a JSON catalog file: the catalog key plus
+// any VehicleSpecs fields at the top level.
+type catalogJSONEntry struct {
+	Brand   string `json:"brand"`
+	Model   string `json:"model"`
+	Version string `json:"version"`
+	Year    int    `json:"year"`
+	diveinspect.VehicleSpecs
+}
+
+// Import parses a CSV, XLSX or JSON catalog file and upserts its entries.
+// Tabular files have brand, model, version and year columns; every other
+// column is matched to a spec field by its JSON name ("power_hp", "Power HP").
+// Invalid rows are reported and skipped.
+func (s *SpecsCatalogService) Import(ctx context.Context, fileName string, data []byte) (*diveinspect.SpecsCatalogImportResult, error) {
+	var entries []diveinspect.SpecsCatalogEntry
+	var rowErrors []string
+	var err error
+
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv", ".txt":
+		entries, rowErrors, err = catalogFromTable(diveinspect.ImportFormatCSV, data)
+	case ".xlsx":
+		entries, rowErrors, err = catalogFromTable(diveinspect.ImportFormatXLSX, data)
+	case ".json":
+		entries, rowErrors, err = catalogFromJSON(data)
+	default:
+		return nil, errx.Validation("Unsupported file type, expected .csv, .xlsx or .json").WithDetail("file", fileName)
+	}
+	if err != nil {
+		return nil, errx.Validation("Could not parse catalog file").WithDetail("reason", err.Error())
+	}
+
+	if err := s.catalogRepo.UpsertBatch(ctx, entries); err != nil {
+		return nil, errx.Wrap(err, "Failed to save specs catalog", errx.TypeInternal)
+	}
+
+	logx.Infof("Specs catalog import %s: imported=%d, failed=%d", fileName, len(entries), len(rowErrors))
+	return &diveinspect.SpecsCatalogImportResult{
+		Imported: len(entries),
+		Failed:   len(rowErrors),
+		Errors:   rowErrors,
+	}, nil
+}
+
+// Lookup returns the catalog entry enrichment would use for the given vehicle.
+func (s *SpecsCatalogService) Lookup(ctx context.Context, brand, model string, version *string, year int) (*diveinspect.SpecsCatalogEntry, error) {
+	entry, err := s.catalogRepo.FindBest(ctx, brand, model, version, year)
+	if err != nil {
+		return nil, errx.Wrap(err, "Failed to search specs catalog", errx.TypeInternal)
+	}
+	if entry == nil {
+		return nil, errx.NotFound("Specs catalog entry not found").
+			WithDetail("brand", brand).
+			WithDetail("model", model).
+			WithDetail("year", year)
+	}
+	return entry, nil
+}
+
+func catalogFromTable(format diveinspect.ImportFormat, data []byte) ([]diveinspect.SpecsCatalogEntry, []string, error) {
+	table, err := parseImportFile(format, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns := make(map[string]int, len(table.Headers))
+	for i, h := range table.Headers {
+		columns[strings.ReplaceAll(normalizeHeader(h), " ", "_")] = i
+	}
+	for _, required := range []string{"brand", "model", "year"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("missing %q column", required)
+		}
+	}
+
+	var entries []diveinspect.SpecsCatalogEntry
+	var rowErrors []string
+	for n, row := range table.Rows {
+		cell := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		entry := diveinspect.SpecsCatalogEntry{
+			Brand:   cell("brand"),
+			Model:   cell("model"),
+			Version: cell("version"),
+		}
+		year, err := parseImportInt(cell("year"))
+		if err != nil {
+			rowErrors = append(rowErrors, fmt.Sprintf("row %d: invalid year %q", n+2, cell("year")))
+			continue
+		}
+		entry.Year = year
+
+		specs := diveinspect.VehicleSpecs{}
+		var problems []string
+		for name := range columns {
+			raw := cell(name)
+			if raw == "" {
+				continue
+			}
+			if err := setCatalogSpecField(&specs, name, raw); err != nil {
+				problems = append(problems, err.Error())
+			}
+		}
+		if len(problems) > 0 {
+			rowErrors = append(rowErrors, fmt.Sprintf("row %d: %s", n+2, strings.Join(problems, "; ")))
+			continue
+		}
+		entry.Specs = diveinspect.CatalogSpecs(specs)
+
+		if err := validateCatalogEntry(&entry); err != nil {
+			rowErrors = append(rowErrors, fmt.Sprintf("row %d: %s", n+2, err))
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rowErrors, nil
+}
+
+func catalogFromJSON(data []byte) ([]diveinspect.SpecsCatalogEntry, []string, error) {
+	var raw []catalogJSONEntry
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, err
+	}
+
+	var entries []diveinspect.SpecsCatalogEntry
+	var rowErrors []string
+	for n, r := range raw {
+		specs := r.VehicleSpecs
+		clearSpecMetaFields(&specs)
+		entry := diveinspect.SpecsCatalogEntry{
+			Brand:   strings.TrimSpace(r.Brand),
+			Model:   strings.TrimSpace(r.Model),
+			Version: strings.TrimSpace(r.Version),
+			Year:    r.Year,
+			Specs:   diveinspect.CatalogSpecs(specs),
+		}
+		if err := validateCatalogEntry(&entry); err != nil {
+			rowErrors = append(rowErrors, fmt.Sprintf("entry %d: %s", n+1, err))
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rowErrors, nil
+}
+
+func validateCatalogEntry(entry *diveinspect.SpecsCatalogEntry) error {
+	if entry.Brand == "" || entry.Model == "" {
+		return fmt.Errorf("brand and model are required")
+	}
+	if entry.Year < 1950 || entry.Year > 2100 {
+		return fmt.Errorf("invalid year %d", entry.Year)
+	}
+	specs := diveinspect.VehicleSpecs(entry.Specs)
+	if len(missingSpecFields(&specs)) == len(missingSpecFields(&diveinspect.VehicleSpecs{})) {
+		return fmt.Errorf("no spec values")
+	}
+	// Curated values go through the same guardrails as generated ones, but a
+	// failing value rejects the row instead of being dropped
+	if report := validateSpecs(&specs, 0, nil); len(report.Issues) > 0 {
+		problems := make([]string, len(report.Issues))
+		for i, issue := range report.Issues {
+			problems[i] = fmt.Sprintf("%s: %s", issue.Field, issue.Reason)
+		}
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// parseSpecNumber reads a spec cell such as "15.625", "15,6", "1.998" or
+// "1,998 cc". When both separators appear the last one is the decimal point.
+// A single separator is a decimal point, except in an integer field where
+// exactly three digits follow it: "1.998" cc is 1998 but 15.625 km/l stays
+// fractional.
+func parseSpecNumber(raw string, integer bool) (float64, error) {
+	if strings.ContainsAny(raw, "eE") {
+		return strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	}
+	clean := strings.Map(func(r rune) rune {
+		if (r >= '0' && r <= '9') || r == '.' || r == ',' {
+			return r
+		}
+		return -1
+	}, raw)
+
+	lastDot := strings.LastIndex(clean, ".")
+	lastComma := strings.LastIndex(clean, ",")
+	decimalAt := -1
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		decimalAt = max(lastDot, lastComma)
+	case strings.Count(clean, ".")+strings.Count(clean, ",") == 1:
+		decimalAt = max(lastDot, lastComma)
+		if integer && len(clean)-decimalAt-1 == 3 {
+			decimalAt = -1
+		}
+	}
+
+	var b strings.Builder
+	for i, r := range clean {
+		switch {
+		case i == decimalAt:
+			b.WriteRune('.')
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		}
+	}
+	return strconv.ParseFloat(b.String(), 64)
+}
+
+// setCatalogSpecField sets the spec field with the given JSON name from a
+// cell value. Columns that are not spec fields are ignored.
+func setCatalogSpecField(specs *diveinspect.VehicleSpecs, name, raw string) error {
+	if specMetaFields[name] {
+		return nil
+	}
+	v := reflect.ValueOf(specs).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Split(t.Field(i).Tag.Get("json"), ",")[0] != name {
+			continue
+		}
+		f := v.Field(i)
+		if f.Kind() != reflect.Ptr {
+			return nil
+		}
+		switch f.Type().Elem().Kind() {
+		case reflect.String:
+			s := raw
+			f.Set(reflect.ValueOf(&s))
+		case reflect.Int, reflect.Float64:
+			n, err := parseSpecNumber(raw, f.Type().Elem().Kind() == reflect.Int)
+			if err != nil {
+				return fmt.Errorf("invalid %s %q", name, raw)
+			}
+			setSpecFieldValue(f, n)
+		}
+		return nil
+	}
+	return nil
+}
+
+// clearSpecMetaFields drops record-level fields a catalog file might carry.
+func clearSpecMetaFields(specs *diveinspect.VehicleSpecs) {
+	v := reflect.ValueOf(specs).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if specMetaFields[strings.Split(t.Field(i).Tag.Get("json"), ",")[0]] {
+			v.Field(i).Set(reflect.Zero(t.Field(i).Type))
+		}
+	}
+}
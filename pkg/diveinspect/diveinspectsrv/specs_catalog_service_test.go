@@ -0,0 +1,53 @@
+package diveinspectsrv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+)
+
+func TestParseSpecNumber(t *testing.T) {
+	tests := []struct {
+		raw     string
+		integer bool
+		want    float64
+	}{
+		{"15.625", false, 15.625},
+		{"15,6", false, 15.6},
+		{"7.9 s", false, 7.9},
+		{"1.234,5", false, 1234.5},
+		{"1998", true, 1998},
+		{"1.998", true, 1998},
+		{"1,998 cc", true, 1998},
+		{"2.0", true, 2},
+		{"1.234.567", true, 1234567},
+	}
+	for _, tt := range tests {
+		got, err := parseSpecNumber(tt.raw, tt.integer)
+		if err != nil || got != tt.want {
+			t.Errorf("parseSpecNumber(%q, %v) = %g, %v; want %g", tt.raw, tt.integer, got, err, tt.want)
+		}
+	}
+}
+
+func TestCatalogFromTable(t *testing.T) {
+	csv := "brand,model,version,year,fuel_combined_kml,engine_cc,power_hp,power_kw\n" +
+		"Toyota,RAV4,XLE,2022,\"15,625\",\"1.998\",203,151\n" +
+		"Toyota,Corolla,,2022,15.625,1798,140,500\n"
+
+	entries, rowErrors, err := catalogFromTable(diveinspect.ImportFormatCSV, []byte(csv))
+	if err != nil {
+		t.Fatalf("catalogFromTable: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("entries = %d, want 1", len(entries))
+	}
+	specs := entries[0].Specs
+	if *specs.FuelCombinedKML != 15.625 || *specs.EngineCC != 1998 {
+		t.Fatalf("specs = %g km/l, %d cc; want 15.625 km/l, 1998 cc", *specs.FuelCombinedKML, *specs.EngineCC)
+	}
+	if len(rowErrors) != 1 || !strings.Contains(rowErrors[0], "row 3") || !strings.Contains(rowErrors[0], "power_kw") {
+		t.Fatalf("row errors = %v, want the Corolla's kW rejected", rowErrors)
+	}
+}
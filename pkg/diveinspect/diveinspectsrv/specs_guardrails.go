@@ -0,0 +1,376 @@
+package diveinspectsrv
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+)
+
+// specsReviewThreshold is the confidence below which enriched specs go to
+// manual review instead of being publishable.
+const specsReviewThreshold = 0.7
+
+// specsAgreementTolerance is how far (relative to the median) a sample may
+// deviate and still count as agreeing.
+const specsAgreementTolerance = 0.05
+
+// specsExpectedFields is the number of numeric fields a confident answer
+// usually fills; sparser answers lower the confidence.
+const specsExpectedFields = 10
+
+type specRange struct {
+	Min, Max float64
+}
+
+// globalSpecRanges are hard physical limits for any passenger or light
+// commercial vehicle. Anything outside is a hallucination.
+var globalSpecRanges = map[string]specRange{
+	"engine_cc":          {50, 8500},
+	"engine_cylinders":   {1, 16},
+	"power_hp":           {20, 1600},
+	"power_kw":           {15, 1200},
+	"torque_nm":          {40, 2000},
+	"transmission_gears": {1, 10},
+	"accel_0_100":        {1.8, 30},
+	"top_speed_kmh":      {80, 420},
+	"fuel_city_kml":      {2, 50},
+	"fuel_highway_kml":   {2, 50},
+	"fuel_combined_kml":  {2, 50},
+	"fuel_tank_liters":   {20, 200},
+	"length_mm":          {2300, 7500},
+	"width_mm":           {1400, 2600},
+	"height_mm":          {1000, 3200},
+	"wheelbase_mm":       {1500, 4500},
+	"cargo_liters":       {50, 20000},
+	"cargo_max_liters":   {100, 20000},
+	"curb_weight_kg":     {500, 4500},
+}
+
+// segmentSpecRanges narrow the global limits for the segments the model
+// classifies vehicles into.
+var segmentSpecRanges = map[string]map[string]specRange{
+	"city": {
+		"power_hp": {40, 180}, "length_mm": {3000, 4200},
+		"wheelbase_mm": {2000, 2700}, "curb_weight_kg": {600, 1400},
+	},
+	"compact": {
+		"power_hp": {70, 420}, "length_mm": {3900, 4700},
+		"wheelbase_mm": {2400, 2850}, "curb_weight_kg": {900, 1800},
+	},
+	"midsize": {
+		"power_hp": {100, 650}, "length_mm": {4500, 5100},
+		"wheelbase_mm": {2650, 3050}, "curb_weight_kg": {1200, 2200},
+	},
+	"fullsize": {
+		"power_hp": {150, 800}, "length_mm": {4900, 5600},
+		"wheelbase_mm": {2850, 3500}, "curb_weight_kg": {1500, 2800},
+	},
+	"suv": {
+		"power_hp": {80, 800}, "length_mm": {3900, 5800},
+		"wheelbase_mm": {2300, 3500}, "curb_weight_kg": {1000, 3200},
+	},
+	"pickup": {
+		"power_hp": {80, 720}, "length_mm": {4700, 6500},
+		"wheelbase_mm": {2800, 4200}, "curb_weight_kg": {1500, 3800},
+	},
+	"van": {
+		"power_hp": {70, 400}, "length_mm": {4000, 7500},
+		"wheelbase_mm": {2500, 4400}, "curb_weight_kg": {1300, 3900},
+	},
+	"sports": {
+		"power_hp": {150, 1600}, "length_mm": {3800, 5100},
+		"wheelbase_mm": {2200, 3000}, "curb_weight_kg": {900, 2300},
+	},
+}
+
+// ============================================================================
+// Validation
+// ============================================================================
+
+// validateSpecs checks specs against the plausibility rules, nulls every
+// field that fails, and scores the result. agreement is the multi-sample
+// agreement ratio, nil when only one sample was taken.
+func validateSpecs(specs *diveinspect.VehicleSpecs, samples int, agreement *float64) *diveinspect.SpecsValidation {
+	fields := specNumericFields(specs)
+	report := &diveinspect.SpecsValidation{
+		Samples:     samples,
+		Agreement:   agreement,
+		Issues:      []diveinspect.SpecIssue{},
+		ValidatedAt: time.Now(),
+	}
+
+	values := make(map[string]float64, len(fields))
+	for name, f := range fields {
+		if v, ok := specFieldValue(f); ok {
+			values[name] = v
+		}
+	}
+	report.CheckedFields = len(values)
+
+	failed := make(map[string]bool)
+	reject := func(field, rule, reason string) {
+		if _, ok := values[field]; !ok || failed[field] {
+			return
+		}
+		failed[field] = true
+		report.Issues = append(report.Issues, diveinspect.SpecIssue{
+			Field:  field,
+			Value:  values[field],
+			Rule:   rule,
+			Reason: reason,
+		})
+	}
+	present := func(field string) (float64, bool) {
+		v, ok := values[field]
+		return v, ok && !failed[field]
+	}
+
+	// Hard physical limits
+	for _, name := range sortedKeys(values) {
+		if r, ok := globalSpecRanges[name]; ok && (values[name] < r.Min || values[name] > r.Max) {
+			reject(name, "physical_range", fmt.Sprintf("%g is outside the plausible range %g–%g", values[name], r.Min, r.Max))
+		}
+	}
+
+	// Segment limits
+	segment := ""
+	if specs.Segment != nil {
+		segment = strings.ToLower(strings.TrimSpace(*specs.Segment))
+	}
+	if ranges, ok := segmentSpecRanges[segment]; ok {
+		for _, name := range sortedKeys(values) {
+			v, ok := present(name)
+			r, hasRange := ranges[name]
+			if ok && hasRange && (v < r.Min || v > r.Max) {
+				reject(name, "segment_range", fmt.Sprintf("%g is implausible for a %s vehicle (expected %g–%g)", v, segment, r.Min, r.Max))
+			}
+		}
+	}
+
+	// kW and HP must describe the same output (1 hp = 0.7457 kW; metric PS is within tolerance)
+	if hp, ok := present("power_hp"); ok {
+		if kw, ok := present("power_kw"); ok {
+			expected := hp * 0.7457
+			if math.Abs(kw-expected)/expected > 0.05 {
+				reason := fmt.Sprintf("%g kW does not match %g HP (expected about %.0f kW)", kw, hp, expected)
+				reject("power_hp", "kw_hp_consistency", reason)
+				reject("power_kw", "kw_hp_consistency", reason)
+			}
+		}
+	}
+
+	// Acceleration must roughly follow power-to-weight
+	if hp, ok := present("power_hp"); ok {
+		if kg, ok := present("curb_weight_kg"); ok {
+			if accel, ok := present("accel_0_100"); ok {
+				hpPerTon := hp / (kg / 1000)
+				expected := 2.5 + 850/hpPerTon
+				if accel < expected*0.5 || accel > expected*2 {
+					reject("accel_0_100", "accel_vs_power",
+						fmt.Sprintf("%gs 0-100 is implausible for %.0f HP/ton (expected about %.1fs)", accel, hpPerTon, expected))
+				}
+			}
+		}
+	}
+
+	// Dimensions must be consistent with each other
+	if length, ok := present("length_mm"); ok {
+		if wb, ok := present("wheelbase_mm"); ok && wb >= length*0.9 {
+			reject("wheelbase_mm", "dimension_consistency", fmt.Sprintf("wheelbase %g mm is too long for a %g mm vehicle", wb, length))
+		}
+		if w, ok := present("width_mm"); ok && w >= length {
+			reject("width_mm", "dimension_consistency", fmt.Sprintf("width %g mm exceeds length %g mm", w, length))
+		}
+	}
+	if cargo, ok := present("cargo_liters"); ok {
+		if max, ok := present("cargo_max_liters"); ok && max < cargo {
+			reject("cargo_max_liters", "dimension_consistency", fmt.Sprintf("max cargo %g L is less than cargo %g L", max, cargo))
+		}
+	}
+
+	for name := range failed {
+		fields[name].Set(reflect.Zero(fields[name].Type()))
+	}
+	report.PassedFields = report.CheckedFields - len(failed)
+	report.Confidence = specsConfidence(report)
+	return report
+}
+
+// specsConfidence combines the pass ratio, sample agreement and coverage.
+// A single sample counts as 0.5 agreement: nothing confirms or contradicts it.
+func specsConfidence(r *diveinspect.SpecsValidation) float64 {
+	if r.CheckedFields == 0 {
+		return 0
+	}
+	passRatio := float64(r.PassedFields) / float64(r.CheckedFields)
+	agreement := 0.5
+	if r.Agreement != nil {
+		agreement = *r.Agreement
+	}
+	coverage := math.Min(1, float64(r.PassedFields)/specsExpectedFields)
+
+	confidence := passRatio * (0.5 + 0.5*agreement) * (0.5 + 0.5*coverage)
+	return math.Round(confidence*100) / 100
+}
+
+// ============================================================================
+// Multi-sample merge
+// ============================================================================
+
+// mergeSpecSamples combines independent samples into one set of specs: the
+// median for numeric fields and the most common answer for text fields. It
+// also returns the share of numeric fields on which the samples agree.
+func mergeSpecSamples(samples []*diveinspect.VehicleSpecs) (*diveinspect.VehicleSpecs, *float64) {
+	merged := *samples[0]
+	if len(samples) == 1 {
+		return &merged, nil
+	}
+
+	mv := reflect.ValueOf(&merged).Elem()
+	t := mv.Type()
+	compared, agreed := 0, 0
+
+	for i := 0; i < t.NumField(); i++ {
+		field := mv.Field(i)
+		if field.Kind() != reflect.Ptr {
+			continue
+		}
+
+		switch field.Type().Elem().Kind() {
+		case reflect.Int, reflect.Float64:
+			var vals []float64
+			for _, s := range samples {
+				if v, ok := specFieldValue(reflect.ValueOf(s).Elem().Field(i)); ok {
+					vals = append(vals, v)
+				}
+			}
+			if len(vals) == 0 {
+				continue
+			}
+			median := medianOf(vals)
+			setSpecFieldValue(field, median)
+
+			if len(vals) >= 2 {
+				compared++
+				if withinTolerance(vals, median) {
+					agreed++
+				}
+			}
+
+		case reflect.String:
+			counts := map[string]int{}
+			best, bestCount := "", 0
+			for _, s := range samples {
+				f := reflect.ValueOf(s).Elem().Field(i)
+				if f.IsNil() {
+					continue
+				}
+				key := strings.ToLower(strings.TrimSpace(f.Elem().String()))
+				counts[key]++
+				if counts[key] > bestCount {
+					best, bestCount = f.Elem().String(), counts[key]
+				}
+			}
+			if bestCount > 0 {
+				v := best
+				field.Set(reflect.ValueOf(&v))
+			}
+		}
+	}
+
+	if compared == 0 {
+		return &merged, nil
+	}
+	agreement := float64(agreed) / float64(compared)
+	return &merged, &agreement
+}
+
+func withinTolerance(vals []float64, median float64) bool {
+	for _, v := range vals {
+		if median == 0 {
+			if v != 0 {
+				return false
+			}
+			continue
+		}
+		if math.Abs(v-median)/math.Abs(median) > specsAgreementTolerance {
+			return false
+		}
+	}
+	return true
+}
+
+func medianOf(vals []float64) float64 {
+	sorted := append([]float64(nil), vals...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// ============================================================================
+// Reflection helpers
+// ============================================================================
+
+// specNumericFields maps the JSON name of every *int / *float64 spec field to
+// its settable value.
+func specNumericFields(specs *diveinspect.VehicleSpecs) map[string]reflect.Value {
+	v := reflect.ValueOf(specs).Elem()
+	t := v.Type()
+	fields := make(map[string]reflect.Value)
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i).Type
+		if ft.Kind() != reflect.Ptr {
+			continue
+		}
+		if k := ft.Elem().Kind(); k != reflect.Int && k != reflect.Float64 {
+			continue
+		}
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "specs_confidence" {
+			continue
+		}
+		fields[name] = v.Field(i)
+	}
+	return fields
+}
+
+func specFieldValue(f reflect.Value) (float64, bool) {
+	if f.IsNil() {
+		return 0, false
+	}
+	switch f.Elem().Kind() {
+	case reflect.Int:
+		return float64(f.Elem().Int()), true
+	case reflect.Float64:
+		return f.Elem().Float(), true
+	}
+	return 0, false
+}
+
+func setSpecFieldValue(f reflect.Value, v float64) {
+	switch f.Type().Elem().Kind() {
+	case reflect.Int:
+		n := int(math.Round(v))
+		f.Set(reflect.ValueOf(&n))
+	case reflect.Float64:
+		x := v
+		f.Set(reflect.ValueOf(&x))
+	}
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
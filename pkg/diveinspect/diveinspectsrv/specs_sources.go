@@ -0,0 +1,376 @@
+package diveinspectsrv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/logx"
+)
+
+// specsSamples is how many independent answers the LLM source compares.
+const specsSamples = 3
+
+// ============================================================================
+// Chain
+// ============================================================================
+
+// SpecsChain asks its sources in priority order. Each source only fills the
+// fields still missing after the sources before it, and every field records
+// which source supplied it.
+type SpecsChain struct {
+	sources []diveinspect.SpecsSource
+}
+
+func NewSpecsChain(sources ...diveinspect.SpecsSource) *SpecsChain {
+	return &SpecsChain{sources: sources}
+}
+
+func (c *SpecsChain) Name() string { return "chain" }
+
+func (c *SpecsChain) FetchSpecs(ctx context.Context, vehicle *diveinspect.Vehicle) (*diveinspect.VehicleSpecs, error) {
+	return c.FetchSpecsOver(ctx, vehicle, nil)
+}
+
+// FetchSpecsOver is FetchSpecs for a vehicle that already has specs. The
+// fields a person corrected in current seed the result, so no source is asked
+// for them or may overwrite them. current may be nil.
+func (c *SpecsChain) FetchSpecsOver(ctx context.Context, vehicle *diveinspect.Vehicle, current *diveinspect.VehicleSpecs) (*diveinspect.VehicleSpecs, error) {
+	result := &diveinspect.VehicleSpecs{VehicleID: vehicle.ID}
+	provenance := diveinspect.SpecsProvenance{}
+	var used []string
+	var validation *diveinspect.SpecsValidation
+	var lastErr error
+
+	// Confidence-weighted count of filled fields, for the combined score.
+	// Corrections count as certain.
+	weighted := 0.0
+	if manual := keepManualSpecs(result, current); len(manual) > 0 {
+		for _, field := range manual {
+			provenance[field] = specsManualSource
+		}
+		used = append(used, specsManualSource)
+		weighted += float64(len(manual))
+	}
+	sourced := 0
+
+	for _, src := range c.sources {
+		missing := missingSpecFields(result)
+		if len(missing) == 0 {
+			break
+		}
+
+		specs, err := src.FetchSpecs(ctx, vehicle)
+		if err != nil {
+			logx.Warnf("Specs source %s failed for vehicle %s: %v", src.Name(), vehicle.ID, err)
+			lastErr = err
+			continue
+		}
+		if specs == nil {
+			continue
+		}
+
+		// Sources that don't score themselves (curated data) count as certain
+		confidence := 1.0
+		if specs.SpecsConfidence != nil {
+			confidence = *specs.SpecsConfidence
+		}
+
+		filled := fillSpecFields(result, specs, missing)
+		for _, field := range filled {
+			provenance[field] = src.Name()
+		}
+		if len(filled) > 0 {
+			used = append(used, src.Name())
+			weighted += confidence * float64(len(filled))
+			sourced += len(filled)
+		}
+		if specs.Validation != nil {
+			validation = specs.Validation
+		}
+	}
+
+	if sourced == 0 && len(missingSpecFields(result)) > 0 {
+		if lastErr != nil {
+			return nil, lastErr
+		}
+		return nil, nil
+	}
+
+	now := time.Now()
+	source := strings.Join(used, "+")
+	confidence := math.Round(weighted/float64(len(provenance))*100) / 100
+	if validation == nil {
+		validation = &diveinspect.SpecsValidation{Samples: 1, Issues: []diveinspect.SpecIssue{}, ValidatedAt: now}
+	}
+	validation.Confidence = confidence
+
+	result.SpecsSource = &source
+	result.SpecsConfidence = &confidence
+	result.Provenance = provenance
+	result.Validation = validation
+	result.EnrichedAt = &now
+	result.ReviewStatus = diveinspect.SpecsApproved
+	if confidence < specsReviewThreshold {
+		result.ReviewStatus = diveinspect.SpecsNeedsReview
+	}
+	return result, nil
+}
+
+// ============================================================================
+// Catalog source
+// ============================================================================
+
+// CatalogSpecsSource serves specs from the imported factory catalog.
+type CatalogSpecsSource struct {
+	catalogRepo diveinspect.SpecsCatalogRepository
+}
+
+func NewCatalogSpecsSource(catalogRepo diveinspect.SpecsCatalogRepository) *CatalogSpecsSource {
+	return &CatalogSpecsSource{catalogRepo: catalogRepo}
+}
+
+func (s *CatalogSpecsSource) Name() string { return "catalog" }
+
+func (s *CatalogSpecsSource) FetchSpecs(ctx context.Context, vehicle *diveinspect.Vehicle) (*diveinspect.VehicleSpecs, error) {
+	entry, err := s.catalogRepo.FindBest(ctx, vehicle.Brand, vehicle.Model, vehicle.Version, vehicle.Year)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+	specs := diveinspect.VehicleSpecs(entry.Specs)
+	specs.VehicleID = vehicle.ID
+	return &specs, nil
+}
+
+// ============================================================================
+// LLM source
+// ============================================================================
+
+// LLMSpecsSource asks the language model for factory specs.
+type LLMSpecsSource struct {
+	llmClient *llm.Client
+}
+
+func NewLLMSpecsSource(llmClient *llm.Client) *LLMSpecsSource {
+	return &LLMSpecsSource{llmClient: llmClient}
+}
+
+// specsAnswer is the specs the model is asked for. Fields share their JSON
+// names with VehicleSpecs; unknown values are null.
+type specsAnswer struct {
+	EngineType        *string  `json:"engine_type" description:"e.g. 'Inline-4 Turbo'"`
+	EngineCC          *int     `json:"engine_cc"`
+	EngineCylinders   *int     `json:"engine_cylinders"`
+	PowerHP           *float64 `json:"power_hp"`
+	PowerKW           *float64 `json:"power_kw"`
+	TorqueNM          *int     `json:"torque_nm"`
+	TorqueRPMRange    *string  `json:"torque_rpm_range" description:"e.g. '1620-2600'"`
+	FuelType          *string  `json:"fuel_type"`
+	FuelSystem        *string  `json:"fuel_system"`
+	TransmissionType  *string  `json:"transmission_type" description:"e.g. '7G-DCT Doble Embrague'"`
+	TransmissionGears *int     `json:"transmission_gears"`
+	Drivetrain        *string  `json:"drivetrain" description:"FWD, RWD, AWD or 4WD"`
+	Accel0100         *float64 `json:"accel_0_100" description:"Seconds from 0 to 100 km/h"`
+	TopSpeedKMH       *int     `json:"top_speed_kmh"`
+	FuelCityKML       *float64 `json:"fuel_city_kml" description:"Kilometers per liter"`
+	FuelHighwayKML    *float64 `json:"fuel_highway_kml" description:"Kilometers per liter"`
+	FuelCombinedKML   *float64 `json:"fuel_combined_kml" description:"Kilometers per liter"`
+	FuelTankLiters    *int     `json:"fuel_tank_liters"`
+	LengthMM          *int     `json:"length_mm"`
+	WidthMM           *int     `json:"width_mm"`
+	HeightMM          *int     `json:"height_mm"`
+	WheelbaseMM       *int     `json:"wheelbase_mm"`
+	CargoLiters       *int     `json:"cargo_liters"`
+	CargoMaxLiters    *int     `json:"cargo_max_liters"`
+	CurbWeightKG      *int     `json:"curb_weight_kg"`
+	TireSize          *string  `json:"tire_size"`
+	SpareTire         *string  `json:"spare_tire"`
+	Segment           *string  `json:"segment" enum:"city,compact,midsize,fullsize,suv,pickup,van,sports"`
+}
+
+// vehicleSpecs copies the answer into a VehicleSpecs by JSON name.
+func (a *specsAnswer) vehicleSpecs() (*diveinspect.VehicleSpecs, error) {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	var specs diveinspect.VehicleSpecs
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return nil, err
+	}
+	return &specs, nil
+}
+
+func (s *LLMSpecsSource) Name() string { return "llm" }
+
+// FetchSpecs samples the model several times, merges the answers and runs
+// them through the plausibility guardrails. Implausible fields come back nil.
+func (s *LLMSpecsSource) FetchSpecs(ctx context.Context, vehicle *diveinspect.Vehicle) (*diveinspect.VehicleSpecs, error) {
+	version := ""
+	if vehicle.Version != nil {
+		version = *vehicle.Version
+	}
+	trim := ""
+	if vehicle.Trim != nil {
+		trim = *vehicle.Trim
+	}
+
+	prompt := fmt.Sprintf(`You are a vehicle specifications expert. Given the following vehicle identification, provide the complete factory specifications.
+
+Vehicle: %s %s %s %s %d
+
+Use null for any value you don't know.`, vehicle.Brand, vehicle.Model, version, trim, vehicle.Year)
+
+	samples := s.sampleSpecs(ctx, prompt)
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("all %d specs samples failed", specsSamples)
+	}
+
+	specs, agreement := mergeSpecSamples(samples)
+	validation := validateSpecs(specs, len(samples), agreement)
+
+	specs.ID = ""
+	specs.VehicleID = vehicle.ID
+	specs.SpecsConfidence = &validation.Confidence
+	specs.Validation = validation
+
+	if len(validation.Issues) > 0 {
+		logx.Warnf("Specs guardrails nulled %d fields for vehicle %s (confidence %.2f)",
+			len(validation.Issues), vehicle.ID, validation.Confidence)
+	}
+	return specs, nil
+}
+
+// sampleSpecs asks for the specs several times in parallel so the answers can
+// be cross-checked. The samples are identical requests, so they bypass the
+// response cache; cached copies of one answer would always agree. Failed
+// samples are dropped.
+func (s *LLMSpecsSource) sampleSpecs(ctx context.Context, prompt string) []*diveinspect.VehicleSpecs {
+	results := make([]*diveinspect.VehicleSpecs, specsSamples)
+	var wg sync.WaitGroup
+	for i := 0; i < specsSamples; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			answer, _, err := llm.ChatStructured[specsAnswer](ctx, s.llmClient, []llm.Message{
+				llm.NewSystemMessage("You are a precise vehicle specifications database. Return the factory specs for the given vehicle. Be accurate and use real data from manufacturer catalogs."),
+				llm.NewUserMessage(prompt),
+			}, llm.WithTemperature(0.4), llm.WithoutCache())
+			if err != nil {
+				logx.Warnf("Specs sample %d failed: %v", i+1, err)
+				return
+			}
+
+			specs, err := answer.vehicleSpecs()
+			if err != nil {
+				logx.Warnf("Specs sample %d unparseable: %v", i+1, err)
+				return
+			}
+			results[i] = specs
+		}(i)
+	}
+	wg.Wait()
+
+	samples := make([]*diveinspect.VehicleSpecs, 0, specsSamples)
+	for _, r := range results {
+		if r != nil {
+			samples = append(samples, r)
+		}
+	}
+	return samples
+}
+
+// ============================================================================
+// Field helpers
+// ============================================================================
+
+// specMetaFields are VehicleSpecs fields that describe the record rather than
+// the vehicle, so sources never fill them.
+var specMetaFields = map[string]bool{
+	"id": true, "vehicle_id": true, "specs_source": true, "specs_confidence": true,
+	"enriched_at": true, "validation": true, "review_status": true, "provenance": true,
+}
+
+// missingSpecFields returns the JSON names of spec fields that are still nil.
+func missingSpecFields(specs *diveinspect.VehicleSpecs) []string {
+	v := reflect.ValueOf(specs).Elem()
+	t := v.Type()
+	var missing []string
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if specMetaFields[name] || v.Field(i).Kind() != reflect.Ptr {
+			continue
+		}
+		if v.Field(i).IsNil() {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// keepManualSpecs copies the fields current records as a person's corrections
+// into dst and returns their names. current may be nil.
+func keepManualSpecs(dst, current *diveinspect.VehicleSpecs) []string {
+	if current == nil {
+		return nil
+	}
+	var manual []string
+	for field, source := range current.Provenance {
+		if source == specsManualSource {
+			manual = append(manual, field)
+		}
+	}
+	return fillSpecFields(dst, current, manual)
+}
+
+// changedSpecFields returns the JSON names of spec fields whose values differ
+// between a and b.
+func changedSpecFields(a, b *diveinspect.VehicleSpecs) []string {
+	av := reflect.ValueOf(a).Elem()
+	bv := reflect.ValueOf(b).Elem()
+	t := av.Type()
+	var changed []string
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if specMetaFields[name] || av.Field(i).Kind() != reflect.Ptr {
+			continue
+		}
+		if !reflect.DeepEqual(av.Field(i).Interface(), bv.Field(i).Interface()) {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// fillSpecFields copies the listed fields from src into dst where src has a
+// value, and returns the ones it filled.
+func fillSpecFields(dst, src *diveinspect.VehicleSpecs, fields []string) []string {
+	want := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		want[f] = true
+	}
+
+	dv := reflect.ValueOf(dst).Elem()
+	sv := reflect.ValueOf(src).Elem()
+	t := dv.Type()
+	var filled []string
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if !want[name] || sv.Field(i).IsNil() {
+			continue
+		}
+		dv.Field(i).Set(sv.Field(i))
+		filled = append(filled, name)
+	}
+	return filled
+}
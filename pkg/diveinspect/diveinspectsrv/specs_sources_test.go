@@ -0,0 +1,36 @@
+package diveinspectsrv
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Abraxas-365/divi/pkg/ai/aicache"
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+	"github.com/Abraxas-365/divi/pkg/ai/providers/aifake"
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+)
+
+func TestLLMSpecsSourceSamplesPastTheCache(t *testing.T) {
+	ctx := context.Background()
+	var answers []aifake.Reply
+	for _, hp := range []string{"150", "180", "210", "140", "175", "220"} {
+		answers = append(answers, aifake.Text(`{"power_hp": `+hp+`}`))
+	}
+	model := aifake.NewFakeProvider(aifake.WithReply("Vehicle: Toyota RAV4", answers...))
+	source := NewLLMSpecsSource(llm.NewClient(aicache.NewCachedLLM(model, aicache.NewMemoryStore(100))))
+	vehicle := &diveinspect.Vehicle{ID: "v1", Brand: "Toyota", Model: "RAV4", Year: 2022}
+
+	// The second run asks the same questions the first one cached
+	for run := 1; run <= 2; run++ {
+		specs, err := source.FetchSpecs(ctx, vehicle)
+		if err != nil {
+			t.Fatalf("run %d: FetchSpecs: %v", run, err)
+		}
+		if agreement := specs.Validation.Agreement; agreement == nil || *agreement != 0 {
+			t.Fatalf("run %d: samples agree, want the distinct answers to disagree", run)
+		}
+	}
+	if n := len(model.Requests()); n != 2*specsSamples {
+		t.Fatalf("model asked %d times, want every sample to reach it", n)
+	}
+}
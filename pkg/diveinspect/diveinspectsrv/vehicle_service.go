@@ -2,9 +2,13 @@ package diveinspectsrv
 
 import (
 	"context"
+	"encoding/json"
 
+	"github.com/Abraxas-365/divi/pkg/audit"
 	"github.com/Abraxas-365/divi/pkg/diveinspect"
 	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/Abraxas-365/divi/pkg/kernel"
+	"github.com/Abraxas-365/divi/pkg/logx"
 )
 
 type VehicleService struct {
@@ -15,6 +19,11 @@ type VehicleService struct {
 	inspectionRepo diveinspect.InspectionRepository
 	findingRepo   diveinspect.InspectionFindingRepository
 	photoRepo     diveinspect.InspectionPhotoRepository
+	indexer       diveinspect.VehicleIndexer
+	stepRepo      diveinspect.EnrichmentStepRepository
+	txManager     diveinspect.TxManager
+	events        *EventPublisher
+	auditLog      audit.Log
 }
 
 func NewVehicleService(
@@ -25,6 +34,11 @@ func NewVehicleService(
 	inspectionRepo diveinspect.InspectionRepository,
 	findingRepo diveinspect.InspectionFindingRepository,
 	photoRepo diveinspect.InspectionPhotoRepository,
+	indexer diveinspect.VehicleIndexer,
+	stepRepo diveinspect.EnrichmentStepRepository,
+	txManager diveinspect.TxManager,
+	events *EventPublisher,
+	auditLog audit.Log,
 ) *VehicleService {
 	return &VehicleService{
 		vehicleRepo:    vehicleRepo,
@@ -34,20 +48,41 @@ func NewVehicleService(
 		inspectionRepo: inspectionRepo,
 		findingRepo:    findingRepo,
 		photoRepo:      photoRepo,
+		indexer:        indexer,
+		stepRepo:       stepRepo,
+		txManager:      txManager,
+		events:         events,
+		auditLog:       auditLog,
 	}
 }
 
 func (s *VehicleService) Create(ctx context.Context, v *diveinspect.Vehicle) error {
+	if err := validateVehicle(v); err != nil {
+		return err
+	}
+	if v.Status == "" {
+		v.Status = diveinspect.VehicleStatusDraft
+	}
+	return s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.vehicleRepo.Create(ctx, v); err != nil {
+			return err
+		}
+		if err := s.auditLog.RecordChange(ctx, vehicleChange(v, auditVehicle, v.ID, audit.ActionCreate, nil, v)); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, v.TenantID, diveinspect.EventVehicleCreated, v.ID, v)
+	})
+}
+
+// validateVehicle holds the creation rules shared by the API and bulk import.
+func validateVehicle(v *diveinspect.Vehicle) error {
 	if v.Brand == "" || v.Model == "" {
 		return errx.Validation("Brand and model are required")
 	}
 	if v.Year < 1900 || v.Year > 2100 {
 		return errx.Validation("Invalid vehicle year")
 	}
-	if v.Status == "" {
-		v.Status = diveinspect.VehicleStatusDraft
-	}
-	return s.vehicleRepo.Create(ctx, v)
+	return nil
 }
 
 func (s *VehicleService) GetByID(ctx context.Context, id string) (*diveinspect.Vehicle, error) {
@@ -55,11 +90,111 @@ func (s *VehicleService) GetByID(ctx context.Context, id string) (*diveinspect.V
 }
 
 func (s *VehicleService) Update(ctx context.Context, v *diveinspect.Vehicle) error {
-	return s.vehicleRepo.Update(ctx, v)
+	before, err := s.vehicleRepo.GetByID(ctx, v.ID)
+	if err != nil {
+		return err
+	}
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		return s.update(ctx, before, v)
+	})
+	if err != nil {
+		return err
+	}
+	s.syncIndex(ctx, v.ID)
+	return nil
+}
+
+// vehicleReadOnly are the vehicle members a merge patch may not touch. The
+// status and deleted_at change only through Publish, Delete and Restore, which
+// run the review gate and publish their events.
+var vehicleReadOnly = []string{"id", "tenant_id", "status", "revision", "created_at", "updated_at", "deleted_at"}
+
+// Patch applies a JSON Merge Patch to the vehicle. ifMatch is the revision
+// the client last read, if it sent one.
+func (s *VehicleService) Patch(ctx context.Context, id string, patch []byte, ifMatch *int) (*diveinspect.Vehicle, error) {
+	var patched *diveinspect.Vehicle
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		current, err := s.vehicleRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkRevision(ifMatch, current.Revision); err != nil {
+			return err
+		}
+		patched, err = applyMergePatch(current, patch, vehicleReadOnly...)
+		if err != nil {
+			return err
+		}
+		if err := validateVehicle(patched); err != nil {
+			return err
+		}
+		if patched.MileageKM < 0 {
+			return errx.Validation("Mileage must not be negative")
+		}
+		return s.update(ctx, current, patched)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.syncIndex(ctx, id)
+	return patched, nil
+}
+
+// update saves v over before, with its audit entry and event. It runs inside
+// the caller's transaction.
+func (s *VehicleService) update(ctx context.Context, before, v *diveinspect.Vehicle) error {
+	if err := s.vehicleRepo.Update(ctx, v); err != nil {
+		return err
+	}
+	if err := s.auditLog.RecordChange(ctx, vehicleChange(before, auditVehicle, v.ID, audit.ActionUpdate, before, v)); err != nil {
+		return err
+	}
+	return s.events.Publish(ctx, v.TenantID, diveinspect.EventVehicleUpdated, v.ID, v)
 }
 
+// Delete soft-deletes the vehicle and drops it from the search index. The
+// RetentionService purges its rows and files once the retention period ends.
 func (s *VehicleService) Delete(ctx context.Context, id string) error {
-	return s.vehicleRepo.Delete(ctx, id)
+	vehicle, err := s.vehicleRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.vehicleRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		if err := s.auditLog.RecordChange(ctx, vehicleChange(vehicle, auditVehicle, id, audit.ActionDelete, vehicle, nil)); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, vehicle.TenantID, diveinspect.EventVehicleDeleted, id, vehicle)
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.indexer.RemoveVehicle(ctx, id); err != nil {
+		logx.Warnf("Failed to remove vehicle %s from search index: %v", id, err)
+	}
+	return nil
+}
+
+// Restore brings back a soft-deleted vehicle with everything it owned.
+func (s *VehicleService) Restore(ctx context.Context, id string) (*diveinspect.Vehicle, error) {
+	var vehicle *diveinspect.Vehicle
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if vehicle, err = s.vehicleRepo.Restore(ctx, id); err != nil {
+			return err
+		}
+		if err := s.auditLog.RecordChange(ctx, vehicleChange(vehicle, auditVehicle, id, audit.ActionRestore, nil, vehicle)); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, vehicle.TenantID, diveinspect.EventVehicleRestored, id, vehicle)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.syncIndex(ctx, id)
+	return vehicle, nil
 }
 
 func (s *VehicleService) List(ctx context.Context, page, pageSize int) ([]diveinspect.Vehicle, int, error) {
@@ -72,8 +207,96 @@ func (s *VehicleService) List(ctx context.Context, page, pageSize int) ([]divein
 	return s.vehicleRepo.List(ctx, page, pageSize)
 }
 
-func (s *VehicleService) UpdateSpecs(ctx context.Context, specs *diveinspect.VehicleSpecs) error {
-	return s.specsRepo.Upsert(ctx, specs)
+// Query lists vehicles matching a typed filter/sort spec.
+func (s *VehicleService) Query(ctx context.Context, q diveinspect.VehicleQuery) (*diveinspect.VehicleQueryPage, error) {
+	if q.Page < 1 || q.Cursor != "" {
+		q.Page = 1
+	}
+	if q.PageSize < 1 || q.PageSize > 100 {
+		q.PageSize = 20
+	}
+	if q.YearMin != nil && q.YearMax != nil && *q.YearMin > *q.YearMax {
+		return nil, errx.Validation("year_min must not exceed year_max")
+	}
+	if q.MileageMin != nil && q.MileageMax != nil && *q.MileageMin > *q.MileageMax {
+		return nil, errx.Validation("mileage_min must not exceed mileage_max")
+	}
+	if q.PriceMin != nil && q.PriceMax != nil && *q.PriceMin > *q.PriceMax {
+		return nil, errx.Validation("price_min must not exceed price_max")
+	}
+	return s.vehicleRepo.Query(ctx, q)
+}
+
+// Facets returns per-dimension counts for the vehicles matching q.
+func (s *VehicleService) Facets(ctx context.Context, q diveinspect.VehicleQuery) (*diveinspect.VehicleFacets, error) {
+	return s.vehicleRepo.Facets(ctx, q)
+}
+
+// specsReadOnly are the specs members a merge patch may not touch: identity
+// and everything enrichment and the guardrails derive.
+var specsReadOnly = []string{
+	"id", "vehicle_id", "specs_source", "specs_confidence", "enriched_at",
+	"segment", "validation", "review_status", "provenance", "revision",
+}
+
+// specsManualSource is the provenance of fields a person edited.
+const specsManualSource = "manual"
+
+// PatchSpecs applies a JSON Merge Patch to the vehicle's specs, creating
+// them if the vehicle has none. A person wrote the patched fields, so they
+// are recorded as manual and the specs count as reviewed. ifMatch is the
+// revision the client last read, if it sent one.
+func (s *VehicleService) PatchSpecs(ctx context.Context, vehicleID string, patch []byte, ifMatch *int) (*diveinspect.VehicleSpecs, error) {
+	vehicle, err := s.vehicleRepo.GetByID(ctx, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	var patched *diveinspect.VehicleSpecs
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		action := audit.ActionUpdate
+		current, err := s.specsRepo.GetByVehicleID(ctx, vehicleID)
+		if err != nil {
+			action, current = audit.ActionCreate, &diveinspect.VehicleSpecs{VehicleID: vehicleID}
+		}
+		if err := checkRevision(ifMatch, current.Revision); err != nil {
+			return err
+		}
+		patched, err = applyMergePatch(current, patch, specsReadOnly...)
+		if err != nil {
+			return err
+		}
+
+		var fields map[string]json.RawMessage
+		_ = json.Unmarshal(patch, &fields)
+		provenance := diveinspect.SpecsProvenance{}
+		for k, v := range current.Provenance {
+			provenance[k] = v
+		}
+		for field, value := range fields {
+			if string(value) == "null" {
+				delete(provenance, field)
+			} else {
+				provenance[field] = specsManualSource
+			}
+		}
+		patched.Provenance = provenance
+		patched.ReviewStatus = diveinspect.SpecsApproved
+
+		if err := s.specsRepo.Upsert(ctx, patched); err != nil {
+			return err
+		}
+		var before any
+		if action == audit.ActionUpdate {
+			before = current
+		}
+		return s.auditLog.RecordChange(ctx, vehicleChange(vehicle, auditSpecs, vehicleID, action, before, patched))
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.syncIndex(ctx, vehicleID)
+	return patched, nil
 }
 
 func (s *VehicleService) GetPreview(ctx context.Context, vehicleID string) (*diveinspect.VehiclePreview, error) {
@@ -112,17 +335,106 @@ func (s *VehicleService) GetPreview(ctx context.Context, vehicleID string) (*div
 		}
 	}
 
+	steps, err := s.stepRepo.ListByVehicleID(ctx, vehicleID)
+	if err == nil {
+		preview.Enrichment = steps
+	}
+
 	return preview, nil
 }
 
+// ApproveSpecs marks flagged specs as checked by a person and releases the
+// vehicle from review back to draft.
+func (s *VehicleService) ApproveSpecs(ctx context.Context, vehicleID string) (*diveinspect.VehicleSpecs, error) {
+	vehicle, err := s.vehicleRepo.GetByID(ctx, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	specs, err := s.specsRepo.GetByVehicleID(ctx, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	specsBefore, vehicleBefore := *specs, *vehicle
+	specs.ReviewStatus = diveinspect.SpecsApproved
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.specsRepo.Upsert(ctx, specs); err != nil {
+			return err
+		}
+		if err := s.auditLog.RecordChange(ctx, vehicleChange(vehicle, auditSpecs, vehicleID, audit.ActionUpdate, &specsBefore, specs)); err != nil {
+			return err
+		}
+		if vehicle.Status != diveinspect.VehicleStatusReview {
+			return nil
+		}
+		vehicle.Status = diveinspect.VehicleStatusDraft
+		if err := s.vehicleRepo.Update(ctx, vehicle); err != nil {
+			return err
+		}
+		if err := s.auditLog.RecordChange(ctx, vehicleChange(vehicle, auditVehicle, vehicle.ID, audit.ActionUpdate, &vehicleBefore, vehicle)); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, vehicle.TenantID, diveinspect.EventVehicleUpdated, vehicle.ID, vehicle)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return specs, nil
+}
+
 func (s *VehicleService) Publish(ctx context.Context, vehicleID string) (*diveinspect.Vehicle, error) {
 	vehicle, err := s.vehicleRepo.GetByID(ctx, vehicleID)
 	if err != nil {
 		return nil, err
 	}
+	if specs, err := s.specsRepo.GetByVehicleID(ctx, vehicleID); err == nil && specs.ReviewStatus == diveinspect.SpecsNeedsReview {
+		e := errx.Business("Vehicle specs need manual review before publishing")
+		if specs.SpecsConfidence != nil {
+			e = e.WithDetail("specs_confidence", *specs.SpecsConfidence)
+		}
+		return nil, e
+	}
+	before := *vehicle
 	vehicle.Status = diveinspect.VehicleStatusPublished
-	if err := s.vehicleRepo.Update(ctx, vehicle); err != nil {
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.vehicleRepo.Update(ctx, vehicle); err != nil {
+			return err
+		}
+		if err := s.auditLog.RecordChange(ctx, vehicleChange(vehicle, auditVehicle, vehicle.ID, audit.ActionUpdate, &before, vehicle)); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, vehicle.TenantID, diveinspect.EventVehiclePublished, vehicle.ID, vehicle)
+	})
+	if err != nil {
 		return nil, err
 	}
+	s.syncIndex(ctx, vehicle.ID)
 	return vehicle, nil
 }
+
+// History returns the audit trail of the vehicle and everything it owns,
+// newest first.
+func (s *VehicleService) History(ctx context.Context, vehicleID string, page, pageSize int) (*kernel.Paginated[audit.Entry], error) {
+	vehicle, err := s.vehicleRepo.GetByID(ctx, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	return s.auditLog.List(ctx, audit.Query{
+		TenantID:  vehicle.TenantID,
+		SubjectID: vehicleID,
+		Page:      page,
+		PageSize:  pageSize,
+	})
+}
+
+func (s *VehicleService) syncIndex(ctx context.Context, vehicleID string) {
+	syncIndex(ctx, s.indexer, vehicleID)
+}
+
+// syncIndex refreshes the vehicle's search index entry. Indexing is best
+// effort: a failure here must not roll back the state change that caused it.
+func syncIndex(ctx context.Context, indexer diveinspect.VehicleIndexer, vehicleID string) {
+	if err := indexer.IndexVehicle(ctx, vehicleID); err != nil {
+		logx.Warnf("Failed to sync search index for vehicle %s: %v", vehicleID, err)
+	}
+}
@@ -0,0 +1,324 @@
+package diveinspectsrv
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Abraxas-365/divi/pkg/audit"
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+func TestVehicleServiceCreate(t *testing.T) {
+	tests := []struct {
+		name    string
+		vehicle diveinspect.Vehicle
+		wantErr errx.Type
+	}{
+		{
+			name:    "valid vehicle starts as draft",
+			vehicle: diveinspect.Vehicle{Brand: "Toyota", Model: "RAV4", Year: 2022},
+		},
+		{
+			name:    "missing brand",
+			vehicle: diveinspect.Vehicle{Model: "RAV4", Year: 2022},
+			wantErr: errx.TypeValidation,
+		},
+		{
+			name:    "year out of range",
+			vehicle: diveinspect.Vehicle{Brand: "Toyota", Model: "RAV4", Year: 1850},
+			wantErr: errx.TypeValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			v := tt.vehicle
+
+			err := env.vehicleService().Create(context.Background(), &v)
+			if tt.wantErr != "" {
+				assertType(t, err, tt.wantErr)
+				if len(env.eventTypes()) != 0 {
+					t.Fatalf("events = %v, want none", env.eventTypes())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+
+			stored, err := env.vehicles.GetByID(context.Background(), v.ID)
+			if err != nil {
+				t.Fatalf("GetByID: %v", err)
+			}
+			if stored.Status != diveinspect.VehicleStatusDraft || stored.Revision != 1 {
+				t.Fatalf("stored = %s rev %d, want draft rev 1", stored.Status, stored.Revision)
+			}
+			if got := env.eventTypes(); !slices.Equal(got, []diveinspect.EventType{diveinspect.EventVehicleCreated}) {
+				t.Fatalf("events = %v", got)
+			}
+			if got := env.audit.actions(); !slices.Equal(got, []audit.Action{audit.ActionCreate}) {
+				t.Fatalf("audit = %v", got)
+			}
+		})
+	}
+}
+
+func TestVehicleServiceCreateRollsBackWhenAuditFails(t *testing.T) {
+	env := newTestEnv(t)
+	env.audit.failWith = errors.New("audit unavailable")
+
+	v := diveinspect.Vehicle{Brand: "Toyota", Model: "RAV4", Year: 2022}
+	if err := env.vehicleService().Create(context.Background(), &v); err == nil {
+		t.Fatal("Create succeeded, want audit error")
+	}
+
+	vehicles, total, err := env.vehicles.List(context.Background(), 1, 10)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if total != 0 || len(vehicles) != 0 {
+		t.Fatalf("vehicles = %d, want the create rolled back", total)
+	}
+	if len(env.eventTypes()) != 0 {
+		t.Fatalf("events = %v, want none", env.eventTypes())
+	}
+}
+
+func TestVehicleServicePatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		patch   string
+		ifMatch *int
+		wantErr *errx.ErrorCode
+		wantTyp errx.Type
+	}{
+		{name: "updates mileage", patch: `{"mileage_km": 42000}`, ifMatch: ptr(1)},
+		{name: "without If-Match", patch: `{"mileage_km": 42000}`},
+		{name: "stale If-Match", patch: `{"mileage_km": 42000}`, ifMatch: ptr(7), wantErr: diveinspect.ErrPreconditionFailed},
+		{name: "read-only field", patch: `{"revision": 9}`, wantErr: diveinspect.ErrInvalidPatch},
+		{name: "unknown field", patch: `{"colour": "red"}`, wantErr: diveinspect.ErrInvalidPatch},
+		{name: "status", patch: `{"status": "published"}`, wantErr: diveinspect.ErrInvalidPatch},
+		{name: "deleted_at", patch: `{"deleted_at": null}`, wantErr: diveinspect.ErrInvalidPatch},
+		{name: "negative mileage", patch: `{"mileage_km": -1}`, wantTyp: errx.TypeValidation},
+		{name: "missing vehicle", id: "missing", patch: `{"mileage_km": 1}`, wantErr: diveinspect.ErrVehicleNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			v := env.addVehicle(t, diveinspect.Vehicle{MileageKM: 10000})
+			id := v.ID
+			if tt.id != "" {
+				id = tt.id
+			}
+
+			patched, err := env.vehicleService().Patch(context.Background(), id, []byte(tt.patch), tt.ifMatch)
+			switch {
+			case tt.wantErr != nil:
+				assertCode(t, err, tt.wantErr)
+			case tt.wantTyp != "":
+				assertType(t, err, tt.wantTyp)
+			case err != nil:
+				t.Fatalf("Patch: %v", err)
+			}
+
+			stored, err := env.vehicles.GetByID(context.Background(), v.ID)
+			if err != nil {
+				t.Fatalf("GetByID: %v", err)
+			}
+			if tt.wantErr != nil || tt.wantTyp != "" {
+				if stored.MileageKM != 10000 || stored.Revision != 1 {
+					t.Fatalf("stored = %d km rev %d, want it untouched", stored.MileageKM, stored.Revision)
+				}
+				return
+			}
+			if patched.MileageKM != 42000 || stored.MileageKM != 42000 || stored.Revision != 2 {
+				t.Fatalf("stored = %d km rev %d, want 42000 km rev 2", stored.MileageKM, stored.Revision)
+			}
+			if !env.indexer.Indexed(v.ID) {
+				t.Fatal("patched vehicle was not reindexed")
+			}
+		})
+	}
+}
+
+func TestVehicleServiceDeleteAndRestore(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	svc := env.vehicleService()
+	v := env.addVehicle(t, diveinspect.Vehicle{})
+	if err := env.indexer.IndexVehicle(ctx, v.ID); err != nil {
+		t.Fatalf("IndexVehicle: %v", err)
+	}
+
+	if err := svc.Delete(ctx, v.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	_, err := svc.GetByID(ctx, v.ID)
+	assertCode(t, err, diveinspect.ErrVehicleNotFound)
+	if env.indexer.Indexed(v.ID) {
+		t.Fatal("deleted vehicle is still indexed")
+	}
+
+	restored, err := svc.Restore(ctx, v.ID)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if restored.DeletedAt != nil || !env.indexer.Indexed(v.ID) {
+		t.Fatal("restored vehicle should be live and indexed")
+	}
+
+	_, err = svc.Restore(ctx, v.ID)
+	assertCode(t, err, diveinspect.ErrVehicleNotFound)
+
+	want := []diveinspect.EventType{diveinspect.EventVehicleDeleted, diveinspect.EventVehicleRestored}
+	if got := env.eventTypes(); !slices.Equal(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+}
+
+func TestVehicleServicePublish(t *testing.T) {
+	tests := []struct {
+		name    string
+		specs   *diveinspect.VehicleSpecs
+		wantErr errx.Type
+	}{
+		{name: "without specs"},
+		{name: "approved specs", specs: &diveinspect.VehicleSpecs{ReviewStatus: diveinspect.SpecsApproved}},
+		{
+			name:    "specs awaiting review",
+			specs:   &diveinspect.VehicleSpecs{ReviewStatus: diveinspect.SpecsNeedsReview, SpecsConfidence: ptr(0.4)},
+			wantErr: errx.TypeBusiness,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newTestEnv(t)
+			v := env.addVehicle(t, diveinspect.Vehicle{})
+			if tt.specs != nil {
+				tt.specs.VehicleID = v.ID
+				if err := env.specs.Upsert(ctx, tt.specs); err != nil {
+					t.Fatalf("Upsert specs: %v", err)
+				}
+			}
+
+			published, err := env.vehicleService().Publish(ctx, v.ID)
+			if tt.wantErr != "" {
+				assertType(t, err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatalf("Publish: %v", err)
+			}
+			if published.Status != diveinspect.VehicleStatusPublished {
+				t.Fatalf("status = %s, want published", published.Status)
+			}
+		})
+	}
+}
+
+func TestVehicleServiceQuery(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	svc := env.vehicleService()
+	env.addVehicle(t, diveinspect.Vehicle{Brand: "Toyota", Model: "RAV4", Year: 2020})
+	env.addVehicle(t, diveinspect.Vehicle{Brand: "Toyota", Model: "Corolla", Year: 2022})
+	env.addVehicle(t, diveinspect.Vehicle{Brand: "Mazda", Model: "CX-5", Year: 2023})
+
+	tests := []struct {
+		name      string
+		query     diveinspect.VehicleQuery
+		wantModel []string
+		wantErr   bool
+	}{
+		{
+			name:      "brand filter ignores case",
+			query:     diveinspect.VehicleQuery{Brands: []string{"toyota"}, Sort: []diveinspect.VehicleSort{{Field: diveinspect.SortByYear}}},
+			wantModel: []string{"RAV4", "Corolla"},
+		},
+		{
+			name:      "year range sorted descending",
+			query:     diveinspect.VehicleQuery{YearMin: ptr(2021), Sort: []diveinspect.VehicleSort{{Field: diveinspect.SortByYear, Desc: true}}},
+			wantModel: []string{"CX-5", "Corolla"},
+		},
+		{
+			name:    "inverted year range",
+			query:   diveinspect.VehicleQuery{YearMin: ptr(2023), YearMax: ptr(2020)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := svc.Query(ctx, tt.query)
+			if tt.wantErr {
+				assertType(t, err, errx.TypeValidation)
+				return
+			}
+			if err != nil {
+				t.Fatalf("Query: %v", err)
+			}
+			var models []string
+			for _, v := range page.Items {
+				models = append(models, v.Model)
+			}
+			if !slices.Equal(models, tt.wantModel) {
+				t.Fatalf("models = %v, want %v", models, tt.wantModel)
+			}
+		})
+	}
+
+	facets, err := svc.Facets(ctx, diveinspect.VehicleQuery{Brands: []string{"Toyota"}})
+	if err != nil {
+		t.Fatalf("Facets: %v", err)
+	}
+	brands := map[string]int{}
+	for _, f := range facets.Brands {
+		brands[f.Value] = f.Count
+	}
+	if brands["Toyota"] != 2 || brands["Mazda"] != 1 {
+		t.Fatalf("brand facets = %v, want every brand counted", brands)
+	}
+	if len(facets.Models) != 2 {
+		t.Fatalf("model facets = %v, want only Toyota models", facets.Models)
+	}
+}
+
+func TestVehicleServiceQueryPagination(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	svc := env.vehicleService()
+	for _, model := range []string{"RAV4", "Corolla", "Yaris"} {
+		env.addVehicle(t, diveinspect.Vehicle{Brand: "Toyota", Model: model, Year: 2022})
+	}
+	byModel := []diveinspect.VehicleSort{{Field: diveinspect.SortByModel}}
+
+	first, err := svc.Query(ctx, diveinspect.VehicleQuery{Sort: byModel, PageSize: 2})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if first.Page.Total != 3 || first.Page.Pages != 2 {
+		t.Fatalf("offset page = %+v, want the page/total metadata", first.Page)
+	}
+	if !first.HasMore || first.NextCursor == "" {
+		t.Fatalf("offset page should point at the next page")
+	}
+
+	next, err := svc.Query(ctx, diveinspect.VehicleQuery{Sort: byModel, PageSize: 2, Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatalf("Query with cursor: %v", err)
+	}
+	if next.Page.Total != 0 || next.Page.Size != 2 {
+		t.Fatalf("cursor page = %+v, want the page size without a total", next.Page)
+	}
+	if len(next.Items) != 1 || next.Items[0].Model != "Yaris" || next.HasMore || next.NextCursor != "" {
+		t.Fatalf("cursor page = %+v, want only the last vehicle", next)
+	}
+}
@@ -2,57 +2,71 @@ package diveinspectsrv
 
 import (
 	"context"
-	"encoding/base64"
-	"encoding/json"
 	"fmt"
 	"math"
 	"time"
 
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+	"github.com/Abraxas-365/divi/pkg/audit"
 	"github.com/Abraxas-365/divi/pkg/diveinspect"
 	"github.com/Abraxas-365/divi/pkg/errx"
 	"github.com/Abraxas-365/divi/pkg/fsx"
 	"github.com/Abraxas-365/divi/pkg/logx"
-	"github.com/openai/openai-go/v3"
-	"github.com/openai/openai-go/v3/shared"
 )
 
+// VisionService scores inspection photos with a multi-modal model. The
+// client must reach a provider that accepts images.
 type VisionService struct {
-	openaiClient   *openai.Client
+	visionClient   *llm.Client
+	listingSvc     *ListingService
 	fs             fsx.FileSystem
 	inspectionRepo diveinspect.InspectionRepository
 	findingRepo    diveinspect.InspectionFindingRepository
 	photoRepo      diveinspect.InspectionPhotoRepository
+	txManager      diveinspect.TxManager
+	events         *EventPublisher
+	auditLog       audit.Recorder
 }
 
 func NewVisionService(
-	openaiClient *openai.Client,
+	visionClient *llm.Client,
+	listingSvc *ListingService,
 	fs fsx.FileSystem,
 	inspectionRepo diveinspect.InspectionRepository,
 	findingRepo diveinspect.InspectionFindingRepository,
 	photoRepo diveinspect.InspectionPhotoRepository,
+	txManager diveinspect.TxManager,
+	events *EventPublisher,
+	auditLog audit.Recorder,
 ) *VisionService {
 	return &VisionService{
-		openaiClient:   openaiClient,
+		visionClient:   visionClient,
+		listingSvc:     listingSvc,
 		fs:             fs,
 		inspectionRepo: inspectionRepo,
 		findingRepo:    findingRepo,
 		photoRepo:      photoRepo,
+		txManager:      txManager,
+		events:         events,
+		auditLog:       auditLog,
 	}
 }
 
 type photoAnalysisResult struct {
-	Score    int `json:"score"`
+	Score    int `json:"score" description:"1-10 integer, 10 being perfect condition"`
 	Findings []struct {
-		Type        string  `json:"type"`
-		Severity    string  `json:"severity"`
-		Location    string  `json:"location"`
-		Description string  `json:"description"`
-		Confidence  float64 `json:"confidence"`
+		Type        string  `json:"type" enum:"scratch,dent,rust,paint_mismatch,wear,crack,stain,missing_part"`
+		Severity    string  `json:"severity" enum:"minor,moderate,major"`
+		Location    string  `json:"location" description:"Descriptive location within the zone"`
+		Description string  `json:"description" description:"Detailed description of the finding in Spanish"`
+		Confidence  float64 `json:"confidence" description:"0.0-1.0"`
 	} `json:"findings"`
 }
 
 // RunInspection performs the visual AI inspection on all uploaded photos
 func (s *VisionService) RunInspection(ctx context.Context, vehicle *diveinspect.Vehicle, inspectionID string) error {
+	ctx = usageContext(ctx, vehicle, featureVision)
+
 	inspection, err := s.inspectionRepo.GetByID(ctx, inspectionID)
 	if err != nil {
 		return err
@@ -74,6 +88,7 @@ func (s *VisionService) RunInspection(ctx context.Context, vehicle *diveinspect.
 	}
 
 	logx.Infof("Running vision inspection %s with %d photos", inspectionID, len(photos))
+	brand := s.listingSvc.BrandSettings(ctx, vehicle.TenantID)
 
 	var allFindings []diveinspect.InspectionFinding
 	exteriorScores := []int{}
@@ -83,7 +98,7 @@ func (s *VisionService) RunInspection(ctx context.Context, vehicle *diveinspect.
 
 	for _, photo := range photos {
 		zone := mapPhotoZoneToFindingZone(photo.Zone)
-		result, err := s.analyzePhoto(ctx, vehicle, photo, zone)
+		result, err := s.analyzePhoto(ctx, brand, vehicle, photo, zone)
 		if err != nil {
 			logx.Errorf("Failed to analyze photo %s: %v", photo.ID, err)
 			continue
@@ -138,14 +153,8 @@ func (s *VisionService) RunInspection(ctx context.Context, vehicle *diveinspect.
 		overall = 100
 	}
 
-	// Save findings
-	if len(allFindings) > 0 {
-		if err := s.findingRepo.CreateBatch(ctx, allFindings); err != nil {
-			logx.Errorf("Failed to save findings: %v", err)
-		}
-	}
-
-	// Update inspection
+	// Save findings and results together with their events
+	before := *inspection
 	now := time.Now()
 	inspection.ScoreOverall = &overall
 	inspection.ScoreExterior = &scoreExterior
@@ -153,108 +162,87 @@ func (s *VisionService) RunInspection(ctx context.Context, vehicle *diveinspect.
 	inspection.ScoreMechanical = &scoreMechanical
 	inspection.ScoreTires = &scoreTires
 	inspection.FindingsCount = len(allFindings)
-	inspection.PhotosCount = len(photos)
 	inspection.Status = diveinspect.InspectionCompleted
 	inspection.InspectedAt = &now
 
-	if err := s.inspectionRepo.Update(ctx, inspection); err != nil {
-		return errx.Wrap(err, "Failed to update inspection results", errx.TypeInternal)
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.findingRepo.CreateBatch(ctx, allFindings); err != nil {
+			return errx.Wrap(err, "Failed to save findings", errx.TypeInternal)
+		}
+		if err := s.inspectionRepo.Update(ctx, inspection); err != nil {
+			return errx.Wrap(err, "Failed to update inspection results", errx.TypeInternal)
+		}
+		for i := range allFindings {
+			if err := s.auditLog.RecordChange(ctx, vehicleChange(vehicle, auditFinding, allFindings[i].ID, audit.ActionCreate, nil, allFindings[i])); err != nil {
+				return err
+			}
+			if err := s.events.Publish(ctx, vehicle.TenantID, diveinspect.EventFindingCreated, allFindings[i].ID, allFindings[i]); err != nil {
+				return err
+			}
+		}
+		if err := s.auditLog.RecordChange(ctx, vehicleChange(vehicle, auditInspection, inspection.ID, audit.ActionUpdate, &before, inspection)); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, vehicle.TenantID, diveinspect.EventInspectionCompleted, inspection.ID, inspection)
+	})
+	if err != nil {
+		return err
 	}
 
 	logx.Infof("Inspection %s completed: score=%d, findings=%d", inspectionID, overall, len(allFindings))
 	return nil
 }
 
-func (s *VisionService) analyzePhoto(ctx context.Context, vehicle *diveinspect.Vehicle, photo diveinspect.InspectionPhoto, zone diveinspect.FindingZone) (*photoAnalysisResult, error) {
+func (s *VisionService) analyzePhoto(ctx context.Context, brand *diveinspect.BrandSettings, vehicle *diveinspect.Vehicle, photo diveinspect.InspectionPhoto, zone diveinspect.FindingZone) (*photoAnalysisResult, error) {
 	// Read the photo from filesystem
 	photoData, err := s.fs.ReadFile(ctx, photo.PhotoURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read photo: %w", err)
 	}
 
-	base64Image := base64.StdEncoding.EncodeToString(photoData)
-	imageURL := fmt.Sprintf("data:image/jpeg;base64,%s", base64Image)
-
 	version := ""
 	if vehicle.Version != nil {
 		version = *vehicle.Version
 	}
 
-	systemPrompt := fmt.Sprintf(`You are a professional vehicle inspector for Divemotor, the leading automotive dealer in Peru.
+	systemPrompt := fmt.Sprintf(`%s
 
 Analyze this photo of the %s zone of a %s %s %s %d.
 
-Evaluate and return JSON:
-{
-  "score": <1-10 integer, 10 being perfect condition>,
-  "findings": [
-    {
-      "type": "scratch|dent|rust|paint_mismatch|wear|crack|stain|missing_part",
-      "severity": "minor|moderate|major",
-      "location": "descriptive location within the zone",
-      "description": "detailed description of the finding in Spanish",
-      "confidence": <0.0-1.0>
-    }
-  ]
-}
-
 Rules:
 - Be precise but do not invent damage you cannot clearly see
 - If the zone looks perfect, return score 10 with empty findings array
 - Score 8-10: Excellent/Like new
 - Score 6-7: Good with minor cosmetic issues
 - Score 4-5: Fair with visible wear
-- Score 1-3: Poor with significant damage
-- Only return the JSON object`, zone, vehicle.Brand, vehicle.Model, version, vehicle.Year)
-
-	messages := []openai.ChatCompletionMessageParamUnion{
-		openai.SystemMessage(systemPrompt),
-		{
-			OfUser: &openai.ChatCompletionUserMessageParam{
-				Content: openai.ChatCompletionUserMessageParamContentUnion{
-					OfArrayOfContentParts: []openai.ChatCompletionContentPartUnionParam{
-						{
-							OfImageURL: &openai.ChatCompletionContentPartImageParam{
-								ImageURL: openai.ChatCompletionContentPartImageImageURLParam{
-									URL:    imageURL,
-									Detail: shared.ImageURLDetailAuto,
-								},
-							},
-						},
-						{
-							OfText: &openai.ChatCompletionContentPartTextParam{
-								Text: "Analyze this vehicle photo and provide your inspection findings as JSON.",
-							},
-						},
-					},
-				},
-			},
-		},
+- Score 1-3: Poor with significant damage`, inspectorIdentity(brand), zone, vehicle.Brand, vehicle.Model, version, vehicle.Year)
+
+	messages := []llm.Message{
+		llm.NewSystemMessage(systemPrompt),
+		llm.NewUserImageMessage("Analyze this vehicle photo and provide your inspection findings.",
+			llm.Image{Data: photoData, MediaType: "image/jpeg"}),
 	}
 
-	completion, err := s.openaiClient.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
-		Model:    "gpt-4o",
-		Messages: messages,
-		ResponseFormat: openai.ChatCompletionNewParamsResponseFormatUnion{
-			OfJSONObject: &shared.ResponseFormatJSONObjectParam{},
-		},
-		MaxTokens:   openai.Int(1024),
-		Temperature: openai.Float(0.1),
-	})
+	// A response that misses the schema is sent back with the errors rather
+	// than failing the photo
+	result, _, err := llm.ChatStructured[photoAnalysisResult](ctx, s.visionClient, messages,
+		llm.WithModel(visionModel),
+		llm.WithMaxTokens(1024),
+		llm.WithTemperature(0.1),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("vision API call failed: %w", err)
 	}
+	return &result, nil
+}
 
-	if len(completion.Choices) == 0 {
-		return nil, fmt.Errorf("no response from vision API")
-	}
-
-	var result photoAnalysisResult
-	if err := json.Unmarshal([]byte(completion.Choices[0].Message.Content), &result); err != nil {
-		return nil, fmt.Errorf("failed to parse vision response: %w", err)
+// inspectorIdentity introduces the inspector the vision prompts speak as,
+// working for the tenant's dealership.
+func inspectorIdentity(brand *diveinspect.BrandSettings) string {
+	if brand.DealerName != "" {
+		return fmt.Sprintf("You are a professional vehicle inspector for %s.", brand.DealerName)
 	}
-
-	return &result, nil
+	return "You are a professional vehicle inspector for a vehicle dealer."
 }
 
 func mapPhotoZoneToFindingZone(pz diveinspect.PhotoZone) diveinspect.FindingZone {
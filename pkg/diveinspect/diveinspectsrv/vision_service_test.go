@@ -0,0 +1,160 @@
+package diveinspectsrv
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/ai/llm"
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/errx"
+)
+
+// newVisionLLM answers each photo with the analysis scripted for the zone
+// named in its prompt, asked on behalf of the test dealer. A zone without an
+// analysis fails the call.
+func newVisionLLM(analyses map[diveinspect.FindingZone]string) *scriptedLLM {
+	var replies []scriptedReply
+	for zone, analysis := range analyses {
+		match := fmt.Sprintf("inspector for %s.\n\nAnalyze this photo of the %s zone", testDealerName, zone)
+		replies = append(replies, scriptedReply{match: match, content: analysis})
+	}
+	return newScriptedLLM(replies...)
+}
+
+func (e *testEnv) visionService(model llm.LLM) *VisionService {
+	client := llm.NewClient(model)
+	return NewVisionService(client, e.listingService(client), e.fs, e.inspections, e.findings, e.photos, e.tx, e.publisher, e.audit)
+}
+
+func TestVisionServiceRunInspection(t *testing.T) {
+	analyses := map[diveinspect.FindingZone]string{
+		diveinspect.ZoneFront: `{"score": 6, "findings": [
+			{"type": "scratch", "severity": "minor", "location": "bumper", "description": "Rayón leve", "confidence": 0.9}
+		]}`,
+		diveinspect.ZoneInteriorFront: `{"score": 8, "findings": []}`,
+		diveinspect.ZoneEngine:        `{"score": 7, "findings": []}`,
+	}
+
+	tests := []struct {
+		name           string
+		zones          []diveinspect.PhotoZone
+		analyses       map[diveinspect.FindingZone]string
+		wantOverall    int
+		wantMechanical int
+		wantFindings   int
+	}{
+		{
+			name:           "scores every zone",
+			zones:          []diveinspect.PhotoZone{diveinspect.PhotoZoneFront, diveinspect.PhotoZoneInteriorDriver, diveinspect.PhotoZoneEngine},
+			analyses:       analyses,
+			wantOverall:    71, // 6*3.5 + 8*3 + 7*2 + 8*1.5
+			wantMechanical: 7,
+			wantFindings:   1,
+		},
+		{
+			name:  "skips a photo the model fails on",
+			zones: []diveinspect.PhotoZone{diveinspect.PhotoZoneFront, diveinspect.PhotoZoneInteriorDriver, diveinspect.PhotoZoneEngine},
+			analyses: map[diveinspect.FindingZone]string{
+				diveinspect.ZoneFront:         analyses[diveinspect.ZoneFront],
+				diveinspect.ZoneInteriorFront: analyses[diveinspect.ZoneInteriorFront],
+			},
+			wantOverall:    77, // mechanical falls back to the age and mileage base of 10
+			wantMechanical: 10,
+			wantFindings:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newTestEnv(t)
+			model := newVisionLLM(tt.analyses)
+			svc := env.inspectionService(env.visionService(model))
+			v := env.addVehicle(t, diveinspect.Vehicle{Brand: "Toyota", Model: "RAV4", Year: time.Now().Year()})
+			inspection := env.addInspection(t, v.ID)
+			for _, zone := range tt.zones {
+				if _, err := svc.UploadPhoto(ctx, inspection.ID, zone, strings.NewReader("jpeg"), "photo.jpg"); err != nil {
+					t.Fatalf("UploadPhoto: %v", err)
+				}
+			}
+
+			if err := svc.RunInspection(ctx, inspection.ID); err != nil {
+				t.Fatalf("RunInspection: %v", err)
+			}
+			if model.images != len(tt.zones) {
+				t.Fatalf("model saw %d images, want one per photo", model.images)
+			}
+
+			view, err := svc.GetByID(ctx, inspection.ID)
+			if err != nil {
+				t.Fatalf("GetByID: %v", err)
+			}
+			got := view.Inspection
+			if got.Status != diveinspect.InspectionCompleted {
+				t.Fatalf("status = %s, want completed", got.Status)
+			}
+			if *got.ScoreOverall != tt.wantOverall || *got.ScoreMechanical != tt.wantMechanical {
+				t.Fatalf("scores = overall %d mechanical %d, want %d and %d",
+					*got.ScoreOverall, *got.ScoreMechanical, tt.wantOverall, tt.wantMechanical)
+			}
+			if got.FindingsCount != tt.wantFindings || len(view.Findings) != tt.wantFindings {
+				t.Fatalf("findings = %d counted, %d stored; want %d", got.FindingsCount, len(view.Findings), tt.wantFindings)
+			}
+			f := view.Findings[0]
+			if f.Zone != diveinspect.ZoneFront || f.Severity != diveinspect.SeverityMinor || *f.Description != "bumper - Rayón leve" {
+				t.Fatalf("finding = %+v", f)
+			}
+		})
+	}
+}
+
+func TestVisionServiceRunInspectionWithoutPhotos(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	svc := env.inspectionService(env.visionService(newVisionLLM(nil)))
+	v := env.addVehicle(t, diveinspect.Vehicle{})
+	inspection := env.addInspection(t, v.ID)
+
+	err := svc.RunInspection(ctx, inspection.ID)
+	assertType(t, err, errx.TypeValidation)
+
+	_, err = svc.GetByID(ctx, "missing")
+	assertCode(t, err, diveinspect.ErrInspectionNotFound)
+}
+
+func TestVisionServiceRetriesOffSchemaAnalysis(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	const corrected = "does not match the required JSON schema"
+	model := newScriptedLLM(
+		scriptedReply{match: corrected, content: `{"score": 5, "findings": [
+			{"type": "dent", "severity": "moderate", "location": "door", "description": "Abolladura", "confidence": 0.8}
+		]}`},
+		scriptedReply{match: "Analyze this photo of the front zone", content: `{"score": "five", "findings": [
+			{"type": "hail", "severity": "moderate", "location": "door", "description": "Abolladura", "confidence": 0.8}
+		]}`},
+	)
+	svc := env.inspectionService(env.visionService(model))
+	v := env.addVehicle(t, diveinspect.Vehicle{Brand: "Toyota", Model: "RAV4", Year: time.Now().Year()})
+	inspection := env.addInspection(t, v.ID)
+	if _, err := svc.UploadPhoto(ctx, inspection.ID, diveinspect.PhotoZoneFront, strings.NewReader("jpeg"), "photo.jpg"); err != nil {
+		t.Fatalf("UploadPhoto: %v", err)
+	}
+
+	if err := svc.RunInspection(ctx, inspection.ID); err != nil {
+		t.Fatalf("RunInspection: %v", err)
+	}
+	if n := model.callCount(corrected); n != 1 {
+		t.Fatalf("corrections asked = %d, want 1", n)
+	}
+	view, err := svc.GetByID(ctx, inspection.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if *view.Inspection.ScoreExterior != 5 || len(view.Findings) != 1 || view.Findings[0].FindingType != diveinspect.FindingDent {
+		t.Fatalf("inspection = %+v, findings = %+v; want the corrected analysis", view.Inspection, view.Findings)
+	}
+}
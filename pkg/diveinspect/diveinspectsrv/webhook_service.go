@@ -0,0 +1,529 @@
+package diveinspectsrv
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Abraxas-365/divi/pkg/diveinspect"
+	"github.com/Abraxas-365/divi/pkg/errx"
+	"github.com/Abraxas-365/divi/pkg/kernel"
+	"github.com/Abraxas-365/divi/pkg/logx"
+)
+
+const (
+	webhookBatchSize     = 50
+	webhookConcurrency   = 10 // deliveries of a batch sent at once
+	webhookTimeout       = 10 * time.Second
+	webhookMaxAttempts   = 8
+	webhookBaseBackoff   = 30 * time.Second
+	webhookMaxBackoff    = 6 * time.Hour
+	webhookResponseLimit = 2048 // bytes of the receiver's response kept in the log
+
+	// webhookLease is how long a claimed batch stays claimed. It covers
+	// sending the whole batch, webhookConcurrency at a time, plus a margin for
+	// the bookkeeping; a shorter lease would let another dispatcher re-claim
+	// deliveries still in flight and send them twice.
+	webhookLease = (webhookBatchSize+webhookConcurrency-1)/webhookConcurrency*webhookTimeout + time.Minute
+)
+
+// Headers sent with every webhook. The signature is
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>" keyed by the secret>".
+const (
+	webhookSignatureHeader = "X-DiveInspect-Signature"
+	webhookEventHeader     = "X-DiveInspect-Event"
+	webhookEventIDHeader   = "X-DiveInspect-Event-ID"
+	webhookDeliveryHeader  = "X-DiveInspect-Delivery"
+)
+
+// WebhookService manages tenants' webhook endpoints and runs the dispatcher
+// that fans outbox events out to deliveries and sends them, retrying failures
+// with exponential backoff until they succeed or are dead-lettered.
+type WebhookService struct {
+	endpointRepo diveinspect.WebhookEndpointRepository
+	deliveryRepo diveinspect.WebhookDeliveryRepository
+	eventRepo    diveinspect.DomainEventRepository
+	txManager    diveinspect.TxManager
+	httpClient   *http.Client
+	interval     time.Duration
+}
+
+func NewWebhookService(
+	endpointRepo diveinspect.WebhookEndpointRepository,
+	deliveryRepo diveinspect.WebhookDeliveryRepository,
+	eventRepo diveinspect.DomainEventRepository,
+	txManager diveinspect.TxManager,
+	interval time.Duration,
+) *WebhookService {
+	return &WebhookService{
+		endpointRepo: endpointRepo,
+		deliveryRepo: deliveryRepo,
+		eventRepo:    eventRepo,
+		txManager:    txManager,
+		httpClient:   newWebhookHTTPClient(),
+		interval:     interval,
+	}
+}
+
+// ============================================================================
+// Endpoints
+// ============================================================================
+
+// CreateEndpoint registers an endpoint with a freshly generated secret.
+func (s *WebhookService) CreateEndpoint(ctx context.Context, e *diveinspect.WebhookEndpoint) error {
+	if err := validateWebhookEndpoint(ctx, e); err != nil {
+		return err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return errx.Wrap(err, "Failed to generate webhook secret", errx.TypeInternal)
+	}
+	e.Secret = secret
+	if err := s.endpointRepo.Create(ctx, e); err != nil {
+		return errx.Wrap(err, "Failed to create webhook endpoint", errx.TypeInternal)
+	}
+	return nil
+}
+
+func (s *WebhookService) ListEndpoints(ctx context.Context, tenantID string) ([]diveinspect.WebhookEndpoint, error) {
+	endpoints, err := s.endpointRepo.ListByTenantID(ctx, tenantID)
+	if err != nil {
+		return nil, errx.Wrap(err, "Failed to list webhook endpoints", errx.TypeInternal)
+	}
+	return endpoints, nil
+}
+
+// GetEndpoint returns the tenant's endpoint. Other tenants' endpoints are
+// reported as not found.
+func (s *WebhookService) GetEndpoint(ctx context.Context, tenantID, id string) (*diveinspect.WebhookEndpoint, error) {
+	e, err := s.endpointRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if e.TenantID != tenantID {
+		return nil, errx.NotFound("Webhook endpoint not found").WithDetail("id", id)
+	}
+	return e, nil
+}
+
+func (s *WebhookService) UpdateEndpoint(ctx context.Context, e *diveinspect.WebhookEndpoint) error {
+	if err := validateWebhookEndpoint(ctx, e); err != nil {
+		return err
+	}
+	return s.endpointRepo.Update(ctx, e)
+}
+
+// RotateSecret replaces the endpoint's signing secret. Deliveries sent from
+// now on are signed with the new one.
+func (s *WebhookService) RotateSecret(ctx context.Context, tenantID, id string) (*diveinspect.WebhookEndpoint, error) {
+	e, err := s.GetEndpoint(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, errx.Wrap(err, "Failed to generate webhook secret", errx.TypeInternal)
+	}
+	e.Secret = secret
+	if err := s.endpointRepo.Update(ctx, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, tenantID, id string) error {
+	if _, err := s.GetEndpoint(ctx, tenantID, id); err != nil {
+		return err
+	}
+	return s.endpointRepo.Delete(ctx, id)
+}
+
+// validateWebhookEndpoint checks the endpoint, including that its host
+// resolves to public addresses only. The dialer checks again on every
+// delivery, since DNS can change after the endpoint is saved.
+func validateWebhookEndpoint(ctx context.Context, e *diveinspect.WebhookEndpoint) error {
+	e.URL = strings.TrimSpace(e.URL)
+	u, err := url.Parse(e.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Hostname() == "" {
+		return errx.Validation("Webhook url must be an absolute http(s) URL").WithDetail("url", e.URL)
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return errx.Validation("Webhook url host could not be resolved").WithDetail("url", e.URL)
+	}
+	for _, addr := range addrs {
+		if !isPublicAddr(addr) {
+			return errx.Validation("Webhook url must point to a public address").
+				WithDetail("url", e.URL).
+				WithDetail("address", addr.String())
+		}
+	}
+	for _, t := range e.EventTypes {
+		if !diveinspect.EventType(t).IsValid() {
+			return errx.Validation("Unknown event type").WithDetail("event_type", t)
+		}
+	}
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// ============================================================================
+// Delivery Log
+// ============================================================================
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, q diveinspect.WebhookDeliveryQuery) (*kernel.Paginated[diveinspect.WebhookDelivery], error) {
+	if q.Status != "" && !q.Status.IsValid() {
+		return nil, errx.Validation("Invalid delivery status").WithDetail("status", q.Status)
+	}
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 || q.PageSize > 100 {
+		q.PageSize = 20
+	}
+	deliveries, total, err := s.deliveryRepo.List(ctx, q)
+	if err != nil {
+		return nil, errx.Wrap(err, "Failed to list webhook deliveries", errx.TypeInternal)
+	}
+	page := kernel.NewPaginated(deliveries, q.Page, q.PageSize, total)
+	return &page, nil
+}
+
+// GetDelivery returns the tenant's delivery. Other tenants' deliveries are
+// reported as not found.
+func (s *WebhookService) GetDelivery(ctx context.Context, tenantID, id string) (*diveinspect.WebhookDelivery, error) {
+	d, err := s.deliveryRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d.TenantID != tenantID {
+		return nil, errx.NotFound("Webhook delivery not found").WithDetail("id", id)
+	}
+	return d, nil
+}
+
+// Redeliver queues the delivery's event to be sent to its endpoint again,
+// e.g. after a dead-lettered delivery once the receiver is fixed. The new
+// delivery starts with a fresh retry budget; the old one stays in the log.
+func (s *WebhookService) Redeliver(ctx context.Context, tenantID, deliveryID string) (*diveinspect.WebhookDelivery, error) {
+	d, err := s.GetDelivery(ctx, tenantID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.GetEndpoint(ctx, tenantID, d.EndpointID); err != nil {
+		return nil, err
+	}
+
+	redelivery := []diveinspect.WebhookDelivery{{
+		TenantID:   d.TenantID,
+		EndpointID: d.EndpointID,
+		EventID:    d.EventID,
+		EventType:  d.EventType,
+		Status:     diveinspect.DeliveryPending,
+	}}
+	if err := s.deliveryRepo.CreateBatch(ctx, redelivery); err != nil {
+		return nil, errx.Wrap(err, "Failed to queue redelivery", errx.TypeInternal)
+	}
+	return &redelivery[0], nil
+}
+
+// ============================================================================
+// Dispatcher
+// ============================================================================
+
+// Start runs the dispatcher until ctx is cancelled.
+func (s *WebhookService) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logx.Info("Webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+			s.dispatch(ctx)
+		}
+	}
+}
+
+func (s *WebhookService) dispatch(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := s.fanOut(ctx)
+		if err != nil {
+			logx.Errorf("Failed to fan out domain events: %v", err)
+			break
+		}
+		if n < webhookBatchSize {
+			break
+		}
+	}
+
+	for ctx.Err() == nil {
+		deliveries, err := s.deliveryRepo.ClaimDue(ctx, webhookBatchSize, webhookLease)
+		if err != nil {
+			logx.Errorf("Failed to claim webhook deliveries: %v", err)
+			return
+		}
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, webhookConcurrency)
+		for i := range deliveries {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(d *diveinspect.WebhookDelivery) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				s.deliver(ctx, d)
+			}(&deliveries[i])
+		}
+		wg.Wait()
+		if len(deliveries) < webhookBatchSize {
+			return
+		}
+	}
+}
+
+// fanOut turns a batch of outbox events into one pending delivery per
+// subscribed endpoint and marks the events dispatched, all in one
+// transaction. It returns how many events it claimed.
+func (s *WebhookService) fanOut(ctx context.Context) (int, error) {
+	var claimed int
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		events, err := s.eventRepo.ClaimUndispatched(ctx, webhookBatchSize)
+		if err != nil {
+			return err
+		}
+		claimed = len(events)
+		if claimed == 0 {
+			return nil
+		}
+
+		endpoints := make(map[string][]diveinspect.WebhookEndpoint)
+		ids := make([]string, 0, len(events))
+		var deliveries []diveinspect.WebhookDelivery
+		for _, e := range events {
+			ids = append(ids, e.ID)
+			if e.TenantID == nil {
+				continue
+			}
+			tenantEndpoints, ok := endpoints[*e.TenantID]
+			if !ok {
+				tenantEndpoints, err = s.endpointRepo.ListByTenantID(ctx, *e.TenantID)
+				if err != nil {
+					return err
+				}
+				endpoints[*e.TenantID] = tenantEndpoints
+			}
+			for _, ep := range tenantEndpoints {
+				if !ep.IsActive || !ep.Subscribes(e.Type) {
+					continue
+				}
+				deliveries = append(deliveries, diveinspect.WebhookDelivery{
+					TenantID:   ep.TenantID,
+					EndpointID: ep.ID,
+					EventID:    e.ID,
+					EventType:  e.Type,
+					Status:     diveinspect.DeliveryPending,
+				})
+			}
+		}
+
+		if err := s.deliveryRepo.CreateBatch(ctx, deliveries); err != nil {
+			return err
+		}
+		return s.eventRepo.MarkDispatched(ctx, ids)
+	})
+	return claimed, err
+}
+
+// deliver makes one attempt at a claimed delivery and records the outcome.
+func (s *WebhookService) deliver(ctx context.Context, d *diveinspect.WebhookDelivery) {
+	now := time.Now()
+	d.Attempts++
+	d.LastAttemptAt = &now
+	d.ResponseStatus = nil
+	d.ResponseBody = nil
+	d.Error = nil
+
+	err := s.send(ctx, d)
+	switch {
+	case err == nil:
+		d.Status = diveinspect.DeliverySucceeded
+		d.NextAttemptAt = nil
+	case d.Attempts >= webhookMaxAttempts:
+		d.Status = diveinspect.DeliveryDead
+		d.NextAttemptAt = nil
+		logx.Warnf("Webhook delivery %s to endpoint %s dead-lettered after %d attempts: %v", d.ID, d.EndpointID, d.Attempts, err)
+	default:
+		d.Status = diveinspect.DeliveryFailed
+		next := now.Add(webhookBackoff(d.Attempts))
+		d.NextAttemptAt = &next
+	}
+	if err != nil {
+		msg := err.Error()
+		d.Error = &msg
+	}
+
+	if err := s.deliveryRepo.Update(ctx, d); err != nil {
+		logx.Errorf("Failed to record webhook delivery %s: %v", d.ID, err)
+	}
+}
+
+// send POSTs the event to the endpoint. Any non-2xx response is an error.
+func (s *WebhookService) send(ctx context.Context, d *diveinspect.WebhookDelivery) error {
+	endpoint, err := s.endpointRepo.GetByID(ctx, d.EndpointID)
+	if err != nil {
+		return fmt.Errorf("endpoint not found")
+	}
+	if !endpoint.IsActive {
+		return fmt.Errorf("endpoint is disabled")
+	}
+	event, err := s.eventRepo.GetByID(ctx, d.EventID)
+	if err != nil {
+		return fmt.Errorf("event not found")
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "DiveInspect-Webhooks/1.0")
+	req.Header.Set(webhookEventHeader, string(event.Type))
+	req.Header.Set(webhookEventIDHeader, event.ID)
+	req.Header.Set(webhookDeliveryHeader, d.ID)
+	req.Header.Set(webhookSignatureHeader, signWebhook(endpoint.Secret, time.Now().Unix(), body))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	status := resp.StatusCode
+	d.ResponseStatus = &status
+	if respBody, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit)); len(respBody) > 0 {
+		text := strings.ToValidUTF8(string(respBody), "")
+		d.ResponseBody = &text
+	}
+	if status < 200 || status > 299 {
+		return fmt.Errorf("endpoint responded with status %d", status)
+	}
+	return nil
+}
+
+// ============================================================================
+// Outbound Requests
+// ============================================================================
+
+// blockedPrefixes are address ranges outside the standard library's
+// loopback/private/link-local checks that still reach internal services.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),        // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),    // carrier-grade NAT, Alibaba Cloud metadata
+	netip.MustParsePrefix("168.63.129.16/32"), // Azure platform endpoint
+	netip.MustParsePrefix("192.0.0.0/24"),     // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),    // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),      // reserved
+	netip.MustParsePrefix("64:ff9b::/96"),     // NAT64, maps onto IPv4
+	netip.MustParsePrefix("2001:db8::/32"),    // documentation
+}
+
+// isPublicAddr reports whether webhooks may be sent to addr. Loopback,
+// private (RFC 1918 and fc00::/7), link-local (which holds the cloud metadata
+// service at 169.254.169.254) and the blockedPrefixes are refused.
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, p := range blockedPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// refuseInternalDial is the dialer's Control hook: it runs after DNS
+// resolution, so it sees the address actually being connected to.
+func refuseInternalDial(network, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("webhook dial to %s refused: %w", address, err)
+	}
+	if !isPublicAddr(ap.Addr()) {
+		return fmt.Errorf("webhook dial to %s refused: address is not public", address)
+	}
+	return nil
+}
+
+// newWebhookHTTPClient returns the client deliveries are sent with. It only
+// connects to public addresses, ignores proxy settings (the check would see
+// the proxy instead of the receiver) and doesn't follow redirects: a 3xx is
+// reported as a failed delivery.
+func newWebhookHTTPClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: refuseInternalDial}
+	return &http.Client{
+		Timeout: webhookTimeout,
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			MaxIdleConnsPerHost:   webhookConcurrency,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// signWebhook returns the signature header value for body sent at ts.
+func signWebhook(secret string, ts int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", ts)
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", ts, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// webhookBackoff is the wait after the given number of failed attempts:
+// 30s, 1m, 2m, ... capped at webhookMaxBackoff.
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, webhookMaxBackoff)
+}
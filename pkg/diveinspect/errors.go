@@ -15,6 +15,10 @@ var (
 	ErrMissingField   = errorRegistry.Register("MISSING_FIELD", errx.TypeValidation, 400, "Required field is missing")
 	ErrInvalidStatus  = errorRegistry.Register("INVALID_STATUS", errx.TypeValidation, 400, "Invalid status value")
 	ErrInvalidYear    = errorRegistry.Register("INVALID_YEAR", errx.TypeValidation, 400, "Invalid vehicle year")
+	ErrInvalidPatch   = errorRegistry.Register("INVALID_PATCH", errx.TypeValidation, 400, "Invalid merge patch")
+
+	ErrRevisionConflict   = errorRegistry.Register("REVISION_CONFLICT", errx.TypeConflict, 409, "Resource was modified by another request")
+	ErrPreconditionFailed = errorRegistry.Register("PRECONDITION_FAILED", errx.TypeConflict, 412, "If-Match does not match the current revision")
 
 	ErrEnrichmentFailed  = errorRegistry.Register("ENRICHMENT_FAILED", errx.TypeExternal, 502, "Vehicle enrichment failed")
 	ErrVisionFailed      = errorRegistry.Register("VISION_FAILED", errx.TypeExternal, 502, "Vision analysis failed")
@@ -23,3 +27,8 @@ var (
 	ErrPhotoUploadFailed = errorRegistry.Register("PHOTO_UPLOAD_FAILED", errx.TypeInternal, 500, "Photo upload failed")
 	ErrDBOperation       = errorRegistry.Register("DB_OPERATION", errx.TypeInternal, 500, "Database operation failed")
 )
+
+// NewError creates an error from one of the codes above.
+func NewError(code *errx.ErrorCode) *errx.Error {
+	return errorRegistry.New(code)
+}
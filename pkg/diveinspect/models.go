@@ -1,8 +1,12 @@
 package diveinspect
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 
+	"github.com/Abraxas-365/divi/pkg/kernel"
 	"github.com/lib/pq"
 )
 
@@ -18,9 +22,19 @@ const (
 	VehicleStatusPublished VehicleStatus = "published"
 )
 
+func (s VehicleStatus) IsValid() bool {
+	switch s {
+	case VehicleStatusDraft, VehicleStatusReview, VehicleStatusPublished:
+		return true
+	}
+	return false
+}
+
 type Vehicle struct {
 	ID            string        `json:"id" db:"id"`
+	TenantID      *string       `json:"tenant_id,omitempty" db:"tenant_id"`
 	Plate         *string       `json:"plate,omitempty" db:"plate"`
+	VIN           *string       `json:"vin,omitempty" db:"vin"`
 	Brand         string        `json:"brand" db:"brand"`
 	Model         string        `json:"model" db:"model"`
 	Version       *string       `json:"version,omitempty" db:"version"`
@@ -33,8 +47,10 @@ type Vehicle struct {
 	Branch        *string       `json:"branch,omitempty" db:"branch"`
 	Origin        *string       `json:"origin,omitempty" db:"origin"`
 	Status        VehicleStatus `json:"status" db:"status"`
+	Revision      int           `json:"revision" db:"revision"`
 	CreatedAt     time.Time     `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time     `json:"updated_at" db:"updated_at"`
+	DeletedAt     *time.Time    `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
 // ============================================================================
@@ -88,6 +104,86 @@ type VehicleSpecs struct {
 	SpecsSource     *string    `json:"specs_source,omitempty" db:"specs_source"`
 	SpecsConfidence *float64   `json:"specs_confidence,omitempty" db:"specs_confidence"`
 	EnrichedAt      *time.Time `json:"enriched_at,omitempty" db:"enriched_at"`
+
+	// Validation
+	Segment      *string           `json:"segment,omitempty" db:"segment"`
+	Validation   *SpecsValidation  `json:"validation,omitempty" db:"validation"`
+	ReviewStatus SpecsReviewStatus `json:"review_status" db:"review_status"`
+	Provenance   SpecsProvenance   `json:"provenance,omitempty" db:"provenance"`
+
+	Revision int `json:"revision" db:"revision"`
+}
+
+// SpecsProvenance maps a spec field (JSON name) to the source that supplied it.
+type SpecsProvenance map[string]string
+
+func (p SpecsProvenance) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	return json.Marshal(p)
+}
+
+func (p *SpecsProvenance) Scan(src any) error { return scanJSON(src, p) }
+
+type SpecsReviewStatus string
+
+const (
+	SpecsApproved    SpecsReviewStatus = "approved"
+	SpecsNeedsReview SpecsReviewStatus = "needs_review"
+)
+
+// SpecIssue records a field that failed a plausibility rule and was nulled.
+type SpecIssue struct {
+	Field  string `json:"field"`
+	Value  any    `json:"value"`
+	Rule   string `json:"rule"`
+	Reason string `json:"reason"`
+}
+
+// SpecsValidation is the guardrail report stored alongside enriched specs.
+type SpecsValidation struct {
+	Confidence    float64     `json:"confidence"`
+	CheckedFields int         `json:"checked_fields"`
+	PassedFields  int         `json:"passed_fields"`
+	Samples       int         `json:"samples"`
+	Agreement     *float64    `json:"agreement,omitempty"`
+	Issues        []SpecIssue `json:"issues"`
+	ValidatedAt   time.Time   `json:"validated_at"`
+}
+
+func (v SpecsValidation) Value() (driver.Value, error) { return json.Marshal(v) }
+
+func (v *SpecsValidation) Scan(src any) error { return scanJSON(src, v) }
+
+// ============================================================================
+// Specs Catalog
+// ============================================================================
+
+// CatalogSpecs is a VehicleSpecs stored as a JSON document in the catalog.
+type CatalogSpecs VehicleSpecs
+
+func (c CatalogSpecs) Value() (driver.Value, error) { return json.Marshal(VehicleSpecs(c)) }
+
+func (c *CatalogSpecs) Scan(src any) error { return scanJSON(src, c) }
+
+// SpecsCatalogEntry holds curated factory specs for one brand/model/version/year.
+// An empty Version is the fallback for any version of that model and year.
+type SpecsCatalogEntry struct {
+	ID        string       `json:"id" db:"id"`
+	Brand     string       `json:"brand" db:"brand"`
+	Model     string       `json:"model" db:"model"`
+	Version   string       `json:"version" db:"version"`
+	Year      int          `json:"year" db:"year"`
+	Specs     CatalogSpecs `json:"specs" db:"specs"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+type SpecsCatalogImportResult struct {
+	Imported int      `json:"imported"`
+	Failed   int      `json:"failed"`
+	Errors   []string `json:"errors,omitempty"`
 }
 
 // ============================================================================
@@ -112,6 +208,14 @@ const (
 	SourceManualInput     EquipmentSource = "manual_input"
 )
 
+type EquipmentVerification string
+
+const (
+	VerificationUnverified      EquipmentVerification = "unverified"
+	VerificationConfirmed       EquipmentVerification = "confirmed"
+	VerificationPossiblyMissing EquipmentVerification = "possibly_missing"
+)
+
 type VehicleEquipment struct {
 	ID                 string            `json:"id" db:"id"`
 	VehicleID          string            `json:"vehicle_id" db:"vehicle_id"`
@@ -121,6 +225,20 @@ type VehicleEquipment struct {
 	IsStandard         bool              `json:"is_standard" db:"is_standard"`
 	IsConfirmed        bool              `json:"is_confirmed" db:"is_confirmed"`
 	Source             EquipmentSource   `json:"source" db:"source"`
+
+	VerificationStatus EquipmentVerification `json:"verification_status" db:"verification_status"`
+	VerificationNote   *string               `json:"verification_note,omitempty" db:"verification_note"`
+	VerifiedAt         *time.Time            `json:"verified_at,omitempty" db:"verified_at"`
+}
+
+// EquipmentVerificationResult summarizes a photo-based verification pass.
+type EquipmentVerificationResult struct {
+	VehicleID       string             `json:"vehicle_id"`
+	PhotosAnalyzed  int                `json:"photos_analyzed"`
+	Confirmed       int                `json:"confirmed"`
+	PossiblyMissing int                `json:"possibly_missing"`
+	ExtrasDetected  int                `json:"extras_detected"`
+	Equipment       []VehicleEquipment `json:"equipment"`
 }
 
 // ============================================================================
@@ -151,6 +269,7 @@ type Inspection struct {
 	Status          InspectionStatus `json:"status" db:"status"`
 	PDFURL          *string          `json:"pdf_url,omitempty" db:"pdf_url"`
 	InspectedAt     *time.Time       `json:"inspected_at,omitempty" db:"inspected_at"`
+	Revision        int              `json:"revision" db:"revision"`
 	CreatedAt       time.Time        `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time        `json:"updated_at" db:"updated_at"`
 }
@@ -174,6 +293,15 @@ const (
 	ZoneTires         FindingZone = "tires"
 )
 
+func (z FindingZone) IsValid() bool {
+	switch z {
+	case ZoneFront, ZoneRear, ZoneLeft, ZoneRight, ZoneRoof, ZoneInteriorFront,
+		ZoneInteriorRear, ZoneEngine, ZoneTrunk, ZoneTires:
+		return true
+	}
+	return false
+}
+
 type FindingType string
 
 const (
@@ -187,6 +315,15 @@ const (
 	FindingMissingPart   FindingType = "missing_part"
 )
 
+func (t FindingType) IsValid() bool {
+	switch t {
+	case FindingScratch, FindingDent, FindingRust, FindingPaintMismatch,
+		FindingWear, FindingCrack, FindingStain, FindingMissingPart:
+		return true
+	}
+	return false
+}
+
 type FindingSeverity string
 
 const (
@@ -195,6 +332,14 @@ const (
 	SeverityMajor    FindingSeverity = "major"
 )
 
+func (s FindingSeverity) IsValid() bool {
+	switch s {
+	case SeverityMinor, SeverityModerate, SeverityMajor:
+		return true
+	}
+	return false
+}
+
 type InspectionFinding struct {
 	ID               string          `json:"id" db:"id"`
 	InspectionID     string          `json:"inspection_id" db:"inspection_id"`
@@ -206,6 +351,7 @@ type InspectionFinding struct {
 	Description      *string         `json:"description,omitempty" db:"description"`
 	AIConfidence     *float64        `json:"ai_confidence,omitempty" db:"ai_confidence"`
 	ConfirmedByHuman bool            `json:"confirmed_by_human" db:"confirmed_by_human"`
+	Revision         int             `json:"revision" db:"revision"`
 }
 
 // ============================================================================
@@ -250,11 +396,171 @@ type GeneratedListing struct {
 	Title          *string        `json:"title,omitempty" db:"title"`
 	DescriptionES  *string        `json:"description_es,omitempty" db:"description_es"`
 	DescriptionEN  *string        `json:"description_en,omitempty" db:"description_en"`
+	DescriptionPT  *string        `json:"description_pt,omitempty" db:"description_pt"`
 	SEOKeywords    pq.StringArray `json:"seo_keywords,omitempty" db:"seo_keywords"`
 	SchemaJSONLD   *string        `json:"schema_json_ld,omitempty" db:"schema_json_ld"`
 	GeneratedAt    time.Time      `json:"generated_at" db:"generated_at"`
 }
 
+// ============================================================================
+// Enrichment Steps
+// ============================================================================
+
+type EnrichmentStep string
+
+const (
+	StepSpecs     EnrichmentStep = "specs"
+	StepEquipment EnrichmentStep = "equipment"
+	StepListing   EnrichmentStep = "listing"
+	StepJSONLD    EnrichmentStep = "json-ld"
+)
+
+// EnrichmentSteps lists every step in the order they run.
+var EnrichmentSteps = []EnrichmentStep{StepSpecs, StepEquipment, StepListing, StepJSONLD}
+
+func (s EnrichmentStep) IsValid() bool {
+	for _, step := range EnrichmentSteps {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+type EnrichmentStepStatus string
+
+const (
+	StepPending   EnrichmentStepStatus = "pending"
+	StepRunning   EnrichmentStepStatus = "running"
+	StepSucceeded EnrichmentStepStatus = "succeeded"
+	StepFailed    EnrichmentStepStatus = "failed"
+)
+
+// VehicleEnrichmentStep is the last known outcome of one enrichment step.
+type VehicleEnrichmentStep struct {
+	VehicleID   string               `json:"vehicle_id" db:"vehicle_id"`
+	Step        EnrichmentStep       `json:"step" db:"step"`
+	Status      EnrichmentStepStatus `json:"status" db:"status"`
+	Error       *string              `json:"error,omitempty" db:"error"`
+	Attempts    int                  `json:"attempts" db:"attempts"`
+	StartedAt   *time.Time           `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt *time.Time           `json:"completed_at,omitempty" db:"completed_at"`
+	UpdatedAt   time.Time            `json:"updated_at" db:"updated_at"`
+}
+
+// ============================================================================
+// Brand Settings & Listing Variants
+// ============================================================================
+
+type ListingChannel string
+
+const (
+	ChannelWebsite     ListingChannel = "website"
+	ChannelMarketplace ListingChannel = "marketplace"
+	ChannelSocial      ListingChannel = "social"
+	ChannelWhatsApp    ListingChannel = "whatsapp"
+)
+
+var ListingChannels = []ListingChannel{ChannelWebsite, ChannelMarketplace, ChannelSocial, ChannelWhatsApp}
+
+type ListingLanguage string
+
+const (
+	LanguageES ListingLanguage = "es"
+	LanguageEN ListingLanguage = "en"
+	LanguagePT ListingLanguage = "pt"
+)
+
+var ListingLanguages = []ListingLanguage{LanguageES, LanguageEN, LanguagePT}
+
+// ChannelStyle controls tone and length of the copy written for a channel.
+// A zero MaxTitleChars means the channel has no title.
+type ChannelStyle struct {
+	Tone          string `json:"tone"`
+	MaxTitleChars int    `json:"max_title_chars"`
+	MaxBodyChars  int    `json:"max_body_chars"`
+	Hashtags      bool   `json:"hashtags"`
+}
+
+// DefaultChannelStyles apply when a tenant hasn't overridden a channel.
+var DefaultChannelStyles = map[ListingChannel]ChannelStyle{
+	ChannelWebsite: {
+		Tone:          "professional and informative, SEO-friendly",
+		MaxTitleChars: 100,
+		MaxBodyChars:  1800,
+	},
+	ChannelMarketplace: {
+		Tone:          "direct and factual, key specs first",
+		MaxTitleChars: 60,
+		MaxBodyChars:  1000,
+	},
+	ChannelSocial: {
+		Tone:         "energetic and engaging, short sentences, at most two emojis",
+		MaxBodyChars: 400,
+		Hashtags:     true,
+	},
+	ChannelWhatsApp: {
+		Tone:         "friendly and personal, like a salesperson writing to a customer",
+		MaxBodyChars: 500,
+	},
+}
+
+type ChannelStyles map[ListingChannel]ChannelStyle
+
+func (c ChannelStyles) Value() (driver.Value, error) {
+	if c == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(c)
+}
+
+func (c *ChannelStyles) Scan(src any) error { return scanJSON(src, c) }
+
+// BrandSettings is the per-tenant branding used in generated copy.
+type BrandSettings struct {
+	TenantID        string         `json:"tenant_id" db:"tenant_id"`
+	DealerName      string         `json:"dealer_name" db:"dealer_name"`
+	WarrantyText    *string        `json:"warranty_text,omitempty" db:"warranty_text"`
+	ForbiddenClaims pq.StringArray `json:"forbidden_claims" db:"forbidden_claims"`
+	ChannelStyles   ChannelStyles  `json:"channel_styles" db:"channel_styles"`
+	CreatedAt       time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// StyleFor returns the tenant's style for a channel, falling back to the
+// defaults for any field the tenant left empty.
+func (b *BrandSettings) StyleFor(channel ListingChannel) ChannelStyle {
+	style := DefaultChannelStyles[channel]
+	if override, ok := b.ChannelStyles[channel]; ok {
+		if override.Tone != "" {
+			style.Tone = override.Tone
+		}
+		if override.MaxTitleChars > 0 {
+			style.MaxTitleChars = override.MaxTitleChars
+		}
+		if override.MaxBodyChars > 0 {
+			style.MaxBodyChars = override.MaxBodyChars
+		}
+		style.Hashtags = style.Hashtags || override.Hashtags
+	}
+	return style
+}
+
+// ListingVariant is one version of the copy for a vehicle on a channel in a
+// language. Exactly one version per (vehicle, channel, language) is active.
+type ListingVariant struct {
+	ID          string          `json:"id" db:"id"`
+	VehicleID   string          `json:"vehicle_id" db:"vehicle_id"`
+	Channel     ListingChannel  `json:"channel" db:"channel"`
+	Language    ListingLanguage `json:"language" db:"language"`
+	Version     int             `json:"version" db:"version"`
+	Title       *string         `json:"title,omitempty" db:"title"`
+	Body        string          `json:"body" db:"body"`
+	Hashtags    pq.StringArray  `json:"hashtags,omitempty" db:"hashtags"`
+	IsActive    bool            `json:"is_active" db:"is_active"`
+	GeneratedAt time.Time       `json:"generated_at" db:"generated_at"`
+}
+
 // ============================================================================
 // Composite Views (for API responses)
 // ============================================================================
@@ -273,9 +579,375 @@ type InspectionFullView struct {
 }
 
 type VehiclePreview struct {
-	Vehicle    Vehicle             `json:"vehicle"`
-	Specs      *VehicleSpecs       `json:"specs,omitempty"`
-	Equipment  []VehicleEquipment  `json:"equipment,omitempty"`
-	Listing    *GeneratedListing   `json:"listing,omitempty"`
-	Inspection *InspectionFullView `json:"inspection,omitempty"`
+	Vehicle    Vehicle                 `json:"vehicle"`
+	Specs      *VehicleSpecs           `json:"specs,omitempty"`
+	Equipment  []VehicleEquipment      `json:"equipment,omitempty"`
+	Listing    *GeneratedListing       `json:"listing,omitempty"`
+	Inspection *InspectionFullView     `json:"inspection,omitempty"`
+	Enrichment []VehicleEnrichmentStep `json:"enrichment,omitempty"`
+}
+
+// ============================================================================
+// Vehicle Query
+// ============================================================================
+
+type VehicleSortField string
+
+const (
+	SortByCreatedAt VehicleSortField = "created_at"
+	SortByUpdatedAt VehicleSortField = "updated_at"
+	SortByPrice     VehicleSortField = "price_usd"
+	SortByYear      VehicleSortField = "year"
+	SortByMileage   VehicleSortField = "mileage_km"
+	SortByBrand     VehicleSortField = "brand"
+	SortByModel     VehicleSortField = "model"
+)
+
+type VehicleSort struct {
+	Field VehicleSortField `json:"field"`
+	Desc  bool             `json:"desc"`
+}
+
+// VehicleQuery is a typed filter/sort/pagination spec for listing vehicles.
+// List filters match any of the given values (case-insensitive for text);
+// ranges are inclusive. When Cursor is set, keyset pagination is used and
+// Page is ignored.
+type VehicleQuery struct {
+	Brands      []string        `json:"brands,omitempty"`
+	Models      []string        `json:"models,omitempty"`
+	Branches    []string        `json:"branches,omitempty"`
+	Statuses    []VehicleStatus `json:"statuses,omitempty"`
+	FuelTypes   []string        `json:"fuel_types,omitempty"`
+	Drivetrains []string        `json:"drivetrains,omitempty"`
+
+	YearMin    *int     `json:"year_min,omitempty"`
+	YearMax    *int     `json:"year_max,omitempty"`
+	MileageMin *int     `json:"mileage_min,omitempty"`
+	MileageMax *int     `json:"mileage_max,omitempty"`
+	PriceMin   *float64 `json:"price_min,omitempty"`
+	PriceMax   *float64 `json:"price_max,omitempty"`
+
+	// IncludeDeleted also returns soft-deleted vehicles
+	IncludeDeleted bool `json:"include_deleted,omitempty"`
+
+	Sort     []VehicleSort `json:"sort,omitempty"`
+	Cursor   string        `json:"cursor,omitempty"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+}
+
+// VehicleQueryPage is one page of a vehicle query. Cursor pages aren't
+// counted, so their pagination carries no total; HasMore and NextCursor say
+// where to continue.
+type VehicleQueryPage struct {
+	kernel.Paginated[Vehicle]
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+type FacetCount struct {
+	Value string `json:"value" db:"value"`
+	Count int    `json:"count" db:"count"`
+}
+
+// VehicleFacets holds per-dimension counts. Each dimension is counted with
+// every filter applied except its own, so sibling values stay selectable.
+type VehicleFacets struct {
+	Brands   []FacetCount `json:"brands"`
+	Models   []FacetCount `json:"models"`
+	Years    []FacetCount `json:"years"`
+	Branches []FacetCount `json:"branches"`
+	Statuses []FacetCount `json:"statuses"`
+}
+
+// ============================================================================
+// Inventory Search
+// ============================================================================
+
+type InventorySearchQuery struct {
+	// TenantID restricts the search to one dealer's inventory
+	TenantID     string   `json:"-"`
+	Query        string   `json:"query"`
+	MinPriceUSD  *float64 `json:"min_price_usd,omitempty"`
+	MaxPriceUSD  *float64 `json:"max_price_usd,omitempty"`
+	MinYear      *int     `json:"min_year,omitempty"`
+	MaxYear      *int     `json:"max_year,omitempty"`
+	MinMileageKM *int     `json:"min_mileage_km,omitempty"`
+	MaxMileageKM *int     `json:"max_mileage_km,omitempty"`
+	FuelType     *string  `json:"fuel_type,omitempty"`
+	Limit        int      `json:"limit"`
+}
+
+type InventorySearchHit struct {
+	Rank    int     `json:"rank"`
+	Vehicle Vehicle `json:"vehicle"`
+	Title   *string `json:"title,omitempty"`
+}
+
+// ============================================================================
+// Vehicle Import
+// ============================================================================
+
+type ImportJobStatus string
+
+const (
+	ImportAwaitingMapping ImportJobStatus = "awaiting_mapping"
+	ImportQueued          ImportJobStatus = "queued"
+	ImportRunning         ImportJobStatus = "running"
+	ImportCompleted       ImportJobStatus = "completed"
+	ImportFailed          ImportJobStatus = "failed"
+)
+
+type ImportFormat string
+
+const (
+	ImportFormatCSV  ImportFormat = "csv"
+	ImportFormatXLSX ImportFormat = "xlsx"
+)
+
+type ImportRowStatus string
+
+const (
+	ImportRowCreated   ImportRowStatus = "created"
+	ImportRowDuplicate ImportRowStatus = "duplicate"
+	ImportRowFailed    ImportRowStatus = "failed"
+)
+
+// ColumnMapping maps a vehicle field (e.g. "brand") to a source column header.
+type ColumnMapping map[string]string
+
+func (m ColumnMapping) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return json.Marshal(m)
+}
+
+func (m *ColumnMapping) Scan(src any) error {
+	return scanJSON(src, m)
+}
+
+type ImportRowResult struct {
+	Row       int             `json:"row"`
+	Status    ImportRowStatus `json:"status"`
+	VehicleID *string         `json:"vehicle_id,omitempty"`
+	Reasons   []string        `json:"reasons,omitempty"`
+}
+
+type ImportRowResults []ImportRowResult
+
+func (r ImportRowResults) Value() (driver.Value, error) {
+	if r == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(r)
+}
+
+func (r *ImportRowResults) Scan(src any) error {
+	return scanJSON(src, r)
+}
+
+type VehicleImportJob struct {
+	ID             string           `json:"id" db:"id"`
+	TenantID       *string          `json:"tenant_id,omitempty" db:"tenant_id"`
+	FileName       string           `json:"file_name" db:"file_name"`
+	FilePath       string           `json:"-" db:"file_path"`
+	Format         ImportFormat     `json:"format" db:"format"`
+	Status         ImportJobStatus  `json:"status" db:"status"`
+	Headers        pq.StringArray   `json:"headers" db:"headers"`
+	Mapping        ColumnMapping    `json:"mapping,omitempty" db:"mapping"`
+	EnrichCreated  bool             `json:"enrich_created" db:"enrich_created"`
+	TotalRows      int              `json:"total_rows" db:"total_rows"`
+	CreatedCount   int              `json:"created_count" db:"created_count"`
+	DuplicateCount int              `json:"duplicate_count" db:"duplicate_count"`
+	FailedCount    int              `json:"failed_count" db:"failed_count"`
+	Results        ImportRowResults `json:"results" db:"results"`
+	Error          *string          `json:"error,omitempty" db:"error"`
+	CreatedAt      time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at" db:"updated_at"`
+	CompletedAt    *time.Time       `json:"completed_at,omitempty" db:"completed_at"`
+	LockedUntil    *time.Time       `json:"-" db:"locked_until"`
+}
+
+// ImportPreview is returned after upload so the caller can confirm the
+// column mapping before the import runs.
+type ImportPreview struct {
+	Job              VehicleImportJob `json:"job"`
+	SampleRows       [][]string       `json:"sample_rows"`
+	SuggestedMapping ColumnMapping    `json:"suggested_mapping"`
+}
+
+// ============================================================================
+// Domain Events & Webhooks
+// ============================================================================
+
+type EventType string
+
+const (
+	EventVehicleCreated           EventType = "vehicle.created"
+	EventVehicleUpdated           EventType = "vehicle.updated"
+	EventVehiclePublished         EventType = "vehicle.published"
+	EventVehicleDeleted           EventType = "vehicle.deleted"
+	EventVehicleRestored          EventType = "vehicle.restored"
+	EventInspectionCreated        EventType = "inspection.created"
+	EventInspectionCompleted      EventType = "inspection.completed"
+	EventFindingCreated           EventType = "finding.created"
+	EventFindingUpdated           EventType = "finding.updated"
+	EventListingGenerated         EventType = "listing.generated"
+	EventListingVariantsGenerated EventType = "listing.variants_generated"
+	EventListingVariantActivated  EventType = "listing.variant_activated"
+)
+
+// EventTypes lists every event a webhook can subscribe to.
+var EventTypes = []EventType{
+	EventVehicleCreated,
+	EventVehicleUpdated,
+	EventVehiclePublished,
+	EventVehicleDeleted,
+	EventVehicleRestored,
+	EventInspectionCreated,
+	EventInspectionCompleted,
+	EventFindingCreated,
+	EventFindingUpdated,
+	EventListingGenerated,
+	EventListingVariantsGenerated,
+	EventListingVariantActivated,
+}
+
+func (t EventType) IsValid() bool {
+	for _, e := range EventTypes {
+		if t == e {
+			return true
+		}
+	}
+	return false
+}
+
+// DomainEvent is a row of the transactional outbox. It is written in the same
+// transaction as the state change it describes and fanned out to webhook
+// deliveries by the dispatcher. Events without a tenant are never delivered.
+type DomainEvent struct {
+	ID           string          `json:"id" db:"id"`
+	TenantID     *string         `json:"tenant_id,omitempty" db:"tenant_id"`
+	Type         EventType       `json:"type" db:"event_type"`
+	SubjectID    string          `json:"subject_id" db:"subject_id"`
+	Payload      EventPayload    `json:"data" db:"payload"`
+	CreatedAt    time.Time       `json:"created_at" db:"created_at"`
+	DispatchedAt *time.Time      `json:"-" db:"dispatched_at"`
+}
+
+// EventPayload is the JSON body of a domain event, stored verbatim.
+type EventPayload json.RawMessage
+
+func (p EventPayload) Value() (driver.Value, error) {
+	if len(p) == 0 {
+		return "{}", nil
+	}
+	return string(p), nil
+}
+
+func (p *EventPayload) Scan(src any) error {
+	switch src := src.(type) {
+	case nil:
+		*p = nil
+	case []byte:
+		*p = append((*p)[:0], src...)
+	case string:
+		*p = EventPayload(src)
+	default:
+		return fmt.Errorf("unsupported type for JSON column: %T", src)
+	}
+	return nil
+}
+
+func (p EventPayload) MarshalJSON() ([]byte, error) {
+	return json.RawMessage(p).MarshalJSON()
+}
+
+// WebhookEndpoint is a tenant's registered receiver. An empty EventTypes
+// subscribes to every event.
+type WebhookEndpoint struct {
+	ID          string         `json:"id" db:"id"`
+	TenantID    string         `json:"tenant_id" db:"tenant_id"`
+	URL         string         `json:"url" db:"url"`
+	Secret      string         `json:"-" db:"secret"`
+	EventTypes  pq.StringArray `json:"event_types" db:"event_types"`
+	Description *string        `json:"description,omitempty" db:"description"`
+	IsActive    bool           `json:"is_active" db:"is_active"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// Subscribes reports whether the endpoint wants events of type t.
+func (e *WebhookEndpoint) Subscribes(t EventType) bool {
+	if len(e.EventTypes) == 0 {
+		return true
+	}
+	for _, et := range e.EventTypes {
+		if EventType(et) == t {
+			return true
+		}
+	}
+	return false
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed" // will be retried
+	DeliveryDead      DeliveryStatus = "dead"   // retries exhausted
+)
+
+func (s DeliveryStatus) IsValid() bool {
+	switch s {
+	case DeliveryPending, DeliverySucceeded, DeliveryFailed, DeliveryDead:
+		return true
+	}
+	return false
+}
+
+// WebhookDelivery is one attempt series of sending an event to an endpoint.
+// Manual redelivery creates a new delivery so the log keeps the history.
+type WebhookDelivery struct {
+	ID             string         `json:"id" db:"id"`
+	TenantID       string         `json:"tenant_id" db:"tenant_id"`
+	EndpointID     string         `json:"endpoint_id" db:"endpoint_id"`
+	EventID        string         `json:"event_id" db:"event_id"`
+	EventType      EventType      `json:"event_type" db:"event_type"`
+	Status         DeliveryStatus `json:"status" db:"status"`
+	Attempts       int            `json:"attempts" db:"attempts"`
+	NextAttemptAt  *time.Time     `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	LastAttemptAt  *time.Time     `json:"last_attempt_at,omitempty" db:"last_attempt_at"`
+	ResponseStatus *int           `json:"response_status,omitempty" db:"response_status"`
+	ResponseBody   *string        `json:"response_body,omitempty" db:"response_body"`
+	Error          *string        `json:"error,omitempty" db:"error"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// WebhookDeliveryQuery filters a tenant's delivery log.
+type WebhookDeliveryQuery struct {
+	TenantID   string
+	EndpointID string
+	Status     DeliveryStatus
+	Page       int
+	PageSize   int
+}
+
+func scanJSON(src any, dest any) error {
+	if src == nil {
+		return nil
+	}
+	var data []byte
+	switch src := src.(type) {
+	case []byte:
+		data = src
+	case string:
+		data = []byte(src)
+	default:
+		return fmt.Errorf("unsupported type for JSON column: %T", src)
+	}
+	return json.Unmarshal(data, dest)
 }
@@ -1,6 +1,9 @@
 package diveinspect
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // ============================================================================
 // Vehicle Repository
@@ -10,9 +13,20 @@ type VehicleRepository interface {
 	Create(ctx context.Context, v *Vehicle) error
 	GetByID(ctx context.Context, id string) (*Vehicle, error)
 	Update(ctx context.Context, v *Vehicle) error
+	// Delete soft-deletes the vehicle; every read except ListDeletedBefore
+	// and Query with IncludeDeleted skips it from then on.
 	Delete(ctx context.Context, id string) error
+	Restore(ctx context.Context, id string) (*Vehicle, error)
+	// HardDelete removes a vehicle soft-deleted before cutoff and, by
+	// cascade, everything it owns. A vehicle restored or deleted again since
+	// is not found.
+	HardDelete(ctx context.Context, id string, cutoff time.Time) error
+	ListDeletedBefore(ctx context.Context, cutoff time.Time, limit int) ([]Vehicle, error)
 	List(ctx context.Context, page, pageSize int) ([]Vehicle, int, error)
 	ListByStatus(ctx context.Context, status VehicleStatus, page, pageSize int) ([]Vehicle, int, error)
+	Query(ctx context.Context, q VehicleQuery) (*VehicleQueryPage, error)
+	Facets(ctx context.Context, q VehicleQuery) (*VehicleFacets, error)
+	FindByPlateOrVIN(ctx context.Context, plate, vin *string) (*Vehicle, error)
 }
 
 // ============================================================================
@@ -26,6 +40,43 @@ type VehicleSpecsRepository interface {
 	Delete(ctx context.Context, vehicleID string) error
 }
 
+// ============================================================================
+// Specs Sources
+// ============================================================================
+
+// SpecsSource looks up factory specs for a vehicle. A source with nothing for
+// the vehicle returns nil, nil. Sources that estimate rather than know their
+// data set SpecsConfidence on the result.
+type SpecsSource interface {
+	Name() string
+	FetchSpecs(ctx context.Context, vehicle *Vehicle) (*VehicleSpecs, error)
+}
+
+type SpecsCatalogRepository interface {
+	UpsertBatch(ctx context.Context, entries []SpecsCatalogEntry) error
+	// FindBest returns the entry for the exact version if there is one, else
+	// the version-less entry for the model and year, else nil.
+	FindBest(ctx context.Context, brand, model string, version *string, year int) (*SpecsCatalogEntry, error)
+}
+
+// ============================================================================
+// Brand Settings & Listing Variant Repositories
+// ============================================================================
+
+type BrandSettingsRepository interface {
+	GetByTenantID(ctx context.Context, tenantID string) (*BrandSettings, error)
+	Upsert(ctx context.Context, b *BrandSettings) error
+}
+
+type ListingVariantRepository interface {
+	// CreateVersions stores each variant as the next version for its channel
+	// and language and makes it the active one.
+	CreateVersions(ctx context.Context, variants []ListingVariant) error
+	ListActive(ctx context.Context, vehicleID string) ([]ListingVariant, error)
+	ListHistory(ctx context.Context, vehicleID string, channel ListingChannel, language ListingLanguage) ([]ListingVariant, error)
+	Activate(ctx context.Context, vehicleID string, channel ListingChannel, language ListingLanguage, version int) (*ListingVariant, error)
+}
+
 // ============================================================================
 // Vehicle Equipment Repository
 // ============================================================================
@@ -35,6 +86,7 @@ type VehicleEquipmentRepository interface {
 	GetByVehicleID(ctx context.Context, vehicleID string) ([]VehicleEquipment, error)
 	GetByVehicleIDAndCategory(ctx context.Context, vehicleID string, category EquipmentCategory) ([]VehicleEquipment, error)
 	DeleteByVehicleID(ctx context.Context, vehicleID string) error
+	UpdateVerificationBatch(ctx context.Context, equipment []VehicleEquipment) error
 }
 
 // ============================================================================
@@ -45,7 +97,14 @@ type InspectionRepository interface {
 	Create(ctx context.Context, i *Inspection) error
 	GetByID(ctx context.Context, id string) (*Inspection, error)
 	GetByVehicleID(ctx context.Context, vehicleID string) (*Inspection, error)
+	ListByVehicleID(ctx context.Context, vehicleID string) ([]Inspection, error)
+	// Update saves i and bumps its revision, failing with a conflict when i
+	// is stale. It leaves photos_count and pdf_url alone; they have their
+	// own atomic writes below.
 	Update(ctx context.Context, i *Inspection) error
+	// IncrementPhotosCount adds one to photos_count and returns the new count.
+	IncrementPhotosCount(ctx context.Context, id string) (int, error)
+	SetPDFURL(ctx context.Context, id, pdfURL string) error
 	Delete(ctx context.Context, id string) error
 }
 
@@ -55,6 +114,7 @@ type InspectionRepository interface {
 
 type InspectionFindingRepository interface {
 	CreateBatch(ctx context.Context, findings []InspectionFinding) error
+	GetByID(ctx context.Context, id string) (*InspectionFinding, error)
 	GetByInspectionID(ctx context.Context, inspectionID string) ([]InspectionFinding, error)
 	Update(ctx context.Context, f *InspectionFinding) error
 	Delete(ctx context.Context, id string) error
@@ -79,3 +139,86 @@ type GeneratedListingRepository interface {
 	GetByVehicleID(ctx context.Context, vehicleID string) (*GeneratedListing, error)
 	Delete(ctx context.Context, vehicleID string) error
 }
+
+// ============================================================================
+// Enrichment Step Repository
+// ============================================================================
+
+type EnrichmentStepRepository interface {
+	// Upsert records the step's state; Attempts is incremented when the step
+	// moves to running.
+	Upsert(ctx context.Context, s *VehicleEnrichmentStep) error
+	ListByVehicleID(ctx context.Context, vehicleID string) ([]VehicleEnrichmentStep, error)
+}
+
+// ============================================================================
+// Transactions
+// ============================================================================
+
+// TxManager runs fn in a single transaction. Repository calls made with the
+// context passed to fn join that transaction.
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// ============================================================================
+// Domain Events & Webhooks
+// ============================================================================
+
+type DomainEventRepository interface {
+	// Create joins the transaction carried by ctx, so the event commits or
+	// rolls back with the state change it describes.
+	Create(ctx context.Context, e *DomainEvent) error
+	GetByID(ctx context.Context, id string) (*DomainEvent, error)
+	// ClaimUndispatched locks up to limit events not yet fanned out, oldest
+	// first, skipping rows another dispatcher holds. Call it within a
+	// transaction and MarkDispatched before committing.
+	ClaimUndispatched(ctx context.Context, limit int) ([]DomainEvent, error)
+	MarkDispatched(ctx context.Context, ids []string) error
+}
+
+type WebhookEndpointRepository interface {
+	Create(ctx context.Context, e *WebhookEndpoint) error
+	GetByID(ctx context.Context, id string) (*WebhookEndpoint, error)
+	ListByTenantID(ctx context.Context, tenantID string) ([]WebhookEndpoint, error)
+	Update(ctx context.Context, e *WebhookEndpoint) error
+	Delete(ctx context.Context, id string) error
+}
+
+type WebhookDeliveryRepository interface {
+	CreateBatch(ctx context.Context, deliveries []WebhookDelivery) error
+	GetByID(ctx context.Context, id string) (*WebhookDelivery, error)
+	// ClaimDue leases up to limit pending or failed deliveries whose next
+	// attempt is due by moving next_attempt_at forward by lease, so other
+	// dispatchers skip them while they are being sent.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]WebhookDelivery, error)
+	Update(ctx context.Context, d *WebhookDelivery) error
+	List(ctx context.Context, q WebhookDeliveryQuery) ([]WebhookDelivery, int, error)
+}
+
+// ============================================================================
+// Vehicle Import Job Repository
+// ============================================================================
+
+type VehicleImportJobRepository interface {
+	Create(ctx context.Context, j *VehicleImportJob) error
+	GetByID(ctx context.Context, id string) (*VehicleImportJob, error)
+	Update(ctx context.Context, j *VehicleImportJob) error
+	// ClaimNext atomically moves the oldest queued job to running, leased to
+	// the caller for lease. A running job whose lease has expired (its worker
+	// died) is claimed again. Returns nil, nil when no job is waiting.
+	ClaimNext(ctx context.Context, lease time.Duration) (*VehicleImportJob, error)
+	// ExtendLease keeps the caller's claim on a running job for another
+	// lease. It fails with not found once the job is no longer running.
+	ExtendLease(ctx context.Context, id string, lease time.Duration) error
+}
+
+// ============================================================================
+// Vehicle Indexer
+// ============================================================================
+
+// VehicleIndexer keeps the semantic inventory index in sync with vehicle state.
+type VehicleIndexer interface {
+	IndexVehicle(ctx context.Context, vehicleID string) error
+	RemoveVehicle(ctx context.Context, vehicleID string) error
+}
@@ -29,6 +29,7 @@ type AuthHandlers struct {
 	stateManager   StateManager
 	invitationRepo invitation.InvitationRepository
 	config         *config.Config
+	audit          AuditService
 }
 
 // NewAuthHandlers crea un nuevo handler de autenticación
@@ -42,6 +43,7 @@ func NewAuthHandlers(
 	stateManager StateManager,
 	invitationRepo invitation.InvitationRepository,
 	config *config.Config,
+	audit AuditService,
 ) *AuthHandlers {
 	return &AuthHandlers{
 		oauthServices:  oauthServices,
@@ -53,6 +55,7 @@ func NewAuthHandlers(
 		stateManager:   stateManager,
 		invitationRepo: invitationRepo,
 		config:         config,
+		audit:          audit,
 	}
 }
 
@@ -304,6 +307,10 @@ func (ah *AuthHandlers) HandleCallback(c *fiber.Ctx) error {
 		Path:     ah.config.Auth.Cookie.Path,
 	})
 
+	if ah.audit != nil {
+		_ = ah.audit.LogLoginAttempt(auditContext(c, tenantEntity.ID), userEntity.ID, true, c.IP())
+	}
+
 	return c.JSON(response)
 }
 
@@ -394,6 +401,10 @@ func (ah *AuthHandlers) RefreshToken(c *fiber.Ctx) error {
 		SameSite: "Lax",
 	})
 
+	if ah.audit != nil {
+		_ = ah.audit.LogTokenRefresh(auditContext(c, tenantEntity.ID), userEntity.ID, c.IP())
+	}
+
 	return c.JSON(fiber.Map{
 		"access_token": accessToken,
 		"token_type":   "Bearer",
@@ -651,6 +662,12 @@ func (ah *AuthHandlers) findOrCreateUser(ctx context.Context, userInfo *OAuthUse
 }
 
 // Helper functions
+// auditContext carries the tenant of an auth event, which happens before
+// there is an AuthContext to read it from.
+func auditContext(c *fiber.Ctx, tenantID kernel.TenantID) context.Context {
+	return context.WithValue(c.Context(), kernel.TenantContextKey, tenantID)
+}
+
 func generateID() string {
 	return uuid.NewString()
 }
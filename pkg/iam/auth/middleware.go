@@ -70,7 +70,7 @@ func (am *TokenMiddleware) Authenticate() fiber.Handler {
 		}
 
 		// Agregar al contexto de Fiber
-		c.Locals("auth", authContext)
+		setAuthContext(c, authContext)
 
 		return c.Next()
 	}
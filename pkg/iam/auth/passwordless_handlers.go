@@ -26,6 +26,7 @@ type PasswordlessAuthHandlers struct {
 	invitationRepo invitation.InvitationRepository
 	otpService     *otpsrv.OTPService
 	config         *config.Config
+	audit          AuditService
 }
 
 func NewPasswordlessAuthHandlers(
@@ -37,6 +38,7 @@ func NewPasswordlessAuthHandlers(
 	invitationRepo invitation.InvitationRepository,
 	otpService *otpsrv.OTPService,
 	config *config.Config,
+	audit AuditService,
 ) *PasswordlessAuthHandlers {
 	return &PasswordlessAuthHandlers{
 		tokenService:   tokenService,
@@ -47,6 +49,7 @@ func NewPasswordlessAuthHandlers(
 		invitationRepo: invitationRepo,
 		otpService:     otpService,
 		config:         config,
+		audit:          audit,
 	}
 }
 
@@ -537,6 +540,9 @@ func (h *PasswordlessAuthHandlers) VerifyLogin(c *fiber.Ctx) error {
 
 	// 3. Check user can login
 	if !userEntity.CanLogin() {
+		if h.audit != nil {
+			_ = h.audit.LogLoginAttempt(auditContext(c, userEntity.TenantID), userEntity.ID, false, c.IP())
+		}
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"error": "Account cannot login. Status: " + string(userEntity.Status),
 		})
@@ -628,6 +634,10 @@ func (h *PasswordlessAuthHandlers) VerifyLogin(c *fiber.Ctx) error {
 		Path:     h.config.Auth.Cookie.Path,
 	})
 
+	if h.audit != nil {
+		_ = h.audit.LogLoginAttempt(auditContext(c, tenantEntity.ID), userEntity.ID, true, c.IP())
+	}
+
 	// 11. Return tokens and user info
 	return c.JSON(TokenResponse{
 		AccessToken:  accessToken,
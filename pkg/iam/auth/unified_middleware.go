@@ -50,9 +50,10 @@ func (am *UnifiedAuthMiddleware) authenticateAPIKey(c *fiber.Ctx, keyString stri
 		TenantID: key.TenantID,
 		Scopes:   key.Scopes,
 		IsAPIKey: true,
+		APIKeyID: key.ID,
 	}
 
-	c.Locals("auth", authContext)
+	setAuthContext(c, authContext)
 	c.Locals("api_key_id", key.ID)
 
 	return c.Next()
@@ -95,7 +96,7 @@ func (am *UnifiedAuthMiddleware) authenticateJWT(c *fiber.Ctx) error {
 		IsAPIKey: false,
 	}
 
-	c.Locals("auth", authContext)
+	setAuthContext(c, authContext)
 	return c.Next()
 }
 
@@ -197,6 +198,14 @@ func extractAPIKey(c *fiber.Ctx) string {
 	return ""
 }
 
+// setAuthContext stores the caller for handlers, and under
+// kernel.AuthContextKey so services reached through c.Context() can read it
+// with kernel.AuthContextFrom.
+func setAuthContext(c *fiber.Ctx, authContext *kernel.AuthContext) {
+	c.Locals("auth", authContext)
+	c.Locals(kernel.AuthContextKey, authContext)
+}
+
 // GetAuthContext helper to extract auth context from Fiber
 func GetAuthContext(c *fiber.Ctx) (*kernel.AuthContext, bool) {
 	authContext, ok := c.Locals("auth").(*kernel.AuthContext)
@@ -39,6 +39,10 @@ type Deps struct {
 	// OTPNotifier is a cross-context dependency injected as an interface so the
 	// IAM module has zero knowledge of the concrete notification implementation.
 	OTPNotifier otp.NotificationService
+
+	// Audit records logins, failed logins and token refreshes; nil disables
+	// auth event logging.
+	Audit auth.AuditService
 }
 
 // ---------------------------------------------------------------------------
@@ -181,6 +185,7 @@ func New(deps Deps) *Container {
 		stateManager,
 		invitationRepo,
 		deps.Cfg,
+		deps.Audit,
 	)
 
 	c.PasswordlessHandlers = auth.NewPasswordlessAuthHandlers(
@@ -192,6 +197,7 @@ func New(deps Deps) *Container {
 		invitationRepo,
 		c.OTPService,
 		deps.Cfg,
+		deps.Audit,
 	)
 
 	// ── API handlers ─────────────────────────────────────────────────────
@@ -1,5 +1,7 @@
 package kernel
 
+import "context"
+
 // ============================================================================
 // Context Types - Tipos para context.Context
 // ============================================================================
@@ -12,6 +14,7 @@ type AuthContext struct {
 	Name     string   `json:"name"`
 	Scopes   []string `json:"scopes"`
 	IsAPIKey bool     `json:"is_api_key"`
+	APIKeyID string   `json:"api_key_id,omitempty"`
 }
 
 // ============================================================================
@@ -92,3 +95,15 @@ const (
 	// RequestIDKey es la clave para almacenar el ID de la petición
 	RequestIDKey ContextKey = "request_id"
 )
+
+// WithAuthContext devuelve una copia de ctx que lleva el AuthContext
+func WithAuthContext(ctx context.Context, ac *AuthContext) context.Context {
+	return context.WithValue(ctx, AuthContextKey, ac)
+}
+
+// AuthContextFrom obtiene el AuthContext de ctx. El contexto de un request
+// de Fiber lo lleva una vez que corre el middleware de autenticación.
+func AuthContextFrom(ctx context.Context) (*AuthContext, bool) {
+	ac, ok := ctx.Value(AuthContextKey).(*AuthContext)
+	return ac, ok && ac != nil
+}